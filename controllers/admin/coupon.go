@@ -0,0 +1,125 @@
+package admin
+
+import (
+	"strconv"
+
+	"nasa-go-admin/inout"
+	"nasa-go-admin/services/app_service"
+
+	"github.com/gin-gonic/gin"
+)
+
+var couponService = app_service.NewCouponService()
+
+// ========== 优惠券管理相关接口 ==========
+
+// CreateCouponTemplate 创建优惠券模板
+func CreateCouponTemplate(c *gin.Context) {
+	var req inout.CreateCouponTemplateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	template, err := couponService.WithContext(c).CreateTemplate(&req)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, template)
+}
+
+// UpdateCouponTemplate 更新优惠券模板
+func UpdateCouponTemplate(c *gin.Context) {
+	var req inout.UpdateCouponTemplateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	template, err := couponService.WithContext(c).UpdateTemplate(&req)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, template)
+}
+
+// GetCouponTemplateList 获取优惠券模板列表
+func GetCouponTemplateList(c *gin.Context) {
+	var req inout.CouponTemplateListReq
+
+	// 设置默认值
+	req.Page = 1
+	req.PageSize = 10
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	result, err := couponService.WithContext(c).GetTemplateList(&req)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, result)
+}
+
+// DeleteCouponTemplate 删除优惠券模板
+func DeleteCouponTemplate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		Resp.Err(c, 20001, "模板ID格式错误")
+		return
+	}
+
+	if err := couponService.WithContext(c).DeleteTemplate(id); err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, gin.H{"message": "优惠券模板删除成功"})
+}
+
+// IssueCoupons 向会员发放优惠券
+func IssueCoupons(c *gin.Context) {
+	var req inout.IssueCouponReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	result, err := couponService.WithContext(c).IssueCoupons(&req)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, result)
+}
+
+// GetUserCouponList 获取已发放的用户优惠券列表
+func GetUserCouponList(c *gin.Context) {
+	var req inout.UserCouponListReq
+
+	// 设置默认值
+	req.Page = 1
+	req.PageSize = 10
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	result, err := couponService.WithContext(c).GetUserCouponList(&req)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, result)
+}
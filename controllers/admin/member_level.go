@@ -0,0 +1,197 @@
+package admin
+
+import (
+	"strconv"
+
+	"nasa-go-admin/inout"
+	"nasa-go-admin/services/app_service"
+
+	"github.com/gin-gonic/gin"
+)
+
+var membershipService = app_service.NewMembershipService()
+var rechargeBonusService = app_service.NewRechargeBonusService()
+
+// ========== 会员等级管理相关接口 ==========
+
+// CreateMemberLevel 创建会员等级
+func CreateMemberLevel(c *gin.Context) {
+	var req inout.CreateMemberLevelReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	level, err := membershipService.CreateLevel(&req)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, level)
+}
+
+// UpdateMemberLevel 更新会员等级
+func UpdateMemberLevel(c *gin.Context) {
+	var req inout.UpdateMemberLevelReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	level, err := membershipService.UpdateLevel(&req)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, level)
+}
+
+// GetMemberLevelList 获取会员等级列表
+func GetMemberLevelList(c *gin.Context) {
+	levels, err := membershipService.GetLevelList()
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, levels)
+}
+
+// DeleteMemberLevel 删除会员等级
+func DeleteMemberLevel(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		Resp.Err(c, 20001, "等级ID格式错误")
+		return
+	}
+
+	if err := membershipService.DeleteLevel(id); err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, gin.H{"message": "会员等级删除成功"})
+}
+
+// ========== 会员积分管理相关接口 ==========
+
+// GetMemberAccount 获取会员等级和积分信息
+func GetMemberAccount(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		Resp.Err(c, 20001, "会员ID格式错误")
+		return
+	}
+
+	account, err := membershipService.GetAccount(userID)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, account)
+}
+
+// AdjustMemberPoints 后台调整会员积分
+func AdjustMemberPoints(c *gin.Context) {
+	var req inout.AdjustPointsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	account, err := membershipService.AdjustPoints(&req, c.GetInt("uid"))
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, account)
+}
+
+// GetPointsLogList 获取积分流水
+func GetPointsLogList(c *gin.Context) {
+	var req inout.PointsLogListReq
+
+	// 设置默认值
+	req.Page = 1
+	req.PageSize = 10
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	result, err := membershipService.GetPointsLogs(&req)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, result)
+}
+
+// ========== 充值赠送规则相关接口 ==========
+
+// CreateRechargeBonusRule 创建充值赠送规则
+func CreateRechargeBonusRule(c *gin.Context) {
+	var req inout.CreateRechargeBonusRuleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	rule, err := rechargeBonusService.CreateRule(&req)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, rule)
+}
+
+// UpdateRechargeBonusRule 更新充值赠送规则
+func UpdateRechargeBonusRule(c *gin.Context) {
+	var req inout.UpdateRechargeBonusRuleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	rule, err := rechargeBonusService.UpdateRule(&req)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, rule)
+}
+
+// GetRechargeBonusRuleList 获取充值赠送规则列表
+func GetRechargeBonusRuleList(c *gin.Context) {
+	rules, err := rechargeBonusService.GetRuleList()
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, rules)
+}
+
+// DeleteRechargeBonusRule 删除充值赠送规则
+func DeleteRechargeBonusRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		Resp.Err(c, 20001, "规则ID格式错误")
+		return
+	}
+
+	if err := rechargeBonusService.DeleteRule(id); err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, gin.H{"message": "充值赠送规则删除成功"})
+}
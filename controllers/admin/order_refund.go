@@ -0,0 +1,78 @@
+package admin
+
+import (
+	"nasa-go-admin/inout"
+	"nasa-go-admin/services/admin_service"
+	"nasa-go-admin/services/app_service"
+	"nasa-go-admin/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+var orderRefundService = &app_service.OrderRefundService{}
+
+// GetRefundList 获取退款申请列表
+func GetRefundList(c *gin.Context) {
+	var params inout.OrderRefundListReq
+	if err := c.ShouldBind(&params); err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	parentId, err := utils.GetParentId(c)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	list, err := orderRefundService.GetRefundList(params, parentId, c.GetInt("type") == admin_service.UserTypeAdmin)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+	Resp.Succ(c, list)
+}
+
+// ApproveRefund 同意退款申请
+func ApproveRefund(c *gin.Context) {
+	var params inout.ApproveRefundReq
+	if err := c.ShouldBindJSON(&params); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	parentId, err := utils.GetParentId(c)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	if err := orderRefundService.ApproveRefund(params, parentId,
+		c.GetInt("type") == admin_service.UserTypeAdmin, c.GetInt("uid")); err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+	Resp.Succ(c, gin.H{"message": "退款成功"})
+}
+
+// RejectRefund 拒绝退款申请
+func RejectRefund(c *gin.Context) {
+	var params inout.RejectRefundReq
+	if err := c.ShouldBindJSON(&params); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	parentId, err := utils.GetParentId(c)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	if err := orderRefundService.RejectRefund(params, parentId,
+		c.GetInt("type") == admin_service.UserTypeAdmin, c.GetInt("uid")); err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+	Resp.Succ(c, gin.H{"message": "已拒绝退款申请"})
+}
@@ -57,3 +57,19 @@ func RefreshRevenueStats(c *gin.Context) {
 		"days":    days,
 	})
 }
+
+// GetRevenueDashboard 收入看板（商品订单 + 房间预订）
+func GetRevenueDashboard(c *gin.Context) {
+	var params inout.RevenueDashboardReq
+	if err := c.ShouldBindQuery(&params); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	dashboard, err := revenueService.GetRevenueDashboard(c, params)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+	Resp.Succ(c, dashboard)
+}
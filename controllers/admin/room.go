@@ -12,6 +12,11 @@ import (
 var adminRoomService = &app_service.RoomService{}
 var bookingScheduler = app_service.NewBookingScheduler()
 var bookingLogService = &app_service.BookingLogService{}
+var bookingRefundService = app_service.NewBookingRefundService()
+var bookingCheckinService = app_service.NewBookingCheckinService()
+var bookingEntryService = app_service.NewBookingEntryService()
+var bookingWaitlistService = app_service.NewBookingWaitlistService()
+var roomBlackoutService = app_service.NewRoomBlackoutService()
 
 // ========== 房间管理相关接口 ==========
 
@@ -36,7 +41,7 @@ func CreateRoom(c *gin.Context) {
 		return
 	}
 
-	room, err := adminRoomService.CreateRoom(&req, uid)
+	room, err := adminRoomService.WithContext(c).CreateRoom(&req, uid)
 	if err != nil {
 		Resp.Err(c, 20001, err.Error())
 		return
@@ -53,7 +58,7 @@ func UpdateRoom(c *gin.Context) {
 		return
 	}
 
-	room, err := adminRoomService.UpdateRoom(&req)
+	room, err := adminRoomService.WithContext(c).UpdateRoom(&req)
 	if err != nil {
 		Resp.Err(c, 20001, err.Error())
 		return
@@ -83,7 +88,7 @@ func GetAdminRoomList(c *gin.Context) {
 		req.PageSize = 10
 	}
 
-	resp, err := adminRoomService.GetRoomList(&req)
+	resp, err := adminRoomService.WithContext(c).GetRoomList(&req)
 	if err != nil {
 		Resp.Err(c, 20001, err.Error())
 		return
@@ -106,7 +111,7 @@ func GetAdminRoomDetail(c *gin.Context) {
 		return
 	}
 
-	detail, err := adminRoomService.GetRoomDetail(id)
+	detail, err := adminRoomService.WithContext(c).GetRoomDetail(id)
 	if err != nil {
 		Resp.Err(c, 20001, err.Error())
 		return
@@ -123,7 +128,7 @@ func UpdateRoomStatus(c *gin.Context) {
 		return
 	}
 
-	if err := adminRoomService.UpdateRoomStatus(&req); err != nil {
+	if err := adminRoomService.WithContext(c).UpdateRoomStatus(&req); err != nil {
 		Resp.Err(c, 20001, err.Error())
 		return
 	}
@@ -145,7 +150,7 @@ func DeleteRoom(c *gin.Context) {
 		return
 	}
 
-	if err := adminRoomService.DeleteRoom(id); err != nil {
+	if err := adminRoomService.WithContext(c).DeleteRoom(id); err != nil {
 		Resp.Err(c, 20001, err.Error())
 		return
 	}
@@ -177,7 +182,7 @@ func GetAdminBookingList(c *gin.Context) {
 	}
 
 	// 管理员可以查看所有预订，不限制用户ID
-	resp, err := adminRoomService.GetBookingList(&req, nil)
+	resp, err := adminRoomService.WithContext(c).GetBookingList(&req, nil)
 	if err != nil {
 		Resp.Err(c, 20001, err.Error())
 		return
@@ -194,21 +199,114 @@ func UpdateBookingStatus(c *gin.Context) {
 		return
 	}
 
-	// 管理员可以更新任意预订状态，不限制用户ID
-	if err := adminRoomService.CancelBooking(&inout.CancelBookingReq{
+	// 记录操作管理员
+	var operatorID *int
+	if uid, exists := c.Get("uid"); exists {
+		if id, ok := uid.(int); ok {
+			operatorID = &id
+		}
+	}
+
+	// 管理员可以更新任意预订状态，不限制用户ID；已支付的预订按取消政策退款
+	refund, err := bookingRefundService.WithContext(c).CancelBooking(&inout.CancelBookingReq{
 		ID:     req.ID,
 		Reason: "管理员操作",
-	}, nil); err != nil {
+	}, nil, operatorID)
+	if err != nil {
 		Resp.Err(c, 20001, err.Error())
 		return
 	}
 
-	Resp.Succ(c, gin.H{"message": "预订状态更新成功"})
+	Resp.Succ(c, gin.H{"message": "预订状态更新成功", "refund": refund})
+}
+
+// GetAdminBookingSeriesDetail 获取周期预订详情及各次预订
+func GetAdminBookingSeriesDetail(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		Resp.Err(c, 20001, "周期预订ID格式错误")
+		return
+	}
+
+	resp, err := adminRoomService.WithContext(c).GetBookingSeriesDetail(id, nil)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, resp)
+}
+
+// CancelAdminBookingSeries 取消周期预订的某一次或全部未开始的预订
+func CancelAdminBookingSeries(c *gin.Context) {
+	var req inout.CancelBookingSeriesReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	// 记录操作管理员
+	var operatorID *int
+	if uid, exists := c.Get("uid"); exists {
+		if id, ok := uid.(int); ok {
+			operatorID = &id
+		}
+	}
+	if req.Reason == "" {
+		req.Reason = "管理员操作"
+	}
+
+	resp, err := adminRoomService.WithContext(c).CancelBookingSeries(&req, nil, operatorID)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, resp)
+}
+
+// GetAdminWaitlistList 获取候补列表
+func GetAdminWaitlistList(c *gin.Context) {
+	var req inout.WaitlistListReq
+
+	// 设置默认值
+	req.Page = 1
+	req.PageSize = 10
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	resp, err := bookingWaitlistService.WithContext(c).GetWaitlistList(&req, nil)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, resp)
+}
+
+// GetWaitlistDemand 按房间统计候补需求
+func GetWaitlistDemand(c *gin.Context) {
+	var req inout.WaitlistDemandReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	resp, err := bookingWaitlistService.WithContext(c).GetWaitlistDemand(&req)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, resp)
 }
 
 // GetRoomStatisticsAdmin 获取房间统计信息（管理后台）
 func GetRoomStatisticsAdmin(c *gin.Context) {
-	stats, err := adminRoomService.GetRoomStatistics()
+	stats, err := adminRoomService.WithContext(c).GetRoomStatistics()
 	if err != nil {
 		Resp.Err(c, 20001, err.Error())
 		return
@@ -217,6 +315,45 @@ func GetRoomStatisticsAdmin(c *gin.Context) {
 	Resp.Succ(c, stats)
 }
 
+// GetRoomAnalytics 房间利用率分析
+func GetRoomAnalytics(c *gin.Context) {
+	var req inout.RoomAnalyticsReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	analytics, err := adminRoomService.WithContext(c).GetRoomAnalytics(&req)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, analytics)
+}
+
+// ExportRoomAnalytics 导出房间利用率分析
+func ExportRoomAnalytics(c *gin.Context) {
+	var req inout.RoomAnalyticsReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	fileData, filename, err := adminRoomService.WithContext(c).ExportRoomAnalytics(&req)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	// 设置响应头
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Header("Content-Transfer-Encoding", "binary")
+
+	c.Data(200, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", fileData)
+}
+
 // ========== 订单状态管理相关接口 ==========
 
 // GetBookingStatusInfo 获取订单状态信息
@@ -227,7 +364,7 @@ func GetBookingStatusInfo(c *gin.Context) {
 		return
 	}
 
-	info, err := bookingScheduler.GetBookingStatusInfo(req.ID)
+	info, err := bookingScheduler.WithContext(c).GetBookingStatusInfo(req.ID)
 	if err != nil {
 		Resp.Err(c, 20001, err.Error())
 		return
@@ -276,7 +413,7 @@ func ManualStartBooking(c *gin.Context) {
 		return
 	}
 
-	if err := bookingScheduler.ManuallyStartBooking(req.ID, uid); err != nil {
+	if err := bookingScheduler.WithContext(c).ManuallyStartBooking(req.ID, uid); err != nil {
 		Resp.Err(c, 20001, err.Error())
 		return
 	}
@@ -305,7 +442,7 @@ func ManualEndBooking(c *gin.Context) {
 		return
 	}
 
-	if err := bookingScheduler.ManuallyEndBooking(req.ID, uid); err != nil {
+	if err := bookingScheduler.WithContext(c).ManuallyEndBooking(req.ID, uid); err != nil {
 		Resp.Err(c, 20001, err.Error())
 		return
 	}
@@ -313,6 +450,147 @@ func ManualEndBooking(c *gin.Context) {
 	Resp.Succ(c, gin.H{"message": "订单已手动结束"})
 }
 
+// ========== 前台入住退房接口 ==========
+
+// CheckInBooking 办理入住，支持扫描预订二维码传入预订单号
+func CheckInBooking(c *gin.Context) {
+	var req inout.CheckInReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	resp, err := bookingCheckinService.WithContext(c).CheckIn(&req, c.GetInt("uid"))
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, resp)
+}
+
+// VerifyBookingEntry 核验预订入场二维码或数字核验码，核验通过后开始使用
+func VerifyBookingEntry(c *gin.Context) {
+	var req inout.VerifyBookingEntryReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	resp, err := bookingEntryService.WithContext(c).VerifyEntry(&req, c.GetInt("uid"))
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, resp)
+}
+
+// CheckOutBooking 办理退房并结算超时费用
+func CheckOutBooking(c *gin.Context) {
+	var req inout.CheckOutReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	resp, err := bookingCheckinService.WithContext(c).CheckOut(&req, c.GetInt("uid"))
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, resp)
+}
+
+// SettleBookingFee 结算挂账的额外费用
+func SettleBookingFee(c *gin.Context) {
+	var req inout.SettleUsageFeeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	resp, err := bookingCheckinService.WithContext(c).SettleUsageFee(&req, c.GetInt("uid"))
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, resp)
+}
+
+// ExtendBooking 为用户预订续时，费用从用户钱包扣除
+func ExtendBooking(c *gin.Context) {
+	var req inout.ExtendBookingReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	operatorID := c.GetInt("uid")
+	resp, err := bookingCheckinService.WithContext(c).ExtendBooking(&req, nil, &operatorID)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, resp)
+}
+
+// ========== 前台代客预订与改期换房接口 ==========
+
+// CreateAdminBooking 前台代会员或散客创建预订
+func CreateAdminBooking(c *gin.Context) {
+	var req inout.AdminCreateBookingReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	resp, err := bookingCheckinService.WithContext(c).CreateWalkInBooking(&req, c.GetInt("uid"))
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, resp)
+}
+
+// ModifyAdminBooking 修改未开始预订的时间或房间，已支付预订结算差价
+func ModifyAdminBooking(c *gin.Context) {
+	var req inout.UpdateBookingReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	resp, err := bookingCheckinService.WithContext(c).ModifyBooking(&req, c.GetInt("uid"))
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, resp)
+}
+
+// TransferBookingRoom 使用中的预订换房，剩余时长按房价差折算差价
+func TransferBookingRoom(c *gin.Context) {
+	var req inout.TransferRoomReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	resp, err := bookingCheckinService.WithContext(c).TransferRoom(&req, c.GetInt("uid"))
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, resp)
+}
+
 // ========== 订单状态日志管理接口 ==========
 
 // GetBookingLogList 获取订单状态日志列表
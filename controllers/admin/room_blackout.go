@@ -0,0 +1,133 @@
+package admin
+
+import (
+	"strconv"
+
+	"nasa-go-admin/inout"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ========== 房间维护/停业时段相关接口 ==========
+
+// CreateRoomBlackout 创建维护/停业时段，返回受影响的预订
+func CreateRoomBlackout(c *gin.Context) {
+	var req inout.CreateRoomBlackoutReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	// 记录操作管理员
+	var operatorID int
+	if uid, exists := c.Get("uid"); exists {
+		if id, ok := uid.(int); ok {
+			operatorID = id
+		}
+	}
+
+	resp, err := roomBlackoutService.WithContext(c).CreateBlackout(&req, operatorID)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, resp)
+}
+
+// UpdateRoomBlackout 更新维护/停业时段，返回受影响的预订
+func UpdateRoomBlackout(c *gin.Context) {
+	var req inout.UpdateRoomBlackoutReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	resp, err := roomBlackoutService.WithContext(c).UpdateBlackout(&req)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, resp)
+}
+
+// GetRoomBlackoutList 获取维护/停业时段列表
+func GetRoomBlackoutList(c *gin.Context) {
+	var req inout.RoomBlackoutListReq
+
+	// 设置默认值
+	req.Page = 1
+	req.PageSize = 10
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	result, err := roomBlackoutService.WithContext(c).GetBlackoutList(&req)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, result)
+}
+
+// CancelRoomBlackout 取消维护/停业时段
+func CancelRoomBlackout(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		Resp.Err(c, 20001, "维护时段ID格式错误")
+		return
+	}
+
+	if err := roomBlackoutService.WithContext(c).CancelBlackout(id); err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, gin.H{"message": "维护时段已取消"})
+}
+
+// GetBlackoutAffectedBookings 获取受维护/停业影响的预订及可改订的同类房间
+func GetBlackoutAffectedBookings(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		Resp.Err(c, 20001, "维护时段ID格式错误")
+		return
+	}
+
+	resp, err := roomBlackoutService.WithContext(c).GetAffectedBookings(id)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, resp)
+}
+
+// ResolveBlackoutBookings 批量改订或退款受维护/停业影响的预订
+func ResolveBlackoutBookings(c *gin.Context) {
+	var req inout.ResolveBlackoutReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	// 记录操作管理员
+	var operatorID *int
+	if uid, exists := c.Get("uid"); exists {
+		if id, ok := uid.(int); ok {
+			operatorID = &id
+		}
+	}
+
+	resp, err := roomBlackoutService.WithContext(c).ResolveAffectedBookings(&req, operatorID)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, resp)
+}
@@ -0,0 +1,83 @@
+package admin
+
+import (
+	"strconv"
+
+	"nasa-go-admin/inout"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ========== 预订取消政策管理相关接口 ==========
+
+// CreateCancelPolicy 创建取消政策
+func CreateCancelPolicy(c *gin.Context) {
+	var req inout.CreateCancelPolicyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	policy, err := bookingRefundService.WithContext(c).CreateCancelPolicy(&req)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, policy)
+}
+
+// UpdateCancelPolicy 更新取消政策
+func UpdateCancelPolicy(c *gin.Context) {
+	var req inout.UpdateCancelPolicyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	policy, err := bookingRefundService.WithContext(c).UpdateCancelPolicy(&req)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, policy)
+}
+
+// GetCancelPolicyList 获取取消政策列表
+func GetCancelPolicyList(c *gin.Context) {
+	var req inout.CancelPolicyListReq
+
+	// 设置默认值
+	req.Page = 1
+	req.PageSize = 10
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	result, err := bookingRefundService.WithContext(c).GetCancelPolicyList(&req)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, result)
+}
+
+// DeleteCancelPolicy 删除取消政策
+func DeleteCancelPolicy(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		Resp.Err(c, 20001, "政策ID格式错误")
+		return
+	}
+
+	if err := bookingRefundService.WithContext(c).DeleteCancelPolicy(id); err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, gin.H{"message": "取消政策删除成功"})
+}
@@ -37,7 +37,7 @@ func (rpc *RoomPackageController) CreatePackage(c *gin.Context) {
 		return
 	}
 
-	pkg, err := rpc.roomService.CreatePackage(&req, userID.(int))
+	pkg, err := rpc.roomService.WithContext(c).CreatePackage(&req, userID.(int))
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "创建套餐失败", err.Error())
 		return
@@ -57,7 +57,7 @@ func (rpc *RoomPackageController) UpdatePackage(c *gin.Context) {
 		return
 	}
 
-	pkg, err := rpc.roomService.UpdatePackage(&req)
+	pkg, err := rpc.roomService.WithContext(c).UpdatePackage(&req)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "更新套餐失败", err.Error())
 		return
@@ -85,7 +85,7 @@ func (rpc *RoomPackageController) GetPackageList(c *gin.Context) {
 		req.PageSize = 10
 	}
 
-	result, err := rpc.roomService.GetPackageList(&req)
+	result, err := rpc.roomService.WithContext(c).GetPackageList(&req)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "获取套餐列表失败", err.Error())
 		return
@@ -103,7 +103,7 @@ func (rpc *RoomPackageController) DeletePackage(c *gin.Context) {
 		return
 	}
 
-	if err := rpc.roomService.DeletePackage(id); err != nil {
+	if err := rpc.roomService.WithContext(c).DeletePackage(id); err != nil {
 		response.Error(c, http.StatusInternalServerError, "删除套餐失败", err.Error())
 		return
 	}
@@ -121,7 +121,7 @@ func (rpc *RoomPackageController) CreatePackageRule(c *gin.Context) {
 		return
 	}
 
-	rule, err := rpc.roomService.CreatePackageRule(&req)
+	rule, err := rpc.roomService.WithContext(c).CreatePackageRule(&req)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "创建套餐规则失败", err.Error())
 		return
@@ -141,7 +141,7 @@ func (rpc *RoomPackageController) UpdatePackageRule(c *gin.Context) {
 		return
 	}
 
-	rule, err := rpc.roomService.UpdatePackageRule(&req)
+	rule, err := rpc.roomService.WithContext(c).UpdatePackageRule(&req)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "更新套餐规则失败", err.Error())
 		return
@@ -167,7 +167,7 @@ func (rpc *RoomPackageController) GetPackageRuleList(c *gin.Context) {
 		return
 	}
 
-	rules, err := rpc.roomService.GetPackageRuleList(packageID)
+	rules, err := rpc.roomService.WithContext(c).GetPackageRuleList(packageID)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "获取套餐规则列表失败", err.Error())
 		return
@@ -185,7 +185,7 @@ func (rpc *RoomPackageController) DeletePackageRule(c *gin.Context) {
 		return
 	}
 
-	if err := rpc.roomService.DeletePackageRule(id); err != nil {
+	if err := rpc.roomService.WithContext(c).DeletePackageRule(id); err != nil {
 		response.Error(c, http.StatusInternalServerError, "删除套餐规则失败", err.Error())
 		return
 	}
@@ -210,7 +210,7 @@ func (rpc *RoomPackageController) GetSpecialDateList(c *gin.Context) {
 		pageSize = 20
 	}
 
-	dates, err := rpc.roomService.GetSpecialDateList(page, pageSize)
+	dates, err := rpc.roomService.WithContext(c).GetSpecialDateList(page, pageSize)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "获取特殊日期列表失败", err.Error())
 		return
@@ -223,7 +223,7 @@ func (rpc *RoomPackageController) GetSpecialDateList(c *gin.Context) {
 func (rpc *RoomPackageController) CreateSpecialDate(c *gin.Context) {
 	var req struct {
 		Date        string `json:"date" binding:"required"`
-		DateType    string `json:"date_type" binding:"required,oneof=holiday festival special"`
+		DateType    string `json:"date_type" binding:"required,oneof=holiday festival special workday"`
 		Name        string `json:"name" binding:"required"`
 		Description string `json:"description"`
 	}
@@ -240,7 +240,7 @@ func (rpc *RoomPackageController) CreateSpecialDate(c *gin.Context) {
 		return
 	}
 
-	specialDate, err := rpc.roomService.CreateSpecialDate(date, req.DateType, req.Name, req.Description)
+	specialDate, err := rpc.roomService.WithContext(c).CreateSpecialDate(date, req.DateType, req.Name, req.Description)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "创建特殊日期失败", err.Error())
 		return
@@ -261,7 +261,7 @@ func (rpc *RoomPackageController) DeleteSpecialDate(c *gin.Context) {
 		return
 	}
 
-	if err := rpc.roomService.DeleteSpecialDate(id); err != nil {
+	if err := rpc.roomService.WithContext(c).DeleteSpecialDate(id); err != nil {
 		response.Error(c, http.StatusInternalServerError, "删除特殊日期失败", err.Error())
 		return
 	}
@@ -271,6 +271,30 @@ func (rpc *RoomPackageController) DeleteSpecialDate(c *gin.Context) {
 	})
 }
 
+// ImportSpecialDates 批量导入年度节假日和调休安排
+func (rpc *RoomPackageController) ImportSpecialDates(c *gin.Context) {
+	var req inout.SpecialDateImportReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "参数错误", err.Error())
+		return
+	}
+	if req.Format == "ics" && req.Content == "" {
+		response.Error(c, http.StatusBadRequest, "参数错误", "ICS内容不能为空")
+		return
+	}
+
+	result, err := app_service.GetHolidayCalendar().ImportYear(&req)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "导入节假日安排失败", err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{
+		"data":    result,
+		"message": "导入节假日安排成功",
+	})
+}
+
 // CalculatePrice 价格计算接口
 func (rpc *RoomPackageController) CalculatePrice(c *gin.Context) {
 	var req struct {
@@ -285,7 +309,7 @@ func (rpc *RoomPackageController) CalculatePrice(c *gin.Context) {
 	}
 
 	// 解析开始时间
-	startTime, err := time.Parse("2006-01-02 15:04:05", req.StartTime)
+	startTime, err := time.ParseInLocation("2006-01-02 15:04:05", req.StartTime, time.Local)
 	if err != nil {
 		response.Error(c, http.StatusBadRequest, "参数错误", "开始时间格式错误")
 		return
@@ -294,7 +318,7 @@ func (rpc *RoomPackageController) CalculatePrice(c *gin.Context) {
 	endTime := startTime.Add(time.Duration(req.Hours) * time.Hour)
 
 	// 计算价格
-	finalPrice, appliedRules, err := rpc.roomService.CalculatePriceWithPackage(req.RoomID, startTime, endTime)
+	finalPrice, appliedRules, err := rpc.roomService.WithContext(c).CalculatePriceWithPackage(req.RoomID, startTime, endTime)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "价格计算失败", err.Error())
 		return
@@ -321,7 +345,7 @@ func (rpc *RoomPackageController) GetRoomPackages(c *gin.Context) {
 		return
 	}
 
-	resp, err := rpc.roomService.GetRoomPackages(&req)
+	resp, err := rpc.roomService.WithContext(c).GetRoomPackages(&req)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "获取套餐失败", err.Error())
 		return
@@ -338,7 +362,7 @@ func (rpc *RoomPackageController) BookingPricePreview(c *gin.Context) {
 		return
 	}
 
-	resp, err := rpc.roomService.BookingPricePreview(&req)
+	resp, err := rpc.roomService.WithContext(c).BookingPricePreview(&req)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "价格计算失败", err.Error())
 		return
@@ -0,0 +1,149 @@
+package admin
+
+import (
+	"strconv"
+
+	"nasa-go-admin/inout"
+	"nasa-go-admin/services/app_service"
+	"nasa-go-admin/services/miniapp_service"
+
+	"github.com/gin-gonic/gin"
+)
+
+var subscribeTemplateService = miniapp_service.NewSubscribeTemplateService()
+var subscribeEventService = app_service.NewSubscribeEventService()
+
+// ========== 订阅消息模板配置相关接口 ==========
+
+// GetSubscribeTemplateList 获取订阅消息模板列表
+func GetSubscribeTemplateList(c *gin.Context) {
+	var req inout.SubscribeTemplateListReq
+
+	// 设置默认值
+	req.Page = 1
+	req.PageSize = 10
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	result, err := subscribeTemplateService.GetTemplateList(&req)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, result)
+}
+
+// GetSubscribeEventList 获取可配置订阅消息的业务事件
+func GetSubscribeEventList(c *gin.Context) {
+	result, err := subscribeTemplateService.GetEventList()
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, result)
+}
+
+// CreateSubscribeTemplate 创建订阅消息模板
+func CreateSubscribeTemplate(c *gin.Context) {
+	var req inout.CreateSubscribeTemplateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	tpl, err := subscribeTemplateService.CreateTemplate(&req)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, tpl)
+}
+
+// UpdateSubscribeTemplate 更新订阅消息模板
+func UpdateSubscribeTemplate(c *gin.Context) {
+	var req inout.UpdateSubscribeTemplateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	tpl, err := subscribeTemplateService.UpdateTemplate(&req)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, tpl)
+}
+
+// DeleteSubscribeTemplate 删除订阅消息模板
+func DeleteSubscribeTemplate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		Resp.Err(c, 20001, "模板ID格式错误")
+		return
+	}
+
+	if err := subscribeTemplateService.DeleteTemplate(id); err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, gin.H{"message": "删除成功"})
+}
+
+// PreviewSubscribeTemplate 预览订阅消息渲染结果
+func PreviewSubscribeTemplate(c *gin.Context) {
+	var req inout.PreviewSubscribeTemplateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	tpl, err := subscribeTemplateService.ResolvePreviewTemplate(&req)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	payload := req.Payload
+	if req.BizNo != "" {
+		_, bizPayload, err := subscribeEventService.WithContext(c).BuildEventPayload(tpl.Event, req.BizNo)
+		if err != nil {
+			Resp.Err(c, 20001, err.Error())
+			return
+		}
+		payload = bizPayload
+	}
+
+	result, err := subscribeTemplateService.Preview(tpl, payload)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, result)
+}
+
+// SendSubscribeEvent 按业务事件向单据所属用户发送订阅消息
+func SendSubscribeEvent(c *gin.Context) {
+	var req inout.SendSubscribeEventReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	result, err := subscribeEventService.WithContext(c).SendEvent(&req)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	Resp.Succ(c, result)
+}
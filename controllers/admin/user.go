@@ -9,6 +9,7 @@ import (
 	"nasa-go-admin/redis"
 	"nasa-go-admin/services/admin_service"
 	"strconv"
+	"strings"
 	"time"
 
 	"nasa-go-admin/utils"
@@ -145,6 +146,7 @@ func AddMenu(c *gin.Context) {
 		Path:     params.Path,
 		Title:    params.Title,
 		Enable:   params.Show,
+		Method:   strings.ToUpper(strings.TrimSpace(params.Method)),
 	}
 	// Check if the menu already exists
 	Id, err := tenantsService.AddMenu(c, menu)
@@ -199,6 +201,7 @@ func UpdateMenu(c *gin.Context) {
 		Path:     params.Path,
 		Title:    params.Title,
 		Enable:   params.Show,
+		Method:   strings.ToUpper(strings.TrimSpace(params.Method)),
 	}
 	// Check if the menu already exists
 	id, err := tenantsService.UpdateMenu(c, params.Id, menu)
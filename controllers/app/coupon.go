@@ -0,0 +1,68 @@
+package app
+
+import (
+	"nasa-go-admin/api"
+	"nasa-go-admin/inout"
+	"nasa-go-admin/services/app_service"
+
+	"github.com/gin-gonic/gin"
+)
+
+var couponService = app_service.NewCouponService()
+
+// ========== 优惠券相关接口 ==========
+
+// GetMyCouponList 获取我的优惠券列表
+func GetMyCouponList(c *gin.Context) {
+	var req inout.UserCouponListReq
+
+	// 设置默认值
+	req.Page = 1
+	req.PageSize = 10
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		api.Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	uid := c.GetInt("uid")
+	if uid == 0 {
+		api.Resp.Err(c, 10002, "用户未登录")
+		return
+	}
+
+	// 只能查询自己的优惠券
+	req.UserID = uid
+	req.TemplateID = 0
+
+	resp, err := couponService.GetUserCouponList(&req)
+	if err != nil {
+		api.Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	api.Resp.Succ(c, resp)
+}
+
+// ClaimCoupon 凭推广码领取优惠券
+func ClaimCoupon(c *gin.Context) {
+	var req inout.ClaimCouponReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		api.Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	uid := c.GetInt("uid")
+	if uid == 0 {
+		api.Resp.Err(c, 10002, "用户未登录")
+		return
+	}
+
+	coupon, err := couponService.ClaimByPromoCode(uid, req.PromoCode)
+	if err != nil {
+		api.Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	api.Resp.Succ(c, coupon)
+}
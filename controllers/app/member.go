@@ -0,0 +1,61 @@
+package app
+
+import (
+	"nasa-go-admin/api"
+	"nasa-go-admin/inout"
+	"nasa-go-admin/services/app_service"
+
+	"github.com/gin-gonic/gin"
+)
+
+var membershipService = app_service.NewMembershipService()
+
+// ========== 会员等级与积分相关接口 ==========
+
+// GetMyMemberAccount 获取我的会员等级和积分
+func GetMyMemberAccount(c *gin.Context) {
+	uid := c.GetInt("uid")
+	if uid == 0 {
+		api.Resp.Err(c, 10002, "用户未登录")
+		return
+	}
+
+	account, err := membershipService.GetAccount(uid)
+	if err != nil {
+		api.Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	api.Resp.Succ(c, account)
+}
+
+// GetMyPointsLogs 获取我的积分流水
+func GetMyPointsLogs(c *gin.Context) {
+	var req inout.PointsLogListReq
+
+	// 设置默认值
+	req.Page = 1
+	req.PageSize = 10
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		api.Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	uid := c.GetInt("uid")
+	if uid == 0 {
+		api.Resp.Err(c, 10002, "用户未登录")
+		return
+	}
+
+	// 只能查询自己的积分流水
+	req.UserID = uid
+
+	resp, err := membershipService.GetPointsLogs(&req)
+	if err != nil {
+		api.Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	api.Resp.Succ(c, resp)
+}
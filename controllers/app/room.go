@@ -11,6 +11,10 @@ import (
 )
 
 var roomService = &app_service.RoomService{}
+var bookingPaymentService = app_service.NewBookingPaymentService()
+var bookingRefundService = app_service.NewBookingRefundService()
+var bookingCheckinService = app_service.NewBookingCheckinService()
+var bookingWaitlistService = app_service.NewBookingWaitlistService()
 
 // ========== 房间管理相关接口 ==========
 
@@ -84,6 +88,23 @@ func CheckRoomAvailability(c *gin.Context) {
 	api.Resp.Succ(c, resp)
 }
 
+// GetRoomCalendar 获取房间可预订日历
+func GetRoomCalendar(c *gin.Context) {
+	var req inout.RoomCalendarReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		api.Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	resp, err := roomService.GetRoomCalendar(&req)
+	if err != nil {
+		api.Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	api.Resp.Succ(c, resp)
+}
+
 // GetRoomStatistics 获取房间统计信息
 func GetRoomStatistics(c *gin.Context) {
 	stats, err := roomService.GetRoomStatistics()
@@ -197,38 +218,78 @@ func GetBookingDetail(c *gin.Context) {
 		return
 	}
 
-	// 构造查询请求
-	req := &inout.BookingListReq{
-		Page:     1,
-		PageSize: 1,
+	booking, err := roomService.GetBookingDetail(id, &uid)
+	if err != nil {
+		api.Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	api.Resp.Succ(c, booking)
+}
+
+// CancelBooking 取消预订
+func CancelBooking(c *gin.Context) {
+	var req inout.CancelBookingReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		api.Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	// 获取用户ID
+	userID, exists := c.Get("uid")
+	if !exists {
+		api.Resp.Err(c, 10002, "用户未登录")
+		return
+	}
+
+	uid, ok := userID.(int)
+	if !ok {
+		api.Resp.Err(c, 10002, "用户信息错误")
+		return
 	}
 
-	resp, err := roomService.GetBookingList(req, &uid)
+	refund, err := bookingRefundService.CancelBooking(&req, &uid, &uid)
 	if err != nil {
 		api.Resp.Err(c, 20001, err.Error())
 		return
 	}
 
-	// 查找指定的预订
-	var booking *inout.BookingDetail
-	for _, b := range resp.List {
-		if b.ID == id {
-			booking = &b
-			break
-		}
+	api.Resp.Succ(c, gin.H{"message": "预订已取消", "refund": refund})
+}
+
+// GetBookingRefundPreview 预览取消预订的退款金额
+func GetBookingRefundPreview(c *gin.Context) {
+	var req inout.BookingRefundPreviewReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		api.Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
 	}
 
-	if booking == nil {
-		api.Resp.Err(c, 20001, "预订不存在或无权限访问")
+	// 获取用户ID
+	userID, exists := c.Get("uid")
+	if !exists {
+		api.Resp.Err(c, 10002, "用户未登录")
 		return
 	}
 
-	api.Resp.Succ(c, booking)
+	uid, ok := userID.(int)
+	if !ok {
+		api.Resp.Err(c, 10002, "用户信息错误")
+		return
+	}
+
+	quote, err := bookingRefundService.PreviewRefund(req.ID, &uid)
+	if err != nil {
+		api.Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	api.Resp.Succ(c, quote)
 }
 
-// CancelBooking 取消预订
-func CancelBooking(c *gin.Context) {
-	var req inout.CancelBookingReq
+// PayBooking 使用钱包余额支付预订
+func PayBooking(c *gin.Context) {
+	var req inout.PayBookingReq
 	if err := c.ShouldBindJSON(&req); err != nil {
 		api.Resp.Err(c, 20001, "参数错误: "+err.Error())
 		return
@@ -247,12 +308,43 @@ func CancelBooking(c *gin.Context) {
 		return
 	}
 
-	if err := roomService.CancelBooking(&req, &uid); err != nil {
+	resp, err := bookingPaymentService.PayBooking(&req, uid)
+	if err != nil {
 		api.Resp.Err(c, 20001, err.Error())
 		return
 	}
 
-	api.Resp.Succ(c, gin.H{"message": "预订已取消"})
+	api.Resp.Succ(c, resp)
+}
+
+// ExtendBooking 预订续时，使用钱包余额支付续时费用
+func ExtendBooking(c *gin.Context) {
+	var req inout.ExtendBookingReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		api.Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	// 获取用户ID
+	userID, exists := c.Get("uid")
+	if !exists {
+		api.Resp.Err(c, 10002, "用户未登录")
+		return
+	}
+
+	uid, ok := userID.(int)
+	if !ok {
+		api.Resp.Err(c, 10002, "用户信息错误")
+		return
+	}
+
+	resp, err := bookingCheckinService.ExtendBooking(&req, &uid, nil)
+	if err != nil {
+		api.Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	api.Resp.Succ(c, resp)
 }
 
 // ========== 套餐相关接口 ==========
@@ -290,3 +382,268 @@ func BookingPricePreview(c *gin.Context) {
 
 	api.Resp.Succ(c, resp)
 }
+
+// ========== 周期预订相关接口 ==========
+
+// PreviewBookingSeries 预览周期预订的各次日期、可用性和价格
+func PreviewBookingSeries(c *gin.Context) {
+	var req inout.CreateBookingSeriesReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		api.Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	resp, err := roomService.PreviewBookingSeries(&req)
+	if err != nil {
+		api.Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	api.Resp.Succ(c, resp)
+}
+
+// CreateBookingSeries 创建周期预订
+func CreateBookingSeries(c *gin.Context) {
+	var req inout.CreateBookingSeriesReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		api.Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	// 获取用户ID
+	userID, exists := c.Get("uid")
+	if !exists {
+		api.Resp.Err(c, 10002, "用户未登录")
+		return
+	}
+
+	uid, ok := userID.(int)
+	if !ok {
+		api.Resp.Err(c, 10002, "用户信息错误")
+		return
+	}
+
+	resp, err := roomService.CreateBookingSeries(&req, uid)
+	if err != nil {
+		api.Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	api.Resp.Succ(c, resp)
+}
+
+// GetBookingSeriesDetail 获取周期预订详情
+func GetBookingSeriesDetail(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		api.Resp.Err(c, 20001, "周期预订ID格式错误")
+		return
+	}
+
+	// 获取用户ID
+	userID, exists := c.Get("uid")
+	if !exists {
+		api.Resp.Err(c, 10002, "用户未登录")
+		return
+	}
+
+	uid, ok := userID.(int)
+	if !ok {
+		api.Resp.Err(c, 10002, "用户信息错误")
+		return
+	}
+
+	resp, err := roomService.GetBookingSeriesDetail(id, &uid)
+	if err != nil {
+		api.Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	api.Resp.Succ(c, resp)
+}
+
+// CancelBookingSeries 取消周期预订的某一次或全部未开始的预订
+func CancelBookingSeries(c *gin.Context) {
+	var req inout.CancelBookingSeriesReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		api.Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	// 获取用户ID
+	userID, exists := c.Get("uid")
+	if !exists {
+		api.Resp.Err(c, 10002, "用户未登录")
+		return
+	}
+
+	uid, ok := userID.(int)
+	if !ok {
+		api.Resp.Err(c, 10002, "用户信息错误")
+		return
+	}
+
+	resp, err := roomService.CancelBookingSeries(&req, &uid, &uid)
+	if err != nil {
+		api.Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	api.Resp.Succ(c, resp)
+}
+
+// PayBookingSeries 使用钱包余额支付周期预订中全部待支付的预订
+func PayBookingSeries(c *gin.Context) {
+	var req inout.PayBookingSeriesReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		api.Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	// 获取用户ID
+	userID, exists := c.Get("uid")
+	if !exists {
+		api.Resp.Err(c, 10002, "用户未登录")
+		return
+	}
+
+	uid, ok := userID.(int)
+	if !ok {
+		api.Resp.Err(c, 10002, "用户信息错误")
+		return
+	}
+
+	resp, err := roomService.PayBookingSeries(&req, uid)
+	if err != nil {
+		api.Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	api.Resp.Succ(c, resp)
+}
+
+// ========== 预订候补相关接口 ==========
+
+// JoinWaitlist 登记候补
+func JoinWaitlist(c *gin.Context) {
+	var req inout.JoinWaitlistReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		api.Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	// 获取用户ID
+	userID, exists := c.Get("uid")
+	if !exists {
+		api.Resp.Err(c, 10002, "用户未登录")
+		return
+	}
+
+	uid, ok := userID.(int)
+	if !ok {
+		api.Resp.Err(c, 10002, "用户信息错误")
+		return
+	}
+
+	resp, err := bookingWaitlistService.JoinWaitlist(&req, uid)
+	if err != nil {
+		api.Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	api.Resp.Succ(c, resp)
+}
+
+// GetMyWaitlist 获取我的候补列表
+func GetMyWaitlist(c *gin.Context) {
+	var req inout.WaitlistListReq
+
+	// 设置默认值
+	req.Page = 1
+	req.PageSize = 10
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		api.Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	// 获取用户ID
+	userID, exists := c.Get("uid")
+	if !exists {
+		api.Resp.Err(c, 10002, "用户未登录")
+		return
+	}
+
+	uid, ok := userID.(int)
+	if !ok {
+		api.Resp.Err(c, 10002, "用户信息错误")
+		return
+	}
+
+	resp, err := bookingWaitlistService.GetWaitlistList(&req, &uid)
+	if err != nil {
+		api.Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	api.Resp.Succ(c, resp)
+}
+
+// CancelWaitlist 退出候补，已保留时段时放弃保留
+func CancelWaitlist(c *gin.Context) {
+	var req inout.WaitlistActionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		api.Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	// 获取用户ID
+	userID, exists := c.Get("uid")
+	if !exists {
+		api.Resp.Err(c, 10002, "用户未登录")
+		return
+	}
+
+	uid, ok := userID.(int)
+	if !ok {
+		api.Resp.Err(c, 10002, "用户信息错误")
+		return
+	}
+
+	if err := bookingWaitlistService.CancelWaitlist(req.ID, uid); err != nil {
+		api.Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	api.Resp.Succ(c, gin.H{"message": "已退出候补"})
+}
+
+// ConfirmWaitlistOffer 使用钱包余额支付候补保留的预订
+func ConfirmWaitlistOffer(c *gin.Context) {
+	var req inout.WaitlistActionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		api.Resp.Err(c, 20001, "参数错误: "+err.Error())
+		return
+	}
+
+	// 获取用户ID
+	userID, exists := c.Get("uid")
+	if !exists {
+		api.Resp.Err(c, 10002, "用户未登录")
+		return
+	}
+
+	uid, ok := userID.(int)
+	if !ok {
+		api.Resp.Err(c, 10002, "用户信息错误")
+		return
+	}
+
+	resp, err := bookingWaitlistService.ConfirmOffer(req.ID, uid)
+	if err != nil {
+		api.Resp.Err(c, 20001, err.Error())
+		return
+	}
+
+	api.Resp.Succ(c, resp)
+}
@@ -1,15 +1,17 @@
 package app
 
 import (
-	"fmt"
+	"log"
 	"nasa-go-admin/inout"
-	"nasa-go-admin/model/app_model"
+	"nasa-go-admin/pkg/payment"
 	"nasa-go-admin/services/app_service"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
 var walletService = &app_service.WalletService{}
+var rechargeService = app_service.NewRechargeService()
 
 // GetUserWallet
 func GetUserWallet(c *gin.Context) {
@@ -22,7 +24,7 @@ func GetUserWallet(c *gin.Context) {
 	Resp.Succ(c, wallet)
 }
 
-// Recharge
+// Recharge 创建充值单并返回支付参数，到账以支付回调为准
 func Recharge(c *gin.Context) {
 	var params inout.RechargeReq
 	if err := c.ShouldBind(&params); err != nil {
@@ -30,15 +32,56 @@ func Recharge(c *gin.Context) {
 		return
 	}
 	uid := c.GetInt("uid")
-	data := app_model.AppWallet{
+	resp, err := rechargeService.CreateRecharge(uid, params.Amount)
+	if err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
+	}
+	Resp.Succ(c, resp)
+}
 
-		Money: params.Amount,
+// GetRechargeStatus 查询充值单状态
+func GetRechargeStatus(c *gin.Context) {
+	var params inout.RechargeStatusReq
+	if err := c.ShouldBind(&params); err != nil {
+		Resp.Err(c, 20001, err.Error())
+		return
 	}
-	fmt.Println("data", data)
-	err := walletService.Recharge(c, uid, data)
+	uid := c.GetInt("uid")
+	resp, err := rechargeService.GetRechargeStatus(uid, params.OrderNo)
 	if err != nil {
 		Resp.Err(c, 20001, err.Error())
 		return
 	}
-	Resp.Succ(c, nil)
+	Resp.Succ(c, resp)
+}
+
+// PaymentNotify 支付渠道异步回调（验签后入账）
+func PaymentNotify(c *gin.Context) {
+	provider, err := payment.GetProvider()
+	if err != nil {
+		log.Printf("支付回调处理失败，渠道未配置: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"code": "FAIL", "message": "支付渠道未配置"})
+		return
+	}
+
+	if c.Param("provider") != provider.Name() {
+		c.JSON(provider.NotifyAck(false, "支付渠道不匹配"))
+		return
+	}
+
+	result, err := provider.ParseNotify(c.Request)
+	if err != nil {
+		log.Printf("⚠️ 支付回调校验失败 [%s] %s: %v", provider.Name(), c.ClientIP(), err)
+		c.JSON(provider.NotifyAck(false, err.Error()))
+		return
+	}
+
+	if err := rechargeService.HandlePaymentNotify(provider.Name(), result); err != nil {
+		log.Printf("支付回调处理失败 %s: %v", result.OutTradeNo, err)
+		c.JSON(provider.NotifyAck(false, "处理失败"))
+		return
+	}
+
+	c.JSON(provider.NotifyAck(true, ""))
 }
@@ -372,6 +372,7 @@ func WebSocketStats(c *gin.Context) {
 		"total_connections":  atomic.LoadInt64(&totalConnections),
 		"total_messages":     atomic.LoadInt64(&totalMessages),
 		"ip_connections":     getIPConnectionCounts(),
+		"cluster":            metrics["cluster_stats"], // 全部节点汇总
 		"service_metrics":    metrics,
 	})
 }
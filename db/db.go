@@ -5,6 +5,7 @@ import (
 	"log"
 	"nasa-go-admin/pkg/config"
 	"nasa-go-admin/pkg/monitoring"
+	"nasa-go-admin/pkg/tenant"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -77,6 +78,11 @@ func Init() {
 		log.Fatalf("db connection error is %s", err.Error())
 	}
 
+	// 注册租户隔离插件
+	if err := openDb.Use(tenant.NewPlugin()); err != nil {
+		log.Fatalf("register tenant plugin error is %s", err.Error())
+	}
+
 	dbCon, err := openDb.DB()
 	if err != nil {
 		log.Fatalf("openDb.DB error is  %s", err.Error())
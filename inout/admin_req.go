@@ -61,6 +61,7 @@ type AddMenuReq struct {
 	Title     string `form:"title"`
 	Layout    string `form:"layout"`
 	KeepAlive int    `form:"keepAlive"`
+	Method    string `form:"method"` // 接口权限的请求方法（GET/POST/PUT/DELETE/*），菜单不填
 }
 
 type AddArticleReq struct {
@@ -119,6 +120,7 @@ type UpdateMenuReq struct {
 	Type     string `form:"type" binding:"required"`
 	Show     int    `form:"show"`
 	Sort     int    `form:"sort"`
+	Method   string `form:"method"` // 接口权限的请求方法（GET/POST/PUT/DELETE/*），菜单不填
 }
 
 type SettingReq struct {
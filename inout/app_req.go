@@ -12,12 +12,27 @@ type LoginAppReq struct {
 }
 
 type RechargeReq struct {
-	Amount float64 `form:"amount" binding:"required"`
+	Amount float64 `form:"amount" json:"amount" binding:"required,gt=0,lte=50000"`
+}
+
+type RechargeResp struct {
+	OrderNo       string            `json:"order_no"`       // 充值单号
+	Amount        float64           `json:"amount"`         // 充值金额
+	Status        string            `json:"status"`         // 充值状态
+	PaymentMethod string            `json:"payment_method"` // 支付渠道
+	PrepayID      string            `json:"prepay_id"`      // 预支付交易会话标识
+	PayParams     map[string]string `json:"pay_params"`     // 前端调起支付参数
+}
+
+type RechargeStatusReq struct {
+	OrderNo string `form:"order_no" binding:"required"`
 }
 
 type CreateOrderReq struct {
-	GoodsId int `form:"goods_id" binding:"required"`
-	Num     int `form:"num" binding:"required"`
+	GoodsId    int    `form:"goods_id" binding:"required"`
+	Num        int    `form:"num" binding:"required"`
+	CouponCode string `form:"coupon_code"`                // 优惠券码或推广码，可选
+	UsePoints  int    `form:"use_points" binding:"min=0"` // 使用的积分数，可选
 }
 
 type MyOrderReq struct {
@@ -36,16 +51,19 @@ type MyOrderResp struct {
 }
 
 type OrderItem struct {
-	Id         int     `json:"id"`
-	UserId     int     `json:"user_id"`
-	GoodsId    int     `json:"goods_id"`
-	Num        int     `json:"num"`
-	Amount     float64 `json:"amount"`
-	GoodsName  string  `json:"goods_name"`
-	GoodsPrice float64 `json:"goods_price"`
-	Status     string  `json:"status"`
-	CreateTime string  `json:"create_time"`
-	UpdateTime string  `json:"update_time"`
+	Id             int     `json:"id"`
+	UserId         int     `json:"user_id"`
+	GoodsId        int     `json:"goods_id"`
+	Num            int     `json:"num"`
+	Amount         float64 `json:"amount"`
+	CouponDiscount float64 `json:"coupon_discount"`
+	PointsUsed     int     `json:"points_used"`
+	PointsDiscount float64 `json:"points_discount"`
+	GoodsName      string  `json:"goods_name"`
+	GoodsPrice     float64 `json:"goods_price"`
+	Status         string  `json:"status"`
+	CreateTime     string  `json:"create_time"`
+	UpdateTime     string  `json:"update_time"`
 }
 
 type DetailReq struct {
@@ -53,8 +71,9 @@ type DetailReq struct {
 }
 
 type RefundReq struct {
-	OrderId int    `form:"order_id" binding:"required"`
-	Reason  string `form:"reason" binding:"required"`
+	OrderId int      `form:"order_id" json:"order_id" binding:"required"`
+	Reason  string   `form:"reason" json:"reason" binding:"required,max=500"`
+	Images  []string `form:"images" json:"images" binding:"max=9"` // 凭证图片
 }
 
 type UpdateUserAppReq struct {
@@ -0,0 +1,103 @@
+package inout
+
+import "time"
+
+// ========== 优惠券模板管理 ==========
+
+// CreateCouponTemplateReq 创建优惠券模板请求
+type CreateCouponTemplateReq struct {
+	Name           string  `json:"name" binding:"required"`
+	Description    string  `json:"description"`
+	CouponType     string  `json:"coupon_type" binding:"required,oneof=fixed percent"`
+	Value          float64 `json:"value" binding:"required,gt=0"`
+	MaxDiscount    float64 `json:"max_discount" binding:"min=0"`
+	Threshold      float64 `json:"threshold" binding:"min=0"`
+	FirstOrderOnly bool    `json:"first_order_only"`
+	Scope          string  `json:"scope" binding:"omitempty,oneof=all goods room"`
+	ScopeIDs       []int   `json:"scope_ids"`
+	PromoCode      string  `json:"promo_code" binding:"omitempty,alphanum,max=32"`
+	ValidFrom      string  `json:"valid_from"` // 格式：2006-01-02 15:04:05
+	ValidTo        string  `json:"valid_to"`
+	ValidDays      int     `json:"valid_days" binding:"min=0,max=3650"`
+	TotalLimit     int     `json:"total_limit" binding:"min=0"`
+	PerUserLimit   int     `json:"per_user_limit" binding:"min=0"`
+}
+
+// UpdateCouponTemplateReq 更新优惠券模板请求
+type UpdateCouponTemplateReq struct {
+	ID int `json:"id" binding:"required"`
+	CreateCouponTemplateReq
+	IsActive bool `json:"is_active"`
+}
+
+// CouponTemplateListReq 优惠券模板列表请求
+type CouponTemplateListReq struct {
+	Page       int    `json:"page" form:"page" binding:"min=1"`
+	PageSize   int    `json:"page_size" form:"page_size" binding:"min=1,max=100"`
+	Name       string `json:"name" form:"name"`
+	Scope      string `json:"scope" form:"scope"`
+	CouponType string `json:"coupon_type" form:"coupon_type"`
+	IsActive   *bool  `json:"is_active" form:"is_active"`
+}
+
+// IssueCouponReq 向会员发放优惠券请求
+type IssueCouponReq struct {
+	TemplateID int   `json:"template_id" binding:"required"`
+	UserIDs    []int `json:"user_ids" binding:"required,min=1,max=500"`
+	Quantity   int   `json:"quantity" binding:"omitempty,min=1,max=10"` // 每人发放张数，默认1
+}
+
+// IssueCouponResp 发放结果
+type IssueCouponResp struct {
+	TemplateID int            `json:"template_id"`
+	Issued     int            `json:"issued"`
+	Failed     map[int]string `json:"failed,omitempty"` // 发放失败的用户及原因
+}
+
+// UserCouponListReq 用户优惠券列表请求
+// 管理端按模板/用户查询，用户端只能查询自己的券
+type UserCouponListReq struct {
+	Page       int `json:"page" form:"page" binding:"min=1"`
+	PageSize   int `json:"page_size" form:"page_size" binding:"min=1,max=100"`
+	TemplateID int `json:"template_id" form:"template_id"`
+	UserID     int `json:"user_id" form:"user_id"`
+	Status     int `json:"status" form:"status"`
+}
+
+// CouponListResp 优惠券列表响应
+type CouponListResp struct {
+	Total    int64       `json:"total"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"page_size"`
+	List     interface{} `json:"list"`
+}
+
+// ========== 用户端 ==========
+
+// ClaimCouponReq 凭推广码领取优惠券请求
+type ClaimCouponReq struct {
+	PromoCode string `json:"promo_code" binding:"required"`
+}
+
+// UserCouponItem 用户优惠券
+type UserCouponItem struct {
+	ID             int        `json:"id"`
+	TemplateID     int        `json:"template_id"`
+	CouponCode     string     `json:"coupon_code"`
+	Name           string     `json:"name"`
+	Description    string     `json:"description"`
+	CouponType     string     `json:"coupon_type"`
+	Value          float64    `json:"value"`
+	MaxDiscount    float64    `json:"max_discount"`
+	Threshold      float64    `json:"threshold"`
+	FirstOrderOnly bool       `json:"first_order_only"`
+	Scope          string     `json:"scope"`
+	Status         int        `json:"status"`
+	StatusText     string     `json:"status_text"`
+	ValidFrom      time.Time  `json:"valid_from"`
+	ValidTo        time.Time  `json:"valid_to"`
+	OrderType      string     `json:"order_type,omitempty"`
+	OrderNo        string     `json:"order_no,omitempty"`
+	DiscountAmount float64    `json:"discount_amount"`
+	UsedTime       *time.Time `json:"used_time,omitempty"`
+}
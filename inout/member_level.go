@@ -0,0 +1,102 @@
+package inout
+
+import "time"
+
+// ========== 会员等级管理 ==========
+
+// CreateMemberLevelReq 创建会员等级请求
+type CreateMemberLevelReq struct {
+	Name       string  `json:"name" binding:"required,max=50"`
+	MinSpend   float64 `json:"min_spend" binding:"min=0"`
+	PointsRate float64 `json:"points_rate" binding:"omitempty,gt=0,max=10"` // 积分倍率，默认1
+	Benefits   string  `json:"benefits"`
+	Icon       string  `json:"icon"`
+}
+
+// UpdateMemberLevelReq 更新会员等级请求
+type UpdateMemberLevelReq struct {
+	ID int `json:"id" binding:"required"`
+	CreateMemberLevelReq
+	IsActive bool `json:"is_active"`
+}
+
+// ========== 积分管理 ==========
+
+// AdjustPointsReq 后台调整积分请求
+type AdjustPointsReq struct {
+	UserID int    `json:"user_id" binding:"required"`
+	Points int    `json:"points" binding:"required,ne=0"` // 正数增加，负数扣减
+	Remark string `json:"remark" binding:"required,max=200"`
+}
+
+// PointsLogListReq 积分流水列表请求
+type PointsLogListReq struct {
+	Page       int    `json:"page" form:"page" binding:"min=1"`
+	PageSize   int    `json:"page_size" form:"page_size" binding:"min=1,max=100"`
+	UserID     int    `json:"user_id" form:"user_id"`
+	ChangeType string `json:"change_type" form:"change_type"`
+	BizNo      string `json:"biz_no" form:"biz_no"`
+	StartDate  string `json:"start_date" form:"start_date"` // 格式：2006-01-02
+	EndDate    string `json:"end_date" form:"end_date"`
+}
+
+// PointsLogItem 积分流水
+type PointsLogItem struct {
+	ID             int64     `json:"id"`
+	UserID         int       `json:"user_id"`
+	ChangeType     string    `json:"change_type"`
+	ChangeTypeText string    `json:"change_type_text"`
+	Points         int       `json:"points"`
+	BalanceAfter   int       `json:"balance_after"`
+	BizType        string    `json:"biz_type"`
+	BizNo          string    `json:"biz_no"`
+	Amount         float64   `json:"amount"`
+	OperatorID     int       `json:"operator_id,omitempty"`
+	Remark         string    `json:"remark"`
+	CreateTime     time.Time `json:"create_time"`
+}
+
+// MemberListResp 会员等级/积分相关列表响应
+type MemberListResp struct {
+	Total    int64       `json:"total"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"page_size"`
+	List     interface{} `json:"list"`
+}
+
+// MemberAccountResp 会员账户信息
+type MemberAccountResp struct {
+	UserID        int     `json:"user_id"`
+	LevelID       int     `json:"level_id"`
+	LevelName     string  `json:"level_name"`
+	LevelIcon     string  `json:"level_icon"`
+	Benefits      string  `json:"benefits"`
+	PointsRate    float64 `json:"points_rate"`
+	TotalSpend    float64 `json:"total_spend"`
+	Points        int     `json:"points"`
+	PointsValue   float64 `json:"points_value"` // 可用积分可抵扣的金额
+	TotalEarned   int     `json:"total_earned"`
+	TotalRedeemed int     `json:"total_redeemed"`
+
+	// 下一等级
+	NextLevelName  string  `json:"next_level_name,omitempty"`
+	NextLevelSpend float64 `json:"next_level_spend,omitempty"` // 距下一等级还需消费金额
+}
+
+// ========== 充值赠送规则 ==========
+
+// CreateRechargeBonusRuleReq 创建充值赠送规则请求
+type CreateRechargeBonusRuleReq struct {
+	Name        string  `json:"name" binding:"required,max=50"`
+	MinAmount   float64 `json:"min_amount" binding:"required,gt=0"`
+	BonusAmount float64 `json:"bonus_amount" binding:"required,gt=0"`
+	ValidFrom   string  `json:"valid_from"` // 格式：2006-01-02 15:04:05
+	ValidTo     string  `json:"valid_to"`
+}
+
+// UpdateRechargeBonusRuleReq 更新充值赠送规则请求
+type UpdateRechargeBonusRuleReq struct {
+	ID int `json:"id" binding:"required"`
+	CreateRechargeBonusRuleReq
+	IsActive bool `json:"is_active"`
+}
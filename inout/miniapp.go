@@ -17,3 +17,104 @@ type WxMsgResponse struct {
 	ErrCode int    `json:"errcode"`
 	ErrMsg  string `json:"errmsg"`
 }
+
+// ========== 订阅消息模板配置 ==========
+
+// CreateSubscribeTemplateReq 创建订阅消息模板请求
+// Fields 为模板关键词到表达式的映射，表达式用 {{对象.字段|格式}} 引用事件数据，如 {"thing1": "{{room.room_name}}"}
+type CreateSubscribeTemplateReq struct {
+	Event      string            `json:"event" binding:"required"`       // 业务事件
+	TemplateID string            `json:"template_id" binding:"required"` // 微信模板ID
+	Title      string            `json:"title"`                          // 模板标题
+	Page       string            `json:"page"`                           // 落地页路径，支持表达式
+	Fields     map[string]string `json:"fields" binding:"required"`      // 关键词映射
+	Status     *int              `json:"status" binding:"omitempty,oneof=0 1"`
+	Remark     string            `json:"remark"`
+}
+
+// UpdateSubscribeTemplateReq 更新订阅消息模板请求
+type UpdateSubscribeTemplateReq struct {
+	ID         int               `json:"id" binding:"required"`
+	Event      string            `json:"event" binding:"required"`
+	TemplateID string            `json:"template_id" binding:"required"`
+	Title      string            `json:"title"`
+	Page       string            `json:"page"`
+	Fields     map[string]string `json:"fields" binding:"required"`
+	Status     int               `json:"status" binding:"oneof=0 1"`
+	Remark     string            `json:"remark"`
+}
+
+// SubscribeTemplateListReq 订阅消息模板列表请求
+type SubscribeTemplateListReq struct {
+	Page     int    `json:"page" form:"page" binding:"min=1"`
+	PageSize int    `json:"page_size" form:"page_size" binding:"min=1,max=100"`
+	Event    string `json:"event" form:"event"`
+	Status   *int   `json:"status" form:"status"`
+}
+
+// SubscribeTemplateListResp 订阅消息模板列表响应
+type SubscribeTemplateListResp struct {
+	Total    int64                   `json:"total"`
+	Page     int                     `json:"page"`
+	PageSize int                     `json:"page_size"`
+	List     []SubscribeTemplateItem `json:"list"`
+}
+
+// SubscribeTemplateItem 订阅消息模板
+type SubscribeTemplateItem struct {
+	ID         int               `json:"id"`
+	Event      string            `json:"event"`
+	EventName  string            `json:"event_name"`
+	TemplateID string            `json:"template_id"`
+	Title      string            `json:"title"`
+	Page       string            `json:"page"`
+	Fields     map[string]string `json:"fields"`
+	Status     int               `json:"status"`
+	Remark     string            `json:"remark"`
+	CreateTime string            `json:"create_time"`
+	UpdateTime string            `json:"update_time"`
+}
+
+// SubscribeEventItem 可配置的业务事件
+type SubscribeEventItem struct {
+	Event      string   `json:"event"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`     // 表达式可引用的数据对象
+	Configured bool     `json:"configured"` // 是否已配置启用的模板
+}
+
+// PreviewSubscribeTemplateReq 预览订阅消息请求
+// 传 id 预览已保存的模板，否则按 event/page/fields 预览未保存的配置；
+// 传 biz_no 时使用真实业务数据，传 payload 时使用自定义数据，都不传时使用示例数据
+type PreviewSubscribeTemplateReq struct {
+	ID      int                    `json:"id"`
+	Event   string                 `json:"event"`
+	Page    string                 `json:"page"`
+	Fields  map[string]string      `json:"fields"`
+	BizNo   string                 `json:"biz_no"`
+	Payload map[string]interface{} `json:"payload"`
+}
+
+// PreviewSubscribeTemplateResp 预览订阅消息响应
+type PreviewSubscribeTemplateResp struct {
+	Event      string                       `json:"event"`
+	TemplateID string                       `json:"template_id"`
+	Page       string                       `json:"page"`
+	Data       map[string]map[string]string `json:"data"`
+	Missing    []string                     `json:"missing"` // 取值为空的表达式
+}
+
+// SendSubscribeEventReq 按业务事件发送订阅消息请求，接收人为业务单据所属用户
+type SendSubscribeEventReq struct {
+	Event string `json:"event" binding:"required"`
+	BizNo string `json:"biz_no" binding:"required"` // 订单号或预订单号
+}
+
+// SendSubscribeEventResp 按业务事件发送订阅消息响应
+type SendSubscribeEventResp struct {
+	Event      string                       `json:"event"`
+	UserID     int                          `json:"user_id"`
+	TemplateID string                       `json:"template_id"`
+	Page       string                       `json:"page"`
+	Data       map[string]map[string]string `json:"data"`
+}
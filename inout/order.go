@@ -36,3 +36,45 @@ type OrderListItem struct {
 	UpdateTime string  `json:"update_time"` // 更新时间
 	CouponId   int     `json:"coupon_id"`   // 优惠券ID
 }
+
+type OrderRefundListReq struct {
+	Page     int    `form:"page"`      // 页码
+	PageSize int    `form:"page_size"` // 每页数量
+	Status   string `form:"status"`    // 申请状态 0待审核 1已同意 2已拒绝
+	No       string `form:"no"`        // 订单号
+}
+
+type OrderRefundListResp struct {
+	Items    []OrderRefundItem `json:"items"`     // 退款申请列表
+	Total    int64             `json:"total"`     // 总记录数
+	Page     int               `json:"page"`      // 当前页码
+	PageSize int               `json:"page_size"` // 每页数量
+}
+
+type OrderRefundItem struct {
+	Id           int      `json:"id"`            // 退款申请ID
+	OrderId      int      `json:"order_id"`      // 订单ID
+	No           string   `json:"no"`            // 订单号
+	UserId       int      `json:"user_id"`       // 用户ID
+	GoodsId      int      `json:"goods_id"`      // 商品ID
+	GoodsName    string   `json:"goods_name"`    // 商品名称
+	Amount       float64  `json:"amount"`        // 退款金额
+	Reason       string   `json:"reason"`        // 退款原因
+	Images       []string `json:"images"`        // 凭证图片
+	Status       string   `json:"status"`        // 申请状态
+	StatusText   string   `json:"status_text"`   // 申请状态文本
+	RejectReason string   `json:"reject_reason"` // 拒绝原因
+	AuditBy      *int     `json:"audit_by"`      // 审核人ID
+	AuditTime    string   `json:"audit_time"`    // 审核时间
+	CreateTime   string   `json:"create_time"`   // 申请时间
+}
+
+type ApproveRefundReq struct {
+	Id     int    `json:"id" binding:"required"` // 退款申请ID
+	Remark string `json:"remark"`                // 审核备注
+}
+
+type RejectRefundReq struct {
+	Id     int    `json:"id" binding:"required"`             // 退款申请ID
+	Reason string `json:"reason" binding:"required,max=500"` // 拒绝原因
+}
@@ -60,4 +60,8 @@ type MemberListItem struct {
 	CreateTime string `json:"create_time"`
 	// 更新时间
 	UpdateTime string `json:"update_time"`
+	// 会员等级与积分
+	LevelName  string  `json:"level_name"`
+	Points     int     `json:"points"`
+	TotalSpend float64 `json:"total_spend"`
 }
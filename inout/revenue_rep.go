@@ -18,6 +18,16 @@ type RevenueRepItems struct {
 	TotalRevenue  float64 `json:"total_revenue"`
 	ActualRevenue float64 `json:"actual_revenue"`
 	PaidOrders    int     `json:"paid_orders"`
+	RefundAmount  float64 `json:"refund_amount"`
+	// 房间预订
+	BookingCount    int     `json:"booking_count"`
+	PaidBookings    int     `json:"paid_bookings"`
+	BookingRevenue  float64 `json:"booking_revenue"`
+	BookingRefund   float64 `json:"booking_refund"`
+	BookingHours    int     `json:"booking_hours"`
+	PackageDiscount float64 `json:"package_discount"`
+	// 商品与预订合计实收
+	CombinedRevenue float64 `json:"combined_revenue"`
 }
 
 type RevenueRep struct {
@@ -26,3 +36,98 @@ type RevenueRep struct {
 	Page     int               `json:"page"`
 	PageSize int               `json:"page_size"`
 }
+
+// RevenueDashboardReq 收入看板请求
+type RevenueDashboardReq struct {
+	Period string `form:"period" binding:"omitempty,oneof=day week month year"` // 默认 day
+	Date   string `form:"date"`                                                 // 周期内任意一天，格式：2006-01-02，默认今天
+}
+
+// RevenueDashboardResp 收入看板，商品订单和房间预订两条收入线合并展示
+type RevenueDashboardResp struct {
+	Period      string                `json:"period"`
+	PeriodStart string                `json:"period_start"`
+	PeriodEnd   string                `json:"period_end"`
+	Summary     RevenueSummary        `json:"summary"`
+	Goods       GoodsRevenueSummary   `json:"goods"`
+	Booking     BookingRevenueSummary `json:"booking"`
+	Trend       []RevenueTrendItem    `json:"trend"`
+	GoodsItems  []GoodsRevenueItem    `json:"goods_items"`
+	RoomItems   []RoomRevenueItem     `json:"room_items"`
+	Packages    []PackageRevenueItem  `json:"packages"`
+}
+
+// RevenueSummary 收入合计
+type RevenueSummary struct {
+	TotalRevenue  float64 `json:"total_revenue"`
+	RefundAmount  float64 `json:"refund_amount"`
+	ActualRevenue float64 `json:"actual_revenue"`
+}
+
+// GoodsRevenueSummary 商品订单收入
+type GoodsRevenueSummary struct {
+	TotalOrders    int     `json:"total_orders"`
+	PaidOrders     int     `json:"paid_orders"`
+	RefundedOrders int     `json:"refunded_orders"`
+	ItemsSold      int     `json:"items_sold"`
+	TotalRevenue   float64 `json:"total_revenue"`
+	RefundAmount   float64 `json:"refund_amount"`
+	ActualRevenue  float64 `json:"actual_revenue"`
+}
+
+// BookingRevenueSummary 房间预订收入
+type BookingRevenueSummary struct {
+	TotalBookings    int     `json:"total_bookings"`
+	PaidBookings     int     `json:"paid_bookings"`
+	RefundedBookings int     `json:"refunded_bookings"`
+	BookingHours     int     `json:"booking_hours"`
+	TotalRevenue     float64 `json:"total_revenue"`
+	RefundAmount     float64 `json:"refund_amount"`
+	ActualRevenue    float64 `json:"actual_revenue"`
+	PackageDiscount  float64 `json:"package_discount"`
+}
+
+// RevenueTrendItem 按天收入趋势
+type RevenueTrendItem struct {
+	StatDate       string  `json:"stat_date"`
+	GoodsRevenue   float64 `json:"goods_revenue"`
+	BookingRevenue float64 `json:"booking_revenue"`
+	ActualRevenue  float64 `json:"actual_revenue"`
+}
+
+// GoodsRevenueItem 商品收入明细
+type GoodsRevenueItem struct {
+	GoodsId      int     `json:"goods_id"`
+	GoodsName    string  `json:"goods_name"`
+	OrderCount   int     `json:"order_count"`
+	SoldCount    int     `json:"sold_count"`
+	Revenue      float64 `json:"revenue"`
+	RefundCount  int     `json:"refund_count"`
+	RefundAmount float64 `json:"refund_amount"`
+}
+
+// RoomRevenueItem 房间收入明细
+type RoomRevenueItem struct {
+	RoomId          int     `json:"room_id"`
+	RoomName        string  `json:"room_name"`
+	BookingCount    int     `json:"booking_count"`
+	BookingHours    int     `json:"booking_hours"`
+	Revenue         float64 `json:"revenue"`
+	PackageDiscount float64 `json:"package_discount"`
+	RefundCount     int     `json:"refund_count"`
+	RefundAmount    float64 `json:"refund_amount"`
+}
+
+// PackageRevenueItem 套餐收入明细，PackageId 为 0 表示按小时计费的预订
+type PackageRevenueItem struct {
+	RoomId          int     `json:"room_id"`
+	RoomName        string  `json:"room_name"`
+	PackageId       int     `json:"package_id"`
+	PackageName     string  `json:"package_name"`
+	BookingCount    int     `json:"booking_count"`
+	BookingHours    int     `json:"booking_hours"`
+	Revenue         float64 `json:"revenue"`
+	PackageDiscount float64 `json:"package_discount"`
+	RefundCount     int     `json:"refund_count"`
+	RefundAmount    float64 `json:"refund_amount"`
+}
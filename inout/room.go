@@ -1,6 +1,7 @@
 package inout
 
 import (
+	"nasa-go-admin/model/app_model"
 	"time"
 )
 
@@ -18,6 +19,10 @@ type CreateRoomReq struct {
 	Floor       int     `json:"floor" binding:"min=1,max=50"`
 	Area        float64 `json:"area" binding:"min=0"`
 	Description string  `json:"description"`
+	CleaningMin int     `json:"cleaning_min" binding:"min=0,max=240"`
+	// 超时计费单位和免费宽限，不传时分别默认30分钟和10分钟，计费单位传0表示不收取超时费
+	OvertimeUnitMin  *int `json:"overtime_unit_min" binding:"omitempty,min=0,max=240"`
+	OvertimeGraceMin *int `json:"overtime_grace_min" binding:"omitempty,min=0,max=120"`
 }
 
 // UpdateRoomReq 更新房间请求
@@ -34,6 +39,10 @@ type UpdateRoomReq struct {
 	Area        float64 `json:"area" binding:"min=0"`
 	Description string  `json:"description"`
 	Status      int     `json:"status" binding:"oneof=1 2 3 4"`
+	CleaningMin int     `json:"cleaning_min" binding:"min=0,max=240"`
+	// 超时计费单位和免费宽限，不传时保持不变，计费单位传0表示不收取超时费
+	OvertimeUnitMin  *int `json:"overtime_unit_min" binding:"omitempty,min=0,max=240"`
+	OvertimeGraceMin *int `json:"overtime_grace_min" binding:"omitempty,min=0,max=120"`
 }
 
 // RoomListReq 房间列表请求
@@ -67,6 +76,22 @@ type CreateBookingReq struct {
 	ContactName  string `json:"contact_name" binding:"required"`
 	ContactPhone string `json:"contact_phone" binding:"required"`
 	Remarks      string `json:"remarks"`
+	CouponCode   string `json:"coupon_code"`                // 优惠券码或推广码，可选
+	UsePoints    int    `json:"use_points" binding:"min=0"` // 使用的积分数，可选
+}
+
+// AdminCreateBookingReq 前台代客预订请求，user_id 为0表示散客
+type AdminCreateBookingReq struct {
+	UserID       int    `json:"user_id" binding:"min=0"`
+	RoomID       int    `json:"room_id" binding:"required"`
+	StartTime    string `json:"start_time"` // 为空表示立即开始
+	Hours        int    `json:"hours" binding:"required,min=1,max=168"`
+	PackageID    *int   `json:"package_id"`
+	ContactName  string `json:"contact_name" binding:"required"`
+	ContactPhone string `json:"contact_phone" binding:"required"`
+	Remarks      string `json:"remarks"`
+	PayMethod    string `json:"pay_method" binding:"required,oneof=wallet offline unpaid"` // wallet:会员钱包扣款 offline:前台线下收款 unpaid:会员在小程序自行支付
+	CheckIn      bool   `json:"check_in"`                                                  // 创建后立即办理入住
 }
 
 // UpdateBookingReq 更新预订请求
@@ -79,6 +104,7 @@ type UpdateBookingReq struct {
 	ContactName  string `json:"contact_name" binding:"required"`
 	ContactPhone string `json:"contact_phone" binding:"required"`
 	Remarks      string `json:"remarks"`
+	SettleMethod string `json:"settle_method" binding:"omitempty,oneof=wallet offline"` // 差价结算方式，默认会员走钱包、散客线下
 }
 
 // BookingListReq 预订列表请求
@@ -91,6 +117,7 @@ type BookingListReq struct {
 	StartDate string `json:"start_date" form:"start_date"`
 	EndDate   string `json:"end_date" form:"end_date"`
 	Keyword   string `json:"keyword" form:"keyword"`
+	SeriesID  int    `json:"series_id" form:"series_id"` // 指定周期预订系列时逐条列出各次预订，否则同一系列合并为一项
 }
 
 // CheckAvailabilityReq 检查房间可用性请求
@@ -129,16 +156,47 @@ type BookingStatusInfoReq struct {
 
 // ========== 房间使用记录相关请求 ==========
 
-// CheckInReq 入住请求
+// CheckInReq 入住请求，booking_id 和 booking_no 二选一，扫描预订二维码时传 booking_no
 type CheckInReq struct {
-	BookingID int `json:"booking_id" binding:"required"`
+	BookingID int    `json:"booking_id"`
+	BookingNo string `json:"booking_no"`
 }
 
-// CheckOutReq 退房请求
+// CheckOutReq 退房请求，booking_id 和 booking_no 二选一
 type CheckOutReq struct {
-	BookingID int     `json:"booking_id" binding:"required"`
-	ExtraFee  float64 `json:"extra_fee" binding:"min=0"`
-	Remarks   string  `json:"remarks"`
+	BookingID  int     `json:"booking_id"`
+	BookingNo  string  `json:"booking_no"`
+	ExtraFee   float64 `json:"extra_fee" binding:"min=0"`                               // 超时费之外的其他额外费用
+	ChargeMode string  `json:"charge_mode" binding:"omitempty,oneof=wallet receivable"` // 额外费用收取方式，默认 wallet，余额不足时自动挂账
+	Remarks    string  `json:"remarks"`
+}
+
+// SettleUsageFeeReq 挂账费用结算请求
+type SettleUsageFeeReq struct {
+	BookingID int    `json:"booking_id" binding:"required"`
+	Method    string `json:"method" binding:"required,oneof=wallet offline"` // wallet:从钱包扣款 offline:线下已收款
+	Remarks   string `json:"remarks"`
+}
+
+// ExtendBookingReq 预订续时请求
+type ExtendBookingReq struct {
+	BookingID int `json:"booking_id" binding:"required"`
+	Hours     int `json:"hours" binding:"required,min=1,max=24"`
+}
+
+// TransferRoomReq 使用中换房请求
+type TransferRoomReq struct {
+	BookingID    int    `json:"booking_id" binding:"required"`
+	RoomID       int    `json:"room_id" binding:"required"`                             // 目标房间ID
+	SettleMethod string `json:"settle_method" binding:"omitempty,oneof=wallet offline"` // 差价结算方式，默认会员走钱包、散客线下
+	Remarks      string `json:"remarks"`
+}
+
+// VerifyBookingEntryReq 入场核验请求，token（扫描二维码）和 code（数字核验码）二选一
+type VerifyBookingEntryReq struct {
+	RoomID int    `json:"room_id" binding:"required"`
+	Token  string `json:"token"`
+	Code   string `json:"code" binding:"omitempty,len=6,numeric"`
 }
 
 // UsageLogListReq 使用记录列表请求
@@ -163,22 +221,25 @@ type RoomListResp struct {
 
 // RoomDetail 房间详情
 type RoomDetail struct {
-	ID           int       `json:"id"`
-	RoomNumber   string    `json:"room_number"`
-	RoomName     string    `json:"room_name"`
-	RoomType     string    `json:"room_type"`
-	RoomTypeText string    `json:"room_type_text"`
-	Capacity     int       `json:"capacity"`
-	HourlyRate   float64   `json:"hourly_rate"`
-	Features     []string  `json:"features"`
-	Images       []string  `json:"images"`
-	Status       int       `json:"status"`
-	StatusText   string    `json:"status_text"`
-	Floor        int       `json:"floor"`
-	Area         float64   `json:"area"`
-	Description  string    `json:"description"`
-	CreateTime   time.Time `json:"create_time"`
-	UpdateTime   time.Time `json:"update_time"`
+	ID               int       `json:"id"`
+	RoomNumber       string    `json:"room_number"`
+	RoomName         string    `json:"room_name"`
+	RoomType         string    `json:"room_type"`
+	RoomTypeText     string    `json:"room_type_text"`
+	Capacity         int       `json:"capacity"`
+	HourlyRate       float64   `json:"hourly_rate"`
+	Features         []string  `json:"features"`
+	Images           []string  `json:"images"`
+	Status           int       `json:"status"`
+	StatusText       string    `json:"status_text"`
+	Floor            int       `json:"floor"`
+	Area             float64   `json:"area"`
+	Description      string    `json:"description"`
+	CleaningMin      int       `json:"cleaning_min"`
+	OvertimeUnitMin  int       `json:"overtime_unit_min"`
+	OvertimeGraceMin int       `json:"overtime_grace_min"`
+	CreateTime       time.Time `json:"create_time"`
+	UpdateTime       time.Time `json:"update_time"`
 
 	// 扩展信息
 	IsAvailable    bool           `json:"is_available"`
@@ -216,6 +277,9 @@ type BookingDetail struct {
 	OriginalPrice  float64 `json:"original_price"`
 	PackagePrice   float64 `json:"package_price"`
 	DiscountAmount float64 `json:"discount_amount"`
+	CouponDiscount float64 `json:"coupon_discount"`
+	PointsUsed     int     `json:"points_used"`
+	PointsDiscount float64 `json:"points_discount"`
 	PriceBreakdown string  `json:"price_breakdown"`
 
 	CreateTime time.Time `json:"create_time"`
@@ -225,6 +289,13 @@ type BookingDetail struct {
 	Room        *RoomDetail    `json:"room,omitempty"`
 	UserInfo    *UserInfo      `json:"user_info,omitempty"`
 	PackageInfo *PackageDetail `json:"package_info,omitempty"`
+
+	// 入场凭证，仅用户端预订详情返回
+	EntryCredential *BookingEntryCredential `json:"entry_credential,omitempty"`
+
+	// 周期预订，预订列表中同一系列合并为一项时附带系列汇总
+	SeriesID *int                 `json:"series_id"`
+	Series   *BookingSeriesDetail `json:"series,omitempty"`
 }
 
 // UserInfo 用户信息
@@ -248,6 +319,7 @@ type AvailabilityResp struct {
 	DayType        string          `json:"day_type"`
 	DayTypeText    string          `json:"day_type_text"`
 	PriceBreakdown *PriceBreakdown `json:"price_breakdown,omitempty"`
+	CanWaitlist    bool            `json:"can_waitlist"` // 时段已被预订时可登记候补
 }
 
 // UsageLogListResp 使用记录列表响应
@@ -301,6 +373,8 @@ type CreatePackageReq struct {
 	MinHours    int     `json:"min_hours" binding:"min=1,max=24"`
 	MaxHours    int     `json:"max_hours" binding:"min=1,max=168"`
 	BasePrice   float64 `json:"base_price" binding:"min=0"`
+	MinCharge   float64 `json:"min_charge" binding:"min=0"` // 最低消费，0表示不限
+	MaxCharge   float64 `json:"max_charge" binding:"min=0"` // 封顶价格，0表示不限
 	Priority    int     `json:"priority"`
 	StartDate   string  `json:"start_date"`
 	EndDate     string  `json:"end_date"`
@@ -317,6 +391,8 @@ type UpdatePackageReq struct {
 	MinHours    int     `json:"min_hours" binding:"min=1,max=24"`
 	MaxHours    int     `json:"max_hours" binding:"min=1,max=168"`
 	BasePrice   float64 `json:"base_price" binding:"min=0"`
+	MinCharge   float64 `json:"min_charge" binding:"min=0"` // 最低消费，0表示不限
+	MaxCharge   float64 `json:"max_charge" binding:"min=0"` // 封顶价格，0表示不限
 	Priority    int     `json:"priority"`
 	StartDate   string  `json:"start_date"`
 	EndDate     string  `json:"end_date"`
@@ -380,6 +456,12 @@ type PriceBreakdown struct {
 	RuleValue      float64 `json:"rule_value"`
 	Adjustment     float64 `json:"adjustment"`
 	FinalTotal     float64 `json:"final_total"`
+
+	// 分段计价明细，按规则和日期类型边界切分
+	LineItems              []app_model.PriceLineItem `json:"line_items,omitempty"`
+	LineItemsTotal         float64                   `json:"line_items_total,omitempty"`  // 分段金额合计
+	ChargeAdjustment       float64                   `json:"charge_adjustment,omitempty"` // 套餐封顶/最低消费调整
+	ChargeAdjustmentReason string                    `json:"charge_adjustment_reason,omitempty"`
 }
 
 // PackageDetail 套餐详情
@@ -394,6 +476,8 @@ type PackageDetail struct {
 	MinHours        int                 `json:"min_hours"`
 	MaxHours        int                 `json:"max_hours"`
 	BasePrice       float64             `json:"base_price"`
+	MinCharge       float64             `json:"min_charge"`
+	MaxCharge       float64             `json:"max_charge"`
 	IsActive        bool                `json:"is_active"`
 	Priority        int                 `json:"priority"`
 	StartDate       *time.Time          `json:"start_date"`
@@ -535,3 +619,643 @@ type BookingStatusInfoResp struct {
 	ShouldAutoStart bool      `json:"should_auto_start"`
 	ShouldAutoEnd   bool      `json:"should_auto_end"`
 }
+
+// PayBookingReq 预订支付请求
+type PayBookingReq struct {
+	BookingID int `json:"booking_id" binding:"required"`
+}
+
+// PayBookingResp 预订支付响应
+type PayBookingResp struct {
+	BookingID    int     `json:"booking_id"`
+	BookingNo    string  `json:"booking_no"`
+	Status       int     `json:"status"`
+	StatusText   string  `json:"status_text"`
+	PaidAmount   float64 `json:"paid_amount"`
+	PaymentID    int     `json:"payment_id"`
+	BalanceAfter float64 `json:"balance_after"`
+}
+
+// ========== 入场凭证相关响应 ==========
+
+// BookingEntryCredential 预订入场凭证
+type BookingEntryCredential struct {
+	QRToken    string     `json:"qr_token"`    // 签名的二维码内容
+	VerifyCode string     `json:"verify_code"` // 6位数字核验码
+	ValidFrom  time.Time  `json:"valid_from"`
+	ValidUntil time.Time  `json:"valid_until"`
+	Verified   bool       `json:"verified"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+}
+
+// VerifyBookingEntryResp 入场核验结果
+type VerifyBookingEntryResp struct {
+	BookingID    int       `json:"booking_id"`
+	BookingNo    string    `json:"booking_no"`
+	RoomID       int       `json:"room_id"`
+	RoomName     string    `json:"room_name"`
+	UserID       int       `json:"user_id"`
+	ContactName  string    `json:"contact_name"`
+	ContactPhone string    `json:"contact_phone"`
+	StartTime    time.Time `json:"start_time"`
+	EndTime      time.Time `json:"end_time"`
+	Status       int       `json:"status"`
+	StatusText   string    `json:"status_text"`
+	Method       string    `json:"method"` // qr_token / verify_code
+	VerifiedAt   time.Time `json:"verified_at"`
+}
+
+// ========== 入住退房与续时相关响应 ==========
+
+// BookingUsageResp 入住/退房结果
+type BookingUsageResp struct {
+	BookingID     int        `json:"booking_id"`
+	BookingNo     string     `json:"booking_no"`
+	RoomID        int        `json:"room_id"`
+	RoomName      string     `json:"room_name"`
+	Status        int        `json:"status"`
+	StatusText    string     `json:"status_text"`
+	StartTime     time.Time  `json:"start_time"`
+	EndTime       time.Time  `json:"end_time"`
+	CheckInAt     time.Time  `json:"check_in_at"`
+	CheckOutAt    *time.Time `json:"check_out_at"`
+	ActualHours   float64    `json:"actual_hours"`
+	OvertimeMin   int        `json:"overtime_min"`
+	BilledMin     int        `json:"billed_min"` // 按计费单位向上取整后的计费分钟数
+	OvertimeFee   float64    `json:"overtime_fee"`
+	ExtraFee      float64    `json:"extra_fee"` // 超时费与其他额外费用合计
+	FeeStatus     string     `json:"fee_status"`
+	FeeStatusText string     `json:"fee_status_text"`
+	FeePaymentID  *int       `json:"fee_payment_id,omitempty"`
+	BalanceAfter  *float64   `json:"balance_after,omitempty"`
+
+	OvertimeItems []app_model.PriceLineItem `json:"overtime_items,omitempty"`
+}
+
+// ExtendBookingResp 预订续时结果
+type ExtendBookingResp struct {
+	BookingID    int                       `json:"booking_id"`
+	BookingNo    string                    `json:"booking_no"`
+	Hours        int                       `json:"hours"`
+	EndTime      time.Time                 `json:"end_time"`
+	ExtendHours  int                       `json:"extend_hours"`
+	ExtendAmount float64                   `json:"extend_amount"`
+	TotalAmount  float64                   `json:"total_amount"`
+	PaidAmount   float64                   `json:"paid_amount"`
+	PaymentID    int                       `json:"payment_id"`
+	BalanceAfter float64                   `json:"balance_after"`
+	LineItems    []app_model.PriceLineItem `json:"line_items"`
+}
+
+// AdminBookingResp 前台代客预订、修改预订和换房结果
+type AdminBookingResp struct {
+	BookingID    int       `json:"booking_id"`
+	BookingNo    string    `json:"booking_no"`
+	UserID       int       `json:"user_id"`
+	RoomID       int       `json:"room_id"`
+	RoomName     string    `json:"room_name"`
+	FromRoomID   int       `json:"from_room_id,omitempty"`
+	FromRoomName string    `json:"from_room_name,omitempty"`
+	Status       int       `json:"status"`
+	StatusText   string    `json:"status_text"`
+	StartTime    time.Time `json:"start_time"`
+	EndTime      time.Time `json:"end_time"`
+	Hours        int       `json:"hours"`
+	TotalAmount  float64   `json:"total_amount"`
+	PaidAmount   float64   `json:"paid_amount"`
+	PayMethod    string    `json:"pay_method,omitempty"`
+	DiffAmount   float64   `json:"diff_amount"` // 差价，正数为补收，负数为退还
+	SettleMethod string    `json:"settle_method,omitempty"`
+	PaymentID    *int      `json:"payment_id,omitempty"`
+	BalanceAfter *float64  `json:"balance_after,omitempty"`
+	CheckedIn    bool      `json:"checked_in"`
+	Message      string    `json:"message,omitempty"`
+}
+
+// ========== 取消退款政策相关请求响应 ==========
+
+// BookingRefundPreviewReq 取消退款预览请求
+type BookingRefundPreviewReq struct {
+	ID int `json:"id" form:"id" binding:"required"`
+}
+
+// BookingRefundQuote 取消退款计算结果
+type BookingRefundQuote struct {
+	BookingID        int     `json:"booking_id"`
+	BookingNo        string  `json:"booking_no"`
+	PaidAmount       float64 `json:"paid_amount"`
+	RefundAmount     float64 `json:"refund_amount"`
+	FeeAmount        float64 `json:"fee_amount"`
+	RefundRule       string  `json:"refund_rule"`
+	RefundRuleText   string  `json:"refund_rule_text"`
+	PolicyID         *int    `json:"policy_id"`
+	PolicyName       string  `json:"policy_name"`
+	PolicyScope      string  `json:"policy_scope"`
+	FreeCancelHours  int     `json:"free_cancel_hours"`
+	FeePercent       float64 `json:"fee_percent"`
+	HoursBeforeStart float64 `json:"hours_before_start"`
+}
+
+// CreateCancelPolicyReq 创建取消政策请求
+type CreateCancelPolicyReq struct {
+	PolicyName      string  `json:"policy_name" binding:"required"`
+	RoomID          *int    `json:"room_id"`
+	PackageID       *int    `json:"package_id"`
+	FreeCancelHours int     `json:"free_cancel_hours" binding:"min=0,max=720"`
+	FeePercent      float64 `json:"fee_percent" binding:"min=0,max=100"`
+	Description     string  `json:"description"`
+}
+
+// UpdateCancelPolicyReq 更新取消政策请求
+type UpdateCancelPolicyReq struct {
+	ID              int     `json:"id" binding:"required"`
+	PolicyName      string  `json:"policy_name" binding:"required"`
+	RoomID          *int    `json:"room_id"`
+	PackageID       *int    `json:"package_id"`
+	FreeCancelHours int     `json:"free_cancel_hours" binding:"min=0,max=720"`
+	FeePercent      float64 `json:"fee_percent" binding:"min=0,max=100"`
+	Description     string  `json:"description"`
+	IsActive        bool    `json:"is_active"`
+}
+
+// CancelPolicyListReq 取消政策列表请求
+type CancelPolicyListReq struct {
+	Page      int `json:"page" form:"page" binding:"min=1"`
+	PageSize  int `json:"page_size" form:"page_size" binding:"min=1,max=100"`
+	RoomID    int `json:"room_id" form:"room_id"`
+	PackageID int `json:"package_id" form:"package_id"`
+}
+
+// CancelPolicyListResp 取消政策列表响应
+type CancelPolicyListResp struct {
+	Total    int64       `json:"total"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"page_size"`
+	List     interface{} `json:"list"`
+}
+
+// ========== 房间日历相关 ==========
+
+// RoomCalendarReq 房间可预订日历请求
+// 指定 room_id 时查询单个房间，否则按 room_type / min_capacity 查询同类房间
+type RoomCalendarReq struct {
+	RoomID      int    `json:"room_id" form:"room_id"`
+	RoomType    string `json:"room_type" form:"room_type"`
+	MinCapacity int    `json:"min_capacity" form:"min_capacity"`
+	StartDate   string `json:"start_date" form:"start_date" binding:"required"` // 2006-01-02
+	EndDate     string `json:"end_date" form:"end_date" binding:"required"`     // 2006-01-02，含当天
+	Hours       int    `json:"hours" form:"hours" binding:"required,min=1,max=24"`
+	SlotMinutes int    `json:"slot_minutes" form:"slot_minutes"` // 可选开始时间间隔，默认60分钟
+	PackageID   *int   `json:"package_id" form:"package_id"`     // 指定套餐时只按该套餐报价
+}
+
+// CalendarInterval 日历时间区间
+type CalendarInterval struct {
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+	Reason    string `json:"reason,omitempty"` // booked/cleaning/maintenance/closed/past
+}
+
+// CalendarSlot 可预订开始时段及报价
+type CalendarSlot struct {
+	StartTime      string  `json:"start_time"`
+	EndTime        string  `json:"end_time"`
+	PackageID      *int    `json:"package_id"`
+	PackageName    string  `json:"package_name"`
+	RuleName       string  `json:"rule_name,omitempty"`
+	DayType        string  `json:"day_type"`
+	OriginalPrice  float64 `json:"original_price"`
+	FinalPrice     float64 `json:"final_price"`
+	DiscountAmount float64 `json:"discount_amount"`
+}
+
+// RoomCalendar 单个房间的日历
+type RoomCalendar struct {
+	RoomID         int                 `json:"room_id"`
+	RoomNumber     string              `json:"room_number"`
+	RoomName       string              `json:"room_name"`
+	RoomType       string              `json:"room_type"`
+	Capacity       int                 `json:"capacity"`
+	HourlyRate     float64             `json:"hourly_rate"`
+	CleaningMin    int                 `json:"cleaning_min"`
+	Status         int                 `json:"status"`
+	StatusText     string              `json:"status_text"`
+	Bookable       bool                `json:"bookable"`
+	UnavailableMsg string              `json:"unavailable_msg,omitempty"`
+	BusyIntervals  []*CalendarInterval `json:"busy_intervals"`
+	FreeIntervals  []*CalendarInterval `json:"free_intervals"`
+	Slots          []*CalendarSlot     `json:"slots"`
+	CheapestSlot   *CalendarSlot       `json:"cheapest_slot,omitempty"`
+}
+
+// RoomCalendarResp 房间可预订日历响应
+type RoomCalendarResp struct {
+	StartDate   string          `json:"start_date"`
+	EndDate     string          `json:"end_date"`
+	Hours       int             `json:"hours"`
+	SlotMinutes int             `json:"slot_minutes"`
+	Rooms       []*RoomCalendar `json:"rooms"`
+}
+
+// SpecialDateItem 导入的特殊日期
+type SpecialDateItem struct {
+	Date        string `json:"date" binding:"required"` // 2006-01-02
+	DateType    string `json:"date_type" binding:"required,oneof=holiday festival special workday"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// SpecialDateImportReq 批量导入年度节假日安排请求
+// format=json 时使用 dates，format=ics 时使用 content（ICS文件内容）
+type SpecialDateImportReq struct {
+	Year    int               `json:"year" binding:"required,min=2000,max=2100"`
+	Format  string            `json:"format" binding:"required,oneof=json ics"`
+	Content string            `json:"content"`
+	Dates   []SpecialDateItem `json:"dates" binding:"dive"`
+	Replace bool              `json:"replace"` // 导入前清除该年已有的法定节假日和调休安排
+}
+
+// SpecialDateImportResp 批量导入结果
+type SpecialDateImportResp struct {
+	Year    int      `json:"year"`
+	Created int      `json:"created"`
+	Updated int      `json:"updated"`
+	Removed int      `json:"removed"`
+	Skipped []string `json:"skipped"`
+}
+
+// ========== 房间利用率分析相关 ==========
+
+// RoomAnalyticsReq 房间利用率分析请求
+type RoomAnalyticsReq struct {
+	StartDate string `form:"start_date" binding:"required"` // 格式：2006-01-02
+	EndDate   string `form:"end_date" binding:"required"`
+	RoomType  string `form:"room_type"`
+	Floor     *int   `form:"floor"`
+	OpenHour  int    `form:"open_hour" binding:"min=0,max=23"`  // 每日营业开始小时，默认0
+	CloseHour int    `form:"close_hour" binding:"min=0,max=24"` // 每日营业结束小时，默认24
+}
+
+// RoomAnalyticsResp 房间利用率分析
+type RoomAnalyticsResp struct {
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	OpenHour  int    `json:"open_hour"`
+	CloseHour int    `json:"close_hour"`
+
+	Summary RoomUtilizationItem    `json:"summary"`
+	Rooms   []*RoomUtilizationItem `json:"rooms"`
+	Types   []*RoomUtilizationItem `json:"types"`
+	Floors  []*RoomUtilizationItem `json:"floors"`
+
+	// Heatmap[周几][小时] 占用率(%)，周几 0 为周日
+	Heatmap [7][24]float64 `json:"heatmap"`
+
+	PaidBookings   int     `json:"paid_bookings"`     // 开始时间已到的已支付预订
+	NoShowBookings int     `json:"no_show_bookings"`  // 其中未办理入住且未核验入场的预订
+	NoShowRate     float64 `json:"no_show_rate"`      // 爽约率(%)
+	AvgLeadHours   float64 `json:"avg_lead_hours"`    // 下单到开始的平均提前小时数
+	NetRevenue     float64 `json:"net_revenue"`       // 扣除退款后的预订收入
+	RevPerRoomHour float64 `json:"rev_per_room_hour"` // 每可用房间小时收入
+}
+
+// RoomUtilizationItem 利用率统计行，按房间、房型或楼层汇总
+type RoomUtilizationItem struct {
+	RoomID         int     `json:"room_id,omitempty"`
+	RoomName       string  `json:"room_name,omitempty"`
+	RoomType       string  `json:"room_type,omitempty"`
+	Floor          int     `json:"floor,omitempty"`
+	RoomCount      int     `json:"room_count"`
+	OpenHours      float64 `json:"open_hours"`     // 可用房间小时数
+	OccupiedHours  float64 `json:"occupied_hours"` // 实际占用小时数
+	Utilization    float64 `json:"utilization"`    // 利用率(%)
+	BookingCount   int     `json:"booking_count"`
+	NetRevenue     float64 `json:"net_revenue"`
+	RevPerRoomHour float64 `json:"rev_per_room_hour"`
+}
+
+// ========== 周期预订相关 ==========
+
+// BookingRecurrence 重复规则，字段含义同 RRULE 的 FREQ/INTERVAL/BYDAY/UNTIL/COUNT
+type BookingRecurrence struct {
+	Freq     string   `json:"freq" binding:"required,oneof=daily weekly"`
+	Interval int      `json:"interval" binding:"min=0,max=12"` // 重复间隔，默认1
+	ByDay    []string `json:"by_day"`                          // 每周重复的星期 MO/TU/WE/TH/FR/SA/SU，默认首次预订的星期
+	Until    string   `json:"until"`                           // 截止日期 YYYY-MM-DD（含），与 count 至少指定一个
+	Count    int      `json:"count" binding:"min=0"`           // 重复次数（含首次）
+}
+
+// CreateBookingSeriesReq 创建周期预订请求
+type CreateBookingSeriesReq struct {
+	RoomID        int               `json:"room_id" binding:"required"`
+	StartTime     string            `json:"start_time" binding:"required"` // 首次开始时间
+	Hours         int               `json:"hours" binding:"required,min=1,max=168"`
+	PackageID     *int              `json:"package_id"`
+	ContactName   string            `json:"contact_name" binding:"required"`
+	ContactPhone  string            `json:"contact_phone" binding:"required"`
+	Remarks       string            `json:"remarks"`
+	Recurrence    BookingRecurrence `json:"recurrence" binding:"required"`
+	SkipConflicts bool              `json:"skip_conflicts"` // 跳过冲突日期只预订可用的日期，否则有冲突时整体不创建
+}
+
+// SeriesOccurrence 周期预订中的单次预订
+type SeriesOccurrence struct {
+	Index         int       `json:"index"`
+	StartTime     time.Time `json:"start_time"`
+	EndTime       time.Time `json:"end_time"`
+	Available     bool      `json:"available"`
+	Reason        string    `json:"reason,omitempty"` // 不可预订原因
+	OriginalPrice float64   `json:"original_price"`
+	TotalAmount   float64   `json:"total_amount"`
+	BookingID     int       `json:"booking_id,omitempty"`
+	BookingNo     string    `json:"booking_no,omitempty"`
+}
+
+// BookingSeriesPreviewResp 周期预订预览响应
+type BookingSeriesPreviewResp struct {
+	Rule          string              `json:"rule"`
+	Occurrences   []*SeriesOccurrence `json:"occurrences"`
+	Total         int                 `json:"total"`
+	ConflictCount int                 `json:"conflict_count"`
+	TotalAmount   float64             `json:"total_amount"` // 可预订日期的合计金额
+}
+
+// CreateBookingSeriesResp 创建周期预订响应
+type CreateBookingSeriesResp struct {
+	Series  *BookingSeriesDetail `json:"series"`
+	Booked  []*SeriesOccurrence  `json:"booked"`
+	Skipped []*SeriesOccurrence  `json:"skipped"`
+}
+
+// BookingSeriesDetail 周期预订系列详情
+type BookingSeriesDetail struct {
+	ID           int        `json:"id"`
+	SeriesNo     string     `json:"series_no"`
+	RoomID       int        `json:"room_id"`
+	UserID       int        `json:"user_id"`
+	Rule         string     `json:"rule"`
+	Freq         string     `json:"freq"`
+	Interval     int        `json:"interval"`
+	ByDay        string     `json:"by_day"`
+	Until        *time.Time `json:"until"`
+	Count        int        `json:"count"`
+	StartTime    time.Time  `json:"start_time"`
+	Hours        int        `json:"hours"`
+	PackageID    *int       `json:"package_id"`
+	Occurrences  int        `json:"occurrences"`
+	TotalAmount  float64    `json:"total_amount"`
+	Status       int        `json:"status"`
+	StatusText   string     `json:"status_text"`
+	ContactName  string     `json:"contact_name"`
+	ContactPhone string     `json:"contact_phone"`
+	Remarks      string     `json:"remarks"`
+	CreateTime   time.Time  `json:"create_time"`
+
+	// 各状态预订数
+	PendingCount   int        `json:"pending_count"`
+	ActiveCount    int        `json:"active_count"` // 已支付及使用中
+	CompletedCount int        `json:"completed_count"`
+	CancelledCount int        `json:"cancelled_count"` // 已取消及已退款
+	NextStartTime  *time.Time `json:"next_start_time"`
+
+	// 各次预订，仅系列详情返回
+	Bookings []BookingDetail `json:"bookings,omitempty"`
+}
+
+// CancelBookingSeriesReq 取消周期预订请求，指定 booking_id 时只取消该次预订
+type CancelBookingSeriesReq struct {
+	SeriesID  int    `json:"series_id" binding:"required"`
+	BookingID int    `json:"booking_id"`
+	Reason    string `json:"reason"`
+}
+
+// CancelBookingSeriesResp 取消周期预订响应
+type CancelBookingSeriesResp struct {
+	Cancelled    int                   `json:"cancelled"`
+	RefundAmount float64               `json:"refund_amount"`
+	Refunds      []*BookingRefundQuote `json:"refunds"`
+	Failed       []string              `json:"failed,omitempty"`
+}
+
+// PayBookingSeriesReq 周期预订支付请求，一次支付系列中全部待支付预订
+type PayBookingSeriesReq struct {
+	SeriesID int `json:"series_id" binding:"required"`
+}
+
+// PayBookingSeriesResp 周期预订支付响应
+type PayBookingSeriesResp struct {
+	Paid       int               `json:"paid"`
+	PaidAmount float64           `json:"paid_amount"`
+	Payments   []*PayBookingResp `json:"payments"`
+	Failed     []string          `json:"failed,omitempty"`
+}
+
+// ========== 预订候补相关 ==========
+
+// JoinWaitlistReq 登记候补请求，room_id 与 room_type 至少指定一个，只指定房型时同房型任意房间空出即可
+type JoinWaitlistReq struct {
+	RoomID       int    `json:"room_id"`
+	RoomType     string `json:"room_type"`
+	WindowStart  string `json:"window_start" binding:"required"` // 期望时间窗口开始 YYYY-MM-DD HH:mm:ss
+	WindowEnd    string `json:"window_end" binding:"required"`   // 期望时间窗口结束
+	Hours        int    `json:"hours" binding:"required,min=1,max=24"`
+	ContactName  string `json:"contact_name" binding:"required"`
+	ContactPhone string `json:"contact_phone" binding:"required"`
+	Remarks      string `json:"remarks"`
+}
+
+// WaitlistActionReq 候补操作请求
+type WaitlistActionReq struct {
+	ID int `json:"id" binding:"required"`
+}
+
+// WaitlistListReq 候补列表请求
+type WaitlistListReq struct {
+	Page      int    `json:"page" form:"page" binding:"min=1"`
+	PageSize  int    `json:"page_size" form:"page_size" binding:"min=1,max=100"`
+	Status    int    `json:"status" form:"status"`
+	RoomID    int    `json:"room_id" form:"room_id"`
+	RoomType  string `json:"room_type" form:"room_type"`
+	UserID    int    `json:"user_id" form:"user_id"`
+	StartDate string `json:"start_date" form:"start_date"` // 按时间窗口开始日期筛选
+	EndDate   string `json:"end_date" form:"end_date"`
+}
+
+// WaitlistItem 候补详情
+type WaitlistItem struct {
+	ID           int       `json:"id"`
+	UserID       int       `json:"user_id"`
+	RoomID       int       `json:"room_id"`
+	RoomName     string    `json:"room_name"`
+	RoomType     string    `json:"room_type"`
+	RoomTypeText string    `json:"room_type_text"`
+	WindowStart  time.Time `json:"window_start"`
+	WindowEnd    time.Time `json:"window_end"`
+	Hours        int       `json:"hours"`
+	ContactName  string    `json:"contact_name"`
+	ContactPhone string    `json:"contact_phone"`
+	Remarks      string    `json:"remarks"`
+	Status       int       `json:"status"`
+	StatusText   string    `json:"status_text"`
+	Position     int       `json:"position,omitempty"` // 候补中时的排队位置
+	CreateTime   time.Time `json:"create_time"`
+
+	// 已保留时段
+	OfferBookingID *int       `json:"offer_booking_id"`
+	OfferBookingNo string     `json:"offer_booking_no,omitempty"`
+	OfferStartTime *time.Time `json:"offer_start_time,omitempty"`
+	OfferEndTime   *time.Time `json:"offer_end_time,omitempty"`
+	OfferAmount    float64    `json:"offer_amount,omitempty"`
+	OfferedAt      *time.Time `json:"offered_at"`
+	OfferExpireAt  *time.Time `json:"offer_expire_at,omitempty"`
+}
+
+// WaitlistListResp 候补列表响应
+type WaitlistListResp struct {
+	Total    int64           `json:"total"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"page_size"`
+	List     []*WaitlistItem `json:"list"`
+}
+
+// WaitlistDemandReq 候补需求统计请求
+type WaitlistDemandReq struct {
+	StartDate string `json:"start_date" form:"start_date" binding:"required"` // 按时间窗口开始日期统计
+	EndDate   string `json:"end_date" form:"end_date" binding:"required"`
+	RoomType  string `json:"room_type" form:"room_type"`
+}
+
+// WaitlistDemandItem 候补需求统计行，room_id 为0时表示按房型候补
+type WaitlistDemandItem struct {
+	RoomID         int     `json:"room_id"`
+	RoomName       string  `json:"room_name"`
+	RoomType       string  `json:"room_type"`
+	Total          int     `json:"total"`
+	Waiting        int     `json:"waiting"`
+	Offered        int     `json:"offered"`
+	Confirmed      int     `json:"confirmed"`
+	Expired        int     `json:"expired"`
+	Cancelled      int     `json:"cancelled"`
+	Users          int     `json:"users"`           // 候补用户数
+	DemandHours    int     `json:"demand_hours"`    // 候补需求小时数
+	ConversionRate float64 `json:"conversion_rate"` // 候补转化率(%)：已确认/已保留过
+}
+
+// WaitlistDemandResp 候补需求统计响应
+type WaitlistDemandResp struct {
+	Summary WaitlistDemandItem    `json:"summary"`
+	Rooms   []*WaitlistDemandItem `json:"rooms"`
+}
+
+// ========== 房间维护/停业时段 ==========
+
+// CreateRoomBlackoutReq 创建维护/停业时段请求
+type CreateRoomBlackoutReq struct {
+	RoomID    int    `json:"room_id"`    // 房间ID，0表示全场停业
+	TenantsId int    `json:"tenants_id"` // 全场停业时指定商家，仅超级管理员需要传
+	Type      string `json:"type" binding:"required,oneof=maintenance closed"`
+	Reason    string `json:"reason" binding:"required,max=100"`
+	StartTime string `json:"start_time" binding:"required"` // 开始时间 YYYY-MM-DD HH:mm:ss
+	EndTime   string `json:"end_time" binding:"required"`
+}
+
+// UpdateRoomBlackoutReq 更新维护/停业时段请求，不可修改适用房间
+type UpdateRoomBlackoutReq struct {
+	ID        int    `json:"id" binding:"required"`
+	Type      string `json:"type" binding:"required,oneof=maintenance closed"`
+	Reason    string `json:"reason" binding:"required,max=100"`
+	StartTime string `json:"start_time" binding:"required"`
+	EndTime   string `json:"end_time" binding:"required"`
+}
+
+// RoomBlackoutListReq 维护/停业时段列表请求
+type RoomBlackoutListReq struct {
+	Page      int    `json:"page" form:"page" binding:"min=1"`
+	PageSize  int    `json:"page_size" form:"page_size" binding:"min=1,max=100"`
+	RoomID    *int   `json:"room_id" form:"room_id"` // 传0只查询全场停业
+	Type      string `json:"type" form:"type"`
+	Status    int    `json:"status" form:"status"`
+	StartDate string `json:"start_date" form:"start_date"` // 查询与日期范围重叠的时段
+	EndDate   string `json:"end_date" form:"end_date"`
+}
+
+// RoomBlackoutItem 维护/停业时段信息
+type RoomBlackoutItem struct {
+	ID            int       `json:"id"`
+	TenantsId     int       `json:"tenants_id"`
+	RoomID        int       `json:"room_id"`
+	RoomName      string    `json:"room_name"`
+	Type          string    `json:"type"`
+	TypeText      string    `json:"type_text"`
+	Reason        string    `json:"reason"`
+	StartTime     time.Time `json:"start_time"`
+	EndTime       time.Time `json:"end_time"`
+	Status        int       `json:"status"`
+	StatusText    string    `json:"status_text"`
+	OperatorID    int       `json:"operator_id"`
+	AffectedCount int       `json:"affected_count"` // 受影响的有效预订数
+	CreateTime    time.Time `json:"create_time"`
+}
+
+// RoomBlackoutListResp 维护/停业时段列表响应
+type RoomBlackoutListResp struct {
+	Total    int64               `json:"total"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"page_size"`
+	List     []*RoomBlackoutItem `json:"list"`
+}
+
+// BlackoutAffectedBooking 与维护/停业时段重叠的预订
+type BlackoutAffectedBooking struct {
+	BookingID         int       `json:"booking_id"`
+	BookingNo         string    `json:"booking_no"`
+	UserID            int       `json:"user_id"`
+	ContactName       string    `json:"contact_name"`
+	ContactPhone      string    `json:"contact_phone"`
+	RoomID            int       `json:"room_id"`
+	RoomName          string    `json:"room_name"`
+	StartTime         time.Time `json:"start_time"`
+	EndTime           time.Time `json:"end_time"`
+	Status            int       `json:"status"`
+	StatusText        string    `json:"status_text"`
+	PaidAmount        float64   `json:"paid_amount"`
+	CanResolve        bool      `json:"can_resolve"`                   // 是否可改订或退款，使用中的预订需到店处理
+	CandidateRoomID   int       `json:"candidate_room_id,omitempty"`   // 可改订的同类房间
+	CandidateRoomName string    `json:"candidate_room_name,omitempty"` // 没有可改订房间时为空
+	Note              string    `json:"note,omitempty"`
+}
+
+// RoomBlackoutResp 维护/停业时段及受影响的预订
+type RoomBlackoutResp struct {
+	Blackout *RoomBlackoutItem          `json:"blackout"`
+	Affected []*BlackoutAffectedBooking `json:"affected"`
+}
+
+// ResolveBlackoutReq 批量处理受维护/停业影响的预订请求
+type ResolveBlackoutReq struct {
+	ID             int    `json:"id" binding:"required"`                         // 维护/停业时段ID
+	Action         string `json:"action" binding:"required,oneof=rebook refund"` // rebook:改订同类房间 refund:取消并全额退款
+	BookingIDs     []int  `json:"booking_ids"`                                   // 为空时处理全部受影响的预订
+	TargetRoomID   int    `json:"target_room_id"`                                // 改订到指定房间，不指定时自动选择同类房间
+	FallbackRefund bool   `json:"fallback_refund"`                               // 没有可改订房间时改为退款
+}
+
+// BlackoutResolveResult 单个预订的处理结果
+type BlackoutResolveResult struct {
+	BookingID    int     `json:"booking_id"`
+	BookingNo    string  `json:"booking_no"`
+	Result       string  `json:"result"` // rebooked/refunded/failed
+	RoomID       int     `json:"room_id,omitempty"`
+	RoomName     string  `json:"room_name,omitempty"`
+	RefundAmount float64 `json:"refund_amount,omitempty"`
+	Message      string  `json:"message,omitempty"`
+}
+
+// ResolveBlackoutResp 批量处理受维护/停业影响的预订响应
+type ResolveBlackoutResp struct {
+	Rebooked     int                      `json:"rebooked"`
+	Refunded     int                      `json:"refunded"`
+	Failed       int                      `json:"failed"`
+	RefundAmount float64                  `json:"refund_amount"`
+	Results      []*BlackoutResolveResult `json:"results"`
+}
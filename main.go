@@ -123,10 +123,14 @@ func main() {
 
 	// 初始化 MongoDB 客户端
 	mongodb.InitMongoDB()
+	middleware.InitMongoClient()
 
 	// 初始化缓存
 	cache.InitCache()
 
+	// 初始化后台任务选主（多实例部署时每个定时任务只由一个实例执行）
+	app_service.InitLeaderElector(redis.GetClient())
+
 	// 初始化订单安全系统
 	if routerMode == "app" || routerMode == "all" {
 		log.Printf("🔐 初始化订单安全系统...")
@@ -138,6 +142,9 @@ func main() {
 		}
 	}
 
+	// 初始化节假日日历（定价日期类型解析）
+	app_service.InitHolidayCalendar(redis.GetClient())
+
 	// 初始化订单状态自动管理调度器
 	if routerMode == "admin" || routerMode == "all" {
 		log.Printf("📅 启动订单状态自动管理调度器...")
@@ -197,6 +204,9 @@ func main() {
 			healthData["order_system"] = globalOrderSystem.GetSystemStatus()
 		}
 
+		// 添加后台任务leader信息
+		healthData["schedulers"] = app_service.GetLeaderElector().GetStatus()
+
 		c.JSON(http.StatusOK, healthData)
 	})
 
@@ -257,6 +267,14 @@ func main() {
 		globalOrderSystem.Shutdown()
 	}
 
+	// 释放后台任务租约，其他实例立即接管
+	app_service.GetLeaderElector().ResignAll()
+
+	// 关闭WebSocket服务，移除本节点在线记录
+	if routerMode == "app" || routerMode == "all" {
+		public_service.GetWebSocketService().Close()
+	}
+
 	// 关闭HTTP服务器
 	if err := server.Shutdown(ctx); err != nil {
 		log.Printf("服务器强制关闭: %v", err)
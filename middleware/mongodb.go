@@ -3,6 +3,7 @@ package middleware
 import (
 	"context"
 	"log"
+	"sync"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -12,21 +13,28 @@ const (
 	mongoURI = "mongodb://localhost:27017"
 )
 
-var mongoClient *mongo.Client
+var (
+	mongoClient *mongo.Client
+	mongoOnce   sync.Once
+)
 
-func init() {
-	clientOptions := options.Client().ApplyURI(mongoURI)
-	client, err := mongo.Connect(context.Background(), clientOptions)
-	if err != nil {
-		log.Fatalf("Failed to connect to MongoDB: %v", err)
-	}
-	err = client.Ping(context.Background(), nil)
-	if err != nil {
-		log.Fatalf("Failed to ping MongoDB: %v", err)
-	}
-	mongoClient = client
+// InitMongoClient 连接日志使用的 MongoDB，启动时调用以便连接失败时尽早退出
+func InitMongoClient() {
+	mongoOnce.Do(func() {
+		clientOptions := options.Client().ApplyURI(mongoURI)
+		client, err := mongo.Connect(context.Background(), clientOptions)
+		if err != nil {
+			log.Fatalf("Failed to connect to MongoDB: %v", err)
+		}
+		err = client.Ping(context.Background(), nil)
+		if err != nil {
+			log.Fatalf("Failed to ping MongoDB: %v", err)
+		}
+		mongoClient = client
+	})
 }
 
 func GetMongoCollection(databaseName, collectionName string) *mongo.Collection {
+	InitMongoClient()
 	return mongoClient.Database(databaseName).Collection(collectionName)
 }
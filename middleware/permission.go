@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"nasa-go-admin/pkg/response"
+	"nasa-go-admin/services/admin_service"
+	"nasa-go-admin/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// permissionWhitelist 登录后即可访问的接口（前端初始化和个人设置所需），不做接口权限校验
+var permissionWhitelist = map[string]bool{
+	"POST /api/admin/auth/logout":  true,
+	"GET /api/admin/tenants/info":  true,
+	"GET /api/admin/route":         true,
+	"GET /api/admin/menu":          true,
+	"PUT /api/admin/user/profile":  true,
+	"PUT /api/admin/user/password": true,
+}
+
+// APIPermissionMiddleware 接口权限校验中间件
+// 按请求的路由模板和方法匹配角色拥有的接口权限，超级管理员直接放行。
+// 未在权限表登记的接口默认拒绝；灰度上线期间可设置 PERMISSION_STRICT_MODE=false 临时放行未登记接口。
+func APIPermissionMiddleware() gin.HandlerFunc {
+	strictMode := os.Getenv("PERMISSION_STRICT_MODE") != "false"
+	if !strictMode {
+		log.Printf("[WARN] 接口权限严格模式已关闭，未登记权限的管理端接口将被放行")
+	}
+
+	return func(c *gin.Context) {
+		// 超级管理员直接放行
+		if c.GetInt("type") == admin_service.UserTypeAdmin {
+			c.Next()
+			return
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			// 未匹配路由交给 gin 返回 404
+			c.Next()
+			return
+		}
+
+		method := c.Request.Method
+		if permissionWhitelist[method+" "+route] {
+			c.Next()
+			return
+		}
+
+		uid := c.GetInt("uid")
+		if uid == 0 {
+			response.Abort(c, response.AUTH_ERROR, "用户未登录")
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+		defer cancel()
+
+		check, err := admin_service.GetPermissionService().CheckAPIPermission(ctx, uid, method, route, c.Request.URL.Path)
+		if err != nil {
+			log.Printf("[ERROR] 接口权限校验失败 user=%d %s %s: %v", uid, method, route, err)
+			response.Abort(c, response.INTERNAL_ERROR, "权限校验失败")
+			return
+		}
+
+		if check.Allowed {
+			c.Next()
+			return
+		}
+
+		if !check.Declared && !strictMode {
+			c.Next()
+			return
+		}
+
+		recordPermissionDeny(c, uid, check)
+		response.Abort(c, response.FORBIDDEN, "无权限访问该接口")
+	}
+}
+
+// recordPermissionDeny 记录接口权限拒绝日志到 MongoDB
+func recordPermissionDeny(c *gin.Context, uid int, check *admin_service.APIPermissionCheck) {
+	username := ""
+	if userInfo, ok := c.Get("userInfo"); ok {
+		if info, ok := userInfo.(map[string]string); ok {
+			username = info["username"]
+		}
+	}
+
+	denyLog := map[string]interface{}{
+		"user_id":    uid,
+		"username":   username,
+		"user_type":  c.GetInt("type"),
+		"role_id":    check.RoleID,
+		"method":     c.Request.Method,
+		"route":      c.FullPath(),
+		"path":       c.Request.URL.Path,
+		"query":      c.Request.URL.RawQuery,
+		"client_ip":  c.ClientIP(),
+		"user_agent": c.Request.UserAgent(),
+		"declared":   check.Declared,
+		"reason":     check.Reason,
+		"timestamp":  utils.GetCurrentTimeForMongo(),
+	}
+
+	log.Printf("[WARN] 接口权限拒绝 user=%d role=%d %s %s: %s",
+		uid, check.RoleID, c.Request.Method, c.FullPath(), check.Reason)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		collection := GetMongoCollection("admin_log_db", "permission_deny_logs")
+		if _, err := collection.InsertOne(ctx, denyLog); err != nil {
+			log.Printf("[ERROR] 写入权限拒绝日志失败: %v", err)
+		}
+	}()
+}
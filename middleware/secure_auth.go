@@ -6,6 +6,8 @@ import (
 	"nasa-go-admin/pkg/jwt"
 	"nasa-go-admin/pkg/response"
 	"nasa-go-admin/pkg/security"
+	"nasa-go-admin/pkg/tenant"
+	"nasa-go-admin/services/admin_service"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,49 +15,86 @@ import (
 // SecureJWTAuth 安全的JWT认证中间件
 func SecureJWTAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 获取token
-		token := getSecureTokenFromRequest(c)
-		if token == "" {
-			response.Abort(c, response.AUTH_ERROR, "请求未携带token，无权限访问")
+		if _, ok := authenticateSecureToken(c); !ok {
 			return
 		}
+		c.Next()
+	}
+}
 
-		// 验证输入安全性
-		if err := security.ValidateInput(token); err != nil {
-			response.Abort(c, response.AUTH_ERROR, "token包含非法字符")
+// SecureAdminJWTAuth 安全的管理员JWT认证中间件
+// 认证通过后从token解析租户，写入 gin.Context 和 Request.Context，供 GORM 租户插件使用
+func SecureAdminJWTAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := authenticateSecureToken(c)
+		if !ok {
 			return
 		}
 
-		// 使用安全的JWT管理器验证token
-		jwtManager := jwt.NewSecureJWTManager()
-		claims, err := jwtManager.ValidateToken(token)
-
-		if err != nil {
-			var message string
-			switch err {
-			case jwt.ErrTokenInBlacklist:
-				message = "token已被撤销"
-			default:
-				message = err.Error()
+		info := &tenant.Info{
+			UserID:   claims.UID,
+			UserType: claims.TYPE,
+			Bypass:   claims.TYPE == admin_service.UserTypeAdmin,
+		}
+		if !info.Bypass {
+			info.TenantID = claims.TID
+			if info.TenantID == 0 && claims.TYPE == admin_service.UserTypeShop {
+				// 旧token未携带租户，店铺用户的租户即自身
+				info.TenantID = claims.UID
+			}
+			if info.TenantID == 0 {
+				response.Abort(c, response.AUTH_ERROR, "登录信息已过期，请重新登录")
+				return
 			}
-			response.Abort(c, response.AUTH_ERROR, message)
-			return
 		}
 
-		// 将用户信息存储到上下文
-		c.Set("uid", claims.UID)
-		c.Set("rid", claims.RID)
-		c.Set("type", claims.TYPE)
-		c.Set("jti", claims.JTI)
-		c.Set("claims", claims)
+		c.Set(tenant.ContextKey, info)
+		c.Set("tenants_id", info.TenantID)
+		c.Request = c.Request.WithContext(tenant.WithContext(c.Request.Context(), info))
 
 		c.Next()
 	}
 }
 
-// SecureAdminJWTAuth 安全的管理员JWT认证中间件
-func SecureAdminJWTAuth() gin.HandlerFunc {
-	return SecureJWTAuth()
+// authenticateSecureToken 校验token并将用户信息存储到上下文，失败时直接中止请求
+func authenticateSecureToken(c *gin.Context) (*jwt.SecureCustomClaims, bool) {
+	// 获取token
+	token := getSecureTokenFromRequest(c)
+	if token == "" {
+		response.Abort(c, response.AUTH_ERROR, "请求未携带token，无权限访问")
+		return nil, false
+	}
+
+	// 验证输入安全性
+	if err := security.ValidateInput(token); err != nil {
+		response.Abort(c, response.AUTH_ERROR, "token包含非法字符")
+		return nil, false
+	}
+
+	// 使用安全的JWT管理器验证token
+	jwtManager := jwt.NewSecureJWTManager()
+	claims, err := jwtManager.ValidateToken(token)
+
+	if err != nil {
+		var message string
+		switch err {
+		case jwt.ErrTokenInBlacklist:
+			message = "token已被撤销"
+		default:
+			message = err.Error()
+		}
+		response.Abort(c, response.AUTH_ERROR, message)
+		return nil, false
+	}
+
+	// 将用户信息存储到上下文
+	c.Set("uid", claims.UID)
+	c.Set("rid", claims.RID)
+	c.Set("type", claims.TYPE)
+	c.Set("jti", claims.JTI)
+	c.Set("claims", claims)
+
+	return claims, true
 }
 
 // SecureAppJWTAuth 安全的应用JWT认证中间件
@@ -0,0 +1,14 @@
+-- 前台入住退房与超时计费：房间超时计费配置，使用记录增加经办人、超时和额外费用收取状态
+ALTER TABLE `rooms`
+    ADD COLUMN `overtime_unit_min` int(11) NOT NULL DEFAULT 30 COMMENT '超时计费单位(分钟)，0表示不收取超时费' AFTER `cleaning_min`,
+    ADD COLUMN `overtime_grace_min` int(11) NOT NULL DEFAULT 10 COMMENT '超时免费宽限(分钟)' AFTER `overtime_unit_min`;
+
+ALTER TABLE `room_usage_logs`
+    ADD COLUMN `check_in_by` int(11) NOT NULL DEFAULT 0 COMMENT '办理入住的管理员ID，0表示系统自动开始' AFTER `extra_fee`,
+    ADD COLUMN `check_out_by` int(11) NOT NULL DEFAULT 0 COMMENT '办理退房的管理员ID' AFTER `check_in_by`,
+    ADD COLUMN `overtime_min` int(11) NOT NULL DEFAULT 0 COMMENT '超时分钟数' AFTER `check_out_by`,
+    ADD COLUMN `overtime_fee` decimal(10,2) NOT NULL DEFAULT 0.00 COMMENT '超时费用' AFTER `overtime_min`,
+    ADD COLUMN `fee_status` varchar(20) NOT NULL DEFAULT 'none' COMMENT '额外费用状态(none/paid/receivable/settled)' AFTER `overtime_fee`,
+    ADD COLUMN `fee_payment_id` int(11) DEFAULT NULL COMMENT '额外费用钱包流水ID' AFTER `fee_status`,
+    ADD COLUMN `remarks` text COMMENT '备注' AFTER `fee_payment_id`,
+    ADD INDEX `idx_fee_status` (`fee_status`);
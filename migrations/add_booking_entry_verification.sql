@@ -0,0 +1,6 @@
+-- 预订入场凭证：支付时分配6位数字核验码，前台核验二维码或核验码后记录核验时间，同一凭证不能重复使用
+ALTER TABLE `room_bookings`
+    ADD COLUMN `verify_code` varchar(6) NOT NULL DEFAULT '' COMMENT '入场数字核验码' AFTER `points_discount`,
+    ADD COLUMN `verified_at` datetime DEFAULT NULL COMMENT '入场核验时间' AFTER `verify_code`,
+    ADD COLUMN `verified_by` int(11) NOT NULL DEFAULT 0 COMMENT '核验管理员ID' AFTER `verified_at`,
+    ADD INDEX `idx_room_verify_code` (`room_id`, `verify_code`);
@@ -0,0 +1,35 @@
+-- 商家收入统计增加房间预订收入
+-- 商品订单字段保持原含义，预订收入单独记录，看板中合并展示
+ALTER TABLE `merchant_revenue_stats`
+    ADD COLUMN `booking_count` int(11) NOT NULL DEFAULT 0 COMMENT '预订总数' AFTER `items_sold`,
+    ADD COLUMN `paid_bookings` int(11) NOT NULL DEFAULT 0 COMMENT '已支付预订数' AFTER `booking_count`,
+    ADD COLUMN `booking_revenue` decimal(12,2) NOT NULL DEFAULT 0.00 COMMENT '预订收入(元)' AFTER `paid_bookings`,
+    ADD COLUMN `booking_refund` decimal(12,2) NOT NULL DEFAULT 0.00 COMMENT '预订退款总额' AFTER `booking_revenue`,
+    ADD COLUMN `refunded_bookings` int(11) NOT NULL DEFAULT 0 COMMENT '已退款预订数' AFTER `booking_refund`,
+    ADD COLUMN `booking_hours` int(11) NOT NULL DEFAULT 0 COMMENT '预订占用小时数' AFTER `refunded_bookings`,
+    ADD COLUMN `package_discount` decimal(12,2) NOT NULL DEFAULT 0.00 COMMENT '套餐优惠总额' AFTER `booking_hours`;
+
+-- 房间预订收入明细，按房间和套餐分行，未使用套餐的预订 package_id 为 0
+CREATE TABLE IF NOT EXISTS `merchant_room_revenue_details` (
+    `id` int(11) NOT NULL AUTO_INCREMENT,
+    `tenants_id` int(11) NOT NULL COMMENT '商家ID',
+    `stat_date` date NOT NULL COMMENT '统计日期',
+    `room_id` int(11) NOT NULL COMMENT '房间ID',
+    `room_name` varchar(255) NOT NULL DEFAULT '' COMMENT '房间名称',
+    `package_id` int(11) NOT NULL DEFAULT 0 COMMENT '套餐ID，0为按小时计费',
+    `package_name` varchar(255) NOT NULL DEFAULT '' COMMENT '套餐名称',
+    `booking_count` int(11) NOT NULL DEFAULT 0 COMMENT '已支付预订数',
+    `booking_hours` int(11) NOT NULL DEFAULT 0 COMMENT '占用小时数',
+    `revenue` decimal(12,2) NOT NULL DEFAULT 0.00 COMMENT '收入金额',
+    `package_discount` decimal(12,2) NOT NULL DEFAULT 0.00 COMMENT '套餐优惠金额',
+    `refund_count` int(11) NOT NULL DEFAULT 0 COMMENT '退款预订数',
+    `refund_amount` decimal(12,2) NOT NULL DEFAULT 0.00 COMMENT '退款金额',
+    `create_time` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_time` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    KEY `idx_stat_date` (`stat_date`),
+    KEY `idx_merchant_room_date` (`tenants_id`, `stat_date`, `room_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='商家房间预订收入明细';
+
+-- 预订统计按商家和创建日期聚合
+CREATE INDEX idx_room_bookings_tenant_create ON room_bookings(tenants_id, create_time);
@@ -0,0 +1,17 @@
+-- 退款申请审核流程字段
+-- status: 0待审核 1已同意 2已拒绝
+ALTER TABLE order_refud
+    ADD COLUMN tenants_id INT NOT NULL DEFAULT 0 COMMENT '商家ID' AFTER goods_id,
+    ADD COLUMN reason VARCHAR(500) NOT NULL DEFAULT '' COMMENT '退款原因' AFTER tenants_id,
+    ADD COLUMN images TEXT COMMENT '凭证图片(JSON数组)' AFTER reason,
+    ADD COLUMN reject_reason VARCHAR(500) NOT NULL DEFAULT '' COMMENT '拒绝原因' AFTER status,
+    ADD COLUMN audit_by INT NULL COMMENT '审核人ID' AFTER reject_reason,
+    ADD COLUMN audit_time DATETIME NULL COMMENT '审核时间' AFTER audit_by,
+    ADD INDEX idx_tenants_status (tenants_id, status),
+    ADD INDEX idx_order_id (order_id);
+
+-- 回填历史退款申请的商家ID
+UPDATE order_refud r
+    INNER JOIN `order` o ON r.order_id = o.id
+SET r.tenants_id = o.tenants_id
+WHERE r.tenants_id = 0;
@@ -0,0 +1,4 @@
+-- 套餐封顶价和最低消费：分段计价合计后再按此调整，0表示不限
+ALTER TABLE `room_packages`
+    ADD COLUMN `min_charge` decimal(10,2) NOT NULL DEFAULT 0.00 COMMENT '最低消费，0表示不限' AFTER `base_price`,
+    ADD COLUMN `max_charge` decimal(10,2) NOT NULL DEFAULT 0.00 COMMENT '封顶价格，0表示不限' AFTER `min_charge`;
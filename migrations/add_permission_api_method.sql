@@ -0,0 +1,197 @@
+-- 接口权限：permission_user 中 method 非空的记录视为接口权限
+-- path 填写管理端路由模板（可省略 /api/admin 前缀），如 /rooms/:id，支持 /rooms/* 前缀通配
+-- method 填写 GET/POST/PUT/DELETE，* 表示全部方法
+ALTER TABLE permission_user
+    ADD COLUMN method VARCHAR(16) NULL DEFAULT NULL COMMENT '接口请求方法(接口权限)' AFTER path,
+    ADD INDEX idx_method (method);
+
+-- 登记现有全部管理端接口（登录、验证码等免登录接口及 permissionWhitelist 中的接口除外）
+-- 接口权限默认拒绝，未登记的接口只有超级管理员可以访问，新增接口需在此补充登记
+INSERT INTO permission_user (label, title, type, path, method, `show`, enable, sort, create_time, update_time)
+SELECT CONCAT(t.method, ' ', t.path), CONCAT(t.method, ' ', t.path), 'API', t.path, t.method, 0, 1, 0, NOW(), NOW()
+FROM (
+    SELECT 'GET' AS method, '/ai/chatai' AS path
+    UNION ALL SELECT 'POST', '/article/add'
+    UNION ALL SELECT 'DELETE', '/article/delete'
+    UNION ALL SELECT 'GET', '/article/detail'
+    UNION ALL SELECT 'GET', '/article/list'
+    UNION ALL SELECT 'PUT', '/article/update'
+    UNION ALL SELECT 'POST', '/banner/add'
+    UNION ALL SELECT 'DELETE', '/banner/delete/:id'
+    UNION ALL SELECT 'GET', '/banner/detail/:id'
+    UNION ALL SELECT 'GET', '/banner/list'
+    UNION ALL SELECT 'PUT', '/banner/update'
+    UNION ALL SELECT 'GET', '/bookings'
+    UNION ALL SELECT 'POST', '/bookings'
+    UNION ALL SELECT 'PUT', '/bookings'
+    UNION ALL SELECT 'POST', '/bookings/check-in'
+    UNION ALL SELECT 'POST', '/bookings/check-out'
+    UNION ALL SELECT 'POST', '/bookings/extend'
+    UNION ALL SELECT 'GET', '/bookings/logs'
+    UNION ALL SELECT 'GET', '/bookings/logs/statistics'
+    UNION ALL SELECT 'POST', '/bookings/manual-end'
+    UNION ALL SELECT 'POST', '/bookings/manual-start'
+    UNION ALL SELECT 'GET', '/bookings/series/:id'
+    UNION ALL SELECT 'POST', '/bookings/series/cancel'
+    UNION ALL SELECT 'POST', '/bookings/settle-fee'
+    UNION ALL SELECT 'PUT', '/bookings/status'
+    UNION ALL SELECT 'GET', '/bookings/status-info'
+    UNION ALL SELECT 'POST', '/bookings/transfer'
+    UNION ALL SELECT 'POST', '/bookings/verify'
+    UNION ALL SELECT 'GET', '/bookings/waitlist'
+    UNION ALL SELECT 'GET', '/bookings/waitlist/demand'
+    UNION ALL SELECT 'GET', '/captcha/status'
+    UNION ALL SELECT 'PUT', '/captcha/status'
+    UNION ALL SELECT 'POST', '/coupons/issue'
+    UNION ALL SELECT 'GET', '/coupons/templates'
+    UNION ALL SELECT 'POST', '/coupons/templates'
+    UNION ALL SELECT 'PUT', '/coupons/templates'
+    UNION ALL SELECT 'DELETE', '/coupons/templates/:id'
+    UNION ALL SELECT 'GET', '/coupons/user-coupons'
+    UNION ALL SELECT 'POST', '/employee/add'
+    UNION ALL SELECT 'DELETE', '/employee/delete'
+    UNION ALL SELECT 'GET', '/employee/detail/:id'
+    UNION ALL SELECT 'POST', '/employee/group/add'
+    UNION ALL SELECT 'DELETE', '/employee/group/delete'
+    UNION ALL SELECT 'GET', '/employee/group/detail'
+    UNION ALL SELECT 'GET', '/employee/group/list'
+    UNION ALL SELECT 'PUT', '/employee/group/update'
+    UNION ALL SELECT 'GET', '/employee/list'
+    UNION ALL SELECT 'PUT', '/employee/update'
+    UNION ALL SELECT 'GET', '/feishu/get'
+    UNION ALL SELECT 'POST', '/goods/add'
+    UNION ALL SELECT 'POST', '/goods/category/add'
+    UNION ALL SELECT 'DELETE', '/goods/category/delete/:id'
+    UNION ALL SELECT 'GET', '/goods/category/detail/:id'
+    UNION ALL SELECT 'GET', '/goods/category/list'
+    UNION ALL SELECT 'PUT', '/goods/category/update'
+    UNION ALL SELECT 'DELETE', '/goods/delete'
+    UNION ALL SELECT 'GET', '/goods/detail/:id'
+    UNION ALL SELECT 'GET', '/goods/list'
+    UNION ALL SELECT 'PUT', '/goods/update'
+    UNION ALL SELECT 'GET', '/member/accounts/:user_id'
+    UNION ALL SELECT 'GET', '/member/export'
+    UNION ALL SELECT 'GET', '/member/levels'
+    UNION ALL SELECT 'POST', '/member/levels'
+    UNION ALL SELECT 'PUT', '/member/levels'
+    UNION ALL SELECT 'DELETE', '/member/levels/:id'
+    UNION ALL SELECT 'GET', '/member/list'
+    UNION ALL SELECT 'POST', '/member/points/adjust'
+    UNION ALL SELECT 'GET', '/member/points/logs'
+    UNION ALL SELECT 'GET', '/member/recharge-bonus'
+    UNION ALL SELECT 'POST', '/member/recharge-bonus'
+    UNION ALL SELECT 'PUT', '/member/recharge-bonus'
+    UNION ALL SELECT 'DELETE', '/member/recharge-bonus/:id'
+    UNION ALL SELECT 'GET', '/member/stats'
+    UNION ALL SELECT 'POST', '/menu/add'
+    UNION ALL SELECT 'DELETE', '/menu/delete'
+    UNION ALL SELECT 'GET', '/menu/detail/:id'
+    UNION ALL SELECT 'PUT', '/menu/update'
+    UNION ALL SELECT 'POST', '/miniapp/events/send'
+    UNION ALL SELECT 'GET', '/miniapp/templates'
+    UNION ALL SELECT 'POST', '/miniapp/templates'
+    UNION ALL SELECT 'PUT', '/miniapp/templates'
+    UNION ALL SELECT 'DELETE', '/miniapp/templates/:id'
+    UNION ALL SELECT 'GET', '/miniapp/templates/events'
+    UNION ALL SELECT 'POST', '/miniapp/templates/preview'
+    UNION ALL SELECT 'POST', '/news/add'
+    UNION ALL SELECT 'DELETE', '/news/delete'
+    UNION ALL SELECT 'GET', '/news/detail'
+    UNION ALL SELECT 'GET', '/news/list'
+    UNION ALL SELECT 'PUT', '/news/update'
+    UNION ALL SELECT 'GET', '/notification/admin-receive-records'
+    UNION ALL SELECT 'GET', '/notification/admin-receive-stats'
+    UNION ALL SELECT 'DELETE', '/notification/all-offline-messages'
+    UNION ALL SELECT 'GET', '/notification/all-offline-messages'
+    UNION ALL SELECT 'POST', '/notification/batch-mark-read'
+    UNION ALL SELECT 'POST', '/notification/mark-confirmed'
+    UNION ALL SELECT 'POST', '/notification/mark-read'
+    UNION ALL SELECT 'GET', '/notification/messages/:messageID/receive-status'
+    UNION ALL SELECT 'DELETE', '/notification/offline-messages'
+    UNION ALL SELECT 'GET', '/notification/offline-messages'
+    UNION ALL SELECT 'GET', '/notification/online-users'
+    UNION ALL SELECT 'GET', '/notification/records'
+    UNION ALL SELECT 'DELETE', '/notification/records/:id'
+    UNION ALL SELECT 'GET', '/notification/records/:id'
+    UNION ALL SELECT 'POST', '/notification/records/:id/resend'
+    UNION ALL SELECT 'GET', '/notification/stats'
+    UNION ALL SELECT 'GET', '/notification/user-summary'
+    UNION ALL SELECT 'GET', '/order/list'
+    UNION ALL SELECT 'POST', '/order/refund/approve'
+    UNION ALL SELECT 'GET', '/order/refund/list'
+    UNION ALL SELECT 'POST', '/order/refund/reject'
+    UNION ALL SELECT 'GET', '/order/revenue/dashboard'
+    UNION ALL SELECT 'GET', '/order/revenue/list'
+    UNION ALL SELECT 'POST', '/order/revenue/refresh'
+    UNION ALL SELECT 'GET', '/queue/log'
+    UNION ALL SELECT 'POST', '/role/add'
+    UNION ALL SELECT 'GET', '/role/all'
+    UNION ALL SELECT 'DELETE', '/role/delete/:id'
+    UNION ALL SELECT 'GET', '/role/detail'
+    UNION ALL SELECT 'GET', '/role/list'
+    UNION ALL SELECT 'POST', '/role/set/permission'
+    UNION ALL SELECT 'PUT', '/role/update'
+    UNION ALL SELECT 'GET', '/rooms'
+    UNION ALL SELECT 'POST', '/rooms'
+    UNION ALL SELECT 'PUT', '/rooms'
+    UNION ALL SELECT 'DELETE', '/rooms/:id'
+    UNION ALL SELECT 'GET', '/rooms/:id'
+    UNION ALL SELECT 'GET', '/rooms/analytics'
+    UNION ALL SELECT 'GET', '/rooms/analytics/export'
+    UNION ALL SELECT 'GET', '/rooms/blackouts'
+    UNION ALL SELECT 'POST', '/rooms/blackouts'
+    UNION ALL SELECT 'PUT', '/rooms/blackouts'
+    UNION ALL SELECT 'DELETE', '/rooms/blackouts/:id'
+    UNION ALL SELECT 'GET', '/rooms/blackouts/:id/affected'
+    UNION ALL SELECT 'POST', '/rooms/blackouts/resolve'
+    UNION ALL SELECT 'POST', '/rooms/calculate-price'
+    UNION ALL SELECT 'GET', '/rooms/cancel-policies'
+    UNION ALL SELECT 'POST', '/rooms/cancel-policies'
+    UNION ALL SELECT 'PUT', '/rooms/cancel-policies'
+    UNION ALL SELECT 'DELETE', '/rooms/cancel-policies/:id'
+    UNION ALL SELECT 'GET', '/rooms/package-rules'
+    UNION ALL SELECT 'POST', '/rooms/package-rules'
+    UNION ALL SELECT 'PUT', '/rooms/package-rules'
+    UNION ALL SELECT 'DELETE', '/rooms/package-rules/:id'
+    UNION ALL SELECT 'GET', '/rooms/packages'
+    UNION ALL SELECT 'POST', '/rooms/packages'
+    UNION ALL SELECT 'PUT', '/rooms/packages'
+    UNION ALL SELECT 'DELETE', '/rooms/packages/:id'
+    UNION ALL SELECT 'GET', '/rooms/special-dates'
+    UNION ALL SELECT 'POST', '/rooms/special-dates'
+    UNION ALL SELECT 'DELETE', '/rooms/special-dates/:id'
+    UNION ALL SELECT 'POST', '/rooms/special-dates/import'
+    UNION ALL SELECT 'GET', '/rooms/statistics'
+    UNION ALL SELECT 'PUT', '/rooms/status'
+    UNION ALL SELECT 'POST', '/system/dict/type/add'
+    UNION ALL SELECT 'POST', '/system/dict/value/add'
+    UNION ALL SELECT 'GET', '/system/info'
+    UNION ALL SELECT 'POST', '/system/info/add'
+    UNION ALL SELECT 'GET', '/system/info/list'
+    UNION ALL SELECT 'PUT', '/system/info/update'
+    UNION ALL SELECT 'DELETE', '/system/log'
+    UNION ALL SELECT 'GET', '/system/log'
+    UNION ALL SELECT 'POST', '/system/notice'
+    UNION ALL SELECT 'GET', '/system/setting'
+    UNION ALL SELECT 'POST', '/system/setting'
+    UNION ALL SELECT 'DELETE', '/system/setting/delete/:id'
+    UNION ALL SELECT 'GET', '/system/setting/detail'
+    UNION ALL SELECT 'PUT', '/system/setting/update'
+    UNION ALL SELECT 'DELETE', '/system/user/log'
+    UNION ALL SELECT 'GET', '/system/user/log'
+    UNION ALL SELECT 'POST', '/tenants/add'
+    UNION ALL SELECT 'PUT', '/tenants/update'
+    UNION ALL SELECT 'POST', '/upload'
+) t
+WHERE NOT EXISTS (
+    SELECT 1 FROM permission_user p WHERE p.method = t.method AND p.path = t.path
+);
+
+-- 上线时为现有角色分配全部已登记接口，保持上线前的访问范围，之后在角色管理中按需收回
+INSERT INTO role_permissions_permission (roleId, permissionId)
+SELECT r.id, p.id
+FROM role r
+JOIN permission_user p ON p.method IS NOT NULL AND p.method != ''
+WHERE NOT EXISTS (
+    SELECT 1 FROM role_permissions_permission rp WHERE rp.roleId = r.id AND rp.permissionId = p.id
+);
@@ -0,0 +1,5 @@
+-- 钱包充值接入支付渠道
+-- 充值单创建时为 pending，支付回调验签成功后置为 completed，失败置为 failed
+-- 按充值单号 + 交易类型锁定充值单，渠道交易号用于对账
+CREATE INDEX idx_app_recharge_order_type ON app_recharge(order_no, transaction_type);
+CREATE INDEX idx_app_recharge_payment_no ON app_recharge(payment_no);
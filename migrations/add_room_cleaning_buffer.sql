@@ -0,0 +1,3 @@
+-- 房间清洁缓冲时间：预订前后预留的清洁时间，日历和可预订时段计算时视为占用
+ALTER TABLE `rooms`
+    ADD COLUMN `cleaning_min` int(11) NOT NULL DEFAULT 0 COMMENT '预订前后清洁缓冲时间(分钟)' AFTER `status`;
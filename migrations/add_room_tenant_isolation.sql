@@ -0,0 +1,34 @@
+-- 房间、预订、套餐按商家（租户）隔离
+-- tenants_id：店铺用户为自身ID，员工为所属店铺ID，0 表示平台（超级管理员）创建的数据
+ALTER TABLE `rooms`
+    ADD COLUMN `tenants_id` int(11) NOT NULL DEFAULT 0 COMMENT '商家ID' AFTER `id`;
+
+ALTER TABLE `room_bookings`
+    ADD COLUMN `tenants_id` int(11) NOT NULL DEFAULT 0 COMMENT '商家ID' AFTER `id`,
+    ADD INDEX `idx_tenants_id` (`tenants_id`);
+
+ALTER TABLE `room_packages`
+    ADD COLUMN `tenants_id` int(11) NOT NULL DEFAULT 0 COMMENT '商家ID' AFTER `id`,
+    ADD INDEX `idx_tenants_id` (`tenants_id`);
+
+-- 按创建人回填房间所属商家（超级管理员创建的房间保持为 0）
+UPDATE `rooms` r
+    JOIN `user` u ON u.id = r.created_by
+SET r.tenants_id = CASE
+    WHEN u.user_type = 1 THEN 0
+    ELSE COALESCE(NULLIF(u.parent_id, 0), u.id)
+END;
+
+-- 预订和套餐继承房间的商家
+UPDATE `room_bookings` b
+    JOIN `rooms` r ON r.id = b.room_id
+SET b.tenants_id = r.tenants_id;
+
+UPDATE `room_packages` p
+    JOIN `rooms` r ON r.id = p.room_id
+SET p.tenants_id = r.tenants_id;
+
+-- 房间号只需在同一商家内唯一
+ALTER TABLE `rooms`
+    DROP INDEX `uk_room_number`,
+    ADD UNIQUE KEY `uk_tenant_room_number` (`tenants_id`, `room_number`);
@@ -0,0 +1,3 @@
+-- 特殊日期支持调休上班日：调休的周末按工作日定价
+ALTER TABLE `room_special_dates`
+    MODIFY COLUMN `date_type` ENUM('holiday', 'festival', 'special', 'workday') NOT NULL COMMENT '日期类型(holiday/festival/special/workday)';
@@ -0,0 +1,16 @@
+-- 预订通知发送记录：同一预订的同类通知只发送一次，调度器扫描提醒时排除已发送的预订
+CREATE TABLE IF NOT EXISTS `booking_notifications` (
+    `id` bigint NOT NULL AUTO_INCREMENT,
+    `booking_id` int(11) NOT NULL COMMENT '预订ID',
+    `notice_type` varchar(32) NOT NULL COMMENT '通知类型(payment_pending/start_soon/ending_soon/completed)',
+    `user_id` int(11) NOT NULL COMMENT '用户ID',
+    `status` varchar(16) NOT NULL COMMENT '发送结果(sending/sent/failed/skipped)',
+    `channels` varchar(64) NOT NULL DEFAULT '' COMMENT '已送达渠道',
+    `message_id` varchar(64) NOT NULL DEFAULT '' COMMENT '推送记录消息ID',
+    `error_msg` text COMMENT '错误信息',
+    `create_time` datetime DEFAULT CURRENT_TIMESTAMP,
+    `update_time` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_booking_notice` (`booking_id`, `notice_type`),
+    KEY `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='预订通知发送记录';
@@ -0,0 +1,33 @@
+-- 周期预订：按每日/每周重复规则一次生成多次预订，各次预订通过 series_id 归属同一系列
+CREATE TABLE IF NOT EXISTS `room_booking_series` (
+    `id` int(11) NOT NULL AUTO_INCREMENT,
+    `tenants_id` int(11) NOT NULL DEFAULT 0 COMMENT '商家ID',
+    `series_no` varchar(64) NOT NULL COMMENT '系列单号',
+    `room_id` int(11) NOT NULL COMMENT '房间ID',
+    `user_id` int(11) NOT NULL COMMENT '用户ID',
+    `rule` varchar(255) NOT NULL COMMENT '重复规则(RRULE格式)',
+    `freq` varchar(16) NOT NULL COMMENT '重复频率(daily/weekly)',
+    `interval` int(11) NOT NULL DEFAULT 1 COMMENT '重复间隔',
+    `by_day` varchar(32) NOT NULL DEFAULT '' COMMENT '每周重复的星期(MO,TU...)',
+    `until` datetime DEFAULT NULL COMMENT '重复截止日期',
+    `count` int(11) NOT NULL DEFAULT 0 COMMENT '重复次数',
+    `start_time` datetime NOT NULL COMMENT '首次开始时间',
+    `hours` int(11) NOT NULL COMMENT '每次预订小时数',
+    `package_id` int(11) DEFAULT NULL COMMENT '使用的套餐ID',
+    `occurrences` int(11) NOT NULL COMMENT '生成的预订次数',
+    `total_amount` decimal(10,2) NOT NULL COMMENT '各次预订总金额',
+    `status` tinyint(4) NOT NULL DEFAULT 1 COMMENT '系列状态(1:进行中,2:已取消)',
+    `contact_name` varchar(64) NOT NULL DEFAULT '' COMMENT '联系人姓名',
+    `contact_phone` varchar(32) NOT NULL DEFAULT '' COMMENT '联系人电话',
+    `remarks` text COMMENT '备注信息',
+    `create_time` datetime DEFAULT CURRENT_TIMESTAMP,
+    `update_time` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_series_no` (`series_no`),
+    KEY `idx_tenants_id` (`tenants_id`),
+    KEY `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='周期预订系列';
+
+ALTER TABLE `room_bookings`
+    ADD COLUMN `series_id` int(11) DEFAULT NULL COMMENT '周期预订系列ID' AFTER `remarks`,
+    ADD INDEX `idx_series_id` (`series_id`);
@@ -0,0 +1,30 @@
+-- 预订候补：时段释放时按登记顺序为候补用户生成待支付预订保留时段
+CREATE TABLE IF NOT EXISTS `booking_waitlists` (
+    `id` int(11) NOT NULL AUTO_INCREMENT,
+    `tenants_id` int(11) NOT NULL DEFAULT 0 COMMENT '商家ID，按房型候补在分配房间前为0',
+    `user_id` int(11) NOT NULL COMMENT '用户ID',
+    `room_id` int(11) NOT NULL DEFAULT 0 COMMENT '候补房间ID，0表示同房型任意房间',
+    `room_type` varchar(64) NOT NULL DEFAULT '' COMMENT '候补房间类型',
+    `window_start` datetime NOT NULL COMMENT '期望时间窗口开始',
+    `window_end` datetime NOT NULL COMMENT '期望时间窗口结束',
+    `hours` int(11) NOT NULL COMMENT '预订小时数',
+    `contact_name` varchar(64) NOT NULL DEFAULT '' COMMENT '联系人姓名',
+    `contact_phone` varchar(32) NOT NULL DEFAULT '' COMMENT '联系人电话',
+    `remarks` text COMMENT '备注信息',
+    `status` tinyint(4) NOT NULL DEFAULT 1 COMMENT '候补状态(1:候补中,2:已保留,3:已确认,4:已失效,5:已取消)',
+    `offer_booking_id` int(11) DEFAULT NULL COMMENT '保留时段生成的待支付预订ID',
+    `offered_at` datetime DEFAULT NULL COMMENT '保留时间',
+    `create_time` datetime DEFAULT CURRENT_TIMESTAMP,
+    `update_time` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    KEY `idx_tenants_id` (`tenants_id`),
+    KEY `idx_user_id` (`user_id`),
+    KEY `idx_room_id` (`room_id`),
+    KEY `idx_offer_booking_id` (`offer_booking_id`),
+    KEY `idx_status_room_type` (`status`, `room_type`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='预订候补';
+
+-- 候补保留通知模板，在微信后台申请模板后填写模板ID并启用
+INSERT IGNORE INTO `wx_subscribe_template` (`event`, `template_id`, `title`, `page`, `fields`, `status`) VALUES
+('booking_waitlist_offer', '', '候补时段已保留', 'pages/booking/detail?id={{booking.id}}',
+ '{"thing1":"{{room.room_name}}","time2":"{{booking.start_time|datetime}}","amount3":"{{booking.total_amount|amount}}","time4":"{{notice.pay_deadline|datetime}}","thing5":"{{notice.tip}}"}', 0);
@@ -0,0 +1,63 @@
+-- 优惠券模板表
+-- tenants_id 为 0 表示平台券，可在所有商家使用；scope_ids 为空表示适用范围内全部商品/房间可用
+CREATE TABLE coupon_templates (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    tenants_id INT NOT NULL DEFAULT 0 COMMENT '商家ID，0为平台券',
+    name VARCHAR(100) NOT NULL COMMENT '优惠券名称',
+    description TEXT COMMENT '使用说明',
+    coupon_type VARCHAR(20) NOT NULL COMMENT '优惠类型(fixed/percent)',
+    value DECIMAL(10,2) NOT NULL COMMENT '优惠值，fixed为减免金额，percent为折扣百分比(如85表示85折)',
+    max_discount DECIMAL(10,2) DEFAULT 0 COMMENT '折扣券最高减免金额，0表示不限',
+    threshold DECIMAL(10,2) DEFAULT 0 COMMENT '使用门槛金额，0表示无门槛',
+    first_order_only TINYINT(1) DEFAULT 0 COMMENT '是否仅限首单',
+    scope VARCHAR(20) DEFAULT 'all' COMMENT '适用范围(all/goods/room)',
+    scope_ids VARCHAR(1000) DEFAULT NULL COMMENT '适用的商品或房间ID，逗号分隔',
+    promo_code VARCHAR(32) DEFAULT NULL COMMENT '推广码，用户凭码领取',
+    valid_from DATETIME NULL COMMENT '有效期开始',
+    valid_to DATETIME NULL COMMENT '有效期结束',
+    valid_days INT DEFAULT 0 COMMENT '领取后N天内有效，0表示使用模板有效期',
+    total_limit INT DEFAULT 0 COMMENT '发放总量，0表示不限',
+    per_user_limit INT DEFAULT 1 COMMENT '每人限领张数，0表示不限',
+    issued_count INT DEFAULT 0 COMMENT '已发放数量',
+    used_count INT DEFAULT 0 COMMENT '已使用数量',
+    is_active TINYINT(1) DEFAULT 1 COMMENT '是否启用',
+    create_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    update_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_promo_code (promo_code),
+    INDEX idx_tenants_id (tenants_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='优惠券模板';
+
+-- 用户优惠券表
+CREATE TABLE user_coupons (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    tenants_id INT NOT NULL DEFAULT 0 COMMENT '商家ID，与模板一致',
+    template_id INT NOT NULL COMMENT '优惠券模板ID',
+    user_id INT NOT NULL COMMENT '用户ID',
+    coupon_code VARCHAR(32) NOT NULL COMMENT '券码',
+    status TINYINT DEFAULT 1 COMMENT '状态(1:未使用,2:已使用,3:已过期,4:已作废)',
+    source VARCHAR(20) DEFAULT 'issue' COMMENT '来源(issue:后台发放,promo:推广码领取)',
+    valid_from DATETIME NOT NULL COMMENT '生效时间',
+    valid_to DATETIME NOT NULL COMMENT '失效时间',
+    order_type VARCHAR(20) DEFAULT '' COMMENT '使用的订单类型(goods/booking)',
+    order_no VARCHAR(64) DEFAULT '' COMMENT '使用的订单号',
+    discount_amount DECIMAL(10,2) DEFAULT 0 COMMENT '实际抵扣金额',
+    used_time DATETIME NULL COMMENT '使用时间',
+    returned_time DATETIME NULL COMMENT '最近一次退回时间',
+    create_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    update_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_coupon_code (coupon_code),
+    INDEX idx_template_user (template_id, user_id),
+    INDEX idx_user_status (user_id, status),
+    INDEX idx_order (order_type, order_no),
+    INDEX idx_tenants_id (tenants_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='用户优惠券';
+
+-- 订单和预订记录优惠券抵扣金额
+ALTER TABLE `order`
+    ADD COLUMN `coupon_discount` decimal(10,2) NOT NULL DEFAULT 0.00 COMMENT '优惠券抵扣金额' AFTER `amount`;
+
+ALTER TABLE `room_bookings`
+    ADD COLUMN `user_coupon_id` int(11) NULL COMMENT '使用的用户优惠券ID' AFTER `price_breakdown`,
+    ADD COLUMN `coupon_discount` decimal(10,2) NOT NULL DEFAULT 0.00 COMMENT '优惠券抵扣金额' AFTER `user_coupon_id`;
@@ -0,0 +1,75 @@
+-- 会员等级表
+-- 会员按累计消费金额自动定级，points_rate 为该等级的积分倍率
+CREATE TABLE member_levels (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(50) NOT NULL COMMENT '等级名称',
+    min_spend DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT '累计消费门槛',
+    points_rate DECIMAL(5,2) NOT NULL DEFAULT 1 COMMENT '积分倍率',
+    benefits TEXT COMMENT '等级权益说明',
+    icon VARCHAR(255) DEFAULT '' COMMENT '等级图标',
+    is_active TINYINT(1) DEFAULT 1 COMMENT '是否启用',
+    create_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    update_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_min_spend (min_spend)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='会员等级';
+
+-- 会员账户表，用户首次消费或调整积分时创建
+CREATE TABLE member_accounts (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL COMMENT '用户ID',
+    level_id INT NOT NULL DEFAULT 0 COMMENT '会员等级ID，0为未达到任何等级',
+    total_spend DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT '累计消费金额',
+    points INT NOT NULL DEFAULT 0 COMMENT '可用积分',
+    total_earned INT NOT NULL DEFAULT 0 COMMENT '累计获得积分',
+    total_redeemed INT NOT NULL DEFAULT 0 COMMENT '累计使用积分',
+    create_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    update_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_user_id (user_id),
+    INDEX idx_level_id (level_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='会员账户';
+
+-- 积分流水表，只新增不修改
+CREATE TABLE member_points_logs (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL COMMENT '用户ID',
+    change_type VARCHAR(20) NOT NULL COMMENT '变动类型(earn/redeem/return/revoke/adjust)',
+    points INT NOT NULL COMMENT '变动积分，正数增加负数减少',
+    balance_after INT NOT NULL COMMENT '变动后积分余额',
+    biz_type VARCHAR(20) DEFAULT '' COMMENT '业务类型(goods/booking)',
+    biz_no VARCHAR(64) DEFAULT '' COMMENT '业务单号',
+    amount DECIMAL(12,2) DEFAULT 0 COMMENT '关联金额(消费金额或抵扣金额)',
+    idempotency_key VARCHAR(128) NULL COMMENT '幂等键',
+    operator_id INT DEFAULT 0 COMMENT '操作人ID(后台调整)',
+    remark VARCHAR(255) DEFAULT '' COMMENT '备注',
+    create_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_idempotency_key (idempotency_key),
+    INDEX idx_user_time (user_id, create_time),
+    INDEX idx_biz (biz_type, biz_no)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='积分流水';
+
+-- 充值赠送规则表，单笔充值满 min_amount 赠送 bonus_amount，多条满足时取门槛最高的一条
+CREATE TABLE recharge_bonus_rules (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(50) NOT NULL COMMENT '规则名称',
+    min_amount DECIMAL(10,2) NOT NULL COMMENT '单笔充值门槛',
+    bonus_amount DECIMAL(10,2) NOT NULL COMMENT '赠送金额',
+    valid_from DATETIME NULL COMMENT '生效时间',
+    valid_to DATETIME NULL COMMENT '失效时间',
+    is_active TINYINT(1) DEFAULT 1 COMMENT '是否启用',
+    create_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    update_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    INDEX idx_min_amount (min_amount)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='充值赠送规则';
+
+-- 订单和预订记录积分抵扣
+ALTER TABLE `order`
+    ADD COLUMN `points_used` int(11) NOT NULL DEFAULT 0 COMMENT '使用的积分' AFTER `coupon_discount`,
+    ADD COLUMN `points_discount` decimal(10,2) NOT NULL DEFAULT 0.00 COMMENT '积分抵扣金额' AFTER `points_used`;
+
+ALTER TABLE `room_bookings`
+    ADD COLUMN `points_used` int(11) NOT NULL DEFAULT 0 COMMENT '使用的积分' AFTER `coupon_discount`,
+    ADD COLUMN `points_discount` decimal(10,2) NOT NULL DEFAULT 0.00 COMMENT '积分抵扣金额' AFTER `points_used`;
@@ -0,0 +1,24 @@
+-- 房间维护/停业时段：生效期间房间不可预订，room_id 为0表示同商家全场停业
+CREATE TABLE IF NOT EXISTS `room_blackouts` (
+    `id` int(11) NOT NULL AUTO_INCREMENT,
+    `tenants_id` int(11) NOT NULL DEFAULT 0 COMMENT '商家ID',
+    `room_id` int(11) NOT NULL DEFAULT 0 COMMENT '房间ID，0表示全场',
+    `type` varchar(16) NOT NULL COMMENT '类型(maintenance:维护,closed:停业)',
+    `reason` varchar(255) NOT NULL COMMENT '原因',
+    `start_time` datetime NOT NULL COMMENT '开始时间',
+    `end_time` datetime NOT NULL COMMENT '结束时间',
+    `status` tinyint(4) NOT NULL DEFAULT 1 COMMENT '状态(1:生效,2:已取消)',
+    `operator_id` int(11) NOT NULL DEFAULT 0 COMMENT '创建人ID',
+    `create_time` datetime DEFAULT CURRENT_TIMESTAMP,
+    `update_time` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    KEY `idx_tenants_id` (`tenants_id`),
+    KEY `idx_room_time` (`room_id`, `status`, `start_time`, `end_time`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='房间维护/停业时段';
+
+-- 维护/停业处理通知模板，在微信后台申请模板后填写模板ID并启用
+INSERT IGNORE INTO `wx_subscribe_template` (`event`, `template_id`, `title`, `page`, `fields`, `status`) VALUES
+('booking_rebooked', '', '预订房间变更通知', 'pages/booking/detail?id={{booking.id}}',
+ '{"thing1":"{{notice.from_room_name}}","thing2":"{{room.room_name}}","time3":"{{booking.start_time|datetime}}","thing4":"{{notice.reason}}","thing5":"{{notice.tip}}"}', 0),
+('booking_venue_cancelled', '', '预订取消通知', 'pages/booking/detail?id={{booking.id}}',
+ '{"thing1":"{{room.room_name}}","time2":"{{booking.start_time|datetime}}","thing3":"{{notice.reason}}","amount4":"{{notice.refund_amount|amount}}","thing5":"{{notice.tip}}"}', 0);
@@ -0,0 +1,22 @@
+-- 预订取消退款政策表
+-- 优先级：套餐政策 > 房间政策 > 全局政策(room_id、package_id 均为空) > 系统默认政策(2小时/20%)
+CREATE TABLE room_cancel_policies (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    policy_name VARCHAR(100) NOT NULL COMMENT '政策名称',
+    room_id INT NULL COMMENT '适用房间ID，为空表示不限房间',
+    package_id INT NULL COMMENT '适用套餐ID，为空表示不限套餐',
+    free_cancel_hours INT DEFAULT 2 COMMENT '开始前N小时以上取消全额退款',
+    fee_percent DECIMAL(5,2) DEFAULT 0 COMMENT '免费取消窗口内取消的手续费百分比(0-100)',
+    description TEXT COMMENT '政策说明',
+    is_active TINYINT(1) DEFAULT 1 COMMENT '是否启用',
+    create_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    update_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    INDEX idx_room_id (room_id),
+    INDEX idx_package_id (package_id),
+    INDEX idx_is_active (is_active)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='预订取消退款政策';
+
+-- 默认全局政策
+INSERT INTO room_cancel_policies (policy_name, free_cancel_hours, fee_percent, description) VALUES
+('默认取消政策', 2, 20.00, '开始前2小时以上取消全额退款，2小时内取消收取20%手续费，开始后取消不予退款');
@@ -0,0 +1,31 @@
+-- 订阅消息模板配置：按业务事件匹配微信模板，关键词映射为事件数据表达式
+CREATE TABLE IF NOT EXISTS `wx_subscribe_template` (
+    `id` int(11) NOT NULL AUTO_INCREMENT,
+    `event` varchar(64) NOT NULL COMMENT '业务事件',
+    `template_id` varchar(128) NOT NULL COMMENT '微信模板ID',
+    `title` varchar(100) NOT NULL DEFAULT '' COMMENT '模板标题',
+    `page` varchar(255) NOT NULL DEFAULT '' COMMENT '落地页路径，支持表达式',
+    `fields` text NOT NULL COMMENT '关键词映射JSON',
+    `status` tinyint(1) NOT NULL DEFAULT 1 COMMENT '1-启用 0-停用',
+    `remark` varchar(255) NOT NULL DEFAULT '' COMMENT '备注',
+    `create_time` datetime DEFAULT CURRENT_TIMESTAMP,
+    `update_time` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_event` (`event`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='订阅消息模板配置';
+
+-- 原代码中固定的下单通知模板
+INSERT IGNORE INTO `wx_subscribe_template` (`event`, `template_id`, `title`, `page`, `fields`, `status`) VALUES
+('order_paid', 'FL4Qq5zBk5zpXs1Jkd7F8D_STgGm9PcdSqOkZnegm2g', '下单通知', 'pages/order/detail?no={{order.no}}',
+ '{"character_string1":"{{order.no}}","thing2":"{{goods.goods_name}}","amount3":"{{order.amount|amount}}","date4":"{{order.create_time|datetime}}","thing5":"感谢您的购买！"}', 1);
+
+-- 预订通知模板，在微信后台申请模板后填写模板ID并启用
+INSERT IGNORE INTO `wx_subscribe_template` (`event`, `template_id`, `title`, `page`, `fields`, `status`) VALUES
+('booking_payment_pending', '', '预订待支付提醒', 'pages/booking/detail?id={{booking.id}}',
+ '{"thing1":"{{room.room_name}}","character_string2":"{{booking.booking_no}}","amount3":"{{booking.total_amount|amount}}","time4":"{{notice.pay_deadline|datetime}}","thing5":"{{notice.tip}}"}', 0),
+('booking_start_soon', '', '预订开始提醒', 'pages/booking/detail?id={{booking.id}}',
+ '{"thing1":"{{room.room_name}}","character_string2":"{{booking.booking_no}}","time3":"{{booking.start_time|datetime}}","thing4":"{{notice.tip}}"}', 0),
+('booking_ending_soon', '', '预订结束提醒', 'pages/booking/detail?id={{booking.id}}',
+ '{"thing1":"{{room.room_name}}","character_string2":"{{booking.booking_no}}","time3":"{{booking.end_time|datetime}}","thing4":"{{notice.tip}}"}', 0),
+('booking_completed', '', '预订完成通知', 'pages/booking/detail?id={{booking.id}}',
+ '{"thing1":"{{room.room_name}}","character_string2":"{{booking.booking_no}}","amount3":"{{booking.paid_amount|amount}}","time4":"{{booking.end_time|datetime}}","thing5":"{{notice.tip}}"}', 0);
@@ -0,0 +1,59 @@
+-- 钱包复式记账凭证表（只允许新增，不允许修改和删除）
+CREATE TABLE wallet_journals (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    journal_no VARCHAR(40) NOT NULL COMMENT '凭证号',
+    idempotency_key VARCHAR(128) NOT NULL COMMENT '幂等键',
+    user_id INT NOT NULL COMMENT '用户ID',
+    biz_type VARCHAR(32) NOT NULL COMMENT '业务类型(同钱包交易类型)',
+    biz_no VARCHAR(64) DEFAULT '' COMMENT '业务单号',
+    amount DECIMAL(12,2) NOT NULL COMMENT '金额',
+    recharge_id INT NULL COMMENT '关联钱包流水ID',
+    remark VARCHAR(255) DEFAULT '' COMMENT '备注',
+    create_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_journal_no (journal_no),
+    UNIQUE KEY uk_idempotency_key (idempotency_key),
+    INDEX idx_user_id (user_id),
+    INDEX idx_biz (biz_type, biz_no)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='钱包记账凭证';
+
+-- 钱包记账分录表
+CREATE TABLE wallet_journal_entries (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    journal_id BIGINT NOT NULL COMMENT '凭证ID',
+    account_code VARCHAR(32) NOT NULL COMMENT '科目代码',
+    user_id INT NOT NULL DEFAULT 0 COMMENT '用户ID(平台科目为0)',
+    direction ENUM('debit', 'credit') NOT NULL COMMENT '借贷方向',
+    amount DECIMAL(12,2) NOT NULL COMMENT '金额',
+    create_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_journal_id (journal_id),
+    INDEX idx_account_user (account_code, user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='钱包记账分录';
+
+-- 钱包对账差异记录表
+CREATE TABLE wallet_reconcile_records (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    batch_no VARCHAR(32) NOT NULL COMMENT '对账批次号',
+    user_id INT NOT NULL COMMENT '用户ID',
+    wallet_balance DECIMAL(12,2) COMMENT '钱包余额',
+    ledger_balance DECIMAL(12,2) COMMENT '账本余额',
+    drift DECIMAL(12,2) COMMENT '差额(钱包-账本)',
+    create_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_batch_no (batch_no),
+    INDEX idx_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='钱包对账差异记录';
+
+-- 期初余额：账本上线前已有的钱包余额记一笔 借 opening_balance / 贷 user_wallet
+INSERT INTO wallet_journals (journal_no, idempotency_key, user_id, biz_type, biz_no, amount, remark)
+SELECT CONCAT('JNOPEN', LPAD(user_id, 12, '0')), CONCAT('opening_balance:', user_id), user_id,
+       'opening_balance', '', money, '账本上线期初余额'
+FROM app_wallet
+WHERE money > 0;
+
+INSERT INTO wallet_journal_entries (journal_id, account_code, user_id, direction, amount)
+SELECT id, 'opening_balance', 0, 'debit', amount FROM wallet_journals WHERE biz_type = 'opening_balance';
+
+INSERT INTO wallet_journal_entries (journal_id, account_code, user_id, direction, amount)
+SELECT id, 'user_wallet', user_id, 'credit', amount FROM wallet_journals WHERE biz_type = 'opening_balance';
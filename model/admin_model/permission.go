@@ -46,6 +46,7 @@ type PermissionUser struct {
 	Sort      int              `json:"sort"`
 	KeepAlive int              `json:"keepAlive" gorm:"column:keepAlive"`
 	IsLink    int              `json:"is_link"`
+	Method    string           `json:"method"` // 接口权限的请求方法，菜单为空
 	Children  []PermissionUser `json:"children" gorm:"-"`
 	//是个对象
 	Meta Meta `json:"meta" gorm:"-"`
@@ -78,6 +79,7 @@ type PermissionMenu struct {
 	Type       string    `json:"type"`
 	IsLink     int       `json:"is_link"`
 	KeepAlive  int       `json:"keepAlive" gorm:"column:keepAlive"`
+	Method     string    `json:"method"` // 接口权限的请求方法，菜单为空
 	ParentId   *int64    `json:"parent_id" gorm:"column:parent_id"`
 	CreateTime time.Time `json:"create_time" gorm:"column:create_time"`
 	UpdateTime time.Time `json:"update_time" gorm:"column:update_time"`
@@ -3,17 +3,28 @@ package admin_model
 import "time"
 
 type Revenue struct {
-	Id            int       `json:"id" gorm:"primaryKey;autoIncrement"`
-	TenantsId     int       `json:"tenants_id" gorm:"column:tenants_id"`
-	StatDate      string    `json:"stat_date" gorm:"column:stat_date"`
-	PeriodStart   string    `json:"period_start" gorm:"column:period_start"`
-	PeriodEnd     string    `json:"period_end" gorm:"column:period_end"`
-	TotalOrders   int       `json:"total_orders" gorm:"column:total_orders"`
-	TotalRevenue  float64   `json:"total_revenue" gorm:"column:total_revenue"`
-	ActualRevenue float64   `json:"actual_revenue" gorm:"column:actual_revenue"`
-	PaidOrders    int       `json:"paid_orders" gorm:"column:paid_orders"`
-	CreateTime    time.Time `json:"create_time" gorm:"column:create_time"`
-	UpdateTime    time.Time `json:"update_time" gorm:"column:update_time"`
+	Id               int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	TenantsId        int       `json:"tenants_id" gorm:"column:tenants_id"`
+	StatDate         string    `json:"stat_date" gorm:"column:stat_date"`
+	StatPeriod       string    `json:"stat_period" gorm:"column:stat_period"`
+	PeriodStart      string    `json:"period_start" gorm:"column:period_start"`
+	PeriodEnd        string    `json:"period_end" gorm:"column:period_end"`
+	TotalOrders      int       `json:"total_orders" gorm:"column:total_orders"`
+	TotalRevenue     float64   `json:"total_revenue" gorm:"column:total_revenue"`
+	ActualRevenue    float64   `json:"actual_revenue" gorm:"column:actual_revenue"`
+	RefundAmount     float64   `json:"refund_amount" gorm:"column:refund_amount"`
+	PaidOrders       int       `json:"paid_orders" gorm:"column:paid_orders"`
+	RefundedOrders   int       `json:"refunded_orders" gorm:"column:refunded_orders"`
+	ItemsSold        int       `json:"items_sold" gorm:"column:items_sold"`
+	BookingCount     int       `json:"booking_count" gorm:"column:booking_count"`
+	PaidBookings     int       `json:"paid_bookings" gorm:"column:paid_bookings"`
+	BookingRevenue   float64   `json:"booking_revenue" gorm:"column:booking_revenue"`
+	BookingRefund    float64   `json:"booking_refund" gorm:"column:booking_refund"`
+	RefundedBookings int       `json:"refunded_bookings" gorm:"column:refunded_bookings"`
+	BookingHours     int       `json:"booking_hours" gorm:"column:booking_hours"`
+	PackageDiscount  float64   `json:"package_discount" gorm:"column:package_discount"`
+	CreateTime       time.Time `json:"create_time" gorm:"column:create_time"`
+	UpdateTime       time.Time `json:"update_time" gorm:"column:update_time"`
 }
 
 func (Revenue) TableName() string {
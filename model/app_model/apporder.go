@@ -3,49 +3,86 @@ package app_model
 import "time"
 
 type AppOrder struct {
-	Id         int       `json:"id" gorm:"primary_key"`
-	UserId     int       `json:"user_id" gorm:"column:user_id"`
-	Amount     float64   `json:"amount"`
-	Num        int       `json:"num"`
-	No         string    `json:"no"`
-	TenantsId  int       `json:"tenants_id" gorm:"column:tenants_id"`
-	GoodsId    int       `json:"goods_id" gorm:"column:goods_id"`
-	Status     string    `json:"status"`
-	CreateTime time.Time `json:"create_time" gorm:"column:create_time"`
-	UpdateTime time.Time `json:"update_time" gorm:"column:update_time"`
+	Id             int       `json:"id" gorm:"primary_key"`
+	UserId         int       `json:"user_id" gorm:"column:user_id"`
+	Amount         float64   `json:"amount"`
+	CouponDiscount float64   `json:"coupon_discount" gorm:"column:coupon_discount"` // 优惠券抵扣金额，Amount 为抵扣后的实付金额
+	PointsUsed     int       `json:"points_used" gorm:"column:points_used"`         // 使用的积分
+	PointsDiscount float64   `json:"points_discount" gorm:"column:points_discount"` // 积分抵扣金额
+	Num            int       `json:"num"`
+	No             string    `json:"no"`
+	TenantsId      int       `json:"tenants_id" gorm:"column:tenants_id"`
+	GoodsId        int       `json:"goods_id" gorm:"column:goods_id"`
+	Status         string    `json:"status"`
+	CreateTime     time.Time `json:"create_time" gorm:"column:create_time"`
+	UpdateTime     time.Time `json:"update_time" gorm:"column:update_time"`
 }
 
 type OrderRefund struct {
-	Id         int       `json:"id" gorm:"primary_key"`
-	UserId     int       `json:"user_id" gorm:"column:user_id"`
-	Amount     float64   `json:"amount"`
-	No         string    `json:"no"`
-	OrderId    int       `json:"order_id" gorm:"column:order_id"`
-	GoodsId    int       `json:"goods_id" gorm:"column:goods_id"`
-	Status     string    `json:"status"`
-	CreateTime time.Time `json:"create_time" gorm:"column:create_time"`
-	UpdateTime time.Time `json:"update_time" gorm:"column:update_time"`
+	Id           int        `json:"id" gorm:"primary_key"`
+	UserId       int        `json:"user_id" gorm:"column:user_id"`
+	Amount       float64    `json:"amount"`
+	No           string     `json:"no"`
+	OrderId      int        `json:"order_id" gorm:"column:order_id"`
+	GoodsId      int        `json:"goods_id" gorm:"column:goods_id"`
+	TenantsId    int        `json:"tenants_id" gorm:"column:tenants_id"`
+	Reason       string     `json:"reason" gorm:"column:reason"`
+	Images       string     `json:"images" gorm:"column:images"` // 凭证图片(JSON数组)
+	Status       string     `json:"status"`
+	RejectReason string     `json:"reject_reason" gorm:"column:reject_reason"`
+	AuditBy      *int       `json:"audit_by" gorm:"column:audit_by"`
+	AuditTime    *time.Time `json:"audit_time" gorm:"column:audit_time"`
+	CreateTime   time.Time  `json:"create_time" gorm:"column:create_time"`
+	UpdateTime   time.Time  `json:"update_time" gorm:"column:update_time"`
+}
+
+// 退款申请状态
+const (
+	RefundStatusPending  = "0" // 待审核
+	RefundStatusApproved = "1" // 已同意
+	RefundStatusRejected = "2" // 已拒绝
+)
+
+// GetRefundStatusText 获取退款申请状态文本
+func (r *OrderRefund) GetRefundStatusText() string {
+	switch r.Status {
+	case RefundStatusPending:
+		return "待审核"
+	case RefundStatusApproved:
+		return "已同意"
+	case RefundStatusRejected:
+		return "已拒绝"
+	default:
+		return "未知"
+	}
 }
 
 // MerchantRevenueStats 商家收入统计表
 type MerchantRevenueStats struct {
-	Id              int       `gorm:"primaryKey;autoIncrement" json:"id"`
-	TenantsId       int       `gorm:"index:idx_merchant_period;not null" json:"tenants_id" comment:"商家ID"`
-	StatDate        string    `gorm:"index:idx_stat_date;not null;type:date" json:"stat_date" comment:"统计日期"`
-	StatPeriod      string    `gorm:"index:idx_merchant_period;not null;type:enum('day','week','month','year')" json:"stat_period" comment:"统计周期"`
-	PeriodStart     string    `gorm:"index:idx_merchant_period;not null;type:date" json:"period_start" comment:"周期开始日期"`
-	PeriodEnd       string    `gorm:"not null;type:date" json:"period_end" comment:"周期结束日期"`
-	TotalOrders     int       `gorm:"not null;default:0" json:"total_orders" comment:"订单总数"`
-	TotalRevenue    float64   `gorm:"not null;default:0;type:decimal(12,2)" json:"total_revenue" comment:"总收入(元)"`
-	ActualRevenue   float64   `gorm:"not null;default:0;type:decimal(12,2)" json:"actual_revenue" comment:"实际收入(扣除退款后)"`
-	RefundAmount    float64   `gorm:"not null;default:0;type:decimal(12,2)" json:"refund_amount" comment:"退款总额"`
-	PaidOrders      int       `gorm:"not null;default:0" json:"paid_orders" comment:"已支付订单数"`
-	PendingOrders   int       `gorm:"not null;default:0" json:"pending_orders" comment:"待支付订单数"`
-	CancelledOrders int       `gorm:"not null;default:0" json:"cancelled_orders" comment:"已取消订单数"`
-	RefundedOrders  int       `gorm:"not null;default:0" json:"refunded_orders" comment:"已退款订单数"`
-	ItemsSold       int       `gorm:"not null;default:0" json:"items_sold" comment:"售出商品总数"`
-	CreateTime      time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"create_time" comment:"创建时间"`
-	UpdateTime      time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"update_time" comment:"更新时间"`
+	Id               int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	TenantsId        int       `gorm:"index:idx_merchant_period;not null" json:"tenants_id" comment:"商家ID"`
+	StatDate         string    `gorm:"index:idx_stat_date;not null;type:date" json:"stat_date" comment:"统计日期"`
+	StatPeriod       string    `gorm:"index:idx_merchant_period;not null;type:enum('day','week','month','year')" json:"stat_period" comment:"统计周期"`
+	PeriodStart      string    `gorm:"index:idx_merchant_period;not null;type:date" json:"period_start" comment:"周期开始日期"`
+	PeriodEnd        string    `gorm:"not null;type:date" json:"period_end" comment:"周期结束日期"`
+	TotalOrders      int       `gorm:"not null;default:0" json:"total_orders" comment:"订单总数"`
+	TotalRevenue     float64   `gorm:"not null;default:0;type:decimal(12,2)" json:"total_revenue" comment:"总收入(元)"`
+	ActualRevenue    float64   `gorm:"not null;default:0;type:decimal(12,2)" json:"actual_revenue" comment:"实际收入(扣除退款后)"`
+	RefundAmount     float64   `gorm:"not null;default:0;type:decimal(12,2)" json:"refund_amount" comment:"退款总额"`
+	PaidOrders       int       `gorm:"not null;default:0" json:"paid_orders" comment:"已支付订单数"`
+	PendingOrders    int       `gorm:"not null;default:0" json:"pending_orders" comment:"待支付订单数"`
+	CancelledOrders  int       `gorm:"not null;default:0" json:"cancelled_orders" comment:"已取消订单数"`
+	RefundedOrders   int       `gorm:"not null;default:0" json:"refunded_orders" comment:"已退款订单数"`
+	ItemsSold        int       `gorm:"not null;default:0" json:"items_sold" comment:"售出商品总数"`
+	BookingCount     int       `gorm:"not null;default:0" json:"booking_count" comment:"预订总数"`
+	PaidBookings     int       `gorm:"not null;default:0" json:"paid_bookings" comment:"已支付预订数"`
+	BookingRevenue   float64   `gorm:"not null;default:0;type:decimal(12,2)" json:"booking_revenue" comment:"预订收入(元)"`
+	BookingRefund    float64   `gorm:"not null;default:0;type:decimal(12,2)" json:"booking_refund" comment:"预订退款总额"`
+	RefundedBookings int       `gorm:"not null;default:0" json:"refunded_bookings" comment:"已退款预订数"`
+	BookingHours     int       `gorm:"not null;default:0" json:"booking_hours" comment:"预订占用小时数"`
+	PackageDiscount  float64   `gorm:"not null;default:0;type:decimal(12,2)" json:"package_discount" comment:"套餐优惠总额"`
+	CreateTime       time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"create_time" comment:"创建时间"`
+	UpdateTime       time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"update_time" comment:"更新时间"`
 }
 
 // MerchantRevenueDetails 商家收入详细分析表
@@ -64,6 +101,33 @@ type MerchantRevenueDetails struct {
 	UpdateTime   time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"update_time" comment:"更新时间"`
 }
 
+// MerchantRoomRevenueDetails 商家房间预订收入明细表，按房间和套餐分行，未使用套餐的预订 PackageId 为 0
+type MerchantRoomRevenueDetails struct {
+	Id              int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	TenantsId       int       `gorm:"index:idx_merchant_room_date;not null" json:"tenants_id" comment:"商家ID"`
+	StatDate        string    `gorm:"index:idx_stat_date;index:idx_merchant_room_date;not null;type:date" json:"stat_date" comment:"统计日期"`
+	RoomId          int       `gorm:"index:idx_merchant_room_date;not null" json:"room_id" comment:"房间ID"`
+	RoomName        string    `gorm:"not null;type:varchar(255)" json:"room_name" comment:"房间名称"`
+	PackageId       int       `gorm:"not null;default:0" json:"package_id" comment:"套餐ID，0为按小时计费"`
+	PackageName     string    `gorm:"not null;type:varchar(255)" json:"package_name" comment:"套餐名称"`
+	BookingCount    int       `gorm:"not null;default:0" json:"booking_count" comment:"已支付预订数"`
+	BookingHours    int       `gorm:"not null;default:0" json:"booking_hours" comment:"占用小时数"`
+	Revenue         float64   `gorm:"not null;default:0;type:decimal(12,2)" json:"revenue" comment:"收入金额"`
+	PackageDiscount float64   `gorm:"not null;default:0;type:decimal(12,2)" json:"package_discount" comment:"套餐优惠金额"`
+	RefundCount     int       `gorm:"not null;default:0" json:"refund_count" comment:"退款预订数"`
+	RefundAmount    float64   `gorm:"not null;default:0;type:decimal(12,2)" json:"refund_amount" comment:"退款金额"`
+	CreateTime      time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"create_time" comment:"创建时间"`
+	UpdateTime      time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"update_time" comment:"更新时间"`
+}
+
+// 收入统计周期
+const (
+	StatPeriodDay   = "day"
+	StatPeriodWeek  = "week"
+	StatPeriodMonth = "month"
+	StatPeriodYear  = "year"
+)
+
 func (AppOrder) TableName() string {
 	return "order"
 }
@@ -78,3 +142,6 @@ func (MerchantRevenueStats) TableName() string {
 func (MerchantRevenueDetails) TableName() string {
 	return "merchant_revenue_details"
 }
+func (MerchantRoomRevenueDetails) TableName() string {
+	return "merchant_room_revenue_details"
+}
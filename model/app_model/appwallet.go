@@ -35,3 +35,45 @@ func (AppWallet) TableName() string {
 func (AppRecharge) TableName() string {
 	return "app_recharge"
 }
+
+// 钱包交易类型
+const (
+	TransactionTypeRecharge       = "recharge"        // 用户充值
+	TransactionTypeRechargeBonus  = "recharge_bonus"  // 充值赠送
+	TransactionTypeOrderPayment   = "order_payment"   // 商品订单支付
+	TransactionTypeBookingPayment = "booking_payment" // 房间预订支付
+	TransactionTypeBookingExtend  = "booking_extend"  // 房间预订续时
+	TransactionTypeBookingExtra   = "booking_extra"   // 房间超时及额外费用
+	TransactionTypeBookingAdjust  = "booking_adjust"  // 房间预订改期/换房补收差价
+	TransactionTypeBookingOffline = "booking_offline" // 房间预订前台线下收款（不经过钱包）
+	TransactionTypeOrderRefund    = "order_refund"    // 商品订单退款
+	TransactionTypeBookingRefund  = "booking_refund"  // 房间预订取消退款
+	TransactionTypeAdjustRefund   = "adjust_refund"   // 房间预订改期/换房退还差价
+	TransactionTypeSystemRefund   = "system_refund"   // 系统补偿退款
+	TransactionTypeOpeningBalance = "opening_balance" // 期初余额（账本上线前已有的钱包余额）
+)
+
+// 充值状态（钱包流水的支付状态）
+const (
+	RechargeStatusPending   = "pending"   // 待支付
+	RechargeStatusCompleted = "completed" // 支付成功，已入账
+	RechargeStatusFailed    = "failed"    // 支付失败
+)
+
+// RechargeBonusRule 充值赠送规则，单笔充值满 MinAmount 赠送 BonusAmount
+// 同时满足多条规则时取门槛最高的一条
+type RechargeBonusRule struct {
+	ID          int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name        string     `json:"name" gorm:"column:name;not null;comment:规则名称"`
+	MinAmount   float64    `json:"min_amount" gorm:"column:min_amount;type:decimal(10,2);not null;comment:单笔充值门槛"`
+	BonusAmount float64    `json:"bonus_amount" gorm:"column:bonus_amount;type:decimal(10,2);not null;comment:赠送金额"`
+	ValidFrom   *time.Time `json:"valid_from" gorm:"column:valid_from;comment:生效时间"`
+	ValidTo     *time.Time `json:"valid_to" gorm:"column:valid_to;comment:失效时间"`
+	IsActive    bool       `json:"is_active" gorm:"column:is_active;default:true;comment:是否启用"`
+	CreateTime  time.Time  `json:"create_time" gorm:"column:create_time;autoCreateTime"`
+	UpdateTime  time.Time  `json:"update_time" gorm:"column:update_time;autoUpdateTime"`
+}
+
+func (RechargeBonusRule) TableName() string {
+	return "recharge_bonus_rules"
+}
@@ -40,6 +40,15 @@ const (
 	LogTypeManualStart     = "manual_start"     // 手动开始订单
 	LogTypeManualEnd       = "manual_end"       // 手动结束订单
 	LogTypeUsageLogError   = "usage_log_error"  // 使用记录错误
+	LogTypeBookingPaid     = "booking_paid"     // 订单支付
+	LogTypeBookingRefund   = "booking_refund"   // 订单取消退款
+	LogTypeCheckOut        = "check_out"        // 前台退房结算
+	LogTypeBookingExtend   = "booking_extend"   // 订单续时
+	LogTypeBookingVerify   = "booking_verify"   // 入场核验
+	LogTypeBookingRebook   = "booking_rebook"   // 维护/停业改订房间
+	LogTypeAdminCreate     = "admin_create"     // 前台代客预订
+	LogTypeBookingModify   = "booking_modify"   // 前台修改预订时间或房间
+	LogTypeRoomTransfer    = "room_transfer"    // 使用中换房
 )
 
 // GetLogTypeText 获取日志类型文本
@@ -63,6 +72,24 @@ func GetLogTypeText(logType string) string {
 		return "手动结束订单"
 	case LogTypeUsageLogError:
 		return "使用记录错误"
+	case LogTypeBookingPaid:
+		return "订单支付"
+	case LogTypeBookingRefund:
+		return "订单取消退款"
+	case LogTypeCheckOut:
+		return "前台退房结算"
+	case LogTypeBookingExtend:
+		return "订单续时"
+	case LogTypeBookingVerify:
+		return "入场核验"
+	case LogTypeBookingRebook:
+		return "改订房间"
+	case LogTypeAdminCreate:
+		return "前台代客预订"
+	case LogTypeBookingModify:
+		return "修改预订"
+	case LogTypeRoomTransfer:
+		return "使用中换房"
 	default:
 		return "未知类型"
 	}
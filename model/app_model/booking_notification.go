@@ -0,0 +1,40 @@
+package app_model
+
+import "time"
+
+// BookingNotification 预订通知发送记录，同一预订的同类通知只发送一次
+type BookingNotification struct {
+	ID         int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	BookingID  int       `json:"booking_id" gorm:"column:booking_id;uniqueIndex:uk_booking_notice;not null;comment:预订ID"`
+	NoticeType string    `json:"notice_type" gorm:"column:notice_type;uniqueIndex:uk_booking_notice;not null;comment:通知类型"`
+	UserID     int       `json:"user_id" gorm:"column:user_id;index;not null;comment:用户ID"`
+	Status     string    `json:"status" gorm:"column:status;not null;comment:发送结果"`
+	Channels   string    `json:"channels" gorm:"column:channels;comment:已送达渠道"`
+	MessageID  string    `json:"message_id" gorm:"column:message_id;comment:推送记录消息ID"`
+	ErrorMsg   string    `json:"error_msg" gorm:"column:error_msg;type:text;comment:错误信息"`
+	CreateTime time.Time `json:"create_time" gorm:"column:create_time;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:update_time;autoUpdateTime"`
+}
+
+func (BookingNotification) TableName() string {
+	return "booking_notifications"
+}
+
+// 预订通知类型
+const (
+	BookingNoticePaymentPending = "payment_pending" // 待支付提醒（超时自动取消前）
+	BookingNoticeStartSoon      = "start_soon"      // 即将开始提醒
+	BookingNoticeEndingSoon     = "ending_soon"     // 即将结束提醒（附续时入口）
+	BookingNoticeCompleted      = "completed"       // 完成及消费回执
+	BookingNoticeWaitlistOffer  = "waitlist_offer"  // 候补时段已保留，等待支付确认
+	BookingNoticeRebooked       = "rebooked"        // 因房间维护/停业调换到同类房间
+	BookingNoticeVenueCancelled = "venue_cancelled" // 因房间维护/停业取消并全额退款
+)
+
+// 预订通知发送结果
+const (
+	BookingNoticeStatusSending = "sending" // 已占用，发送中
+	BookingNoticeStatusSent    = "sent"    // 至少一个渠道送达
+	BookingNoticeStatusFailed  = "failed"  // 全部渠道发送失败
+	BookingNoticeStatusSkipped = "skipped" // 用户未订阅，跳过
+)
@@ -0,0 +1,61 @@
+package app_model
+
+import "time"
+
+// BookingWaitlist 预订候补，时段释放时按登记顺序为候补用户保留时段
+type BookingWaitlist struct {
+	ID             int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	TenantsId      int        `json:"tenants_id" gorm:"column:tenants_id;index;default:0;comment:商家ID，按房型候补在分配房间前为0"`
+	UserID         int        `json:"user_id" gorm:"column:user_id;index;not null;comment:用户ID"`
+	RoomID         int        `json:"room_id" gorm:"column:room_id;index;default:0;comment:候补房间ID，0表示同房型任意房间"`
+	RoomType       string     `json:"room_type" gorm:"column:room_type;comment:候补房间类型"`
+	WindowStart    time.Time  `json:"window_start" gorm:"column:window_start;not null;comment:期望时间窗口开始"`
+	WindowEnd      time.Time  `json:"window_end" gorm:"column:window_end;not null;comment:期望时间窗口结束"`
+	Hours          int        `json:"hours" gorm:"column:hours;not null;comment:预订小时数"`
+	ContactName    string     `json:"contact_name" gorm:"column:contact_name;comment:联系人姓名"`
+	ContactPhone   string     `json:"contact_phone" gorm:"column:contact_phone;comment:联系人电话"`
+	Remarks        string     `json:"remarks" gorm:"column:remarks;type:text;comment:备注信息"`
+	Status         int        `json:"status" gorm:"column:status;default:1;comment:候补状态(1:候补中,2:已保留,3:已确认,4:已失效,5:已取消)"`
+	OfferBookingID *int       `json:"offer_booking_id" gorm:"column:offer_booking_id;index;comment:保留时段生成的待支付预订ID"`
+	OfferedAt      *time.Time `json:"offered_at" gorm:"column:offered_at;comment:保留时间"`
+	CreateTime     time.Time  `json:"create_time" gorm:"column:create_time;autoCreateTime"`
+	UpdateTime     time.Time  `json:"update_time" gorm:"column:update_time;autoUpdateTime"`
+
+	// 关联查询字段
+	Room         *Room        `json:"room,omitempty" gorm:"foreignKey:RoomID"`
+	OfferBooking *RoomBooking `json:"offer_booking,omitempty" gorm:"foreignKey:OfferBookingID"`
+}
+
+func (BookingWaitlist) TableName() string {
+	return "booking_waitlists"
+}
+
+// TenantScoped 候补按商家隔离
+func (BookingWaitlist) TenantScoped() {}
+
+// 候补状态
+const (
+	WaitlistStatusWaiting   = 1 // 候补中
+	WaitlistStatusOffered   = 2 // 已保留，等待用户支付确认
+	WaitlistStatusConfirmed = 3 // 已确认
+	WaitlistStatusExpired   = 4 // 已失效（时间窗口已过或保留超时）
+	WaitlistStatusCancelled = 5 // 已取消（用户退出或放弃保留）
+)
+
+// GetStatusText 获取候补状态文本
+func (w *BookingWaitlist) GetStatusText() string {
+	switch w.Status {
+	case WaitlistStatusWaiting:
+		return "候补中"
+	case WaitlistStatusOffered:
+		return "已保留"
+	case WaitlistStatusConfirmed:
+		return "已确认"
+	case WaitlistStatusExpired:
+		return "已失效"
+	case WaitlistStatusCancelled:
+		return "已取消"
+	default:
+		return "未知状态"
+	}
+}
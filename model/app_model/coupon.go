@@ -0,0 +1,204 @@
+package app_model
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CouponTemplate 优惠券模板
+// tenants_id 为 0 表示平台券，可在所有商家使用；否则只能用于该商家的商品和房间
+type CouponTemplate struct {
+	ID             int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	TenantsId      int        `json:"tenants_id" gorm:"column:tenants_id;index;default:0;comment:商家ID，0为平台券"`
+	Name           string     `json:"name" gorm:"column:name;not null;comment:优惠券名称"`
+	Description    string     `json:"description" gorm:"column:description;type:text;comment:使用说明"`
+	CouponType     string     `json:"coupon_type" gorm:"column:coupon_type;not null;comment:优惠类型(fixed/percent)"`
+	Value          float64    `json:"value" gorm:"column:value;type:decimal(10,2);not null;comment:优惠值，fixed为减免金额，percent为折扣百分比(如85表示85折)"`
+	MaxDiscount    float64    `json:"max_discount" gorm:"column:max_discount;type:decimal(10,2);default:0;comment:折扣券最高减免金额，0表示不限"`
+	Threshold      float64    `json:"threshold" gorm:"column:threshold;type:decimal(10,2);default:0;comment:使用门槛金额，0表示无门槛"`
+	FirstOrderOnly bool       `json:"first_order_only" gorm:"column:first_order_only;default:false;comment:是否仅限首单"`
+	Scope          string     `json:"scope" gorm:"column:scope;default:all;comment:适用范围(all/goods/room)"`
+	ScopeIDs       string     `json:"scope_ids" gorm:"column:scope_ids;type:varchar(1000);comment:适用的商品或房间ID，逗号分隔，为空表示该范围内全部可用"`
+	PromoCode      *string    `json:"promo_code" gorm:"column:promo_code;type:varchar(32);uniqueIndex:uk_promo_code;comment:推广码，用户凭码领取"`
+	ValidFrom      *time.Time `json:"valid_from" gorm:"column:valid_from;comment:有效期开始"`
+	ValidTo        *time.Time `json:"valid_to" gorm:"column:valid_to;comment:有效期结束"`
+	ValidDays      int        `json:"valid_days" gorm:"column:valid_days;default:0;comment:领取后N天内有效，0表示使用模板有效期"`
+	TotalLimit     int        `json:"total_limit" gorm:"column:total_limit;default:0;comment:发放总量，0表示不限"`
+	PerUserLimit   int        `json:"per_user_limit" gorm:"column:per_user_limit;default:1;comment:每人限领张数，0表示不限"`
+	IssuedCount    int        `json:"issued_count" gorm:"column:issued_count;default:0;comment:已发放数量"`
+	UsedCount      int        `json:"used_count" gorm:"column:used_count;default:0;comment:已使用数量"`
+	IsActive       bool       `json:"is_active" gorm:"column:is_active;default:true;comment:是否启用"`
+	CreateTime     time.Time  `json:"create_time" gorm:"column:create_time;autoCreateTime"`
+	UpdateTime     time.Time  `json:"update_time" gorm:"column:update_time;autoUpdateTime"`
+}
+
+// UserCoupon 用户持有的优惠券
+type UserCoupon struct {
+	ID             int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	TenantsId      int        `json:"tenants_id" gorm:"column:tenants_id;index;default:0;comment:商家ID，与模板一致"`
+	TemplateID     int        `json:"template_id" gorm:"column:template_id;index:idx_template_user;not null;comment:优惠券模板ID"`
+	UserID         int        `json:"user_id" gorm:"column:user_id;index:idx_template_user;index:idx_user_status;not null;comment:用户ID"`
+	CouponCode     string     `json:"coupon_code" gorm:"column:coupon_code;type:varchar(32);uniqueIndex:uk_coupon_code;not null;comment:券码"`
+	Status         int        `json:"status" gorm:"column:status;index:idx_user_status;default:1;comment:状态(1:未使用,2:已使用,3:已过期,4:已作废)"`
+	Source         string     `json:"source" gorm:"column:source;default:issue;comment:来源(issue:后台发放,promo:推广码领取)"`
+	ValidFrom      time.Time  `json:"valid_from" gorm:"column:valid_from;not null;comment:生效时间"`
+	ValidTo        time.Time  `json:"valid_to" gorm:"column:valid_to;not null;comment:失效时间"`
+	OrderType      string     `json:"order_type" gorm:"column:order_type;index:idx_order;comment:使用的订单类型(goods/booking)"`
+	OrderNo        string     `json:"order_no" gorm:"column:order_no;index:idx_order;comment:使用的订单号"`
+	DiscountAmount float64    `json:"discount_amount" gorm:"column:discount_amount;type:decimal(10,2);default:0;comment:实际抵扣金额"`
+	UsedTime       *time.Time `json:"used_time" gorm:"column:used_time;comment:使用时间"`
+	ReturnedTime   *time.Time `json:"returned_time" gorm:"column:returned_time;comment:最近一次退回时间"`
+	CreateTime     time.Time  `json:"create_time" gorm:"column:create_time;autoCreateTime"`
+	UpdateTime     time.Time  `json:"update_time" gorm:"column:update_time;autoUpdateTime"`
+
+	// 关联查询
+	Template *CouponTemplate `json:"template,omitempty" gorm:"foreignKey:TemplateID"`
+}
+
+func (CouponTemplate) TableName() string {
+	return "coupon_templates"
+}
+
+func (UserCoupon) TableName() string {
+	return "user_coupons"
+}
+
+// TenantScoped 优惠券模板和用户优惠券按商家隔离
+func (CouponTemplate) TenantScoped() {}
+func (UserCoupon) TenantScoped()     {}
+
+// 优惠类型
+const (
+	CouponTypeFixed   = "fixed"   // 满减/立减固定金额
+	CouponTypePercent = "percent" // 折扣
+)
+
+// 适用范围
+const (
+	CouponScopeAll   = "all"   // 全场通用
+	CouponScopeGoods = "goods" // 商品订单
+	CouponScopeRoom  = "room"  // 房间预订
+)
+
+// 使用的订单类型
+const (
+	CouponOrderGoods   = "goods"   // 商品订单
+	CouponOrderBooking = "booking" // 房间预订
+)
+
+// 用户优惠券状态
+const (
+	UserCouponStatusUnused  = 1 // 未使用
+	UserCouponStatusUsed    = 2 // 已使用
+	UserCouponStatusExpired = 3 // 已过期
+	UserCouponStatusRevoked = 4 // 已作废
+)
+
+// 用户优惠券来源
+const (
+	CouponSourceIssue = "issue" // 后台发放
+	CouponSourcePromo = "promo" // 推广码领取
+)
+
+// GetStatusText 获取用户优惠券状态文本
+func (uc *UserCoupon) GetStatusText() string {
+	switch uc.Status {
+	case UserCouponStatusUnused:
+		return "未使用"
+	case UserCouponStatusUsed:
+		return "已使用"
+	case UserCouponStatusExpired:
+		return "已过期"
+	case UserCouponStatusRevoked:
+		return "已作废"
+	default:
+		return "未知"
+	}
+}
+
+// ValidityFor 计算领取时间对应的有效期，未设置领取后有效天数时使用模板有效期
+func (ct *CouponTemplate) ValidityFor(issueTime time.Time) (time.Time, time.Time) {
+	validFrom := issueTime
+	if ct.ValidFrom != nil && ct.ValidFrom.After(issueTime) {
+		validFrom = *ct.ValidFrom
+	}
+
+	var validTo time.Time
+	switch {
+	case ct.ValidDays > 0:
+		validTo = validFrom.AddDate(0, 0, ct.ValidDays)
+		if ct.ValidTo != nil && ct.ValidTo.Before(validTo) {
+			validTo = *ct.ValidTo
+		}
+	case ct.ValidTo != nil:
+		validTo = *ct.ValidTo
+	default:
+		// 未配置有效期时默认一年
+		validTo = validFrom.AddDate(1, 0, 0)
+	}
+
+	return validFrom, validTo
+}
+
+// AppliesTo 优惠券是否适用于指定订单类型和商品/房间
+func (ct *CouponTemplate) AppliesTo(orderType string, targetID int) bool {
+	switch ct.Scope {
+	case CouponScopeGoods:
+		if orderType != CouponOrderGoods {
+			return false
+		}
+	case CouponScopeRoom:
+		if orderType != CouponOrderBooking {
+			return false
+		}
+	}
+
+	ids := ct.ScopeIDList()
+	if len(ids) == 0 || ct.Scope == CouponScopeAll {
+		return true
+	}
+	for _, id := range ids {
+		if id == targetID {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopeIDList 解析适用的商品或房间ID
+func (ct *CouponTemplate) ScopeIDList() []int {
+	var ids []int
+	for _, part := range strings.Split(ct.ScopeIDs, ",") {
+		if id, err := strconv.Atoi(strings.TrimSpace(part)); err == nil && id > 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// CalculateDiscount 计算订单金额可抵扣的优惠金额，未达门槛返回0
+func (ct *CouponTemplate) CalculateDiscount(amount float64) float64 {
+	if amount <= 0 || amount < ct.Threshold {
+		return 0
+	}
+
+	var discount float64
+	switch ct.CouponType {
+	case CouponTypeFixed:
+		discount = ct.Value
+	case CouponTypePercent:
+		if ct.Value <= 0 || ct.Value >= 100 {
+			return 0
+		}
+		discount = amount * (100 - ct.Value) / 100
+		if ct.MaxDiscount > 0 && discount > ct.MaxDiscount {
+			discount = ct.MaxDiscount
+		}
+	}
+
+	if discount > amount {
+		discount = amount
+	}
+	return roundAmount(discount)
+}
@@ -0,0 +1,123 @@
+package app_model
+
+import (
+	"math"
+	"time"
+)
+
+// MemberLevel 会员等级，按累计消费金额自动升降级
+type MemberLevel struct {
+	ID          int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name        string    `json:"name" gorm:"column:name;not null;comment:等级名称"`
+	MinSpend    float64   `json:"min_spend" gorm:"column:min_spend;type:decimal(12,2);uniqueIndex:uk_min_spend;not null;default:0;comment:累计消费门槛"`
+	PointsRate  float64   `json:"points_rate" gorm:"column:points_rate;type:decimal(5,2);not null;default:1;comment:积分倍率"`
+	Benefits    string    `json:"benefits" gorm:"column:benefits;type:text;comment:等级权益说明"`
+	Icon        string    `json:"icon" gorm:"column:icon;comment:等级图标"`
+	IsActive    bool      `json:"is_active" gorm:"column:is_active;default:true;comment:是否启用"`
+	CreateTime  time.Time `json:"create_time" gorm:"column:create_time;autoCreateTime"`
+	UpdateTime  time.Time `json:"update_time" gorm:"column:update_time;autoUpdateTime"`
+	MemberCount int64     `json:"member_count" gorm:"-"` // 当前等级会员数（列表展示用）
+}
+
+// MemberAccount 会员账户，记录等级、累计消费和积分余额
+type MemberAccount struct {
+	ID            int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID        int       `json:"user_id" gorm:"column:user_id;uniqueIndex:uk_user_id;not null;comment:用户ID"`
+	LevelID       int       `json:"level_id" gorm:"column:level_id;index;default:0;comment:会员等级ID，0为未达到任何等级"`
+	TotalSpend    float64   `json:"total_spend" gorm:"column:total_spend;type:decimal(12,2);default:0;comment:累计消费金额"`
+	Points        int       `json:"points" gorm:"column:points;default:0;comment:可用积分"`
+	TotalEarned   int       `json:"total_earned" gorm:"column:total_earned;default:0;comment:累计获得积分"`
+	TotalRedeemed int       `json:"total_redeemed" gorm:"column:total_redeemed;default:0;comment:累计使用积分"`
+	CreateTime    time.Time `json:"create_time" gorm:"column:create_time;autoCreateTime"`
+	UpdateTime    time.Time `json:"update_time" gorm:"column:update_time;autoUpdateTime"`
+
+	// 关联查询
+	Level *MemberLevel `json:"level,omitempty" gorm:"foreignKey:LevelID"`
+}
+
+// MemberPointsLog 积分流水，只新增不修改，余额变动都必须有对应流水
+type MemberPointsLog struct {
+	ID             int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID         int       `json:"user_id" gorm:"column:user_id;index:idx_user_time;not null;comment:用户ID"`
+	ChangeType     string    `json:"change_type" gorm:"column:change_type;not null;comment:变动类型"`
+	Points         int       `json:"points" gorm:"column:points;not null;comment:变动积分，正数增加负数减少"`
+	BalanceAfter   int       `json:"balance_after" gorm:"column:balance_after;not null;comment:变动后积分余额"`
+	BizType        string    `json:"biz_type" gorm:"column:biz_type;index:idx_biz;comment:业务类型(goods/booking)"`
+	BizNo          string    `json:"biz_no" gorm:"column:biz_no;index:idx_biz;comment:业务单号"`
+	Amount         float64   `json:"amount" gorm:"column:amount;type:decimal(12,2);default:0;comment:关联金额(消费金额或抵扣金额)"`
+	IdempotencyKey *string   `json:"-" gorm:"column:idempotency_key;type:varchar(128);uniqueIndex:uk_idempotency_key;comment:幂等键"`
+	OperatorID     int       `json:"operator_id" gorm:"column:operator_id;default:0;comment:操作人ID(后台调整)"`
+	Remark         string    `json:"remark" gorm:"column:remark;comment:备注"`
+	CreateTime     time.Time `json:"create_time" gorm:"column:create_time;index:idx_user_time;autoCreateTime"`
+}
+
+func (MemberLevel) TableName() string {
+	return "member_levels"
+}
+
+func (MemberAccount) TableName() string {
+	return "member_accounts"
+}
+
+func (MemberPointsLog) TableName() string {
+	return "member_points_logs"
+}
+
+// 积分变动类型
+const (
+	PointsChangeEarn   = "earn"   // 消费获得
+	PointsChangeRedeem = "redeem" // 下单抵扣
+	PointsChangeReturn = "return" // 取消/退款退回抵扣积分
+	PointsChangeRevoke = "revoke" // 退款扣回已获得积分
+	PointsChangeAdjust = "adjust" // 后台调整
+)
+
+// 积分业务类型
+const (
+	PointsBizGoods   = "goods"   // 商品订单
+	PointsBizBooking = "booking" // 房间预订
+)
+
+// 积分规则
+const (
+	PointsEarnPerYuan   = 1   // 每消费1元获得的基础积分
+	PointsPerYuan       = 100 // 积分抵扣比例：100积分抵1元
+	PointsMaxRedeemRate = 0.5 // 积分最多抵扣订单金额的比例
+)
+
+// GetChangeTypeText 获取积分变动类型文本
+func (l *MemberPointsLog) GetChangeTypeText() string {
+	switch l.ChangeType {
+	case PointsChangeEarn:
+		return "消费获得"
+	case PointsChangeRedeem:
+		return "积分抵扣"
+	case PointsChangeReturn:
+		return "抵扣退回"
+	case PointsChangeRevoke:
+		return "退款扣回"
+	case PointsChangeAdjust:
+		return "后台调整"
+	default:
+		return "未知"
+	}
+}
+
+// PointsForSpend 计算消费金额可获得的积分，按等级倍率向下取整
+func (ml *MemberLevel) PointsForSpend(amount float64) int {
+	rate := 1.0
+	if ml != nil && ml.PointsRate > 0 {
+		rate = ml.PointsRate
+	}
+	return int(math.Floor(amount * PointsEarnPerYuan * rate))
+}
+
+// PointsValue 积分可抵扣的金额
+func PointsValue(points int) float64 {
+	return roundAmount(float64(points) / PointsPerYuan)
+}
+
+// MaxRedeemablePoints 订单金额最多可使用的积分数
+func MaxRedeemablePoints(amount float64) int {
+	return int(math.Floor(amount * PointsMaxRedeemRate * PointsPerYuan))
+}
@@ -6,26 +6,31 @@ import (
 
 // Room 房间包厢模型
 type Room struct {
-	ID          int       `json:"id" gorm:"primaryKey;autoIncrement"`
-	RoomNumber  string    `json:"room_number" gorm:"column:room_number;uniqueIndex;not null;comment:房间号码"`
-	RoomName    string    `json:"room_name" gorm:"column:room_name;not null;comment:房间名称"`
-	RoomType    string    `json:"room_type" gorm:"column:room_type;not null;comment:房间类型(小包厢/中包厢/大包厢/豪华包厢)"`
-	Capacity    int       `json:"capacity" gorm:"column:capacity;not null;comment:容纳人数"`
-	HourlyRate  float64   `json:"hourly_rate" gorm:"column:hourly_rate;type:decimal(10,2);not null;comment:每小时价格"`
-	Features    string    `json:"features" gorm:"column:features;type:text;comment:房间特色设施(JSON格式)"`
-	Images      string    `json:"images" gorm:"column:images;type:text;comment:房间图片URLs(JSON格式)"`
-	Status      int       `json:"status" gorm:"column:status;default:1;comment:房间状态(1:可用,2:使用中,3:维护中,4:停用)"`
-	Floor       int       `json:"floor" gorm:"column:floor;comment:楼层"`
-	Area        float64   `json:"area" gorm:"column:area;type:decimal(8,2);comment:房间面积(平方米)"`
-	Description string    `json:"description" gorm:"column:description;type:text;comment:房间描述"`
-	CreatedBy   int       `json:"created_by" gorm:"column:created_by;comment:创建人ID"`
-	CreateTime  time.Time `json:"create_time" gorm:"column:create_time;autoCreateTime"`
-	UpdateTime  time.Time `json:"update_time" gorm:"column:update_time;autoUpdateTime"`
+	ID               int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	TenantsId        int       `json:"tenants_id" gorm:"column:tenants_id;uniqueIndex:uk_tenant_room_number,priority:1;default:0;comment:商家ID"`
+	RoomNumber       string    `json:"room_number" gorm:"column:room_number;uniqueIndex:uk_tenant_room_number,priority:2;not null;comment:房间号码"`
+	RoomName         string    `json:"room_name" gorm:"column:room_name;not null;comment:房间名称"`
+	RoomType         string    `json:"room_type" gorm:"column:room_type;not null;comment:房间类型(小包厢/中包厢/大包厢/豪华包厢)"`
+	Capacity         int       `json:"capacity" gorm:"column:capacity;not null;comment:容纳人数"`
+	HourlyRate       float64   `json:"hourly_rate" gorm:"column:hourly_rate;type:decimal(10,2);not null;comment:每小时价格"`
+	Features         string    `json:"features" gorm:"column:features;type:text;comment:房间特色设施(JSON格式)"`
+	Images           string    `json:"images" gorm:"column:images;type:text;comment:房间图片URLs(JSON格式)"`
+	Status           int       `json:"status" gorm:"column:status;default:1;comment:房间状态(1:可用,2:使用中,3:维护中,4:停用)"`
+	CleaningMin      int       `json:"cleaning_min" gorm:"column:cleaning_min;default:0;comment:预订前后清洁缓冲时间(分钟)"`
+	OvertimeUnitMin  int       `json:"overtime_unit_min" gorm:"column:overtime_unit_min;comment:超时计费单位(分钟)，0表示不收取超时费"`
+	OvertimeGraceMin int       `json:"overtime_grace_min" gorm:"column:overtime_grace_min;comment:超时免费宽限(分钟)"`
+	Floor            int       `json:"floor" gorm:"column:floor;comment:楼层"`
+	Area             float64   `json:"area" gorm:"column:area;type:decimal(8,2);comment:房间面积(平方米)"`
+	Description      string    `json:"description" gorm:"column:description;type:text;comment:房间描述"`
+	CreatedBy        int       `json:"created_by" gorm:"column:created_by;comment:创建人ID"`
+	CreateTime       time.Time `json:"create_time" gorm:"column:create_time;autoCreateTime"`
+	UpdateTime       time.Time `json:"update_time" gorm:"column:update_time;autoUpdateTime"`
 }
 
 // RoomBooking 房间预订模型
 type RoomBooking struct {
 	ID           int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	TenantsId    int       `json:"tenants_id" gorm:"column:tenants_id;index;default:0;comment:商家ID"`
 	RoomID       int       `json:"room_id" gorm:"column:room_id;not null;comment:房间ID"`
 	UserID       int       `json:"user_id" gorm:"column:user_id;not null;comment:用户ID"`
 	BookingNo    string    `json:"booking_no" gorm:"column:booking_no;uniqueIndex;not null;comment:预订单号"`
@@ -39,6 +44,7 @@ type RoomBooking struct {
 	ContactName  string    `json:"contact_name" gorm:"column:contact_name;comment:联系人姓名"`
 	ContactPhone string    `json:"contact_phone" gorm:"column:contact_phone;comment:联系人电话"`
 	Remarks      string    `json:"remarks" gorm:"column:remarks;type:text;comment:备注信息"`
+	SeriesID     *int      `json:"series_id" gorm:"column:series_id;index;comment:周期预订系列ID"`
 
 	// 套餐相关字段
 	PackageID      *int    `json:"package_id" gorm:"column:package_id;comment:使用的套餐ID"`
@@ -48,6 +54,19 @@ type RoomBooking struct {
 	DiscountAmount float64 `json:"discount_amount" gorm:"column:discount_amount;type:decimal(10,2);default:0;comment:优惠金额"`
 	PriceBreakdown string  `json:"price_breakdown" gorm:"column:price_breakdown;type:text;comment:价格明细JSON"`
 
+	// 优惠券相关字段
+	UserCouponID   *int    `json:"user_coupon_id" gorm:"column:user_coupon_id;comment:使用的用户优惠券ID"`
+	CouponDiscount float64 `json:"coupon_discount" gorm:"column:coupon_discount;type:decimal(10,2);default:0;comment:优惠券抵扣金额"`
+
+	// 积分抵扣
+	PointsUsed     int     `json:"points_used" gorm:"column:points_used;default:0;comment:使用的积分"`
+	PointsDiscount float64 `json:"points_discount" gorm:"column:points_discount;type:decimal(10,2);default:0;comment:积分抵扣金额"`
+
+	// 入场核验
+	VerifyCode string     `json:"-" gorm:"column:verify_code;comment:入场数字核验码"`
+	VerifiedAt *time.Time `json:"verified_at" gorm:"column:verified_at;comment:入场核验时间"`
+	VerifiedBy int        `json:"verified_by" gorm:"column:verified_by;default:0;comment:核验管理员ID"`
+
 	CreateTime time.Time `json:"create_time" gorm:"column:create_time;autoCreateTime"`
 	UpdateTime time.Time `json:"update_time" gorm:"column:update_time;autoUpdateTime"`
 
@@ -67,8 +86,18 @@ type RoomUsageLog struct {
 	CheckOutAt  *time.Time `json:"check_out_at" gorm:"column:check_out_at;comment:离开时间"`
 	ActualHours float64    `json:"actual_hours" gorm:"column:actual_hours;type:decimal(8,2);comment:实际使用小时数"`
 	ExtraFee    float64    `json:"extra_fee" gorm:"column:extra_fee;type:decimal(10,2);default:0;comment:额外费用"`
-	CreateTime  time.Time  `json:"create_time" gorm:"column:create_time;autoCreateTime"`
-	UpdateTime  time.Time  `json:"update_time" gorm:"column:update_time;autoUpdateTime"`
+
+	// 前台入住/退房结算
+	CheckInBy    int     `json:"check_in_by" gorm:"column:check_in_by;default:0;comment:办理入住的管理员ID，0表示系统自动开始"`
+	CheckOutBy   int     `json:"check_out_by" gorm:"column:check_out_by;default:0;comment:办理退房的管理员ID"`
+	OvertimeMin  int     `json:"overtime_min" gorm:"column:overtime_min;default:0;comment:超时分钟数"`
+	OvertimeFee  float64 `json:"overtime_fee" gorm:"column:overtime_fee;type:decimal(10,2);default:0;comment:超时费用"`
+	FeeStatus    string  `json:"fee_status" gorm:"column:fee_status;default:none;comment:额外费用状态(none/paid/receivable/settled)"`
+	FeePaymentID *int    `json:"fee_payment_id" gorm:"column:fee_payment_id;comment:额外费用钱包流水ID"`
+	Remarks      string  `json:"remarks" gorm:"column:remarks;type:text;comment:备注"`
+
+	CreateTime time.Time `json:"create_time" gorm:"column:create_time;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:update_time;autoUpdateTime"`
 }
 
 // 定义表名
@@ -84,6 +113,10 @@ func (RoomUsageLog) TableName() string {
 	return "room_usage_logs"
 }
 
+// TenantScoped 房间和预订按商家隔离
+func (Room) TenantScoped()        {}
+func (RoomBooking) TenantScoped() {}
+
 // 房间状态常量
 const (
 	RoomStatusAvailable   = 1 // 可用
@@ -102,6 +135,20 @@ const (
 	BookingStatusRefunded  = 6 // 已退款
 )
 
+// 超时计费默认配置
+const (
+	DefaultOvertimeUnitMin  = 30 // 默认按30分钟计费
+	DefaultOvertimeGraceMin = 10 // 默认超时10分钟内免费
+)
+
+// 额外费用状态常量
+const (
+	UsageFeeStatusNone       = "none"       // 无额外费用
+	UsageFeeStatusPaid       = "paid"       // 已从钱包扣款
+	UsageFeeStatusReceivable = "receivable" // 挂账待收
+	UsageFeeStatusSettled    = "settled"    // 挂账已线下收讫
+)
+
 // 房间类型常量
 const (
 	RoomTypeSmall  = "small"  // 小包厢
@@ -0,0 +1,65 @@
+package app_model
+
+import "time"
+
+// RoomBlackout 房间维护/停业时段，生效期间房间不可预订
+// room_id 为0表示全场停业，作用于同一商家的所有房间
+type RoomBlackout struct {
+	ID         int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	TenantsId  int       `json:"tenants_id" gorm:"column:tenants_id;index;default:0;comment:商家ID"`
+	RoomID     int       `json:"room_id" gorm:"column:room_id;index;default:0;comment:房间ID，0表示全场"`
+	Type       string    `json:"type" gorm:"column:type;not null;comment:类型(maintenance:维护,closed:停业)"`
+	Reason     string    `json:"reason" gorm:"column:reason;not null;comment:原因"`
+	StartTime  time.Time `json:"start_time" gorm:"column:start_time;not null;comment:开始时间"`
+	EndTime    time.Time `json:"end_time" gorm:"column:end_time;not null;comment:结束时间"`
+	Status     int       `json:"status" gorm:"column:status;default:1;comment:状态(1:生效,2:已取消)"`
+	OperatorID int       `json:"operator_id" gorm:"column:operator_id;default:0;comment:创建人ID"`
+	CreateTime time.Time `json:"create_time" gorm:"column:create_time;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:update_time;autoUpdateTime"`
+
+	// 关联查询字段
+	Room *Room `json:"room,omitempty" gorm:"foreignKey:RoomID"`
+}
+
+func (RoomBlackout) TableName() string {
+	return "room_blackouts"
+}
+
+// TenantScoped 维护/停业时段按商家隔离
+func (RoomBlackout) TenantScoped() {}
+
+// 维护/停业时段类型
+const (
+	BlackoutTypeMaintenance = "maintenance" // 维护
+	BlackoutTypeClosed      = "closed"      // 停业
+)
+
+// 维护/停业时段状态
+const (
+	BlackoutStatusActive    = 1 // 生效
+	BlackoutStatusCancelled = 2 // 已取消
+)
+
+// GetTypeText 获取类型文本
+func (b *RoomBlackout) GetTypeText() string {
+	switch b.Type {
+	case BlackoutTypeMaintenance:
+		return "维护"
+	case BlackoutTypeClosed:
+		return "停业"
+	default:
+		return "未知类型"
+	}
+}
+
+// GetStatusText 获取状态文本
+func (b *RoomBlackout) GetStatusText() string {
+	switch b.Status {
+	case BlackoutStatusActive:
+		return "生效"
+	case BlackoutStatusCancelled:
+		return "已取消"
+	default:
+		return "未知状态"
+	}
+}
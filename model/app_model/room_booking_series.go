@@ -0,0 +1,63 @@
+package app_model
+
+import "time"
+
+// RoomBookingSeries 周期预订系列，按重复规则生成的各次预订通过 series_id 关联
+type RoomBookingSeries struct {
+	ID           int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	TenantsId    int        `json:"tenants_id" gorm:"column:tenants_id;index;default:0;comment:商家ID"`
+	SeriesNo     string     `json:"series_no" gorm:"column:series_no;uniqueIndex;not null;comment:系列单号"`
+	RoomID       int        `json:"room_id" gorm:"column:room_id;not null;comment:房间ID"`
+	UserID       int        `json:"user_id" gorm:"column:user_id;index;not null;comment:用户ID"`
+	Rule         string     `json:"rule" gorm:"column:rule;not null;comment:重复规则(RRULE格式)"`
+	Freq         string     `json:"freq" gorm:"column:freq;not null;comment:重复频率(daily/weekly)"`
+	Interval     int        `json:"interval" gorm:"column:interval;default:1;comment:重复间隔"`
+	ByDay        string     `json:"by_day" gorm:"column:by_day;comment:每周重复的星期(MO,TU...)"`
+	Until        *time.Time `json:"until" gorm:"column:until;comment:重复截止日期"`
+	Count        int        `json:"count" gorm:"column:count;default:0;comment:重复次数"`
+	StartTime    time.Time  `json:"start_time" gorm:"column:start_time;not null;comment:首次开始时间"`
+	Hours        int        `json:"hours" gorm:"column:hours;not null;comment:每次预订小时数"`
+	PackageID    *int       `json:"package_id" gorm:"column:package_id;comment:使用的套餐ID"`
+	Occurrences  int        `json:"occurrences" gorm:"column:occurrences;not null;comment:生成的预订次数"`
+	TotalAmount  float64    `json:"total_amount" gorm:"column:total_amount;type:decimal(10,2);not null;comment:各次预订总金额"`
+	Status       int        `json:"status" gorm:"column:status;default:1;comment:系列状态(1:进行中,2:已取消)"`
+	ContactName  string     `json:"contact_name" gorm:"column:contact_name;comment:联系人姓名"`
+	ContactPhone string     `json:"contact_phone" gorm:"column:contact_phone;comment:联系人电话"`
+	Remarks      string     `json:"remarks" gorm:"column:remarks;type:text;comment:备注信息"`
+	CreateTime   time.Time  `json:"create_time" gorm:"column:create_time;autoCreateTime"`
+	UpdateTime   time.Time  `json:"update_time" gorm:"column:update_time;autoUpdateTime"`
+}
+
+func (RoomBookingSeries) TableName() string {
+	return "room_booking_series"
+}
+
+// TenantScoped 周期预订系列按商家隔离
+func (RoomBookingSeries) TenantScoped() {}
+
+// 周期预订系列状态
+const (
+	BookingSeriesStatusActive    = 1 // 进行中
+	BookingSeriesStatusCancelled = 2 // 已取消
+)
+
+// 重复频率
+const (
+	RecurrenceDaily  = "daily"
+	RecurrenceWeekly = "weekly"
+)
+
+// MaxSeriesOccurrences 单个系列最多生成的预订次数
+const MaxSeriesOccurrences = 52
+
+// GetStatusText 获取系列状态文本
+func (s *RoomBookingSeries) GetStatusText() string {
+	switch s.Status {
+	case BookingSeriesStatusActive:
+		return "进行中"
+	case BookingSeriesStatusCancelled:
+		return "已取消"
+	default:
+		return "未知状态"
+	}
+}
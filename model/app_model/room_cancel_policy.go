@@ -0,0 +1,132 @@
+package app_model
+
+import (
+	"math"
+	"time"
+)
+
+// RoomCancelPolicy 预订取消退款政策
+// 优先级：套餐政策 > 房间政策 > 全局政策(room_id、package_id 均为空) > 系统默认政策
+type RoomCancelPolicy struct {
+	ID              int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	PolicyName      string    `json:"policy_name" gorm:"column:policy_name;not null;comment:政策名称"`
+	RoomID          *int      `json:"room_id" gorm:"column:room_id;comment:适用房间ID，为空表示不限房间"`
+	PackageID       *int      `json:"package_id" gorm:"column:package_id;comment:适用套餐ID，为空表示不限套餐"`
+	FreeCancelHours int       `json:"free_cancel_hours" gorm:"column:free_cancel_hours;default:2;comment:开始前N小时以上取消全额退款"`
+	FeePercent      float64   `json:"fee_percent" gorm:"column:fee_percent;type:decimal(5,2);default:0;comment:免费取消窗口内取消的手续费百分比(0-100)"`
+	Description     string    `json:"description" gorm:"column:description;type:text;comment:政策说明"`
+	IsActive        bool      `json:"is_active" gorm:"column:is_active;default:true;comment:是否启用"`
+	CreateTime      time.Time `json:"create_time" gorm:"column:create_time;autoCreateTime"`
+	UpdateTime      time.Time `json:"update_time" gorm:"column:update_time;autoUpdateTime"`
+}
+
+func (RoomCancelPolicy) TableName() string {
+	return "room_cancel_policies"
+}
+
+// 系统默认取消政策（未配置任何政策时使用）
+const (
+	DefaultFreeCancelHours = 2    // 开始前2小时以上取消全额退款
+	DefaultCancelFeePct    = 20.0 // 2小时内取消收取20%手续费
+)
+
+// 取消政策适用范围
+const (
+	CancelPolicyScopePackage  = "package"  // 套餐政策
+	CancelPolicyScopeRoom     = "room"     // 房间政策
+	CancelPolicyScopeGlobal   = "global"   // 全局政策
+	CancelPolicyScopeDefault  = "default"  // 系统默认政策
+	CancelPolicyScopeMerchant = "merchant" // 商家原因取消，全额退款
+)
+
+// 退款规则类型
+const (
+	RefundRuleFull    = "full_refund"    // 全额退款
+	RefundRulePartial = "partial_refund" // 扣除手续费后退款
+	RefundRuleNone    = "no_refund"      // 不予退款
+)
+
+// DefaultCancelPolicy 系统默认取消政策
+func DefaultCancelPolicy() *RoomCancelPolicy {
+	return &RoomCancelPolicy{
+		PolicyName:      "系统默认取消政策",
+		FreeCancelHours: DefaultFreeCancelHours,
+		FeePercent:      DefaultCancelFeePct,
+		IsActive:        true,
+	}
+}
+
+// SelectCancelPolicy 从启用的候选政策中按 套餐 > 房间 > 全局 > 系统默认 选出适用政策，同一级别取ID最大的政策
+func SelectCancelPolicy(policies []RoomCancelPolicy, roomID int, packageID *int) (*RoomCancelPolicy, string) {
+	var matched [3]*RoomCancelPolicy
+	for i := range policies {
+		p := &policies[i]
+		if !p.IsActive {
+			continue
+		}
+
+		level := -1
+		switch {
+		case p.PackageID != nil:
+			if packageID != nil && *p.PackageID == *packageID {
+				level = 0
+			}
+		case p.RoomID != nil:
+			if *p.RoomID == roomID {
+				level = 1
+			}
+		default:
+			level = 2
+		}
+		if level >= 0 && (matched[level] == nil || p.ID > matched[level].ID) {
+			matched[level] = p
+		}
+	}
+
+	scopes := [3]string{CancelPolicyScopePackage, CancelPolicyScopeRoom, CancelPolicyScopeGlobal}
+	for level, p := range matched {
+		if p != nil {
+			return p, scopes[level]
+		}
+	}
+	return DefaultCancelPolicy(), CancelPolicyScopeDefault
+}
+
+// CalculateRefund 根据取消时间计算退款金额和手续费
+func (p *RoomCancelPolicy) CalculateRefund(paidAmount float64, startTime, cancelTime time.Time) (refundAmount, feeAmount float64, rule string) {
+	if paidAmount <= 0 {
+		return 0, 0, RefundRuleNone
+	}
+
+	// 已开始的预订不予退款
+	if !cancelTime.Before(startTime) {
+		return 0, paidAmount, RefundRuleNone
+	}
+
+	// 距开始时间超过免费取消窗口，全额退款
+	if startTime.Sub(cancelTime) >= time.Duration(p.FreeCancelHours)*time.Hour {
+		return paidAmount, 0, RefundRuleFull
+	}
+
+	// 免费取消窗口内，按比例扣除手续费
+	feePercent := p.FeePercent
+	if feePercent < 0 {
+		feePercent = 0
+	}
+	if feePercent > 100 {
+		feePercent = 100
+	}
+
+	feeAmount = roundAmount(paidAmount * feePercent / 100)
+	refundAmount = roundAmount(paidAmount - feeAmount)
+	if refundAmount <= 0 {
+		return 0, paidAmount, RefundRuleNone
+	}
+
+	return refundAmount, feeAmount, RefundRulePartial
+}
+
+// roundAmount 金额保留两位小数
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
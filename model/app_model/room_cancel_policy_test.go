@@ -0,0 +1,86 @@
+package app_model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalculateRefund(t *testing.T) {
+	start := time.Date(2024, 1, 1, 14, 0, 0, 0, time.Local)
+	policy := &RoomCancelPolicy{FreeCancelHours: 2, FeePercent: 20}
+
+	tests := []struct {
+		name       string
+		policy     *RoomCancelPolicy
+		paid       float64
+		cancelTime time.Time
+		wantRefund float64
+		wantFee    float64
+		wantRule   string
+	}{
+		{"窗口外全额退款", policy, 100, start.Add(-3 * time.Hour), 100, 0, RefundRuleFull},
+		{"恰好在窗口边界全额退款", policy, 100, start.Add(-2 * time.Hour), 100, 0, RefundRuleFull},
+		{"窗口内一秒扣手续费", policy, 100, start.Add(-2*time.Hour + time.Second), 80, 20, RefundRulePartial},
+		{"手续费按比例四舍五入", &RoomCancelPolicy{FreeCancelHours: 2, FeePercent: 15}, 88.88, start.Add(-time.Hour), 75.55, 13.33, RefundRulePartial},
+		{"手续费为0时窗口内全额退回", &RoomCancelPolicy{FreeCancelHours: 24, FeePercent: 0}, 100, start.Add(-time.Hour), 100, 0, RefundRulePartial},
+		{"手续费超过100按100计", &RoomCancelPolicy{FreeCancelHours: 2, FeePercent: 150}, 100, start.Add(-time.Hour), 0, 100, RefundRuleNone},
+		{"负手续费按0计", &RoomCancelPolicy{FreeCancelHours: 2, FeePercent: -10}, 100, start.Add(-time.Hour), 100, 0, RefundRulePartial},
+		{"开始时取消不退款", policy, 100, start, 0, 100, RefundRuleNone},
+		{"开始后取消不退款", policy, 100, start.Add(time.Minute), 0, 100, RefundRuleNone},
+		{"未支付不退款", policy, 0, start.Add(-3 * time.Hour), 0, 0, RefundRuleNone},
+		{"系统默认政策窗口内扣20%", DefaultCancelPolicy(), 150, start.Add(-90 * time.Minute), 120, 30, RefundRulePartial},
+		{"系统默认政策窗口外全额退款", DefaultCancelPolicy(), 150, start.Add(-2 * time.Hour), 150, 0, RefundRuleFull},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refund, fee, rule := tt.policy.CalculateRefund(tt.paid, start, tt.cancelTime)
+			if refund != tt.wantRefund || fee != tt.wantFee || rule != tt.wantRule {
+				t.Errorf("CalculateRefund() = (%v, %v, %s), want (%v, %v, %s)",
+					refund, fee, rule, tt.wantRefund, tt.wantFee, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestSelectCancelPolicy(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+
+	global := RoomCancelPolicy{ID: 1, PolicyName: "全局", IsActive: true}
+	newerGlobal := RoomCancelPolicy{ID: 5, PolicyName: "全局-新", IsActive: true}
+	room := RoomCancelPolicy{ID: 2, PolicyName: "房间", RoomID: intPtr(10), IsActive: true}
+	otherRoom := RoomCancelPolicy{ID: 3, PolicyName: "其他房间", RoomID: intPtr(11), IsActive: true}
+	pkg := RoomCancelPolicy{ID: 4, PolicyName: "套餐", RoomID: intPtr(10), PackageID: intPtr(100), IsActive: true}
+	inactivePkg := RoomCancelPolicy{ID: 6, PolicyName: "停用套餐", PackageID: intPtr(100), IsActive: false}
+
+	tests := []struct {
+		name      string
+		policies  []RoomCancelPolicy
+		packageID *int
+		wantName  string
+		wantScope string
+	}{
+		{"套餐优先于房间和全局", []RoomCancelPolicy{global, room, pkg}, intPtr(100), "套餐", CancelPolicyScopePackage},
+		{"未使用套餐时忽略套餐政策", []RoomCancelPolicy{global, room, pkg}, nil, "房间", CancelPolicyScopeRoom},
+		{"其他套餐的政策不适用", []RoomCancelPolicy{global, room, pkg}, intPtr(200), "房间", CancelPolicyScopeRoom},
+		{"房间优先于全局", []RoomCancelPolicy{global, room}, intPtr(100), "房间", CancelPolicyScopeRoom},
+		{"其他房间的政策不适用", []RoomCancelPolicy{global, otherRoom}, nil, "全局", CancelPolicyScopeGlobal},
+		{"同级取ID最大的政策", []RoomCancelPolicy{newerGlobal, global}, nil, "全局-新", CancelPolicyScopeGlobal},
+		{"停用的政策不适用", []RoomCancelPolicy{inactivePkg, global}, intPtr(100), "全局", CancelPolicyScopeGlobal},
+		{"没有政策时使用系统默认", nil, nil, "系统默认取消政策", CancelPolicyScopeDefault},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, scope := SelectCancelPolicy(tt.policies, 10, tt.packageID)
+			if policy.PolicyName != tt.wantName || scope != tt.wantScope {
+				t.Errorf("SelectCancelPolicy() = (%s, %s), want (%s, %s)", policy.PolicyName, scope, tt.wantName, tt.wantScope)
+			}
+		})
+	}
+
+	policy, _ := SelectCancelPolicy(nil, 10, nil)
+	if policy.FreeCancelHours != DefaultFreeCancelHours || policy.FeePercent != DefaultCancelFeePct {
+		t.Errorf("默认政策 = (%d, %v), want (%d, %v)", policy.FreeCancelHours, policy.FeePercent, DefaultFreeCancelHours, DefaultCancelFeePct)
+	}
+}
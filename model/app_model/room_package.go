@@ -1,13 +1,13 @@
 package app_model
 
 import (
-	"fmt"
 	"time"
 )
 
 // RoomPackage 房间套餐规则
 type RoomPackage struct {
 	ID          int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	TenantsId   int        `json:"tenants_id" gorm:"column:tenants_id;index;default:0;comment:商家ID"`
 	RoomID      int        `json:"room_id" gorm:"column:room_id;not null;comment:房间ID"`
 	PackageName string     `json:"package_name" gorm:"column:package_name;not null;comment:套餐名称"`
 	Description string     `json:"description" gorm:"column:description;type:text;comment:套餐描述"`
@@ -16,6 +16,8 @@ type RoomPackage struct {
 	MinHours    int        `json:"min_hours" gorm:"column:min_hours;default:1;comment:最少预订小时数"`
 	MaxHours    int        `json:"max_hours" gorm:"column:max_hours;default:24;comment:最多预订小时数"`
 	BasePrice   float64    `json:"base_price" gorm:"column:base_price;type:decimal(10,2);default:0;comment:套餐基础价格"`
+	MinCharge   float64    `json:"min_charge" gorm:"column:min_charge;type:decimal(10,2);default:0;comment:最低消费，0表示不限"`
+	MaxCharge   float64    `json:"max_charge" gorm:"column:max_charge;type:decimal(10,2);default:0;comment:封顶价格，0表示不限"`
 	IsActive    bool       `json:"is_active" gorm:"column:is_active;default:true;comment:是否启用"`
 	Priority    int        `json:"priority" gorm:"column:priority;default:0;comment:优先级，数字越大优先级越高"`
 	StartDate   *time.Time `json:"start_date" gorm:"column:start_date;comment:生效开始日期"`
@@ -51,8 +53,8 @@ type RoomPackageRule struct {
 // RoomSpecialDate 特殊日期配置（节假日、特殊活动日等）
 type RoomSpecialDate struct {
 	ID          int       `json:"id" gorm:"primaryKey;autoIncrement"`
-	Date        time.Time `json:"date" gorm:"column:date;type:date;not null;comment:特殊日期"`
-	DateType    string    `json:"date_type" gorm:"column:date_type;not null;comment:日期类型(holiday/festival/special)"`
+	Date        time.Time `json:"date" gorm:"column:date;type:date;uniqueIndex:uk_date;not null;comment:特殊日期"`
+	DateType    string    `json:"date_type" gorm:"column:date_type;not null;comment:日期类型(holiday/festival/special/workday)"`
 	Name        string    `json:"name" gorm:"column:name;not null;comment:日期名称"`
 	Description string    `json:"description" gorm:"column:description;type:text;comment:描述"`
 	IsActive    bool      `json:"is_active" gorm:"column:is_active;default:true;comment:是否启用"`
@@ -73,6 +75,9 @@ func (RoomSpecialDate) TableName() string {
 	return "room_special_dates"
 }
 
+// TenantScoped 套餐按商家隔离
+func (RoomPackage) TenantScoped() {}
+
 // 日期类型常量
 const (
 	DayTypeWeekday = "weekday" // 工作日
@@ -93,6 +98,7 @@ const (
 	SpecialDateHoliday  = "holiday"  // 法定节假日
 	SpecialDateFestival = "festival" // 传统节日
 	SpecialDateSpecial  = "special"  // 特殊活动日
+	SpecialDateWorkday  = "workday"  // 调休上班日
 )
 
 // 套餐类型常量
@@ -103,43 +109,47 @@ const (
 	PackageTypeWeekly     = "weekly"      // 周套餐(7天)
 )
 
-// GetDayType 根据日期获取日期类型
+// DayTypeResolver 按日期解析定价日期类型
+type DayTypeResolver func(date time.Time) string
+
+// dayTypeResolver 由节假日日历服务注册，结合 room_special_dates 解析节假日和调休
+var dayTypeResolver DayTypeResolver
+
+// SetDayTypeResolver 注册日期类型解析器
+func SetDayTypeResolver(resolver DayTypeResolver) {
+	dayTypeResolver = resolver
+}
+
+// GetDayType 根据日期获取日期类型，未注册节假日日历时仅区分工作日和周末
 func GetDayType(date time.Time) string {
-	// 检查是否为特殊日期（这里可以查询数据库或配置）
-	if IsSpecialDate(date) {
-		return DayTypeSpecial
+	if dayTypeResolver != nil {
+		return dayTypeResolver(date)
 	}
+	return GetWeekDayType(date)
+}
 
-	// 检查是否为周末
+// GetWeekDayType 仅按星期区分工作日和周末
+func GetWeekDayType(date time.Time) string {
 	weekday := date.Weekday()
 	if weekday == time.Saturday || weekday == time.Sunday {
 		return DayTypeWeekend
 	}
-
-	// 默认为工作日
 	return DayTypeWeekday
 }
 
-// IsSpecialDate 检查是否为特殊日期（这里是示例，实际应该查询数据库）
-func IsSpecialDate(date time.Time) bool {
-	// 这里可以查询 room_special_dates 表
-	// 为了示例，我们硬编码一些特殊日期
-	dateStr := date.Format("01-02")
-	specialDates := []string{
-		"01-01", // 元旦
-		"02-14", // 情人节
-		"05-01", // 劳动节
-		"06-01", // 儿童节
-		"10-01", // 国庆节
-		"12-25", // 圣诞节
-	}
-
-	for _, special := range specialDates {
-		if dateStr == special {
-			return true
-		}
+// SpecialDateDayType 特殊日期类型对应的定价日期类型，未知类型返回空字符串
+func SpecialDateDayType(dateType string) string {
+	switch dateType {
+	case SpecialDateHoliday, SpecialDateFestival:
+		return DayTypeHoliday
+	case SpecialDateSpecial:
+		return DayTypeSpecial
+	case SpecialDateWorkday:
+		// 调休上班的周末按工作日计价
+		return DayTypeWeekday
+	default:
+		return ""
 	}
-	return false
 }
 
 // CalculatePrice 根据套餐规则计算价格
@@ -156,92 +166,12 @@ func (rp *RoomPackage) CalculatePrice(basePrice float64, startTime time.Time, re
 		return basePrice, nil, nil
 	}
 
-	// 检查套餐类型和时长限制
-	actualHours := requestedHours
-	switch rp.PackageType {
-	case PackageTypeFixedHours:
-		// 固定时长套餐：必须使用固定小时数
-		if rp.FixedHours > 0 {
-			actualHours = rp.FixedHours
-		}
-	case PackageTypeDaily:
-		// 全天套餐：固定24小时
-		actualHours = 24
-	case PackageTypeWeekly:
-		// 周套餐：固定168小时(7天)
-		actualHours = 168
-	case PackageTypeFlexible:
-		// 灵活套餐：检查最小最大时长限制
-		if requestedHours < rp.MinHours || requestedHours > rp.MaxHours {
-			return basePrice, nil, fmt.Errorf("预订时长必须在 %d-%d 小时之间", rp.MinHours, rp.MaxHours)
-		}
-	}
-
-	// 获取日期类型
-	dayType := GetDayType(startTime)
-	timeStr := startTime.Format("15:04")
-
-	// 找到匹配的规则，按优先级排序
-	var matchedRule *RoomPackageRule
-	for _, rule := range rp.Rules {
-		if !rule.IsActive {
-			continue
-		}
-
-		// 检查日期类型匹配
-		if rule.DayType != dayType {
-			continue
-		}
-
-		// 检查时间段匹配
-		if rule.TimeStart != "" && rule.TimeEnd != "" {
-			if timeStr < rule.TimeStart || timeStr >= rule.TimeEnd {
-				continue
-			}
-		}
-
-		// 检查小时数限制
-		if actualHours < rule.MinHours || actualHours > rule.MaxHours {
-			continue
-		}
-
-		// 找到匹配的规则
-		matchedRule = &rule
-		break
-	}
-
-	// 使用套餐基础价格或房间基础价格
-	priceBase := basePrice
-	if rp.BasePrice > 0 {
-		priceBase = rp.BasePrice
-	}
-
-	// 计算最终价格
-	var finalPrice float64
-	if matchedRule != nil {
-		switch matchedRule.PriceType {
-		case PriceTypeFixed:
-			finalPrice = matchedRule.PriceValue
-		case PriceTypeMultiply:
-			finalPrice = priceBase * matchedRule.PriceValue
-		case PriceTypeAdd:
-			finalPrice = priceBase + matchedRule.PriceValue
-		default:
-			finalPrice = priceBase
-		}
-	} else {
-		// 没有匹配的规则，使用基础价格
-		finalPrice = priceBase
-	}
-
-	// 对于固定时长套餐，价格通常是总价，而不是按小时计算
-	if rp.PackageType == PackageTypeFixedHours || rp.PackageType == PackageTypeDaily || rp.PackageType == PackageTypeWeekly {
-		// 固定套餐价格就是最终价格
-		return finalPrice, matchedRule, nil
-	} else {
-		// 灵活套餐按小时计算
-		return finalPrice * float64(actualHours), matchedRule, nil
+	// 按规则和日期边界分段计价
+	quote, err := rp.Quote(basePrice, startTime, requestedHours)
+	if err != nil {
+		return basePrice, nil, err
 	}
+	return quote.FinalPrice, quote.FirstRule(), nil
 }
 
 // GetActivePackage 获取房间的有效套餐（按优先级排序）
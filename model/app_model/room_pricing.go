@@ -0,0 +1,329 @@
+package app_model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// PriceLineItem 分段计价明细，同一规则、同一日期类型的连续时段合并为一行
+type PriceLineItem struct {
+	StartTime   string  `json:"start_time"`
+	EndTime     string  `json:"end_time"`
+	Hours       float64 `json:"hours"`
+	DayType     string  `json:"day_type"`
+	RuleID      int     `json:"rule_id,omitempty"`
+	RuleName    string  `json:"rule_name,omitempty"`
+	PriceType   string  `json:"price_type,omitempty"`
+	PriceValue  float64 `json:"price_value,omitempty"`
+	HourlyPrice float64 `json:"hourly_price"` // 固定时长套餐为0，金额即套餐总价
+	Amount      float64 `json:"amount"`
+}
+
+// PriceQuote 预订计价结果，序列化后保存到 RoomBooking.PriceBreakdown
+type PriceQuote struct {
+	BasePrice        float64         `json:"base_price"` // 房间每小时价格
+	Hours            int             `json:"hours"`
+	OriginalPrice    float64         `json:"original_price"` // 按房间小时价计算的原价
+	PackageID        int             `json:"package_id,omitempty"`
+	PackageName      string          `json:"package_name,omitempty"`
+	PackageType      string          `json:"package_type,omitempty"`
+	SubTotal         float64         `json:"sub_total"`  // 分段金额合计
+	Adjustment       float64         `json:"adjustment"` // 封顶/最低消费调整金额
+	AdjustmentReason string          `json:"adjustment_reason,omitempty"`
+	FinalPrice       float64         `json:"final_price"`
+	DiscountAmount   float64         `json:"discount_amount"`
+	RuleName         string          `json:"rule_name,omitempty"` // 首段命中的规则，兼容旧版明细
+	LineItems        []PriceLineItem `json:"line_items"`
+	CouponCode       string          `json:"coupon_code,omitempty"`
+	CouponDiscount   float64         `json:"coupon_discount,omitempty"` // 优惠券抵扣，在 FinalPrice 基础上扣减
+	PointsUsed       int             `json:"points_used,omitempty"`
+	PointsDiscount   float64         `json:"points_discount,omitempty"` // 积分抵扣，在优惠券之后扣减
+
+	firstRule *RoomPackageRule
+}
+
+// FirstRule 首段命中的规则
+func (q *PriceQuote) FirstRule() *RoomPackageRule {
+	return q.firstRule
+}
+
+// NewBasePriceQuote 不使用套餐时按房间小时价计价
+func NewBasePriceQuote(basePrice float64, startTime time.Time, hours int) *PriceQuote {
+	amount := roundAmount(basePrice * float64(hours))
+	return &PriceQuote{
+		BasePrice:     basePrice,
+		Hours:         hours,
+		OriginalPrice: amount,
+		SubTotal:      amount,
+		FinalPrice:    amount,
+		LineItems: []PriceLineItem{{
+			StartTime:   startTime.Format("2006-01-02 15:04:05"),
+			EndTime:     startTime.Add(time.Duration(hours) * time.Hour).Format("2006-01-02 15:04:05"),
+			Hours:       float64(hours),
+			DayType:     GetDayType(startTime),
+			HourlyPrice: basePrice,
+			Amount:      amount,
+		}},
+	}
+}
+
+// Quote 按套餐规则分段计价
+// 灵活时长套餐在每个规则时间边界和日期边界处切分，各段按命中的规则独立计价；
+// 固定时长/全天/周套餐按开始时间命中的规则整体计价。最后应用套餐封顶价和最低消费。
+func (rp *RoomPackage) Quote(basePrice float64, startTime time.Time, requestedHours int) (*PriceQuote, error) {
+	if !rp.IsActive {
+		return nil, fmt.Errorf("套餐未启用")
+	}
+	if rp.StartDate != nil && startTime.Before(*rp.StartDate) {
+		return nil, fmt.Errorf("套餐尚未生效")
+	}
+	if rp.EndDate != nil && startTime.After(*rp.EndDate) {
+		return nil, fmt.Errorf("套餐已过期")
+	}
+
+	actualHours := requestedHours
+	switch rp.PackageType {
+	case PackageTypeFixedHours:
+		if rp.FixedHours > 0 {
+			actualHours = rp.FixedHours
+		}
+	case PackageTypeDaily:
+		actualHours = 24
+	case PackageTypeWeekly:
+		actualHours = 168
+	case PackageTypeFlexible:
+		if requestedHours < rp.MinHours || requestedHours > rp.MaxHours {
+			return nil, fmt.Errorf("预订时长必须在 %d-%d 小时之间", rp.MinHours, rp.MaxHours)
+		}
+	}
+
+	// 套餐设置了基础价格时以套餐价为准
+	priceBase := basePrice
+	if rp.BasePrice > 0 {
+		priceBase = rp.BasePrice
+	}
+
+	quote := &PriceQuote{
+		BasePrice:     basePrice,
+		Hours:         actualHours,
+		OriginalPrice: roundAmount(basePrice * float64(actualHours)),
+		PackageID:     rp.ID,
+		PackageName:   rp.PackageName,
+		PackageType:   rp.PackageType,
+	}
+
+	endTime := startTime.Add(time.Duration(actualHours) * time.Hour)
+	if rp.isFixedPackage() {
+		rule := rp.matchRule(startTime, actualHours)
+		amount := roundAmount(applyPriceRule(rule, priceBase))
+		quote.LineItems = []PriceLineItem{newPriceLineItem(startTime, endTime, GetDayType(startTime), rule, 0, amount)}
+		quote.firstRule = rule
+	} else {
+		quote.LineItems, quote.firstRule = rp.splitQuote(priceBase, startTime, endTime, actualHours)
+	}
+
+	for _, item := range quote.LineItems {
+		quote.SubTotal += item.Amount
+	}
+	quote.SubTotal = roundAmount(quote.SubTotal)
+	quote.FinalPrice = quote.SubTotal
+
+	// 封顶价和最低消费
+	if rp.MaxCharge > 0 && quote.FinalPrice > rp.MaxCharge {
+		quote.FinalPrice = rp.MaxCharge
+		quote.AdjustmentReason = fmt.Sprintf("套餐封顶价 %.2f", rp.MaxCharge)
+	} else if rp.MinCharge > 0 && quote.FinalPrice < rp.MinCharge {
+		quote.FinalPrice = rp.MinCharge
+		quote.AdjustmentReason = fmt.Sprintf("套餐最低消费 %.2f", rp.MinCharge)
+	}
+	quote.Adjustment = roundAmount(quote.FinalPrice - quote.SubTotal)
+	quote.DiscountAmount = roundAmount(quote.OriginalPrice - quote.FinalPrice)
+	if quote.firstRule != nil {
+		quote.RuleName = quote.firstRule.RuleName
+	}
+
+	return quote, nil
+}
+
+// QuoteTimeRange 按当前规则价计算续时或超时时段 [startTime, endTime) 的费用
+// 灵活时长套餐按时段命中的规则逐段计价，规则的时长条件按整单时长 totalHours 匹配；
+// 未使用套餐、套餐已停用或为固定时长/全天/周套餐时按房间小时价计价。不应用封顶价和最低消费。
+func QuoteTimeRange(pkg *RoomPackage, basePrice float64, startTime, endTime time.Time, totalHours int) ([]PriceLineItem, float64) {
+	var items []PriceLineItem
+	if pkg != nil && pkg.IsActive && !pkg.isFixedPackage() {
+		priceBase := basePrice
+		if pkg.BasePrice > 0 {
+			priceBase = pkg.BasePrice
+		}
+		items, _ = pkg.splitQuote(priceBase, startTime, endTime, totalHours)
+	} else {
+		amount := roundAmount(basePrice * endTime.Sub(startTime).Hours())
+		items = []PriceLineItem{newPriceLineItem(startTime, endTime, GetDayType(startTime), nil, basePrice, amount)}
+	}
+
+	var total float64
+	for _, item := range items {
+		total += item.Amount
+	}
+	return items, roundAmount(total)
+}
+
+// isFixedPackage 是否按套餐总价计价
+func (rp *RoomPackage) isFixedPackage() bool {
+	return rp.PackageType == PackageTypeFixedHours || rp.PackageType == PackageTypeDaily || rp.PackageType == PackageTypeWeekly
+}
+
+// splitQuote 在规则和日期边界处切分时段并逐段计价
+func (rp *RoomPackage) splitQuote(priceBase float64, startTime, endTime time.Time, totalHours int) ([]PriceLineItem, *RoomPackageRule) {
+	cuts := rp.boundaries(startTime, endTime)
+
+	var items []PriceLineItem
+	var firstRule *RoomPackageRule
+	var lastRuleID int
+	var lastDayType string
+	var lastHourly, lastHours float64 // 当前明细行未取整的小时价和累计时长
+	for i := 0; i+1 < len(cuts); i++ {
+		segStart, segEnd := cuts[i], cuts[i+1]
+		dayType := GetDayType(segStart)
+		rule := rp.matchRuleForDayType(segStart, dayType, totalHours)
+		if i == 0 {
+			firstRule = rule
+		}
+
+		ruleID := 0
+		if rule != nil {
+			ruleID = rule.ID
+		}
+		hourly := applyPriceRule(rule, priceBase)
+		hours := segEnd.Sub(segStart).Hours()
+
+		// 与上一段规则和日期类型相同则合并，金额按未取整的小时价重算，与单段计价一致
+		if len(items) > 0 && ruleID == lastRuleID && dayType == lastDayType {
+			lastHours += hours
+			last := &items[len(items)-1]
+			last.EndTime = segEnd.Format("2006-01-02 15:04:05")
+			last.Hours = roundAmount(lastHours)
+			last.Amount = roundAmount(lastHourly * lastHours)
+			continue
+		}
+
+		items = append(items, newPriceLineItem(segStart, segEnd, dayType, rule, hourly, roundAmount(hourly*hours)))
+		lastRuleID, lastDayType = ruleID, dayType
+		lastHourly, lastHours = hourly, hours
+	}
+
+	return items, firstRule
+}
+
+// boundaries 计算 [startTime, endTime) 内的全部切分点：起止时间、每日零点、各规则的起止时刻
+func (rp *RoomPackage) boundaries(startTime, endTime time.Time) []time.Time {
+	points := map[int64]time.Time{
+		startTime.Unix(): startTime,
+		endTime.Unix():   endTime,
+	}
+
+	day := time.Date(startTime.Year(), startTime.Month(), startTime.Day(), 0, 0, 0, 0, startTime.Location())
+	for ; day.Before(endTime); day = day.AddDate(0, 0, 1) {
+		candidates := []time.Time{day}
+		for _, rule := range rp.Rules {
+			if !rule.IsActive {
+				continue
+			}
+			for _, clock := range []string{rule.TimeStart, rule.TimeEnd} {
+				if offset, ok := parseClock(clock); ok {
+					candidates = append(candidates, day.Add(offset))
+				}
+			}
+		}
+		for _, t := range candidates {
+			if t.After(startTime) && t.Before(endTime) {
+				points[t.Unix()] = t
+			}
+		}
+	}
+
+	cuts := make([]time.Time, 0, len(points))
+	for _, t := range points {
+		cuts = append(cuts, t)
+	}
+	sort.Slice(cuts, func(i, j int) bool { return cuts[i].Before(cuts[j]) })
+	return cuts
+}
+
+// matchRule 查找开始时间命中的规则
+func (rp *RoomPackage) matchRule(at time.Time, totalHours int) *RoomPackageRule {
+	return rp.matchRuleForDayType(at, GetDayType(at), totalHours)
+}
+
+// matchRuleForDayType 按日期类型、时间段和时长查找首个命中的规则
+func (rp *RoomPackage) matchRuleForDayType(at time.Time, dayType string, totalHours int) *RoomPackageRule {
+	timeStr := at.Format("15:04")
+	for i := range rp.Rules {
+		rule := &rp.Rules[i]
+		if !rule.IsActive || rule.DayType != dayType {
+			continue
+		}
+		if rule.TimeStart != "" && rule.TimeEnd != "" {
+			if timeStr < rule.TimeStart || timeStr >= rule.TimeEnd {
+				continue
+			}
+		}
+		if totalHours < rule.MinHours || totalHours > rule.MaxHours {
+			continue
+		}
+		return rule
+	}
+	return nil
+}
+
+// applyPriceRule 按规则调整价格，未命中规则时返回原价
+func applyPriceRule(rule *RoomPackageRule, priceBase float64) float64 {
+	if rule == nil {
+		return priceBase
+	}
+	switch rule.PriceType {
+	case PriceTypeFixed:
+		return rule.PriceValue
+	case PriceTypeMultiply:
+		return priceBase * rule.PriceValue
+	case PriceTypeAdd:
+		return priceBase + rule.PriceValue
+	default:
+		return priceBase
+	}
+}
+
+// newPriceLineItem 创建计价明细行
+func newPriceLineItem(start, end time.Time, dayType string, rule *RoomPackageRule, hourly, amount float64) PriceLineItem {
+	item := PriceLineItem{
+		StartTime:   start.Format("2006-01-02 15:04:05"),
+		EndTime:     end.Format("2006-01-02 15:04:05"),
+		Hours:       roundAmount(end.Sub(start).Hours()),
+		DayType:     dayType,
+		HourlyPrice: roundAmount(hourly),
+		Amount:      amount,
+	}
+	if rule != nil {
+		item.RuleID = rule.ID
+		item.RuleName = rule.RuleName
+		item.PriceType = rule.PriceType
+		item.PriceValue = rule.PriceValue
+	}
+	return item
+}
+
+// parseClock 解析 HH:mm，返回距当天零点的时长（支持 24:00）
+func parseClock(clock string) (time.Duration, bool) {
+	if clock == "" {
+		return 0, false
+	}
+	var hour, minute int
+	if _, err := fmt.Sscanf(clock, "%d:%d", &hour, &minute); err != nil {
+		return 0, false
+	}
+	if hour < 0 || hour > 24 || minute < 0 || minute > 59 {
+		return 0, false
+	}
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute, true
+}
@@ -0,0 +1,234 @@
+package app_model
+
+import (
+	"testing"
+	"time"
+)
+
+// 2025-01-08 周三，2025-01-10 周五，2025-01-11 周六
+var (
+	ruleEvening = RoomPackageRule{ID: 1, RuleName: "晚间", DayType: DayTypeWeekday, TimeStart: "18:00", TimeEnd: "24:00",
+		PriceType: PriceTypeMultiply, PriceValue: 1.5, MinHours: 1, MaxHours: 24, IsActive: true}
+	ruleLateNight = RoomPackageRule{ID: 2, RuleName: "深夜", DayType: DayTypeWeekday, TimeStart: "00:00", TimeEnd: "06:00",
+		PriceType: PriceTypeMultiply, PriceValue: 0.8, MinHours: 1, MaxHours: 24, IsActive: true}
+	ruleWeekendNight = RoomPackageRule{ID: 3, RuleName: "周末深夜", DayType: DayTypeWeekend, TimeStart: "00:00", TimeEnd: "06:00",
+		PriceType: PriceTypeMultiply, PriceValue: 1.2, MinHours: 1, MaxHours: 24, IsActive: true}
+	ruleHoliday = RoomPackageRule{ID: 4, RuleName: "节假日", DayType: DayTypeHoliday,
+		PriceType: PriceTypeFixed, PriceValue: 200, MinHours: 1, MaxHours: 24, IsActive: true}
+)
+
+func flexiblePackage(rules ...RoomPackageRule) *RoomPackage {
+	return &RoomPackage{ID: 10, PackageName: "灵活套餐", PackageType: PackageTypeFlexible,
+		MinHours: 1, MaxHours: 24, IsActive: true, Rules: rules}
+}
+
+func TestQuoteFlexible(t *testing.T) {
+	type wantItem struct {
+		ruleID  int
+		dayType string
+		hours   float64
+		amount  float64
+	}
+
+	tests := []struct {
+		name      string
+		pkg       *RoomPackage
+		basePrice float64
+		start     time.Time
+		hours     int
+		wantItems []wantItem
+		wantFinal float64
+	}{
+		{
+			name:      "晚间跨零点进入深夜时段",
+			pkg:       flexiblePackage(ruleEvening, ruleLateNight),
+			basePrice: 100,
+			start:     time.Date(2025, 1, 8, 19, 0, 0, 0, time.Local),
+			hours:     7,
+			wantItems: []wantItem{
+				{1, DayTypeWeekday, 5, 750},
+				{2, DayTypeWeekday, 2, 160},
+			},
+			wantFinal: 910,
+		},
+		{
+			name:      "周五晚跨零点进入周末",
+			pkg:       flexiblePackage(ruleEvening, ruleLateNight, ruleWeekendNight),
+			basePrice: 100,
+			start:     time.Date(2025, 1, 10, 22, 0, 0, 0, time.Local),
+			hours:     4,
+			wantItems: []wantItem{
+				{1, DayTypeWeekday, 2, 300},
+				{3, DayTypeWeekend, 2, 240},
+			},
+			wantFinal: 540,
+		},
+		{
+			name:      "规则结束时间为24:00时在零点切分",
+			pkg:       flexiblePackage(ruleEvening),
+			basePrice: 100,
+			start:     time.Date(2025, 1, 8, 23, 0, 0, 0, time.Local),
+			hours:     2,
+			wantItems: []wantItem{
+				{1, DayTypeWeekday, 1, 150},
+				{0, DayTypeWeekday, 1, 100},
+			},
+			wantFinal: 250,
+		},
+		{
+			name:      "相邻同规则时段合并为一行",
+			pkg:       flexiblePackage(ruleWeekendNight),
+			basePrice: 33.335,
+			start:     time.Date(2025, 1, 8, 4, 0, 0, 0, time.Local),
+			hours:     4,
+			wantItems: []wantItem{
+				{0, DayTypeWeekday, 4, 133.34},
+			},
+			wantFinal: 133.34,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote, err := tt.pkg.Quote(tt.basePrice, tt.start, tt.hours)
+			if err != nil {
+				t.Fatalf("Quote() error = %v", err)
+			}
+			if len(quote.LineItems) != len(tt.wantItems) {
+				t.Fatalf("Quote() 明细 %d 行, want %d: %+v", len(quote.LineItems), len(tt.wantItems), quote.LineItems)
+			}
+			for i, want := range tt.wantItems {
+				got := quote.LineItems[i]
+				if got.RuleID != want.ruleID || got.DayType != want.dayType || got.Hours != want.hours || got.Amount != want.amount {
+					t.Errorf("明细[%d] = (rule %d, %s, %vh, %v), want (rule %d, %s, %vh, %v)",
+						i, got.RuleID, got.DayType, got.Hours, got.Amount, want.ruleID, want.dayType, want.hours, want.amount)
+				}
+			}
+			if quote.FinalPrice != tt.wantFinal || quote.SubTotal != tt.wantFinal {
+				t.Errorf("Quote() SubTotal = %v, FinalPrice = %v, want %v", quote.SubTotal, quote.FinalPrice, tt.wantFinal)
+			}
+		})
+	}
+}
+
+func TestQuoteCrossMidnightIntoHoliday(t *testing.T) {
+	SetDayTypeResolver(func(date time.Time) string {
+		if date.Format("2006-01-02") == "2025-01-28" {
+			return DayTypeHoliday
+		}
+		return GetWeekDayType(date)
+	})
+	t.Cleanup(func() { SetDayTypeResolver(nil) })
+
+	pkg := flexiblePackage(ruleEvening, ruleLateNight, ruleHoliday)
+	quote, err := pkg.Quote(100, time.Date(2025, 1, 27, 22, 0, 0, 0, time.Local), 4)
+	if err != nil {
+		t.Fatalf("Quote() error = %v", err)
+	}
+	if len(quote.LineItems) != 2 {
+		t.Fatalf("Quote() 明细 %d 行, want 2: %+v", len(quote.LineItems), quote.LineItems)
+	}
+	if item := quote.LineItems[1]; item.DayType != DayTypeHoliday || item.RuleID != ruleHoliday.ID || item.Amount != 400 {
+		t.Errorf("零点后明细 = (%s, rule %d, %v), want (%s, rule %d, 400)", item.DayType, item.RuleID, item.Amount, DayTypeHoliday, ruleHoliday.ID)
+	}
+	if quote.FinalPrice != 700 {
+		t.Errorf("Quote() FinalPrice = %v, want 700", quote.FinalPrice)
+	}
+}
+
+func TestQuoteChargeLimits(t *testing.T) {
+	start := time.Date(2025, 1, 8, 19, 0, 0, 0, time.Local) // 分段合计 910
+
+	tests := []struct {
+		name           string
+		maxCharge      float64
+		minCharge      float64
+		wantFinal      float64
+		wantAdjustment float64
+	}{
+		{"封顶价", 800, 0, 800, -110},
+		{"最低消费", 0, 1000, 1000, 90},
+		{"未触发封顶和最低消费", 1000, 500, 910, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg := flexiblePackage(ruleEvening, ruleLateNight)
+			pkg.MaxCharge, pkg.MinCharge = tt.maxCharge, tt.minCharge
+			quote, err := pkg.Quote(100, start, 7)
+			if err != nil {
+				t.Fatalf("Quote() error = %v", err)
+			}
+			if quote.SubTotal != 910 || quote.FinalPrice != tt.wantFinal || quote.Adjustment != tt.wantAdjustment {
+				t.Errorf("Quote() = (SubTotal %v, FinalPrice %v, Adjustment %v), want (910, %v, %v)",
+					quote.SubTotal, quote.FinalPrice, quote.Adjustment, tt.wantFinal, tt.wantAdjustment)
+			}
+			if quote.DiscountAmount != 700-tt.wantFinal {
+				t.Errorf("Quote() DiscountAmount = %v, want %v", quote.DiscountAmount, 700-tt.wantFinal)
+			}
+		})
+	}
+}
+
+func TestQuoteFixedPackages(t *testing.T) {
+	weekendMarkup := RoomPackageRule{ID: 5, RuleName: "周末加价", DayType: DayTypeWeekend,
+		PriceType: PriceTypeMultiply, PriceValue: 1.1, MinHours: 1, MaxHours: 168, IsActive: true}
+	weekdayFixed := RoomPackageRule{ID: 6, RuleName: "工作日套餐价", DayType: DayTypeWeekday,
+		PriceType: PriceTypeFixed, PriceValue: 250, MinHours: 1, MaxHours: 24, IsActive: true}
+
+	tests := []struct {
+		name      string
+		pkg       *RoomPackage
+		start     time.Time
+		requested int
+		wantHours int
+		wantFinal float64
+		wantRule  string
+	}{
+		{
+			name:      "固定时长套餐按规则总价",
+			pkg:       &RoomPackage{PackageType: PackageTypeFixedHours, FixedHours: 3, BasePrice: 200, IsActive: true, Rules: []RoomPackageRule{weekdayFixed}},
+			start:     time.Date(2025, 1, 8, 22, 0, 0, 0, time.Local),
+			requested: 5,
+			wantHours: 3,
+			wantFinal: 250,
+			wantRule:  "工作日套餐价",
+		},
+		{
+			name:      "全天套餐跨零点不切分",
+			pkg:       &RoomPackage{PackageType: PackageTypeDaily, BasePrice: 1200, IsActive: true},
+			start:     time.Date(2025, 1, 10, 12, 0, 0, 0, time.Local),
+			requested: 1,
+			wantHours: 24,
+			wantFinal: 1200,
+		},
+		{
+			name:      "周套餐按开始日期类型命中规则",
+			pkg:       &RoomPackage{PackageType: PackageTypeWeekly, BasePrice: 6000, IsActive: true, Rules: []RoomPackageRule{weekendMarkup}},
+			start:     time.Date(2025, 1, 11, 12, 0, 0, 0, time.Local),
+			requested: 1,
+			wantHours: 168,
+			wantFinal: 6600,
+			wantRule:  "周末加价",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote, err := tt.pkg.Quote(100, tt.start, tt.requested)
+			if err != nil {
+				t.Fatalf("Quote() error = %v", err)
+			}
+			if quote.Hours != tt.wantHours || quote.FinalPrice != tt.wantFinal || quote.RuleName != tt.wantRule {
+				t.Errorf("Quote() = (%dh, %v, %q), want (%dh, %v, %q)",
+					quote.Hours, quote.FinalPrice, quote.RuleName, tt.wantHours, tt.wantFinal, tt.wantRule)
+			}
+			if len(quote.LineItems) != 1 || quote.LineItems[0].Amount != tt.wantFinal {
+				t.Errorf("Quote() 明细 = %+v, want 单行 %v", quote.LineItems, tt.wantFinal)
+			}
+			if quote.OriginalPrice != 100*float64(tt.wantHours) {
+				t.Errorf("Quote() OriginalPrice = %v, want %v", quote.OriginalPrice, 100*float64(tt.wantHours))
+			}
+		})
+	}
+}
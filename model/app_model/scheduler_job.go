@@ -0,0 +1,28 @@
+package app_model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SchedulerJobRun 后台定时任务执行记录（仅持有leader租约的实例执行并记录）
+type SchedulerJobRun struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	JobName    string             `bson:"job_name" json:"job_name"`       // 任务名称
+	InstanceID string             `bson:"instance_id" json:"instance_id"` // 执行实例ID
+	Status     string             `bson:"status" json:"status"`           // 执行结果
+	ErrorMsg   string             `bson:"error_msg" json:"error_msg"`     // 错误信息
+	StartedAt  string             `bson:"started_at" json:"started_at"`   // 开始时间
+	FinishedAt string             `bson:"finished_at" json:"finished_at"` // 结束时间
+	DurationMs int64              `bson:"duration_ms" json:"duration_ms"` // 耗时(毫秒)
+	ServerInfo ServerInfo         `bson:"server_info" json:"server_info"` // 服务器信息
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`   // 记录时间（TTL索引按此字段过期）
+}
+
+// 任务执行结果常量
+const (
+	JobRunStatusSuccess = "success" // 执行成功
+	JobRunStatusFailed  = "failed"  // 执行失败
+	JobRunStatusPanic   = "panic"   // 执行中发生panic
+)
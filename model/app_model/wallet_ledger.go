@@ -0,0 +1,98 @@
+package app_model
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WalletJournal 钱包记账凭证（复式记账，每张凭证借贷金额相等）
+// 凭证和分录只允许新增，不允许修改和删除，冲正需要新增反向凭证
+type WalletJournal struct {
+	ID             int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	JournalNo      string    `json:"journal_no" gorm:"column:journal_no;uniqueIndex;not null;comment:凭证号"`
+	IdempotencyKey string    `json:"idempotency_key" gorm:"column:idempotency_key;uniqueIndex;not null;comment:幂等键"`
+	UserID         int       `json:"user_id" gorm:"column:user_id;index;not null;comment:用户ID"`
+	BizType        string    `json:"biz_type" gorm:"column:biz_type;not null;comment:业务类型(同钱包交易类型)"`
+	BizNo          string    `json:"biz_no" gorm:"column:biz_no;comment:业务单号"`
+	Amount         float64   `json:"amount" gorm:"column:amount;type:decimal(12,2);not null;comment:金额"`
+	RechargeID     *int      `json:"recharge_id" gorm:"column:recharge_id;comment:关联钱包流水ID"`
+	Remark         string    `json:"remark" gorm:"column:remark;comment:备注"`
+	CreateTime     time.Time `json:"create_time" gorm:"column:create_time;autoCreateTime"`
+}
+
+func (WalletJournal) TableName() string {
+	return "wallet_journals"
+}
+
+// WalletJournalEntry 记账分录
+type WalletJournalEntry struct {
+	ID          int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	JournalID   int64     `json:"journal_id" gorm:"column:journal_id;index;not null;comment:凭证ID"`
+	AccountCode string    `json:"account_code" gorm:"column:account_code;not null;comment:科目代码"`
+	UserID      int       `json:"user_id" gorm:"column:user_id;not null;default:0;comment:用户ID(平台科目为0)"`
+	Direction   string    `json:"direction" gorm:"column:direction;not null;comment:借贷方向"`
+	Amount      float64   `json:"amount" gorm:"column:amount;type:decimal(12,2);not null;comment:金额"`
+	CreateTime  time.Time `json:"create_time" gorm:"column:create_time;autoCreateTime"`
+}
+
+func (WalletJournalEntry) TableName() string {
+	return "wallet_journal_entries"
+}
+
+// WalletReconcileRecord 钱包对账结果（仅记录存在差异的用户）
+type WalletReconcileRecord struct {
+	ID            int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	BatchNo       string    `json:"batch_no" gorm:"column:batch_no;index;not null;comment:对账批次号"`
+	UserID        int       `json:"user_id" gorm:"column:user_id;not null;comment:用户ID"`
+	WalletBalance float64   `json:"wallet_balance" gorm:"column:wallet_balance;type:decimal(12,2);comment:钱包余额"`
+	LedgerBalance float64   `json:"ledger_balance" gorm:"column:ledger_balance;type:decimal(12,2);comment:账本余额"`
+	Drift         float64   `json:"drift" gorm:"column:drift;type:decimal(12,2);comment:差额(钱包-账本)"`
+	CreateTime    time.Time `json:"create_time" gorm:"column:create_time;autoCreateTime"`
+}
+
+func (WalletReconcileRecord) TableName() string {
+	return "wallet_reconcile_records"
+}
+
+// 借贷方向
+const (
+	LedgerDebit  = "debit"  // 借
+	LedgerCredit = "credit" // 贷
+)
+
+// 会计科目
+// 用户钱包为平台负债科目：贷方增加余额，借方减少余额
+const (
+	AccountUserWallet     = "user_wallet"     // 用户钱包余额
+	AccountPlatformCash   = "platform_cash"   // 平台收款（充值资金）
+	AccountGoodsRevenue   = "goods_revenue"   // 商品销售收入
+	AccountBookingRevenue = "booking_revenue" // 房间预订收入
+	AccountCompensation   = "compensation"    // 系统补偿支出
+	AccountMarketing      = "marketing"       // 营销赠送支出
+	AccountOpeningBalance = "opening_balance" // 期初余额
+)
+
+// ErrLedgerImmutable 账本记录不可修改
+var ErrLedgerImmutable = errors.New("账本记录不可修改或删除")
+
+// BeforeUpdate 禁止修改凭证
+func (WalletJournal) BeforeUpdate(tx *gorm.DB) error {
+	return ErrLedgerImmutable
+}
+
+// BeforeDelete 禁止删除凭证
+func (WalletJournal) BeforeDelete(tx *gorm.DB) error {
+	return ErrLedgerImmutable
+}
+
+// BeforeUpdate 禁止修改分录
+func (WalletJournalEntry) BeforeUpdate(tx *gorm.DB) error {
+	return ErrLedgerImmutable
+}
+
+// BeforeDelete 禁止删除分录
+func (WalletJournalEntry) BeforeDelete(tx *gorm.DB) error {
+	return ErrLedgerImmutable
+}
@@ -30,3 +30,52 @@ func (UserSubscription) TableName() string {
 func (PushHistory) TableName() string {
 	return "wx_push_history"
 }
+
+// SubscribeTemplate 订阅消息模板配置，按业务事件匹配模板并将关键词映射为事件数据表达式
+type SubscribeTemplate struct {
+	ID         int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Event      string    `json:"event" gorm:"column:event;uniqueIndex;not null"`       // 业务事件
+	TemplateID string    `json:"template_id" gorm:"column:template_id;not null"`       // 微信模板ID
+	Title      string    `json:"title" gorm:"column:title"`                            // 模板标题
+	Page       string    `json:"page" gorm:"column:page"`                              // 落地页路径，支持表达式
+	Fields     string    `json:"fields" gorm:"column:fields;type:text;not null"`       // 关键词映射JSON，如 {"thing1":"{{room.room_name}}"}
+	Status     int       `json:"status" gorm:"column:status;default:1"`                // 1-启用 0-停用
+	Remark     string    `json:"remark" gorm:"column:remark"`                          // 备注
+	CreateTime time.Time `json:"create_time" gorm:"column:create_time;autoCreateTime"` // 创建时间
+	UpdateTime time.Time `json:"update_time" gorm:"column:update_time;autoUpdateTime"` // 更新时间
+}
+
+// TableName 设置表名
+func (SubscribeTemplate) TableName() string {
+	return "wx_subscribe_template"
+}
+
+// 订阅消息业务事件
+const (
+	EventOrderCreated          = "order_created"           // 商品订单待支付
+	EventOrderPaid             = "order_paid"              // 商品订单支付成功
+	EventOrderRefundApproved   = "order_refund_approved"   // 退款申请已同意
+	EventOrderRefundRejected   = "order_refund_rejected"   // 退款申请已拒绝
+	EventBookingPaymentPending = "booking_payment_pending" // 预订待支付提醒
+	EventBookingStartSoon      = "booking_start_soon"      // 预订即将开始
+	EventBookingEndingSoon     = "booking_ending_soon"     // 预订即将结束
+	EventBookingCompleted      = "booking_completed"       // 预订完成回执
+	EventBookingWaitlistOffer  = "booking_waitlist_offer"  // 候补时段已保留
+	EventBookingRebooked       = "booking_rebooked"        // 预订房间已调换
+	EventBookingVenueCancelled = "booking_venue_cancelled" // 预订因商家原因取消
+)
+
+// SubscribeEventScopes 各业务事件可在表达式中引用的数据对象
+var SubscribeEventScopes = map[string][]string{
+	EventOrderCreated:          {"order", "goods"},
+	EventOrderPaid:             {"order", "goods"},
+	EventOrderRefundApproved:   {"order", "goods", "refund"},
+	EventOrderRefundRejected:   {"order", "goods", "refund"},
+	EventBookingPaymentPending: {"booking", "room", "notice"},
+	EventBookingStartSoon:      {"booking", "room", "notice"},
+	EventBookingEndingSoon:     {"booking", "room", "notice"},
+	EventBookingCompleted:      {"booking", "room", "usage", "notice"},
+	EventBookingWaitlistOffer:  {"booking", "room", "notice"},
+	EventBookingRebooked:       {"booking", "room", "notice"},
+	EventBookingVenueCancelled: {"booking", "room", "notice"},
+}
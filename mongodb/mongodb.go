@@ -71,9 +71,10 @@ type CollectionIndexInfo struct {
 
 // IndexInfo 索引信息
 type IndexInfo struct {
-	Keys   bson.D
-	Unique bool
-	Name   string
+	Keys        bson.D
+	Unique      bool
+	Name        string
+	ExpireAfter time.Duration // TTL索引过期时间，0表示普通索引
 }
 
 // 定义需要确保存在的集合和索引
@@ -127,6 +128,16 @@ var requiredCollections = []CollectionIndexInfo{
 			{Keys: bson.D{{"username", 1}}, Unique: false, Name: "username_idx"},
 		},
 	},
+	{
+		DatabaseKey:   "scheduler_log_db",
+		CollectionKey: "job_runs",
+		Indexes: []IndexInfo{
+			{Keys: bson.D{{"job_name", 1}, {"started_at", -1}}, Unique: false, Name: "job_started_idx"},
+			{Keys: bson.D{{"started_at", -1}}, Unique: false, Name: "started_at_desc"},
+			{Keys: bson.D{{"status", 1}}, Unique: false, Name: "status_idx"},
+			{Keys: bson.D{{"created_at", 1}}, Unique: false, Name: "created_at_ttl", ExpireAfter: 7 * 24 * time.Hour},
+		},
+	},
 }
 
 // AutoEnsureCollectionsAndIndexes 自动确保集合和索引存在
@@ -191,9 +202,13 @@ func AutoEnsureCollectionsAndIndexes() error {
 		// 创建索引
 		successCount := 0
 		for _, indexInfo := range collInfo.Indexes {
+			indexOptions := options.Index().SetUnique(indexInfo.Unique).SetName(indexInfo.Name)
+			if indexInfo.ExpireAfter > 0 {
+				indexOptions.SetExpireAfterSeconds(int32(indexInfo.ExpireAfter.Seconds()))
+			}
 			indexModel := mongo.IndexModel{
 				Keys:    indexInfo.Keys,
-				Options: options.Index().SetUnique(indexInfo.Unique).SetName(indexInfo.Name),
+				Options: indexOptions,
 			}
 
 			_, err := collection.Indexes().CreateOne(ctx, indexModel)
@@ -32,7 +32,8 @@ type SecureCustomClaims struct {
 	UID  int    `json:"uid"`
 	RID  int    `json:"rid"`
 	TYPE int    `json:"type"`
-	JTI  string `json:"jti"` // JWT ID，用于黑名单
+	TID  int    `json:"tid,omitempty"` // 租户（商家）ID
+	JTI  string `json:"jti"`           // JWT ID，用于黑名单
 	jwt.RegisteredClaims
 }
 
@@ -84,12 +85,18 @@ func NewSecureJWTManager() *SecureJWTManager {
 
 // GenerateToken 生成安全的token
 func (sjm *SecureJWTManager) GenerateToken(uid, rid, userType int) (string, error) {
+	return sjm.GenerateTenantToken(uid, rid, userType, 0)
+}
+
+// GenerateTenantToken 生成携带租户ID的安全token
+func (sjm *SecureJWTManager) GenerateTenantToken(uid, rid, userType, tenantID int) (string, error) {
 	jti := uuid.New().String() // 生成唯一的JWT ID
 
 	claims := SecureCustomClaims{
 		UID:  uid,
 		RID:  rid,
 		TYPE: userType,
+		TID:  tenantID,
 		JTI:  jti,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(sjm.config.AccessTokenTTL)),
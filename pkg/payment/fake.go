@@ -0,0 +1,109 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+// FakeSignatureHeader 模拟渠道回调签名头
+const FakeSignatureHeader = "X-Fake-Pay-Signature"
+
+// FakeNotifyBody 模拟渠道回调内容
+type FakeNotifyBody struct {
+	OutTradeNo    string `json:"out_trade_no"`
+	TransactionID string `json:"transaction_id"`
+	AmountFen     int64  `json:"amount_fen"`
+	TradeState    string `json:"trade_state"` // SUCCESS 表示支付成功
+}
+
+// FakeProvider 本地模拟支付渠道，仅用于开发和测试
+// 回调使用 HMAC-SHA256 签名，签名错误与真实渠道一样被拒绝
+type FakeProvider struct {
+	secret []byte
+}
+
+// NewFakeProvider 创建模拟支付渠道，生产环境（GIN_MODE=release 或 GO_ENV=production）禁止使用
+func NewFakeProvider(secret string) (*FakeProvider, error) {
+	if isProductionEnv() {
+		return nil, fmt.Errorf("生产环境不允许使用模拟支付渠道")
+	}
+	if secret == "" {
+		return nil, fmt.Errorf("模拟支付渠道未配置回调签名密钥 FAKE_PAY_SECRET")
+	}
+	return &FakeProvider{secret: []byte(secret)}, nil
+}
+
+// isProductionEnv 是否运行在生产环境
+func isProductionEnv() bool {
+	return os.Getenv("GIN_MODE") == "release" || os.Getenv("GO_ENV") == "production"
+}
+
+// Name 渠道名称
+func (p *FakeProvider) Name() string {
+	return ProviderFake
+}
+
+// Prepay 模拟预下单
+func (p *FakeProvider) Prepay(ctx context.Context, req *PrepayRequest) (*PrepayResult, error) {
+	if req.AmountFen <= 0 {
+		return nil, fmt.Errorf("支付金额必须大于0")
+	}
+
+	prepayID := "fake_prepay_" + req.OutTradeNo
+	return &PrepayResult{
+		PrepayID: prepayID,
+		PayParams: map[string]string{
+			"provider":     ProviderFake,
+			"out_trade_no": req.OutTradeNo,
+			"package":      "prepay_id=" + prepayID,
+		},
+	}, nil
+}
+
+// ParseNotify 校验签名并解析模拟回调
+func (p *FakeProvider) ParseNotify(r *http.Request) (*NotifyResult, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取回调内容失败: %w", err)
+	}
+
+	expected := p.Sign(body)
+	if !hmac.Equal([]byte(expected), []byte(r.Header.Get(FakeSignatureHeader))) {
+		return nil, ErrInvalidSignature
+	}
+
+	var notify FakeNotifyBody
+	if err := json.Unmarshal(body, &notify); err != nil {
+		return nil, fmt.Errorf("解析回调内容失败: %w", err)
+	}
+
+	return &NotifyResult{
+		OutTradeNo:    notify.OutTradeNo,
+		TransactionID: notify.TransactionID,
+		AmountFen:     notify.AmountFen,
+		Success:       notify.TradeState == "SUCCESS",
+		TradeState:    notify.TradeState,
+	}, nil
+}
+
+// NotifyAck 回调应答
+func (p *FakeProvider) NotifyAck(success bool, message string) (int, interface{}) {
+	if success {
+		return http.StatusOK, map[string]string{"code": "SUCCESS", "message": "成功"}
+	}
+	return http.StatusInternalServerError, map[string]string{"code": "FAIL", "message": message}
+}
+
+// Sign 计算回调签名，测试时用于构造合法回调
+func (p *FakeProvider) Sign(body []byte) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,61 @@
+package payment
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewFakeProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		ginMode string
+		goEnv   string
+		wantErr bool
+	}{
+		{"开发环境配置密钥", "test-secret", "debug", "development", false},
+		{"未配置密钥", "", "debug", "development", true},
+		{"GIN_MODE=release", "test-secret", "release", "", true},
+		{"GO_ENV=production", "test-secret", "debug", "production", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GIN_MODE", tt.ginMode)
+			t.Setenv("GO_ENV", tt.goEnv)
+			_, err := NewFakeProvider(tt.secret)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewFakeProvider() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFakeParseNotify(t *testing.T) {
+	t.Setenv("GIN_MODE", "debug")
+	t.Setenv("GO_ENV", "")
+	provider, err := NewFakeProvider("test-secret")
+	if err != nil {
+		t.Fatalf("NewFakeProvider() error = %v", err)
+	}
+	body := `{"out_trade_no":"RC001","transaction_id":"FAKE001","amount_fen":100,"trade_state":"SUCCESS"}`
+
+	req := httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(body))
+	req.Header.Set(FakeSignatureHeader, provider.Sign([]byte(body)))
+	result, err := provider.ParseNotify(req)
+	if err != nil {
+		t.Fatalf("ParseNotify() error = %v", err)
+	}
+	if result.OutTradeNo != "RC001" || result.AmountFen != 100 || !result.Success {
+		t.Errorf("ParseNotify() = %+v", result)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(body))
+	req.Header.Set(FakeSignatureHeader, "bad-signature")
+	if _, err := provider.ParseNotify(req); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("ParseNotify() error = %v, want %v", err, ErrInvalidSignature)
+	}
+}
@@ -0,0 +1,85 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// 支付错误定义
+var (
+	ErrInvalidSignature = errors.New("支付回调签名校验失败")
+	ErrNotConfigured    = errors.New("支付渠道未配置")
+)
+
+// 支付渠道
+const (
+	ProviderWeChat = "wechat"
+	ProviderFake   = "fake"
+)
+
+// PrepayRequest 预下单请求
+type PrepayRequest struct {
+	OutTradeNo  string // 商户订单号
+	Description string // 商品描述
+	AmountFen   int64  // 金额（分）
+	OpenID      string // 用户openid（JSAPI支付必填）
+}
+
+// PrepayResult 预下单结果
+type PrepayResult struct {
+	PrepayID  string            `json:"prepay_id"`
+	PayParams map[string]string `json:"pay_params"` // 前端调起支付所需参数
+}
+
+// NotifyResult 支付回调解析结果
+type NotifyResult struct {
+	OutTradeNo    string // 商户订单号
+	TransactionID string // 支付渠道交易号
+	AmountFen     int64  // 实付金额（分）
+	Success       bool   // 是否支付成功
+	TradeState    string // 渠道原始交易状态
+}
+
+// PaymentProvider 支付渠道接口
+type PaymentProvider interface {
+	// Name 渠道名称，写入 AppRecharge.PaymentMethod
+	Name() string
+	// Prepay 预下单，返回前端调起支付的参数
+	Prepay(ctx context.Context, req *PrepayRequest) (*PrepayResult, error)
+	// ParseNotify 校验签名并解析异步回调，签名错误返回 ErrInvalidSignature
+	ParseNotify(r *http.Request) (*NotifyResult, error)
+	// NotifyAck 回调处理结果应答
+	NotifyAck(success bool, message string) (int, interface{})
+}
+
+var (
+	defaultProvider PaymentProvider
+	defaultErr      error
+	providerOnce    sync.Once
+)
+
+// GetProvider 获取当前配置的支付渠道（PAYMENT_PROVIDER=wechat|fake，默认 wechat）
+func GetProvider() (PaymentProvider, error) {
+	providerOnce.Do(func() {
+		switch name := os.Getenv("PAYMENT_PROVIDER"); name {
+		case "", ProviderWeChat:
+			defaultProvider, defaultErr = NewWeChatProviderFromEnv()
+		case ProviderFake:
+			defaultProvider, defaultErr = NewFakeProvider(os.Getenv("FAKE_PAY_SECRET"))
+		default:
+			defaultErr = fmt.Errorf("不支持的支付渠道: %s", name)
+		}
+	})
+	return defaultProvider, defaultErr
+}
+
+// SetProvider 替换当前支付渠道（用于测试）
+func SetProvider(provider PaymentProvider) {
+	providerOnce.Do(func() {})
+	defaultProvider = provider
+	defaultErr = nil
+}
@@ -0,0 +1,417 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	wechatPayBaseURL        = "https://api.mch.weixin.qq.com"
+	wechatPayJSAPIPath      = "/v3/pay/transactions/jsapi"
+	wechatPayAuthSchema     = "WECHATPAY2-SHA256-RSA2048"
+	wechatNotifyMaxSkew     = 5 * time.Minute // 回调时间戳允许的最大偏差，防止重放
+	wechatTradeStateSuccess = "SUCCESS"
+)
+
+// WeChatConfig 微信支付v3配置
+type WeChatConfig struct {
+	AppID          string // 小程序/公众号AppID
+	MchID          string // 商户号
+	MchSerialNo    string // 商户API证书序列号
+	APIv3Key       string // APIv3密钥（32字节）
+	NotifyURL      string // 支付结果回调地址
+	PlatformSerial string // 微信支付平台证书序列号（可选，用于校验回调头）
+	PrivateKey     *rsa.PrivateKey
+	PlatformKey    *rsa.PublicKey
+}
+
+// WeChatProvider 微信支付v3 JSAPI 支付渠道
+type WeChatProvider struct {
+	config     *WeChatConfig
+	httpClient *http.Client
+}
+
+// NewWeChatProvider 创建微信支付渠道
+func NewWeChatProvider(config *WeChatConfig) (*WeChatProvider, error) {
+	if config.AppID == "" || config.MchID == "" || config.MchSerialNo == "" ||
+		config.NotifyURL == "" || config.PrivateKey == nil || config.PlatformKey == nil {
+		return nil, ErrNotConfigured
+	}
+	if len(config.APIv3Key) != 32 {
+		return nil, fmt.Errorf("APIv3密钥长度必须为32字节")
+	}
+
+	return &WeChatProvider{
+		config:     config,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// NewWeChatProviderFromEnv 从环境变量创建微信支付渠道
+func NewWeChatProviderFromEnv() (*WeChatProvider, error) {
+	privateKey, err := loadPrivateKey(os.Getenv("WECHAT_PAY_PRIVATE_KEY_PATH"))
+	if err != nil {
+		return nil, fmt.Errorf("加载商户私钥失败: %w", err)
+	}
+
+	platformKey, err := loadPublicKey(os.Getenv("WECHAT_PAY_PLATFORM_CERT_PATH"))
+	if err != nil {
+		return nil, fmt.Errorf("加载微信支付平台证书失败: %w", err)
+	}
+
+	return NewWeChatProvider(&WeChatConfig{
+		AppID:          os.Getenv("WECHAT_PAY_APP_ID"),
+		MchID:          os.Getenv("WECHAT_PAY_MCH_ID"),
+		MchSerialNo:    os.Getenv("WECHAT_PAY_MCH_SERIAL_NO"),
+		APIv3Key:       os.Getenv("WECHAT_PAY_API_V3_KEY"),
+		NotifyURL:      os.Getenv("WECHAT_PAY_NOTIFY_URL"),
+		PlatformSerial: os.Getenv("WECHAT_PAY_PLATFORM_SERIAL"),
+		PrivateKey:     privateKey,
+		PlatformKey:    platformKey,
+	})
+}
+
+// Name 渠道名称
+func (p *WeChatProvider) Name() string {
+	return ProviderWeChat
+}
+
+// Prepay JSAPI 预下单
+func (p *WeChatProvider) Prepay(ctx context.Context, req *PrepayRequest) (*PrepayResult, error) {
+	if req.OpenID == "" {
+		return nil, fmt.Errorf("JSAPI支付需要用户openid")
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"appid":        p.config.AppID,
+		"mchid":        p.config.MchID,
+		"description":  req.Description,
+		"out_trade_no": req.OutTradeNo,
+		"notify_url":   p.config.NotifyURL,
+		"amount": map[string]interface{}{
+			"total":    req.AmountFen,
+			"currency": "CNY",
+		},
+		"payer": map[string]string{
+			"openid": req.OpenID,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("序列化预下单请求失败: %w", err)
+	}
+
+	authorization, err := p.buildAuthorization(http.MethodPost, wechatPayJSAPIPath, body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, wechatPayBaseURL+wechatPayJSAPIPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("创建预下单请求失败: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("Authorization", authorization)
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("请求微信支付失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取微信支付响应失败: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(respBody, &errResp)
+		return nil, fmt.Errorf("微信支付预下单失败: [%d] %s %s", resp.StatusCode, errResp.Code, errResp.Message)
+	}
+
+	var prepayResp struct {
+		PrepayID string `json:"prepay_id"`
+	}
+	if err := json.Unmarshal(respBody, &prepayResp); err != nil || prepayResp.PrepayID == "" {
+		return nil, fmt.Errorf("解析微信支付预下单响应失败")
+	}
+
+	payParams, err := p.buildJSAPIPayParams(prepayResp.PrepayID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PrepayResult{
+		PrepayID:  prepayResp.PrepayID,
+		PayParams: payParams,
+	}, nil
+}
+
+// ParseNotify 校验回调签名并解密支付结果
+func (p *WeChatProvider) ParseNotify(r *http.Request) (*NotifyResult, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取回调内容失败: %w", err)
+	}
+
+	if err := p.verifyNotifySignature(r.Header, body); err != nil {
+		return nil, err
+	}
+
+	var notify struct {
+		ID           string `json:"id"`
+		EventType    string `json:"event_type"`
+		ResourceType string `json:"resource_type"`
+		Resource     struct {
+			Algorithm      string `json:"algorithm"`
+			Ciphertext     string `json:"ciphertext"`
+			AssociatedData string `json:"associated_data"`
+			Nonce          string `json:"nonce"`
+		} `json:"resource"`
+	}
+	if err := json.Unmarshal(body, &notify); err != nil {
+		return nil, fmt.Errorf("解析回调内容失败: %w", err)
+	}
+
+	plaintext, err := p.decryptResource(notify.Resource.Ciphertext, notify.Resource.Nonce, notify.Resource.AssociatedData)
+	if err != nil {
+		return nil, err
+	}
+
+	var transaction struct {
+		OutTradeNo    string `json:"out_trade_no"`
+		TransactionID string `json:"transaction_id"`
+		TradeState    string `json:"trade_state"`
+		Amount        struct {
+			Total      int64 `json:"total"`
+			PayerTotal int64 `json:"payer_total"`
+		} `json:"amount"`
+	}
+	if err := json.Unmarshal(plaintext, &transaction); err != nil {
+		return nil, fmt.Errorf("解析支付结果失败: %w", err)
+	}
+
+	return &NotifyResult{
+		OutTradeNo:    transaction.OutTradeNo,
+		TransactionID: transaction.TransactionID,
+		AmountFen:     transaction.Amount.Total,
+		Success:       transaction.TradeState == wechatTradeStateSuccess,
+		TradeState:    transaction.TradeState,
+	}, nil
+}
+
+// NotifyAck 回调应答，非2xx状态码时微信支付会重试通知
+func (p *WeChatProvider) NotifyAck(success bool, message string) (int, interface{}) {
+	if success {
+		return http.StatusOK, map[string]string{"code": "SUCCESS", "message": "成功"}
+	}
+	return http.StatusInternalServerError, map[string]string{"code": "FAIL", "message": message}
+}
+
+// buildAuthorization 生成请求签名头
+func (p *WeChatProvider) buildAuthorization(method, path string, body []byte) (string, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce, err := generateNonce()
+	if err != nil {
+		return "", err
+	}
+
+	message := fmt.Sprintf("%s\n%s\n%s\n%s\n%s\n", method, path, timestamp, nonce, body)
+	signature, err := p.sign(message)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(`%s mchid="%s",nonce_str="%s",signature="%s",timestamp="%s",serial_no="%s"`,
+		wechatPayAuthSchema, p.config.MchID, nonce, signature, timestamp, p.config.MchSerialNo), nil
+}
+
+// buildJSAPIPayParams 生成小程序调起支付参数
+func (p *WeChatProvider) buildJSAPIPayParams(prepayID string) (map[string]string, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce, err := generateNonce()
+	if err != nil {
+		return nil, err
+	}
+	pkg := "prepay_id=" + prepayID
+
+	paySign, err := p.sign(fmt.Sprintf("%s\n%s\n%s\n%s\n", p.config.AppID, timestamp, nonce, pkg))
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		"appId":     p.config.AppID,
+		"timeStamp": timestamp,
+		"nonceStr":  nonce,
+		"package":   pkg,
+		"signType":  "RSA",
+		"paySign":   paySign,
+	}, nil
+}
+
+// verifyNotifySignature 使用平台证书校验回调签名
+func (p *WeChatProvider) verifyNotifySignature(header http.Header, body []byte) error {
+	timestamp := header.Get("Wechatpay-Timestamp")
+	nonce := header.Get("Wechatpay-Nonce")
+	signature := header.Get("Wechatpay-Signature")
+	serial := header.Get("Wechatpay-Serial")
+
+	if timestamp == "" || nonce == "" || signature == "" {
+		return ErrInvalidSignature
+	}
+
+	if p.config.PlatformSerial != "" && serial != p.config.PlatformSerial {
+		return ErrInvalidSignature
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if skew := time.Since(time.Unix(ts, 0)); skew > wechatNotifyMaxSkew || skew < -wechatNotifyMaxSkew {
+		return ErrInvalidSignature
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	message := fmt.Sprintf("%s\n%s\n%s\n", timestamp, nonce, body)
+	hashed := sha256.Sum256([]byte(message))
+	if err := rsa.VerifyPKCS1v15(p.config.PlatformKey, crypto.SHA256, hashed[:], sig); err != nil {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// decryptResource 使用APIv3密钥解密回调资源（AEAD_AES_256_GCM）
+func (p *WeChatProvider) decryptResource(ciphertext, nonce, associatedData string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("解码回调密文失败: %w", err)
+	}
+
+	block, err := aes.NewCipher([]byte(p.config.APIv3Key))
+	if err != nil {
+		return nil, fmt.Errorf("初始化解密失败: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("初始化解密失败: %w", err)
+	}
+
+	plaintext, err := gcm.Open(nil, []byte(nonce), data, []byte(associatedData))
+	if err != nil {
+		return nil, fmt.Errorf("解密回调内容失败: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+// sign 使用商户私钥进行 SHA256-RSA 签名
+func (p *WeChatProvider) sign(message string) (string, error) {
+	hashed := sha256.Sum256([]byte(message))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.config.PrivateKey, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", fmt.Errorf("签名失败: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// generateNonce 生成随机串
+func generateNonce() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成随机串失败: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// loadPrivateKey 加载PEM格式商户私钥
+func loadPrivateKey(path string) (*rsa.PrivateKey, error) {
+	if path == "" {
+		return nil, ErrNotConfigured
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("无效的PEM文件")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if rsaKey, ok := key.(*rsa.PrivateKey); ok {
+			return rsaKey, nil
+		}
+		return nil, fmt.Errorf("私钥不是RSA格式")
+	}
+
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
+
+// loadPublicKey 加载平台证书或平台公钥
+func loadPublicKey(path string) (*rsa.PublicKey, error) {
+	if path == "" {
+		return nil, ErrNotConfigured
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("无效的PEM文件")
+	}
+
+	if block.Type == "CERTIFICATE" {
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if rsaKey, ok := cert.PublicKey.(*rsa.PublicKey); ok {
+			return rsaKey, nil
+		}
+		return nil, fmt.Errorf("平台证书公钥不是RSA格式")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("平台公钥不是RSA格式")
+	}
+	return rsaKey, nil
+}
@@ -0,0 +1,205 @@
+package payment
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testAPIv3Key = "0123456789abcdef0123456789abcdef"
+
+// newTestWeChatProvider 创建测试用微信支付渠道，返回平台私钥用于构造回调签名
+func newTestWeChatProvider(t *testing.T) (*WeChatProvider, *rsa.PrivateKey) {
+	t.Helper()
+
+	merchantKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("生成商户私钥失败: %v", err)
+	}
+	platformKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("生成平台私钥失败: %v", err)
+	}
+
+	provider, err := NewWeChatProvider(&WeChatConfig{
+		AppID:          "wx_test_app",
+		MchID:          "1900000001",
+		MchSerialNo:    "MCH_SERIAL",
+		APIv3Key:       testAPIv3Key,
+		NotifyURL:      "https://example.com/notify",
+		PlatformSerial: "PLATFORM_SERIAL",
+		PrivateKey:     merchantKey,
+		PlatformKey:    &platformKey.PublicKey,
+	})
+	if err != nil {
+		t.Fatalf("创建微信支付渠道失败: %v", err)
+	}
+	return provider, platformKey
+}
+
+// encryptResource 按 AEAD_AES_256_GCM 加密回调资源
+func encryptResource(t *testing.T, key string, plaintext []byte, nonce, associatedData string) string {
+	t.Helper()
+
+	block, err := aes.NewCipher([]byte(key))
+	if err != nil {
+		t.Fatalf("初始化加密失败: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatalf("初始化加密失败: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nil, []byte(nonce), plaintext, []byte(associatedData)))
+}
+
+// buildNotifyBody 构造微信支付回调报文
+func buildNotifyBody(t *testing.T, key string) []byte {
+	t.Helper()
+
+	transaction, _ := json.Marshal(map[string]interface{}{
+		"out_trade_no":   "RC202401010001",
+		"transaction_id": "4200000001",
+		"trade_state":    "SUCCESS",
+		"amount":         map[string]interface{}{"total": 10000, "payer_total": 10000},
+	})
+	body, _ := json.Marshal(map[string]interface{}{
+		"id":            "EV-001",
+		"event_type":    "TRANSACTION.SUCCESS",
+		"resource_type": "encrypt-resource",
+		"resource": map[string]string{
+			"algorithm":       "AEAD_AES_256_GCM",
+			"ciphertext":      encryptResource(t, key, transaction, "abcdefghijkl", "transaction"),
+			"associated_data": "transaction",
+			"nonce":           "abcdefghijkl",
+		},
+	})
+	return body
+}
+
+// newNotifyRequest 构造带平台签名头的回调请求
+func newNotifyRequest(t *testing.T, platformKey *rsa.PrivateKey, body []byte, ts time.Time, serial string) *http.Request {
+	t.Helper()
+
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+	nonce := "notify-nonce"
+	hashed := sha256.Sum256([]byte(fmt.Sprintf("%s\n%s\n%s\n", timestamp, nonce, body)))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, platformKey, crypto.SHA256, hashed[:])
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(string(body)))
+	req.Header.Set("Wechatpay-Timestamp", timestamp)
+	req.Header.Set("Wechatpay-Nonce", nonce)
+	req.Header.Set("Wechatpay-Signature", base64.StdEncoding.EncodeToString(sig))
+	req.Header.Set("Wechatpay-Serial", serial)
+	return req
+}
+
+func TestWeChatParseNotify(t *testing.T) {
+	provider, platformKey := newTestWeChatProvider(t)
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	body := buildNotifyBody(t, testAPIv3Key)
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		req     func() *http.Request
+		wantErr error
+	}{
+		{"合法回调", func() *http.Request {
+			return newNotifyRequest(t, platformKey, body, now, "PLATFORM_SERIAL")
+		}, nil},
+		{"非平台私钥签名", func() *http.Request {
+			return newNotifyRequest(t, otherKey, body, now, "PLATFORM_SERIAL")
+		}, ErrInvalidSignature},
+		{"报文被篡改", func() *http.Request {
+			req := newNotifyRequest(t, platformKey, body, now, "PLATFORM_SERIAL")
+			tampered := strings.Replace(string(body), "EV-001", "EV-002", 1)
+			req.Body = httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(tampered)).Body
+			return req
+		}, ErrInvalidSignature},
+		{"平台证书序列号不匹配", func() *http.Request {
+			return newNotifyRequest(t, platformKey, body, now, "OTHER_SERIAL")
+		}, ErrInvalidSignature},
+		{"时间戳过旧", func() *http.Request {
+			return newNotifyRequest(t, platformKey, body, now.Add(-wechatNotifyMaxSkew-time.Minute), "PLATFORM_SERIAL")
+		}, ErrInvalidSignature},
+		{"时间戳超前", func() *http.Request {
+			return newNotifyRequest(t, platformKey, body, now.Add(wechatNotifyMaxSkew+time.Minute), "PLATFORM_SERIAL")
+		}, ErrInvalidSignature},
+		{"时间戳在允许偏差内", func() *http.Request {
+			return newNotifyRequest(t, platformKey, body, now.Add(-wechatNotifyMaxSkew+time.Minute), "PLATFORM_SERIAL")
+		}, nil},
+		{"缺少签名头", func() *http.Request {
+			req := newNotifyRequest(t, platformKey, body, now, "PLATFORM_SERIAL")
+			req.Header.Del("Wechatpay-Signature")
+			return req
+		}, ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := provider.ParseNotify(tt.req())
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ParseNotify() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseNotify() error = %v", err)
+			}
+			if result.OutTradeNo != "RC202401010001" || result.TransactionID != "4200000001" ||
+				result.AmountFen != 10000 || !result.Success {
+				t.Errorf("ParseNotify() = %+v", result)
+			}
+		})
+	}
+}
+
+func TestWeChatDecryptResource(t *testing.T) {
+	provider, _ := newTestWeChatProvider(t)
+	plaintext := []byte(`{"out_trade_no":"RC202401010001"}`)
+	ciphertext := encryptResource(t, testAPIv3Key, plaintext, "abcdefghijkl", "transaction")
+
+	got, err := provider.decryptResource(ciphertext, "abcdefghijkl", "transaction")
+	if err != nil {
+		t.Fatalf("decryptResource() error = %v", err)
+	}
+	if string(got) != string(plaintext) {
+		t.Errorf("decryptResource() = %s, want %s", got, plaintext)
+	}
+
+	tests := []struct {
+		name           string
+		ciphertext     string
+		nonce          string
+		associatedData string
+	}{
+		{"附加数据不一致", ciphertext, "abcdefghijkl", "other"},
+		{"随机串不一致", ciphertext, "lkjihgfedcba", "transaction"},
+		{"密钥不一致", encryptResource(t, "fedcba9876543210fedcba9876543210", plaintext, "abcdefghijkl", "transaction"), "abcdefghijkl", "transaction"},
+		{"密文非base64", "not-base64!", "abcdefghijkl", "transaction"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := provider.decryptResource(tt.ciphertext, tt.nonce, tt.associatedData); err == nil {
+				t.Errorf("decryptResource() 应解密失败")
+			}
+		})
+	}
+}
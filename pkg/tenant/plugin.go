@@ -0,0 +1,114 @@
+package tenant
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Column 租户字段列名
+const Column = "tenants_id"
+
+// Plugin GORM 租户隔离插件
+// 对实现 Scoped 的模型：查询/更新/删除自动追加 tenants_id 条件，新增时自动填充 tenants_id。
+// 只有 context 中带租户信息时生效（通过 db.WithContext 传入），定时任务等后台逻辑不受影响。
+type Plugin struct{}
+
+// NewPlugin 创建租户隔离插件
+func NewPlugin() *Plugin {
+	return &Plugin{}
+}
+
+// Name 插件名称
+func (p *Plugin) Name() string {
+	return "tenant"
+}
+
+// Initialize 注册回调
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").Register("tenant:query", p.addCondition); err != nil {
+		return err
+	}
+	if err := db.Callback().Row().Before("gorm:row").Register("tenant:row", p.addCondition); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("tenant:update", p.addCondition); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().Before("gorm:delete").Register("tenant:delete", p.addCondition); err != nil {
+		return err
+	}
+	return db.Callback().Create().Before("gorm:create").Register("tenant:create", p.fillTenant)
+}
+
+// addCondition 追加 tenants_id 过滤条件
+func (p *Plugin) addCondition(db *gorm.DB) {
+	info, ok := p.enforced(db)
+	if !ok {
+		return
+	}
+
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: Column}, Value: info.TenantID},
+	}})
+}
+
+// fillTenant 新增记录时填充 tenants_id，已指定其他租户则拒绝写入
+func (p *Plugin) fillTenant(db *gorm.DB) {
+	info, ok := p.enforced(db)
+	if !ok {
+		return
+	}
+
+	field := db.Statement.Schema.LookUpField(Column)
+	if field == nil {
+		return
+	}
+
+	rv := db.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if err := p.setTenant(db, field, reflect.Indirect(rv.Index(i)), info.TenantID); err != nil {
+				db.AddError(err)
+				return
+			}
+		}
+	case reflect.Struct:
+		if err := p.setTenant(db, field, rv, info.TenantID); err != nil {
+			db.AddError(err)
+		}
+	}
+}
+
+// setTenant 为单条记录设置 tenants_id
+func (p *Plugin) setTenant(db *gorm.DB, field *schema.Field, rv reflect.Value, tenantID int) error {
+	ctx := db.Statement.Context
+	value, isZero := field.ValueOf(ctx, rv)
+	if !isZero {
+		if id, ok := value.(int); ok && id != tenantID {
+			return ErrCrossTenant
+		}
+		return nil
+	}
+	return field.Set(ctx, rv, tenantID)
+}
+
+// enforced 当前语句是否需要租户隔离
+func (p *Plugin) enforced(db *gorm.DB) (*Info, bool) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return nil, false
+	}
+
+	info, ok := FromContext(db.Statement.Context)
+	if !ok || !info.Enforced() {
+		return nil, false
+	}
+
+	if _, ok := reflect.New(db.Statement.Schema.ModelType).Interface().(Scoped); !ok {
+		return nil, false
+	}
+	return info, true
+}
@@ -0,0 +1,171 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// scopedRoom 按租户隔离的测试模型
+type scopedRoom struct {
+	ID        int
+	RoomName  string
+	TenantsID int `gorm:"column:tenants_id"`
+}
+
+func (scopedRoom) TenantScoped() {}
+
+// sharedConfig 不隔离的测试模型
+type sharedConfig struct {
+	ID        int
+	Name      string
+	TenantsID int `gorm:"column:tenants_id"`
+}
+
+// newDryRunDB DryRun 只生成SQL，不连接数据库；写操作跳过默认事务，避免开启事务时连接数据库
+func newDryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	gdb, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "test:test@tcp(127.0.0.1:3306)/test?parseTime=true",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("创建 DryRun 连接失败: %v", err)
+	}
+	if err := gdb.Use(NewPlugin()); err != nil {
+		t.Fatalf("注册租户插件失败: %v", err)
+	}
+	return gdb
+}
+
+func tenantCtx(tenantID int) context.Context {
+	return WithContext(context.Background(), &Info{TenantID: tenantID, UserID: 1})
+}
+
+// hasTenantCondition SQL 是否带租户条件且参数为 tenantID
+func hasTenantCondition(stmt *gorm.Statement, tenantID int) bool {
+	if !strings.Contains(stmt.SQL.String(), "`tenants_id` = ?") {
+		return false
+	}
+	for _, v := range stmt.Vars {
+		if v == tenantID {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPluginAddsTenantCondition(t *testing.T) {
+	gdb := newDryRunDB(t)
+	ctx := tenantCtx(8)
+
+	tests := []struct {
+		name string
+		run  func(tx *gorm.DB) *gorm.DB
+	}{
+		{"查询", func(tx *gorm.DB) *gorm.DB {
+			var rooms []scopedRoom
+			return tx.Where("room_name = ?", "A01").Find(&rooms)
+		}},
+		{"计数", func(tx *gorm.DB) *gorm.DB {
+			var count int64
+			return tx.Model(&scopedRoom{}).Count(&count)
+		}},
+		{"更新", func(tx *gorm.DB) *gorm.DB {
+			return tx.Model(&scopedRoom{ID: 1}).Update("room_name", "A02")
+		}},
+		{"删除", func(tx *gorm.DB) *gorm.DB {
+			return tx.Delete(&scopedRoom{}, 1)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.run(gdb.WithContext(ctx))
+			if result.Error != nil {
+				t.Fatalf("执行失败: %v", result.Error)
+			}
+			if !hasTenantCondition(result.Statement, 8) {
+				t.Errorf("缺少租户条件: %s %v", result.Statement.SQL.String(), result.Statement.Vars)
+			}
+		})
+	}
+}
+
+func TestPluginSkipsTenantCondition(t *testing.T) {
+	gdb := newDryRunDB(t)
+
+	tests := []struct {
+		name string
+		tx   *gorm.DB
+		dest interface{}
+	}{
+		{"没有租户上下文", gdb, &[]scopedRoom{}},
+		{"超级管理员跨租户", gdb.WithContext(WithContext(context.Background(), &Info{TenantID: 8, Bypass: true})), &[]scopedRoom{}},
+		{"未实现 Scoped 的模型", gdb.WithContext(tenantCtx(8)), &[]sharedConfig{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.tx.Find(tt.dest)
+			if result.Error != nil {
+				t.Fatalf("执行失败: %v", result.Error)
+			}
+			if sql := result.Statement.SQL.String(); strings.Contains(sql, "tenants_id") {
+				t.Errorf("不应追加租户条件: %s", sql)
+			}
+		})
+	}
+}
+
+func TestPluginFillsTenantOnCreate(t *testing.T) {
+	gdb := newDryRunDB(t)
+	ctx := tenantCtx(8)
+
+	room := scopedRoom{RoomName: "A01"}
+	if err := gdb.WithContext(ctx).Create(&room).Error; err != nil {
+		t.Fatalf("新增失败: %v", err)
+	}
+	if room.TenantsID != 8 {
+		t.Errorf("tenants_id = %d, want 8", room.TenantsID)
+	}
+
+	rooms := []scopedRoom{{RoomName: "A02"}, {RoomName: "A03", TenantsID: 8}}
+	if err := gdb.WithContext(ctx).Create(&rooms).Error; err != nil {
+		t.Fatalf("批量新增失败: %v", err)
+	}
+	for _, r := range rooms {
+		if r.TenantsID != 8 {
+			t.Errorf("%s tenants_id = %d, want 8", r.RoomName, r.TenantsID)
+		}
+	}
+
+	// 没有租户上下文时保持原值
+	background := scopedRoom{RoomName: "B01"}
+	if err := gdb.Create(&background).Error; err != nil {
+		t.Fatalf("新增失败: %v", err)
+	}
+	if background.TenantsID != 0 {
+		t.Errorf("无租户上下文 tenants_id = %d, want 0", background.TenantsID)
+	}
+}
+
+func TestPluginRejectsCrossTenantCreate(t *testing.T) {
+	gdb := newDryRunDB(t)
+	ctx := tenantCtx(8)
+
+	err := gdb.WithContext(ctx).Create(&scopedRoom{RoomName: "A01", TenantsID: 9}).Error
+	if !errors.Is(err, ErrCrossTenant) {
+		t.Errorf("跨租户新增 err = %v, want ErrCrossTenant", err)
+	}
+
+	rooms := []scopedRoom{{RoomName: "A02"}, {RoomName: "A03", TenantsID: 9}}
+	err = gdb.WithContext(ctx).Create(&rooms).Error
+	if !errors.Is(err, ErrCrossTenant) {
+		t.Errorf("批量跨租户新增 err = %v, want ErrCrossTenant", err)
+	}
+}
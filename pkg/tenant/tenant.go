@@ -0,0 +1,55 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+)
+
+// ContextKey gin.Context 中保存租户信息的键
+// 使用字符串键，使 *gin.Context 直接作为 context.Context 传给 GORM 时也能取到租户
+const ContextKey = "tenant_context"
+
+var (
+	// ErrCrossTenant 写入的数据不属于当前租户
+	ErrCrossTenant = errors.New("禁止跨租户操作数据")
+)
+
+// ctxKey 标准 context 中保存租户信息的键
+type ctxKey struct{}
+
+// Info 当前请求的租户信息
+type Info struct {
+	TenantID int  // 商家ID（店铺用户为自身ID，员工为所属店铺ID）
+	UserID   int  // 当前登录用户ID
+	UserType int  // 用户类型
+	Bypass   bool // 超级管理员可跨租户访问
+}
+
+// Scoped 按租户隔离的模型实现该接口，GORM 插件据此自动追加 tenants_id 条件
+type Scoped interface {
+	TenantScoped()
+}
+
+// WithContext 将租户信息写入 context
+func WithContext(ctx context.Context, info *Info) context.Context {
+	return context.WithValue(ctx, ctxKey{}, info)
+}
+
+// FromContext 从 context 读取租户信息
+func FromContext(ctx context.Context) (*Info, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	if info, ok := ctx.Value(ctxKey{}).(*Info); ok && info != nil {
+		return info, true
+	}
+	if info, ok := ctx.Value(ContextKey).(*Info); ok && info != nil {
+		return info, true
+	}
+	return nil, false
+}
+
+// Enforced 是否需要按租户过滤
+func (i *Info) Enforced() bool {
+	return i != nil && !i.Bypass
+}
@@ -0,0 +1,307 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// backplaneChannel 跨节点消息分发频道
+	backplaneChannel = "ws:backplane"
+	// onlineUsersKey 集群在线用户 ZSET，score 为在线状态过期时间戳
+	onlineUsersKey = "ws:online:users"
+	// userNodesKeyPrefix 用户所在节点 SET，带TTL
+	userNodesKeyPrefix = "ws:online:user:"
+	// nodesKey 存活节点 ZSET，score 为心跳过期时间戳
+	nodesKey = "ws:nodes"
+	// nodeStatsKeyPrefix 节点连接统计 HASH
+	nodeStatsKeyPrefix = "ws:node:"
+
+	// presenceTTL 在线状态有效期，节点宕机后最多该时长内其用户被判定离线
+	presenceTTL = 60 * time.Second
+	// heartbeatInterval 在线状态心跳周期
+	heartbeatInterval = 20 * time.Second
+)
+
+// 跨节点消息类型
+const (
+	envelopeKindUser      = "user"
+	envelopeKindBroadcast = "broadcast"
+)
+
+// markOfflineScript 移除用户在本节点的在线记录，所有节点都离线时从在线用户集合移除
+var markOfflineScript = redis.NewScript(`
+redis.call("SREM", KEYS[1], ARGV[1])
+local remaining = redis.call("SCARD", KEYS[1])
+if remaining == 0 then
+	redis.call("ZREM", KEYS[2], ARGV[2])
+end
+return remaining
+`)
+
+// Envelope 跨节点消息
+type Envelope struct {
+	Origin    string `json:"origin"`               // 发布节点
+	Kind      string `json:"kind"`                 // user / broadcast
+	UserID    int    `json:"user_id,omitempty"`    // 目标用户
+	MessageID string `json:"message_id,omitempty"` // 业务消息ID
+	Payload   []byte `json:"payload"`              // 原始消息
+}
+
+// RemoteDeliveryHandler 处理其他节点转发来的定向消息投递结果
+// delivered 为 false 表示用户已不在本节点，由调用方决定是否转存离线消息
+type RemoteDeliveryHandler func(userID int, messageID string, payload []byte, delivered bool)
+
+// Backplane 基于 Redis pub/sub 的多节点消息总线和在线状态
+type Backplane struct {
+	hub         *Hub
+	redisClient *redis.Client
+	nodeID      string
+	ctx         context.Context
+	cancel      context.CancelFunc
+}
+
+// newBackplane 创建消息总线
+func newBackplane(hub *Hub, redisClient *redis.Client) *Backplane {
+	hostname, _ := os.Hostname()
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Backplane{
+		hub:         hub,
+		redisClient: redisClient,
+		nodeID:      fmt.Sprintf("%s:%d:%s", hostname, os.Getpid(), uuid.New().String()[:8]),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// start 启动订阅和心跳
+func (b *Backplane) start() {
+	go b.subscribe()
+	go b.heartbeatLoop()
+	log.Printf("✅ WebSocket跨节点消息总线已启动: node=%s", b.nodeID)
+}
+
+// stop 停止并清理本节点在线记录
+func (b *Backplane) stop() {
+	b.cancel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	for _, userID := range b.hub.GetOnlineUserIDs() {
+		b.markOffline(ctx, userID)
+	}
+	b.redisClient.ZRem(ctx, nodesKey, b.nodeID)
+	b.redisClient.Del(ctx, nodeStatsKeyPrefix+b.nodeID)
+}
+
+// subscribe 订阅跨节点消息，go-redis 断线后会自动重连
+func (b *Backplane) subscribe() {
+	pubsub := b.redisClient.Subscribe(b.ctx, backplaneChannel)
+	defer pubsub.Close()
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			b.handleMessage(msg.Payload)
+		}
+	}
+}
+
+// handleMessage 处理其他节点发布的消息
+func (b *Backplane) handleMessage(data string) {
+	var env Envelope
+	if err := json.Unmarshal([]byte(data), &env); err != nil {
+		log.Printf("解析跨节点消息失败: %v", err)
+		return
+	}
+	if env.Origin == b.nodeID {
+		return
+	}
+
+	switch env.Kind {
+	case envelopeKindUser:
+		// 用户不在本节点时不处理，由用户所在节点负责投递
+		if !b.hub.hasLocalClients(env.UserID) {
+			return
+		}
+		delivered := b.hub.deliverLocal(env.UserID, env.Payload) > 0
+		if b.hub.onRemoteDelivery != nil {
+			b.hub.onRemoteDelivery(env.UserID, env.MessageID, env.Payload, delivered)
+		}
+	case envelopeKindBroadcast:
+		select {
+		case b.hub.remoteBroadcast <- env.Payload:
+		case <-b.ctx.Done():
+		}
+	}
+}
+
+// publish 发布跨节点消息
+func (b *Backplane) publish(env *Envelope) error {
+	env.Origin = b.nodeID
+	data, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("序列化跨节点消息失败: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(b.ctx, 3*time.Second)
+	defer cancel()
+	if err := b.redisClient.Publish(ctx, backplaneChannel, data).Err(); err != nil {
+		return fmt.Errorf("发布跨节点消息失败: %w", err)
+	}
+	return nil
+}
+
+// markOnline 记录用户在本节点在线
+func (b *Backplane) markOnline(ctx context.Context, userIDs ...int) {
+	if len(userIDs) == 0 {
+		return
+	}
+
+	expireAt := float64(time.Now().Add(presenceTTL).Unix())
+	pipe := b.redisClient.Pipeline()
+	for _, userID := range userIDs {
+		key := userNodesKeyPrefix + strconv.Itoa(userID)
+		pipe.SAdd(ctx, key, b.nodeID)
+		pipe.Expire(ctx, key, presenceTTL)
+		pipe.ZAdd(ctx, onlineUsersKey, redis.Z{Score: expireAt, Member: strconv.Itoa(userID)})
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("更新集群在线状态失败: %v", err)
+	}
+}
+
+// markOffline 移除用户在本节点的在线记录，返回用户是否仍在其他节点在线
+func (b *Backplane) markOffline(ctx context.Context, userID int) bool {
+	remaining, err := markOfflineScript.Run(ctx, b.redisClient,
+		[]string{userNodesKeyPrefix + strconv.Itoa(userID), onlineUsersKey},
+		b.nodeID, strconv.Itoa(userID)).Int64()
+	if err != nil {
+		log.Printf("移除集群在线状态失败: UserID=%d, Error=%v", userID, err)
+		return false
+	}
+	return remaining > 0
+}
+
+// isOnline 用户是否在任一节点在线
+func (b *Backplane) isOnline(ctx context.Context, userID int) (bool, error) {
+	count, err := b.redisClient.SCard(ctx, userNodesKeyPrefix+strconv.Itoa(userID)).Result()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// onlineUserIDs 集群在线用户ID
+func (b *Backplane) onlineUserIDs(ctx context.Context) ([]int, error) {
+	members, err := b.redisClient.ZRangeByScore(ctx, onlineUsersKey, &redis.ZRangeBy{
+		Min: strconv.FormatInt(time.Now().Unix(), 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]int, 0, len(members))
+	for _, member := range members {
+		if userID, err := strconv.Atoi(member); err == nil {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	return userIDs, nil
+}
+
+// heartbeatLoop 定期刷新本节点用户的在线状态和节点统计
+func (b *Backplane) heartbeatLoop() {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	b.heartbeat()
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+			b.heartbeat()
+		}
+	}
+}
+
+// heartbeat 刷新在线状态
+func (b *Backplane) heartbeat() {
+	ctx, cancel := context.WithTimeout(b.ctx, 5*time.Second)
+	defer cancel()
+
+	b.markOnline(ctx, b.hub.GetOnlineUserIDs()...)
+
+	stats := b.hub.GetStats()
+	now := time.Now()
+	pipe := b.redisClient.Pipeline()
+	pipe.ZAdd(ctx, nodesKey, redis.Z{Score: float64(now.Add(presenceTTL).Unix()), Member: b.nodeID})
+	pipe.HSet(ctx, nodeStatsKeyPrefix+b.nodeID,
+		"connections", stats["total_connections"],
+		"users", stats["unique_users"],
+		"heartbeat_at", now.Format("2006-01-02 15:04:05"))
+	pipe.Expire(ctx, nodeStatsKeyPrefix+b.nodeID, presenceTTL)
+	// 清理宕机节点遗留的过期记录
+	pipe.ZRemRangeByScore(ctx, onlineUsersKey, "-inf", strconv.FormatInt(now.Unix(), 10))
+	pipe.ZRemRangeByScore(ctx, nodesKey, "-inf", strconv.FormatInt(now.Unix(), 10))
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("WebSocket节点心跳失败: %v", err)
+	}
+}
+
+// clusterStats 集群连接统计
+func (b *Backplane) clusterStats(ctx context.Context) (map[string]interface{}, error) {
+	nowStr := strconv.FormatInt(time.Now().Unix(), 10)
+	nodeIDs, err := b.redisClient.ZRangeByScore(ctx, nodesKey, &redis.ZRangeBy{Min: nowStr, Max: "+inf"}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("查询WebSocket节点失败: %w", err)
+	}
+
+	onlineUsers, err := b.redisClient.ZCount(ctx, onlineUsersKey, nowStr, "+inf").Result()
+	if err != nil {
+		return nil, fmt.Errorf("查询集群在线用户失败: %w", err)
+	}
+
+	totalConnections := 0
+	nodes := make([]map[string]interface{}, 0, len(nodeIDs))
+	for _, nodeID := range nodeIDs {
+		values, err := b.redisClient.HGetAll(ctx, nodeStatsKeyPrefix+nodeID).Result()
+		if err != nil {
+			continue
+		}
+		connections, _ := strconv.Atoi(values["connections"])
+		users, _ := strconv.Atoi(values["users"])
+		totalConnections += connections
+		nodes = append(nodes, map[string]interface{}{
+			"node_id":      nodeID,
+			"connections":  connections,
+			"users":        users,
+			"heartbeat_at": values["heartbeat_at"],
+			"current":      nodeID == b.nodeID,
+		})
+	}
+
+	return map[string]interface{}{
+		"node_id":           b.nodeID,
+		"node_count":        len(nodeIDs),
+		"total_connections": totalConnections,
+		"online_users":      onlineUsers,
+		"nodes":             nodes,
+	}, nil
+}
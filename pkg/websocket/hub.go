@@ -1,11 +1,14 @@
 package websocket
 
 import (
+	"context"
 	"log"
 	"nasa-go-admin/middleware"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/redis/go-redis/v9"
 )
 
 // Hub 维护活跃客户端的集合并广播消息
@@ -31,6 +34,15 @@ type Hub struct {
 	// 用户离线回调函数
 	onUserOffline func(userID int, connectionID string)
 
+	// 跨节点消息总线，未启用时仅在本节点内投递
+	backplane *Backplane
+
+	// 其他节点转发的广播消息，由Run统一投递以保证Clients只在Run中修改
+	remoteBroadcast chan []byte
+
+	// 其他节点转发的定向消息投递回调
+	onRemoteDelivery RemoteDeliveryHandler
+
 	// 性能统计
 	stats struct {
 		totalConnections int64
@@ -42,14 +54,41 @@ type Hub struct {
 // NewHub 创建一个新的Hub实例
 func NewHub() *Hub {
 	return &Hub{
-		Broadcast:   make(chan []byte),
-		Register:    make(chan *Client),
-		Unregister:  make(chan *Client),
-		Clients:     make(map[*Client]bool),
-		UserClients: make(map[int][]*Client),
+		Broadcast:       make(chan []byte),
+		Register:        make(chan *Client),
+		Unregister:      make(chan *Client),
+		Clients:         make(map[*Client]bool),
+		UserClients:     make(map[int][]*Client),
+		remoteBroadcast: make(chan []byte, 256),
 	}
 }
 
+// EnableBackplane 启用基于Redis的跨节点消息分发和集群在线状态（需在Run之前调用）
+func (h *Hub) EnableBackplane(redisClient *redis.Client) {
+	if redisClient == nil || h.backplane != nil {
+		return
+	}
+	h.backplane = newBackplane(h, redisClient)
+	h.backplane.start()
+}
+
+// StopBackplane 停止跨节点消息分发并移除本节点在线记录
+func (h *Hub) StopBackplane() {
+	if h.backplane != nil {
+		h.backplane.stop()
+	}
+}
+
+// BackplaneEnabled 是否启用了跨节点消息分发
+func (h *Hub) BackplaneEnabled() bool {
+	return h.backplane != nil
+}
+
+// SetRemoteDeliveryHandler 设置其他节点转发的定向消息投递回调
+func (h *Hub) SetRemoteDeliveryHandler(handler RemoteDeliveryHandler) {
+	h.onRemoteDelivery = handler
+}
+
 // SetUserOfflineCallback 设置用户离线回调函数
 func (h *Hub) SetUserOfflineCallback(callback func(userID int, connectionID string)) {
 	h.onUserOffline = callback
@@ -84,6 +123,15 @@ func (h *Hub) Run() {
 					totalConnections, uniqueUsers, float64(totalConnections)/float64(uniqueUsers))
 			}
 
+			// 同步集群在线状态
+			if h.backplane != nil {
+				go func(userID int) {
+					ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+					defer cancel()
+					h.backplane.markOnline(ctx, userID)
+				}(client.UserID)
+			}
+
 			// 异步处理用户上线后的离线消息
 			go func(userID int) {
 				// 延迟1秒确保连接稳定
@@ -122,46 +170,94 @@ func (h *Hub) Run() {
 
 				// 如果用户没有其他连接，调用用户离线回调
 				if len(h.UserClients[client.UserID]) == 0 {
-					if h.onUserOffline != nil {
-						h.onUserOffline(client.UserID, client.ConnectionID)
+					if h.backplane != nil {
+						// 需要查询Redis确认其他节点是否仍在线，不阻塞Run
+						go h.handleUserOffline(client.UserID, client.ConnectionID)
+					} else {
+						h.notifyUserOffline(client.UserID, client.ConnectionID)
 					}
-					log.Printf("用户 %d 已完全离线", client.UserID)
 				}
 			}
 
 		case message := <-h.Broadcast:
-			for client := range h.Clients {
-				select {
-				case client.Send <- message:
-				default:
-					close(client.Send)
-					delete(h.Clients, client)
-
-					// 从用户映射中移除客户端
-					h.mu.Lock()
-					clients := h.UserClients[client.UserID]
-					for i, c := range clients {
-						if c == client {
-							h.UserClients[client.UserID] = append(clients[:i], clients[i+1:]...)
-							break
-						}
-					}
-					if len(h.UserClients[client.UserID]) == 0 {
-						delete(h.UserClients, client.UserID)
+			h.broadcastLocal(message)
+			if h.backplane != nil {
+				go func(payload []byte) {
+					if err := h.backplane.publish(&Envelope{Kind: envelopeKindBroadcast, Payload: payload}); err != nil {
+						log.Printf("广播消息跨节点分发失败: %v", err)
 					}
-					h.mu.Unlock()
+				}(message)
+			}
+
+		case message := <-h.remoteBroadcast:
+			h.broadcastLocal(message)
+		}
+	}
+}
+
+// broadcastLocal 向本节点所有客户端广播消息（仅在Run中调用）
+func (h *Hub) broadcastLocal(message []byte) {
+	for client := range h.Clients {
+		select {
+		case client.Send <- message:
+		default:
+			close(client.Send)
+			delete(h.Clients, client)
+
+			// 从用户映射中移除客户端
+			h.mu.Lock()
+			clients := h.UserClients[client.UserID]
+			for i, c := range clients {
+				if c == client {
+					h.UserClients[client.UserID] = append(clients[:i], clients[i+1:]...)
+					break
 				}
 			}
+			if len(h.UserClients[client.UserID]) == 0 {
+				delete(h.UserClients, client.UserID)
+			}
+			h.mu.Unlock()
 		}
 	}
 }
 
-// SendToUser 向特定用户发送消息
+// handleUserOffline 移除本节点在线记录，用户在所有节点都离线时才触发离线回调
+func (h *Hub) handleUserOffline(userID int, connectionID string) {
+	// 注销期间用户已重新连接到本节点
+	if h.hasLocalClients(userID) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if h.backplane.markOffline(ctx, userID) {
+		log.Printf("用户 %d 在本节点已离线，其他节点仍在线", userID)
+		return
+	}
+	h.notifyUserOffline(userID, connectionID)
+}
+
+// notifyUserOffline 调用用户离线回调
+func (h *Hub) notifyUserOffline(userID int, connectionID string) {
+	if h.onUserOffline != nil {
+		h.onUserOffline(userID, connectionID)
+	}
+	log.Printf("用户 %d 已完全离线", userID)
+}
+
+// SendToUser 向特定用户发送消息，用户不在本节点时转发给其他节点
 func (h *Hub) SendToUser(userID int, message []byte) {
 	h.mu.Lock()
 	clients := h.UserClients[userID]
 	h.mu.Unlock()
 
+	if len(clients) == 0 && h.backplane != nil {
+		if err := h.PublishToUser(userID, "", message); err != nil {
+			log.Printf("消息跨节点转发失败: UserID=%d, Error=%v", userID, err)
+		}
+		return
+	}
+
 	for _, client := range clients {
 		select {
 		case client.Send <- message:
@@ -239,8 +335,27 @@ func (h *Hub) GetUserClients(userID int) []*Client {
 	return result
 }
 
-// IsUserOnline 检查用户是否在线
+// IsUserOnline 检查用户是否在线，启用跨节点分发时按集群判断
 func (h *Hub) IsUserOnline(userID int) bool {
+	if h.hasLocalClients(userID) {
+		return true
+	}
+	if h.backplane == nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	online, err := h.backplane.isOnline(ctx, userID)
+	if err != nil {
+		log.Printf("查询集群在线状态失败: UserID=%d, Error=%v", userID, err)
+		return false
+	}
+	return online
+}
+
+// hasLocalClients 用户是否在本节点有连接
+func (h *Hub) hasLocalClients(userID int) bool {
 	h.mu.RLock() // 优化：使用读锁提升并发性能
 	defer h.mu.RUnlock()
 
@@ -248,6 +363,81 @@ func (h *Hub) IsUserOnline(userID int) bool {
 	return exists && len(clients) > 0
 }
 
+// deliverLocal 向本节点的用户连接投递消息，返回成功投递的连接数
+func (h *Hub) deliverLocal(userID int, message []byte) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	successCount := 0
+	for _, client := range h.UserClients[userID] {
+		select {
+		case client.Send <- message:
+			successCount++
+		default:
+			// 客户端缓冲区已满，异步处理
+			go h.handleFullBuffer(client)
+		}
+	}
+
+	atomic.AddInt64(&h.stats.messageCount, int64(successCount))
+	return successCount
+}
+
+// PublishToUser 将定向消息转发给其他节点，由用户所在节点投递
+func (h *Hub) PublishToUser(userID int, messageID string, message []byte) error {
+	if h.backplane == nil {
+		return nil
+	}
+	return h.backplane.publish(&Envelope{
+		Kind:      envelopeKindUser,
+		UserID:    userID,
+		MessageID: messageID,
+		Payload:   message,
+	})
+}
+
+// GetClusterOnlineUserIDs 获取集群在线用户ID列表，未启用跨节点分发或查询失败时返回本节点在线用户
+func (h *Hub) GetClusterOnlineUserIDs() []int {
+	if h.backplane == nil {
+		return h.GetOnlineUserIDs()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	userIDs, err := h.backplane.onlineUserIDs(ctx)
+	if err != nil {
+		log.Printf("查询集群在线用户失败: %v", err)
+		return h.GetOnlineUserIDs()
+	}
+	return userIDs
+}
+
+// GetClusterStats 获取集群连接统计
+func (h *Hub) GetClusterStats() map[string]interface{} {
+	if h.backplane == nil {
+		local := h.GetStats()
+		return map[string]interface{}{
+			"enabled":           false,
+			"node_count":        1,
+			"total_connections": local["total_connections"],
+			"online_users":      local["unique_users"],
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	stats, err := h.backplane.clusterStats(ctx)
+	if err != nil {
+		return map[string]interface{}{
+			"enabled": true,
+			"node_id": h.backplane.nodeID,
+			"error":   err.Error(),
+		}
+	}
+	stats["enabled"] = true
+	return stats
+}
+
 // RemoveClient 移除客户端（线程安全）
 func (h *Hub) RemoveClient(client *Client) {
 	h.mu.Lock()
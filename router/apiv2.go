@@ -39,6 +39,8 @@ func InitApp(r *gin.Engine) {
 		logGroup.POST("/register", middleware.ValidationMiddleware(&inout.AddUserAppReq{}), app.Register)
 		//登录
 		logGroup.POST("/login", middleware.ValidationMiddleware(&inout.LoginAppReq{}), app.Login)
+		//支付渠道回调（验签，不需要登录）
+		logGroup.POST("/payment/notify/:provider", app.PaymentNotify)
 
 		// ========== 房间查看相关接口（无需登录，但记录日志） ==========
 		// 房间列表
@@ -47,6 +49,8 @@ func InitApp(r *gin.Engine) {
 		logGroup.GET("/rooms/:id", app.GetRoomDetail)
 		// 检查房间可用性
 		logGroup.POST("/rooms/check-availability", app.CheckRoomAvailability)
+		// 房间可预订日历（空闲时段及报价）
+		logGroup.GET("/rooms/calendar", app.GetRoomCalendar)
 		// 房间统计信息
 		logGroup.GET("/rooms/statistics", app.GetRoomStatistics)
 		// 获取房间可用套餐
@@ -77,6 +81,8 @@ func InitApp(r *gin.Engine) {
 			authGroup.GET("/user/wallet", app.GetUserWallet)
 			//用户充值
 			authGroup.POST("/user/recharge", middleware.ValidationMiddleware(&inout.RechargeReq{}), app.Recharge)
+			//充值单状态
+			authGroup.GET("/user/recharge/status", app.GetRechargeStatus)
 			//创建订单（现在使用安全订单创建器）
 			authGroup.POST("/order/create", middleware.ValidationMiddleware(&inout.CreateOrderReq{}), app.CreateOrder)
 			//我的订单列表
@@ -92,8 +98,36 @@ func InitApp(r *gin.Engine) {
 			authGroup.GET("/bookings", app.GetMyBookingList)
 			authGroup.GET("/bookings/:id", app.GetBookingDetail)
 			authGroup.POST("/bookings/cancel", app.CancelBooking)
+			authGroup.GET("/bookings/refund-preview", app.GetBookingRefundPreview)
+			// 钱包支付预订
+			authGroup.POST("/bookings/pay", app.PayBooking)
+			// 预订续时
+			authGroup.POST("/bookings/extend", app.ExtendBooking)
 			// 预订价格预览
 			authGroup.POST("/bookings/price-preview", app.BookingPricePreview)
+			// 周期预订
+			authGroup.POST("/bookings/series/preview", app.PreviewBookingSeries)
+			authGroup.POST("/bookings/series", app.CreateBookingSeries)
+			authGroup.GET("/bookings/series/:id", app.GetBookingSeriesDetail)
+			authGroup.POST("/bookings/series/cancel", app.CancelBookingSeries)
+			authGroup.POST("/bookings/series/pay", app.PayBookingSeries)
+			// 预订候补
+			authGroup.POST("/bookings/waitlist", app.JoinWaitlist)
+			authGroup.GET("/bookings/waitlist", app.GetMyWaitlist)
+			authGroup.POST("/bookings/waitlist/cancel", app.CancelWaitlist)
+			authGroup.POST("/bookings/waitlist/confirm", app.ConfirmWaitlistOffer)
+
+			// ========== 优惠券相关接口（需要登录） ==========
+			// 我的优惠券
+			authGroup.GET("/coupons", app.GetMyCouponList)
+			// 凭推广码领取
+			authGroup.POST("/coupons/claim", app.ClaimCoupon)
+
+			// ========== 会员等级与积分接口（需要登录） ==========
+			// 我的会员等级和积分
+			authGroup.GET("/member/account", app.GetMyMemberAccount)
+			// 我的积分流水
+			authGroup.GET("/member/points-logs", app.GetMyPointsLogs)
 		}
 	}
 }
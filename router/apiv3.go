@@ -55,7 +55,8 @@ func InitAdmin(r *gin.Engine) {
 	authGroup.Use(middleware.SecureAdminJWTAuth()) // 应用安全的管理员JWT中间件（支持Token黑名单）
 	authGroup.Use(middleware.RequestLogger("request_admin_log"))
 	authGroup.Use(middleware.UserInfoMiddleware())
-	authGroup.Use(middleware.RevokeTokenMiddleware()) // 添加Token撤销中间件
+	authGroup.Use(middleware.RevokeTokenMiddleware())   // 添加Token撤销中间件
+	authGroup.Use(middleware.APIPermissionMiddleware()) // 接口权限校验（按角色分配的接口权限）
 
 	// 注册资讯news路由
 	RegisterNewsRoutes(authGroup)
@@ -73,18 +74,37 @@ func InitAdmin(r *gin.Engine) {
 		// 预订管理
 		authGroup.GET("/bookings", admin.GetAdminBookingList)
 		authGroup.PUT("/bookings/status", admin.UpdateBookingStatus)
+		authGroup.GET("/bookings/series/:id", admin.GetAdminBookingSeriesDetail)
+		authGroup.POST("/bookings/series/cancel", admin.CancelAdminBookingSeries)
+		authGroup.GET("/bookings/waitlist", admin.GetAdminWaitlistList)
+		authGroup.GET("/bookings/waitlist/demand", admin.GetWaitlistDemand)
 
 		// 订单状态管理
 		authGroup.GET("/bookings/status-info", admin.GetBookingStatusInfo)
 		authGroup.POST("/bookings/manual-start", admin.ManualStartBooking)
 		authGroup.POST("/bookings/manual-end", admin.ManualEndBooking)
 
+		// 前台入住退房
+		authGroup.POST("/bookings/check-in", admin.CheckInBooking)
+		authGroup.POST("/bookings/verify", admin.VerifyBookingEntry)
+		authGroup.POST("/bookings/check-out", admin.CheckOutBooking)
+		authGroup.POST("/bookings/settle-fee", admin.SettleBookingFee)
+		authGroup.POST("/bookings/extend", admin.ExtendBooking)
+
+		// 前台代客预订与改期换房
+		authGroup.POST("/bookings", admin.CreateAdminBooking)
+		authGroup.PUT("/bookings", admin.ModifyAdminBooking)
+		authGroup.POST("/bookings/transfer", admin.TransferBookingRoom)
+
 		// 订单状态日志管理
 		authGroup.GET("/bookings/logs", admin.GetBookingLogList)
 		authGroup.GET("/bookings/logs/statistics", admin.GetBookingLogStatistics)
 
 		// 统计信息
 		authGroup.GET("/rooms/statistics", admin.GetRoomStatisticsAdmin)
+		// 房间利用率分析
+		authGroup.GET("/rooms/analytics", admin.GetRoomAnalytics)
+		authGroup.GET("/rooms/analytics/export", admin.ExportRoomAnalytics)
 
 		// ========== 房间套餐管理接口 ==========
 		// 套餐管理
@@ -104,9 +124,53 @@ func InitAdmin(r *gin.Engine) {
 		authGroup.GET("/rooms/special-dates", roomPackageController.GetSpecialDateList)
 		authGroup.POST("/rooms/special-dates", roomPackageController.CreateSpecialDate)
 		authGroup.DELETE("/rooms/special-dates/:id", roomPackageController.DeleteSpecialDate)
+		authGroup.POST("/rooms/special-dates/import", roomPackageController.ImportSpecialDates)
 
 		// 价格计算接口
 		authGroup.POST("/rooms/calculate-price", roomPackageController.CalculatePrice)
+
+		// 取消退款政策管理
+		authGroup.GET("/rooms/cancel-policies", admin.GetCancelPolicyList)
+		authGroup.POST("/rooms/cancel-policies", admin.CreateCancelPolicy)
+		authGroup.PUT("/rooms/cancel-policies", admin.UpdateCancelPolicy)
+		authGroup.DELETE("/rooms/cancel-policies/:id", admin.DeleteCancelPolicy)
+
+		// 房间维护/停业时段
+		authGroup.GET("/rooms/blackouts", admin.GetRoomBlackoutList)
+		authGroup.POST("/rooms/blackouts", admin.CreateRoomBlackout)
+		authGroup.PUT("/rooms/blackouts", admin.UpdateRoomBlackout)
+		authGroup.DELETE("/rooms/blackouts/:id", admin.CancelRoomBlackout)
+		authGroup.GET("/rooms/blackouts/:id/affected", admin.GetBlackoutAffectedBookings)
+		authGroup.POST("/rooms/blackouts/resolve", admin.ResolveBlackoutBookings)
+	}
+
+	// ========== 优惠券管理接口 ==========
+	{
+		authGroup.GET("/coupons/templates", admin.GetCouponTemplateList)
+		authGroup.POST("/coupons/templates", admin.CreateCouponTemplate)
+		authGroup.PUT("/coupons/templates", admin.UpdateCouponTemplate)
+		authGroup.DELETE("/coupons/templates/:id", admin.DeleteCouponTemplate)
+		// 向会员发放
+		authGroup.POST("/coupons/issue", admin.IssueCoupons)
+		// 已发放的用户优惠券
+		authGroup.GET("/coupons/user-coupons", admin.GetUserCouponList)
+	}
+
+	// ========== 会员等级与积分管理接口 ==========
+	{
+		authGroup.GET("/member/levels", admin.GetMemberLevelList)
+		authGroup.POST("/member/levels", admin.CreateMemberLevel)
+		authGroup.PUT("/member/levels", admin.UpdateMemberLevel)
+		authGroup.DELETE("/member/levels/:id", admin.DeleteMemberLevel)
+		// 会员积分
+		authGroup.GET("/member/accounts/:user_id", admin.GetMemberAccount)
+		authGroup.POST("/member/points/adjust", admin.AdjustMemberPoints)
+		authGroup.GET("/member/points/logs", admin.GetPointsLogList)
+		// 充值赠送规则
+		authGroup.GET("/member/recharge-bonus", admin.GetRechargeBonusRuleList)
+		authGroup.POST("/member/recharge-bonus", admin.CreateRechargeBonusRule)
+		authGroup.PUT("/member/recharge-bonus", admin.UpdateRechargeBonusRule)
+		authGroup.DELETE("/member/recharge-bonus/:id", admin.DeleteRechargeBonusRule)
 	}
 	{
 		//退出登录
@@ -115,6 +179,15 @@ func InitAdmin(r *gin.Engine) {
 		//发送系统消息通知
 		authGroup.POST("/system/notice", admin.PostnoticeInfo)
 
+		// 订阅消息模板配置
+		authGroup.GET("/miniapp/templates", admin.GetSubscribeTemplateList)
+		authGroup.GET("/miniapp/templates/events", admin.GetSubscribeEventList)
+		authGroup.POST("/miniapp/templates", admin.CreateSubscribeTemplate)
+		authGroup.PUT("/miniapp/templates", admin.UpdateSubscribeTemplate)
+		authGroup.DELETE("/miniapp/templates/:id", admin.DeleteSubscribeTemplate)
+		authGroup.POST("/miniapp/templates/preview", admin.PreviewSubscribeTemplate)
+		authGroup.POST("/miniapp/events/send", admin.SendSubscribeEvent)
+
 		// 推送记录管理
 		authGroup.GET("/notification/records", admin.GetPushRecordList)
 		authGroup.GET("/notification/records/:id", admin.GetPushRecordDetail)
@@ -232,10 +305,19 @@ func InitAdmin(r *gin.Engine) {
 		//获取订单列表
 		authGroup.GET("/order/list", admin.GetOrderList)
 
+		//获取退款申请列表
+		authGroup.GET("/order/refund/list", admin.GetRefundList)
+		//同意退款申请
+		authGroup.POST("/order/refund/approve", admin.ApproveRefund)
+		//拒绝退款申请
+		authGroup.POST("/order/refund/reject", admin.RejectRefund)
+
 		//获取收益流水统计列表
 		authGroup.GET("/order/revenue/list", admin.GetRevenueList)
 		//手动刷新收益统计数据
 		authGroup.POST("/order/revenue/refresh", admin.RefreshRevenueStats)
+		//收入看板（商品订单 + 房间预订）
+		authGroup.GET("/order/revenue/dashboard", admin.GetRevenueDashboard)
 
 		//添加员工
 		authGroup.POST("/employee/add", admin.AddEmployee)
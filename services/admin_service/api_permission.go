@@ -0,0 +1,206 @@
+package admin_service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"nasa-go-admin/db"
+	"nasa-go-admin/model/admin_model"
+	"nasa-go-admin/redis"
+	"strings"
+	"sync"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const (
+	// permissionCacheVersionKey 权限缓存版本号，角色权限或接口权限变更时递增，
+	// 所有实例的本地缓存和Redis缓存随之失效
+	permissionCacheVersionKey = "permission:cache:version"
+	// apiRouteCacheTTL 接口权限路由表缓存时间
+	apiRouteCacheTTL = 15 * time.Minute
+	// adminAPIPrefix 管理端接口前缀，接口权限的 path 可带可不带
+	adminAPIPrefix = "/api/admin"
+)
+
+var (
+	defaultPermissionService *PermissionService
+	permissionServiceOnce    sync.Once
+)
+
+// GetPermissionService 获取全局权限服务（共享本地缓存，保证失效及时生效）
+func GetPermissionService() *PermissionService {
+	permissionServiceOnce.Do(func() {
+		defaultPermissionService = NewPermissionService()
+	})
+	return defaultPermissionService
+}
+
+// APIPermissionCheck 接口权限校验结果
+type APIPermissionCheck struct {
+	Allowed  bool   // 是否放行
+	Declared bool   // 接口是否在权限表中登记
+	RoleID   int    // 用户角色ID
+	Reason   string // 拒绝原因
+}
+
+// CheckAPIPermission 校验用户是否拥有接口权限
+// route 为 gin 路由模板（如 /api/admin/rooms/:id），path 为实际请求路径
+func (s *PermissionService) CheckAPIPermission(ctx context.Context, userID int, method, route, path string) (*APIPermissionCheck, error) {
+	method = strings.ToUpper(method)
+	route = normalizeAPIPath(route)
+	path = normalizeAPIPath(path)
+
+	routes, err := s.getAPIRoutes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &APIPermissionCheck{}
+	for _, perm := range routes {
+		if matchAPIPermission(perm, method, route, path) {
+			result.Declared = true
+			break
+		}
+	}
+
+	userPerm, err := s.GetUserPermissions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	result.RoleID = userPerm.RoleID
+
+	// 超级管理员拥有全部权限
+	if userPerm.UserType == UserTypeAdmin {
+		result.Allowed = true
+		return result, nil
+	}
+
+	if !result.Declared {
+		result.Reason = "接口未登记权限"
+		return result, nil
+	}
+
+	for _, perm := range userPerm.Permissions {
+		if perm.Enable == 0 || perm.Method == "" {
+			continue
+		}
+		if matchAPIPermission(perm, method, route, path) {
+			result.Allowed = true
+			return result, nil
+		}
+	}
+
+	result.Reason = "角色未分配该接口权限"
+	return result, nil
+}
+
+// getAPIRoutes 获取所有登记的接口权限（method 非空的权限记录）
+func (s *PermissionService) getAPIRoutes(ctx context.Context) ([]admin_model.PermissionUser, error) {
+	// 读不到缓存版本号时跳过缓存，避免读到失效前的旧数据
+	version, versionErr := s.cacheVersion(ctx)
+	cacheKey := fmt.Sprintf("permission:api:routes:v%d", version)
+	if versionErr == nil {
+		var cached []admin_model.PermissionUser
+		if err := s.cache.Get(ctx, cacheKey, &cached); err == nil {
+			return cached, nil
+		}
+	}
+
+	var routes []admin_model.PermissionUser
+	if err := db.Dao.WithContext(ctx).
+		Select("id, path, method, enable").
+		Where("method IS NOT NULL AND method != ''").
+		Find(&routes).Error; err != nil {
+		return nil, fmt.Errorf("获取接口权限失败: %w", err)
+	}
+
+	if versionErr == nil {
+		s.cache.Set(ctx, cacheKey, routes, apiRouteCacheTTL)
+	}
+	return routes, nil
+}
+
+// InvalidateRolePermissionCache 角色权限变更后清除相关缓存
+func (s *PermissionService) InvalidateRolePermissionCache(ctx context.Context, roleID int) error {
+	if err := s.bumpCacheVersion(ctx); err != nil {
+		return err
+	}
+	log.Printf("角色 %d 权限已变更，权限缓存已失效", roleID)
+	return nil
+}
+
+// InvalidateAPIRouteCache 接口权限登记变更后清除相关缓存
+func (s *PermissionService) InvalidateAPIRouteCache(ctx context.Context) error {
+	return s.bumpCacheVersion(ctx)
+}
+
+// userPermissionCacheKey 用户权限缓存键（带版本号），读取版本号失败时返回错误，调用方应跳过缓存
+func (s *PermissionService) userPermissionCacheKey(ctx context.Context, userID int) (string, error) {
+	version, err := s.cacheVersion(ctx)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("user:permissions:%d:v%d", userID, version), nil
+}
+
+// cacheVersion 当前权限缓存版本号，版本号尚未生成时为0
+func (s *PermissionService) cacheVersion(ctx context.Context) (int64, error) {
+	client := redis.GetClient()
+	if client == nil {
+		return 0, fmt.Errorf("Redis未初始化")
+	}
+	version, err := client.Get(ctx, permissionCacheVersionKey).Int64()
+	if err == goredis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		log.Printf("[WARN] 读取权限缓存版本失败，跳过权限缓存: %v", err)
+		return 0, fmt.Errorf("读取权限缓存版本失败: %w", err)
+	}
+	return version, nil
+}
+
+// bumpCacheVersion 递增权限缓存版本号
+func (s *PermissionService) bumpCacheVersion(ctx context.Context) error {
+	client := redis.GetClient()
+	if client == nil {
+		return fmt.Errorf("Redis未初始化")
+	}
+	if err := client.Incr(ctx, permissionCacheVersionKey).Err(); err != nil {
+		return fmt.Errorf("更新权限缓存版本失败: %w", err)
+	}
+	return nil
+}
+
+// matchAPIPermission 判断接口权限是否匹配请求
+func matchAPIPermission(perm admin_model.PermissionUser, method, route, path string) bool {
+	permMethod := strings.ToUpper(strings.TrimSpace(perm.Method))
+	if permMethod != "*" && permMethod != method {
+		return false
+	}
+
+	permPath := normalizeAPIPath(perm.Path)
+	if permPath == "" {
+		return false
+	}
+
+	// 前缀通配：/rooms/* 匹配 /rooms 下所有接口
+	if strings.HasSuffix(permPath, "/*") {
+		prefix := strings.TrimSuffix(permPath, "/*")
+		return route == prefix || strings.HasPrefix(route, prefix+"/")
+	}
+
+	return permPath == route || permPath == path
+}
+
+// normalizeAPIPath 去掉管理端前缀和末尾斜杠
+func normalizeAPIPath(path string) string {
+	path = strings.TrimSpace(path)
+	path = strings.TrimPrefix(path, adminAPIPrefix)
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return path
+}
@@ -20,6 +20,17 @@ const (
 	UserTypeGeneral = 3 // 普通用户
 )
 
+// TenantIDOf 计算用户所属租户：管理员不属于任何租户，店铺用户为自身ID，员工为所属店铺ID
+func TenantIDOf(userType, userID, parentID int) int {
+	if userType == UserTypeAdmin {
+		return 0
+	}
+	if parentID > 0 {
+		return parentID
+	}
+	return userID
+}
+
 type EmployeeService struct{}
 
 // AddEmployee 添加员工
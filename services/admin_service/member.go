@@ -5,6 +5,7 @@ import (
 	"nasa-go-admin/db"
 	"nasa-go-admin/inout"
 	"nasa-go-admin/model/admin_model"
+	"nasa-go-admin/model/app_model"
 	"nasa-go-admin/utils"
 	"regexp"
 	"strings"
@@ -327,6 +328,8 @@ func (s *MemberService) buildSearchConditions(query *gorm.DB, params inout.GetMe
 }
 
 func (s *MemberService) formMemberData(data []admin_model.Member) []inout.MemberListItem {
+	accounts := s.getMemberAccounts(data)
+
 	formattedData := make([]inout.MemberListItem, len(data))
 	for i, item := range data {
 		formattedData[i] = inout.MemberListItem{
@@ -339,6 +342,36 @@ func (s *MemberService) formMemberData(data []admin_model.Member) []inout.Member
 			CreateTime: utils.FormatTime2(item.CreateTime),
 			UpdateTime: utils.FormatTime2(item.UpdateTime),
 		}
+		if account, ok := accounts[item.Id]; ok {
+			formattedData[i].Points = account.Points
+			formattedData[i].TotalSpend = account.TotalSpend
+			if account.Level != nil {
+				formattedData[i].LevelName = account.Level.Name
+			}
+		}
 	}
 	return formattedData
 }
+
+// getMemberAccounts 批量查询会员等级和积分，查询失败时只记录日志
+func (s *MemberService) getMemberAccounts(data []admin_model.Member) map[int]app_model.MemberAccount {
+	accounts := make(map[int]app_model.MemberAccount, len(data))
+	if len(data) == 0 {
+		return accounts
+	}
+
+	userIds := make([]int, 0, len(data))
+	for _, item := range data {
+		userIds = append(userIds, item.Id)
+	}
+
+	var list []app_model.MemberAccount
+	if err := db.Dao.Preload("Level").Where("user_id IN ?", userIds).Find(&list).Error; err != nil {
+		fmt.Printf("查询会员账户失败: %v\n", err)
+		return accounts
+	}
+	for _, account := range list {
+		accounts[account.UserID] = account
+	}
+	return accounts
+}
@@ -36,11 +36,13 @@ type UserPermission struct {
 
 // GetUserPermissions 获取用户权限（优化版本）
 func (s *PermissionService) GetUserPermissions(ctx context.Context, userID int) (*UserPermission, error) {
-	// 1. 尝试从缓存获取
-	cacheKey := fmt.Sprintf("user:permissions:%d", userID)
-	var cached UserPermission
-	if err := s.cache.Get(ctx, cacheKey, &cached); err == nil {
-		return &cached, nil
+	// 1. 尝试从缓存获取（读不到缓存版本号时跳过缓存）
+	cacheKey, keyErr := s.userPermissionCacheKey(ctx, userID)
+	if keyErr == nil {
+		var cached UserPermission
+		if err := s.cache.Get(ctx, cacheKey, &cached); err == nil {
+			return &cached, nil
+		}
 	}
 
 	// 2. 从数据库查询
@@ -50,7 +52,9 @@ func (s *PermissionService) GetUserPermissions(ctx context.Context, userID int)
 	}
 
 	// 3. 缓存结果（15分钟）
-	s.cache.Set(ctx, cacheKey, result, 15*time.Minute)
+	if keyErr == nil {
+		s.cache.Set(ctx, cacheKey, result, 15*time.Minute)
+	}
 
 	return result, nil
 }
@@ -275,7 +279,10 @@ func (s *PermissionService) buildTreeRecursive(nodes []interface{}, permMap map[
 
 // InvalidateUserPermissionCache 清除用户权限缓存
 func (s *PermissionService) InvalidateUserPermissionCache(ctx context.Context, userID int) error {
-	cacheKey := fmt.Sprintf("user:permissions:%d", userID)
+	cacheKey, err := s.userPermissionCacheKey(ctx, userID)
+	if err != nil {
+		return err
+	}
 	return s.cache.Delete(ctx, cacheKey)
 }
 
@@ -292,7 +299,11 @@ func (s *PermissionService) BatchGetUserPermissions(ctx context.Context, userIDs
 
 	// 1. 尝试从缓存批量获取
 	for _, userID := range userIDs {
-		cacheKey := fmt.Sprintf("user:permissions:%d", userID)
+		cacheKey, err := s.userPermissionCacheKey(ctx, userID)
+		if err != nil {
+			missedUserIDs = append(missedUserIDs, userID)
+			continue
+		}
 		var cached UserPermission
 		if err := s.cache.Get(ctx, cacheKey, &cached); err == nil {
 			result[userID] = &cached
@@ -328,7 +339,10 @@ func (s *PermissionService) BatchGetUserPermissions(ctx context.Context, userIDs
 
 			// 异步缓存
 			go func(userID int, permission *UserPermission) {
-				cacheKey := fmt.Sprintf("user:permissions:%d", userID)
+				cacheKey, err := s.userPermissionCacheKey(context.Background(), userID)
+				if err != nil {
+					return
+				}
 				s.cache.Set(context.Background(), cacheKey, permission, 15*time.Minute)
 			}(user.ID, perm)
 		}
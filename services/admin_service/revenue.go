@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"nasa-go-admin/db"
 	"nasa-go-admin/inout"
 	"nasa-go-admin/model/admin_model"
@@ -13,6 +14,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type RevenueService struct{}
@@ -57,8 +59,9 @@ func (s *RevenueService) GetRevenueList(c *gin.Context, params inout.GetRevenueL
 
 	// 构建基础查询，只选择需要的字段
 	query := db.Dao.Model(&admin_model.Revenue{}).
-		Select("id, tenants_id, stat_date, total_orders, total_revenue, actual_revenue, paid_orders").
-		Where("tenants_id = ?", parentId)
+		Select("id, tenants_id, stat_date, period_start, period_end, total_orders, total_revenue, actual_revenue, refund_amount, paid_orders, "+
+			"booking_count, paid_bookings, booking_revenue, booking_refund, booking_hours, package_discount").
+		Where("tenants_id = ? AND stat_period = ?", parentId, app_model.StatPeriodDay)
 
 	// 添加日期范围过滤
 	if params.Start != "" && params.End != "" {
@@ -169,94 +172,176 @@ func (s *RevenueService) GenerateRevenueStats(tenantsId int, days int) error {
 	return nil
 }
 
-// generateDayRevenueStats 生成某天的收益统计数据
+// generateDayRevenueStats 生成某天的收益统计数据，商品订单和房间预订写入同一条日统计
 func (s *RevenueService) generateDayRevenueStats(tenantsId int, statDate string) error {
-	// 检查是否已存在该天的统计数据
-	var existingCount int64
-	db.Dao.Model(&admin_model.Revenue{}).
-		Where("tenants_id = ? AND stat_date = ?", tenantsId, statDate).
-		Count(&existingCount)
-
-	if existingCount > 0 {
-		// 如果已存在，更新数据
-		return s.updateDayRevenueStats(tenantsId, statDate)
+	goodsStats, err := s.queryGoodsDayStats(tenantsId, statDate)
+	if err != nil {
+		return err
 	}
 
-	// 查询该天的订单统计数据
-	var stats struct {
-		TotalOrders   int     `json:"total_orders"`
-		PaidOrders    int     `json:"paid_orders"`
-		TotalRevenue  float64 `json:"total_revenue"`
-		ActualRevenue float64 `json:"actual_revenue"`
+	bookingStats, err := s.queryBookingDayStats(tenantsId, statDate)
+	if err != nil {
+		return err
 	}
 
-	err := db.Dao.Model(&app_model.AppOrder{}).
-		Select(`
-			COUNT(*) as total_orders,
-			SUM(CASE WHEN status = 'paid' THEN 1 ELSE 0 END) as paid_orders,
-			SUM(CASE WHEN status = 'paid' THEN amount ELSE 0 END) as total_revenue,
-			SUM(CASE WHEN status = 'paid' THEN amount ELSE 0 END) as actual_revenue
-		`).
-		Where("tenants_id = ? AND DATE(create_time) = ?", tenantsId, statDate).
-		Scan(&stats).Error
+	goodsDetails, err := s.queryGoodsDayDetails(tenantsId, statDate)
+	if err != nil {
+		return err
+	}
 
+	roomDetails, err := s.queryRoomDayDetails(tenantsId, statDate)
 	if err != nil {
-		return fmt.Errorf("查询订单统计失败: %w", err)
+		return err
 	}
 
-	// 创建统计记录
-	revenue := admin_model.Revenue{
-		TenantsId:     tenantsId,
-		StatDate:      statDate,
-		PeriodStart:   statDate,
-		PeriodEnd:     statDate,
-		TotalOrders:   stats.TotalOrders,
-		TotalRevenue:  stats.TotalRevenue,
-		ActualRevenue: stats.ActualRevenue,
-		PaidOrders:    stats.PaidOrders,
-		CreateTime:    time.Now(),
-		UpdateTime:    time.Now(),
+	now := time.Now()
+	updates := map[string]interface{}{
+		"total_orders":      goodsStats.TotalOrders,
+		"total_revenue":     goodsStats.TotalRevenue,
+		"actual_revenue":    roundMoney(goodsStats.TotalRevenue - goodsStats.RefundAmount),
+		"refund_amount":     goodsStats.RefundAmount,
+		"paid_orders":       goodsStats.PaidOrders,
+		"refunded_orders":   goodsStats.RefundedOrders,
+		"items_sold":        goodsStats.ItemsSold,
+		"booking_count":     bookingStats.BookingCount,
+		"paid_bookings":     bookingStats.PaidBookings,
+		"booking_revenue":   bookingStats.BookingRevenue,
+		"booking_refund":    bookingStats.BookingRefund,
+		"refunded_bookings": bookingStats.RefundedBookings,
+		"booking_hours":     bookingStats.BookingHours,
+		"package_discount":  bookingStats.PackageDiscount,
+		"update_time":       now,
 	}
 
-	return db.Dao.Create(&revenue).Error
+	return db.Dao.Transaction(func(tx *gorm.DB) error {
+		// 检查是否已存在该天的统计数据
+		var existingCount int64
+		if err := tx.Model(&admin_model.Revenue{}).
+			Where("tenants_id = ? AND stat_date = ? AND stat_period = ?", tenantsId, statDate, app_model.StatPeriodDay).
+			Count(&existingCount).Error; err != nil {
+			return fmt.Errorf("查询统计记录失败: %w", err)
+		}
+
+		if existingCount > 0 {
+			if err := tx.Model(&admin_model.Revenue{}).
+				Where("tenants_id = ? AND stat_date = ? AND stat_period = ?", tenantsId, statDate, app_model.StatPeriodDay).
+				Updates(updates).Error; err != nil {
+				return fmt.Errorf("更新统计记录失败: %w", err)
+			}
+		} else {
+			updates["tenants_id"] = tenantsId
+			updates["stat_date"] = statDate
+			updates["stat_period"] = app_model.StatPeriodDay
+			updates["period_start"] = statDate
+			updates["period_end"] = statDate
+			updates["create_time"] = now
+			if err := tx.Model(&admin_model.Revenue{}).Create(updates).Error; err != nil {
+				return fmt.Errorf("创建统计记录失败: %w", err)
+			}
+		}
+
+		// 明细按天整体重建
+		if err := tx.Where("tenants_id = ? AND stat_date = ?", tenantsId, statDate).
+			Delete(&app_model.MerchantRevenueDetails{}).Error; err != nil {
+			return fmt.Errorf("清理商品收入明细失败: %w", err)
+		}
+		if len(goodsDetails) > 0 {
+			if err := tx.Create(&goodsDetails).Error; err != nil {
+				return fmt.Errorf("写入商品收入明细失败: %w", err)
+			}
+		}
+
+		if err := tx.Where("tenants_id = ? AND stat_date = ?", tenantsId, statDate).
+			Delete(&app_model.MerchantRoomRevenueDetails{}).Error; err != nil {
+			return fmt.Errorf("清理房间收入明细失败: %w", err)
+		}
+		if len(roomDetails) > 0 {
+			if err := tx.Create(&roomDetails).Error; err != nil {
+				return fmt.Errorf("写入房间收入明细失败: %w", err)
+			}
+		}
+		return nil
+	})
 }
 
-// updateDayRevenueStats 更新某天的收益统计数据
-func (s *RevenueService) updateDayRevenueStats(tenantsId int, statDate string) error {
-	// 查询该天的订单统计数据
-	var stats struct {
-		TotalOrders   int     `json:"total_orders"`
-		PaidOrders    int     `json:"paid_orders"`
-		TotalRevenue  float64 `json:"total_revenue"`
-		ActualRevenue float64 `json:"actual_revenue"`
-	}
+// goodsDayStats 商品订单日统计
+type goodsDayStats struct {
+	TotalOrders    int
+	PaidOrders     int
+	RefundedOrders int
+	ItemsSold      int
+	TotalRevenue   float64
+	RefundAmount   float64
+}
+
+// bookingDayStats 房间预订日统计
+type bookingDayStats struct {
+	BookingCount     int
+	PaidBookings     int
+	RefundedBookings int
+	BookingHours     int
+	BookingRevenue   float64
+	BookingRefund    float64
+	PackageDiscount  float64
+}
 
+// paidGoodsStatuses 已支付过的商品订单状态，退款订单计入总收入并在退款中扣除
+var paidGoodsStatuses = []string{"paid", "shipped", "delivered", "completed", "refunded"}
+
+// queryGoodsDayStats 查询某天创建的商品订单统计
+func (s *RevenueService) queryGoodsDayStats(tenantsId int, statDate string) (*goodsDayStats, error) {
+	var stats goodsDayStats
 	err := db.Dao.Model(&app_model.AppOrder{}).
 		Select(`
 			COUNT(*) as total_orders,
-			SUM(CASE WHEN status = 'paid' THEN 1 ELSE 0 END) as paid_orders,
-			SUM(CASE WHEN status = 'paid' THEN amount ELSE 0 END) as total_revenue,
-			SUM(CASE WHEN status = 'paid' THEN amount ELSE 0 END) as actual_revenue
-		`).
+			COALESCE(SUM(CASE WHEN status IN ? THEN 1 ELSE 0 END), 0) as paid_orders,
+			COALESCE(SUM(CASE WHEN status = 'refunded' THEN 1 ELSE 0 END), 0) as refunded_orders,
+			COALESCE(SUM(CASE WHEN status IN ? THEN num ELSE 0 END), 0) as items_sold,
+			COALESCE(SUM(CASE WHEN status IN ? THEN amount ELSE 0 END), 0) as total_revenue,
+			COALESCE(SUM(CASE WHEN status = 'refunded' THEN amount ELSE 0 END), 0) as refund_amount
+		`, paidGoodsStatuses, paidGoodsStatuses, paidGoodsStatuses).
 		Where("tenants_id = ? AND DATE(create_time) = ?", tenantsId, statDate).
 		Scan(&stats).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询订单统计失败: %w", err)
+	}
+	return &stats, nil
+}
 
+// queryGoodsDayDetails 查询某天按商品汇总的收入明细
+func (s *RevenueService) queryGoodsDayDetails(tenantsId int, statDate string) ([]app_model.MerchantRevenueDetails, error) {
+	var details []app_model.MerchantRevenueDetails
+	err := db.Dao.Table("`order` o").
+		Select(`
+			o.goods_id,
+			COALESCE(MAX(g.goods_name), '') as goods_name,
+			COUNT(*) as order_count,
+			COALESCE(SUM(o.num), 0) as sold_count,
+			COALESCE(SUM(o.amount), 0) as revenue,
+			COALESCE(SUM(CASE WHEN o.status = 'refunded' THEN 1 ELSE 0 END), 0) as refund_count,
+			COALESCE(SUM(CASE WHEN o.status = 'refunded' THEN o.amount ELSE 0 END), 0) as refund_amount
+		`).
+		Joins("LEFT JOIN goods_list g ON g.id = o.goods_id").
+		Where("o.tenants_id = ? AND DATE(o.create_time) = ? AND o.status IN ?", tenantsId, statDate, paidGoodsStatuses).
+		Group("o.goods_id").
+		Scan(&details).Error
 	if err != nil {
-		return fmt.Errorf("查询订单统计失败: %w", err)
+		return nil, fmt.Errorf("查询商品收入明细失败: %w", err)
 	}
 
-	// 更新统计记录
-	updates := map[string]interface{}{
-		"total_orders":   stats.TotalOrders,
-		"total_revenue":  stats.TotalRevenue,
-		"actual_revenue": stats.ActualRevenue,
-		"paid_orders":    stats.PaidOrders,
-		"update_time":    time.Now(),
+	now := time.Now()
+	for i := range details {
+		details[i].TenantsId = tenantsId
+		details[i].StatDate = statDate
+		details[i].CreateTime = now
+		details[i].UpdateTime = now
 	}
+	return details, nil
+}
 
-	return db.Dao.Model(&admin_model.Revenue{}).
-		Where("tenants_id = ? AND stat_date = ?", tenantsId, statDate).
-		Updates(updates).Error
+// roundMoney 金额保留两位小数
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
 }
 
 // RefreshRevenueStats 刷新收益统计数据（手动调用）
@@ -269,15 +354,23 @@ func formatRevenueData(data []admin_model.Revenue) []inout.RevenueRepItems {
 	formattedData := make([]inout.RevenueRepItems, 0) // 初始化为空数组而不是nil
 	for _, item := range data {
 		formattedData = append(formattedData, inout.RevenueRepItems{
-			Id:            item.Id,
-			TenantsId:     item.TenantsId,
-			StatDate:      item.StatDate,
-			PeriodStart:   item.PeriodStart,
-			PeriodEnd:     item.PeriodEnd,
-			TotalOrders:   item.TotalOrders,
-			TotalRevenue:  item.TotalRevenue,
-			ActualRevenue: item.ActualRevenue,
-			PaidOrders:    item.PaidOrders,
+			Id:              item.Id,
+			TenantsId:       item.TenantsId,
+			StatDate:        item.StatDate,
+			PeriodStart:     item.PeriodStart,
+			PeriodEnd:       item.PeriodEnd,
+			TotalOrders:     item.TotalOrders,
+			TotalRevenue:    item.TotalRevenue,
+			ActualRevenue:   item.ActualRevenue,
+			PaidOrders:      item.PaidOrders,
+			RefundAmount:    item.RefundAmount,
+			BookingCount:    item.BookingCount,
+			PaidBookings:    item.PaidBookings,
+			BookingRevenue:  item.BookingRevenue,
+			BookingRefund:   item.BookingRefund,
+			BookingHours:    item.BookingHours,
+			PackageDiscount: item.PackageDiscount,
+			CombinedRevenue: roundMoney(item.ActualRevenue + item.BookingRevenue - item.BookingRefund),
 		})
 	}
 	return formattedData
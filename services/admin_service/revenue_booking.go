@@ -0,0 +1,95 @@
+package admin_service
+
+import (
+	"fmt"
+	"nasa-go-admin/db"
+	"nasa-go-admin/model/app_model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 房间预订收入口径：
+//   - 已支付：paid_amount > 0 且不是待支付，取消或退款的预订同样计入收入，退款在 booking_refund 中扣除
+//   - 退款金额：钱包流水中 booking_refund 类型按预订单号汇总，部分退款的预订计入退款金额但仍按实际占用统计
+//   - 占用时长：只统计已支付、使用中、已完成的预订
+//   - 套餐优惠：套餐价相对原价的优惠，不含优惠券和积分抵扣
+
+// bookingRefundJoin 按预订单号汇总的退款金额
+const bookingRefundJoin = "LEFT JOIN (SELECT order_no, SUM(amount) AS refund_amount FROM app_recharge " +
+	"WHERE transaction_type = ? GROUP BY order_no) r ON r.order_no = b.booking_no"
+
+// bookingPaidCond 已支付预订条件
+const bookingPaidCond = "b.status <> ? AND b.paid_amount > 0"
+
+// bookingRevenueScope 某商家某天创建的预订
+func bookingRevenueScope(tenantsId int, statDate string) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Table("room_bookings b").
+			Joins(bookingRefundJoin, app_model.TransactionTypeBookingRefund).
+			Where("b.tenants_id = ? AND DATE(b.create_time) = ?", tenantsId, statDate)
+	}
+}
+
+// occupiedBookingStatuses 实际占用房间的预订状态
+var occupiedBookingStatuses = []int{
+	app_model.BookingStatusPaid,
+	app_model.BookingStatusInUse,
+	app_model.BookingStatusCompleted,
+}
+
+// queryBookingDayStats 查询某天创建的房间预订统计
+func (s *RevenueService) queryBookingDayStats(tenantsId int, statDate string) (*bookingDayStats, error) {
+	var stats bookingDayStats
+	err := db.Dao.Scopes(bookingRevenueScope(tenantsId, statDate)).
+		Select(`
+			COUNT(*) as booking_count,
+			COALESCE(SUM(CASE WHEN `+bookingPaidCond+` THEN 1 ELSE 0 END), 0) as paid_bookings,
+			COALESCE(SUM(CASE WHEN r.refund_amount > 0 THEN 1 ELSE 0 END), 0) as refunded_bookings,
+			COALESCE(SUM(CASE WHEN b.status IN ? THEN b.hours ELSE 0 END), 0) as booking_hours,
+			COALESCE(SUM(CASE WHEN `+bookingPaidCond+` THEN b.paid_amount ELSE 0 END), 0) as booking_revenue,
+			COALESCE(SUM(r.refund_amount), 0) as booking_refund,
+			COALESCE(SUM(CASE WHEN `+bookingPaidCond+` AND b.package_id IS NOT NULL
+				THEN b.discount_amount - b.coupon_discount - b.points_discount ELSE 0 END), 0) as package_discount
+		`, app_model.BookingStatusPending, occupiedBookingStatuses, app_model.BookingStatusPending, app_model.BookingStatusPending).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询预订统计失败: %w", err)
+	}
+	return &stats, nil
+}
+
+// queryRoomDayDetails 查询某天按房间和套餐汇总的预订收入明细
+func (s *RevenueService) queryRoomDayDetails(tenantsId int, statDate string) ([]app_model.MerchantRoomRevenueDetails, error) {
+	var details []app_model.MerchantRoomRevenueDetails
+	err := db.Dao.Scopes(bookingRevenueScope(tenantsId, statDate)).
+		Select(`
+			b.room_id,
+			COALESCE(MAX(rm.room_name), '') as room_name,
+			COALESCE(b.package_id, 0) as package_id,
+			COALESCE(MAX(b.package_name), '') as package_name,
+			COUNT(*) as booking_count,
+			COALESCE(SUM(CASE WHEN b.status IN ? THEN b.hours ELSE 0 END), 0) as booking_hours,
+			COALESCE(SUM(b.paid_amount), 0) as revenue,
+			COALESCE(SUM(CASE WHEN b.package_id IS NOT NULL
+				THEN b.discount_amount - b.coupon_discount - b.points_discount ELSE 0 END), 0) as package_discount,
+			COALESCE(SUM(CASE WHEN r.refund_amount > 0 THEN 1 ELSE 0 END), 0) as refund_count,
+			COALESCE(SUM(r.refund_amount), 0) as refund_amount
+		`, occupiedBookingStatuses).
+		Joins("LEFT JOIN rooms rm ON rm.id = b.room_id").
+		Where(bookingPaidCond, app_model.BookingStatusPending).
+		Group("b.room_id, COALESCE(b.package_id, 0)").
+		Scan(&details).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询房间收入明细失败: %w", err)
+	}
+
+	now := time.Now()
+	for i := range details {
+		details[i].TenantsId = tenantsId
+		details[i].StatDate = statDate
+		details[i].CreateTime = now
+		details[i].UpdateTime = now
+	}
+	return details, nil
+}
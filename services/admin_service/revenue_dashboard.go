@@ -0,0 +1,186 @@
+package admin_service
+
+import (
+	"fmt"
+	"nasa-go-admin/db"
+	"nasa-go-admin/inout"
+	"nasa-go-admin/model/admin_model"
+	"nasa-go-admin/model/app_model"
+	"nasa-go-admin/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRevenueDashboard 收入看板，按日/周/月/年汇总日统计和明细，商品和房间预订两条收入线合并返回
+// 周期包含今天时先刷新今天的统计，历史数据以 /order/revenue/refresh 生成的结果为准
+func (s *RevenueService) GetRevenueDashboard(c *gin.Context, params inout.RevenueDashboardReq) (*inout.RevenueDashboardResp, error) {
+	parentId, err := utils.GetParentId(c)
+	if err != nil {
+		return nil, fmt.Errorf("获取租户ID失败: %w", err)
+	}
+
+	if params.Period == "" {
+		params.Period = app_model.StatPeriodDay
+	}
+	anchor := time.Now()
+	if params.Date != "" {
+		anchor, err = time.ParseInLocation("2006-01-02", params.Date, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("日期格式错误: %v", err)
+		}
+	}
+	periodStart, periodEnd := statPeriodRange(params.Period, anchor)
+	start, end := periodStart.Format("2006-01-02"), periodEnd.Format("2006-01-02")
+
+	today := time.Now().Format("2006-01-02")
+	if start <= today && today <= end {
+		if err := s.generateDayRevenueStats(parentId, today); err != nil {
+			fmt.Printf("刷新今日收益统计失败: %v\n", err)
+		}
+	}
+
+	var days []admin_model.Revenue
+	if err := db.Dao.Where("tenants_id = ? AND stat_period = ? AND stat_date BETWEEN ? AND ?",
+		parentId, app_model.StatPeriodDay, start, end).
+		Order("stat_date ASC").
+		Find(&days).Error; err != nil {
+		return nil, fmt.Errorf("查询收益统计失败: %w", err)
+	}
+
+	resp := &inout.RevenueDashboardResp{
+		Period:      params.Period,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Trend:       make([]inout.RevenueTrendItem, 0, len(days)),
+	}
+
+	for _, day := range days {
+		resp.Goods.TotalOrders += day.TotalOrders
+		resp.Goods.PaidOrders += day.PaidOrders
+		resp.Goods.RefundedOrders += day.RefundedOrders
+		resp.Goods.ItemsSold += day.ItemsSold
+		resp.Goods.TotalRevenue += day.TotalRevenue
+		resp.Goods.RefundAmount += day.RefundAmount
+
+		resp.Booking.TotalBookings += day.BookingCount
+		resp.Booking.PaidBookings += day.PaidBookings
+		resp.Booking.RefundedBookings += day.RefundedBookings
+		resp.Booking.BookingHours += day.BookingHours
+		resp.Booking.TotalRevenue += day.BookingRevenue
+		resp.Booking.RefundAmount += day.BookingRefund
+		resp.Booking.PackageDiscount += day.PackageDiscount
+
+		resp.Trend = append(resp.Trend, inout.RevenueTrendItem{
+			StatDate:       formatStatDate(day.StatDate),
+			GoodsRevenue:   day.TotalRevenue,
+			BookingRevenue: day.BookingRevenue,
+			ActualRevenue:  roundMoney(day.TotalRevenue - day.RefundAmount + day.BookingRevenue - day.BookingRefund),
+		})
+	}
+
+	resp.Goods.TotalRevenue = roundMoney(resp.Goods.TotalRevenue)
+	resp.Goods.RefundAmount = roundMoney(resp.Goods.RefundAmount)
+	resp.Goods.ActualRevenue = roundMoney(resp.Goods.TotalRevenue - resp.Goods.RefundAmount)
+	resp.Booking.TotalRevenue = roundMoney(resp.Booking.TotalRevenue)
+	resp.Booking.RefundAmount = roundMoney(resp.Booking.RefundAmount)
+	resp.Booking.PackageDiscount = roundMoney(resp.Booking.PackageDiscount)
+	resp.Booking.ActualRevenue = roundMoney(resp.Booking.TotalRevenue - resp.Booking.RefundAmount)
+	resp.Summary = inout.RevenueSummary{
+		TotalRevenue:  roundMoney(resp.Goods.TotalRevenue + resp.Booking.TotalRevenue),
+		RefundAmount:  roundMoney(resp.Goods.RefundAmount + resp.Booking.RefundAmount),
+		ActualRevenue: roundMoney(resp.Goods.ActualRevenue + resp.Booking.ActualRevenue),
+	}
+
+	if resp.GoodsItems, err = s.sumGoodsDetails(parentId, start, end); err != nil {
+		return nil, err
+	}
+	if resp.Packages, err = s.sumPackageDetails(parentId, start, end); err != nil {
+		return nil, err
+	}
+	resp.RoomItems = sumRoomItems(resp.Packages)
+
+	return resp, nil
+}
+
+// sumGoodsDetails 汇总周期内的商品收入明细，按收入降序
+func (s *RevenueService) sumGoodsDetails(tenantsId int, start, end string) ([]inout.GoodsRevenueItem, error) {
+	items := make([]inout.GoodsRevenueItem, 0)
+	err := db.Dao.Model(&app_model.MerchantRevenueDetails{}).
+		Select(`goods_id, MAX(goods_name) as goods_name,
+			SUM(order_count) as order_count, SUM(sold_count) as sold_count, SUM(revenue) as revenue,
+			SUM(refund_count) as refund_count, SUM(refund_amount) as refund_amount`).
+		Where("tenants_id = ? AND stat_date BETWEEN ? AND ?", tenantsId, start, end).
+		Group("goods_id").
+		Order("revenue DESC").
+		Scan(&items).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询商品收入明细失败: %w", err)
+	}
+	return items, nil
+}
+
+// sumPackageDetails 汇总周期内按房间和套餐的预订收入明细，按房间、收入排序
+func (s *RevenueService) sumPackageDetails(tenantsId int, start, end string) ([]inout.PackageRevenueItem, error) {
+	items := make([]inout.PackageRevenueItem, 0)
+	err := db.Dao.Model(&app_model.MerchantRoomRevenueDetails{}).
+		Select(`room_id, MAX(room_name) as room_name, package_id, MAX(package_name) as package_name,
+			SUM(booking_count) as booking_count, SUM(booking_hours) as booking_hours, SUM(revenue) as revenue,
+			SUM(package_discount) as package_discount, SUM(refund_count) as refund_count, SUM(refund_amount) as refund_amount`).
+		Where("tenants_id = ? AND stat_date BETWEEN ? AND ?", tenantsId, start, end).
+		Group("room_id, package_id").
+		Order("room_id ASC, revenue DESC").
+		Scan(&items).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询房间收入明细失败: %w", err)
+	}
+	return items, nil
+}
+
+// sumRoomItems 套餐明细按房间合并
+func sumRoomItems(packages []inout.PackageRevenueItem) []inout.RoomRevenueItem {
+	rooms := make([]inout.RoomRevenueItem, 0)
+	index := make(map[int]int)
+	for _, pkg := range packages {
+		i, ok := index[pkg.RoomId]
+		if !ok {
+			i = len(rooms)
+			index[pkg.RoomId] = i
+			rooms = append(rooms, inout.RoomRevenueItem{RoomId: pkg.RoomId, RoomName: pkg.RoomName})
+		}
+		rooms[i].BookingCount += pkg.BookingCount
+		rooms[i].BookingHours += pkg.BookingHours
+		rooms[i].Revenue = roundMoney(rooms[i].Revenue + pkg.Revenue)
+		rooms[i].PackageDiscount = roundMoney(rooms[i].PackageDiscount + pkg.PackageDiscount)
+		rooms[i].RefundCount += pkg.RefundCount
+		rooms[i].RefundAmount = roundMoney(rooms[i].RefundAmount + pkg.RefundAmount)
+	}
+	return rooms
+}
+
+// statPeriodRange 计算日期所在统计周期的起止日期，周从周一开始
+func statPeriodRange(period string, anchor time.Time) (time.Time, time.Time) {
+	day := time.Date(anchor.Year(), anchor.Month(), anchor.Day(), 0, 0, 0, 0, anchor.Location())
+	switch period {
+	case app_model.StatPeriodWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		start := day.AddDate(0, 0, -offset)
+		return start, start.AddDate(0, 0, 6)
+	case app_model.StatPeriodMonth:
+		start := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+		return start, start.AddDate(0, 1, -1)
+	case app_model.StatPeriodYear:
+		start := time.Date(day.Year(), 1, 1, 0, 0, 0, 0, day.Location())
+		return start, start.AddDate(1, 0, -1)
+	default:
+		return day, day
+	}
+}
+
+// formatStatDate 统计日期可能以 DATETIME 格式返回，统一为 2006-01-02
+func formatStatDate(statDate string) string {
+	if len(statDate) > 10 {
+		return statDate[:10]
+	}
+	return statDate
+}
@@ -2,6 +2,7 @@ package admin_service
 
 import (
 	"fmt"
+	"log"
 	"nasa-go-admin/db"
 	"nasa-go-admin/inout"
 	"nasa-go-admin/model/admin_model"
@@ -312,8 +313,16 @@ func (s *RoleService) SetRolePermission(c *gin.Context, params inout.SetRolePerm
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
 
-	return err
+	// 清除角色下所有用户的权限缓存，接口权限校验立即按新权限生效
+	if err := GetPermissionService().InvalidateRolePermissionCache(c, params.Id); err != nil {
+		log.Printf("Failed to invalidate role permission cache: %v", err)
+	}
+
+	return nil
 }
 
 // DeleteRole 删除角色
@@ -348,8 +357,15 @@ func (s *RoleService) DeleteRole(c *gin.Context, id int) error {
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
 
-	return err
+	if err := GetPermissionService().InvalidateRolePermissionCache(c, id); err != nil {
+		log.Printf("Failed to invalidate role permission cache: %v", err)
+	}
+
+	return nil
 }
 
 // GetAllRoleList
@@ -231,7 +231,7 @@ func (s *TenantsService) Login(c *gin.Context, username, password string) (map[s
 	// 5. 生成Token并缓存用户信息
 	// 使用安全的 JWT 管理器生成 Token（支持黑名单）
 	jwtManager := jwt.NewSecureJWTManager()
-	token, err := jwtManager.GenerateToken(user.ID, user.RoleId, user.UserType)
+	token, err := jwtManager.GenerateTenantToken(user.ID, user.RoleId, user.UserType, TenantIDOf(user.UserType, user.ID, user.ParentId))
 	if err != nil {
 		return nil, fmt.Errorf("生成令牌失败: %w", err)
 	}
@@ -335,7 +335,7 @@ func (s *TenantsService) GetUserInfo(c *gin.Context, id int) (map[string]interfa
 	// Generate token with error handling
 	// 使用安全的 JWT 管理器生成 Token（支持黑名单）
 	jwtManager := jwt.NewSecureJWTManager()
-	token, err := jwtManager.GenerateToken(user.ID, user.RoleId, user.UserType)
+	token, err := jwtManager.GenerateTenantToken(user.ID, user.RoleId, user.UserType, TenantIDOf(user.UserType, user.ID, user.ParentId))
 	if err != nil {
 		log.Printf("Failed to generate token for user %d: %v", id, err)
 		return nil, fmt.Errorf("生成令牌失败: %v", err)
@@ -364,7 +364,7 @@ func (s *TenantsService) GetUserInfo(c *gin.Context, id int) (map[string]interfa
 // 读取路由菜单
 func (s *TenantsService) GetRoutes(c *gin.Context, id int) ([]admin_model.PermissionUser, error) {
 	// 使用新的优化权限服务
-	permissionService := NewPermissionService()
+	permissionService := GetPermissionService()
 
 	// 获取用户权限菜单树
 	userPermissions, err := permissionService.GetUserPermissions(c, id)
@@ -385,7 +385,7 @@ func (s *TenantsService) GetRoutes(c *gin.Context, id int) ([]admin_model.Permis
 // 读取路由菜单
 func (s *TenantsService) GetMenus(c *gin.Context, id int) ([]admin_model.PermissionUser, error) {
 	// 使用新的优化权限服务
-	permissionService := NewPermissionService()
+	permissionService := GetPermissionService()
 
 	// 获取用户权限菜单树
 	userPermissions, err := permissionService.GetUserPermissions(c, id)
@@ -471,10 +471,9 @@ func (s *TenantsService) AddMenu(c *gin.Context, params admin_model.PermissionMe
 		return 0, err
 	}
 
-	// 清除权限缓存
-	permissionService := NewPermissionService()
-	if err := permissionService.InvalidateUserPermissionCache(c, userID); err != nil {
-		log.Printf("Failed to invalidate user permission cache: %v", err)
+	// 清除权限缓存（菜单和接口权限变更对所有用户生效）
+	if err := GetPermissionService().InvalidateAPIRouteCache(c); err != nil {
+		log.Printf("Failed to invalidate permission cache: %v", err)
 	}
 
 	// 返回新菜单的ID
@@ -521,13 +520,9 @@ func (s *TenantsService) UpdateMenu(c *gin.Context, id int, params admin_model.P
 		return 0, err
 	}
 
-	// 清除权限缓存
-	userId, _ := c.Get("uid")
-	if userID, ok := userId.(int); ok {
-		permissionService := NewPermissionService()
-		if err := permissionService.InvalidateUserPermissionCache(c, userID); err != nil {
-			log.Printf("Failed to invalidate user permission cache: %v", err)
-		}
+	// 清除权限缓存（菜单和接口权限变更对所有用户生效）
+	if err := GetPermissionService().InvalidateAPIRouteCache(c); err != nil {
+		log.Printf("Failed to invalidate permission cache: %v", err)
 	}
 
 	// 更新 路由id
@@ -570,13 +565,9 @@ func (s *TenantsService) DeleteMenu(c *gin.Context, ids []int) error {
 		return err
 	}
 
-	// 清除权限缓存
-	userId, _ := c.Get("uid")
-	if userID, ok := userId.(int); ok {
-		permissionService := NewPermissionService()
-		if err := permissionService.InvalidateUserPermissionCache(c, userID); err != nil {
-			log.Printf("Failed to invalidate user permission cache: %v", err)
-		}
+	// 清除权限缓存（菜单和接口权限变更对所有用户生效）
+	if err := GetPermissionService().InvalidateAPIRouteCache(c); err != nil {
+		log.Printf("Failed to invalidate permission cache: %v", err)
 	}
 
 	return nil
@@ -702,8 +693,8 @@ func filterEmptyFields(permissList []admin_model.PermissionUser) []admin_model.P
 	for _, perm := range permissList {
 		perm.Children = filterEmptyFields(perm.Children)
 		filteredPerm := filterEmptyFieldsFromStruct(perm)
-		// 只过滤类型为BUTTON的菜单，保留其他所有菜单（包括主目录菜单）
-		if filteredPerm.Type != "BUTTON" {
+		// 只过滤类型为BUTTON的菜单和接口权限，保留其他所有菜单（包括主目录菜单）
+		if filteredPerm.Type != "BUTTON" && perm.Method == "" {
 			// 如果是主目录菜单（没有子菜单），确保它被保留
 			if len(perm.Children) == 0 && filteredPerm.ParentId == 0 {
 				filteredList = append(filteredList, filteredPerm)
@@ -143,6 +143,16 @@ func (s *FixedOrderService) performOrderCancellation(tx *gorm.DB, order *app_mod
 		return fmt.Errorf("商品 %d 不存在，无法恢复库存", order.GoodsId)
 	}
 
+	// 退回下单时使用的优惠券
+	if err := NewCouponService().ReturnCoupon(tx, app_model.CouponOrderGoods, order.No); err != nil {
+		return err
+	}
+
+	// 退回下单时抵扣的积分
+	if err := NewMembershipService().RollbackPoints(tx, app_model.PointsBizGoods, order.No); err != nil {
+		return err
+	}
+
 	// 3. 获取商品信息用于统计
 	var goods app_model.AppGoods
 	if err := tx.Where("id = ?", order.GoodsId).First(&goods).Error; err != nil {
@@ -0,0 +1,646 @@
+package app_service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"nasa-go-admin/db"
+	"nasa-go-admin/inout"
+	"nasa-go-admin/model/app_model"
+	"nasa-go-admin/redis"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// checkInEarlyWindow 允许提前入住的时间
+const checkInEarlyWindow = 30 * time.Minute
+
+// errInsufficientBalance 钱包余额不足以支付额外费用，退房时转为挂账
+var errInsufficientBalance = errors.New("钱包余额不足")
+
+// BookingCheckinService 前台入住退房服务 - 办理入住、退房结算超时费用和预订续时
+type BookingCheckinService struct {
+	logService *BookingLogService
+	ctx        context.Context
+}
+
+// NewBookingCheckinService 创建入住退房服务
+func NewBookingCheckinService() *BookingCheckinService {
+	return &BookingCheckinService{
+		logService: &BookingLogService{},
+	}
+}
+
+// WithContext 返回绑定请求上下文的入住退房服务，管理端传入 gin.Context 后按租户隔离数据
+func (bcs *BookingCheckinService) WithContext(ctx context.Context) *BookingCheckinService {
+	return &BookingCheckinService{
+		logService: bcs.logService,
+		ctx:        ctx,
+	}
+}
+
+// dao 获取数据库连接，带上下文时由 GORM 租户插件追加 tenants_id 条件
+func (bcs *BookingCheckinService) dao() *gorm.DB {
+	if bcs.ctx != nil {
+		return db.Dao.WithContext(bcs.ctx)
+	}
+	return db.Dao
+}
+
+// overtimeCharge 超时计费结果
+type overtimeCharge struct {
+	OvertimeMin int // 实际超时分钟数
+	BilledMin   int // 按计费单位向上取整后的计费分钟数
+	Fee         float64
+	Items       []app_model.PriceLineItem
+}
+
+// ========== 入住 ==========
+
+// CheckIn 办理入住：已支付的预订开始使用并创建使用记录；
+// 调度器到点自动开始的预订补记实际入住时间和经办人。前台办理入住的预订需由前台退房结算，调度器不再自动完成
+func (bcs *BookingCheckinService) CheckIn(req *inout.CheckInReq, operatorID int) (*inout.BookingUsageResp, error) {
+	now := time.Now()
+
+	var booking *app_model.RoomBooking
+	var usage app_model.RoomUsageLog
+	oldStatus := 0
+	err := bcs.dao().Transaction(func(tx *gorm.DB) error {
+		var err error
+		booking, err = bcs.lockBooking(tx, req.BookingID, req.BookingNo, nil)
+		if err != nil {
+			return err
+		}
+		oldStatus = booking.Status
+
+		switch booking.Status {
+		case app_model.BookingStatusPaid:
+			if now.Before(booking.StartTime.Add(-checkInEarlyWindow)) {
+				return fmt.Errorf("未到入住时间，最早可于 %s 办理入住",
+					booking.StartTime.Add(-checkInEarlyWindow).Format("2006-01-02 15:04"))
+			}
+			if !now.Before(booking.EndTime) {
+				return fmt.Errorf("预订时间已过，无法入住")
+			}
+
+			// 提前入住时房间可能仍被上一位客人使用
+			var occupied int64
+			if err := tx.Model(&app_model.RoomBooking{}).
+				Where("room_id = ? AND status = ? AND id != ?", booking.RoomID, app_model.BookingStatusInUse, booking.ID).
+				Count(&occupied).Error; err != nil {
+				return fmt.Errorf("检查房间使用状态失败: %v", err)
+			}
+			if occupied > 0 {
+				return fmt.Errorf("房间仍在使用中，请等待上一位客人退房")
+			}
+
+			result := tx.Model(&app_model.RoomBooking{}).
+				Where("id = ? AND status = ?", booking.ID, app_model.BookingStatusPaid).
+				Update("status", app_model.BookingStatusInUse)
+			if result.Error != nil {
+				return fmt.Errorf("更新预订状态失败: %v", result.Error)
+			}
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("预订状态已变更，请刷新后重试")
+			}
+			booking.Status = app_model.BookingStatusInUse
+
+			if err := tx.Model(&app_model.Room{}).Where("id = ?", booking.RoomID).
+				Update("status", app_model.RoomStatusOccupied).Error; err != nil {
+				return fmt.Errorf("更新房间状态失败: %v", err)
+			}
+
+			usage = app_model.RoomUsageLog{
+				RoomID:    booking.RoomID,
+				BookingID: booking.ID,
+				UserID:    booking.UserID,
+				CheckInAt: now,
+				CheckInBy: operatorID,
+				FeeStatus: app_model.UsageFeeStatusNone,
+			}
+			if err := tx.Create(&usage).Error; err != nil {
+				return fmt.Errorf("创建使用记录失败: %v", err)
+			}
+
+		case app_model.BookingStatusInUse:
+			// 调度器已自动开始，补记实际入住时间
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("booking_id = ?", booking.ID).First(&usage).Error
+			if err != nil && err != gorm.ErrRecordNotFound {
+				return fmt.Errorf("查询使用记录失败: %v", err)
+			}
+			if err == gorm.ErrRecordNotFound {
+				usage = app_model.RoomUsageLog{
+					RoomID:    booking.RoomID,
+					BookingID: booking.ID,
+					UserID:    booking.UserID,
+					FeeStatus: app_model.UsageFeeStatusNone,
+				}
+			} else if usage.CheckInBy > 0 {
+				return fmt.Errorf("该预订已办理入住")
+			}
+
+			usage.CheckInAt = now
+			usage.CheckInBy = operatorID
+			if err := tx.Save(&usage).Error; err != nil {
+				return fmt.Errorf("更新使用记录失败: %v", err)
+			}
+
+		default:
+			return fmt.Errorf("当前预订状态为%s，无法入住", booking.GetBookingStatusText())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("办理入住: %s (房间ID: %d, 管理员ID: %d)", booking.BookingNo, booking.RoomID, operatorID)
+
+	room := bcs.loadRoomName(booking.RoomID)
+	if oldStatus == app_model.BookingStatusPaid {
+		bcs.logService.LogManualStart(booking, room.RoomName, operatorID)
+	}
+
+	return bcs.buildUsageResp(booking, &room, &usage, nil, nil), nil
+}
+
+// ========== 退房结算 ==========
+
+// CheckOut 办理退房：计算实际使用时长，超出预订结束时间的部分按房间计费单位向上取整、按当前规则价计费。
+// 超时费和其他额外费用默认从钱包扣款，余额不足或指定挂账时记为待收款
+func (bcs *BookingCheckinService) CheckOut(req *inout.CheckOutReq, operatorID int) (*inout.BookingUsageResp, error) {
+	now := time.Now()
+
+	var booking *app_model.RoomBooking
+	var room app_model.Room
+	var usage app_model.RoomUsageLog
+	var charge *overtimeCharge
+	var balanceAfter *float64
+	err := bcs.dao().Transaction(func(tx *gorm.DB) error {
+		var err error
+		booking, err = bcs.lockBooking(tx, req.BookingID, req.BookingNo, nil)
+		if err != nil {
+			return err
+		}
+		if booking.Status != app_model.BookingStatusInUse {
+			return fmt.Errorf("当前预订状态为%s，无法退房", booking.GetBookingStatusText())
+		}
+
+		if err := tx.First(&room, booking.RoomID).Error; err != nil {
+			return fmt.Errorf("查询房间失败: %v", err)
+		}
+
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("booking_id = ?", booking.ID).First(&usage).Error
+		if err == gorm.ErrRecordNotFound {
+			// 缺失使用记录时按预订开始时间补记
+			usage = app_model.RoomUsageLog{
+				RoomID:    booking.RoomID,
+				BookingID: booking.ID,
+				UserID:    booking.UserID,
+				CheckInAt: booking.StartTime,
+				FeeStatus: app_model.UsageFeeStatusNone,
+			}
+			err = tx.Create(&usage).Error
+		}
+		if err != nil {
+			return fmt.Errorf("查询使用记录失败: %v", err)
+		}
+
+		charge, err = bcs.quoteOvertime(tx, booking, &room, now)
+		if err != nil {
+			return err
+		}
+
+		usage.CheckOutAt = &now
+		usage.CheckOutBy = operatorID
+		usage.ActualHours = math.Round(now.Sub(usage.CheckInAt).Hours()*100) / 100
+		usage.OvertimeMin = charge.OvertimeMin
+		usage.OvertimeFee = charge.Fee
+		usage.ExtraFee = math.Round((charge.Fee+req.ExtraFee)*100) / 100
+		usage.FeeStatus = app_model.UsageFeeStatusNone
+		if req.Remarks != "" {
+			usage.Remarks = req.Remarks
+		}
+
+		bookingUpdates := map[string]interface{}{
+			"status": app_model.BookingStatusCompleted,
+		}
+		if usage.ExtraFee > 0 {
+			bookingUpdates["total_amount"] = gorm.Expr("total_amount + ?", usage.ExtraFee)
+			usage.FeeStatus = app_model.UsageFeeStatusReceivable
+
+			if req.ChargeMode != "receivable" {
+				payment, wallet, err := bcs.chargeExtraFee(tx, booking, usage.ExtraFee, true)
+				switch {
+				case err == nil:
+					usage.FeeStatus = app_model.UsageFeeStatusPaid
+					usage.FeePaymentID = &payment.ID
+					bookingUpdates["paid_amount"] = gorm.Expr("paid_amount + ?", usage.ExtraFee)
+					booking.PaidAmount += usage.ExtraFee
+					balanceAfter = &wallet.Money
+				case errors.Is(err, errInsufficientBalance):
+					log.Printf("钱包余额不足，额外费用转为挂账: %s (金额: %.2f)", booking.BookingNo, usage.ExtraFee)
+				default:
+					return err
+				}
+			}
+		}
+
+		if err := tx.Save(&usage).Error; err != nil {
+			return fmt.Errorf("更新使用记录失败: %v", err)
+		}
+
+		result := tx.Model(&app_model.RoomBooking{}).
+			Where("id = ? AND status = ?", booking.ID, app_model.BookingStatusInUse).
+			Updates(bookingUpdates)
+		if result.Error != nil {
+			return fmt.Errorf("更新预订状态失败: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("预订状态已变更，请刷新后重试")
+		}
+		booking.Status = app_model.BookingStatusCompleted
+
+		// 累计消费并发放积分，挂账部分在结算后不再补发
+		if err := NewMembershipService().EarnPoints(tx, booking.UserID, app_model.PointsBizBooking, booking.BookingNo, booking.PaidAmount); err != nil {
+			return err
+		}
+
+		return bcs.releaseRoom(tx, booking)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("办理退房: %s (超时: %d分钟, 额外费用: %.2f, 状态: %s)",
+		booking.BookingNo, usage.OvertimeMin, usage.ExtraFee, usage.FeeStatus)
+
+	bcs.logService.LogCheckOut(booking, room.RoomName, operatorID, &usage)
+	go NewBookingNotificationService().NotifyBookingCompleted(booking.ID)
+
+	return bcs.buildUsageResp(booking, &room, &usage, charge, balanceAfter), nil
+}
+
+// SettleUsageFee 结算挂账的额外费用：从钱包扣款或确认线下已收款
+func (bcs *BookingCheckinService) SettleUsageFee(req *inout.SettleUsageFeeReq, operatorID int) (*inout.BookingUsageResp, error) {
+	var booking *app_model.RoomBooking
+	var usage app_model.RoomUsageLog
+	var balanceAfter *float64
+	err := bcs.dao().Transaction(func(tx *gorm.DB) error {
+		var err error
+		booking, err = bcs.lockBooking(tx, req.BookingID, "", nil)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("booking_id = ?", booking.ID).First(&usage).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf("该预订没有使用记录")
+			}
+			return fmt.Errorf("查询使用记录失败: %v", err)
+		}
+		if usage.FeeStatus != app_model.UsageFeeStatusReceivable {
+			return fmt.Errorf("该预订没有待收款的额外费用")
+		}
+
+		usage.FeeStatus = app_model.UsageFeeStatusSettled
+		if req.Method == "wallet" {
+			payment, wallet, err := bcs.chargeExtraFee(tx, booking, usage.ExtraFee, false)
+			if err != nil {
+				return err
+			}
+			usage.FeeStatus = app_model.UsageFeeStatusPaid
+			usage.FeePaymentID = &payment.ID
+			balanceAfter = &wallet.Money
+		}
+		note := fmt.Sprintf("挂账结算(管理员ID: %d)", operatorID)
+		if req.Remarks != "" {
+			note += ": " + req.Remarks
+		}
+		if usage.Remarks != "" {
+			note = usage.Remarks + "\n" + note
+		}
+		usage.Remarks = note
+
+		if err := tx.Save(&usage).Error; err != nil {
+			return fmt.Errorf("更新使用记录失败: %v", err)
+		}
+
+		booking.PaidAmount = math.Round((booking.PaidAmount+usage.ExtraFee)*100) / 100
+		return tx.Model(&app_model.RoomBooking{}).Where("id = ?", booking.ID).
+			Update("paid_amount", booking.PaidAmount).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("挂账费用已结算: %s (金额: %.2f, 方式: %s, 管理员ID: %d)",
+		booking.BookingNo, usage.ExtraFee, req.Method, operatorID)
+
+	room := bcs.loadRoomName(booking.RoomID)
+	return bcs.buildUsageResp(booking, &room, &usage, nil, balanceAfter), nil
+}
+
+// ========== 续时 ==========
+
+// ExtendBooking 预订续时：结束时间之后的时段（含清洁缓冲）未被占用时，按当前规则价从钱包扣款并顺延结束时间
+func (bcs *BookingCheckinService) ExtendBooking(req *inout.ExtendBookingReq, userID *int, operatorID *int) (*inout.ExtendBookingResp, error) {
+	securityService := NewSecurityOrderService(redis.GetClient())
+
+	var booking *app_model.RoomBooking
+	var room app_model.Room
+	resp := &inout.ExtendBookingResp{ExtendHours: req.Hours}
+	err := bcs.dao().Transaction(func(tx *gorm.DB) error {
+		var err error
+		booking, err = bcs.lockBooking(tx, req.BookingID, "", userID)
+		if err != nil {
+			return err
+		}
+		if booking.Status != app_model.BookingStatusPaid && booking.Status != app_model.BookingStatusInUse {
+			return fmt.Errorf("当前预订状态为%s，无法续时", booking.GetBookingStatusText())
+		}
+
+		// 锁定房间后检查续时时段及清洁缓冲内没有其他有效预订，与新建预订串行
+		newEndTime := booking.EndTime.Add(time.Duration(req.Hours) * time.Hour)
+		if err := lockRoomAndCheckOverlap(tx, &room, booking.RoomID, booking.EndTime, newEndTime, booking.ID); err != nil {
+			if err == errBookingOverlap {
+				return fmt.Errorf("续时时段房间已被预订，无法续时")
+			}
+			return err
+		}
+
+		pkg, err := bcs.loadBookingPackage(tx, booking)
+		if err != nil {
+			return err
+		}
+		totalHours := booking.Hours + req.Hours
+		resp.LineItems, resp.ExtendAmount = app_model.QuoteTimeRange(pkg, room.HourlyRate, booking.EndTime, newEndTime, totalHours)
+
+		// 每次续时单独记一笔钱包流水，单号按续时次数递增
+		var extendCount int64
+		if err := tx.Model(&app_model.AppRecharge{}).
+			Where("transaction_type = ? AND order_no LIKE ?", app_model.TransactionTypeBookingExtend, booking.BookingNo+"-E%").
+			Count(&extendCount).Error; err != nil {
+			return fmt.Errorf("查询续时记录失败: %v", err)
+		}
+
+		if resp.ExtendAmount > 0 {
+			wallet, err := securityService.SafeDeductWallet(tx, booking.UserID, resp.ExtendAmount)
+			if err != nil {
+				return err
+			}
+			payment, err := securityService.RecordWalletTransactionWithType(tx, booking.UserID,
+				app_model.TransactionTypeBookingExtend, fmt.Sprintf("%s-E%d", booking.BookingNo, extendCount+1),
+				resp.ExtendAmount, wallet.Money+resp.ExtendAmount, wallet.Money,
+				fmt.Sprintf("房间预订续时[%s]: %d小时", booking.BookingNo, req.Hours))
+			if err != nil {
+				return err
+			}
+			resp.PaymentID = payment.ID
+			resp.BalanceAfter = wallet.Money
+		}
+
+		booking.EndTime = newEndTime
+		booking.Hours = totalHours
+		booking.TotalAmount = math.Round((booking.TotalAmount+resp.ExtendAmount)*100) / 100
+		booking.PaidAmount = math.Round((booking.PaidAmount+resp.ExtendAmount)*100) / 100
+
+		result := tx.Model(&app_model.RoomBooking{}).
+			Where("id = ? AND status = ?", booking.ID, booking.Status).
+			Updates(map[string]interface{}{
+				"end_time":        booking.EndTime,
+				"hours":           booking.Hours,
+				"total_amount":    booking.TotalAmount,
+				"paid_amount":     booking.PaidAmount,
+				"price_breakdown": mergeExtensionIntoBreakdown(booking.PriceBreakdown, resp),
+			})
+		if result.Error != nil {
+			return fmt.Errorf("更新预订失败: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("预订状态已变更，请刷新后重试")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp.BookingID = booking.ID
+	resp.BookingNo = booking.BookingNo
+	resp.Hours = booking.Hours
+	resp.EndTime = booking.EndTime
+	resp.TotalAmount = booking.TotalAmount
+	resp.PaidAmount = booking.PaidAmount
+
+	log.Printf("预订续时成功: %s (续时: %d小时, 金额: %.2f)", booking.BookingNo, req.Hours, resp.ExtendAmount)
+
+	bcs.logService.LogBookingExtend(booking, room.RoomName, resp, operatorID)
+
+	return resp, nil
+}
+
+// ========== 辅助方法 ==========
+
+// lockBooking 按预订ID或预订单号锁定预订记录，userID 不为空时只允许操作本人的预订
+func (bcs *BookingCheckinService) lockBooking(tx *gorm.DB, bookingID int, bookingNo string, userID *int) (*app_model.RoomBooking, error) {
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"})
+	switch {
+	case bookingID > 0:
+		query = query.Where("id = ?", bookingID)
+	case bookingNo != "":
+		query = query.Where("booking_no = ?", bookingNo)
+	default:
+		return nil, fmt.Errorf("请提供预订ID或预订单号")
+	}
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+
+	var booking app_model.RoomBooking
+	if err := query.First(&booking).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("预订不存在")
+		}
+		return nil, fmt.Errorf("查询预订失败: %v", err)
+	}
+	return &booking, nil
+}
+
+// quoteOvertime 计算超时费用：免费宽限内不收费，超出后从预订结束时间起按计费单位向上取整计费
+func (bcs *BookingCheckinService) quoteOvertime(tx *gorm.DB, booking *app_model.RoomBooking, room *app_model.Room, checkOutAt time.Time) (*overtimeCharge, error) {
+	charge := &overtimeCharge{}
+	if !checkOutAt.After(booking.EndTime) {
+		return charge, nil
+	}
+
+	charge.OvertimeMin = int(math.Ceil(checkOutAt.Sub(booking.EndTime).Minutes()))
+	if room.OvertimeUnitMin <= 0 || charge.OvertimeMin <= room.OvertimeGraceMin {
+		return charge, nil
+	}
+
+	units := (charge.OvertimeMin + room.OvertimeUnitMin - 1) / room.OvertimeUnitMin
+	charge.BilledMin = units * room.OvertimeUnitMin
+	billedEnd := booking.EndTime.Add(time.Duration(charge.BilledMin) * time.Minute)
+
+	pkg, err := bcs.loadBookingPackage(tx, booking)
+	if err != nil {
+		return nil, err
+	}
+	totalHours := booking.Hours + int(math.Ceil(float64(charge.BilledMin)/60))
+	charge.Items, charge.Fee = app_model.QuoteTimeRange(pkg, room.HourlyRate, booking.EndTime, billedEnd, totalHours)
+	return charge, nil
+}
+
+// loadBookingPackage 加载预订使用的套餐及启用的规则，未使用套餐或套餐已删除时返回 nil 按房间小时价计价
+func (bcs *BookingCheckinService) loadBookingPackage(tx *gorm.DB, booking *app_model.RoomBooking) (*app_model.RoomPackage, error) {
+	if booking.PackageID == nil {
+		return nil, nil
+	}
+
+	var pkg app_model.RoomPackage
+	if err := tx.Preload("Rules", "is_active = 1").First(&pkg, *booking.PackageID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询套餐失败: %v", err)
+	}
+	return &pkg, nil
+}
+
+// chargeExtraFee 从钱包扣除额外费用并记录流水；fallback 为 true 时余额不足返回 errInsufficientBalance 由调用方转为挂账
+func (bcs *BookingCheckinService) chargeExtraFee(tx *gorm.DB, booking *app_model.RoomBooking, amount float64, fallback bool) (*app_model.AppRecharge, *app_model.AppWallet, error) {
+	if fallback {
+		var wallet app_model.AppWallet
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", booking.UserID).First(&wallet).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return nil, nil, fmt.Errorf("查询钱包失败: %v", err)
+		}
+		if err == gorm.ErrRecordNotFound || wallet.Money < amount {
+			return nil, nil, errInsufficientBalance
+		}
+	}
+
+	securityService := NewSecurityOrderService(redis.GetClient())
+	wallet, err := securityService.SafeDeductWallet(tx, booking.UserID, amount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	payment, err := securityService.RecordWalletTransactionWithType(tx, booking.UserID,
+		app_model.TransactionTypeBookingExtra, booking.BookingNo,
+		amount, wallet.Money+amount, wallet.Money,
+		fmt.Sprintf("房间超时及额外费用[%s]", booking.BookingNo))
+	if err != nil {
+		return nil, nil, err
+	}
+	return payment, wallet, nil
+}
+
+// releaseRoom 房间没有其他使用中的预订时恢复为可用
+func (bcs *BookingCheckinService) releaseRoom(tx *gorm.DB, booking *app_model.RoomBooking) error {
+	var activeBookings int64
+	if err := tx.Model(&app_model.RoomBooking{}).
+		Where("room_id = ? AND status = ? AND id != ?",
+			booking.RoomID, app_model.BookingStatusInUse, booking.ID).
+		Count(&activeBookings).Error; err != nil {
+		return fmt.Errorf("检查房间活跃订单失败: %v", err)
+	}
+	if activeBookings > 0 {
+		return nil
+	}
+
+	if err := tx.Model(&app_model.Room{}).Where("id = ?", booking.RoomID).
+		Update("status", app_model.RoomStatusAvailable).Error; err != nil {
+		return fmt.Errorf("更新房间状态失败: %v", err)
+	}
+	return nil
+}
+
+// loadRoomName 查询房间名称用于日志和响应，失败时只记录日志
+func (bcs *BookingCheckinService) loadRoomName(roomID int) app_model.Room {
+	var room app_model.Room
+	if err := bcs.dao().Select("id, room_name").First(&room, roomID).Error; err != nil {
+		log.Printf("查询房间信息失败 (房间ID: %d): %v", roomID, err)
+	}
+	return room
+}
+
+// buildUsageResp 组装入住/退房结果
+func (bcs *BookingCheckinService) buildUsageResp(booking *app_model.RoomBooking, room *app_model.Room, usage *app_model.RoomUsageLog,
+	charge *overtimeCharge, balanceAfter *float64) *inout.BookingUsageResp {
+
+	resp := &inout.BookingUsageResp{
+		BookingID:     booking.ID,
+		BookingNo:     booking.BookingNo,
+		RoomID:        booking.RoomID,
+		RoomName:      room.RoomName,
+		Status:        booking.Status,
+		StatusText:    booking.GetBookingStatusText(),
+		StartTime:     booking.StartTime,
+		EndTime:       booking.EndTime,
+		CheckInAt:     usage.CheckInAt,
+		CheckOutAt:    usage.CheckOutAt,
+		ActualHours:   usage.ActualHours,
+		OvertimeMin:   usage.OvertimeMin,
+		OvertimeFee:   usage.OvertimeFee,
+		ExtraFee:      usage.ExtraFee,
+		FeeStatus:     usage.FeeStatus,
+		FeeStatusText: getUsageFeeStatusText(usage.FeeStatus),
+		FeePaymentID:  usage.FeePaymentID,
+		BalanceAfter:  balanceAfter,
+	}
+	if charge != nil {
+		resp.BilledMin = charge.BilledMin
+		resp.OvertimeItems = charge.Items
+	}
+	return resp
+}
+
+// getUsageFeeStatusText 额外费用状态文本
+func getUsageFeeStatusText(status string) string {
+	switch status {
+	case app_model.UsageFeeStatusPaid:
+		return "已扣款"
+	case app_model.UsageFeeStatusReceivable:
+		return "挂账待收"
+	case app_model.UsageFeeStatusSettled:
+		return "线下已收"
+	default:
+		return "无"
+	}
+}
+
+// mergeExtensionIntoBreakdown 将续时明细追加到预订价格明细JSON
+func mergeExtensionIntoBreakdown(priceBreakdown string, resp *inout.ExtendBookingResp) string {
+	breakdown := map[string]interface{}{}
+	if priceBreakdown != "" {
+		if err := json.Unmarshal([]byte(priceBreakdown), &breakdown); err != nil {
+			breakdown = map[string]interface{}{"raw": priceBreakdown}
+		}
+	}
+
+	extensions, _ := breakdown["extensions"].([]interface{})
+	breakdown["extensions"] = append(extensions, map[string]interface{}{
+		"hours":      resp.ExtendHours,
+		"amount":     resp.ExtendAmount,
+		"payment_id": resp.PaymentID,
+		"line_items": resp.LineItems,
+	})
+
+	bytes, err := json.Marshal(breakdown)
+	if err != nil {
+		return priceBreakdown
+	}
+	return string(bytes)
+}
@@ -0,0 +1,142 @@
+//go:build integration
+
+// 预订并发集成测试，需要 MySQL 和 Redis：
+//
+//	Mysql="user:pass@tcp(127.0.0.1:3306)/db?parseTime=true&loc=Local" REDIS_ADDR=127.0.0.1:6379 \
+//	go test -tags integration -run TestCreateBookingConcurrent ./services/app_service/
+package app_service
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"nasa-go-admin/config"
+	"nasa-go-admin/db"
+	"nasa-go-admin/inout"
+	"nasa-go-admin/model/app_model"
+	"nasa-go-admin/redis"
+
+	"gorm.io/gorm"
+)
+
+// bookingConcurrency 并发请求数
+const bookingConcurrency = 200
+
+func setupBookingTestRoom(t *testing.T) *app_model.Room {
+	t.Helper()
+	if os.Getenv("Mysql") == "" {
+		t.Skip("未配置 Mysql 环境变量，跳过集成测试")
+	}
+	db.Init()
+	if addr := os.Getenv("REDIS_ADDR"); addr != "" {
+		if err := redis.InitRedis(config.RedisConfig{Addr: addr, Password: os.Getenv("REDIS_PASSWORD")}); err != nil {
+			t.Fatalf("连接 Redis 失败: %v", err)
+		}
+	}
+
+	room := &app_model.Room{
+		RoomNumber:  fmt.Sprintf("T%d", time.Now().UnixNano()),
+		RoomName:    "并发测试房间",
+		RoomType:    "小包厢",
+		Capacity:    4,
+		HourlyRate:  100,
+		Status:      app_model.RoomStatusAvailable,
+		CleaningMin: 15,
+	}
+	if err := db.Dao.Create(room).Error; err != nil {
+		t.Fatalf("创建测试房间失败: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Dao.Where("room_id = ?", room.ID).Delete(&app_model.RoomBooking{})
+		db.Dao.Delete(room)
+	})
+	return room
+}
+
+// TestCreateBookingConcurrent 大量并发请求抢订重叠时段，只有一个成功
+func TestCreateBookingConcurrent(t *testing.T) {
+	room := setupBookingTestRoom(t)
+	base := time.Now().Add(48 * time.Hour).Truncate(time.Hour)
+
+	var succeeded int32
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < bookingConcurrency; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			// 开始时间错开，所有请求两两重叠
+			req := &inout.CreateBookingReq{
+				RoomID:       room.ID,
+				StartTime:    base.Add(time.Duration(i%3) * time.Hour).Format("2006-01-02 15:04:05"),
+				Hours:        3,
+				ContactName:  "并发测试",
+				ContactPhone: "13800000000",
+			}
+			if _, err := (&RoomService{}).CreateBooking(req, 1); err == nil {
+				atomic.AddInt32(&succeeded, 1)
+			}
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	if succeeded != 1 {
+		t.Fatalf("并发创建重叠预订成功 %d 个，期望 1 个", succeeded)
+	}
+
+	var count int64
+	db.Dao.Model(&app_model.RoomBooking{}).Where("room_id = ?", room.ID).Count(&count)
+	if count != 1 {
+		t.Fatalf("房间预订记录 %d 条，期望 1 条", count)
+	}
+}
+
+// TestLockRoomAndCheckOverlapConcurrent 绕过 Redis 锁，数据库行锁单独保证只有一个事务写入
+func TestLockRoomAndCheckOverlapConcurrent(t *testing.T) {
+	room := setupBookingTestRoom(t)
+	startTime := time.Now().Add(72 * time.Hour).Truncate(time.Hour)
+	endTime := startTime.Add(2 * time.Hour)
+
+	var succeeded int32
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < bookingConcurrency; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			err := db.Dao.Transaction(func(tx *gorm.DB) error {
+				var locked app_model.Room
+				if err := lockRoomAndCheckOverlap(tx, &locked, room.ID, startTime, endTime, 0); err != nil {
+					return err
+				}
+				return tx.Create(&app_model.RoomBooking{
+					RoomID:       room.ID,
+					UserID:       1,
+					BookingNo:    fmt.Sprintf("BKT%d%04d", time.Now().UnixNano(), i),
+					StartTime:    startTime,
+					EndTime:      endTime,
+					Hours:        2,
+					Status:       app_model.BookingStatusPending,
+					ContactName:  "并发测试",
+					ContactPhone: "13800000000",
+				}).Error
+			})
+			if err == nil {
+				atomic.AddInt32(&succeeded, 1)
+			}
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	if succeeded != 1 {
+		t.Fatalf("并发写入重叠预订成功 %d 个，期望 1 个", succeeded)
+	}
+}
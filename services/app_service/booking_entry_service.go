@@ -0,0 +1,366 @@
+package app_service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"nasa-go-admin/db"
+	"nasa-go-admin/inout"
+	"nasa-go-admin/model/app_model"
+
+	"gorm.io/gorm"
+)
+
+// 入场核验方式
+const (
+	EntryVerifyMethodToken = "qr_token"    // 扫描二维码
+	EntryVerifyMethodCode  = "verify_code" // 输入数字核验码
+)
+
+// verifyCodeRetries 生成核验码遇到重复时的最大重试次数
+const verifyCodeRetries = 10
+
+// BookingEntryService 预订入场凭证服务 - 为已支付预订签发二维码和数字核验码，前台核验后开始使用
+type BookingEntryService struct {
+	logService *BookingLogService
+	ctx        context.Context
+}
+
+// NewBookingEntryService 创建入场凭证服务
+func NewBookingEntryService() *BookingEntryService {
+	return &BookingEntryService{
+		logService: &BookingLogService{},
+	}
+}
+
+// WithContext 返回绑定请求上下文的入场凭证服务，管理端传入 gin.Context 后按租户隔离数据
+func (bes *BookingEntryService) WithContext(ctx context.Context) *BookingEntryService {
+	return &BookingEntryService{
+		logService: bes.logService,
+		ctx:        ctx,
+	}
+}
+
+// dao 获取数据库连接，带上下文时由 GORM 租户插件追加 tenants_id 条件
+func (bes *BookingEntryService) dao() *gorm.DB {
+	if bes.ctx != nil {
+		return db.Dao.WithContext(bes.ctx)
+	}
+	return db.Dao
+}
+
+// entryTokenPayload 二维码令牌载荷，有效期与预订入场时段一致
+type entryTokenPayload struct {
+	BookingID int    `json:"bid"`
+	BookingNo string `json:"bno"`
+	RoomID    int    `json:"rid"`
+	NotBefore int64  `json:"nbf"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// ========== 凭证签发 ==========
+
+// GetCredential 获取预订入场凭证，仅已支付和使用中的预订签发；上线前已支付、未分配核验码的预订在此补发
+func (bes *BookingEntryService) GetCredential(booking *app_model.RoomBooking) (*inout.BookingEntryCredential, error) {
+	if booking.Status != app_model.BookingStatusPaid && booking.Status != app_model.BookingStatusInUse {
+		return nil, nil
+	}
+
+	if booking.VerifyCode == "" {
+		if err := bes.dao().Transaction(func(tx *gorm.DB) error {
+			return bes.AssignVerifyCode(tx, booking)
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	validFrom, validUntil := entryWindow(booking)
+	token, err := signEntryToken(&entryTokenPayload{
+		BookingID: booking.ID,
+		BookingNo: booking.BookingNo,
+		RoomID:    booking.RoomID,
+		NotBefore: validFrom.Unix(),
+		ExpiresAt: validUntil.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &inout.BookingEntryCredential{
+		QRToken:    token,
+		VerifyCode: booking.VerifyCode,
+		ValidFrom:  validFrom,
+		ValidUntil: validUntil,
+		Verified:   booking.VerifiedAt != nil,
+		VerifiedAt: booking.VerifiedAt,
+	}, nil
+}
+
+// AssignVerifyCode 为预订分配6位数字核验码，同一房间的有效预订之间不重复，须在预订支付事务内调用
+func (bes *BookingEntryService) AssignVerifyCode(tx *gorm.DB, booking *app_model.RoomBooking) error {
+	for i := 0; i < verifyCodeRetries; i++ {
+		code, err := randomVerifyCode()
+		if err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&app_model.RoomBooking{}).
+			Where("room_id = ? AND verify_code = ? AND status IN (?) AND id != ?",
+				booking.RoomID, code, calendarBlockingStatuses, booking.ID).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("检查核验码失败: %v", err)
+		}
+		if count > 0 {
+			continue
+		}
+
+		if err := tx.Model(&app_model.RoomBooking{}).Where("id = ?", booking.ID).
+			Update("verify_code", code).Error; err != nil {
+			return fmt.Errorf("保存核验码失败: %v", err)
+		}
+		booking.VerifyCode = code
+		return nil
+	}
+	return fmt.Errorf("生成核验码失败，请稍后重试")
+}
+
+// ========== 入场核验 ==========
+
+// VerifyEntry 核验入场凭证：校验签名、房间和入场时段并拒绝重复使用，已支付的预订核验通过后手动开始使用。
+// 成功和失败的核验均记录日志
+func (bes *BookingEntryService) VerifyEntry(req *inout.VerifyBookingEntryReq, operatorID int) (*inout.VerifyBookingEntryResp, error) {
+	method := EntryVerifyMethodToken
+	if req.Token == "" {
+		method = EntryVerifyMethodCode
+	}
+
+	booking, err := bes.verifyEntry(req, operatorID, time.Now())
+	bes.logService.LogBookingVerify(booking, req.RoomID, operatorID, method, err)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("入场核验通过: %s (房间ID: %d, 方式: %s, 管理员ID: %d)", booking.BookingNo, booking.RoomID, method, operatorID)
+
+	resp := &inout.VerifyBookingEntryResp{
+		BookingID:    booking.ID,
+		BookingNo:    booking.BookingNo,
+		RoomID:       booking.RoomID,
+		UserID:       booking.UserID,
+		ContactName:  booking.ContactName,
+		ContactPhone: booking.ContactPhone,
+		StartTime:    booking.StartTime,
+		EndTime:      booking.EndTime,
+		Status:       booking.Status,
+		StatusText:   booking.GetBookingStatusText(),
+		Method:       method,
+		VerifiedAt:   *booking.VerifiedAt,
+	}
+	if booking.Room != nil {
+		resp.RoomName = booking.Room.RoomName
+	}
+	return resp, nil
+}
+
+// verifyEntry 执行核验，返回的预订在校验失败时也尽量带回用于记录日志
+func (bes *BookingEntryService) verifyEntry(req *inout.VerifyBookingEntryReq, operatorID int, now time.Time) (*app_model.RoomBooking, error) {
+	var booking *app_model.RoomBooking
+	var err error
+	switch {
+	case req.Token != "":
+		booking, err = bes.findByToken(req.Token, req.RoomID, now)
+	case req.Code != "":
+		booking, err = bes.findByCode(req.Code, req.RoomID, now)
+	default:
+		return nil, fmt.Errorf("请提供二维码或核验码")
+	}
+	if err != nil {
+		return booking, err
+	}
+
+	if booking.RoomID != req.RoomID {
+		return booking, fmt.Errorf("入场凭证与房间不匹配")
+	}
+	if booking.Status != app_model.BookingStatusPaid && booking.Status != app_model.BookingStatusInUse {
+		return booking, fmt.Errorf("当前预订状态为%s，无法入场", booking.GetBookingStatusText())
+	}
+	validFrom, validUntil := entryWindow(booking)
+	if now.Before(validFrom) {
+		return booking, fmt.Errorf("未到入场时间，最早可于 %s 入场", validFrom.Format("2006-01-02 15:04"))
+	}
+	if !now.Before(validUntil) {
+		return booking, fmt.Errorf("预订时间已过，入场凭证已失效")
+	}
+	if booking.VerifiedAt != nil {
+		return booking, fmt.Errorf("入场凭证已于 %s 使用", booking.VerifiedAt.Format("2006-01-02 15:04:05"))
+	}
+
+	// 条件更新抢占核验，防止同一凭证并发重复使用
+	result := bes.dao().Model(&app_model.RoomBooking{}).
+		Where("id = ? AND verified_at IS NULL", booking.ID).
+		Updates(map[string]interface{}{
+			"verified_at": now,
+			"verified_by": operatorID,
+		})
+	if result.Error != nil {
+		return booking, fmt.Errorf("记录核验结果失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return booking, fmt.Errorf("入场凭证已使用")
+	}
+
+	if booking.Status == app_model.BookingStatusPaid {
+		if err := NewBookingScheduler().WithContext(bes.ctx).ManuallyStartBooking(booking.ID, operatorID); err != nil {
+			// 开始使用失败时撤销核验，凭证可再次使用
+			if rollbackErr := bes.dao().Model(&app_model.RoomBooking{}).Where("id = ?", booking.ID).
+				Updates(map[string]interface{}{"verified_at": nil, "verified_by": 0}).Error; rollbackErr != nil {
+				log.Printf("撤销入场核验失败 (预订ID: %d): %v", booking.ID, rollbackErr)
+			}
+			return booking, fmt.Errorf("开始使用失败: %v", err)
+		}
+		booking.Status = app_model.BookingStatusInUse
+	}
+
+	booking.VerifiedAt = &now
+	booking.VerifiedBy = operatorID
+	return booking, nil
+}
+
+// findByToken 校验二维码签名和有效期并查询预订
+func (bes *BookingEntryService) findByToken(token string, roomID int, now time.Time) (*app_model.RoomBooking, error) {
+	payload, err := parseEntryToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	var booking app_model.RoomBooking
+	if err := bes.dao().Preload("Room").First(&booking, payload.BookingID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("预订不存在")
+		}
+		return nil, fmt.Errorf("查询预订失败: %v", err)
+	}
+
+	if booking.BookingNo != payload.BookingNo {
+		return &booking, fmt.Errorf("二维码无效")
+	}
+	if payload.RoomID != roomID {
+		return &booking, fmt.Errorf("入场凭证与房间不匹配")
+	}
+	if now.Unix() >= payload.ExpiresAt {
+		return &booking, fmt.Errorf("二维码已过期，请刷新后重试")
+	}
+	return &booking, nil
+}
+
+// findByCode 按房间和核验码查询当前入场时段内的预订
+func (bes *BookingEntryService) findByCode(code string, roomID int, now time.Time) (*app_model.RoomBooking, error) {
+	var bookings []app_model.RoomBooking
+	if err := bes.dao().Preload("Room").
+		Where("room_id = ? AND verify_code = ? AND status IN (?)", roomID, code, calendarBlockingStatuses).
+		Order("start_time ASC").Find(&bookings).Error; err != nil {
+		return nil, fmt.Errorf("查询预订失败: %v", err)
+	}
+	if len(bookings) == 0 {
+		return nil, fmt.Errorf("核验码无效")
+	}
+
+	for i := range bookings {
+		validFrom, validUntil := entryWindow(&bookings[i])
+		if !now.Before(validFrom) && now.Before(validUntil) {
+			return &bookings[i], nil
+		}
+	}
+	// 没有处于入场时段的预订，返回最早的一条给出具体原因
+	return &bookings[0], nil
+}
+
+// ========== 辅助方法 ==========
+
+// entryWindow 入场有效时段：开始前 checkInEarlyWindow 至预订结束
+func entryWindow(booking *app_model.RoomBooking) (time.Time, time.Time) {
+	return booking.StartTime.Add(-checkInEarlyWindow), booking.EndTime
+}
+
+// bookingEntrySecret 二维码签名密钥，未配置 BOOKING_QR_SECRET 时由 JWT 签名密钥派生独立密钥
+func bookingEntrySecret() ([]byte, error) {
+	if secret := os.Getenv("BOOKING_QR_SECRET"); secret != "" {
+		return []byte(secret), nil
+	}
+	if jwtKey := os.Getenv("JWT_SIGNING_KEY"); jwtKey != "" {
+		mac := hmac.New(sha256.New, []byte(jwtKey))
+		mac.Write([]byte("booking-qr"))
+		return mac.Sum(nil), nil
+	}
+	return nil, fmt.Errorf("未配置二维码签名密钥 BOOKING_QR_SECRET 或 JWT_SIGNING_KEY")
+}
+
+// signEntryToken 生成二维码令牌：base64url(载荷JSON).base64url(HMAC-SHA256)
+func signEntryToken(payload *entryTokenPayload) (string, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("生成二维码失败: %v", err)
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(body)
+	signature, err := entryTokenSignature(encoded)
+	if err != nil {
+		return "", fmt.Errorf("生成二维码失败: %v", err)
+	}
+	return encoded + "." + signature, nil
+}
+
+// parseEntryToken 校验签名并解析二维码令牌
+func parseEntryToken(token string) (*entryTokenPayload, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("二维码无效")
+	}
+	signature, err := entryTokenSignature(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("校验二维码失败: %v", err)
+	}
+	if !hmac.Equal([]byte(signature), []byte(parts[1])) {
+		return nil, fmt.Errorf("二维码签名无效")
+	}
+
+	body, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("二维码无效")
+	}
+	var payload entryTokenPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("二维码无效")
+	}
+	return &payload, nil
+}
+
+// entryTokenSignature 计算二维码载荷签名
+func entryTokenSignature(encoded string) (string, error) {
+	secret, err := bookingEntrySecret()
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// randomVerifyCode 生成6位数字核验码
+func randomVerifyCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", fmt.Errorf("生成核验码失败: %v", err)
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
@@ -0,0 +1,614 @@
+package app_service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"nasa-go-admin/inout"
+	"nasa-go-admin/model/app_model"
+	"nasa-go-admin/redis"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 前台代客预订收款方式及改期/换房差价结算方式
+const (
+	FrontDeskPayWallet  = "wallet"  // 会员钱包扣款/退款
+	FrontDeskPayOffline = "offline" // 前台线下收款/退款
+	FrontDeskPayUnpaid  = "unpaid"  // 会员在小程序自行支付
+)
+
+// ========== 代客预订 ==========
+
+// CreateWalkInBooking 前台代会员或散客创建预订，与小程序预订走同一套可用性检查和计价。
+// 会员可从钱包扣款、线下收款或留给会员自行支付；散客只能线下收款。check_in 为 true 时创建后立即办理入住
+func (bcs *BookingCheckinService) CreateWalkInBooking(req *inout.AdminCreateBookingReq, operatorID int) (*inout.AdminBookingResp, error) {
+	if req.UserID == 0 && req.PayMethod != FrontDeskPayOffline {
+		return nil, fmt.Errorf("散客预订只支持线下收款")
+	}
+	if req.CheckIn && req.PayMethod == FrontDeskPayUnpaid {
+		return nil, fmt.Errorf("待支付的预订无法办理入住")
+	}
+
+	if req.UserID > 0 {
+		var count int64
+		if err := bcs.dao().Model(&app_model.UserApp{}).Where("id = ?", req.UserID).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("查询会员失败: %v", err)
+		}
+		if count == 0 {
+			return nil, fmt.Errorf("会员不存在")
+		}
+	}
+
+	startTime := req.StartTime
+	if startTime == "" {
+		startTime = time.Now().Format("2006-01-02 15:04:05")
+	}
+
+	booking, err := (&RoomService{ctx: bcs.ctx}).CreateBooking(&inout.CreateBookingReq{
+		RoomID:       req.RoomID,
+		StartTime:    startTime,
+		Hours:        req.Hours,
+		PackageID:    req.PackageID,
+		ContactName:  req.ContactName,
+		ContactPhone: req.ContactPhone,
+		Remarks:      req.Remarks,
+	}, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	room := bcs.loadRoomName(booking.RoomID)
+	resp := buildAdminBookingResp(booking, &room)
+	resp.PayMethod = req.PayMethod
+
+	switch req.PayMethod {
+	case FrontDeskPayWallet:
+		payResp, err := NewBookingPaymentService().PayBooking(&inout.PayBookingReq{BookingID: booking.ID}, req.UserID)
+		if err != nil {
+			bcs.abandonWalkInBooking(booking)
+			return nil, fmt.Errorf("钱包支付失败，预订已取消: %v", err)
+		}
+		booking.Status = payResp.Status
+		booking.PaidAmount = payResp.PaidAmount
+		booking.PaymentID = &payResp.PaymentID
+		resp.PaymentID = &payResp.PaymentID
+		resp.BalanceAfter = &payResp.BalanceAfter
+
+	case FrontDeskPayOffline:
+		if err := bcs.markOfflinePaid(booking); err != nil {
+			bcs.abandonWalkInBooking(booking)
+			return nil, fmt.Errorf("确认线下收款失败，预订已取消: %v", err)
+		}
+		resp.PaymentID = booking.PaymentID
+	}
+
+	log.Printf("前台代客预订: %s (用户ID: %d, 收款方式: %s, 管理员ID: %d)",
+		booking.BookingNo, booking.UserID, req.PayMethod, operatorID)
+	bcs.logService.LogAdminCreateBooking(booking, room.RoomName, req.PayMethod, operatorID)
+
+	if req.CheckIn {
+		if _, err := bcs.CheckIn(&inout.CheckInReq{BookingID: booking.ID}, operatorID); err != nil {
+			resp.Message = fmt.Sprintf("预订已创建，办理入住失败: %v", err)
+		} else {
+			booking.Status = app_model.BookingStatusInUse
+			resp.CheckedIn = true
+		}
+	}
+
+	resp.Status = booking.Status
+	resp.StatusText = booking.GetBookingStatusText()
+	resp.PaidAmount = booking.PaidAmount
+	return resp, nil
+}
+
+// markOfflinePaid 前台线下收款后将待支付预订标记为已支付并分配入场核验码，
+// 收款金额记一笔线下收款流水（单号为预订单号）并入账，不改变会员钱包余额
+func (bcs *BookingCheckinService) markOfflinePaid(booking *app_model.RoomBooking) error {
+	return bcs.dao().Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"status":      app_model.BookingStatusPaid,
+			"paid_amount": booking.TotalAmount,
+		}
+		payment, err := recordOfflinePayment(tx, booking)
+		if err != nil {
+			return err
+		}
+		if payment != nil {
+			updates["payment_id"] = payment.ID
+		}
+
+		result := tx.Model(&app_model.RoomBooking{}).
+			Where("id = ? AND status = ?", booking.ID, app_model.BookingStatusPending).
+			Updates(updates)
+		if result.Error != nil {
+			return fmt.Errorf("更新预订状态失败: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("预订状态已变更，请刷新后重试")
+		}
+
+		booking.Status = app_model.BookingStatusPaid
+		booking.PaidAmount = booking.TotalAmount
+		if payment != nil {
+			booking.PaymentID = &payment.ID
+		}
+		return NewBookingEntryService().AssignVerifyCode(tx, booking)
+	})
+}
+
+// recordOfflinePayment 记录前台线下收款流水并记账（平台收款 -> 预订收入），金额为0时不记录
+func recordOfflinePayment(tx *gorm.DB, booking *app_model.RoomBooking) (*app_model.AppRecharge, error) {
+	if booking.TotalAmount <= 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	payment := app_model.AppRecharge{
+		UserID:          booking.UserID,
+		OrderNo:         booking.BookingNo,
+		TransactionType: app_model.TransactionTypeBookingOffline,
+		Amount:          booking.TotalAmount,
+		Status:          app_model.RechargeStatusCompleted,
+		PaymentMethod:   FrontDeskPayOffline,
+		Remark:          fmt.Sprintf("房间预订前台线下收款[%s]", booking.BookingNo),
+		CreateTime:      now,
+		UpdateTime:      now,
+		CompleteTime:    &now,
+	}
+	if err := tx.Create(&payment).Error; err != nil {
+		return nil, fmt.Errorf("记录线下收款流水失败: %v", err)
+	}
+
+	if _, err := NewWalletLedgerService().PostWalletTransaction(tx, &payment,
+		WalletIdempotencyKey(payment.TransactionType, payment.OrderNo, payment.ID)); err != nil {
+		return nil, fmt.Errorf("线下收款记账失败: %v", err)
+	}
+	return &payment, nil
+}
+
+// abandonWalkInBooking 代客预订收款失败时取消刚创建的待支付预订，释放时段
+func (bcs *BookingCheckinService) abandonWalkInBooking(booking *app_model.RoomBooking) {
+	if err := bcs.dao().Model(&app_model.RoomBooking{}).
+		Where("id = ? AND status = ?", booking.ID, app_model.BookingStatusPending).
+		Update("status", app_model.BookingStatusCancelled).Error; err != nil {
+		log.Printf("取消代客预订失败 (预订: %s): %v", booking.BookingNo, err)
+	}
+}
+
+// ========== 修改预订 ==========
+
+// ModifyBooking 前台修改未开始预订的时间、时长、房间或套餐。
+// 新时段按与新建预订相同的规则检查可用性并重新计价，原优惠券和积分抵扣保留；
+// 已支付的预订按新旧金额差价从钱包补收或退还，也可由前台线下结算
+func (bcs *BookingCheckinService) ModifyBooking(req *inout.UpdateBookingReq, operatorID int) (*inout.AdminBookingResp, error) {
+	startTime, err := parseBookingTime(req.StartTime)
+	if err != nil {
+		return nil, fmt.Errorf("开始时间格式错误: %v", err)
+	}
+	now := time.Now()
+	if !startTime.After(now) {
+		return nil, fmt.Errorf("开始时间不能早于当前时间")
+	}
+	endTime := startTime.Add(time.Duration(req.Hours) * time.Hour)
+
+	var room app_model.Room
+	if err := bcs.dao().First(&room, req.RoomID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("房间不存在")
+		}
+		return nil, fmt.Errorf("查询房间失败: %v", err)
+	}
+	if room.Status == app_model.RoomStatusMaintenance || room.Status == app_model.RoomStatusDisabled {
+		return nil, fmt.Errorf("房间当前不可用")
+	}
+
+	pkg, err := (&RoomService{ctx: bcs.ctx}).resolveBookingPackage(req.RoomID, req.PackageID)
+	if err != nil {
+		return nil, err
+	}
+	price, err := priceBooking(&room, pkg, startTime, req.Hours)
+	if err != nil {
+		return nil, err
+	}
+
+	roomLock, err := lockRoomForBooking(req.RoomID)
+	if err != nil {
+		return nil, err
+	}
+	defer roomLock.Release()
+
+	var before app_model.RoomBooking
+	var booking *app_model.RoomBooking
+	var resp *inout.AdminBookingResp
+	var fromRoom app_model.Room
+	err = bcs.dao().Transaction(func(tx *gorm.DB) error {
+		var err error
+		booking, err = bcs.lockBooking(tx, req.ID, "", nil)
+		if err != nil {
+			return err
+		}
+		if booking.Status != app_model.BookingStatusPending && booking.Status != app_model.BookingStatusPaid {
+			return fmt.Errorf("当前预订状态为%s，无法修改", booking.GetBookingStatusText())
+		}
+		if !now.Before(booking.StartTime) {
+			return fmt.Errorf("预订已到开始时间，无法修改")
+		}
+		before = *booking
+
+		settleMethod, err := resolveSettleMethod(booking.UserID, req.SettleMethod)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Select("id, room_name").First(&fromRoom, booking.RoomID).Error; err != nil {
+			return fmt.Errorf("查询原房间失败: %v", err)
+		}
+
+		// 锁定目标房间后检查新时段，排除预订自身占用的原时段
+		if err := lockRoomAndCheckOverlap(tx, &room, req.RoomID, startTime, endTime, booking.ID); err != nil {
+			if reason := slotConflictReason(err); reason != "" {
+				return fmt.Errorf("%s%s", room.RoomName, reason)
+			}
+			return err
+		}
+
+		// 重新计价后扣减原有优惠券和积分抵扣
+		totalAmount := math.Round((price.TotalAmount-booking.CouponDiscount-booking.PointsDiscount)*100) / 100
+		if totalAmount < 0 {
+			totalAmount = 0
+		}
+		quote := price.Quote
+		quote.CouponDiscount = booking.CouponDiscount
+		quote.PointsUsed = booking.PointsUsed
+		quote.PointsDiscount = booking.PointsDiscount
+
+		resp = &inout.AdminBookingResp{FromRoomID: fromRoom.ID, FromRoomName: fromRoom.RoomName}
+		updates := map[string]interface{}{
+			"room_id":         req.RoomID,
+			"start_time":      startTime,
+			"end_time":        endTime,
+			"hours":           req.Hours,
+			"package_id":      req.PackageID,
+			"package_name":    price.PackageName,
+			"original_price":  price.OriginalPrice,
+			"package_price":   price.PackagePrice,
+			"discount_amount": math.Round((price.DiscountAmount+booking.CouponDiscount+booking.PointsDiscount)*100) / 100,
+			"total_amount":    totalAmount,
+			"contact_name":    req.ContactName,
+			"contact_phone":   req.ContactPhone,
+			"remarks":         req.Remarks,
+		}
+
+		if booking.Status == app_model.BookingStatusPaid {
+			resp.DiffAmount = math.Round((totalAmount-booking.PaidAmount)*100) / 100
+			resp.SettleMethod = settleMethod
+			payment, balanceAfter, err := bcs.settleBookingDiff(tx, booking, resp.DiffAmount, settleMethod, "修改")
+			if err != nil {
+				return err
+			}
+			if payment != nil {
+				resp.PaymentID = &payment.ID
+			}
+			resp.BalanceAfter = balanceAfter
+			updates["paid_amount"] = totalAmount
+			booking.PaidAmount = totalAmount
+		} else {
+			resp.DiffAmount = math.Round((totalAmount-booking.TotalAmount)*100) / 100
+		}
+
+		updates["price_breakdown"] = mergeAdjustmentIntoBreakdown(booking.PriceBreakdown, quote, map[string]interface{}{
+			"type":            "modify",
+			"from_room_id":    before.RoomID,
+			"from_start_time": before.StartTime,
+			"from_hours":      before.Hours,
+			"from_amount":     before.TotalAmount,
+			"amount":          resp.DiffAmount,
+			"settle_method":   resp.SettleMethod,
+			"payment_id":      resp.PaymentID,
+			"operator_id":     operatorID,
+		})
+
+		result := tx.Model(&app_model.RoomBooking{}).
+			Where("id = ? AND status = ?", booking.ID, booking.Status).
+			Updates(updates)
+		if result.Error != nil {
+			return fmt.Errorf("更新预订失败: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("预订状态已变更，请刷新后重试")
+		}
+
+		booking.RoomID = req.RoomID
+		booking.StartTime = startTime
+		booking.EndTime = endTime
+		booking.Hours = req.Hours
+		booking.PackageID = req.PackageID
+		booking.TotalAmount = totalAmount
+		booking.ContactName = req.ContactName
+		booking.ContactPhone = req.ContactPhone
+		booking.Remarks = req.Remarks
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("修改预订: %s (房间: %s -> %s, 差价: %.2f, 管理员ID: %d)",
+		booking.BookingNo, fromRoom.RoomName, room.RoomName, resp.DiffAmount, operatorID)
+
+	fillAdminBookingResp(resp, booking, &room)
+	bcs.logService.LogBookingModify(&before, booking, resp, operatorID)
+	return resp, nil
+}
+
+// ========== 使用中换房 ==========
+
+// TransferRoom 为使用中的预订换到其他房间，剩余时段在新房间继续使用，结束时间不变。
+// 剩余时段按预订套餐分别以新旧房间报价，差价为两次报价之差，退还金额不超过剩余时长对应的已付金额
+func (bcs *BookingCheckinService) TransferRoom(req *inout.TransferRoomReq, operatorID int) (*inout.AdminBookingResp, error) {
+	now := time.Now()
+
+	roomLock, err := lockRoomForBooking(req.RoomID)
+	if err != nil {
+		return nil, err
+	}
+	defer roomLock.Release()
+
+	var booking *app_model.RoomBooking
+	var fromRoom, toRoom app_model.Room
+	resp := &inout.AdminBookingResp{}
+	err = bcs.dao().Transaction(func(tx *gorm.DB) error {
+		var err error
+		booking, err = bcs.lockBooking(tx, req.BookingID, "", nil)
+		if err != nil {
+			return err
+		}
+		if booking.Status != app_model.BookingStatusInUse {
+			return fmt.Errorf("当前预订状态为%s，无法换房", booking.GetBookingStatusText())
+		}
+		if booking.RoomID == req.RoomID {
+			return fmt.Errorf("目标房间与当前房间相同")
+		}
+		if !now.Before(booking.EndTime) {
+			return fmt.Errorf("预订已到结束时间，请办理退房")
+		}
+
+		settleMethod, err := resolveSettleMethod(booking.UserID, req.SettleMethod)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.First(&fromRoom, booking.RoomID).Error; err != nil {
+			return fmt.Errorf("查询原房间失败: %v", err)
+		}
+
+		// 检查目标房间在剩余时段内可用，且当前没有其他客人在使用
+		if err := lockRoomAndCheckOverlap(tx, &toRoom, req.RoomID, now, booking.EndTime, booking.ID); err != nil {
+			if reason := slotConflictReason(err); reason != "" {
+				return fmt.Errorf("%s%s", toRoom.RoomName, reason)
+			}
+			return err
+		}
+		if toRoom.Status != app_model.RoomStatusAvailable {
+			return fmt.Errorf("%s当前不可用", toRoom.RoomName)
+		}
+
+		// 剩余时段按预订套餐分别以新旧房间重新计价，差价为两次报价之差
+		pkg, err := bcs.loadBookingPackage(tx, booking)
+		if err != nil {
+			return err
+		}
+		_, fromAmount := app_model.QuoteTimeRange(pkg, fromRoom.HourlyRate, now, booking.EndTime, booking.Hours)
+		_, toAmount := app_model.QuoteTimeRange(pkg, toRoom.HourlyRate, now, booking.EndTime, booking.Hours)
+
+		remaining := booking.EndTime.Sub(now)
+		diff := math.Round((toAmount-fromAmount)*100) / 100
+		if total := booking.EndTime.Sub(booking.StartTime); diff < 0 && total > 0 {
+			remainingPaid := math.Round(booking.PaidAmount*remaining.Hours()/total.Hours()*100) / 100
+			if -diff > remainingPaid {
+				diff = -remainingPaid
+			}
+		}
+
+		resp.DiffAmount = diff
+		resp.SettleMethod = settleMethod
+		payment, balanceAfter, err := bcs.settleBookingDiff(tx, booking, diff, settleMethod, "换房")
+		if err != nil {
+			return err
+		}
+		if payment != nil {
+			resp.PaymentID = &payment.ID
+		}
+		resp.BalanceAfter = balanceAfter
+
+		note := fmt.Sprintf("%s 由%s换房至%s", now.Format("2006-01-02 15:04"), fromRoom.RoomName, toRoom.RoomName)
+		if req.Remarks != "" {
+			note += "（" + req.Remarks + "）"
+		}
+
+		booking.TotalAmount = math.Round((booking.TotalAmount+diff)*100) / 100
+		booking.PaidAmount = math.Round((booking.PaidAmount+diff)*100) / 100
+		result := tx.Model(&app_model.RoomBooking{}).
+			Where("id = ? AND status = ?", booking.ID, app_model.BookingStatusInUse).
+			Updates(map[string]interface{}{
+				"room_id":      toRoom.ID,
+				"total_amount": booking.TotalAmount,
+				"paid_amount":  booking.PaidAmount,
+				"remarks":      booking.Remarks + "\n" + note,
+				"price_breakdown": mergeAdjustmentIntoBreakdown(booking.PriceBreakdown, nil, map[string]interface{}{
+					"type":          "transfer",
+					"from_room_id":  fromRoom.ID,
+					"to_room_id":    toRoom.ID,
+					"transfer_at":   now,
+					"amount":        diff,
+					"settle_method": settleMethod,
+					"payment_id":    resp.PaymentID,
+					"operator_id":   operatorID,
+				}),
+			})
+		if result.Error != nil {
+			return fmt.Errorf("更新预订失败: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("预订状态已变更，请刷新后重试")
+		}
+
+		// 使用记录跟随预订迁移到新房间，退房时按新房间计算超时费
+		var usage app_model.RoomUsageLog
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("booking_id = ?", booking.ID).First(&usage).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return fmt.Errorf("查询使用记录失败: %v", err)
+		}
+		if err == nil {
+			if err := tx.Model(&usage).Updates(map[string]interface{}{
+				"room_id": toRoom.ID,
+				"remarks": usage.Remarks + "\n" + note,
+			}).Error; err != nil {
+				return fmt.Errorf("更新使用记录失败: %v", err)
+			}
+		}
+
+		// 原房间没有其他使用中的预订时释放，新房间标记为使用中
+		if err := bcs.releaseRoom(tx, booking); err != nil {
+			return err
+		}
+		if err := tx.Model(&app_model.Room{}).Where("id = ?", toRoom.ID).
+			Update("status", app_model.RoomStatusOccupied).Error; err != nil {
+			return fmt.Errorf("更新房间状态失败: %v", err)
+		}
+
+		booking.RoomID = toRoom.ID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("使用中换房: %s (%s -> %s, 差价: %.2f, 管理员ID: %d)",
+		booking.BookingNo, fromRoom.RoomName, toRoom.RoomName, resp.DiffAmount, operatorID)
+
+	resp.FromRoomID = fromRoom.ID
+	resp.FromRoomName = fromRoom.RoomName
+	fillAdminBookingResp(resp, booking, &toRoom)
+	bcs.logService.LogRoomTransfer(booking, resp, now, operatorID)
+	return resp, nil
+}
+
+// ========== 差价结算 ==========
+
+// resolveSettleMethod 确定差价结算方式，未指定时会员走钱包、散客线下
+func resolveSettleMethod(userID int, method string) (string, error) {
+	if method == "" {
+		if userID > 0 {
+			return FrontDeskPayWallet, nil
+		}
+		return FrontDeskPayOffline, nil
+	}
+	if method == FrontDeskPayWallet && userID == 0 {
+		return "", fmt.Errorf("散客预订不支持钱包结算")
+	}
+	return method, nil
+}
+
+// settleBookingDiff 结算改期/换房差价：diff 为正从钱包补收，为负退回钱包，线下结算时只记录明细。
+// 每次结算单独记一笔钱包流水，单号为 预订单号-A序号
+func (bcs *BookingCheckinService) settleBookingDiff(tx *gorm.DB, booking *app_model.RoomBooking, diff float64, method, action string) (*app_model.AppRecharge, *float64, error) {
+	if diff == 0 || method != FrontDeskPayWallet {
+		return nil, nil, nil
+	}
+
+	var adjustCount int64
+	if err := tx.Model(&app_model.AppRecharge{}).
+		Where("transaction_type IN ? AND order_no LIKE ?",
+			[]string{app_model.TransactionTypeBookingAdjust, app_model.TransactionTypeAdjustRefund}, booking.BookingNo+"-A%").
+		Count(&adjustCount).Error; err != nil {
+		return nil, nil, fmt.Errorf("查询差价记录失败: %v", err)
+	}
+	orderNo := fmt.Sprintf("%s-A%d", booking.BookingNo, adjustCount+1)
+
+	securityService := NewSecurityOrderService(redis.GetClient())
+	if diff > 0 {
+		wallet, err := securityService.SafeDeductWallet(tx, booking.UserID, diff)
+		if err != nil {
+			return nil, nil, err
+		}
+		payment, err := securityService.RecordWalletTransactionWithType(tx, booking.UserID,
+			app_model.TransactionTypeBookingAdjust, orderNo, diff, wallet.Money+diff, wallet.Money,
+			fmt.Sprintf("房间预订%s补差价[%s]", action, booking.BookingNo))
+		if err != nil {
+			return nil, nil, err
+		}
+		return payment, &wallet.Money, nil
+	}
+
+	refund := -diff
+	wallet, err := securityService.SafeCreditWallet(tx, booking.UserID, refund)
+	if err != nil {
+		return nil, nil, err
+	}
+	payment, err := securityService.RecordWalletTransactionWithType(tx, booking.UserID,
+		app_model.TransactionTypeAdjustRefund, orderNo, refund, wallet.Money-refund, wallet.Money,
+		fmt.Sprintf("房间预订%s退差价[%s]", action, booking.BookingNo))
+	if err != nil {
+		return nil, nil, err
+	}
+	return payment, &wallet.Money, nil
+}
+
+// mergeAdjustmentIntoBreakdown 将改期/换房差价追加到预订价格明细JSON的 adjustments；
+// quote 不为空时以重新计价的明细替换原明细，保留原优惠券码和历次调整记录
+func mergeAdjustmentIntoBreakdown(priceBreakdown string, quote *app_model.PriceQuote, adjustment map[string]interface{}) string {
+	breakdown := map[string]interface{}{}
+	if priceBreakdown != "" {
+		if err := json.Unmarshal([]byte(priceBreakdown), &breakdown); err != nil {
+			breakdown = map[string]interface{}{"raw": priceBreakdown}
+		}
+	}
+	adjustments, _ := breakdown["adjustments"].([]interface{})
+
+	if quote != nil {
+		if couponCode, ok := breakdown["coupon_code"].(string); ok {
+			quote.CouponCode = couponCode
+		}
+		repriced := map[string]interface{}{}
+		if bytes, err := json.Marshal(quote); err == nil && json.Unmarshal(bytes, &repriced) == nil {
+			breakdown = repriced
+		}
+	}
+	breakdown["adjustments"] = append(adjustments, adjustment)
+
+	bytes, err := json.Marshal(breakdown)
+	if err != nil {
+		return priceBreakdown
+	}
+	return string(bytes)
+}
+
+// buildAdminBookingResp 组装前台预订操作结果
+func buildAdminBookingResp(booking *app_model.RoomBooking, room *app_model.Room) *inout.AdminBookingResp {
+	resp := &inout.AdminBookingResp{}
+	fillAdminBookingResp(resp, booking, room)
+	return resp
+}
+
+// fillAdminBookingResp 按预订当前信息填充前台预订操作结果
+func fillAdminBookingResp(resp *inout.AdminBookingResp, booking *app_model.RoomBooking, room *app_model.Room) {
+	resp.BookingID = booking.ID
+	resp.BookingNo = booking.BookingNo
+	resp.UserID = booking.UserID
+	resp.RoomID = booking.RoomID
+	resp.RoomName = room.RoomName
+	resp.Status = booking.Status
+	resp.StatusText = booking.GetBookingStatusText()
+	resp.StartTime = booking.StartTime
+	resp.EndTime = booking.EndTime
+	resp.Hours = booking.Hours
+	resp.TotalAmount = booking.TotalAmount
+	resp.PaidAmount = booking.PaidAmount
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"time"
 
+	"nasa-go-admin/inout"
 	"nasa-go-admin/model/app_model"
 	"nasa-go-admin/mongodb"
 	"nasa-go-admin/utils"
@@ -234,6 +235,341 @@ func (bls *BookingLogService) LogManualEnd(booking *app_model.RoomBooking, roomN
 	}
 }
 
+// LogBookingPaid 记录订单支付日志
+func (bls *BookingLogService) LogBookingPaid(booking *app_model.RoomBooking, roomName string, paymentID int, balanceAfter float64) {
+	oldStatus := app_model.BookingStatusPending
+	newStatus := app_model.BookingStatusPaid
+
+	log := &app_model.BookingStatusLog{
+		LogType:   app_model.LogTypeBookingPaid,
+		BookingID: &booking.ID,
+		BookingNo: booking.BookingNo,
+		RoomID:    &booking.RoomID,
+		RoomName:  roomName,
+		UserID:    &booking.UserID,
+		OldStatus: &oldStatus,
+		NewStatus: &newStatus,
+		Message:   fmt.Sprintf("订单已支付: %s (用户ID: %d, 金额: %.2f)", booking.BookingNo, booking.UserID, booking.TotalAmount),
+		CreatedAt: utils.GetCurrentTimeForMongo(),
+		Details: map[string]interface{}{
+			"start_time":     booking.StartTime,
+			"end_time":       booking.EndTime,
+			"hours":          booking.Hours,
+			"amount":         booking.TotalAmount,
+			"payment_id":     paymentID,
+			"payment_method": "wallet",
+			"balance_after":  balanceAfter,
+		},
+		ServerInfo: bls.getServerInfo(),
+	}
+
+	if err := bls.saveLog(log); err != nil {
+		fmt.Printf("保存订单支付日志失败: %v\n", err)
+	}
+}
+
+// LogBookingRefund 记录订单取消退款日志
+func (bls *BookingLogService) LogBookingRefund(booking *app_model.RoomBooking, roomName string, oldStatus int, refund *inout.BookingRefundQuote, operatorID *int) {
+	newStatus := booking.Status
+
+	details := map[string]interface{}{
+		"start_time":        booking.StartTime,
+		"end_time":          booking.EndTime,
+		"paid_amount":       refund.PaidAmount,
+		"refund_amount":     refund.RefundAmount,
+		"fee_amount":        refund.FeeAmount,
+		"refund_rule":       refund.RefundRule,
+		"policy_id":         refund.PolicyID,
+		"policy_name":       refund.PolicyName,
+		"policy_scope":      refund.PolicyScope,
+		"free_cancel_hours": refund.FreeCancelHours,
+		"fee_percent":       refund.FeePercent,
+		"hours_before":      refund.HoursBeforeStart,
+	}
+	if operatorID != nil {
+		details["operator_id"] = *operatorID
+	}
+
+	log := &app_model.BookingStatusLog{
+		LogType:   app_model.LogTypeBookingRefund,
+		BookingID: &booking.ID,
+		BookingNo: booking.BookingNo,
+		RoomID:    &booking.RoomID,
+		RoomName:  roomName,
+		UserID:    &booking.UserID,
+		OldStatus: &oldStatus,
+		NewStatus: &newStatus,
+		Message: fmt.Sprintf("订单已取消: %s (退款: %.2f, 手续费: %.2f, 政策: %s)",
+			booking.BookingNo, refund.RefundAmount, refund.FeeAmount, refund.PolicyName),
+		CreatedAt:  utils.GetCurrentTimeForMongo(),
+		Details:    details,
+		ServerInfo: bls.getServerInfo(),
+	}
+
+	if err := bls.saveLog(log); err != nil {
+		fmt.Printf("保存订单退款日志失败: %v\n", err)
+	}
+}
+
+// LogCheckOut 记录前台退房结算日志
+func (bls *BookingLogService) LogCheckOut(booking *app_model.RoomBooking, roomName string, operatorID int, usage *app_model.RoomUsageLog) {
+	oldStatus := app_model.BookingStatusInUse
+	newStatus := app_model.BookingStatusCompleted
+
+	log := &app_model.BookingStatusLog{
+		LogType:   app_model.LogTypeCheckOut,
+		BookingID: &booking.ID,
+		BookingNo: booking.BookingNo,
+		RoomID:    &booking.RoomID,
+		RoomName:  roomName,
+		UserID:    &booking.UserID,
+		OldStatus: &oldStatus,
+		NewStatus: &newStatus,
+		Message: fmt.Sprintf("退房结算: %s (超时: %d分钟, 额外费用: %.2f, 管理员ID: %d)",
+			booking.BookingNo, usage.OvertimeMin, usage.ExtraFee, operatorID),
+		CreatedAt: utils.GetCurrentTimeForMongo(),
+		Details: map[string]interface{}{
+			"operator_id":   operatorID,
+			"start_time":    booking.StartTime,
+			"end_time":      booking.EndTime,
+			"check_in_at":   usage.CheckInAt,
+			"check_out_at":  usage.CheckOutAt,
+			"planned_hours": booking.Hours,
+			"actual_hours":  usage.ActualHours,
+			"overtime_min":  usage.OvertimeMin,
+			"overtime_fee":  usage.OvertimeFee,
+			"extra_fee":     usage.ExtraFee,
+			"fee_status":    usage.FeeStatus,
+		},
+		ServerInfo: bls.getServerInfo(),
+	}
+
+	if err := bls.saveLog(log); err != nil {
+		fmt.Printf("保存退房结算日志失败: %v\n", err)
+	}
+}
+
+// LogBookingExtend 记录订单续时日志
+func (bls *BookingLogService) LogBookingExtend(booking *app_model.RoomBooking, roomName string, resp *inout.ExtendBookingResp, operatorID *int) {
+	details := map[string]interface{}{
+		"end_time":      resp.EndTime,
+		"hours":         resp.Hours,
+		"extend_hours":  resp.ExtendHours,
+		"extend_amount": resp.ExtendAmount,
+		"payment_id":    resp.PaymentID,
+		"balance_after": resp.BalanceAfter,
+	}
+	if operatorID != nil {
+		details["operator_id"] = *operatorID
+	}
+
+	log := &app_model.BookingStatusLog{
+		LogType:   app_model.LogTypeBookingExtend,
+		BookingID: &booking.ID,
+		BookingNo: booking.BookingNo,
+		RoomID:    &booking.RoomID,
+		RoomName:  roomName,
+		UserID:    &booking.UserID,
+		OldStatus: &booking.Status,
+		NewStatus: &booking.Status,
+		Message: fmt.Sprintf("订单续时: %s (续时: %d小时, 金额: %.2f)",
+			booking.BookingNo, resp.ExtendHours, resp.ExtendAmount),
+		CreatedAt:  utils.GetCurrentTimeForMongo(),
+		Details:    details,
+		ServerInfo: bls.getServerInfo(),
+	}
+
+	if err := bls.saveLog(log); err != nil {
+		fmt.Printf("保存订单续时日志失败: %v\n", err)
+	}
+}
+
+// LogBookingRebook 记录因维护/停业改订房间日志，booking 为改订后的预订
+func (bls *BookingLogService) LogBookingRebook(booking *app_model.RoomBooking, fromRoom, toRoom *app_model.Room, blackout *app_model.RoomBlackout, operatorID *int) {
+	details := map[string]interface{}{
+		"start_time":     booking.StartTime,
+		"end_time":       booking.EndTime,
+		"from_room_id":   fromRoom.ID,
+		"from_room_name": fromRoom.RoomName,
+		"to_room_id":     toRoom.ID,
+		"to_room_name":   toRoom.RoomName,
+		"blackout_id":    blackout.ID,
+		"blackout_type":  blackout.Type,
+		"reason":         blackout.Reason,
+	}
+	if operatorID != nil {
+		details["operator_id"] = *operatorID
+	}
+
+	log := &app_model.BookingStatusLog{
+		LogType:   app_model.LogTypeBookingRebook,
+		BookingID: &booking.ID,
+		BookingNo: booking.BookingNo,
+		RoomID:    &booking.RoomID,
+		RoomName:  toRoom.RoomName,
+		UserID:    &booking.UserID,
+		OldStatus: &booking.Status,
+		NewStatus: &booking.Status,
+		Message: fmt.Sprintf("订单改订房间: %s (%s -> %s, 原因: %s)",
+			booking.BookingNo, fromRoom.RoomName, toRoom.RoomName, blackout.Reason),
+		CreatedAt:  utils.GetCurrentTimeForMongo(),
+		Details:    details,
+		ServerInfo: bls.getServerInfo(),
+	}
+
+	if err := bls.saveLog(log); err != nil {
+		fmt.Printf("保存订单改订日志失败: %v\n", err)
+	}
+}
+
+// LogAdminCreateBooking 记录前台代客预订日志
+func (bls *BookingLogService) LogAdminCreateBooking(booking *app_model.RoomBooking, roomName string, payMethod string, operatorID int) {
+	newStatus := booking.Status
+
+	log := &app_model.BookingStatusLog{
+		LogType:   app_model.LogTypeAdminCreate,
+		BookingID: &booking.ID,
+		BookingNo: booking.BookingNo,
+		RoomID:    &booking.RoomID,
+		RoomName:  roomName,
+		UserID:    &booking.UserID,
+		NewStatus: &newStatus,
+		Message: fmt.Sprintf("前台代客预订: %s (用户ID: %d, 金额: %.2f, 管理员ID: %d)",
+			booking.BookingNo, booking.UserID, booking.TotalAmount, operatorID),
+		CreatedAt: utils.GetCurrentTimeForMongo(),
+		Details: map[string]interface{}{
+			"operator_id":    operatorID,
+			"start_time":     booking.StartTime,
+			"end_time":       booking.EndTime,
+			"hours":          booking.Hours,
+			"amount":         booking.TotalAmount,
+			"paid_amount":    booking.PaidAmount,
+			"payment_method": payMethod,
+			"contact_name":   booking.ContactName,
+			"contact_phone":  booking.ContactPhone,
+		},
+		ServerInfo: bls.getServerInfo(),
+	}
+
+	if err := bls.saveLog(log); err != nil {
+		fmt.Printf("保存前台代客预订日志失败: %v\n", err)
+	}
+}
+
+// LogBookingModify 记录前台修改预订时间或房间日志，before 为修改前的预订
+func (bls *BookingLogService) LogBookingModify(before, booking *app_model.RoomBooking, resp *inout.AdminBookingResp, operatorID int) {
+	log := &app_model.BookingStatusLog{
+		LogType:   app_model.LogTypeBookingModify,
+		BookingID: &booking.ID,
+		BookingNo: booking.BookingNo,
+		RoomID:    &booking.RoomID,
+		RoomName:  resp.RoomName,
+		UserID:    &booking.UserID,
+		OldStatus: &before.Status,
+		NewStatus: &booking.Status,
+		Message: fmt.Sprintf("修改预订: %s (差价: %.2f, 管理员ID: %d)",
+			booking.BookingNo, resp.DiffAmount, operatorID),
+		CreatedAt: utils.GetCurrentTimeForMongo(),
+		Details: map[string]interface{}{
+			"operator_id":     operatorID,
+			"from_room_id":    before.RoomID,
+			"from_room_name":  resp.FromRoomName,
+			"from_start_time": before.StartTime,
+			"from_end_time":   before.EndTime,
+			"from_hours":      before.Hours,
+			"from_amount":     before.TotalAmount,
+			"to_room_id":      booking.RoomID,
+			"to_room_name":    resp.RoomName,
+			"start_time":      booking.StartTime,
+			"end_time":        booking.EndTime,
+			"hours":           booking.Hours,
+			"amount":          booking.TotalAmount,
+			"diff_amount":     resp.DiffAmount,
+			"settle_method":   resp.SettleMethod,
+			"payment_id":      resp.PaymentID,
+		},
+		ServerInfo: bls.getServerInfo(),
+	}
+
+	if err := bls.saveLog(log); err != nil {
+		fmt.Printf("保存修改预订日志失败: %v\n", err)
+	}
+}
+
+// LogRoomTransfer 记录使用中换房日志
+func (bls *BookingLogService) LogRoomTransfer(booking *app_model.RoomBooking, resp *inout.AdminBookingResp, transferAt time.Time, operatorID int) {
+	log := &app_model.BookingStatusLog{
+		LogType:   app_model.LogTypeRoomTransfer,
+		BookingID: &booking.ID,
+		BookingNo: booking.BookingNo,
+		RoomID:    &booking.RoomID,
+		RoomName:  resp.RoomName,
+		UserID:    &booking.UserID,
+		OldStatus: &booking.Status,
+		NewStatus: &booking.Status,
+		Message: fmt.Sprintf("使用中换房: %s (%s -> %s, 差价: %.2f, 管理员ID: %d)",
+			booking.BookingNo, resp.FromRoomName, resp.RoomName, resp.DiffAmount, operatorID),
+		CreatedAt: utils.GetCurrentTimeForMongo(),
+		Details: map[string]interface{}{
+			"operator_id":    operatorID,
+			"transfer_at":    transferAt,
+			"end_time":       booking.EndTime,
+			"from_room_id":   resp.FromRoomID,
+			"from_room_name": resp.FromRoomName,
+			"to_room_id":     resp.RoomID,
+			"to_room_name":   resp.RoomName,
+			"diff_amount":    resp.DiffAmount,
+			"settle_method":  resp.SettleMethod,
+			"payment_id":     resp.PaymentID,
+		},
+		ServerInfo: bls.getServerInfo(),
+	}
+
+	if err := bls.saveLog(log); err != nil {
+		fmt.Printf("保存换房日志失败: %v\n", err)
+	}
+}
+
+// LogBookingVerify 记录入场核验日志，核验失败时 booking 可能为空
+func (bls *BookingLogService) LogBookingVerify(booking *app_model.RoomBooking, roomID int, operatorID int, method string, verifyErr error) {
+	details := map[string]interface{}{
+		"operator_id": operatorID,
+		"method":      method,
+		"success":     verifyErr == nil,
+	}
+
+	log := &app_model.BookingStatusLog{
+		LogType:    app_model.LogTypeBookingVerify,
+		RoomID:     &roomID,
+		CreatedAt:  utils.GetCurrentTimeForMongo(),
+		Details:    details,
+		ServerInfo: bls.getServerInfo(),
+	}
+	if booking != nil {
+		log.BookingID = &booking.ID
+		log.BookingNo = booking.BookingNo
+		log.UserID = &booking.UserID
+		log.NewStatus = &booking.Status
+		if booking.Room != nil {
+			log.RoomName = booking.Room.RoomName
+		}
+		details["booking_room_id"] = booking.RoomID
+		details["start_time"] = booking.StartTime
+		details["end_time"] = booking.EndTime
+	}
+
+	if verifyErr != nil {
+		log.Message = fmt.Sprintf("入场核验失败 (房间ID: %d, 方式: %s, 管理员ID: %d)", roomID, method, operatorID)
+		log.ErrorMsg = verifyErr.Error()
+	} else {
+		log.Message = fmt.Sprintf("入场核验通过: %s (方式: %s, 管理员ID: %d)", booking.BookingNo, method, operatorID)
+	}
+
+	if err := bls.saveLog(log); err != nil {
+		fmt.Printf("保存入场核验日志失败: %v\n", err)
+	}
+}
+
 // LogUsageError 记录使用记录错误日志
 func (bls *BookingLogService) LogUsageError(bookingID int, bookingNo string, operation string, err error) {
 	log := &app_model.BookingStatusLog{
@@ -0,0 +1,427 @@
+package app_service
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"nasa-go-admin/db"
+	"nasa-go-admin/model/admin_model"
+	"nasa-go-admin/model/app_model"
+	"nasa-go-admin/model/miniapp_model"
+	"nasa-go-admin/services/admin_service"
+	"nasa-go-admin/services/miniapp_service"
+	"nasa-go-admin/services/public_service"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// maxPaymentReminderLead 超时自动取消前最早提醒支付的时间，支付时限较短时在剩余一半时提醒
+	maxPaymentReminderLead = time.Hour
+	// startReminderLead 开始前多久发送即将开始提醒
+	startReminderLead = 30 * time.Minute
+	// endingReminderLead 结束前多久发送即将结束提醒
+	endingReminderLead = 15 * time.Minute
+)
+
+// 推送渠道
+const (
+	noticeChannelWebSocket = "websocket"
+	noticeChannelWechat    = "wechat"
+)
+
+// bookingNoticeSpec 预订通知类型配置
+type bookingNoticeSpec struct {
+	Event  string // 订阅消息业务事件
+	WsType public_service.NotificationType
+}
+
+var bookingNoticeSpecs = map[string]bookingNoticeSpec{
+	app_model.BookingNoticePaymentPending: {miniapp_model.EventBookingPaymentPending, public_service.BookingPaymentPending},
+	app_model.BookingNoticeStartSoon:      {miniapp_model.EventBookingStartSoon, public_service.BookingStartSoon},
+	app_model.BookingNoticeEndingSoon:     {miniapp_model.EventBookingEndingSoon, public_service.BookingEndingSoon},
+	app_model.BookingNoticeCompleted:      {miniapp_model.EventBookingCompleted, public_service.BookingCompleted},
+	app_model.BookingNoticeWaitlistOffer:  {miniapp_model.EventBookingWaitlistOffer, public_service.BookingWaitlistOffer},
+	app_model.BookingNoticeRebooked:       {miniapp_model.EventBookingRebooked, public_service.BookingRebooked},
+	app_model.BookingNoticeVenueCancelled: {miniapp_model.EventBookingVenueCancelled, public_service.BookingVenueCancelled},
+}
+
+// bookingNoticeEvents 订阅消息业务事件对应的预订通知类型
+var bookingNoticeEvents = map[string]string{
+	miniapp_model.EventBookingPaymentPending: app_model.BookingNoticePaymentPending,
+	miniapp_model.EventBookingStartSoon:      app_model.BookingNoticeStartSoon,
+	miniapp_model.EventBookingEndingSoon:     app_model.BookingNoticeEndingSoon,
+	miniapp_model.EventBookingCompleted:      app_model.BookingNoticeCompleted,
+	miniapp_model.EventBookingWaitlistOffer:  app_model.BookingNoticeWaitlistOffer,
+	miniapp_model.EventBookingRebooked:       app_model.BookingNoticeRebooked,
+	miniapp_model.EventBookingVenueCancelled: app_model.BookingNoticeVenueCancelled,
+}
+
+// bookingNoticeMessage 一条预订通知的推送内容
+type bookingNoticeMessage struct {
+	Content string                 // 站内通知文本
+	Data    map[string]interface{} // 站内通知附带数据
+	Payload map[string]interface{} // 订阅消息事件数据
+}
+
+// BookingNotificationService 预订提醒和生命周期通知
+// 用户订阅对应模板后才会推送，每个预订的同类通知只发送一次，发送结果记录为推送记录
+type BookingNotificationService struct{}
+
+// NewBookingNotificationService 创建预订通知服务
+func NewBookingNotificationService() *BookingNotificationService {
+	return &BookingNotificationService{}
+}
+
+// ProcessReminders 扫描需要提醒的预订：待支付、即将开始、即将结束
+func (bns *BookingNotificationService) ProcessReminders(now time.Time) {
+	// 待支付：即将超过支付时限
+	window := BookingPaymentWindow()
+	lead := window / 2
+	if lead > maxPaymentReminderLead {
+		lead = maxPaymentReminderLead
+	}
+	bns.remindBookings(app_model.BookingNoticePaymentPending,
+		"status = ? AND create_time <= ? AND create_time > ?",
+		app_model.BookingStatusPending, now.Add(-(window - lead)), now.Add(-window))
+
+	// 即将开始：已支付且30分钟内开始
+	bns.remindBookings(app_model.BookingNoticeStartSoon,
+		"status = ? AND start_time > ? AND start_time <= ?",
+		app_model.BookingStatusPaid, now, now.Add(startReminderLead))
+
+	// 即将结束：使用中且15分钟内结束
+	bns.remindBookings(app_model.BookingNoticeEndingSoon,
+		"status = ? AND end_time > ? AND end_time <= ?",
+		app_model.BookingStatusInUse, now, now.Add(endingReminderLead))
+}
+
+// NotifyBookingCompleted 发送预订完成及消费回执，由自动完成和前台退房调用
+func (bns *BookingNotificationService) NotifyBookingCompleted(bookingID int) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("发送预订完成通知时发生panic: %v", r)
+		}
+	}()
+
+	var booking app_model.RoomBooking
+	if err := db.Dao.First(&booking, bookingID).Error; err != nil {
+		log.Printf("查询预订失败 (ID: %d): %v", bookingID, err)
+		return
+	}
+	if booking.Status != app_model.BookingStatusCompleted {
+		return
+	}
+	bns.notify(&booking, app_model.BookingNoticeCompleted, nil)
+}
+
+// NotifyWaitlistOffer 通知候补用户时段已保留，需在支付时限内支付确认
+func (bns *BookingNotificationService) NotifyWaitlistOffer(bookingID int) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("发送候补保留通知时发生panic: %v", r)
+		}
+	}()
+
+	var booking app_model.RoomBooking
+	if err := db.Dao.First(&booking, bookingID).Error; err != nil {
+		log.Printf("查询预订失败 (ID: %d): %v", bookingID, err)
+		return
+	}
+	if booking.Status != app_model.BookingStatusPending {
+		return
+	}
+	bns.notify(&booking, app_model.BookingNoticeWaitlistOffer, nil)
+}
+
+// NotifyBookingRebooked 通知用户预订因原房间维护/停业已调换到其他房间
+func (bns *BookingNotificationService) NotifyBookingRebooked(bookingID int, fromRoomName, reason string) {
+	bns.notifyBookingDisrupted(bookingID, app_model.BookingNoticeRebooked, map[string]interface{}{
+		"from_room_name": fromRoomName,
+		"reason":         reason,
+	})
+}
+
+// NotifyBookingVenueCancelled 通知用户预订因房间维护/停业已取消并全额退款
+func (bns *BookingNotificationService) NotifyBookingVenueCancelled(bookingID int, reason string) {
+	bns.notifyBookingDisrupted(bookingID, app_model.BookingNoticeVenueCancelled, map[string]interface{}{
+		"reason": reason,
+	})
+}
+
+// notifyBookingDisrupted 发送维护/停业处理结果通知，extra 为通知附带的原因等信息
+func (bns *BookingNotificationService) notifyBookingDisrupted(bookingID int, noticeType string, extra map[string]interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("发送预订变更通知时发生panic: %v", r)
+		}
+	}()
+
+	var booking app_model.RoomBooking
+	if err := db.Dao.First(&booking, bookingID).Error; err != nil {
+		log.Printf("查询预订失败 (ID: %d): %v", bookingID, err)
+		return
+	}
+	bns.notify(&booking, noticeType, extra)
+}
+
+// remindBookings 查询符合条件且未发送过该类通知的预订并逐个推送
+func (bns *BookingNotificationService) remindBookings(noticeType string, query string, args ...interface{}) {
+	sent := db.Dao.Model(&app_model.BookingNotification{}).
+		Select("booking_id").Where("notice_type = ?", noticeType)
+
+	var bookings []app_model.RoomBooking
+	if err := db.Dao.Where(query, args...).Where("id NOT IN (?)", sent).
+		Find(&bookings).Error; err != nil {
+		log.Printf("查询待提醒预订失败 (%s): %v", noticeType, err)
+		return
+	}
+
+	for i := range bookings {
+		bns.notify(&bookings[i], noticeType, nil)
+	}
+}
+
+// notify 占用发送记录后按用户订阅推送站内通知和订阅消息，并记录推送结果
+func (bns *BookingNotificationService) notify(booking *app_model.RoomBooking, noticeType string, extra map[string]interface{}) {
+	spec, ok := bookingNoticeSpecs[noticeType]
+	if !ok || booking.UserID == 0 {
+		// 散客预订没有小程序用户，不推送通知
+		return
+	}
+
+	// 唯一索引去重，多实例或重复触发时只有一次能占用成功
+	record := app_model.BookingNotification{
+		BookingID:  booking.ID,
+		NoticeType: noticeType,
+		UserID:     booking.UserID,
+		Status:     app_model.BookingNoticeStatusSending,
+	}
+	result := db.Dao.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+	if result.Error != nil {
+		log.Printf("创建预订通知记录失败 (预订: %s, 类型: %s): %v", booking.BookingNo, noticeType, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		return
+	}
+
+	var user app_model.UserApp
+	if err := db.Dao.Where("id = ?", booking.UserID).First(&user).Error; err != nil {
+		bns.finish(&record, app_model.BookingNoticeStatusFailed, nil, "", fmt.Sprintf("查询用户失败: %v", err))
+		return
+	}
+
+	tpl, err := miniapp_service.NewSubscribeTemplateService().GetEventTemplate(spec.Event)
+	if err != nil {
+		bns.finish(&record, app_model.BookingNoticeStatusSkipped, nil, "", err.Error())
+		return
+	}
+	if !miniapp_service.IsUserSubscribed(user.Openid, tpl.TemplateID) {
+		bns.finish(&record, app_model.BookingNoticeStatusSkipped, nil, "", miniapp_service.ErrUserNotSubscribed.Error())
+		return
+	}
+
+	msg := bns.buildMessage(booking, noticeType, extra)
+	messageID := fmt.Sprintf("%d-%s", time.Now().UnixNano(), uuid.New().String()[:8])
+
+	channels := make([]string, 0, 2)
+	errs := make([]string, 0, 2)
+
+	if wsService := public_service.GetWebSocketService(); wsService != nil {
+		if err := wsService.SendUserNotification(booking.UserID, spec.WsType, msg.Content, msg.Data); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", noticeChannelWebSocket, err))
+		} else {
+			channels = append(channels, noticeChannelWebSocket)
+		}
+	}
+
+	if _, err := miniapp_service.NewSubscribeTemplateService().SendEvent(user.Openid, spec.Event, msg.Payload); err != nil {
+		errs = append(errs, fmt.Sprintf("%s: %v", noticeChannelWechat, err))
+	} else {
+		channels = append(channels, noticeChannelWechat)
+	}
+
+	status := app_model.BookingNoticeStatusSent
+	if len(channels) == 0 {
+		status = app_model.BookingNoticeStatusFailed
+	}
+	errMsg := strings.Join(errs, "; ")
+
+	bns.savePushRecord(booking, noticeType, spec, tpl.TemplateID, msg, messageID, channels, errMsg)
+	bns.finish(&record, status, channels, messageID, errMsg)
+
+	log.Printf("预订通知已处理: %s (类型: %s, 渠道: %v, 结果: %s)", booking.BookingNo, noticeType, channels, status)
+}
+
+// finish 更新预订通知发送结果
+func (bns *BookingNotificationService) finish(record *app_model.BookingNotification, status string, channels []string, messageID, errMsg string) {
+	if err := db.Dao.Model(record).Updates(map[string]interface{}{
+		"status":     status,
+		"channels":   strings.Join(channels, ","),
+		"message_id": messageID,
+		"error_msg":  errMsg,
+	}).Error; err != nil {
+		log.Printf("更新预订通知记录失败 (ID: %d): %v", record.ID, err)
+	}
+}
+
+// savePushRecord 将推送结果写入推送记录
+func (bns *BookingNotificationService) savePushRecord(booking *app_model.RoomBooking, noticeType string, spec bookingNoticeSpec,
+	templateID string, msg *bookingNoticeMessage, messageID string, channels []string, errMsg string) {
+	now := time.Now().Format("2006-01-02 15:04:05")
+	record := &admin_model.PushRecord{
+		MessageID:       messageID,
+		Content:         msg.Content,
+		MessageType:     string(spec.WsType),
+		Target:          "指定用户",
+		TargetUserIDs:   []int{booking.UserID},
+		RecipientsCount: "1人",
+		Status:          "delivered",
+		Success:         len(channels) > 0,
+		PushTime:        now,
+		SenderID:        0,
+		SenderName:      "预订通知",
+		TotalCount:      1,
+		Priority:        int(public_service.PriorityNormal),
+		ExtraData: map[string]interface{}{
+			"booking_id":  booking.ID,
+			"booking_no":  booking.BookingNo,
+			"notice_type": noticeType,
+			"template_id": templateID,
+			"channels":    channels,
+		},
+	}
+	if len(channels) > 0 {
+		record.DeliveredCount = 1
+	} else {
+		record.Status = "failed"
+		record.FailedCount = 1
+		record.Error = errMsg
+		record.ErrorCode = "PUSH_FAILED"
+	}
+
+	if err := admin_service.NewNotificationRecordService().SavePushRecord(record); err != nil {
+		log.Printf("保存预订推送记录失败: %v", err)
+	}
+}
+
+// buildMessage 按通知类型组装站内通知内容和订阅消息事件数据，extra 同时写入站内通知数据和 notice 对象
+func (bns *BookingNotificationService) buildMessage(booking *app_model.RoomBooking, noticeType string, extra map[string]interface{}) *bookingNoticeMessage {
+	var room app_model.Room
+	if err := db.Dao.First(&room, booking.RoomID).Error; err != nil {
+		log.Printf("查询房间信息失败 (房间ID: %d): %v", booking.RoomID, err)
+	}
+
+	timeRange := fmt.Sprintf("%s~%s", booking.StartTime.Format("01-02 15:04"), booking.EndTime.Format("15:04"))
+	data := map[string]interface{}{
+		"booking_id": booking.ID,
+		"booking_no": booking.BookingNo,
+		"room_id":    booking.RoomID,
+		"room_name":  room.RoomName,
+		"start_time": booking.StartTime.Format("2006-01-02 15:04:05"),
+		"end_time":   booking.EndTime.Format("2006-01-02 15:04:05"),
+	}
+	msg := &bookingNoticeMessage{Data: data}
+	notice := map[string]interface{}{}
+	for k, v := range extra {
+		data[k] = v
+		notice[k] = v
+	}
+	objects := map[string]interface{}{
+		"booking": booking,
+		"room":    &room,
+		"notice":  notice,
+	}
+
+	switch noticeType {
+	case app_model.BookingNoticePaymentPending:
+		deadline := booking.CreateTime.Add(BookingPaymentWindow())
+		data["amount"] = booking.TotalAmount
+		data["pay_deadline"] = deadline.Format("2006-01-02 15:04:05")
+		notice["pay_deadline"] = deadline
+		notice["tip"] = "逾期未支付将自动取消"
+		msg.Content = fmt.Sprintf("您预订的%s(%s)尚未支付，请在%s前完成支付，逾期将自动取消",
+			room.RoomName, timeRange, deadline.Format("15:04"))
+	case app_model.BookingNoticeWaitlistOffer:
+		deadline := booking.CreateTime.Add(BookingPaymentWindow())
+		data["amount"] = booking.TotalAmount
+		data["pay_deadline"] = deadline.Format("2006-01-02 15:04:05")
+		notice["pay_deadline"] = deadline
+		notice["tip"] = "候补成功，逾期未支付将让给下一位"
+		msg.Content = fmt.Sprintf("您候补的%s(%s)有空位了，已为您保留至%s，请及时支付确认，逾期将让给下一位候补用户",
+			room.RoomName, timeRange, deadline.Format("15:04"))
+	case app_model.BookingNoticeRebooked:
+		fromRoom, _ := extra["from_room_name"].(string)
+		reason, _ := extra["reason"].(string)
+		notice["tip"] = "时间和价格不变，请前往新房间"
+		msg.Content = fmt.Sprintf("您预订的%s(%s)因%s已为您调换至%s，时间和价格不变",
+			fromRoom, timeRange, reason, room.RoomName)
+	case app_model.BookingNoticeVenueCancelled:
+		reason, _ := extra["reason"].(string)
+		data["refund_amount"] = booking.PaidAmount
+		notice["refund_amount"] = booking.PaidAmount
+		msg.Content = fmt.Sprintf("很抱歉，您预订的%s(%s)因%s无法使用，预订已取消", room.RoomName, timeRange, reason)
+		if booking.PaidAmount > 0 {
+			notice["tip"] = "已全额退款至钱包"
+			msg.Content += fmt.Sprintf("，已支付的%.2f元已全额退回钱包", booking.PaidAmount)
+		} else {
+			notice["tip"] = "预订已取消，欢迎改约其他时间"
+		}
+	case app_model.BookingNoticeStartSoon:
+		data["verify_code"] = booking.VerifyCode
+		notice["verify_code"] = booking.VerifyCode
+		notice["tip"] = "请准时到店，出示入场码核验"
+		msg.Content = fmt.Sprintf("您预订的%s将于%s开始，请准时到店", room.RoomName, booking.StartTime.Format("15:04"))
+	case app_model.BookingNoticeEndingSoon:
+		canExtend := bns.canExtend(booking, &room)
+		data["can_extend"] = canExtend
+		notice["can_extend"] = canExtend
+		if canExtend {
+			notice["tip"] = "如需继续使用可在线续时"
+			msg.Content = fmt.Sprintf("您在%s的使用将于%s结束，如需继续使用可在小程序内续时", room.RoomName, booking.EndTime.Format("15:04"))
+		} else {
+			notice["tip"] = "后续时段已被预订，请按时离场"
+			msg.Content = fmt.Sprintf("您在%s的使用将于%s结束，后续时段已被预订，请按时离场", room.RoomName, booking.EndTime.Format("15:04"))
+		}
+	case app_model.BookingNoticeCompleted:
+		var usage app_model.RoomUsageLog
+		if err := db.Dao.Where("booking_id = ?", booking.ID).First(&usage).Error; err == nil {
+			data["actual_hours"] = usage.ActualHours
+			data["overtime_min"] = usage.OvertimeMin
+			data["extra_fee"] = usage.ExtraFee
+			data["fee_status"] = usage.FeeStatus
+			objects["usage"] = &usage
+		}
+		data["total_amount"] = booking.TotalAmount
+		data["paid_amount"] = booking.PaidAmount
+		msg.Content = fmt.Sprintf("您在%s的预订已完成，实付%.2f元，感谢使用", room.RoomName, booking.PaidAmount)
+		if usage.FeeStatus == app_model.UsageFeeStatusReceivable {
+			notice["tip"] = "有额外费用待结算"
+			msg.Content += fmt.Sprintf("，另有额外费用%.2f元待结算", usage.ExtraFee)
+		} else {
+			notice["tip"] = "感谢使用，欢迎再次预订"
+		}
+	}
+
+	msg.Payload = miniapp_service.EventPayload(objects)
+	return msg
+}
+
+// canExtend 预订结束后1小时（含清洁缓冲）内房间没有其他有效预订时可以续时
+func (bns *BookingNotificationService) canExtend(booking *app_model.RoomBooking, room *app_model.Room) bool {
+	buffer := time.Duration(room.CleaningMin) * time.Minute
+	var conflicts int64
+	if err := db.Dao.Model(&app_model.RoomBooking{}).Scopes(blockingBookings(time.Now())).
+		Where("room_id = ? AND id != ? AND start_time < ? AND end_time > ?",
+			booking.RoomID, booking.ID,
+			booking.EndTime.Add(time.Hour+buffer), booking.EndTime).
+		Count(&conflicts).Error; err != nil {
+		log.Printf("检查续时可用性失败 (预订: %s): %v", booking.BookingNo, err)
+		return false
+	}
+	return conflicts == 0
+}
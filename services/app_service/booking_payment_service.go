@@ -0,0 +1,165 @@
+package app_service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"nasa-go-admin/db"
+	"nasa-go-admin/inout"
+	"nasa-go-admin/model/app_model"
+	"nasa-go-admin/redis"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BookingPaymentService 房间预订支付服务 - 使用钱包余额支付预订
+type BookingPaymentService struct {
+	logService *BookingLogService
+}
+
+// NewBookingPaymentService 创建预订支付服务
+func NewBookingPaymentService() *BookingPaymentService {
+	return &BookingPaymentService{
+		logService: &BookingLogService{},
+	}
+}
+
+// PayBooking 使用钱包余额支付预订
+func (bps *BookingPaymentService) PayBooking(req *inout.PayBookingReq, userID int) (*inout.PayBookingResp, error) {
+	securityService := NewSecurityOrderService(redis.GetClient())
+
+	// 1. 预订级别锁 - 防止重复支付
+	payLock := securityService.NewDistributedLock(
+		fmt.Sprintf("booking_pay:%d", req.BookingID),
+		30*time.Second,
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := payLock.AcquireWithRenewal(ctx); err != nil {
+		return nil, fmt.Errorf("支付处理中，请稍后再试: %w", err)
+	}
+	defer payLock.Release()
+
+	// 2. 开启事务
+	tx := db.Dao.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			log.Printf("支付预订时发生panic: %v", r)
+			panic(r)
+		}
+	}()
+
+	// 3. 锁定预订记录并校验
+	var booking app_model.RoomBooking
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND user_id = ?", req.BookingID, userID).
+		First(&booking).Error; err != nil {
+		tx.Rollback()
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("预订不存在")
+		}
+		return nil, fmt.Errorf("查询预订失败: %w", err)
+	}
+
+	if booking.Status != app_model.BookingStatusPending {
+		tx.Rollback()
+		return nil, fmt.Errorf("当前预订状态为%s，无法支付", booking.GetBookingStatusText())
+	}
+
+	if !time.Now().Before(booking.EndTime) {
+		tx.Rollback()
+		return nil, fmt.Errorf("预订时间已过，无法支付")
+	}
+
+	// 超过支付时限的待支付预订已释放时段，等待调度器取消
+	now := time.Now()
+	if !now.Before(booking.CreateTime.Add(BookingPaymentWindow())) {
+		tx.Rollback()
+		return nil, fmt.Errorf("预订已超过支付时限，请重新预订")
+	}
+
+	// 兜底检查时段未被其他有效预订、清洁缓冲或维护/停业时段占用，与新建预订在房间行锁上串行
+	var room app_model.Room
+	if err := lockRoomAndCheckOverlap(tx, &room, booking.RoomID, booking.StartTime, booking.EndTime, booking.ID); err != nil {
+		tx.Rollback()
+		if reason := slotConflictReason(err); reason != "" {
+			return nil, fmt.Errorf("%s，请重新选择时间", reason)
+		}
+		return nil, err
+	}
+
+	amount := booking.TotalAmount
+
+	// 4. 安全扣减钱包余额
+	walletAfterDeduct, err := securityService.SafeDeductWallet(tx, userID, amount)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// 5. 记录钱包交易流水
+	balanceBefore := walletAfterDeduct.Money + amount
+	payment, err := securityService.RecordWalletTransactionWithType(tx, userID,
+		app_model.TransactionTypeBookingPayment, booking.BookingNo,
+		amount, balanceBefore, walletAfterDeduct.Money, "房间预订支付")
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// 6. 原子性更新预订状态为已支付
+	result := tx.Model(&app_model.RoomBooking{}).
+		Where("id = ? AND status = ?", booking.ID, app_model.BookingStatusPending).
+		Updates(map[string]interface{}{
+			"status":      app_model.BookingStatusPaid,
+			"paid_amount": amount,
+			"payment_id":  payment.ID,
+		})
+	if result.Error != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("更新预订状态失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return nil, fmt.Errorf("预订状态已变更，请刷新后重试")
+	}
+
+	// 分配入场核验码
+	if err := NewBookingEntryService().AssignVerifyCode(tx, &booking); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// 7. 提交事务
+	if err := tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("提交支付事务失败: %w", err)
+	}
+
+	booking.Status = app_model.BookingStatusPaid
+	booking.PaidAmount = amount
+	booking.PaymentID = &payment.ID
+
+	log.Printf("✅ 预订支付成功: %s (用户ID: %d, 金额: %.2f)", booking.BookingNo, userID, amount)
+
+	// 候补保留的预订支付后确认候补
+	NewBookingWaitlistService().MarkConfirmed(booking.ID)
+
+	// 8. 记录支付日志
+	bps.logService.LogBookingPaid(&booking, room.RoomName, payment.ID, walletAfterDeduct.Money)
+
+	return &inout.PayBookingResp{
+		BookingID:    booking.ID,
+		BookingNo:    booking.BookingNo,
+		Status:       booking.Status,
+		StatusText:   booking.GetBookingStatusText(),
+		PaidAmount:   amount,
+		PaymentID:    payment.ID,
+		BalanceAfter: walletAfterDeduct.Money,
+	}, nil
+}
@@ -0,0 +1,427 @@
+package app_service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"nasa-go-admin/db"
+	"nasa-go-admin/inout"
+	"nasa-go-admin/model/app_model"
+	"nasa-go-admin/redis"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BookingRefundService 预订取消退款服务 - 按取消政策计算退款并退回钱包
+type BookingRefundService struct {
+	logService *BookingLogService
+	ctx        context.Context
+}
+
+// NewBookingRefundService 创建预订取消退款服务
+func NewBookingRefundService() *BookingRefundService {
+	return &BookingRefundService{
+		logService: &BookingLogService{},
+	}
+}
+
+// WithContext 返回绑定请求上下文的退款服务，管理端传入 gin.Context 后按租户隔离数据
+func (brs *BookingRefundService) WithContext(ctx context.Context) *BookingRefundService {
+	return &BookingRefundService{
+		logService: brs.logService,
+		ctx:        ctx,
+	}
+}
+
+// dao 获取数据库连接，带上下文时由 GORM 租户插件追加 tenants_id 条件
+func (brs *BookingRefundService) dao() *gorm.DB {
+	if brs.ctx != nil {
+		return db.Dao.WithContext(brs.ctx)
+	}
+	return db.Dao
+}
+
+// ========== 取消退款 ==========
+
+// CancelBooking 取消预订，已支付的预订按取消政策退款到钱包
+func (brs *BookingRefundService) CancelBooking(req *inout.CancelBookingReq, userID *int, operatorID *int) (*inout.BookingRefundQuote, error) {
+	return brs.cancelBooking(req, userID, operatorID, false)
+}
+
+// CancelBookingFullRefund 因商家原因（如房间维护、停业）取消预订，已支付金额不按取消政策全额退回钱包
+func (brs *BookingRefundService) CancelBookingFullRefund(req *inout.CancelBookingReq, operatorID *int) (*inout.BookingRefundQuote, error) {
+	return brs.cancelBooking(req, nil, operatorID, true)
+}
+
+// cancelBooking 取消预订并退款，fullRefund 为 true 时不收取手续费
+func (brs *BookingRefundService) cancelBooking(req *inout.CancelBookingReq, userID *int, operatorID *int, fullRefund bool) (*inout.BookingRefundQuote, error) {
+	tx := brs.dao().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			log.Printf("取消预订时发生panic: %v", r)
+			panic(r)
+		}
+	}()
+
+	// 锁定预订记录
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"})
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+
+	var booking app_model.RoomBooking
+	if err := query.First(&booking, req.ID).Error; err != nil {
+		tx.Rollback()
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("预订不存在")
+		}
+		return nil, fmt.Errorf("查询预订失败: %v", err)
+	}
+
+	if booking.Status != app_model.BookingStatusPending && booking.Status != app_model.BookingStatusPaid {
+		tx.Rollback()
+		return nil, fmt.Errorf("当前状态无法取消")
+	}
+
+	oldStatus := booking.Status
+	updates := map[string]interface{}{
+		"status": app_model.BookingStatusCancelled,
+	}
+	if req.Reason != "" {
+		updates["remarks"] = booking.Remarks + "\n取消原因: " + req.Reason
+	}
+
+	// 已支付的预订按政策计算退款
+	var quote *inout.BookingRefundQuote
+	if oldStatus == app_model.BookingStatusPaid && booking.PaidAmount > 0 {
+		if fullRefund {
+			quote = brs.fullRefundQuote(&booking, time.Now())
+		} else {
+			var err error
+			quote, err = brs.QuoteRefund(&booking, time.Now())
+			if err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+		}
+
+		if quote.RefundAmount > 0 {
+			updates["status"] = app_model.BookingStatusRefunded
+		}
+		updates["price_breakdown"] = mergeRefundIntoBreakdown(booking.PriceBreakdown, quote)
+	}
+
+	result := tx.Model(&app_model.RoomBooking{}).
+		Where("id = ? AND status = ?", booking.ID, oldStatus).
+		Updates(updates)
+	if result.Error != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("取消预订失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return nil, fmt.Errorf("预订状态已变更，请刷新后重试")
+	}
+
+	// 退回预订时使用的优惠券
+	if err := NewCouponService().ReturnCoupon(tx, app_model.CouponOrderBooking, booking.BookingNo); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// 退回预订时抵扣的积分
+	if err := NewMembershipService().RollbackPoints(tx, app_model.PointsBizBooking, booking.BookingNo); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// 退款入账并记录钱包流水
+	if quote != nil && quote.RefundAmount > 0 {
+		securityService := NewSecurityOrderService(redis.GetClient())
+
+		walletAfterCredit, err := securityService.SafeCreditWallet(tx, booking.UserID, quote.RefundAmount)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		description := fmt.Sprintf("房间预订取消退款[%s]: %s", booking.BookingNo, quote.PolicyName)
+		if _, err := securityService.RecordWalletTransactionWithType(tx, booking.UserID,
+			app_model.TransactionTypeBookingRefund, booking.BookingNo, quote.RefundAmount,
+			walletAfterCredit.Money-quote.RefundAmount, walletAfterCredit.Money, description); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("提交取消事务失败: %v", err)
+	}
+
+	booking.Status = updates["status"].(int)
+	log.Printf("预订已取消: %s (用户ID: %d, 状态: %s)", booking.BookingNo, booking.UserID, booking.GetBookingStatusText())
+
+	// 释放的时段按顺序保留给候补用户
+	go NewBookingWaitlistService().OnBookingReleased(booking.ID)
+
+	if quote != nil {
+		var room app_model.Room
+		if err := brs.dao().Select("id, room_name").First(&room, booking.RoomID).Error; err != nil {
+			log.Printf("查询房间信息失败 (房间ID: %d): %v", booking.RoomID, err)
+		}
+		brs.logService.LogBookingRefund(&booking, room.RoomName, oldStatus, quote, operatorID)
+	}
+
+	return quote, nil
+}
+
+// PreviewRefund 预览取消预订可获得的退款
+func (brs *BookingRefundService) PreviewRefund(bookingID int, userID *int) (*inout.BookingRefundQuote, error) {
+	query := brs.dao()
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+
+	var booking app_model.RoomBooking
+	if err := query.First(&booking, bookingID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("预订不存在")
+		}
+		return nil, fmt.Errorf("查询预订失败: %v", err)
+	}
+
+	if booking.Status != app_model.BookingStatusPending && booking.Status != app_model.BookingStatusPaid {
+		return nil, fmt.Errorf("当前状态无法取消")
+	}
+
+	return brs.QuoteRefund(&booking, time.Now())
+}
+
+// QuoteRefund 按适用的取消政策计算退款
+func (brs *BookingRefundService) QuoteRefund(booking *app_model.RoomBooking, cancelTime time.Time) (*inout.BookingRefundQuote, error) {
+	policy, scope, err := brs.ResolveCancelPolicy(booking.RoomID, booking.PackageID)
+	if err != nil {
+		return nil, err
+	}
+
+	refundAmount, feeAmount, rule := policy.CalculateRefund(booking.PaidAmount, booking.StartTime, cancelTime)
+
+	quote := &inout.BookingRefundQuote{
+		BookingID:        booking.ID,
+		BookingNo:        booking.BookingNo,
+		PaidAmount:       booking.PaidAmount,
+		RefundAmount:     refundAmount,
+		FeeAmount:        feeAmount,
+		RefundRule:       rule,
+		RefundRuleText:   brs.getRefundRuleText(rule),
+		PolicyName:       policy.PolicyName,
+		PolicyScope:      scope,
+		FreeCancelHours:  policy.FreeCancelHours,
+		FeePercent:       policy.FeePercent,
+		HoursBeforeStart: math.Round(booking.StartTime.Sub(cancelTime).Hours()*100) / 100,
+	}
+	if policy.ID > 0 {
+		quote.PolicyID = &policy.ID
+	}
+
+	return quote, nil
+}
+
+// fullRefundQuote 商家原因取消的退款：全额退款，不适用取消政策
+func (brs *BookingRefundService) fullRefundQuote(booking *app_model.RoomBooking, cancelTime time.Time) *inout.BookingRefundQuote {
+	return &inout.BookingRefundQuote{
+		BookingID:        booking.ID,
+		BookingNo:        booking.BookingNo,
+		PaidAmount:       booking.PaidAmount,
+		RefundAmount:     booking.PaidAmount,
+		RefundRule:       app_model.RefundRuleFull,
+		RefundRuleText:   brs.getRefundRuleText(app_model.RefundRuleFull),
+		PolicyName:       "商家原因全额退款",
+		PolicyScope:      app_model.CancelPolicyScopeMerchant,
+		HoursBeforeStart: math.Round(booking.StartTime.Sub(cancelTime).Hours()*100) / 100,
+	}
+}
+
+// ResolveCancelPolicy 查找适用的取消政策：套餐 > 房间 > 全局 > 系统默认
+func (brs *BookingRefundService) ResolveCancelPolicy(roomID int, packageID *int) (*app_model.RoomCancelPolicy, string, error) {
+	query := brs.dao().Where("is_active = 1")
+	if packageID != nil {
+		query = query.Where("package_id = ? OR (room_id = ? AND package_id IS NULL) OR (room_id IS NULL AND package_id IS NULL)",
+			*packageID, roomID)
+	} else {
+		query = query.Where("package_id IS NULL AND (room_id = ? OR room_id IS NULL)", roomID)
+	}
+
+	var policies []app_model.RoomCancelPolicy
+	if err := query.Find(&policies).Error; err != nil {
+		return nil, "", fmt.Errorf("查询取消政策失败: %v", err)
+	}
+
+	policy, scope := app_model.SelectCancelPolicy(policies, roomID, packageID)
+	return policy, scope, nil
+}
+
+// ========== 取消政策管理 ==========
+
+// CreateCancelPolicy 创建取消政策
+func (brs *BookingRefundService) CreateCancelPolicy(req *inout.CreateCancelPolicyReq) (*app_model.RoomCancelPolicy, error) {
+	if err := brs.validatePolicyScope(req.RoomID, req.PackageID); err != nil {
+		return nil, err
+	}
+
+	policy := &app_model.RoomCancelPolicy{
+		PolicyName:      req.PolicyName,
+		RoomID:          req.RoomID,
+		PackageID:       req.PackageID,
+		FreeCancelHours: req.FreeCancelHours,
+		FeePercent:      req.FeePercent,
+		Description:     req.Description,
+		IsActive:        true,
+	}
+
+	if err := brs.dao().Create(policy).Error; err != nil {
+		return nil, fmt.Errorf("创建取消政策失败: %v", err)
+	}
+
+	log.Printf("取消政策已创建: %s (ID: %d)", policy.PolicyName, policy.ID)
+	return policy, nil
+}
+
+// UpdateCancelPolicy 更新取消政策
+func (brs *BookingRefundService) UpdateCancelPolicy(req *inout.UpdateCancelPolicyReq) (*app_model.RoomCancelPolicy, error) {
+	var policy app_model.RoomCancelPolicy
+	if err := brs.dao().First(&policy, req.ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("取消政策不存在")
+		}
+		return nil, fmt.Errorf("查询取消政策失败: %v", err)
+	}
+
+	if err := brs.validatePolicyScope(req.RoomID, req.PackageID); err != nil {
+		return nil, err
+	}
+
+	policy.PolicyName = req.PolicyName
+	policy.RoomID = req.RoomID
+	policy.PackageID = req.PackageID
+	policy.FreeCancelHours = req.FreeCancelHours
+	policy.FeePercent = req.FeePercent
+	policy.Description = req.Description
+	policy.IsActive = req.IsActive
+
+	if err := brs.dao().Save(&policy).Error; err != nil {
+		return nil, fmt.Errorf("更新取消政策失败: %v", err)
+	}
+
+	log.Printf("取消政策已更新: %s (ID: %d)", policy.PolicyName, policy.ID)
+	return &policy, nil
+}
+
+// GetCancelPolicyList 获取取消政策列表
+func (brs *BookingRefundService) GetCancelPolicyList(req *inout.CancelPolicyListReq) (*inout.CancelPolicyListResp, error) {
+	query := brs.dao().Model(&app_model.RoomCancelPolicy{})
+	if req.RoomID > 0 {
+		query = query.Where("room_id = ?", req.RoomID)
+	}
+	if req.PackageID > 0 {
+		query = query.Where("package_id = ?", req.PackageID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("查询取消政策总数失败: %v", err)
+	}
+
+	var policies []app_model.RoomCancelPolicy
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(req.PageSize).Find(&policies).Error; err != nil {
+		return nil, fmt.Errorf("查询取消政策列表失败: %v", err)
+	}
+
+	return &inout.CancelPolicyListResp{
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		List:     policies,
+	}, nil
+}
+
+// DeleteCancelPolicy 删除取消政策
+func (brs *BookingRefundService) DeleteCancelPolicy(id int) error {
+	var policy app_model.RoomCancelPolicy
+	if err := brs.dao().First(&policy, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("取消政策不存在")
+		}
+		return fmt.Errorf("查询取消政策失败: %v", err)
+	}
+
+	if err := brs.dao().Delete(&policy).Error; err != nil {
+		return fmt.Errorf("删除取消政策失败: %v", err)
+	}
+
+	log.Printf("取消政策已删除: %s (ID: %d)", policy.PolicyName, policy.ID)
+	return nil
+}
+
+// ========== 辅助方法 ==========
+
+// validatePolicyScope 校验政策适用的房间和套餐
+func (brs *BookingRefundService) validatePolicyScope(roomID, packageID *int) error {
+	if roomID != nil {
+		var room app_model.Room
+		if err := brs.dao().Select("id").First(&room, *roomID).Error; err != nil {
+			return fmt.Errorf("房间不存在")
+		}
+	}
+
+	if packageID != nil {
+		var pkg app_model.RoomPackage
+		if err := brs.dao().Select("id, room_id").First(&pkg, *packageID).Error; err != nil {
+			return fmt.Errorf("套餐不存在")
+		}
+		if roomID != nil && pkg.RoomID != *roomID {
+			return fmt.Errorf("套餐不属于该房间")
+		}
+	}
+
+	return nil
+}
+
+// getRefundRuleText 获取退款规则文本
+func (brs *BookingRefundService) getRefundRuleText(rule string) string {
+	switch rule {
+	case app_model.RefundRuleFull:
+		return "全额退款"
+	case app_model.RefundRulePartial:
+		return "扣除手续费后退款"
+	case app_model.RefundRuleNone:
+		return "不予退款"
+	default:
+		return "未知"
+	}
+}
+
+// mergeRefundIntoBreakdown 将退款信息写入价格明细JSON
+func mergeRefundIntoBreakdown(priceBreakdown string, quote *inout.BookingRefundQuote) string {
+	breakdown := map[string]interface{}{}
+	if priceBreakdown != "" {
+		if err := json.Unmarshal([]byte(priceBreakdown), &breakdown); err != nil {
+			breakdown = map[string]interface{}{"raw": priceBreakdown}
+		}
+	}
+
+	breakdown["refund"] = quote
+
+	bytes, err := json.Marshal(breakdown)
+	if err != nil {
+		return priceBreakdown
+	}
+	return string(bytes)
+}
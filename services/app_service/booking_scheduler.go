@@ -1,26 +1,84 @@
 package app_service
 
 import (
-	"fmt"
+	"context"
 	"log"
 	"os"
+	"strconv"
+	"sync"
 	"time"
 
 	"nasa-go-admin/db"
+	"nasa-go-admin/inout"
 	"nasa-go-admin/model/app_model"
+
+	"gorm.io/gorm"
 )
 
+// defaultBookingPaymentWindow 待支付预订默认支付时限
+const defaultBookingPaymentWindow = 30 * time.Minute
+
+var (
+	bookingPaymentWindow     time.Duration
+	bookingPaymentWindowOnce sync.Once
+)
+
+// BookingPaymentWindow 待支付预订的支付时限：时限内占用所选时段，超时未支付由调度器自动取消
+// 通过环境变量 BOOKING_PAYMENT_WINDOW_MIN 配置（分钟），未配置时为30分钟
+func BookingPaymentWindow() time.Duration {
+	bookingPaymentWindowOnce.Do(func() {
+		bookingPaymentWindow = parseBookingPaymentWindow(os.Getenv("BOOKING_PAYMENT_WINDOW_MIN"))
+	})
+	return bookingPaymentWindow
+}
+
+// parseBookingPaymentWindow 解析支付时限配置（分钟），未配置或无效时使用默认值
+func parseBookingPaymentWindow(v string) time.Duration {
+	if v == "" {
+		return defaultBookingPaymentWindow
+	}
+	minutes, err := strconv.Atoi(v)
+	if err != nil || minutes <= 0 {
+		log.Printf("BOOKING_PAYMENT_WINDOW_MIN 配置无效: %s，使用默认支付时限", v)
+		return defaultBookingPaymentWindow
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
 type BookingScheduler struct {
 	logService *BookingLogService
+	notifier   *BookingNotificationService
+	waitlist   *BookingWaitlistService
+	ctx        context.Context
 }
 
 // NewBookingScheduler 创建新的订单调度器实例
 func NewBookingScheduler() *BookingScheduler {
 	return &BookingScheduler{
 		logService: &BookingLogService{},
+		notifier:   NewBookingNotificationService(),
+		waitlist:   NewBookingWaitlistService(),
 	}
 }
 
+// WithContext 返回绑定请求上下文的调度器，管理端手动操作预订时按租户隔离数据
+func (bs *BookingScheduler) WithContext(ctx context.Context) *BookingScheduler {
+	return &BookingScheduler{
+		logService: bs.logService,
+		notifier:   bs.notifier,
+		waitlist:   bs.waitlist,
+		ctx:        ctx,
+	}
+}
+
+// dao 获取数据库连接，带上下文时由 GORM 租户插件追加 tenants_id 条件
+func (bs *BookingScheduler) dao() *gorm.DB {
+	if bs.ctx != nil {
+		return db.Dao.WithContext(bs.ctx)
+	}
+	return db.Dao
+}
+
 // StartScheduler 启动订单状态自动管理调度器
 func (bs *BookingScheduler) StartScheduler() {
 	// 启动定时任务，每分钟检查一次
@@ -29,7 +87,11 @@ func (bs *BookingScheduler) StartScheduler() {
 		for {
 			select {
 			case <-ticker.C:
-				bs.ProcessBookingStatusUpdates()
+				// 多实例部署时只有持有租约的实例执行
+				GetLeaderElector().RunAsLeader(JobBookingStatusUpdate, func() error {
+					bs.ProcessBookingStatusUpdates()
+					return nil
+				})
 			}
 		}
 	}()
@@ -54,8 +116,14 @@ func (bs *BookingScheduler) ProcessBookingStatusUpdates() {
 	// 2. 处理使用中但到了结束时间的订单，改为已完成
 	bs.completeBookings(now)
 
-	// 3. 处理超过24小时未支付的订单，自动取消
+	// 3. 处理超过支付时限未支付的订单，自动取消
 	bs.cancelOverdueBookings(now)
+
+	// 4. 发送待支付、即将开始、即将结束提醒
+	bs.notifier.ProcessReminders(now)
+
+	// 5. 处理候补：过期失效，为候补用户保留空闲时段
+	bs.waitlist.ProcessWaitlist(now)
 }
 
 // activateBookings 激活到达开始时间的已支付订单
@@ -116,9 +184,11 @@ func (bs *BookingScheduler) activateBookings(now time.Time) {
 func (bs *BookingScheduler) completeBookings(now time.Time) {
 	var bookings []app_model.RoomBooking
 
-	// 查询使用中且结束时间到了的订单
-	if err := db.Dao.Where("status = ? AND end_time <= ?",
-		app_model.BookingStatusInUse, now).Find(&bookings).Error; err != nil {
+	// 查询使用中且结束时间到了的订单，前台办理入住的订单由前台退房结算超时费用，不自动完成
+	staffCheckedIn := db.Dao.Model(&app_model.RoomUsageLog{}).
+		Select("booking_id").Where("check_in_by > 0 AND check_out_at IS NULL")
+	if err := db.Dao.Where("status = ? AND end_time <= ? AND id NOT IN (?)",
+		app_model.BookingStatusInUse, now, staffCheckedIn).Find(&bookings).Error; err != nil {
 		log.Printf("查询待完成订单失败: %v", err)
 		return
 	}
@@ -131,8 +201,13 @@ func (bs *BookingScheduler) completeBookings(now time.Time) {
 			continue
 		}
 
-		// 更新订单状态为已完成
-		if err := db.Dao.Model(&booking).Update("status", app_model.BookingStatusCompleted).Error; err != nil {
+		// 更新订单状态为已完成，同一事务内累计消费并发放积分
+		if err := db.Dao.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&booking).Update("status", app_model.BookingStatusCompleted).Error; err != nil {
+				return err
+			}
+			return NewMembershipService().EarnPoints(tx, booking.UserID, app_model.PointsBizBooking, booking.BookingNo, booking.PaidAmount)
+		}); err != nil {
 			log.Printf("更新订单状态失败 (ID: %d): %v", booking.ID, err)
 			bs.logService.LogBookingError(booking.ID, booking.BookingNo, "完成订单", err)
 			continue
@@ -180,16 +255,19 @@ func (bs *BookingScheduler) completeBookings(now time.Time) {
 
 		// 记录成功完成日志
 		bs.logService.LogBookingComplete(&booking, room.RoomName, actualHours)
+
+		// 发送完成及消费回执
+		go bs.notifier.NotifyBookingCompleted(booking.ID)
 	}
 }
 
-// cancelOverdueBookings 取消超过24小时未支付的订单
+// cancelOverdueBookings 取消超过支付时限未支付的订单，释放占用的时段
 func (bs *BookingScheduler) cancelOverdueBookings(now time.Time) {
-	cutoffTime := now.Add(-24 * time.Hour)
+	cutoffTime := now.Add(-BookingPaymentWindow())
 
 	var bookings []app_model.RoomBooking
 
-	// 查询超过24小时未支付的订单
+	// 查询超过支付时限未支付的订单
 	if err := db.Dao.Where("status = ? AND create_time <= ?",
 		app_model.BookingStatusPending, cutoffTime).Find(&bookings).Error; err != nil {
 		log.Printf("查询超时未支付订单失败: %v", err)
@@ -200,157 +278,61 @@ func (bs *BookingScheduler) cancelOverdueBookings(now time.Time) {
 		// 更新订单状态为已取消
 		updates := map[string]interface{}{
 			"status":  app_model.BookingStatusCancelled,
-			"remarks": booking.Remarks + "\n系统自动取消: 超过24小时未支付",
+			"remarks": booking.Remarks + "\n系统自动取消: 超过支付时限未支付",
 		}
 
-		if err := db.Dao.Model(&booking).Updates(updates).Error; err != nil {
+		cancelled := false
+		err := db.Dao.Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(&app_model.RoomBooking{}).
+				Where("id = ? AND status = ?", booking.ID, app_model.BookingStatusPending).
+				Updates(updates)
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+			cancelled = true
+			// 退回预订时使用的优惠券和抵扣的积分
+			if err := NewCouponService().ReturnCoupon(tx, app_model.CouponOrderBooking, booking.BookingNo); err != nil {
+				return err
+			}
+			return NewMembershipService().RollbackPoints(tx, app_model.PointsBizBooking, booking.BookingNo)
+		})
+		if err != nil {
 			log.Printf("取消超时订单失败 (ID: %d): %v", booking.ID, err)
 			bs.logService.LogBookingError(booking.ID, booking.BookingNo, "超时取消", err)
 			continue
 		}
+		if !cancelled {
+			// 期间已被支付或取消
+			continue
+		}
 
 		log.Printf("超时订单已取消: %s (用户ID: %d)", booking.BookingNo, booking.UserID)
 
 		// 记录超时取消日志
 		bs.logService.LogBookingTimeout(&booking)
+
+		// 释放的时段顺延给候补用户，超时的候补保留在此失效
+		bs.waitlist.OnBookingReleased(booking.ID)
 	}
 }
 
-// ManuallyStartBooking 手动开始订单（管理员操作）
+// ManuallyStartBooking 手动开始订单（管理员操作），按前台办理入住流程锁定预订并记录经办人，
+// 结束时由前台退房结算超时费用
 func (bs *BookingScheduler) ManuallyStartBooking(bookingID int, adminID int) error {
-	var booking app_model.RoomBooking
-	if err := db.Dao.First(&booking, bookingID).Error; err != nil {
-		return err
-	}
-
-	if booking.Status != app_model.BookingStatusPaid {
-		return fmt.Errorf("订单状态不正确，无法开始使用")
-	}
-
-	now := time.Now()
-
-	// 开始事务
-	tx := db.Dao.Begin()
-
-	// 更新订单状态
-	if err := tx.Model(&booking).Update("status", app_model.BookingStatusInUse).Error; err != nil {
-		tx.Rollback()
-		return err
-	}
-
-	// 更新房间状态
-	if err := tx.Model(&app_model.Room{}).Where("id = ?", booking.RoomID).
-		Update("status", app_model.RoomStatusOccupied).Error; err != nil {
-		tx.Rollback()
-		return err
-	}
-
-	// 创建使用记录
-	usageLog := &app_model.RoomUsageLog{
-		RoomID:    booking.RoomID,
-		BookingID: booking.ID,
-		UserID:    booking.UserID,
-		CheckInAt: now,
-	}
-
-	if err := tx.Create(usageLog).Error; err != nil {
-		tx.Rollback()
-		return err
-	}
-
-	tx.Commit()
-
-	log.Printf("手动开始订单: %s", booking.BookingNo)
-
-	// 获取房间信息并记录日志
-	var room app_model.Room
-	if err := db.Dao.First(&room, booking.RoomID).Error; err == nil {
-		bs.logService.LogManualStart(&booking, room.RoomName, adminID)
-	}
-
-	return nil
+	_, err := NewBookingCheckinService().WithContext(bs.ctx).CheckIn(&inout.CheckInReq{BookingID: bookingID}, adminID)
+	return err
 }
 
-// ManuallyEndBooking 手动结束订单（管理员操作）
+// ManuallyEndBooking 手动结束订单（管理员操作），按退房流程结算超时费用，钱包余额不足时挂账
 func (bs *BookingScheduler) ManuallyEndBooking(bookingID int, adminID int) error {
-	var booking app_model.RoomBooking
-	if err := db.Dao.First(&booking, bookingID).Error; err != nil {
-		return err
-	}
-
-	if booking.Status != app_model.BookingStatusInUse {
-		return fmt.Errorf("订单状态不正确，无法结束使用")
-	}
-
-	now := time.Now()
-
-	// 开始事务
-	tx := db.Dao.Begin()
-
-	// 更新订单状态
-	if err := tx.Model(&booking).Update("status", app_model.BookingStatusCompleted).Error; err != nil {
-		tx.Rollback()
-		return err
-	}
-
-	// 检查房间是否还有其他活跃订单
-	var activeBookings int64
-	if err := tx.Model(&app_model.RoomBooking{}).
-		Where("room_id = ? AND status = ? AND id != ?",
-			booking.RoomID, app_model.BookingStatusInUse, booking.ID).
-		Count(&activeBookings).Error; err != nil {
-		tx.Rollback()
-		return err
-	}
-
-	// 如果没有其他活跃订单，将房间状态改为可用
-	if activeBookings == 0 {
-		if err := tx.Model(&app_model.Room{}).Where("id = ?", booking.RoomID).
-			Update("status", app_model.RoomStatusAvailable).Error; err != nil {
-			tx.Rollback()
-			return err
-		}
-	}
-
-	// 更新使用记录
-	var usageLog app_model.RoomUsageLog
-	if err := tx.Where("booking_id = ?", booking.ID).First(&usageLog).Error; err == nil {
-		actualHours := now.Sub(usageLog.CheckInAt).Hours()
-		updates := map[string]interface{}{
-			"check_out_at": &now,
-			"actual_hours": actualHours,
-		}
-
-		if err := tx.Model(&usageLog).Updates(updates).Error; err != nil {
-			tx.Rollback()
-			return err
-		}
-	}
-
-	tx.Commit()
-
-	log.Printf("手动结束订单: %s", booking.BookingNo)
-
-	// 获取房间信息和实际使用时间并记录日志
-	var room app_model.Room
-	var actualHours float64 = float64(booking.Hours) // 默认使用预订小时数
-
-	if err := db.Dao.First(&room, booking.RoomID).Error; err == nil {
-		// 尝试获取实际使用时间
-		var usageLog app_model.RoomUsageLog
-		if err := db.Dao.Where("booking_id = ?", booking.ID).First(&usageLog).Error; err == nil {
-			actualHours = now.Sub(usageLog.CheckInAt).Hours()
-		}
-		bs.logService.LogManualEnd(&booking, room.RoomName, adminID, actualHours)
-	}
-
-	return nil
+	_, err := NewBookingCheckinService().WithContext(bs.ctx).CheckOut(&inout.CheckOutReq{BookingID: bookingID}, adminID)
+	return err
 }
 
 // GetBookingStatusInfo 获取订单状态信息（用于管理后台展示）
 func (bs *BookingScheduler) GetBookingStatusInfo(bookingID int) (*BookingStatusInfo, error) {
 	var booking app_model.RoomBooking
-	if err := db.Dao.Preload("Room").First(&booking, bookingID).Error; err != nil {
+	if err := bs.dao().Preload("Room").First(&booking, bookingID).Error; err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,62 @@
+package app_service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"nasa-go-admin/model/app_model"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestParseBookingPaymentWindow(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", defaultBookingPaymentWindow},
+		{"15", 15 * time.Minute},
+		{"120", 2 * time.Hour},
+		{"0", defaultBookingPaymentWindow},
+		{"-5", defaultBookingPaymentWindow},
+		{"abc", defaultBookingPaymentWindow},
+		{"1.5", defaultBookingPaymentWindow},
+	}
+
+	for _, tt := range tests {
+		if got := parseBookingPaymentWindow(tt.value); got != tt.want {
+			t.Errorf("parseBookingPaymentWindow(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestBlockingBookings(t *testing.T) {
+	// DryRun 只生成SQL，不连接数据库
+	gdb, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "test:test@tcp(127.0.0.1:3306)/test?parseTime=true",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("创建 DryRun 连接失败: %v", err)
+	}
+
+	now := time.Date(2025, 1, 8, 12, 0, 0, 0, time.Local)
+	var bookings []app_model.RoomBooking
+	stmt := gdb.Scopes(blockingBookings(now)).Find(&bookings).Statement
+
+	sql := stmt.SQL.String()
+	if !strings.Contains(sql, "(status IN (?,?) OR (status = ? AND create_time > ?))") {
+		t.Fatalf("blockingBookings SQL = %s", sql)
+	}
+
+	want := []interface{}{
+		app_model.BookingStatusPaid, app_model.BookingStatusInUse,
+		app_model.BookingStatusPending, now.Add(-BookingPaymentWindow()),
+	}
+	if !reflect.DeepEqual(stmt.Vars, want) {
+		t.Errorf("blockingBookings 参数 = %v, want %v", stmt.Vars, want)
+	}
+}
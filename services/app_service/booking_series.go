@@ -0,0 +1,630 @@
+package app_service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"nasa-go-admin/inout"
+	"nasa-go-admin/model/app_model"
+
+	"gorm.io/gorm"
+)
+
+// recurrenceWeekdays RRULE 星期代码
+var recurrenceWeekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// seriesPlan 周期预订展开结果，各次预订已按套餐引擎计价
+type seriesPlan struct {
+	room        app_model.Room
+	pkg         *app_model.RoomPackage
+	rule        string
+	interval    int
+	byDay       []string
+	until       *time.Time
+	occurrences []*inout.SeriesOccurrence
+	prices      []*bookingPrice
+}
+
+// PreviewBookingSeries 预览周期预订：展开各次预订，逐次检查可用性并计价
+func (rs *RoomService) PreviewBookingSeries(req *inout.CreateBookingSeriesReq) (*inout.BookingSeriesPreviewResp, error) {
+	plan, err := rs.planBookingSeries(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &inout.BookingSeriesPreviewResp{
+		Rule:        plan.rule,
+		Occurrences: plan.occurrences,
+		Total:       len(plan.occurrences),
+	}
+	for _, occ := range plan.occurrences {
+		available, err := rs.CheckRoomAvailability(req.RoomID, occ.StartTime, occ.EndTime)
+		if err != nil {
+			return nil, err
+		}
+		occ.Available = available
+		if !available {
+			occ.Reason = "该时间段房间已被预订"
+			if blackout, err := findRoomBlackout(rs.dao(), &plan.room, occ.StartTime, occ.EndTime); err == nil && blackout != nil {
+				occ.Reason = blackoutMessage(blackout)
+			}
+			resp.ConflictCount++
+			continue
+		}
+		resp.TotalAmount += occ.TotalAmount
+	}
+	resp.TotalAmount = math.Round(resp.TotalAmount*100) / 100
+
+	return resp, nil
+}
+
+// CreateBookingSeries 创建周期预订，在同一事务内锁定房间并逐次检查冲突
+// 有冲突时整体不创建，除非指定跳过冲突日期
+func (rs *RoomService) CreateBookingSeries(req *inout.CreateBookingSeriesReq, userID int) (*inout.CreateBookingSeriesResp, error) {
+	plan, err := rs.planBookingSeries(req)
+	if err != nil {
+		return nil, err
+	}
+
+	roomLock, err := lockRoomForBooking(req.RoomID)
+	if err != nil {
+		return nil, err
+	}
+	defer roomLock.Release()
+
+	resp := &inout.CreateBookingSeriesResp{}
+	var series *app_model.RoomBookingSeries
+	err = rs.dao().Transaction(func(tx *gorm.DB) error {
+		var room app_model.Room
+		var booked []int
+		var conflicts []string
+		for i, occ := range plan.occurrences {
+			if err := lockRoomAndCheckOverlap(tx, &room, req.RoomID, occ.StartTime, occ.EndTime, 0); err != nil {
+				reason := slotConflictReason(err)
+				if reason == "" {
+					return err
+				}
+				occ.Reason = reason
+				conflicts = append(conflicts, occ.StartTime.Format("01-02 15:04"))
+				continue
+			}
+			occ.Available = true
+			booked = append(booked, i)
+		}
+
+		if len(conflicts) > 0 && !req.SkipConflicts {
+			return fmt.Errorf("以下日期房间不可预订: %s", strings.Join(conflicts, "、"))
+		}
+		if len(booked) == 0 {
+			return fmt.Errorf("所选日期房间均不可预订")
+		}
+
+		var totalAmount float64
+		for _, i := range booked {
+			totalAmount += plan.prices[i].TotalAmount
+		}
+
+		seriesNo := rs.generateBookingNo()
+		series = &app_model.RoomBookingSeries{
+			TenantsId:    room.TenantsId,
+			SeriesNo:     "BS" + strings.TrimPrefix(seriesNo, "BK"),
+			RoomID:       req.RoomID,
+			UserID:       userID,
+			Rule:         plan.rule,
+			Freq:         req.Recurrence.Freq,
+			Interval:     plan.interval,
+			ByDay:        strings.Join(plan.byDay, ","),
+			Until:        plan.until,
+			Count:        req.Recurrence.Count,
+			StartTime:    plan.occurrences[0].StartTime,
+			Hours:        req.Hours,
+			PackageID:    req.PackageID,
+			Occurrences:  len(booked),
+			TotalAmount:  math.Round(totalAmount*100) / 100,
+			Status:       app_model.BookingSeriesStatusActive,
+			ContactName:  req.ContactName,
+			ContactPhone: req.ContactPhone,
+			Remarks:      req.Remarks,
+		}
+		if err := tx.Create(series).Error; err != nil {
+			return fmt.Errorf("创建周期预订失败: %v", err)
+		}
+
+		// 各次预订单号在系列单号基础上按序号递增
+		for _, i := range booked {
+			occ, price := plan.occurrences[i], plan.prices[i]
+			breakdownBytes, _ := json.Marshal(price.Quote)
+			booking := &app_model.RoomBooking{
+				TenantsId:      room.TenantsId,
+				RoomID:         req.RoomID,
+				UserID:         userID,
+				BookingNo:      fmt.Sprintf("%s%02d", seriesNo, occ.Index),
+				StartTime:      occ.StartTime,
+				EndTime:        occ.EndTime,
+				Hours:          req.Hours,
+				TotalAmount:    price.TotalAmount,
+				Status:         app_model.BookingStatusPending,
+				ContactName:    req.ContactName,
+				ContactPhone:   req.ContactPhone,
+				Remarks:        req.Remarks,
+				SeriesID:       &series.ID,
+				PackageID:      req.PackageID,
+				PackageName:    price.PackageName,
+				OriginalPrice:  price.OriginalPrice,
+				PackagePrice:   price.PackagePrice,
+				DiscountAmount: price.DiscountAmount,
+				PriceBreakdown: string(breakdownBytes),
+			}
+			if err := tx.Create(booking).Error; err != nil {
+				return fmt.Errorf("创建预订失败: %v", err)
+			}
+			occ.BookingID = booking.ID
+			occ.BookingNo = booking.BookingNo
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, occ := range plan.occurrences {
+		if occ.Available {
+			resp.Booked = append(resp.Booked, occ)
+		} else {
+			resp.Skipped = append(resp.Skipped, occ)
+		}
+	}
+	resp.Series = rs.convertSeriesToDetail(series)
+	resp.Series.PendingCount = len(resp.Booked)
+	resp.Series.NextStartTime = &resp.Booked[0].StartTime
+
+	log.Printf("成功创建周期预订: %s (用户ID: %d, 房间ID: %d, 共%d次)", series.SeriesNo, userID, req.RoomID, series.Occurrences)
+	return resp, nil
+}
+
+// GetBookingSeriesDetail 获取周期预订详情及各次预订，userID 不为空时只查询本人的系列
+func (rs *RoomService) GetBookingSeriesDetail(id int, userID *int) (*inout.BookingSeriesDetail, error) {
+	series, err := rs.loadBookingSeries(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var bookings []app_model.RoomBooking
+	if err := rs.dao().Where("series_id = ?", series.ID).Order("start_time ASC").Find(&bookings).Error; err != nil {
+		return nil, fmt.Errorf("查询周期预订失败: %v", err)
+	}
+
+	detail := rs.convertSeriesToDetail(series)
+	now := time.Now()
+	detail.Bookings = make([]inout.BookingDetail, 0, len(bookings))
+	for i := range bookings {
+		countSeriesBooking(detail, bookings[i].Status, bookings[i].StartTime, now)
+		detail.Bookings = append(detail.Bookings, *rs.convertBookingToDetail(&bookings[i]))
+	}
+
+	return detail, nil
+}
+
+// CancelBookingSeries 取消周期预订，指定 booking_id 时只取消该次预订，否则取消全部未开始的预订
+// 已支付的预订逐次按取消政策退款到钱包
+func (rs *RoomService) CancelBookingSeries(req *inout.CancelBookingSeriesReq, userID *int, operatorID *int) (*inout.CancelBookingSeriesResp, error) {
+	series, err := rs.loadBookingSeries(req.SeriesID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := rs.dao().Model(&app_model.RoomBooking{}).Where("series_id = ?", series.ID)
+	if req.BookingID > 0 {
+		query = query.Where("id = ?", req.BookingID)
+	} else {
+		query = query.Where("status IN (?) AND start_time > ?",
+			[]int{app_model.BookingStatusPending, app_model.BookingStatusPaid}, time.Now())
+	}
+
+	var bookingIDs []int
+	if err := query.Order("start_time ASC").Pluck("id", &bookingIDs).Error; err != nil {
+		return nil, fmt.Errorf("查询周期预订失败: %v", err)
+	}
+	if len(bookingIDs) == 0 {
+		if req.BookingID > 0 {
+			return nil, fmt.Errorf("预订不属于该周期预订")
+		}
+		return nil, fmt.Errorf("没有可取消的预订")
+	}
+
+	resp := &inout.CancelBookingSeriesResp{Refunds: []*inout.BookingRefundQuote{}}
+	refundService := NewBookingRefundService().WithContext(rs.ctx)
+	for _, bookingID := range bookingIDs {
+		quote, err := refundService.CancelBooking(&inout.CancelBookingReq{ID: bookingID, Reason: req.Reason}, userID, operatorID)
+		if err != nil {
+			// 单次取消时直接返回原因，整体取消时记录失败继续处理其余预订
+			if req.BookingID > 0 {
+				return nil, err
+			}
+			resp.Failed = append(resp.Failed, fmt.Sprintf("预订%d: %v", bookingID, err))
+			continue
+		}
+		resp.Cancelled++
+		if quote != nil {
+			resp.RefundAmount += quote.RefundAmount
+			resp.Refunds = append(resp.Refunds, quote)
+		}
+	}
+	resp.RefundAmount = math.Round(resp.RefundAmount*100) / 100
+
+	// 整体取消或已没有待进行的预订时结束系列
+	var remaining int64
+	if err := rs.dao().Model(&app_model.RoomBooking{}).
+		Where("series_id = ? AND status IN (?)", series.ID,
+			[]int{app_model.BookingStatusPending, app_model.BookingStatusPaid, app_model.BookingStatusInUse}).
+		Count(&remaining).Error; err != nil {
+		return nil, fmt.Errorf("查询周期预订失败: %v", err)
+	}
+	if (req.BookingID == 0 && len(resp.Failed) == 0) || remaining == 0 {
+		if err := rs.dao().Model(&app_model.RoomBookingSeries{}).Where("id = ?", series.ID).
+			Update("status", app_model.BookingSeriesStatusCancelled).Error; err != nil {
+			return nil, fmt.Errorf("更新周期预订状态失败: %v", err)
+		}
+	}
+
+	log.Printf("取消周期预订: %s (取消%d次, 退款%.2f)", series.SeriesNo, resp.Cancelled, resp.RefundAmount)
+	return resp, nil
+}
+
+// PayBookingSeries 使用钱包余额支付周期预订中全部待支付的预订
+// 支付前先校验余额足够支付全部预订，避免只支付一部分
+func (rs *RoomService) PayBookingSeries(req *inout.PayBookingSeriesReq, userID int) (*inout.PayBookingSeriesResp, error) {
+	series, err := rs.loadBookingSeries(req.SeriesID, &userID)
+	if err != nil {
+		return nil, err
+	}
+	if series.Status != app_model.BookingSeriesStatusActive {
+		return nil, fmt.Errorf("周期预订已取消，无法支付")
+	}
+
+	var bookings []app_model.RoomBooking
+	if err := rs.dao().Select("id, total_amount").
+		Where("series_id = ? AND status = ?", series.ID, app_model.BookingStatusPending).
+		Order("start_time ASC").Find(&bookings).Error; err != nil {
+		return nil, fmt.Errorf("查询周期预订失败: %v", err)
+	}
+	if len(bookings) == 0 {
+		return nil, fmt.Errorf("没有待支付的预订")
+	}
+
+	var amount float64
+	for _, b := range bookings {
+		amount += b.TotalAmount
+	}
+	var wallet app_model.AppWallet
+	if err := rs.dao().Where("user_id = ?", userID).First(&wallet).Error; err != nil && err != gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("查询钱包失败: %v", err)
+	}
+	if wallet.Money < amount {
+		return nil, fmt.Errorf("余额不足，当前余额: %.2f，需要: %.2f", wallet.Money, amount)
+	}
+
+	resp := &inout.PayBookingSeriesResp{Payments: []*inout.PayBookingResp{}}
+	paymentService := NewBookingPaymentService()
+	for _, b := range bookings {
+		payment, err := paymentService.PayBooking(&inout.PayBookingReq{BookingID: b.ID}, userID)
+		if err != nil {
+			resp.Failed = append(resp.Failed, fmt.Sprintf("预订%d: %v", b.ID, err))
+			continue
+		}
+		resp.Paid++
+		resp.PaidAmount += payment.PaidAmount
+		resp.Payments = append(resp.Payments, payment)
+	}
+	resp.PaidAmount = math.Round(resp.PaidAmount*100) / 100
+
+	return resp, nil
+}
+
+// ========== 辅助方法 ==========
+
+// planBookingSeries 校验请求并按重复规则展开各次预订
+func (rs *RoomService) planBookingSeries(req *inout.CreateBookingSeriesReq) (*seriesPlan, error) {
+	startTime, err := parseBookingTime(req.StartTime)
+	if err != nil {
+		return nil, fmt.Errorf("开始时间格式错误: %v", err)
+	}
+	if !startTime.After(time.Now()) {
+		return nil, fmt.Errorf("首次开始时间已过")
+	}
+
+	plan := &seriesPlan{}
+	if err := rs.dao().First(&plan.room, req.RoomID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("房间不存在")
+		}
+		return nil, fmt.Errorf("查询房间失败: %v", err)
+	}
+	if !plan.room.IsAvailable() {
+		return nil, fmt.Errorf("房间当前不可用")
+	}
+
+	plan.pkg, err = rs.resolveBookingPackage(req.RoomID, req.PackageID)
+	if err != nil {
+		return nil, err
+	}
+
+	starts, err := expandRecurrence(startTime, &req.Recurrence, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	// 相邻两次预订（含清洁缓冲）不能重叠
+	duration := time.Duration(req.Hours) * time.Hour
+	buffer := time.Duration(plan.room.CleaningMin) * time.Minute
+	for i := 1; i < len(starts); i++ {
+		if starts[i].Before(starts[i-1].Add(duration + buffer)) {
+			return nil, fmt.Errorf("每次预订时长超过重复间隔")
+		}
+	}
+
+	for i, start := range starts {
+		price, err := priceBooking(&plan.room, plan.pkg, start, req.Hours)
+		if err != nil {
+			return nil, err
+		}
+		plan.prices = append(plan.prices, price)
+		plan.occurrences = append(plan.occurrences, &inout.SeriesOccurrence{
+			Index:         i + 1,
+			StartTime:     start,
+			EndTime:       start.Add(duration),
+			OriginalPrice: price.OriginalPrice,
+			TotalAmount:   price.TotalAmount,
+		})
+	}
+
+	return plan, nil
+}
+
+// expandRecurrence 按 RRULE 风格的重复规则展开各次开始时间，同时把规范化的规则写入 plan
+func expandRecurrence(start time.Time, r *inout.BookingRecurrence, plan *seriesPlan) ([]time.Time, error) {
+	if r.Count <= 0 && r.Until == "" {
+		return nil, fmt.Errorf("请指定重复截止日期或重复次数")
+	}
+	if r.Count > app_model.MaxSeriesOccurrences {
+		return nil, fmt.Errorf("周期预订最多%d次", app_model.MaxSeriesOccurrences)
+	}
+
+	plan.interval = r.Interval
+	if plan.interval <= 0 {
+		plan.interval = 1
+	}
+
+	// 截止日期当天的预订也包含在内
+	var untilEnd time.Time
+	if r.Until != "" {
+		until, err := parseBookingDate(r.Until)
+		if err != nil {
+			return nil, fmt.Errorf("截止日期格式错误: %v", err)
+		}
+		untilEnd = until.AddDate(0, 0, 1)
+		if !start.Before(untilEnd) {
+			return nil, fmt.Errorf("截止日期不能早于首次开始时间")
+		}
+		plan.until = &until
+	}
+
+	var starts []time.Time
+	// add 追加一次预订，返回是否已到达结束条件
+	add := func(t time.Time) (bool, error) {
+		if r.Until != "" && !t.Before(untilEnd) {
+			return true, nil
+		}
+		starts = append(starts, t)
+		if len(starts) > app_model.MaxSeriesOccurrences {
+			return true, fmt.Errorf("周期预订最多%d次，请缩短截止日期", app_model.MaxSeriesOccurrences)
+		}
+		return r.Count > 0 && len(starts) == r.Count, nil
+	}
+
+	switch r.Freq {
+	case app_model.RecurrenceDaily:
+		if len(r.ByDay) > 0 {
+			return nil, fmt.Errorf("按天重复不支持指定星期")
+		}
+		for k := 0; ; k++ {
+			done, err := add(start.AddDate(0, 0, k*plan.interval))
+			if err != nil {
+				return nil, err
+			}
+			if done {
+				break
+			}
+		}
+
+	case app_model.RecurrenceWeekly:
+		days, err := parseRecurrenceDays(r.ByDay, start.Weekday())
+		if err != nil {
+			return nil, err
+		}
+		plan.byDay = days
+
+		// 以首次预订所在周的周一为基准，每隔 interval 周按星期展开
+		weekStart := start.AddDate(0, 0, -weekdayOffset(start.Weekday()))
+	weeks:
+		for w := 0; ; w++ {
+			base := weekStart.AddDate(0, 0, 7*w*plan.interval)
+			for _, day := range days {
+				t := base.AddDate(0, 0, weekdayOffset(recurrenceWeekdays[day]))
+				if t.Before(start) {
+					continue
+				}
+				done, err := add(t)
+				if err != nil {
+					return nil, err
+				}
+				if done {
+					break weeks
+				}
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("不支持的重复频率: %s", r.Freq)
+	}
+
+	if len(starts) == 0 {
+		return nil, fmt.Errorf("重复规则没有可预订的日期")
+	}
+
+	plan.rule = formatRecurrenceRule(r.Freq, plan.interval, plan.byDay, plan.until, r.Count)
+	return starts, nil
+}
+
+// parseRecurrenceDays 解析并按周一到周日排序星期代码，未指定时使用首次预订的星期
+func parseRecurrenceDays(byDay []string, defaultDay time.Weekday) ([]string, error) {
+	if len(byDay) == 0 {
+		for code, wd := range recurrenceWeekdays {
+			if wd == defaultDay {
+				return []string{code}, nil
+			}
+		}
+	}
+
+	seen := make(map[string]bool, len(byDay))
+	days := make([]string, 0, len(byDay))
+	for _, day := range byDay {
+		code := strings.ToUpper(strings.TrimSpace(day))
+		if _, ok := recurrenceWeekdays[code]; !ok {
+			return nil, fmt.Errorf("星期代码错误: %s", day)
+		}
+		if !seen[code] {
+			seen[code] = true
+			days = append(days, code)
+		}
+	}
+	sort.Slice(days, func(i, j int) bool {
+		return weekdayOffset(recurrenceWeekdays[days[i]]) < weekdayOffset(recurrenceWeekdays[days[j]])
+	})
+	return days, nil
+}
+
+// weekdayOffset 星期相对周一的偏移天数
+func weekdayOffset(wd time.Weekday) int {
+	return (int(wd) + 6) % 7
+}
+
+// formatRecurrenceRule 生成 RRULE 格式的规则描述
+func formatRecurrenceRule(freq string, interval int, byDay []string, until *time.Time, count int) string {
+	parts := []string{"FREQ=" + strings.ToUpper(freq), "INTERVAL=" + strconv.Itoa(interval)}
+	if len(byDay) > 0 {
+		parts = append(parts, "BYDAY="+strings.Join(byDay, ","))
+	}
+	if until != nil {
+		parts = append(parts, "UNTIL="+until.Format("20060102"))
+	}
+	if count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(count))
+	}
+	return strings.Join(parts, ";")
+}
+
+// loadBookingSeries 加载周期预订系列，userID 不为空时只查询本人的系列
+func (rs *RoomService) loadBookingSeries(id int, userID *int) (*app_model.RoomBookingSeries, error) {
+	query := rs.dao().Model(&app_model.RoomBookingSeries{})
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+
+	var series app_model.RoomBookingSeries
+	if err := query.First(&series, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("周期预订不存在")
+		}
+		return nil, fmt.Errorf("查询周期预订失败: %v", err)
+	}
+	return &series, nil
+}
+
+// loadSeriesSummaries 批量加载系列汇总，用于预订列表合并展示
+func (rs *RoomService) loadSeriesSummaries(seriesIDs []int) (map[int]*inout.BookingSeriesDetail, error) {
+	var seriesList []app_model.RoomBookingSeries
+	if err := rs.dao().Where("id IN (?)", seriesIDs).Find(&seriesList).Error; err != nil {
+		return nil, fmt.Errorf("查询周期预订失败: %v", err)
+	}
+
+	var bookings []app_model.RoomBooking
+	if err := rs.dao().Select("id, series_id, status, start_time").
+		Where("series_id IN (?)", seriesIDs).Find(&bookings).Error; err != nil {
+		return nil, fmt.Errorf("查询周期预订失败: %v", err)
+	}
+
+	summaries := make(map[int]*inout.BookingSeriesDetail, len(seriesList))
+	for i := range seriesList {
+		summaries[seriesList[i].ID] = rs.convertSeriesToDetail(&seriesList[i])
+	}
+	now := time.Now()
+	for _, b := range bookings {
+		if summary, ok := summaries[*b.SeriesID]; ok {
+			countSeriesBooking(summary, b.Status, b.StartTime, now)
+		}
+	}
+	return summaries, nil
+}
+
+// countSeriesBooking 按预订状态累计系列汇总，并记录最近一次未开始的预订时间
+func countSeriesBooking(detail *inout.BookingSeriesDetail, status int, startTime, now time.Time) {
+	switch status {
+	case app_model.BookingStatusPending:
+		detail.PendingCount++
+	case app_model.BookingStatusPaid, app_model.BookingStatusInUse:
+		detail.ActiveCount++
+	case app_model.BookingStatusCompleted:
+		detail.CompletedCount++
+	default:
+		detail.CancelledCount++
+	}
+
+	if (status == app_model.BookingStatusPending || status == app_model.BookingStatusPaid) && startTime.After(now) {
+		if detail.NextStartTime == nil || startTime.Before(*detail.NextStartTime) {
+			next := startTime
+			detail.NextStartTime = &next
+		}
+	}
+}
+
+// convertSeriesToDetail 转换周期预订模型为详情响应
+func (rs *RoomService) convertSeriesToDetail(series *app_model.RoomBookingSeries) *inout.BookingSeriesDetail {
+	return &inout.BookingSeriesDetail{
+		ID:           series.ID,
+		SeriesNo:     series.SeriesNo,
+		RoomID:       series.RoomID,
+		UserID:       series.UserID,
+		Rule:         series.Rule,
+		Freq:         series.Freq,
+		Interval:     series.Interval,
+		ByDay:        series.ByDay,
+		Until:        series.Until,
+		Count:        series.Count,
+		StartTime:    series.StartTime,
+		Hours:        series.Hours,
+		PackageID:    series.PackageID,
+		Occurrences:  series.Occurrences,
+		TotalAmount:  series.TotalAmount,
+		Status:       series.Status,
+		StatusText:   series.GetStatusText(),
+		ContactName:  series.ContactName,
+		ContactPhone: series.ContactPhone,
+		Remarks:      series.Remarks,
+		CreateTime:   series.CreateTime,
+	}
+}
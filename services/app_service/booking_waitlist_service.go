@@ -0,0 +1,671 @@
+package app_service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"nasa-go-admin/db"
+	"nasa-go-admin/inout"
+	"nasa-go-admin/model/app_model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// waitlistMaxWindow 候补时间窗口最长跨度
+	waitlistMaxWindow = 24 * time.Hour
+	// waitlistMaxActive 每个用户同时有效的候补数
+	waitlistMaxActive = 5
+	// waitlistSlotStep 在时间窗口内查找空闲时段的开始时间间隔
+	waitlistSlotStep = 30 * time.Minute
+	// waitlistScanBatch 调度器每次尝试保留的候补数
+	waitlistScanBatch = 50
+)
+
+// BookingWaitlistService 预订候补服务
+// 时段释放（取消、超时未支付）时按登记顺序为候补用户生成待支付预订保留时段，
+// 用户在支付时限内支付即确认，逾期由调度器取消后时段让给下一位候补用户
+type BookingWaitlistService struct {
+	notifier *BookingNotificationService
+	ctx      context.Context
+}
+
+// NewBookingWaitlistService 创建预订候补服务
+func NewBookingWaitlistService() *BookingWaitlistService {
+	return &BookingWaitlistService{
+		notifier: NewBookingNotificationService(),
+	}
+}
+
+// WithContext 返回绑定请求上下文的候补服务，管理端传入 gin.Context 后按租户隔离数据
+func (bws *BookingWaitlistService) WithContext(ctx context.Context) *BookingWaitlistService {
+	return &BookingWaitlistService{
+		notifier: bws.notifier,
+		ctx:      ctx,
+	}
+}
+
+// dao 获取数据库连接，带上下文时由 GORM 租户插件追加 tenants_id 条件
+func (bws *BookingWaitlistService) dao() *gorm.DB {
+	if bws.ctx != nil {
+		return db.Dao.WithContext(bws.ctx)
+	}
+	return db.Dao
+}
+
+// ========== 用户候补 ==========
+
+// JoinWaitlist 登记候补，时间窗口内有空闲时段时提示直接预订
+func (bws *BookingWaitlistService) JoinWaitlist(req *inout.JoinWaitlistReq, userID int) (*inout.WaitlistItem, error) {
+	windowStart, err := parseBookingTime(req.WindowStart)
+	if err != nil {
+		return nil, fmt.Errorf("开始时间格式错误: %v", err)
+	}
+	windowEnd, err := parseBookingTime(req.WindowEnd)
+	if err != nil {
+		return nil, fmt.Errorf("结束时间格式错误: %v", err)
+	}
+	duration := time.Duration(req.Hours) * time.Hour
+	if windowEnd.Sub(windowStart) < duration {
+		return nil, fmt.Errorf("时间窗口不足%d小时", req.Hours)
+	}
+	if windowEnd.Sub(windowStart) > waitlistMaxWindow {
+		return nil, fmt.Errorf("时间窗口最长%d小时", int(waitlistMaxWindow.Hours()))
+	}
+	if windowEnd.Add(-duration).Before(time.Now()) {
+		return nil, fmt.Errorf("时间窗口已过")
+	}
+
+	entry := &app_model.BookingWaitlist{
+		UserID:       userID,
+		RoomID:       req.RoomID,
+		RoomType:     req.RoomType,
+		WindowStart:  windowStart,
+		WindowEnd:    windowEnd,
+		Hours:        req.Hours,
+		ContactName:  req.ContactName,
+		ContactPhone: req.ContactPhone,
+		Remarks:      req.Remarks,
+		Status:       app_model.WaitlistStatusWaiting,
+	}
+
+	if req.RoomID > 0 {
+		var room app_model.Room
+		if err := bws.dao().First(&room, req.RoomID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, fmt.Errorf("房间不存在")
+			}
+			return nil, fmt.Errorf("查询房间失败: %v", err)
+		}
+		if room.Status == app_model.RoomStatusDisabled {
+			return nil, fmt.Errorf("房间已停用")
+		}
+		entry.RoomType = room.RoomType
+		entry.TenantsId = room.TenantsId
+	} else {
+		if req.RoomType == "" {
+			return nil, fmt.Errorf("请选择候补的房间或房间类型")
+		}
+		var rooms int64
+		if err := bws.dao().Model(&app_model.Room{}).
+			Where("room_type = ? AND status != ?", req.RoomType, app_model.RoomStatusDisabled).
+			Count(&rooms).Error; err != nil {
+			return nil, fmt.Errorf("查询房间失败: %v", err)
+		}
+		if rooms == 0 {
+			return nil, fmt.Errorf("没有该类型的房间")
+		}
+	}
+
+	var active int64
+	if err := bws.dao().Model(&app_model.BookingWaitlist{}).
+		Where("user_id = ? AND status IN (?)", userID,
+			[]int{app_model.WaitlistStatusWaiting, app_model.WaitlistStatusOffered}).
+		Count(&active).Error; err != nil {
+		return nil, fmt.Errorf("查询候补失败: %v", err)
+	}
+	if active >= waitlistMaxActive {
+		return nil, fmt.Errorf("最多同时候补%d个时段", waitlistMaxActive)
+	}
+
+	var duplicated int64
+	if err := bws.dao().Model(&app_model.BookingWaitlist{}).
+		Where("user_id = ? AND room_id = ? AND room_type = ? AND status = ? AND window_start < ? AND window_end > ?",
+			userID, entry.RoomID, entry.RoomType, app_model.WaitlistStatusWaiting, windowEnd, windowStart).
+		Count(&duplicated).Error; err != nil {
+		return nil, fmt.Errorf("查询候补失败: %v", err)
+	}
+	if duplicated > 0 {
+		return nil, fmt.Errorf("已登记相同时段的候补")
+	}
+
+	if _, _, found, err := bws.findFreeSlot(entry, time.Now()); err != nil {
+		return nil, err
+	} else if found {
+		return nil, fmt.Errorf("该时间窗口内有空闲时段，请直接预订")
+	}
+
+	if err := bws.dao().Create(entry).Error; err != nil {
+		return nil, fmt.Errorf("登记候补失败: %v", err)
+	}
+
+	log.Printf("用户登记候补: ID %d (用户ID: %d, 房间ID: %d, 房型: %s)", entry.ID, userID, entry.RoomID, entry.RoomType)
+	return bws.convertToItem(entry), nil
+}
+
+// CancelWaitlist 退出候补，已保留时段时放弃保留并取消保留的预订，时段让给下一位候补用户
+func (bws *BookingWaitlistService) CancelWaitlist(id int, userID int) error {
+	entry, err := bws.loadEntry(id, &userID)
+	if err != nil {
+		return err
+	}
+
+	switch entry.Status {
+	case app_model.WaitlistStatusWaiting, app_model.WaitlistStatusOffered:
+	default:
+		return fmt.Errorf("当前候补状态为%s，无法取消", entry.GetStatusText())
+	}
+
+	result := bws.dao().Model(&app_model.BookingWaitlist{}).
+		Where("id = ? AND status = ?", entry.ID, entry.Status).
+		Update("status", app_model.WaitlistStatusCancelled)
+	if result.Error != nil {
+		return fmt.Errorf("取消候补失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("候补状态已变更，请刷新后重试")
+	}
+
+	if entry.Status == app_model.WaitlistStatusOffered && entry.OfferBookingID != nil {
+		if _, err := NewBookingRefundService().CancelBooking(&inout.CancelBookingReq{
+			ID:     *entry.OfferBookingID,
+			Reason: "放弃候补保留",
+		}, &userID, &userID); err != nil {
+			log.Printf("取消候补保留的预订失败 (候补ID: %d): %v", entry.ID, err)
+		}
+	}
+	return nil
+}
+
+// ConfirmOffer 支付候补保留的预订，确认候补
+func (bws *BookingWaitlistService) ConfirmOffer(id int, userID int) (*inout.PayBookingResp, error) {
+	entry, err := bws.loadEntry(id, &userID)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Status != app_model.WaitlistStatusOffered || entry.OfferBookingID == nil {
+		return nil, fmt.Errorf("当前候补状态为%s，无法确认", entry.GetStatusText())
+	}
+
+	return NewBookingPaymentService().PayBooking(&inout.PayBookingReq{BookingID: *entry.OfferBookingID}, userID)
+}
+
+// GetWaitlistList 获取候补列表，userID 不为空时只查询本人的候补
+func (bws *BookingWaitlistService) GetWaitlistList(req *inout.WaitlistListReq, userID *int) (*inout.WaitlistListResp, error) {
+	query := bws.dao().Model(&app_model.BookingWaitlist{}).Preload("Room").Preload("OfferBooking")
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	} else if req.UserID > 0 {
+		query = query.Where("user_id = ?", req.UserID)
+	}
+	if req.Status > 0 {
+		query = query.Where("status = ?", req.Status)
+	}
+	if req.RoomID > 0 {
+		query = query.Where("room_id = ?", req.RoomID)
+	}
+	if req.RoomType != "" {
+		query = query.Where("room_type = ?", req.RoomType)
+	}
+	if req.StartDate != "" {
+		query = query.Where("window_start >= ?", req.StartDate)
+	}
+	if req.EndDate != "" {
+		query = query.Where("window_start <= ?", req.EndDate+" 23:59:59")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("查询候补总数失败: %v", err)
+	}
+
+	var entries []app_model.BookingWaitlist
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Offset(offset).Limit(req.PageSize).Order("id DESC").Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("查询候补列表失败: %v", err)
+	}
+
+	list := make([]*inout.WaitlistItem, 0, len(entries))
+	for i := range entries {
+		item := bws.convertToItem(&entries[i])
+		if entries[i].Status == app_model.WaitlistStatusWaiting {
+			item.Position = bws.queuePosition(&entries[i])
+		}
+		list = append(list, item)
+	}
+
+	return &inout.WaitlistListResp{
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		List:     list,
+	}, nil
+}
+
+// ========== 管理端需求统计 ==========
+
+// GetWaitlistDemand 按房间统计候补需求，按房型候补单独成行
+func (bws *BookingWaitlistService) GetWaitlistDemand(req *inout.WaitlistDemandReq) (*inout.WaitlistDemandResp, error) {
+	startDate, err := parseBookingDate(req.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("开始日期格式错误: %v", err)
+	}
+	endDate, err := parseBookingDate(req.EndDate)
+	if err != nil {
+		return nil, fmt.Errorf("结束日期格式错误: %v", err)
+	}
+	if endDate.Before(startDate) {
+		return nil, fmt.Errorf("结束日期不能早于开始日期")
+	}
+
+	query := bws.dao().Where("window_start >= ? AND window_start < ?", startDate, endDate.AddDate(0, 0, 1))
+	if req.RoomType != "" {
+		query = query.Where("room_type = ?", req.RoomType)
+	}
+	var entries []app_model.BookingWaitlist
+	if err := query.Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("查询候补失败: %v", err)
+	}
+
+	type demandGroup struct {
+		item    *inout.WaitlistDemandItem
+		users   map[int]bool
+		offered int
+	}
+	groups := make(map[string]*demandGroup)
+	summary := &demandGroup{item: &inout.WaitlistDemandItem{}, users: make(map[int]bool)}
+	var roomIDs []int
+	for _, e := range entries {
+		key := fmt.Sprintf("%d:%s", e.RoomID, e.RoomType)
+		if e.RoomID > 0 {
+			key = fmt.Sprintf("%d", e.RoomID)
+		}
+		g, ok := groups[key]
+		if !ok {
+			g = &demandGroup{
+				item:  &inout.WaitlistDemandItem{RoomID: e.RoomID, RoomType: e.RoomType},
+				users: make(map[int]bool),
+			}
+			groups[key] = g
+			if e.RoomID > 0 {
+				roomIDs = append(roomIDs, e.RoomID)
+			}
+		}
+		for _, dg := range []*demandGroup{g, summary} {
+			countWaitlistDemand(dg.item, &e)
+			dg.users[e.UserID] = true
+			if e.OfferedAt != nil {
+				dg.offered++
+			}
+		}
+	}
+
+	roomNames := make(map[int]string, len(roomIDs))
+	if len(roomIDs) > 0 {
+		var rooms []app_model.Room
+		if err := bws.dao().Select("id, room_name").Where("id IN (?)", roomIDs).Find(&rooms).Error; err != nil {
+			return nil, fmt.Errorf("查询房间失败: %v", err)
+		}
+		for _, r := range rooms {
+			roomNames[r.ID] = r.RoomName
+		}
+	}
+
+	finish := func(g *demandGroup) *inout.WaitlistDemandItem {
+		g.item.Users = len(g.users)
+		if g.offered > 0 {
+			g.item.ConversionRate = math.Round(float64(g.item.Confirmed)/float64(g.offered)*10000) / 100
+		}
+		return g.item
+	}
+
+	resp := &inout.WaitlistDemandResp{Rooms: make([]*inout.WaitlistDemandItem, 0, len(groups))}
+	for _, g := range groups {
+		item := finish(g)
+		if item.RoomID > 0 {
+			item.RoomName = roomNames[item.RoomID]
+		} else {
+			item.RoomName = "任意" + (&RoomService{}).getRoomTypeText(item.RoomType)
+		}
+		resp.Rooms = append(resp.Rooms, item)
+	}
+	resp.Summary = *finish(summary)
+	sort.Slice(resp.Rooms, func(i, j int) bool {
+		if resp.Rooms[i].Total != resp.Rooms[j].Total {
+			return resp.Rooms[i].Total > resp.Rooms[j].Total
+		}
+		return resp.Rooms[i].RoomID < resp.Rooms[j].RoomID
+	})
+
+	return resp, nil
+}
+
+// countWaitlistDemand 按候补状态累计需求统计
+func countWaitlistDemand(item *inout.WaitlistDemandItem, e *app_model.BookingWaitlist) {
+	item.Total++
+	item.DemandHours += e.Hours
+	switch e.Status {
+	case app_model.WaitlistStatusWaiting:
+		item.Waiting++
+	case app_model.WaitlistStatusOffered:
+		item.Offered++
+	case app_model.WaitlistStatusConfirmed:
+		item.Confirmed++
+	case app_model.WaitlistStatusExpired:
+		item.Expired++
+	case app_model.WaitlistStatusCancelled:
+		item.Cancelled++
+	}
+}
+
+// ========== 时段释放与保留 ==========
+
+// OnBookingReleased 预订取消释放时段后，按登记顺序为匹配的候补用户保留时段
+// 被取消的预订本身是候补保留时，该候补失效，时段顺延给下一位
+func (bws *BookingWaitlistService) OnBookingReleased(bookingID int) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("处理候补时发生panic: %v", r)
+		}
+	}()
+
+	var booking app_model.RoomBooking
+	if err := db.Dao.First(&booking, bookingID).Error; err != nil {
+		log.Printf("查询预订失败 (ID: %d): %v", bookingID, err)
+		return
+	}
+
+	if err := db.Dao.Model(&app_model.BookingWaitlist{}).
+		Where("offer_booking_id = ? AND status = ?", booking.ID, app_model.WaitlistStatusOffered).
+		Update("status", app_model.WaitlistStatusExpired).Error; err != nil {
+		log.Printf("更新候补状态失败 (预订: %s): %v", booking.BookingNo, err)
+	}
+
+	var room app_model.Room
+	if err := db.Dao.First(&room, booking.RoomID).Error; err != nil {
+		log.Printf("查询房间信息失败 (房间ID: %d): %v", booking.RoomID, err)
+		return
+	}
+	buffer := time.Duration(room.CleaningMin) * time.Minute
+
+	var entryIDs []int
+	if err := db.Dao.Model(&app_model.BookingWaitlist{}).
+		Where("status = ? AND (room_id = ? OR (room_id = 0 AND room_type = ?))",
+			app_model.WaitlistStatusWaiting, room.ID, room.RoomType).
+		Where("window_start < ? AND window_end > ? AND window_end > ?",
+			booking.EndTime.Add(buffer), booking.StartTime.Add(-buffer), time.Now()).
+		Order("id ASC").Limit(waitlistScanBatch).
+		Pluck("id", &entryIDs).Error; err != nil {
+		log.Printf("查询候补失败 (预订: %s): %v", booking.BookingNo, err)
+		return
+	}
+
+	for _, id := range entryIDs {
+		if _, err := bws.offerEntry(id); err != nil {
+			log.Printf("候补保留时段失败 (候补ID: %d): %v", id, err)
+		}
+	}
+}
+
+// ProcessWaitlist 调度器定时处理候补：同步保留预订的结果、使时间窗口已过的候补失效，并为候补用户尝试保留空闲时段
+func (bws *BookingWaitlistService) ProcessWaitlist(now time.Time) {
+	// 保留的预订已支付视为确认，已取消视为失效
+	held := db.Dao.Model(&app_model.RoomBooking{}).Select("id").
+		Where("status IN (?)", []int{app_model.BookingStatusPaid, app_model.BookingStatusInUse, app_model.BookingStatusCompleted})
+	if err := db.Dao.Model(&app_model.BookingWaitlist{}).
+		Where("status = ? AND offer_booking_id IN (?)", app_model.WaitlistStatusOffered, held).
+		Update("status", app_model.WaitlistStatusConfirmed).Error; err != nil {
+		log.Printf("同步候补确认状态失败: %v", err)
+	}
+	released := db.Dao.Model(&app_model.RoomBooking{}).Select("id").
+		Where("status IN (?)", []int{app_model.BookingStatusCancelled, app_model.BookingStatusRefunded})
+	if err := db.Dao.Model(&app_model.BookingWaitlist{}).
+		Where("status = ? AND offer_booking_id IN (?)", app_model.WaitlistStatusOffered, released).
+		Update("status", app_model.WaitlistStatusExpired).Error; err != nil {
+		log.Printf("同步候补失效状态失败: %v", err)
+	}
+
+	// 剩余时间窗口不足预订时长的候补失效
+	if err := db.Dao.Model(&app_model.BookingWaitlist{}).
+		Where("status = ? AND window_end < DATE_ADD(?, INTERVAL hours HOUR)", app_model.WaitlistStatusWaiting, now).
+		Update("status", app_model.WaitlistStatusExpired).Error; err != nil {
+		log.Printf("处理过期候补失败: %v", err)
+	}
+
+	var entryIDs []int
+	if err := db.Dao.Model(&app_model.BookingWaitlist{}).
+		Where("status = ?", app_model.WaitlistStatusWaiting).
+		Order("id ASC").Limit(waitlistScanBatch).
+		Pluck("id", &entryIDs).Error; err != nil {
+		log.Printf("查询候补失败: %v", err)
+		return
+	}
+	for _, id := range entryIDs {
+		if _, err := bws.offerEntry(id); err != nil {
+			log.Printf("候补保留时段失败 (候补ID: %d): %v", id, err)
+		}
+	}
+}
+
+// MarkConfirmed 候补保留的预订支付成功后确认候补
+func (bws *BookingWaitlistService) MarkConfirmed(bookingID int) {
+	if err := db.Dao.Model(&app_model.BookingWaitlist{}).
+		Where("offer_booking_id = ? AND status = ?", bookingID, app_model.WaitlistStatusOffered).
+		Update("status", app_model.WaitlistStatusConfirmed).Error; err != nil {
+		log.Printf("更新候补确认状态失败 (预订ID: %d): %v", bookingID, err)
+	}
+}
+
+// offerEntry 在候补的时间窗口内查找空闲时段，锁定房间后生成待支付预订为候补用户保留
+// 返回是否成功保留
+func (bws *BookingWaitlistService) offerEntry(entryID int) (bool, error) {
+	var entry app_model.BookingWaitlist
+	if err := db.Dao.First(&entry, entryID).Error; err != nil {
+		return false, fmt.Errorf("查询候补失败: %v", err)
+	}
+	if entry.Status != app_model.WaitlistStatusWaiting {
+		return false, nil
+	}
+
+	room, startTime, found, err := bws.findFreeSlot(&entry, time.Now())
+	if err != nil || !found {
+		return false, err
+	}
+
+	roomLock, err := lockRoomForBooking(room.ID)
+	if err != nil {
+		return false, err
+	}
+	defer roomLock.Release()
+
+	endTime := startTime.Add(time.Duration(entry.Hours) * time.Hour)
+	rs := &RoomService{}
+	var booking *app_model.RoomBooking
+	err = db.Dao.Transaction(func(tx *gorm.DB) error {
+		var locked app_model.BookingWaitlist
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, entry.ID).Error; err != nil {
+			return fmt.Errorf("查询候补失败: %v", err)
+		}
+		if locked.Status != app_model.WaitlistStatusWaiting {
+			return errBookingOverlap
+		}
+
+		if err := lockRoomAndCheckOverlap(tx, room, room.ID, startTime, endTime, 0); err != nil {
+			return err
+		}
+
+		price, err := priceBooking(room, nil, startTime, entry.Hours)
+		if err != nil {
+			return err
+		}
+		breakdownBytes, _ := json.Marshal(price.Quote)
+		booking = &app_model.RoomBooking{
+			TenantsId:      room.TenantsId,
+			RoomID:         room.ID,
+			UserID:         entry.UserID,
+			BookingNo:      rs.generateBookingNo(),
+			StartTime:      startTime,
+			EndTime:        endTime,
+			Hours:          entry.Hours,
+			TotalAmount:    price.TotalAmount,
+			Status:         app_model.BookingStatusPending,
+			ContactName:    entry.ContactName,
+			ContactPhone:   entry.ContactPhone,
+			Remarks:        entry.Remarks,
+			OriginalPrice:  price.OriginalPrice,
+			DiscountAmount: price.DiscountAmount,
+			PriceBreakdown: string(breakdownBytes),
+		}
+		if err := tx.Create(booking).Error; err != nil {
+			return fmt.Errorf("创建保留预订失败: %v", err)
+		}
+
+		now := time.Now()
+		return tx.Model(&app_model.BookingWaitlist{}).Where("id = ?", entry.ID).Updates(map[string]interface{}{
+			"status":           app_model.WaitlistStatusOffered,
+			"offer_booking_id": booking.ID,
+			"offered_at":       now,
+			"tenants_id":       room.TenantsId,
+		}).Error
+	})
+	if slotConflictReason(err) != "" {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	log.Printf("候补时段已保留: 候补ID %d -> 预订 %s (用户ID: %d, 房间ID: %d)", entry.ID, booking.BookingNo, entry.UserID, room.ID)
+	go bws.notifier.NotifyWaitlistOffer(booking.ID)
+	return true, nil
+}
+
+// findFreeSlot 在候补的时间窗口内查找最早的空闲时段，按房型候补时依次查找同房型的可用房间
+func (bws *BookingWaitlistService) findFreeSlot(entry *app_model.BookingWaitlist, now time.Time) (*app_model.Room, time.Time, bool, error) {
+	var rooms []app_model.Room
+	query := db.Dao.Where("status = ?", app_model.RoomStatusAvailable)
+	if entry.RoomID > 0 {
+		query = query.Where("id = ?", entry.RoomID)
+	} else {
+		query = query.Where("room_type = ?", entry.RoomType)
+	}
+	if err := query.Order("id ASC").Find(&rooms).Error; err != nil {
+		return nil, time.Time{}, false, fmt.Errorf("查询房间失败: %v", err)
+	}
+
+	// 开始时间按窗口开始对齐，跳过已过去的时间
+	first := entry.WindowStart
+	if first.Before(now) {
+		steps := int64(now.Sub(first)/waitlistSlotStep) + 1
+		first = first.Add(time.Duration(steps) * waitlistSlotStep)
+	}
+	duration := time.Duration(entry.Hours) * time.Hour
+	if first.Add(duration).After(entry.WindowEnd) {
+		return nil, time.Time{}, false, nil
+	}
+
+	rs := &RoomService{}
+	for i := range rooms {
+		busy, err := rs.loadBusyRanges(&rooms[i], first, entry.WindowEnd)
+		if err != nil {
+			return nil, time.Time{}, false, err
+		}
+		for start := first; !start.Add(duration).After(entry.WindowEnd); start = start.Add(waitlistSlotStep) {
+			if !overlapsBusy(busy, start, start.Add(duration)) {
+				return &rooms[i], start, true, nil
+			}
+		}
+	}
+	return nil, time.Time{}, false, nil
+}
+
+// overlapsBusy 时段 [start, end) 是否与任一占用区间重叠
+func overlapsBusy(busy []timeRange, start, end time.Time) bool {
+	for _, r := range busy {
+		if r.start.Before(end) && r.end.After(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadEntry 加载候补，userID 不为空时只查询本人的候补
+func (bws *BookingWaitlistService) loadEntry(id int, userID *int) (*app_model.BookingWaitlist, error) {
+	query := bws.dao().Model(&app_model.BookingWaitlist{})
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+
+	var entry app_model.BookingWaitlist
+	if err := query.First(&entry, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("候补不存在")
+		}
+		return nil, fmt.Errorf("查询候补失败: %v", err)
+	}
+	return &entry, nil
+}
+
+// queuePosition 候补中的排队位置：同一房间（或房型）更早登记且仍在候补的数量加一
+func (bws *BookingWaitlistService) queuePosition(entry *app_model.BookingWaitlist) int {
+	var ahead int64
+	if err := db.Dao.Model(&app_model.BookingWaitlist{}).
+		Where("status = ? AND id < ? AND room_id = ? AND room_type = ? AND window_start < ? AND window_end > ?",
+			app_model.WaitlistStatusWaiting, entry.ID, entry.RoomID, entry.RoomType, entry.WindowEnd, entry.WindowStart).
+		Count(&ahead).Error; err != nil {
+		log.Printf("查询候补排队位置失败 (ID: %d): %v", entry.ID, err)
+	}
+	return int(ahead) + 1
+}
+
+// convertToItem 转换候补模型为响应
+func (bws *BookingWaitlistService) convertToItem(entry *app_model.BookingWaitlist) *inout.WaitlistItem {
+	item := &inout.WaitlistItem{
+		ID:             entry.ID,
+		UserID:         entry.UserID,
+		RoomID:         entry.RoomID,
+		RoomType:       entry.RoomType,
+		RoomTypeText:   (&RoomService{}).getRoomTypeText(entry.RoomType),
+		WindowStart:    entry.WindowStart,
+		WindowEnd:      entry.WindowEnd,
+		Hours:          entry.Hours,
+		ContactName:    entry.ContactName,
+		ContactPhone:   entry.ContactPhone,
+		Remarks:        entry.Remarks,
+		Status:         entry.Status,
+		StatusText:     entry.GetStatusText(),
+		CreateTime:     entry.CreateTime,
+		OfferBookingID: entry.OfferBookingID,
+		OfferedAt:      entry.OfferedAt,
+	}
+	if entry.Room != nil {
+		item.RoomName = entry.Room.RoomName
+	}
+	if b := entry.OfferBooking; b != nil {
+		item.OfferBookingNo = b.BookingNo
+		item.OfferStartTime = &b.StartTime
+		item.OfferEndTime = &b.EndTime
+		item.OfferAmount = b.TotalAmount
+		if entry.Status == app_model.WaitlistStatusOffered {
+			expireAt := b.CreateTime.Add(BookingPaymentWindow())
+			item.OfferExpireAt = &expireAt
+		}
+	}
+	return item
+}
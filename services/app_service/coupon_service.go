@@ -0,0 +1,677 @@
+package app_service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"nasa-go-admin/db"
+	"nasa-go-admin/inout"
+	"nasa-go-admin/model/app_model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CouponService 优惠券服务 - 模板管理、发放、下单核销与退回
+type CouponService struct {
+	ctx context.Context
+}
+
+// NewCouponService 创建优惠券服务
+func NewCouponService() *CouponService {
+	return &CouponService{}
+}
+
+// WithContext 返回绑定请求上下文的优惠券服务，管理端传入 gin.Context 后按租户隔离数据
+func (cs *CouponService) WithContext(ctx context.Context) *CouponService {
+	return &CouponService{ctx: ctx}
+}
+
+// dao 获取数据库连接，带上下文时由 GORM 租户插件追加 tenants_id 条件
+func (cs *CouponService) dao() *gorm.DB {
+	if cs.ctx != nil {
+		return db.Dao.WithContext(cs.ctx)
+	}
+	return db.Dao
+}
+
+// CouponRedemption 下单核销优惠券的参数
+type CouponRedemption struct {
+	UserID    int
+	Code      string  // 用户券码或推广码，推广码会先为用户领取一张再核销
+	OrderType string  // goods/booking
+	OrderNo   string  // 商品订单号或预订单号
+	TenantsId int     // 订单所属商家
+	TargetID  int     // 商品ID或房间ID
+	Amount    float64 // 使用优惠券前的应付金额
+}
+
+// ========== 模板管理 ==========
+
+// CreateTemplate 创建优惠券模板
+func (cs *CouponService) CreateTemplate(req *inout.CreateCouponTemplateReq) (*app_model.CouponTemplate, error) {
+	template := &app_model.CouponTemplate{IsActive: true}
+	if err := cs.fillTemplate(template, req); err != nil {
+		return nil, err
+	}
+
+	if err := cs.dao().Create(template).Error; err != nil {
+		return nil, fmt.Errorf("创建优惠券模板失败: %v", err)
+	}
+
+	log.Printf("优惠券模板已创建: %s (ID: %d)", template.Name, template.ID)
+	return template, nil
+}
+
+// UpdateTemplate 更新优惠券模板
+func (cs *CouponService) UpdateTemplate(req *inout.UpdateCouponTemplateReq) (*app_model.CouponTemplate, error) {
+	var template app_model.CouponTemplate
+	if err := cs.dao().First(&template, req.ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("优惠券模板不存在")
+		}
+		return nil, fmt.Errorf("查询优惠券模板失败: %v", err)
+	}
+
+	if err := cs.fillTemplate(&template, &req.CreateCouponTemplateReq); err != nil {
+		return nil, err
+	}
+	if template.TotalLimit > 0 && template.TotalLimit < template.IssuedCount {
+		return nil, fmt.Errorf("发放总量不能少于已发放数量 %d", template.IssuedCount)
+	}
+	template.IsActive = req.IsActive
+
+	// 发放和使用计数由核销流程原子维护，这里不覆盖
+	if err := cs.dao().Omit("issued_count", "used_count").Save(&template).Error; err != nil {
+		return nil, fmt.Errorf("更新优惠券模板失败: %v", err)
+	}
+
+	log.Printf("优惠券模板已更新: %s (ID: %d)", template.Name, template.ID)
+	return &template, nil
+}
+
+// GetTemplateList 获取优惠券模板列表
+func (cs *CouponService) GetTemplateList(req *inout.CouponTemplateListReq) (*inout.CouponListResp, error) {
+	query := cs.dao().Model(&app_model.CouponTemplate{})
+	if req.Name != "" {
+		query = query.Where("name LIKE ?", "%"+req.Name+"%")
+	}
+	if req.Scope != "" {
+		query = query.Where("scope = ?", req.Scope)
+	}
+	if req.CouponType != "" {
+		query = query.Where("coupon_type = ?", req.CouponType)
+	}
+	if req.IsActive != nil {
+		query = query.Where("is_active = ?", *req.IsActive)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("查询优惠券模板总数失败: %v", err)
+	}
+
+	var templates []app_model.CouponTemplate
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(req.PageSize).Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("查询优惠券模板列表失败: %v", err)
+	}
+
+	return &inout.CouponListResp{
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		List:     templates,
+	}, nil
+}
+
+// DeleteTemplate 删除优惠券模板，已发放过的模板只能停用
+func (cs *CouponService) DeleteTemplate(id int) error {
+	var template app_model.CouponTemplate
+	if err := cs.dao().First(&template, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("优惠券模板不存在")
+		}
+		return fmt.Errorf("查询优惠券模板失败: %v", err)
+	}
+
+	if template.IssuedCount > 0 {
+		return fmt.Errorf("优惠券已发放 %d 张，无法删除，请停用该模板", template.IssuedCount)
+	}
+
+	if err := cs.dao().Delete(&template).Error; err != nil {
+		return fmt.Errorf("删除优惠券模板失败: %v", err)
+	}
+
+	log.Printf("优惠券模板已删除: %s (ID: %d)", template.Name, template.ID)
+	return nil
+}
+
+// ========== 发放与领取 ==========
+
+// IssueCoupons 向会员发放优惠券，单个会员失败不影响其他会员
+func (cs *CouponService) IssueCoupons(req *inout.IssueCouponReq) (*inout.IssueCouponResp, error) {
+	var template app_model.CouponTemplate
+	if err := cs.dao().First(&template, req.TemplateID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("优惠券模板不存在")
+		}
+		return nil, fmt.Errorf("查询优惠券模板失败: %v", err)
+	}
+
+	quantity := req.Quantity
+	if quantity <= 0 {
+		quantity = 1
+	}
+
+	// 只向存在的会员发放
+	var existingIDs []int
+	if err := db.Dao.Model(&app_model.UserApp{}).Where("id IN ?", req.UserIDs).Pluck("id", &existingIDs).Error; err != nil {
+		return nil, fmt.Errorf("查询会员失败: %v", err)
+	}
+	existing := make(map[int]bool, len(existingIDs))
+	for _, id := range existingIDs {
+		existing[id] = true
+	}
+
+	resp := &inout.IssueCouponResp{TemplateID: template.ID, Failed: map[int]string{}}
+	for _, userID := range req.UserIDs {
+		if !existing[userID] {
+			resp.Failed[userID] = "会员不存在"
+			continue
+		}
+
+		err := cs.dao().Transaction(func(tx *gorm.DB) error {
+			for i := 0; i < quantity; i++ {
+				if _, err := cs.issue(tx, template.ID, userID, app_model.CouponSourceIssue); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			resp.Failed[userID] = err.Error()
+			continue
+		}
+		resp.Issued += quantity
+	}
+
+	log.Printf("优惠券已发放: %s (模板ID: %d, 成功: %d 张, 失败会员: %d)",
+		template.Name, template.ID, resp.Issued, len(resp.Failed))
+	return resp, nil
+}
+
+// ClaimByPromoCode 用户凭推广码领取优惠券
+func (cs *CouponService) ClaimByPromoCode(userID int, promoCode string) (*inout.UserCouponItem, error) {
+	var coupon *app_model.UserCoupon
+	err := cs.dao().Transaction(func(tx *gorm.DB) error {
+		template, err := cs.findPromoTemplate(tx, promoCode)
+		if err != nil {
+			return err
+		}
+		coupon, err = cs.issue(tx, template.ID, userID, app_model.CouponSourcePromo)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("用户 %d 通过推广码领取优惠券: %s", userID, coupon.CouponCode)
+	return cs.toUserCouponItem(coupon, time.Now()), nil
+}
+
+// issue 在事务中为用户发放一张优惠券
+// 锁定模板行后再校验每人限领数量，发放总量通过条件更新原子递增，保证并发下不超发
+func (cs *CouponService) issue(tx *gorm.DB, templateID, userID int, source string) (*app_model.UserCoupon, error) {
+	var template app_model.CouponTemplate
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&template, templateID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("优惠券模板不存在")
+		}
+		return nil, fmt.Errorf("查询优惠券模板失败: %v", err)
+	}
+
+	now := time.Now()
+	if !template.IsActive {
+		return nil, fmt.Errorf("优惠券活动未开启")
+	}
+	if template.ValidTo != nil && !now.Before(*template.ValidTo) {
+		return nil, fmt.Errorf("优惠券活动已结束")
+	}
+
+	if template.PerUserLimit > 0 {
+		var owned int64
+		if err := tx.Model(&app_model.UserCoupon{}).
+			Where("template_id = ? AND user_id = ?", template.ID, userID).
+			Count(&owned).Error; err != nil {
+			return nil, fmt.Errorf("查询已领取数量失败: %v", err)
+		}
+		if int(owned) >= template.PerUserLimit {
+			return nil, fmt.Errorf("每人限领 %d 张，已达领取上限", template.PerUserLimit)
+		}
+	}
+
+	result := tx.Model(&app_model.CouponTemplate{}).
+		Where("id = ? AND (total_limit = 0 OR issued_count < total_limit)", template.ID).
+		Update("issued_count", gorm.Expr("issued_count + 1"))
+	if result.Error != nil {
+		return nil, fmt.Errorf("更新发放数量失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("优惠券已领完")
+	}
+
+	validFrom, validTo := template.ValidityFor(now)
+	coupon := &app_model.UserCoupon{
+		TenantsId:  template.TenantsId,
+		TemplateID: template.ID,
+		UserID:     userID,
+		CouponCode: cs.generateCouponCode(),
+		Status:     app_model.UserCouponStatusUnused,
+		Source:     source,
+		ValidFrom:  validFrom,
+		ValidTo:    validTo,
+		Template:   &template,
+	}
+	if err := tx.Omit("Template").Create(coupon).Error; err != nil {
+		return nil, fmt.Errorf("发放优惠券失败: %v", err)
+	}
+
+	return coupon, nil
+}
+
+// ========== 查询 ==========
+
+// GetUserCouponList 获取用户优惠券列表
+func (cs *CouponService) GetUserCouponList(req *inout.UserCouponListReq) (*inout.CouponListResp, error) {
+	query := cs.dao().Model(&app_model.UserCoupon{})
+	if req.TemplateID > 0 {
+		query = query.Where("template_id = ?", req.TemplateID)
+	}
+	if req.UserID > 0 {
+		query = query.Where("user_id = ?", req.UserID)
+	}
+
+	// 已过期的未使用券按过期状态筛选
+	now := time.Now()
+	switch req.Status {
+	case app_model.UserCouponStatusUnused:
+		query = query.Where("status = ? AND valid_to > ?", app_model.UserCouponStatusUnused, now)
+	case app_model.UserCouponStatusExpired:
+		query = query.Where("status = ? OR (status = ? AND valid_to <= ?)",
+			app_model.UserCouponStatusExpired, app_model.UserCouponStatusUnused, now)
+	case 0:
+	default:
+		query = query.Where("status = ?", req.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("查询优惠券总数失败: %v", err)
+	}
+
+	var coupons []app_model.UserCoupon
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Preload("Template").Order("id DESC").Offset(offset).Limit(req.PageSize).Find(&coupons).Error; err != nil {
+		return nil, fmt.Errorf("查询优惠券列表失败: %v", err)
+	}
+
+	list := make([]*inout.UserCouponItem, 0, len(coupons))
+	for i := range coupons {
+		list = append(list, cs.toUserCouponItem(&coupons[i], now))
+	}
+
+	return &inout.CouponListResp{
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		List:     list,
+	}, nil
+}
+
+// ========== 下单核销与退回 ==========
+
+// Redeem 在下单事务中核销优惠券，返回使用的券和抵扣金额
+// 券行加锁并以 status 为条件更新，同一张券并发下单只有一个能成功
+func (cs *CouponService) Redeem(tx *gorm.DB, r *CouponRedemption) (*app_model.UserCoupon, float64, error) {
+	code := strings.ToUpper(strings.TrimSpace(r.Code))
+	if code == "" {
+		return nil, 0, fmt.Errorf("优惠券码不能为空")
+	}
+
+	var coupon app_model.UserCoupon
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("coupon_code = ? AND user_id = ?", code, r.UserID).
+		First(&coupon).Error
+	if err == gorm.ErrRecordNotFound {
+		// 不是用户持有的券码时按推广码领取后使用
+		template, findErr := cs.findPromoTemplate(tx, code)
+		if findErr != nil {
+			return nil, 0, fmt.Errorf("优惠券不存在")
+		}
+		issued, issueErr := cs.issue(tx, template.ID, r.UserID, app_model.CouponSourcePromo)
+		if issueErr != nil {
+			return nil, 0, issueErr
+		}
+		coupon = *issued
+	} else if err != nil {
+		return nil, 0, fmt.Errorf("查询优惠券失败: %v", err)
+	}
+
+	var template app_model.CouponTemplate
+	if err := tx.First(&template, coupon.TemplateID).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询优惠券模板失败: %v", err)
+	}
+
+	now := time.Now()
+	switch {
+	case coupon.Status == app_model.UserCouponStatusUsed:
+		return nil, 0, fmt.Errorf("优惠券已被使用")
+	case coupon.Status != app_model.UserCouponStatusUnused:
+		return nil, 0, fmt.Errorf("优惠券不可用: %s", coupon.GetStatusText())
+	case now.Before(coupon.ValidFrom):
+		return nil, 0, fmt.Errorf("优惠券尚未生效")
+	case !now.Before(coupon.ValidTo):
+		return nil, 0, fmt.Errorf("优惠券已过期")
+	case !template.IsActive:
+		return nil, 0, fmt.Errorf("优惠券活动已停止")
+	}
+
+	if template.TenantsId != 0 && template.TenantsId != r.TenantsId {
+		return nil, 0, fmt.Errorf("该优惠券不适用于当前商家")
+	}
+	if !template.AppliesTo(r.OrderType, r.TargetID) {
+		return nil, 0, fmt.Errorf("该优惠券不适用于当前%s", cs.getOrderTypeText(r.OrderType))
+	}
+
+	if template.FirstOrderOnly {
+		first, err := cs.isFirstOrder(tx, r.UserID)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !first {
+			return nil, 0, fmt.Errorf("该优惠券仅限首单使用")
+		}
+	}
+
+	discount := template.CalculateDiscount(r.Amount)
+	if discount <= 0 {
+		return nil, 0, fmt.Errorf("订单金额未达到优惠券使用门槛 %.2f", template.Threshold)
+	}
+
+	result := tx.Model(&app_model.UserCoupon{}).
+		Where("id = ? AND status = ?", coupon.ID, app_model.UserCouponStatusUnused).
+		Updates(map[string]interface{}{
+			"status":          app_model.UserCouponStatusUsed,
+			"order_type":      r.OrderType,
+			"order_no":        r.OrderNo,
+			"discount_amount": discount,
+			"used_time":       now,
+		})
+	if result.Error != nil {
+		return nil, 0, fmt.Errorf("核销优惠券失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, 0, fmt.Errorf("优惠券已被使用")
+	}
+
+	if err := tx.Model(&app_model.CouponTemplate{}).
+		Where("id = ?", template.ID).
+		Update("used_count", gorm.Expr("used_count + 1")).Error; err != nil {
+		return nil, 0, fmt.Errorf("更新优惠券使用数量失败: %v", err)
+	}
+
+	coupon.Status = app_model.UserCouponStatusUsed
+	coupon.OrderType = r.OrderType
+	coupon.OrderNo = r.OrderNo
+	coupon.DiscountAmount = discount
+	coupon.UsedTime = &now
+
+	log.Printf("优惠券已核销: %s (用户ID: %d, 订单: %s, 抵扣: %.2f)", coupon.CouponCode, r.UserID, r.OrderNo, discount)
+	return &coupon, discount, nil
+}
+
+// ReturnCoupon 订单取消或退款后在同一事务中退回优惠券，订单未使用优惠券时不做处理
+// 退回时已过有效期的券直接置为已过期
+func (cs *CouponService) ReturnCoupon(tx *gorm.DB, orderType, orderNo string) error {
+	var coupon app_model.UserCoupon
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_type = ? AND order_no = ? AND status = ?", orderType, orderNo, app_model.UserCouponStatusUsed).
+		First(&coupon).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("查询订单优惠券失败: %v", err)
+	}
+
+	now := time.Now()
+	newStatus := app_model.UserCouponStatusUnused
+	if !now.Before(coupon.ValidTo) {
+		newStatus = app_model.UserCouponStatusExpired
+	}
+
+	result := tx.Model(&app_model.UserCoupon{}).
+		Where("id = ? AND status = ?", coupon.ID, app_model.UserCouponStatusUsed).
+		Updates(map[string]interface{}{
+			"status":          newStatus,
+			"order_type":      "",
+			"order_no":        "",
+			"discount_amount": 0,
+			"used_time":       nil,
+			"returned_time":   now,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("退回优惠券失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	if err := tx.Model(&app_model.CouponTemplate{}).
+		Where("id = ? AND used_count > 0", coupon.TemplateID).
+		Update("used_count", gorm.Expr("used_count - 1")).Error; err != nil {
+		return fmt.Errorf("更新优惠券使用数量失败: %v", err)
+	}
+
+	log.Printf("优惠券已退回: %s (订单: %s)", coupon.CouponCode, orderNo)
+	return nil
+}
+
+// ========== 辅助方法 ==========
+
+// fillTemplate 校验请求并写入模板字段
+func (cs *CouponService) fillTemplate(template *app_model.CouponTemplate, req *inout.CreateCouponTemplateReq) error {
+	if req.CouponType == app_model.CouponTypePercent && req.Value >= 100 {
+		return fmt.Errorf("折扣券的折扣百分比必须小于100")
+	}
+
+	scope := req.Scope
+	if scope == "" {
+		scope = app_model.CouponScopeAll
+	}
+	if scope == app_model.CouponScopeAll && len(req.ScopeIDs) > 0 {
+		return fmt.Errorf("全场通用券不能指定适用的商品或房间")
+	}
+	if err := cs.validateScopeIDs(scope, req.ScopeIDs); err != nil {
+		return err
+	}
+
+	var validFrom, validTo *time.Time
+	if req.ValidFrom != "" {
+		t, err := time.ParseInLocation("2006-01-02 15:04:05", req.ValidFrom, time.Local)
+		if err != nil {
+			return fmt.Errorf("有效期开始时间格式错误: %v", err)
+		}
+		validFrom = &t
+	}
+	if req.ValidTo != "" {
+		t, err := time.ParseInLocation("2006-01-02 15:04:05", req.ValidTo, time.Local)
+		if err != nil {
+			return fmt.Errorf("有效期结束时间格式错误: %v", err)
+		}
+		validTo = &t
+	}
+	if validFrom != nil && validTo != nil && !validTo.After(*validFrom) {
+		return fmt.Errorf("有效期结束时间必须晚于开始时间")
+	}
+
+	var promoCode *string
+	if code := strings.ToUpper(strings.TrimSpace(req.PromoCode)); code != "" {
+		var count int64
+		if err := db.Dao.Model(&app_model.CouponTemplate{}).
+			Where("promo_code = ? AND id <> ?", code, template.ID).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("检查推广码失败: %v", err)
+		}
+		if count > 0 {
+			return fmt.Errorf("推广码已被使用")
+		}
+		promoCode = &code
+	}
+
+	scopeIDs := make([]string, 0, len(req.ScopeIDs))
+	for _, id := range req.ScopeIDs {
+		scopeIDs = append(scopeIDs, strconv.Itoa(id))
+	}
+
+	template.Name = req.Name
+	template.Description = req.Description
+	template.CouponType = req.CouponType
+	template.Value = req.Value
+	template.MaxDiscount = req.MaxDiscount
+	template.Threshold = req.Threshold
+	template.FirstOrderOnly = req.FirstOrderOnly
+	template.Scope = scope
+	template.ScopeIDs = strings.Join(scopeIDs, ",")
+	template.PromoCode = promoCode
+	template.ValidFrom = validFrom
+	template.ValidTo = validTo
+	template.ValidDays = req.ValidDays
+	template.TotalLimit = req.TotalLimit
+	template.PerUserLimit = req.PerUserLimit
+	return nil
+}
+
+// validateScopeIDs 校验适用的商品或房间存在且属于当前商家
+func (cs *CouponService) validateScopeIDs(scope string, ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var count int64
+	switch scope {
+	case app_model.CouponScopeGoods:
+		if err := db.Dao.Model(&app_model.AppGoods{}).Where("id IN ?", ids).Count(&count).Error; err != nil {
+			return fmt.Errorf("查询商品失败: %v", err)
+		}
+	case app_model.CouponScopeRoom:
+		if err := cs.dao().Model(&app_model.Room{}).Where("id IN ?", ids).Count(&count).Error; err != nil {
+			return fmt.Errorf("查询房间失败: %v", err)
+		}
+	}
+
+	if int(count) != len(ids) {
+		return fmt.Errorf("适用的商品或房间不存在")
+	}
+	return nil
+}
+
+// findPromoTemplate 按推广码查找启用的优惠券模板
+func (cs *CouponService) findPromoTemplate(tx *gorm.DB, promoCode string) (*app_model.CouponTemplate, error) {
+	code := strings.ToUpper(strings.TrimSpace(promoCode))
+	if code == "" {
+		return nil, fmt.Errorf("推广码不能为空")
+	}
+
+	var template app_model.CouponTemplate
+	if err := tx.Where("promo_code = ? AND is_active = ?", code, true).First(&template).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("推广码无效")
+		}
+		return nil, fmt.Errorf("查询推广码失败: %v", err)
+	}
+	return &template, nil
+}
+
+// isFirstOrder 用户是否还没有有效的商品订单和房间预订
+func (cs *CouponService) isFirstOrder(tx *gorm.DB, userID int) (bool, error) {
+	var orders int64
+	if err := tx.Model(&app_model.AppOrder{}).
+		Where("user_id = ? AND status <> ?", userID, string(StatusCancelled)).
+		Count(&orders).Error; err != nil {
+		return false, fmt.Errorf("查询历史订单失败: %v", err)
+	}
+	if orders > 0 {
+		return false, nil
+	}
+
+	var bookings int64
+	if err := tx.Model(&app_model.RoomBooking{}).
+		Where("user_id = ? AND status <> ?", userID, app_model.BookingStatusCancelled).
+		Count(&bookings).Error; err != nil {
+		return false, fmt.Errorf("查询历史预订失败: %v", err)
+	}
+	return bookings == 0, nil
+}
+
+// toUserCouponItem 转换用户优惠券为响应结构
+func (cs *CouponService) toUserCouponItem(coupon *app_model.UserCoupon, now time.Time) *inout.UserCouponItem {
+	item := &inout.UserCouponItem{
+		ID:             coupon.ID,
+		TemplateID:     coupon.TemplateID,
+		CouponCode:     coupon.CouponCode,
+		Status:         coupon.Status,
+		ValidFrom:      coupon.ValidFrom,
+		ValidTo:        coupon.ValidTo,
+		OrderType:      coupon.OrderType,
+		OrderNo:        coupon.OrderNo,
+		DiscountAmount: coupon.DiscountAmount,
+		UsedTime:       coupon.UsedTime,
+	}
+
+	// 未使用的券过期后不会自动改状态，展示时按有效期判断
+	if item.Status == app_model.UserCouponStatusUnused && !now.Before(coupon.ValidTo) {
+		item.Status = app_model.UserCouponStatusExpired
+	}
+	item.StatusText = (&app_model.UserCoupon{Status: item.Status}).GetStatusText()
+
+	if t := coupon.Template; t != nil {
+		item.Name = t.Name
+		item.Description = t.Description
+		item.CouponType = t.CouponType
+		item.Value = t.Value
+		item.MaxDiscount = t.MaxDiscount
+		item.Threshold = t.Threshold
+		item.FirstOrderOnly = t.FirstOrderOnly
+		item.Scope = t.Scope
+	}
+	return item
+}
+
+// getOrderTypeText 获取订单类型文本
+func (cs *CouponService) getOrderTypeText(orderType string) string {
+	switch orderType {
+	case app_model.CouponOrderGoods:
+		return "商品"
+	case app_model.CouponOrderBooking:
+		return "房间"
+	default:
+		return "订单"
+	}
+}
+
+// generateCouponCode 生成券码
+func (cs *CouponService) generateCouponCode() string {
+	const alphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	buf := make([]byte, 10)
+	for i := range buf {
+		buf[i] = alphabet[rand.Intn(len(alphabet))]
+	}
+	return "CP" + string(buf)
+}
@@ -0,0 +1,373 @@
+package app_service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"nasa-go-admin/db"
+	"nasa-go-admin/inout"
+	"nasa-go-admin/model/app_model"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const (
+	// holidayCacheKeyPrefix 按年缓存特殊日期的Redis键前缀，HASH: 日期 -> 日期类型
+	holidayCacheKeyPrefix = "room:special_dates:"
+	// holidayCacheLoadedField 标记该年已加载，避免没有配置的年份反复回源
+	holidayCacheLoadedField = "_loaded"
+	// holidayCacheTTL Redis缓存有效期
+	holidayCacheTTL = 24 * time.Hour
+	// holidayLocalCacheTTL 进程内缓存有效期，多实例下修改后最多该时长生效
+	holidayLocalCacheTTL = time.Minute
+)
+
+// HolidayCalendarService 节假日日历，基于 room_special_dates 解析定价日期类型（含调休上班日）
+type HolidayCalendarService struct {
+	redisClient *redis.Client
+	mu          sync.RWMutex
+	years       map[int]*holidayYear
+}
+
+// holidayYear 单个年份的特殊日期
+type holidayYear struct {
+	dates    map[string]string // 日期 -> 特殊日期类型
+	loadedAt time.Time
+}
+
+var (
+	globalHolidayCalendar *HolidayCalendarService
+	holidayCalendarOnce   sync.Once
+)
+
+// InitHolidayCalendar 初始化全局节假日日历并注册为定价日期类型解析器
+func InitHolidayCalendar(redisClient *redis.Client) {
+	holidayCalendarOnce.Do(func() {
+		globalHolidayCalendar = NewHolidayCalendarService(redisClient)
+		app_model.SetDayTypeResolver(globalHolidayCalendar.ResolveDayType)
+		log.Printf("✅ 节假日日历已初始化")
+	})
+}
+
+// GetHolidayCalendar 获取全局节假日日历，未初始化时不使用Redis缓存
+func GetHolidayCalendar() *HolidayCalendarService {
+	holidayCalendarOnce.Do(func() {
+		globalHolidayCalendar = NewHolidayCalendarService(nil)
+		app_model.SetDayTypeResolver(globalHolidayCalendar.ResolveDayType)
+	})
+	return globalHolidayCalendar
+}
+
+// NewHolidayCalendarService 创建节假日日历
+func NewHolidayCalendarService(redisClient *redis.Client) *HolidayCalendarService {
+	return &HolidayCalendarService{
+		redisClient: redisClient,
+		years:       make(map[int]*holidayYear),
+	}
+}
+
+// ResolveDayType 解析日期的定价类型：特殊日期优先，其次按星期区分工作日和周末
+func (s *HolidayCalendarService) ResolveDayType(date time.Time) string {
+	dates, err := s.yearDates(date.Year())
+	if err != nil {
+		log.Printf("加载节假日日历失败: %v", err)
+	} else if dateType, ok := dates[date.Format("2006-01-02")]; ok {
+		if dayType := app_model.SpecialDateDayType(dateType); dayType != "" {
+			return dayType
+		}
+	}
+	return app_model.GetWeekDayType(date)
+}
+
+// Invalidate 清除指定年份的缓存
+func (s *HolidayCalendarService) Invalidate(years ...int) {
+	s.mu.Lock()
+	for _, year := range years {
+		delete(s.years, year)
+	}
+	s.mu.Unlock()
+
+	if s.redisClient == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	for _, year := range years {
+		if err := s.redisClient.Del(ctx, holidayCacheKeyPrefix+strconv.Itoa(year)).Err(); err != nil {
+			log.Printf("清除节假日缓存失败: year=%d, err=%v", year, err)
+		}
+	}
+}
+
+// yearDates 获取某年的特殊日期：进程内缓存 -> Redis -> 数据库
+func (s *HolidayCalendarService) yearDates(year int) (map[string]string, error) {
+	s.mu.RLock()
+	cached, ok := s.years[year]
+	s.mu.RUnlock()
+	if ok && time.Since(cached.loadedAt) < holidayLocalCacheTTL {
+		return cached.dates, nil
+	}
+
+	dates, err := s.loadYear(year)
+	if err != nil {
+		// 回源失败时继续使用过期的本地缓存
+		if ok {
+			return cached.dates, nil
+		}
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.years[year] = &holidayYear{dates: dates, loadedAt: time.Now()}
+	s.mu.Unlock()
+	return dates, nil
+}
+
+// loadYear 从Redis或数据库加载某年的特殊日期
+func (s *HolidayCalendarService) loadYear(year int) (map[string]string, error) {
+	key := holidayCacheKeyPrefix + strconv.Itoa(year)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if s.redisClient != nil {
+		values, err := s.redisClient.HGetAll(ctx, key).Result()
+		if err == nil && values[holidayCacheLoadedField] != "" {
+			delete(values, holidayCacheLoadedField)
+			return values, nil
+		}
+	}
+
+	var specialDates []app_model.RoomSpecialDate
+	if err := db.Dao.Where("date >= ? AND date < ? AND is_active = 1",
+		fmt.Sprintf("%d-01-01", year), fmt.Sprintf("%d-01-01", year+1)).
+		Find(&specialDates).Error; err != nil {
+		return nil, fmt.Errorf("查询特殊日期失败: %w", err)
+	}
+
+	dates := make(map[string]string, len(specialDates))
+	for _, d := range specialDates {
+		dates[d.Date.Format("2006-01-02")] = d.DateType
+	}
+
+	if s.redisClient != nil {
+		fields := make(map[string]interface{}, len(dates)+1)
+		for date, dateType := range dates {
+			fields[date] = dateType
+		}
+		fields[holidayCacheLoadedField] = time.Now().Format("2006-01-02 15:04:05")
+
+		pipe := s.redisClient.TxPipeline()
+		pipe.Del(ctx, key)
+		pipe.HSet(ctx, key, fields)
+		pipe.Expire(ctx, key, holidayCacheTTL)
+		if _, err := pipe.Exec(ctx); err != nil {
+			log.Printf("缓存节假日日历失败: year=%d, err=%v", year, err)
+		}
+	}
+
+	return dates, nil
+}
+
+// ImportYear 批量导入某年的节假日和调休安排（JSON或ICS），同一日期已存在时覆盖
+func (s *HolidayCalendarService) ImportYear(req *inout.SpecialDateImportReq) (*inout.SpecialDateImportResp, error) {
+	var items []inout.SpecialDateItem
+	switch req.Format {
+	case "json":
+		items = req.Dates
+	case "ics":
+		parsed, err := parseHolidayICS(req.Content)
+		if err != nil {
+			return nil, err
+		}
+		items = parsed
+	default:
+		return nil, fmt.Errorf("不支持的导入格式: %s", req.Format)
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("没有可导入的日期")
+	}
+
+	resp := &inout.SpecialDateImportResp{Year: req.Year, Skipped: make([]string, 0)}
+	specialDates := make([]app_model.RoomSpecialDate, 0, len(items))
+	for _, item := range items {
+		date, err := time.Parse("2006-01-02", item.Date)
+		if err != nil {
+			resp.Skipped = append(resp.Skipped, fmt.Sprintf("%s: 日期格式错误", item.Date))
+			continue
+		}
+		if date.Year() != req.Year {
+			resp.Skipped = append(resp.Skipped, fmt.Sprintf("%s: 不属于 %d 年", item.Date, req.Year))
+			continue
+		}
+		if app_model.SpecialDateDayType(item.DateType) == "" {
+			resp.Skipped = append(resp.Skipped, fmt.Sprintf("%s: 未知日期类型 %s", item.Date, item.DateType))
+			continue
+		}
+		specialDates = append(specialDates, app_model.RoomSpecialDate{
+			Date:        date,
+			DateType:    item.DateType,
+			Name:        item.Name,
+			Description: item.Description,
+			IsActive:    true,
+		})
+	}
+
+	err := db.Dao.Transaction(func(tx *gorm.DB) error {
+		// 官方安排每年发布一次，替换时只清除法定节假日和调休，保留商家自定义的活动日
+		if req.Replace {
+			result := tx.Where("date >= ? AND date < ? AND date_type IN (?)",
+				fmt.Sprintf("%d-01-01", req.Year), fmt.Sprintf("%d-01-01", req.Year+1),
+				[]string{app_model.SpecialDateHoliday, app_model.SpecialDateWorkday}).
+				Delete(&app_model.RoomSpecialDate{})
+			if result.Error != nil {
+				return fmt.Errorf("清除原有节假日安排失败: %w", result.Error)
+			}
+			resp.Removed = int(result.RowsAffected)
+		}
+
+		for i := range specialDates {
+			specialDate := &specialDates[i]
+			var existing app_model.RoomSpecialDate
+			err := tx.Where("date = ?", specialDate.Date.Format("2006-01-02")).First(&existing).Error
+			if err == gorm.ErrRecordNotFound {
+				if err := tx.Create(specialDate).Error; err != nil {
+					return fmt.Errorf("创建特殊日期失败: %w", err)
+				}
+				resp.Created++
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("查询特殊日期失败: %w", err)
+			}
+
+			if err := tx.Model(&existing).Updates(map[string]interface{}{
+				"date_type":   specialDate.DateType,
+				"name":        specialDate.Name,
+				"description": specialDate.Description,
+				"is_active":   true,
+			}).Error; err != nil {
+				return fmt.Errorf("更新特殊日期失败: %w", err)
+			}
+			resp.Updated++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.Invalidate(req.Year)
+	log.Printf("✅ 导入 %d 年节假日安排: 新增=%d, 更新=%d, 清除=%d, 跳过=%d",
+		req.Year, resp.Created, resp.Updated, resp.Removed, len(resp.Skipped))
+	return resp, nil
+}
+
+// parseHolidayICS 解析节假日ICS日历
+// 每个VEVENT按 DTSTART/DTEND（DTEND不含当天）展开为逐日记录，SUMMARY 含"班"的视为调休上班日
+func parseHolidayICS(content string) ([]inout.SpecialDateItem, error) {
+	// 展开折行：以空格或制表符开头的行是上一行的延续
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取ICS内容失败: %w", err)
+	}
+
+	var items []inout.SpecialDateItem
+	var inEvent bool
+	var summary, description, dtStart, dtEnd string
+	for _, line := range lines {
+		switch {
+		case line == "BEGIN:VEVENT":
+			inEvent = true
+			summary, description, dtStart, dtEnd = "", "", "", ""
+		case line == "END:VEVENT":
+			inEvent = false
+			eventItems, err := expandHolidayEvent(summary, description, dtStart, dtEnd)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, eventItems...)
+		case inEvent:
+			name, value, ok := strings.Cut(line, ":")
+			if !ok {
+				continue
+			}
+			// 去掉 DTSTART;VALUE=DATE 之类的参数
+			if idx := strings.Index(name, ";"); idx >= 0 {
+				name = name[:idx]
+			}
+			switch name {
+			case "SUMMARY":
+				summary = strings.TrimSpace(value)
+			case "DESCRIPTION":
+				description = strings.TrimSpace(value)
+			case "DTSTART":
+				dtStart = value
+			case "DTEND":
+				dtEnd = value
+			}
+		}
+	}
+
+	return items, nil
+}
+
+// expandHolidayEvent 将ICS事件展开为逐日的特殊日期
+func expandHolidayEvent(summary, description, dtStart, dtEnd string) ([]inout.SpecialDateItem, error) {
+	if dtStart == "" {
+		return nil, fmt.Errorf("ICS事件缺少DTSTART: %s", summary)
+	}
+	start, err := parseICSDate(dtStart)
+	if err != nil {
+		return nil, err
+	}
+	end := start.AddDate(0, 0, 1)
+	if dtEnd != "" {
+		if end, err = parseICSDate(dtEnd); err != nil {
+			return nil, err
+		}
+	}
+
+	dateType := app_model.SpecialDateHoliday
+	if strings.Contains(summary, "班") {
+		dateType = app_model.SpecialDateWorkday
+	}
+
+	var items []inout.SpecialDateItem
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		items = append(items, inout.SpecialDateItem{
+			Date:        day.Format("2006-01-02"),
+			DateType:    dateType,
+			Name:        summary,
+			Description: description,
+		})
+	}
+	return items, nil
+}
+
+// parseICSDate 解析ICS日期（20250128 或 20250128T000000Z）
+func parseICSDate(value string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, fmt.Errorf("ICS日期格式错误: %s", value)
+	}
+	date, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("ICS日期格式错误: %s", value)
+	}
+	return date, nil
+}
@@ -0,0 +1,111 @@
+package app_service
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"nasa-go-admin/model/app_model"
+)
+
+func TestParseHolidayICS(t *testing.T) {
+	content, err := os.ReadFile("testdata/china_holidays_2025.ics")
+	if err != nil {
+		t.Fatalf("读取ICS样例失败: %v", err)
+	}
+
+	items, err := parseHolidayICS(string(content))
+	if err != nil {
+		t.Fatalf("parseHolidayICS() error = %v", err)
+	}
+
+	got := make(map[string]string, len(items))
+	for _, item := range items {
+		got[item.Date] = item.DateType
+	}
+
+	// 春节8天 + 国庆8天 + 4个调休上班日
+	if len(items) != 20 {
+		t.Errorf("parseHolidayICS() 解析出 %d 天, want 20", len(items))
+	}
+
+	tests := []struct {
+		date     string
+		wantType string
+	}{
+		{"2025-01-28", app_model.SpecialDateHoliday}, // DTSTART;VALUE=DATE 当天
+		{"2025-02-04", app_model.SpecialDateHoliday}, // DTEND 前一天
+		{"2025-01-26", app_model.SpecialDateWorkday}, // SUMMARY 折行后才含"班"
+		{"2025-02-08", app_model.SpecialDateWorkday}, // 缺少 DTEND 时为单日
+		{"2025-09-28", app_model.SpecialDateWorkday}, // DTSTART 带时间
+		{"2025-10-08", app_model.SpecialDateHoliday},
+		{"2025-10-11", app_model.SpecialDateWorkday},
+	}
+	for _, tt := range tests {
+		if got[tt.date] != tt.wantType {
+			t.Errorf("%s 类型 = %q, want %q", tt.date, got[tt.date], tt.wantType)
+		}
+	}
+
+	// DTEND 不含当天
+	for _, date := range []string{"2025-02-05", "2025-01-27", "2025-10-09", "2025-09-29", "2025-10-12"} {
+		if dateType, ok := got[date]; ok {
+			t.Errorf("%s 不应导入, got %q", date, dateType)
+		}
+	}
+
+	// 折行的描述完整拼接
+	for _, item := range items {
+		if item.Date == "2025-01-28" && item.Description != "1月28日（农历除夕）至2月4日放假调休，共8天。1月26日（周日）、2月8日（周六）上班。" {
+			t.Errorf("折行描述 = %q", item.Description)
+		}
+	}
+}
+
+func TestParseHolidayICSInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"缺少DTSTART", "BEGIN:VEVENT\r\nSUMMARY:春节\r\nEND:VEVENT\r\n"},
+		{"日期格式错误", "BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:2025-01\r\nSUMMARY:春节\r\nEND:VEVENT\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseHolidayICS(tt.content); err == nil {
+				t.Errorf("parseHolidayICS() 应返回错误")
+			}
+		})
+	}
+}
+
+func TestResolveDayType(t *testing.T) {
+	s := NewHolidayCalendarService(nil)
+	s.years[2025] = &holidayYear{
+		dates: map[string]string{
+			"2025-01-28": app_model.SpecialDateHoliday,
+			"2025-02-08": app_model.SpecialDateWorkday,
+			"2025-06-18": app_model.SpecialDateSpecial,
+		},
+		loadedAt: time.Now(),
+	}
+
+	tests := []struct {
+		name string
+		date time.Time
+		want string
+	}{
+		{"周六调休上班按工作日", time.Date(2025, 2, 8, 10, 0, 0, 0, time.Local), app_model.DayTypeWeekday},
+		{"工作日放假按节假日", time.Date(2025, 1, 28, 10, 0, 0, 0, time.Local), app_model.DayTypeHoliday},
+		{"特殊活动日", time.Date(2025, 6, 18, 10, 0, 0, 0, time.Local), app_model.DayTypeSpecial},
+		{"普通周六", time.Date(2025, 2, 15, 10, 0, 0, 0, time.Local), app_model.DayTypeWeekend},
+		{"普通工作日", time.Date(2025, 2, 10, 10, 0, 0, 0, time.Local), app_model.DayTypeWeekday},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.ResolveDayType(tt.date); got != tt.want {
+				t.Errorf("ResolveDayType(%s) = %s, want %s", tt.date.Format("2006-01-02"), got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,302 @@
+package app_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"nasa-go-admin/model/app_model"
+	"nasa-go-admin/mongodb"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// 需要选主执行的后台任务名称
+const (
+	JobBookingStatusUpdate = "booking_status_update"   // 预订状态自动流转
+	JobExpiredOrderCheck   = "expired_order_check"     // 过期订单检查
+	JobOrderConsistency    = "order_consistency_check" // 订单数据一致性检查
+	JobOrderTimeoutQueue   = "order_timeout_queue"     // Redis超时队列处理
+	JobWalletReconcile     = "wallet_reconcile"        // 钱包夜间对账
+)
+
+// errJobIdle 任务本次没有待处理的数据，按成功处理但不写执行记录，避免高频轮询任务产生大量空记录
+var errJobIdle = errors.New("no pending items")
+
+const (
+	// leaderKeyPrefix leader租约键前缀，值为持有者实例ID
+	leaderKeyPrefix = "scheduler:leader:"
+	// leaderLeaseTTL leader租约有效期，持有者每1/3周期续期一次，实例宕机后最多该时长完成故障转移
+	leaderLeaseTTL = 30 * time.Second
+)
+
+// LeaderElector 基于Redis租约的后台任务选主
+// 每个任务独立选主：同一时刻只有持有该任务租约的实例执行，其余实例跳过；
+// leader失联后租约过期，其他实例在下一次调度时接管。
+type LeaderElector struct {
+	redisClient *redis.Client
+	instanceID  string
+	mu          sync.Mutex
+	jobs        map[string]*leaderJob
+}
+
+// leaderJob 单个任务的选主状态
+type leaderJob struct {
+	lock        *DistributedLock
+	leaderSince time.Time
+	lastRunAt   time.Time
+	lastStatus  string
+	lastError   string
+	runCount    int64
+}
+
+var (
+	globalLeaderElector *LeaderElector
+	leaderElectorOnce   sync.Once
+)
+
+// InitLeaderElector 初始化全局任务选主器（需在启动调度器前调用）
+func InitLeaderElector(redisClient *redis.Client) {
+	leaderElectorOnce.Do(func() {
+		globalLeaderElector = NewLeaderElector(redisClient)
+		log.Printf("✅ 后台任务选主已初始化，实例ID: %s", globalLeaderElector.instanceID)
+	})
+}
+
+// GetLeaderElector 获取全局任务选主器，未初始化时按单实例模式运行
+func GetLeaderElector() *LeaderElector {
+	leaderElectorOnce.Do(func() {
+		log.Printf("⚠️ 后台任务选主未初始化Redis，按单实例模式运行")
+		globalLeaderElector = NewLeaderElector(nil)
+	})
+	return globalLeaderElector
+}
+
+// NewLeaderElector 创建任务选主器
+func NewLeaderElector(redisClient *redis.Client) *LeaderElector {
+	hostname, _ := os.Hostname()
+	return &LeaderElector{
+		redisClient: redisClient,
+		instanceID:  fmt.Sprintf("%s:%d:%s", hostname, os.Getpid(), uuid.New().String()[:8]),
+		jobs:        make(map[string]*leaderJob),
+	}
+}
+
+// InstanceID 当前实例ID
+func (e *LeaderElector) InstanceID() string {
+	return e.instanceID
+}
+
+// RunAsLeader 当前实例持有任务租约时执行任务并记录执行结果（任务无待处理数据时不记录），返回是否执行
+func (e *LeaderElector) RunAsLeader(jobName string, fn func() error) bool {
+	if !e.ensureLeader(jobName) {
+		return false
+	}
+
+	startedAt := time.Now()
+	status, errMsg, idle := e.runJob(jobName, fn)
+	finishedAt := time.Now()
+
+	e.mu.Lock()
+	job := e.getJob(jobName)
+	job.lastRunAt = startedAt
+	job.lastStatus = status
+	job.lastError = errMsg
+	job.runCount++
+	e.mu.Unlock()
+
+	if idle {
+		return true
+	}
+	e.recordRun(&app_model.SchedulerJobRun{
+		JobName:    jobName,
+		InstanceID: e.instanceID,
+		Status:     status,
+		ErrorMsg:   errMsg,
+		StartedAt:  startedAt.Format("2006-01-02 15:04:05"),
+		FinishedAt: finishedAt.Format("2006-01-02 15:04:05"),
+		DurationMs: finishedAt.Sub(startedAt).Milliseconds(),
+		ServerInfo: (&BookingLogService{}).getServerInfo(),
+		CreatedAt:  finishedAt,
+	})
+	return true
+}
+
+// runJob 执行任务，panic不影响调度循环；任务返回 errJobIdle 时 idle 为 true
+func (e *LeaderElector) runJob(jobName string, fn func() error) (status string, errMsg string, idle bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("后台任务 %s 发生panic: %v", jobName, r)
+			status = app_model.JobRunStatusPanic
+			errMsg = fmt.Sprintf("%v", r)
+			idle = false
+		}
+	}()
+
+	if err := fn(); err != nil {
+		if errors.Is(err, errJobIdle) {
+			return app_model.JobRunStatusSuccess, "", true
+		}
+		log.Printf("后台任务 %s 执行失败: %v", jobName, err)
+		return app_model.JobRunStatusFailed, err.Error(), false
+	}
+	return app_model.JobRunStatusSuccess, "", false
+}
+
+// ensureLeader 确认或争取任务租约
+// Redis 往返不持有 e.mu，只在读取和替换任务租约时加锁，避免 GetStatus 被网络延迟阻塞。
+// 同一任务由单个调度协程串行调用，不会并发争取同一租约。
+func (e *LeaderElector) ensureLeader(jobName string) bool {
+	// 未配置Redis时无法协调，退化为单实例执行
+	if e.redisClient == nil {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	e.mu.Lock()
+	current := e.getJob(jobName).lock
+	e.mu.Unlock()
+
+	if current != nil {
+		held, err := current.IsHeld(ctx)
+		if err != nil {
+			// Redis暂时不可用时不执行，避免租约可能已被接管时重复执行
+			log.Printf("检查任务 %s 租约失败: %v", jobName, err)
+			return false
+		}
+		if held {
+			return true
+		}
+
+		log.Printf("⚠️ 实例 %s 失去任务 %s 的leader租约", e.instanceID, jobName)
+		current.Release()
+
+		e.mu.Lock()
+		if job := e.getJob(jobName); job.lock == current {
+			job.lock = nil
+			job.leaderSince = time.Time{}
+		}
+		e.mu.Unlock()
+	}
+
+	lock := NewSecurityOrderService(e.redisClient).NewDistributedLock(leaderKeyPrefix+jobName, leaderLeaseTTL)
+	lock.value = e.instanceID // 以实例ID作为租约值，便于查询当前leader
+	if err := lock.AcquireWithRenewal(ctx); err != nil {
+		return false
+	}
+
+	e.mu.Lock()
+	job := e.getJob(jobName)
+	job.lock = lock
+	job.leaderSince = time.Now()
+	e.mu.Unlock()
+
+	log.Printf("👑 实例 %s 成为任务 %s 的leader", e.instanceID, jobName)
+	return true
+}
+
+// getJob 获取任务状态（调用方需持有锁）
+func (e *LeaderElector) getJob(jobName string) *leaderJob {
+	job, ok := e.jobs[jobName]
+	if !ok {
+		job = &leaderJob{}
+		e.jobs[jobName] = job
+	}
+	return job
+}
+
+// ResignAll 释放当前实例持有的全部租约，优雅关闭时调用以便其他实例立即接管
+func (e *LeaderElector) ResignAll() {
+	e.mu.Lock()
+	held := make(map[string]*DistributedLock)
+	for name, job := range e.jobs {
+		if job.lock == nil {
+			continue
+		}
+		held[name] = job.lock
+		job.lock = nil
+		job.leaderSince = time.Time{}
+	}
+	e.mu.Unlock()
+
+	for name, lock := range held {
+		if err := lock.Release(); err != nil {
+			log.Printf("释放任务 %s 租约失败: %v", name, err)
+		}
+	}
+}
+
+// GetStatus 获取各任务当前leader及本实例执行情况（用于健康检查接口）
+func (e *LeaderElector) GetStatus() map[string]interface{} {
+	e.mu.Lock()
+	names := make([]string, 0, len(e.jobs))
+	for name := range e.jobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	jobs := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		job := e.jobs[name]
+		item := map[string]interface{}{
+			"is_leader":   job.lock != nil || e.redisClient == nil,
+			"run_count":   job.runCount,
+			"last_status": job.lastStatus,
+			"last_error":  job.lastError,
+		}
+		if !job.leaderSince.IsZero() {
+			item["leader_since"] = job.leaderSince.Format("2006-01-02 15:04:05")
+		}
+		if !job.lastRunAt.IsZero() {
+			item["last_run_at"] = job.lastRunAt.Format("2006-01-02 15:04:05")
+		}
+		jobs[name] = item
+	}
+	e.mu.Unlock()
+
+	// 查询Redis中的实际leader，不持有本地锁
+	if e.redisClient != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		for _, name := range names {
+			leader, err := e.redisClient.Get(ctx, leaderKeyPrefix+name).Result()
+			if err == redis.Nil {
+				leader = ""
+			} else if err != nil {
+				leader = "unknown"
+			}
+			jobs[name].(map[string]interface{})["leader"] = leader
+		}
+	}
+
+	return map[string]interface{}{
+		"instance_id":   e.instanceID,
+		"redis_enabled": e.redisClient != nil,
+		"jobs":          jobs,
+	}
+}
+
+// recordRun 异步记录任务执行结果到MongoDB
+func (e *LeaderElector) recordRun(run *app_model.SchedulerJobRun) {
+	go func() {
+		collection := mongodb.GetCollection("scheduler_log_db", "job_runs")
+		if collection == nil {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if _, err := collection.InsertOne(ctx, run); err != nil {
+			log.Printf("保存任务 %s 执行记录失败: %v", run.JobName, err)
+		}
+	}()
+}
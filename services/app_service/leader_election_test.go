@@ -0,0 +1,35 @@
+package app_service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"nasa-go-admin/model/app_model"
+)
+
+func TestRunJobStatus(t *testing.T) {
+	e := NewLeaderElector(nil)
+
+	tests := []struct {
+		name       string
+		fn         func() error
+		wantStatus string
+		wantIdle   bool
+	}{
+		{"处理成功", func() error { return nil }, app_model.JobRunStatusSuccess, false},
+		{"无待处理数据", func() error { return errJobIdle }, app_model.JobRunStatusSuccess, true},
+		{"包装后的无待处理数据", func() error { return fmt.Errorf("队列为空: %w", errJobIdle) }, app_model.JobRunStatusSuccess, true},
+		{"执行失败", func() error { return errors.New("boom") }, app_model.JobRunStatusFailed, false},
+		{"发生panic", func() error { panic("boom") }, app_model.JobRunStatusPanic, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, _, idle := e.runJob("test_job", tt.fn)
+			if status != tt.wantStatus || idle != tt.wantIdle {
+				t.Errorf("runJob() = (%s, %v), want (%s, %v)", status, idle, tt.wantStatus, tt.wantIdle)
+			}
+		})
+	}
+}
@@ -0,0 +1,571 @@
+package app_service
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"nasa-go-admin/db"
+	"nasa-go-admin/inout"
+	"nasa-go-admin/model/app_model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MembershipService 会员等级与积分服务 - 等级配置、消费得积分、积分抵扣与后台调整
+// 会员等级和积分为平台统一体系，不按商家隔离
+type MembershipService struct{}
+
+// NewMembershipService 创建会员服务
+func NewMembershipService() *MembershipService {
+	return &MembershipService{}
+}
+
+// ========== 等级管理 ==========
+
+// CreateLevel 创建会员等级
+func (ms *MembershipService) CreateLevel(req *inout.CreateMemberLevelReq) (*app_model.MemberLevel, error) {
+	level := &app_model.MemberLevel{IsActive: true}
+	if err := ms.fillLevel(level, req); err != nil {
+		return nil, err
+	}
+
+	err := db.Dao.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(level).Error; err != nil {
+			return fmt.Errorf("创建会员等级失败: %v", err)
+		}
+		return ms.recalculateLevels(tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("会员等级已创建: %s (ID: %d, 门槛: %.2f)", level.Name, level.ID, level.MinSpend)
+	return level, nil
+}
+
+// UpdateLevel 更新会员等级，门槛或启用状态变化后重新计算所有会员等级
+func (ms *MembershipService) UpdateLevel(req *inout.UpdateMemberLevelReq) (*app_model.MemberLevel, error) {
+	var level app_model.MemberLevel
+	if err := db.Dao.First(&level, req.ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("会员等级不存在")
+		}
+		return nil, fmt.Errorf("查询会员等级失败: %v", err)
+	}
+
+	if err := ms.fillLevel(&level, &req.CreateMemberLevelReq); err != nil {
+		return nil, err
+	}
+	level.IsActive = req.IsActive
+
+	err := db.Dao.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&level).Error; err != nil {
+			return fmt.Errorf("更新会员等级失败: %v", err)
+		}
+		return ms.recalculateLevels(tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("会员等级已更新: %s (ID: %d)", level.Name, level.ID)
+	return &level, nil
+}
+
+// GetLevelList 获取会员等级列表，按消费门槛升序
+func (ms *MembershipService) GetLevelList() ([]app_model.MemberLevel, error) {
+	var levels []app_model.MemberLevel
+	if err := db.Dao.Order("min_spend ASC").Find(&levels).Error; err != nil {
+		return nil, fmt.Errorf("查询会员等级失败: %v", err)
+	}
+
+	var counts []struct {
+		LevelID int
+		Total   int64
+	}
+	if err := db.Dao.Model(&app_model.MemberAccount{}).
+		Select("level_id, COUNT(*) AS total").
+		Group("level_id").
+		Scan(&counts).Error; err != nil {
+		return nil, fmt.Errorf("统计等级会员数失败: %v", err)
+	}
+
+	countMap := make(map[int]int64, len(counts))
+	for _, c := range counts {
+		countMap[c.LevelID] = c.Total
+	}
+	for i := range levels {
+		levels[i].MemberCount = countMap[levels[i].ID]
+	}
+
+	return levels, nil
+}
+
+// DeleteLevel 删除会员等级，原等级会员按门槛重新定级
+func (ms *MembershipService) DeleteLevel(id int) error {
+	var level app_model.MemberLevel
+	if err := db.Dao.First(&level, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("会员等级不存在")
+		}
+		return fmt.Errorf("查询会员等级失败: %v", err)
+	}
+
+	err := db.Dao.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&level).Error; err != nil {
+			return fmt.Errorf("删除会员等级失败: %v", err)
+		}
+		return ms.recalculateLevels(tx)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("会员等级已删除: %s (ID: %d)", level.Name, level.ID)
+	return nil
+}
+
+// ========== 会员账户 ==========
+
+// GetAccount 获取会员账户信息，包括当前等级和距下一等级的消费差额
+func (ms *MembershipService) GetAccount(userID int) (*inout.MemberAccountResp, error) {
+	var account app_model.MemberAccount
+	err := db.Dao.Preload("Level").Where("user_id = ?", userID).First(&account).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("查询会员账户失败: %v", err)
+	}
+
+	resp := &inout.MemberAccountResp{
+		UserID:        userID,
+		LevelID:       account.LevelID,
+		TotalSpend:    account.TotalSpend,
+		Points:        account.Points,
+		PointsValue:   app_model.PointsValue(account.Points),
+		TotalEarned:   account.TotalEarned,
+		TotalRedeemed: account.TotalRedeemed,
+		PointsRate:    1,
+	}
+	if account.Level != nil {
+		resp.LevelName = account.Level.Name
+		resp.LevelIcon = account.Level.Icon
+		resp.Benefits = account.Level.Benefits
+		resp.PointsRate = account.Level.PointsRate
+	}
+
+	var next app_model.MemberLevel
+	err = db.Dao.Where("is_active = ? AND min_spend > ?", true, account.TotalSpend).
+		Order("min_spend ASC").First(&next).Error
+	if err == nil {
+		resp.NextLevelName = next.Name
+		resp.NextLevelSpend = math.Round((next.MinSpend-account.TotalSpend)*100) / 100
+	} else if err != gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("查询下一会员等级失败: %v", err)
+	}
+
+	return resp, nil
+}
+
+// GetPointsLogs 获取积分流水
+func (ms *MembershipService) GetPointsLogs(req *inout.PointsLogListReq) (*inout.MemberListResp, error) {
+	query := db.Dao.Model(&app_model.MemberPointsLog{})
+	if req.UserID > 0 {
+		query = query.Where("user_id = ?", req.UserID)
+	}
+	if req.ChangeType != "" {
+		query = query.Where("change_type = ?", req.ChangeType)
+	}
+	if req.BizNo != "" {
+		query = query.Where("biz_no = ?", req.BizNo)
+	}
+	if req.StartDate != "" {
+		startDate, err := time.ParseInLocation("2006-01-02", req.StartDate, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("开始日期格式错误: %v", err)
+		}
+		query = query.Where("create_time >= ?", startDate)
+	}
+	if req.EndDate != "" {
+		endDate, err := time.ParseInLocation("2006-01-02", req.EndDate, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("结束日期格式错误: %v", err)
+		}
+		query = query.Where("create_time < ?", endDate.AddDate(0, 0, 1))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("查询积分流水总数失败: %v", err)
+	}
+
+	var logs []app_model.MemberPointsLog
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(req.PageSize).Find(&logs).Error; err != nil {
+		return nil, fmt.Errorf("查询积分流水失败: %v", err)
+	}
+
+	list := make([]inout.PointsLogItem, 0, len(logs))
+	for i := range logs {
+		list = append(list, inout.PointsLogItem{
+			ID:             logs[i].ID,
+			UserID:         logs[i].UserID,
+			ChangeType:     logs[i].ChangeType,
+			ChangeTypeText: logs[i].GetChangeTypeText(),
+			Points:         logs[i].Points,
+			BalanceAfter:   logs[i].BalanceAfter,
+			BizType:        logs[i].BizType,
+			BizNo:          logs[i].BizNo,
+			Amount:         logs[i].Amount,
+			OperatorID:     logs[i].OperatorID,
+			Remark:         logs[i].Remark,
+			CreateTime:     logs[i].CreateTime,
+		})
+	}
+
+	return &inout.MemberListResp{
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		List:     list,
+	}, nil
+}
+
+// AdjustPoints 后台调整会员积分，扣减时不能超过可用积分
+func (ms *MembershipService) AdjustPoints(req *inout.AdjustPointsReq, operatorID int) (*inout.MemberAccountResp, error) {
+	var count int64
+	if err := db.Dao.Table("app_user").Where("id = ?", req.UserID).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("查询会员失败: %v", err)
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("会员不存在")
+	}
+
+	err := db.Dao.Transaction(func(tx *gorm.DB) error {
+		account, err := ms.lockAccount(tx, req.UserID)
+		if err != nil {
+			return err
+		}
+		if req.Points < 0 && account.Points < -req.Points {
+			return fmt.Errorf("可用积分不足，当前可用 %d", account.Points)
+		}
+
+		updates := map[string]interface{}{
+			"points": gorm.Expr("points + ?", req.Points),
+		}
+		if req.Points > 0 {
+			updates["total_earned"] = gorm.Expr("total_earned + ?", req.Points)
+		}
+
+		return ms.changePoints(tx, account, updates, &app_model.MemberPointsLog{
+			ChangeType: app_model.PointsChangeAdjust,
+			Points:     req.Points,
+			OperatorID: operatorID,
+			Remark:     req.Remark,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("会员积分已调整: 用户ID %d, 变动 %d, 操作人 %d", req.UserID, req.Points, operatorID)
+	return ms.GetAccount(req.UserID)
+}
+
+// ========== 订单积分 ==========
+
+// EarnPoints 订单支付或预订完成后在同一事务中累计消费并发放积分，同一业务单只发放一次
+func (ms *MembershipService) EarnPoints(tx *gorm.DB, userID int, bizType, bizNo string, amount float64) error {
+	// 前台代订的散客预订没有会员账户
+	if amount <= 0 || userID <= 0 {
+		return nil
+	}
+
+	key := ms.idempotencyKey(app_model.PointsChangeEarn, bizType, bizNo)
+	if exists, err := ms.logExists(tx, key); err != nil || exists {
+		return err
+	}
+
+	account, err := ms.lockAccount(tx, userID)
+	if err != nil {
+		return err
+	}
+
+	points := account.Level.PointsForSpend(amount)
+	if err := ms.changePoints(tx, account, map[string]interface{}{
+		"total_spend":  gorm.Expr("total_spend + ?", amount),
+		"points":       gorm.Expr("points + ?", points),
+		"total_earned": gorm.Expr("total_earned + ?", points),
+	}, &app_model.MemberPointsLog{
+		ChangeType:     app_model.PointsChangeEarn,
+		Points:         points,
+		BizType:        bizType,
+		BizNo:          bizNo,
+		Amount:         amount,
+		IdempotencyKey: &key,
+		Remark:         fmt.Sprintf("消费 %.2f 元", amount),
+	}); err != nil {
+		return err
+	}
+
+	account.TotalSpend += amount
+	if err := ms.refreshLevel(tx, account); err != nil {
+		return err
+	}
+
+	log.Printf("会员积分已发放: 用户ID %d, 单号 %s, 消费 %.2f, 积分 %d", userID, bizNo, amount, points)
+	return nil
+}
+
+// RedeemPoints 下单时在同一事务中扣减积分并返回抵扣金额
+// amount 为使用积分前的应付金额，积分最多抵扣其 PointsMaxRedeemRate 比例
+func (ms *MembershipService) RedeemPoints(tx *gorm.DB, userID, points int, bizType, bizNo string, amount float64) (float64, error) {
+	if points <= 0 {
+		return 0, nil
+	}
+	if maxPoints := app_model.MaxRedeemablePoints(amount); points > maxPoints {
+		return 0, fmt.Errorf("本单最多可使用 %d 积分", maxPoints)
+	}
+
+	account, err := ms.lockAccount(tx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if account.Points < points {
+		return 0, fmt.Errorf("可用积分不足，当前可用 %d", account.Points)
+	}
+
+	discount := app_model.PointsValue(points)
+	key := ms.idempotencyKey(app_model.PointsChangeRedeem, bizType, bizNo)
+	if err := ms.changePoints(tx, account, map[string]interface{}{
+		"points":         gorm.Expr("points - ?", points),
+		"total_redeemed": gorm.Expr("total_redeemed + ?", points),
+	}, &app_model.MemberPointsLog{
+		ChangeType:     app_model.PointsChangeRedeem,
+		Points:         -points,
+		BizType:        bizType,
+		BizNo:          bizNo,
+		Amount:         discount,
+		IdempotencyKey: &key,
+		Remark:         fmt.Sprintf("积分抵扣 %.2f 元", discount),
+	}); err != nil {
+		return 0, err
+	}
+
+	log.Printf("会员积分已抵扣: 用户ID %d, 单号 %s, 积分 %d, 抵扣 %.2f", userID, bizNo, points, discount)
+	return discount, nil
+}
+
+// RollbackPoints 订单取消或退款后在同一事务中退回抵扣积分并扣回已发放积分
+// 已发放积分超过可用积分时只扣到0，累计消费同步扣减后重新定级
+func (ms *MembershipService) RollbackPoints(tx *gorm.DB, bizType, bizNo string) error {
+	var logs []app_model.MemberPointsLog
+	if err := tx.Where("biz_type = ? AND biz_no = ?", bizType, bizNo).Find(&logs).Error; err != nil {
+		return fmt.Errorf("查询订单积分流水失败: %v", err)
+	}
+
+	var redeemLog, earnLog *app_model.MemberPointsLog
+	returned, revoked := false, false
+	for i := range logs {
+		switch logs[i].ChangeType {
+		case app_model.PointsChangeRedeem:
+			redeemLog = &logs[i]
+		case app_model.PointsChangeEarn:
+			earnLog = &logs[i]
+		case app_model.PointsChangeReturn:
+			returned = true
+		case app_model.PointsChangeRevoke:
+			revoked = true
+		}
+	}
+
+	if redeemLog != nil && !returned {
+		account, err := ms.lockAccount(tx, redeemLog.UserID)
+		if err != nil {
+			return err
+		}
+
+		points := -redeemLog.Points
+		key := ms.idempotencyKey(app_model.PointsChangeReturn, bizType, bizNo)
+		if err := ms.changePoints(tx, account, map[string]interface{}{
+			"points":         gorm.Expr("points + ?", points),
+			"total_redeemed": gorm.Expr("total_redeemed - ?", points),
+		}, &app_model.MemberPointsLog{
+			ChangeType:     app_model.PointsChangeReturn,
+			Points:         points,
+			BizType:        bizType,
+			BizNo:          bizNo,
+			Amount:         redeemLog.Amount,
+			IdempotencyKey: &key,
+			Remark:         "订单取消退回抵扣积分",
+		}); err != nil {
+			return err
+		}
+		log.Printf("抵扣积分已退回: 用户ID %d, 单号 %s, 积分 %d", redeemLog.UserID, bizNo, points)
+	}
+
+	if earnLog != nil && !revoked {
+		account, err := ms.lockAccount(tx, earnLog.UserID)
+		if err != nil {
+			return err
+		}
+
+		points := earnLog.Points
+		if points > account.Points {
+			points = account.Points
+		}
+		key := ms.idempotencyKey(app_model.PointsChangeRevoke, bizType, bizNo)
+		if err := ms.changePoints(tx, account, map[string]interface{}{
+			"total_spend":  gorm.Expr("GREATEST(total_spend - ?, 0)", earnLog.Amount),
+			"points":       gorm.Expr("points - ?", points),
+			"total_earned": gorm.Expr("total_earned - ?", points),
+		}, &app_model.MemberPointsLog{
+			ChangeType:     app_model.PointsChangeRevoke,
+			Points:         -points,
+			BizType:        bizType,
+			BizNo:          bizNo,
+			Amount:         earnLog.Amount,
+			IdempotencyKey: &key,
+			Remark:         fmt.Sprintf("退款扣回积分，冲减消费 %.2f 元", earnLog.Amount),
+		}); err != nil {
+			return err
+		}
+
+		account.TotalSpend -= earnLog.Amount
+		if account.TotalSpend < 0 {
+			account.TotalSpend = 0
+		}
+		if err := ms.refreshLevel(tx, account); err != nil {
+			return err
+		}
+		log.Printf("已发放积分已扣回: 用户ID %d, 单号 %s, 积分 %d", earnLog.UserID, bizNo, points)
+	}
+
+	return nil
+}
+
+// ========== 辅助方法 ==========
+
+// fillLevel 校验请求并写入等级字段
+func (ms *MembershipService) fillLevel(level *app_model.MemberLevel, req *inout.CreateMemberLevelReq) error {
+	var count int64
+	if err := db.Dao.Model(&app_model.MemberLevel{}).
+		Where("min_spend = ? AND id <> ?", req.MinSpend, level.ID).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("检查会员等级门槛失败: %v", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("已存在消费门槛为 %.2f 的会员等级", req.MinSpend)
+	}
+
+	level.Name = req.Name
+	level.MinSpend = req.MinSpend
+	level.PointsRate = req.PointsRate
+	if level.PointsRate <= 0 {
+		level.PointsRate = 1
+	}
+	level.Benefits = req.Benefits
+	level.Icon = req.Icon
+	return nil
+}
+
+// lockAccount 锁定会员账户，首次消费时创建
+func (ms *MembershipService) lockAccount(tx *gorm.DB, userID int) (*app_model.MemberAccount, error) {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&app_model.MemberAccount{UserID: userID}).Error; err != nil {
+		return nil, fmt.Errorf("创建会员账户失败: %v", err)
+	}
+
+	var account app_model.MemberAccount
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", userID).First(&account).Error; err != nil {
+		return nil, fmt.Errorf("查询会员账户失败: %v", err)
+	}
+
+	if account.LevelID > 0 {
+		var level app_model.MemberLevel
+		if err := tx.First(&level, account.LevelID).Error; err == nil {
+			account.Level = &level
+		} else if err != gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("查询会员等级失败: %v", err)
+		}
+	}
+
+	return &account, nil
+}
+
+// changePoints 更新账户并写入积分流水，流水余额以更新后的账户为准
+func (ms *MembershipService) changePoints(tx *gorm.DB, account *app_model.MemberAccount,
+	updates map[string]interface{}, entry *app_model.MemberPointsLog) error {
+
+	result := tx.Model(&app_model.MemberAccount{}).
+		Where("id = ? AND points + ? >= 0", account.ID, entry.Points).
+		Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("更新会员积分失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("可用积分不足")
+	}
+
+	account.Points += entry.Points
+	entry.UserID = account.UserID
+	entry.BalanceAfter = account.Points
+	if err := tx.Create(entry).Error; err != nil {
+		return fmt.Errorf("记录积分流水失败: %v", err)
+	}
+	return nil
+}
+
+// refreshLevel 按累计消费重新计算会员等级
+func (ms *MembershipService) refreshLevel(tx *gorm.DB, account *app_model.MemberAccount) error {
+	var level app_model.MemberLevel
+	levelID := 0
+	err := tx.Where("is_active = ? AND min_spend <= ?", true, account.TotalSpend).
+		Order("min_spend DESC").First(&level).Error
+	if err == nil {
+		levelID = level.ID
+	} else if err != gorm.ErrRecordNotFound {
+		return fmt.Errorf("查询会员等级失败: %v", err)
+	}
+
+	if levelID == account.LevelID {
+		return nil
+	}
+	if err := tx.Model(&app_model.MemberAccount{}).Where("id = ?", account.ID).
+		Update("level_id", levelID).Error; err != nil {
+		return fmt.Errorf("更新会员等级失败: %v", err)
+	}
+
+	log.Printf("会员等级变更: 用户ID %d, 等级 %d -> %d", account.UserID, account.LevelID, levelID)
+	account.LevelID = levelID
+	return nil
+}
+
+// recalculateLevels 等级配置变更后按累计消费重新计算所有会员等级
+func (ms *MembershipService) recalculateLevels(tx *gorm.DB) error {
+	if err := tx.Exec(`
+		UPDATE member_accounts SET level_id = COALESCE((
+			SELECT l.id FROM member_levels l
+			WHERE l.is_active = ? AND l.min_spend <= member_accounts.total_spend
+			ORDER BY l.min_spend DESC LIMIT 1
+		), 0)`, true).Error; err != nil {
+		return fmt.Errorf("重新计算会员等级失败: %v", err)
+	}
+	return nil
+}
+
+// logExists 幂等键对应的积分流水是否已存在
+func (ms *MembershipService) logExists(tx *gorm.DB, key string) (bool, error) {
+	var count int64
+	if err := tx.Model(&app_model.MemberPointsLog{}).Where("idempotency_key = ?", key).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("查询积分流水失败: %v", err)
+	}
+	return count > 0, nil
+}
+
+// idempotencyKey 生成积分流水幂等键
+func (ms *MembershipService) idempotencyKey(changeType, bizType, bizNo string) string {
+	return fmt.Sprintf("%s:%s:%s", changeType, bizType, bizNo)
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"nasa-go-admin/db"
 	"nasa-go-admin/model/app_model"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -363,6 +364,38 @@ func (oms *OrderMonitoringService) checkDataConsistency() error {
 	return nil
 }
 
+// ReportWalletLedgerDrift 上报钱包对账结果，存在差异或借贷不平衡时告警
+func (oms *OrderMonitoringService) ReportWalletLedgerDrift(report *WalletReconcileReport) {
+	if report == nil {
+		return
+	}
+
+	if !report.Balanced {
+		oms.alerter.SendUrgentAlert("钱包账本借贷不平衡",
+			fmt.Sprintf("对账批次 %s: 借方合计 %.2f, 贷方合计 %.2f",
+				report.BatchNo, report.TotalDebit, report.TotalCredit))
+	}
+
+	if report.DriftUsers == 0 {
+		log.Printf("✅ 钱包对账无差异 批次:%s 用户数:%d", report.BatchNo, report.CheckedUsers)
+		return
+	}
+
+	// 只列出前10个差异用户，完整明细见 wallet_reconcile_records
+	details := make([]string, 0, 10)
+	for i, drift := range report.Drifts {
+		if i >= 10 {
+			break
+		}
+		details = append(details, fmt.Sprintf("用户%d(钱包%.2f/账本%.2f)",
+			drift.UserID, drift.WalletBalance, drift.LedgerBalance))
+	}
+
+	oms.alerter.SendUrgentAlert("钱包对账差异告警",
+		fmt.Sprintf("对账批次 %s: %d 个用户钱包余额与账本不一致，差异合计 %.2f 元: %s",
+			report.BatchNo, report.DriftUsers, report.TotalDrift, strings.Join(details, ", ")))
+}
+
 // GetMonitoringStats 获取监控统计数据
 func (oms *OrderMonitoringService) GetMonitoringStats() (map[string]interface{}, error) {
 	stats := make(map[string]interface{})
@@ -11,6 +11,7 @@ import (
 
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // SecurityOrderService 安全订单服务 - 解决并发和卡单问题
@@ -145,27 +146,88 @@ func (s *SecurityOrderService) SafeDeductWallet(tx *gorm.DB, uid int, amount flo
 	return nil, fmt.Errorf("余额扣减失败，重试次数已达上限")
 }
 
+// SafeCreditWallet 安全增加钱包余额 - 用于退款等入账场景，返回入账后的钱包
+func (s *SecurityOrderService) SafeCreditWallet(tx *gorm.DB, uid int, amount float64) (*app_model.AppWallet, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("入账金额必须大于0")
+	}
+
+	var wallet app_model.AppWallet
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", uid).First(&wallet).Error; err != nil {
+
+		if err != gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("查询钱包失败: %w", err)
+		}
+
+		// 钱包不存在，直接创建带余额的钱包
+		wallet = app_model.AppWallet{
+			UserId:     uid,
+			Money:      amount,
+			CreateTime: time.Now(),
+			UpdateTime: time.Now(),
+		}
+		if err := tx.Create(&wallet).Error; err != nil {
+			return nil, fmt.Errorf("创建钱包失败: %w", err)
+		}
+
+		log.Printf("✅ 成功为用户 %d 入账 %.2f，当前余额: %.2f", uid, amount, wallet.Money)
+		return &wallet, nil
+	}
+
+	if err := tx.Model(&app_model.AppWallet{}).
+		Where("user_id = ?", uid).
+		Updates(map[string]interface{}{
+			"money":       gorm.Expr("money + ?", amount),
+			"update_time": time.Now(),
+		}).Error; err != nil {
+		return nil, fmt.Errorf("余额入账失败: %w", err)
+	}
+
+	wallet.Money += amount
+
+	log.Printf("✅ 成功为用户 %d 入账 %.2f，当前余额: %.2f", uid, amount, wallet.Money)
+	return &wallet, nil
+}
+
 // RecordWalletTransaction 记录钱包交易流水
 func (s *SecurityOrderService) RecordWalletTransaction(tx *gorm.DB, uid int, amount float64,
 	balanceBefore, balanceAfter float64, description string) error {
 
+	_, err := s.RecordWalletTransactionWithType(tx, uid, app_model.TransactionTypeOrderPayment, "",
+		amount, balanceBefore, balanceAfter, description)
+	return err
+}
+
+// RecordWalletTransactionWithType 按交易类型记录钱包交易流水，返回流水记录
+func (s *SecurityOrderService) RecordWalletTransactionWithType(tx *gorm.DB, uid int, transactionType, orderNo string,
+	amount, balanceBefore, balanceAfter float64, description string) (*app_model.AppRecharge, error) {
+
+	now := time.Now()
 	transaction := app_model.AppRecharge{
 		UserID:          uid,
+		OrderNo:         orderNo,
 		Remark:          description,
-		TransactionType: "order_payment",
+		TransactionType: transactionType,
 		Amount:          amount,
 		BalanceBefore:   balanceBefore,
 		BalanceAfter:    balanceAfter,
 		Status:          "completed",
-		CreateTime:      time.Now(),
-		UpdateTime:      time.Now(),
+		CreateTime:      now,
+		UpdateTime:      now,
 	}
 
 	if err := tx.Create(&transaction).Error; err != nil {
-		return fmt.Errorf("记录交易流水失败: %w", err)
+		return nil, fmt.Errorf("记录交易流水失败: %w", err)
 	}
 
-	return nil
+	// 同一事务内记账
+	if _, err := NewWalletLedgerService().PostWalletTransaction(tx, &transaction,
+		WalletIdempotencyKey(transactionType, orderNo, transaction.ID)); err != nil {
+		return nil, fmt.Errorf("钱包记账失败: %w", err)
+	}
+
+	return &transaction, nil
 }
 
 // DistributedLock 分布式锁结构
@@ -303,6 +365,22 @@ func (dl *DistributedLock) Release() error {
 	return nil
 }
 
+// IsHeld 检查锁是否仍由当前持有者持有（续期失败或过期后返回false）
+func (dl *DistributedLock) IsHeld(ctx context.Context) (bool, error) {
+	if dl.redisClient == nil {
+		return false, fmt.Errorf("Redis客户端未初始化")
+	}
+
+	value, err := dl.redisClient.Get(ctx, dl.key).Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("查询锁状态失败: %w", err)
+	}
+	return value == dl.value, nil
+}
+
 // IdempotencyChecker 幂等性检查器
 type IdempotencyChecker struct {
 	redisClient *redis.Client
@@ -663,45 +741,57 @@ func (ocs *OrderCompensationService) fixStatusMismatches() error {
 }
 
 func (ocs *OrderCompensationService) refundToWallet(userID int, amount float64, description string) error {
-	tx := ocs.db.Begin()
-	defer tx.Rollback()
+	return ocs.refundToWalletWithType(userID, amount, app_model.TransactionTypeSystemRefund, "", description)
+}
 
-	// 增加用户钱包余额
-	result := tx.Model(&app_model.AppWallet{}).
-		Where("user_id = ?", userID).
-		Update("money", gorm.Expr("money + ?", amount))
+// refundToWalletWithType 退款到用户钱包并记录指定类型的流水
+// ocs.db 为事务时以保存点方式嵌套执行，随外层事务一起提交或回滚
+func (ocs *OrderCompensationService) refundToWalletWithType(userID int, amount float64, transactionType, orderNo, description string) error {
+	return ocs.db.Transaction(func(tx *gorm.DB) error {
+		// 增加用户钱包余额
+		result := tx.Model(&app_model.AppWallet{}).
+			Where("user_id = ?", userID).
+			Update("money", gorm.Expr("money + ?", amount))
 
-	if result.Error != nil {
-		return fmt.Errorf("退款失败: %w", result.Error)
-	}
+		if result.Error != nil {
+			return fmt.Errorf("退款失败: %w", result.Error)
+		}
 
-	if result.RowsAffected == 0 {
-		// 钱包不存在，创建新钱包
-		wallet := app_model.AppWallet{
-			UserId: userID,
-			Money:  amount,
+		if result.RowsAffected == 0 {
+			// 钱包不存在，创建新钱包
+			wallet := app_model.AppWallet{
+				UserId: userID,
+				Money:  amount,
+			}
+			if err := tx.Create(&wallet).Error; err != nil {
+				return fmt.Errorf("创建钱包失败: %w", err)
+			}
 		}
-		if err := tx.Create(&wallet).Error; err != nil {
-			return fmt.Errorf("创建钱包失败: %w", err)
+
+		// 记录退款流水
+		refundRecord := app_model.AppRecharge{
+			UserID:          userID,
+			Remark:          description,
+			OrderNo:         orderNo,
+			TransactionType: transactionType,
+			Amount:          amount,
+			Status:          "completed",
+			CreateTime:      time.Now(),
+			UpdateTime:      time.Now(),
 		}
-	}
 
-	// 记录退款流水
-	refundRecord := app_model.AppRecharge{
-		UserID:          userID,
-		Remark:          description,
-		TransactionType: "system_refund",
-		Amount:          amount,
-		Status:          "completed",
-		CreateTime:      time.Now(),
-		UpdateTime:      time.Now(),
-	}
+		if err := tx.Create(&refundRecord).Error; err != nil {
+			return fmt.Errorf("记录退款流水失败: %w", err)
+		}
 
-	if err := tx.Create(&refundRecord).Error; err != nil {
-		return fmt.Errorf("记录退款流水失败: %w", err)
-	}
+		// 同一事务内记账
+		if _, err := NewWalletLedgerService().PostWalletTransaction(tx, &refundRecord,
+			WalletIdempotencyKey(transactionType, orderNo, refundRecord.ID)); err != nil {
+			return fmt.Errorf("钱包记账失败: %w", err)
+		}
 
-	return tx.Commit().Error
+		return nil
+	})
 }
 
 // uid 生成唯一ID的辅助函数
@@ -91,23 +91,31 @@ func (osm *OrderStatusManager) initializeTransitionRules() {
 	log.Printf("订单状态管理器初始化完成，共加载 %d 条转换规则", len(rules))
 }
 
-// ValidateTransition 验证状态转换是否合法
-func (osm *OrderStatusManager) ValidateTransition(from, to OrderStatus, operator string) error {
+// CheckTransition 检查状态转换是否存在，不校验操作者，用于用户提交由商家审核后才执行转换的申请
+func (osm *OrderStatusManager) CheckTransition(from, to OrderStatus) error {
 	osm.mutex.RLock()
 	defer osm.mutex.RUnlock()
 
-	// 检查目标状态是否在允许的转换列表中
-	allowedTransitions := osm.transitions[from]
-	isAllowed := false
-	for _, allowedTo := range allowedTransitions {
+	return osm.checkTransition(from, to)
+}
+
+// checkTransition 检查目标状态是否在允许的转换列表中，调用方需持有读锁
+func (osm *OrderStatusManager) checkTransition(from, to OrderStatus) error {
+	for _, allowedTo := range osm.transitions[from] {
 		if allowedTo == to {
-			isAllowed = true
-			break
+			return nil
 		}
 	}
+	return fmt.Errorf("不允许的状态转换: %s -> %s", from, to)
+}
+
+// ValidateTransition 验证状态转换是否合法
+func (osm *OrderStatusManager) ValidateTransition(from, to OrderStatus, operator string) error {
+	osm.mutex.RLock()
+	defer osm.mutex.RUnlock()
 
-	if !isAllowed {
-		return fmt.Errorf("不允许的状态转换: %s -> %s", from, to)
+	if err := osm.checkTransition(from, to); err != nil {
+		return err
 	}
 
 	// 检查操作者是否有权限执行此转换
@@ -172,55 +180,64 @@ func (osm *OrderStatusManager) UpdateOrderStatus(orderNo string, newStatus Order
 		return nil
 	}
 
-	// 验证状态转换是否合法
-	if err := osm.ValidateTransition(currentStatus, newStatus, operator); err != nil {
+	if err := osm.TransitionInTx(tx, &order, newStatus, operator, reason); err != nil {
 		tx.Rollback()
 		return err
 	}
 
+	// 提交事务
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("提交状态更新事务失败: %w", err)
+	}
+
+	log.Printf("✅ 订单 %s 状态已更新: %s -> %s (操作者: %s)", orderNo, currentStatus, newStatus, operator)
+
+	// 异步发送状态变更通知
+	go osm.sendStatusChangeNotification(order, currentStatus, newStatus, operator)
+
+	return nil
+}
+
+// TransitionInTx 在调用方事务内变更订单状态：校验转换、更新状态、记录变更历史并执行状态变更的业务处理。
+// order 须为调用方在同一事务内锁定读取的订单，成功后 order.Status 更新为新状态
+func (osm *OrderStatusManager) TransitionInTx(tx *gorm.DB, order *app_model.AppOrder, newStatus OrderStatus, operator string, reason string) error {
+	currentStatus := OrderStatus(order.Status)
+	if err := osm.ValidateTransition(currentStatus, newStatus, operator); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	result := tx.Model(&app_model.AppOrder{}).
+		Where("id = ? AND status = ?", order.Id, order.Status).
+		Updates(map[string]interface{}{
+			"status":      string(newStatus),
+			"update_time": now,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("更新订单状态失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("订单状态已变更，请刷新后重试")
+	}
+
 	// 记录状态变更历史
-	statusHistory := app_model.OrderStatusHistory{
+	if err := tx.Create(&app_model.OrderStatusHistory{
 		OrderId:    order.Id,
-		OrderNo:    orderNo,
+		OrderNo:    order.No,
 		FromStatus: string(currentStatus),
 		ToStatus:   string(newStatus),
 		Operator:   operator,
 		Reason:     reason,
-		CreateTime: time.Now(),
-	}
-
-	if err := tx.Create(&statusHistory).Error; err != nil {
-		tx.Rollback()
+		CreateTime: now,
+	}).Error; err != nil {
 		return fmt.Errorf("记录状态变更历史失败: %w", err)
 	}
-
-	// 更新订单状态
-	err = tx.Model(&order).Updates(map[string]interface{}{
-		"status":      string(newStatus),
-		"update_time": time.Now(),
-	}).Error
-
-	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("更新订单状态失败: %w", err)
-	}
+	order.Status = string(newStatus)
 
 	// 根据新状态执行相应的业务逻辑
-	if err := osm.handleStatusChange(tx, &order, currentStatus, newStatus, operator); err != nil {
-		tx.Rollback()
+	if err := osm.handleStatusChange(tx, order, currentStatus, newStatus, operator); err != nil {
 		return fmt.Errorf("处理状态变更业务逻辑失败: %w", err)
 	}
-
-	// 提交事务
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("提交状态更新事务失败: %w", err)
-	}
-
-	log.Printf("✅ 订单 %s 状态已更新: %s -> %s (操作者: %s)", orderNo, currentStatus, newStatus, operator)
-
-	// 异步发送状态变更通知
-	go osm.sendStatusChangeNotification(order, currentStatus, newStatus, operator)
-
 	return nil
 }
 
@@ -252,9 +269,9 @@ func (osm *OrderStatusManager) handleStatusChange(tx *gorm.DB, order *app_model.
 // handlePaymentCompleted 处理支付完成
 func (osm *OrderStatusManager) handlePaymentCompleted(tx *gorm.DB, order *app_model.AppOrder) error {
 	log.Printf("订单 %s 支付完成，执行后续处理", order.No)
-	// 这里可以添加支付完成后的业务逻辑
-	// 例如：库存扣减、积分奖励、优惠券使用等
-	return nil
+
+	// 累计消费并发放积分
+	return NewMembershipService().EarnPoints(tx, order.UserId, app_model.PointsBizGoods, order.No, order.Amount)
 }
 
 // handleOrderCancelled 处理订单取消
@@ -276,6 +293,11 @@ func (osm *OrderStatusManager) handleOrderCancelled(tx *gorm.DB, order *app_mode
 		return fmt.Errorf("恢复商品库存失败: %w", err)
 	}
 
+	// 退回抵扣积分，已支付订单同时扣回消费积分
+	if err := NewMembershipService().RollbackPoints(tx, app_model.PointsBizGoods, order.No); err != nil {
+		return err
+	}
+
 	log.Printf("✅ 已恢复商品 %d 库存 %d 件", order.GoodsId, order.Num)
 	return nil
 }
@@ -293,6 +315,11 @@ func (osm *OrderStatusManager) handleOrderRefunded(tx *gorm.DB, order *app_model
 		return fmt.Errorf("恢复商品库存失败: %w", err)
 	}
 
+	// 退回抵扣积分并扣回消费积分
+	if err := NewMembershipService().RollbackPoints(tx, app_model.PointsBizGoods, order.No); err != nil {
+		return err
+	}
+
 	// 这里可以添加退款到用户钱包的逻辑
 	log.Printf("✅ 订单 %s 退款处理完成", order.No)
 	return nil
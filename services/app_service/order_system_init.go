@@ -117,6 +117,9 @@ func (osm *OrderSystemManager) startBackgroundTasks() {
 	// 3. 启动Redis超时队列处理器
 	go osm.startTimeoutQueueProcessor()
 
+	// 4. 启动钱包夜间对账任务
+	go osm.startWalletReconciler()
+
 	log.Printf("✅ 后台任务启动完成")
 }
 
@@ -137,12 +140,12 @@ func (osm *OrderSystemManager) startExpiredOrderChecker() {
 	log.Printf("🔍 过期订单检查器已启动")
 
 	for range ticker.C {
-		osm.checkExpiredOrders()
+		GetLeaderElector().RunAsLeader(JobExpiredOrderCheck, osm.checkExpiredOrders)
 	}
 }
 
 // checkExpiredOrders 检查并处理过期订单
-func (osm *OrderSystemManager) checkExpiredOrders() {
+func (osm *OrderSystemManager) checkExpiredOrders() error {
 	defer func() {
 		if r := recover(); r != nil {
 			log.Printf("检查过期订单时发生panic: %v", r)
@@ -163,12 +166,11 @@ func (osm *OrderSystemManager) checkExpiredOrders() {
 		Find(&expiredOrders).Error
 
 	if err != nil {
-		log.Printf("查询过期订单失败: %v", err)
-		return
+		return fmt.Errorf("查询过期订单失败: %w", err)
 	}
 
 	if len(expiredOrders) == 0 {
-		return
+		return nil
 	}
 
 	log.Printf("发现 %d 个过期订单，开始处理...", len(expiredOrders))
@@ -180,6 +182,7 @@ func (osm *OrderSystemManager) checkExpiredOrders() {
 			}
 		}(order.No)
 	}
+	return nil
 }
 
 // startConsistencyChecker 启动数据一致性检查器
@@ -201,13 +204,47 @@ func (osm *OrderSystemManager) startConsistencyChecker() {
 
 	for range ticker.C {
 		if osm.compensationSvc != nil {
-			if err := osm.compensationSvc.DetectAndFixInconsistencies(); err != nil {
-				log.Printf("数据一致性检查失败: %v", err)
-			}
+			GetLeaderElector().RunAsLeader(JobOrderConsistency, osm.compensationSvc.DetectAndFixInconsistencies)
 		}
 	}
 }
 
+// startWalletReconciler 启动钱包夜间对账任务（每天凌晨3点）
+func (osm *OrderSystemManager) startWalletReconciler() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("钱包对账任务发生panic: %v", r)
+			// 10分钟后重启
+			time.Sleep(10 * time.Minute)
+			go osm.startWalletReconciler()
+		}
+	}()
+
+	log.Printf("🔍 钱包夜间对账任务已启动")
+
+	ledgerService := NewWalletLedgerService()
+	for {
+		now := time.Now()
+		next := time.Date(now.Year(), now.Month(), now.Day(), 3, 0, 0, 0, now.Location())
+		if !next.After(now) {
+			next = next.Add(24 * time.Hour)
+		}
+		time.Sleep(time.Until(next))
+
+		GetLeaderElector().RunAsLeader(JobWalletReconcile, func() error {
+			report, err := ledgerService.ReconcileWallets()
+			if err != nil {
+				return fmt.Errorf("钱包对账失败: %w", err)
+			}
+
+			if osm.monitoringService != nil {
+				osm.monitoringService.ReportWalletLedgerDrift(report)
+			}
+			return nil
+		})
+	}
+}
+
 // startTimeoutQueueProcessor 启动Redis超时队列处理器
 func (osm *OrderSystemManager) startTimeoutQueueProcessor() {
 	if osm.redisClient == nil {
@@ -227,13 +264,13 @@ func (osm *OrderSystemManager) startTimeoutQueueProcessor() {
 	log.Printf("🔍 Redis超时队列处理器已启动")
 
 	for {
-		osm.processTimeoutQueue()
+		GetLeaderElector().RunAsLeader(JobOrderTimeoutQueue, osm.processTimeoutQueue)
 		time.Sleep(5 * time.Second) // 每5秒检查一次
 	}
 }
 
 // processTimeoutQueue 处理Redis超时队列
-func (osm *OrderSystemManager) processTimeoutQueue() {
+func (osm *OrderSystemManager) processTimeoutQueue() error {
 	defer func() {
 		if r := recover(); r != nil {
 			log.Printf("处理Redis超时队列时发生panic: %v", r)
@@ -255,12 +292,11 @@ func (osm *OrderSystemManager) processTimeoutQueue() {
 	).Result()
 
 	if err != nil {
-		log.Printf("获取超时订单失败: %v", err)
-		return
+		return fmt.Errorf("获取超时订单失败: %w", err)
 	}
 
 	if len(results) == 0 {
-		return // 没有超时订单
+		return errJobIdle // 没有超时订单，不写执行记录
 	}
 
 	log.Printf("发现 %d 个超时订单需要处理", len(results))
@@ -305,6 +341,7 @@ func (osm *OrderSystemManager) processTimeoutQueue() {
 			}
 		}(orderNo)
 	}
+	return nil
 }
 
 // GetSystemStatus 获取系统状态
@@ -345,6 +382,9 @@ func (osm *OrderSystemManager) GetSystemStatus() map[string]interface{} {
 		"enabled": osm.compensationSvc != nil,
 	}
 
+	// 后台任务选主状态
+	components["leader_election"] = GetLeaderElector().GetStatus()
+
 	return status
 }
 
@@ -416,6 +456,9 @@ func (osm *OrderSystemManager) PerformHealthCheck() map[string]interface{} {
 		}
 	}
 
+	// 后台任务选主
+	components["leader_election"] = GetLeaderElector().GetStatus()
+
 	return health
 }
 
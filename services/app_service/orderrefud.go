@@ -1,56 +1,398 @@
 package app_service
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"log"
 	"nasa-go-admin/db"
 	"nasa-go-admin/inout"
 	"nasa-go-admin/model/app_model"
+	"nasa-go-admin/model/miniapp_model"
+	"nasa-go-admin/redis"
+	"nasa-go-admin/services/public_service"
+	"nasa-go-admin/utils"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OrderRefundService struct{}
 
-// Refund 申请退款
+// Refund 申请退款（提交后等待商家审核）
 func (s *OrderRefundService) Refund(c *gin.Context, uid int, params inout.RefundReq) (interface{}, error) {
-
 	// 查询订单是否存在
 	var order app_model.AppOrder
 	err := db.Dao.Where("id = ? AND user_id = ?", params.OrderId, uid).First(&order).Error
 	if err != nil {
-		return nil, err
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("订单不存在")
+		}
+		return nil, fmt.Errorf("查询订单失败: %w", err)
+	}
+
+	// 退款需经商家审核，提交前先确认订单当前状态存在到已退款的转换
+	if err := s.getStatusManager().CheckTransition(OrderStatus(order.Status), StatusRefunded); err != nil {
+		return nil, fmt.Errorf("当前订单状态不支持退款: %w", err)
+	}
+
+	// 订单级别锁 - 防止重复提交
+	refundLock := NewSecurityOrderService(redis.GetClient()).NewDistributedLock(
+		fmt.Sprintf("order_refund:%s", order.No),
+		30*time.Second,
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
+	if err := refundLock.AcquireWithRenewal(ctx); err != nil {
+		return nil, fmt.Errorf("退款申请处理中，请稍后再试: %w", err)
 	}
+	defer refundLock.Release()
 
-	// 退款订单
+	// 同一订单只能有一个待审核的退款申请
+	var pendingCount int64
+	if err := db.Dao.Model(&app_model.OrderRefund{}).
+		Where("order_id = ? AND status = ?", order.Id, app_model.RefundStatusPending).
+		Count(&pendingCount).Error; err != nil {
+		return nil, fmt.Errorf("查询退款申请失败: %w", err)
+	}
+	if pendingCount > 0 {
+		return nil, fmt.Errorf("该订单已有待审核的退款申请")
+	}
+
+	images, err := json.Marshal(params.Images)
+	if err != nil {
+		return nil, fmt.Errorf("凭证图片格式错误: %w", err)
+	}
+
+	// 退款申请
+	now := time.Now()
 	refund := app_model.OrderRefund{
-		UserId:  uid,
-		Amount:  order.Amount,
-		No:      order.No,
-		GoodsId: order.GoodsId,
-		Status:  "0",
-		OrderId: order.Id,
-	}
-	err = db.Dao.Create(&refund).Error
+		UserId:     uid,
+		Amount:     order.Amount,
+		No:         order.No,
+		GoodsId:    order.GoodsId,
+		TenantsId:  order.TenantsId,
+		Reason:     params.Reason,
+		Images:     string(images),
+		Status:     app_model.RefundStatusPending,
+		OrderId:    order.Id,
+		CreateTime: now,
+		UpdateTime: now,
+	}
+	if err := db.Dao.Create(&refund).Error; err != nil {
+		return nil, fmt.Errorf("提交退款申请失败: %w", err)
+	}
+
+	log.Printf("✅ 订单 %s 退款申请已提交 (申请ID: %d, 金额: %.2f)", order.No, refund.Id, refund.Amount)
+
+	goodsName := s.getGoodsName(order.GoodsId)
+	go s.sendRefundNotification(order.UserId, order.No, "refund_applied", goodsName)
+
+	return s.convertRefundToItem(&refund, goodsName), nil
+}
+
+// GetRefundList 获取退款申请列表（商家只能查看自己的申请）
+func (s *OrderRefundService) GetRefundList(params inout.OrderRefundListReq, tenantsId int, isAdmin bool) (*inout.OrderRefundListResp, error) {
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+	if params.PageSize <= 0 || params.PageSize > 100 {
+		params.PageSize = 10
+	}
+
+	query := db.Dao.Model(&app_model.OrderRefund{})
+	if !isAdmin {
+		query = query.Where("tenants_id = ?", tenantsId)
+	}
+	if params.Status != "" {
+		query = query.Where("status = ?", params.Status)
+	}
+	if params.No != "" {
+		query = query.Where("no = ?", params.No)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("统计退款申请失败: %w", err)
+	}
+
+	var refunds []app_model.OrderRefund
+	offset := (params.Page - 1) * params.PageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(params.PageSize).Find(&refunds).Error; err != nil {
+		return nil, fmt.Errorf("查询退款申请列表失败: %w", err)
+	}
+
+	// 批量查询商品名称
+	goodsIds := make([]int, 0, len(refunds))
+	for _, refund := range refunds {
+		goodsIds = append(goodsIds, refund.GoodsId)
+	}
+	goodsNames := make(map[int]string)
+	if len(goodsIds) > 0 {
+		var goods []app_model.AppGoods
+		if err := db.Dao.Select("id, goods_name").Where("id IN ?", goodsIds).Find(&goods).Error; err != nil {
+			log.Printf("查询商品信息失败: %v", err)
+		}
+		for _, g := range goods {
+			goodsNames[g.Id] = g.GoodsName
+		}
+	}
+
+	items := make([]inout.OrderRefundItem, 0, len(refunds))
+	for i := range refunds {
+		items = append(items, *s.convertRefundToItem(&refunds[i], goodsNames[refunds[i].GoodsId]))
+	}
+
+	return &inout.OrderRefundListResp{
+		Items:    items,
+		Total:    total,
+		Page:     params.Page,
+		PageSize: params.PageSize,
+	}, nil
+}
+
+// ApproveRefund 同意退款：订单置为已退款、恢复库存并退款到用户钱包
+func (s *OrderRefundService) ApproveRefund(params inout.ApproveRefundReq, tenantsId int, isAdmin bool, operatorId int) error {
+	refund, err := s.getRefundForAudit(params.Id, tenantsId, isAdmin)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	securityService := NewSecurityOrderService(redis.GetClient())
+
+	// 订单级别锁 - 与退款申请、订单取消互斥
+	refundLock := securityService.NewDistributedLock(
+		fmt.Sprintf("order_refund:%s", refund.No),
+		30*time.Second,
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := refundLock.AcquireWithRenewal(ctx); err != nil {
+		return fmt.Errorf("退款处理中，请稍后再试: %w", err)
+	}
+	defer refundLock.Release()
+
+	tx := db.Dao.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			log.Printf("审核退款时发生panic: %v", r)
+			panic(r)
+		}
+	}()
+
+	// 锁定退款申请并再次检查状态
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(refund, refund.Id).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("查询退款申请失败: %w", err)
+	}
+	if refund.Status != app_model.RefundStatusPending {
+		tx.Rollback()
+		return fmt.Errorf("退款申请已处理，当前状态: %s", refund.GetRefundStatusText())
+	}
+
+	// 锁定订单
+	var order app_model.AppOrder
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, refund.OrderId).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("查询订单失败: %w", err)
 	}
 
-	// 更新订单状态
-	err = db.Dao.Model(&order).Update("status", "3").Error
+	// 经状态管理器变更为已退款：记录状态历史、恢复库存并回滚积分
+	reason := "同意退款申请: " + refund.Reason
+	if params.Remark != "" {
+		reason += " (" + params.Remark + ")"
+	}
+	if runes := []rune(reason); len(runes) > 200 {
+		reason = string(runes[:200])
+	}
+	if err := s.getStatusManager().TransitionInTx(tx, &order, StatusRefunded, "admin", reason); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// 退回下单时使用的优惠券
+	if err := NewCouponService().ReturnCoupon(tx, app_model.CouponOrderGoods, order.No); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// 更新退款申请状态
+	now := time.Now()
+	if err := tx.Model(&app_model.OrderRefund{}).
+		Where("id = ? AND status = ?", refund.Id, app_model.RefundStatusPending).
+		Updates(map[string]interface{}{
+			"status":      app_model.RefundStatusApproved,
+			"audit_by":    operatorId,
+			"audit_time":  now,
+			"update_time": now,
+		}).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("更新退款申请失败: %w", err)
+	}
+
+	// 退款到用户钱包（与订单状态变更同一事务）
+	compensationService := securityService.NewOrderCompensationService(tx)
+	if err := compensationService.refundToWalletWithType(order.UserId, refund.Amount,
+		app_model.TransactionTypeOrderRefund, order.No,
+		fmt.Sprintf("商品订单退款[%s]", order.No)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("提交退款事务失败: %w", err)
+	}
+
+	log.Printf("✅ 订单 %s 退款已完成 (金额: %.2f, 审核人: %d)", order.No, refund.Amount, operatorId)
+
+	go s.sendRefundNotification(order.UserId, order.No, string(StatusRefunded), s.getGoodsName(order.GoodsId))
+	refund.Status = app_model.RefundStatusApproved
+	refund.AuditTime = &now
+	go s.sendRefundSubscribeMsg(refund, miniapp_model.EventOrderRefundApproved)
+
+	return nil
+}
+
+// RejectRefund 拒绝退款申请，订单状态保持不变
+func (s *OrderRefundService) RejectRefund(params inout.RejectRefundReq, tenantsId int, isAdmin bool, operatorId int) error {
+	refund, err := s.getRefundForAudit(params.Id, tenantsId, isAdmin)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	now := time.Now()
+	result := db.Dao.Model(&app_model.OrderRefund{}).
+		Where("id = ? AND status = ?", refund.Id, app_model.RefundStatusPending).
+		Updates(map[string]interface{}{
+			"status":        app_model.RefundStatusRejected,
+			"reject_reason": params.Reason,
+			"audit_by":      operatorId,
+			"audit_time":    now,
+			"update_time":   now,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("更新退款申请失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("退款申请已处理，请刷新后重试")
+	}
+
+	log.Printf("订单 %s 退款申请已拒绝 (审核人: %d, 原因: %s)", refund.No, operatorId, params.Reason)
+
+	refund.Status = app_model.RefundStatusRejected
+	refund.RejectReason = params.Reason
+	refund.AuditTime = &now
+	go s.sendRefundNotification(refund.UserId, refund.No, "refund_rejected", s.getGoodsName(refund.GoodsId))
+	go s.sendRefundSubscribeMsg(refund, miniapp_model.EventOrderRefundRejected)
+
+	return nil
+}
+
+// getRefundForAudit 查询待审核的退款申请并校验商家归属
+func (s *OrderRefundService) getRefundForAudit(id, tenantsId int, isAdmin bool) (*app_model.OrderRefund, error) {
+	var refund app_model.OrderRefund
+	if err := db.Dao.First(&refund, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("退款申请不存在")
+		}
+		return nil, fmt.Errorf("查询退款申请失败: %w", err)
 	}
 
-	// 使用新的安全订单创建器进行订单取消
-	secureCreator := GetGlobalSecureOrderCreator()
-	if secureCreator != nil {
-		err = secureCreator.CancelExpiredOrder(order.No)
-		if err != nil {
-			log.Printf("取消订单失败: %v", err)
+	if !isAdmin && refund.TenantsId != tenantsId {
+		return nil, fmt.Errorf("无权审核该退款申请")
+	}
+
+	if refund.Status != app_model.RefundStatusPending {
+		return nil, fmt.Errorf("退款申请已处理，当前状态: %s", refund.GetRefundStatusText())
+	}
+
+	return &refund, nil
+}
+
+// getStatusManager 获取订单状态管理器
+func (s *OrderRefundService) getStatusManager() *OrderStatusManager {
+	return GetServiceInitializer().GetOrderStatusManager()
+}
+
+// getGoodsName 获取商品名称
+func (s *OrderRefundService) getGoodsName(goodsId int) string {
+	var goods app_model.AppGoods
+	if err := db.Dao.Select("id, goods_name").First(&goods, goodsId).Error; err != nil {
+		log.Printf("查询商品信息失败 (商品ID: %d): %v", goodsId, err)
+		return ""
+	}
+	return goods.GoodsName
+}
+
+// sendRefundNotification 发送退款通知
+func (s *OrderRefundService) sendRefundNotification(userId int, orderNo, status, goodsName string) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("发送退款通知时发生panic: %v", r)
+		}
+	}()
+
+	wsService := public_service.GetWebSocketService()
+	if wsService != nil {
+		if err := wsService.SendOrderNotification(userId, orderNo, status, goodsName); err != nil {
+			log.Printf("发送退款通知失败 订单:%s 错误:%v", orderNo, err)
 		}
 	}
+}
+
+// sendRefundSubscribeMsg 发送退款审核结果订阅消息
+func (s *OrderRefundService) sendRefundSubscribeMsg(refund *app_model.OrderRefund, event string) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("发送退款订阅消息时发生panic: %v", r)
+		}
+	}()
 
-	return nil, nil
+	var order app_model.AppOrder
+	if err := db.Dao.First(&order, refund.OrderId).Error; err != nil {
+		log.Printf("查询订单失败 (订单ID: %d): %v", refund.OrderId, err)
+		return
+	}
+	if _, err := SendUserSubscribeEvent(refund.UserId, event, OrderEventPayload(&order, refund)); err != nil {
+		log.Printf("发送退款订阅消息失败 订单:%s 错误:%v", order.No, err)
+	}
+}
+
+// convertRefundToItem 转换退款申请为响应格式
+func (s *OrderRefundService) convertRefundToItem(refund *app_model.OrderRefund, goodsName string) *inout.OrderRefundItem {
+	images := make([]string, 0)
+	if refund.Images != "" {
+		if err := json.Unmarshal([]byte(refund.Images), &images); err != nil || images == nil {
+			images = make([]string, 0)
+		}
+	}
+
+	item := &inout.OrderRefundItem{
+		Id:           refund.Id,
+		OrderId:      refund.OrderId,
+		No:           refund.No,
+		UserId:       refund.UserId,
+		GoodsId:      refund.GoodsId,
+		GoodsName:    goodsName,
+		Amount:       refund.Amount,
+		Reason:       refund.Reason,
+		Images:       images,
+		Status:       refund.Status,
+		StatusText:   refund.GetRefundStatusText(),
+		RejectReason: refund.RejectReason,
+		AuditBy:      refund.AuditBy,
+		CreateTime:   utils.FormatTime2(refund.CreateTime),
+	}
+	if refund.AuditTime != nil {
+		item.AuditTime = utils.FormatTime2(*refund.AuditTime)
+	}
 
+	return item
 }
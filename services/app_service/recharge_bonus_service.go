@@ -0,0 +1,130 @@
+package app_service
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"nasa-go-admin/db"
+	"nasa-go-admin/inout"
+	"nasa-go-admin/model/app_model"
+
+	"gorm.io/gorm"
+)
+
+// RechargeBonusService 充值赠送规则服务
+type RechargeBonusService struct{}
+
+// NewRechargeBonusService 创建充值赠送规则服务
+func NewRechargeBonusService() *RechargeBonusService {
+	return &RechargeBonusService{}
+}
+
+// CreateRule 创建充值赠送规则
+func (s *RechargeBonusService) CreateRule(req *inout.CreateRechargeBonusRuleReq) (*app_model.RechargeBonusRule, error) {
+	rule := &app_model.RechargeBonusRule{IsActive: true}
+	if err := s.fillRule(rule, req); err != nil {
+		return nil, err
+	}
+
+	if err := db.Dao.Create(rule).Error; err != nil {
+		return nil, fmt.Errorf("创建充值赠送规则失败: %v", err)
+	}
+
+	log.Printf("充值赠送规则已创建: %s (充 %.2f 送 %.2f)", rule.Name, rule.MinAmount, rule.BonusAmount)
+	return rule, nil
+}
+
+// UpdateRule 更新充值赠送规则
+func (s *RechargeBonusService) UpdateRule(req *inout.UpdateRechargeBonusRuleReq) (*app_model.RechargeBonusRule, error) {
+	var rule app_model.RechargeBonusRule
+	if err := db.Dao.First(&rule, req.ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("充值赠送规则不存在")
+		}
+		return nil, fmt.Errorf("查询充值赠送规则失败: %v", err)
+	}
+
+	if err := s.fillRule(&rule, &req.CreateRechargeBonusRuleReq); err != nil {
+		return nil, err
+	}
+	rule.IsActive = req.IsActive
+
+	if err := db.Dao.Save(&rule).Error; err != nil {
+		return nil, fmt.Errorf("更新充值赠送规则失败: %v", err)
+	}
+
+	log.Printf("充值赠送规则已更新: %s (ID: %d)", rule.Name, rule.ID)
+	return &rule, nil
+}
+
+// GetRuleList 获取充值赠送规则列表，按充值门槛升序
+func (s *RechargeBonusService) GetRuleList() ([]app_model.RechargeBonusRule, error) {
+	var rules []app_model.RechargeBonusRule
+	if err := db.Dao.Order("min_amount ASC, id ASC").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("查询充值赠送规则失败: %v", err)
+	}
+	return rules, nil
+}
+
+// DeleteRule 删除充值赠送规则
+func (s *RechargeBonusService) DeleteRule(id int) error {
+	result := db.Dao.Delete(&app_model.RechargeBonusRule{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("删除充值赠送规则失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("充值赠送规则不存在")
+	}
+	return nil
+}
+
+// MatchRule 查找充值金额可享受的赠送规则，取门槛最高的一条，没有匹配时返回 nil
+func (s *RechargeBonusService) MatchRule(tx *gorm.DB, amount float64, at time.Time) (*app_model.RechargeBonusRule, error) {
+	var rule app_model.RechargeBonusRule
+	err := tx.Where("is_active = ? AND min_amount <= ?", true, amount).
+		Where("valid_from IS NULL OR valid_from <= ?", at).
+		Where("valid_to IS NULL OR valid_to > ?", at).
+		Order("min_amount DESC, bonus_amount DESC").
+		First(&rule).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询充值赠送规则失败: %v", err)
+	}
+	return &rule, nil
+}
+
+// fillRule 校验请求并写入规则字段
+func (s *RechargeBonusService) fillRule(rule *app_model.RechargeBonusRule, req *inout.CreateRechargeBonusRuleReq) error {
+	var validFrom, validTo *time.Time
+	if req.ValidFrom != "" {
+		t, err := time.ParseInLocation("2006-01-02 15:04:05", req.ValidFrom, time.Local)
+		if err != nil {
+			return fmt.Errorf("生效时间格式错误: %v", err)
+		}
+		validFrom = &t
+	}
+	if req.ValidTo != "" {
+		t, err := time.ParseInLocation("2006-01-02 15:04:05", req.ValidTo, time.Local)
+		if err != nil {
+			return fmt.Errorf("失效时间格式错误: %v", err)
+		}
+		validTo = &t
+	}
+	if validFrom != nil && validTo != nil && !validTo.After(*validFrom) {
+		return fmt.Errorf("失效时间必须晚于生效时间")
+	}
+	if req.BonusAmount > req.MinAmount {
+		return fmt.Errorf("赠送金额不能超过充值门槛")
+	}
+
+	rule.Name = req.Name
+	rule.MinAmount = math.Round(req.MinAmount*100) / 100
+	rule.BonusAmount = math.Round(req.BonusAmount*100) / 100
+	rule.ValidFrom = validFrom
+	rule.ValidTo = validTo
+	return nil
+}
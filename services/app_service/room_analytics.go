@@ -0,0 +1,473 @@
+package app_service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"nasa-go-admin/inout"
+	"nasa-go-admin/model/app_model"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// analyticsMaxDays 利用率分析单次最多查询天数
+const analyticsMaxDays = 366
+
+// analyticsBooking 参与分析的预订
+type analyticsBooking struct {
+	ID         int
+	RoomID     int
+	BookingNo  string
+	StartTime  time.Time
+	EndTime    time.Time
+	Status     int
+	PaidAmount float64
+	CreateTime time.Time
+	VerifiedAt *time.Time
+}
+
+// analyticsWindow 分析区间和每日营业时段，区间结束时间不晚于当前时间
+type analyticsWindow struct {
+	start     time.Time
+	end       time.Time
+	openHour  int
+	closeHour int
+}
+
+// eachOpenSlot 按小时遍历营业时段，返回每个时段在区间内的小时数
+func (w *analyticsWindow) eachOpenSlot(from, to time.Time, fn func(slot time.Time, hours float64)) {
+	if from.Before(w.start) {
+		from = w.start
+	}
+	if to.After(w.end) {
+		to = w.end
+	}
+	for t := from.Truncate(time.Hour); t.Before(to); t = t.Add(time.Hour) {
+		if t.Hour() < w.openHour || t.Hour() >= w.closeHour {
+			continue
+		}
+		segStart, segEnd := t, t.Add(time.Hour)
+		if segStart.Before(from) {
+			segStart = from
+		}
+		if segEnd.After(to) {
+			segEnd = to
+		}
+		if segEnd.After(segStart) {
+			fn(t, segEnd.Sub(segStart).Hours())
+		}
+	}
+}
+
+// GetRoomAnalytics 房间利用率分析：占用小时/营业小时、周几×小时热力图、爽约率、提前预订时长和每房间小时收入
+func (rs *RoomService) GetRoomAnalytics(req *inout.RoomAnalyticsReq) (*inout.RoomAnalyticsResp, error) {
+	startDate, err := time.ParseInLocation("2006-01-02", req.StartDate, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("开始日期格式错误: %v", err)
+	}
+	endDate, err := time.ParseInLocation("2006-01-02", req.EndDate, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("结束日期格式错误: %v", err)
+	}
+	if endDate.Before(startDate) {
+		return nil, fmt.Errorf("结束日期不能早于开始日期")
+	}
+	rangeEnd := endDate.AddDate(0, 0, 1)
+	if rangeEnd.Sub(startDate) > analyticsMaxDays*24*time.Hour {
+		return nil, fmt.Errorf("查询范围不能超过 %d 天", analyticsMaxDays)
+	}
+
+	closeHour := req.CloseHour
+	if closeHour == 0 {
+		closeHour = 24
+	}
+	if closeHour <= req.OpenHour {
+		return nil, fmt.Errorf("营业结束时间必须晚于开始时间")
+	}
+
+	now := time.Now()
+	window := &analyticsWindow{start: startDate, end: rangeEnd, openHour: req.OpenHour, closeHour: closeHour}
+	if window.end.After(now) {
+		window.end = now
+	}
+
+	resp := &inout.RoomAnalyticsResp{
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		OpenHour:  req.OpenHour,
+		CloseHour: closeHour,
+		Rooms:     make([]*inout.RoomUtilizationItem, 0),
+		Types:     make([]*inout.RoomUtilizationItem, 0),
+		Floors:    make([]*inout.RoomUtilizationItem, 0),
+	}
+
+	// 停用房间不参与统计
+	query := rs.dao().Model(&app_model.Room{}).Where("status <> ?", app_model.RoomStatusDisabled)
+	if req.RoomType != "" {
+		query = query.Where("room_type = ?", req.RoomType)
+	}
+	if req.Floor != nil {
+		query = query.Where("floor = ?", *req.Floor)
+	}
+	var rooms []app_model.Room
+	if err := query.Order("id ASC").Find(&rooms).Error; err != nil {
+		return nil, fmt.Errorf("查询房间失败: %v", err)
+	}
+	if len(rooms) == 0 {
+		return resp, nil
+	}
+
+	roomIDs := make([]int, 0, len(rooms))
+	roomItems := make(map[int]*inout.RoomUtilizationItem, len(rooms))
+	for _, room := range rooms {
+		roomIDs = append(roomIDs, room.ID)
+		item := &inout.RoomUtilizationItem{
+			RoomID:    room.ID,
+			RoomName:  room.RoomName,
+			RoomType:  room.RoomType,
+			Floor:     room.Floor,
+			RoomCount: 1,
+		}
+		roomItems[room.ID] = item
+		resp.Rooms = append(resp.Rooms, item)
+	}
+
+	// 营业小时：每个房间相同，热力图分母按周几×小时累计
+	var openHoursPerRoom float64
+	var slotHours [7][24]float64
+	window.eachOpenSlot(window.start, window.end, func(slot time.Time, hours float64) {
+		openHoursPerRoom += hours
+		slotHours[slot.Weekday()][slot.Hour()] += hours
+	})
+
+	// 与区间有交集的已支付预订
+	var bookings []analyticsBooking
+	if err := rs.dao().Model(&app_model.RoomBooking{}).
+		Select("id, room_id, booking_no, start_time, end_time, status, paid_amount, create_time, verified_at").
+		Where("room_id IN ? AND start_time < ? AND end_time > ?", roomIDs, rangeEnd, startDate).
+		Where("status <> ? AND paid_amount > 0", app_model.BookingStatusPending).
+		Scan(&bookings).Error; err != nil {
+		return nil, fmt.Errorf("查询预订失败: %v", err)
+	}
+
+	usageLogs, refunds, err := rs.loadAnalyticsUsage(bookings)
+	if err != nil {
+		return nil, err
+	}
+
+	var occupiedSlots [7][24]float64
+	var leadHours float64
+	var leadCount int
+	for _, booking := range bookings {
+		item := roomItems[booking.RoomID]
+
+		// 占用区间以入场记录为准，没有入场记录的使用中/已完成预订按预订时段计算
+		var occupiedFrom, occupiedTo time.Time
+		if usage, ok := usageLogs[booking.ID]; ok {
+			occupiedFrom, occupiedTo = usage.CheckInAt, now
+			if usage.CheckOutAt != nil {
+				occupiedTo = *usage.CheckOutAt
+			}
+		} else if booking.Status == app_model.BookingStatusInUse || booking.Status == app_model.BookingStatusCompleted {
+			occupiedFrom, occupiedTo = booking.StartTime, booking.EndTime
+		}
+		if !occupiedFrom.IsZero() {
+			window.eachOpenSlot(occupiedFrom, occupiedTo, func(slot time.Time, hours float64) {
+				item.OccupiedHours += hours
+				occupiedSlots[slot.Weekday()][slot.Hour()] += hours
+			})
+		}
+
+		// 预订数、收入、爽约和提前时长按开始时间归属到区间
+		if booking.StartTime.Before(startDate) || !booking.StartTime.Before(rangeEnd) {
+			continue
+		}
+		item.BookingCount++
+		item.NetRevenue += booking.PaidAmount - refunds[booking.BookingNo]
+
+		leadHours += booking.StartTime.Sub(booking.CreateTime).Hours()
+		leadCount++
+
+		if booking.StartTime.After(now) {
+			continue
+		}
+		switch booking.Status {
+		case app_model.BookingStatusPaid, app_model.BookingStatusInUse, app_model.BookingStatusCompleted:
+			resp.PaidBookings++
+			if isNoShow(booking, usageLogs[booking.ID]) {
+				resp.NoShowBookings++
+			}
+		}
+	}
+
+	// 汇总房间、房型、楼层
+	typeItems := make(map[string]*inout.RoomUtilizationItem)
+	floorItems := make(map[int]*inout.RoomUtilizationItem)
+	for _, item := range resp.Rooms {
+		item.OpenHours = openHoursPerRoom
+
+		typeItem, ok := typeItems[item.RoomType]
+		if !ok {
+			typeItem = &inout.RoomUtilizationItem{RoomType: item.RoomType}
+			typeItems[item.RoomType] = typeItem
+			resp.Types = append(resp.Types, typeItem)
+		}
+		floorItem, ok := floorItems[item.Floor]
+		if !ok {
+			floorItem = &inout.RoomUtilizationItem{Floor: item.Floor}
+			floorItems[item.Floor] = floorItem
+			resp.Floors = append(resp.Floors, floorItem)
+		}
+		for _, agg := range []*inout.RoomUtilizationItem{typeItem, floorItem, &resp.Summary} {
+			agg.RoomCount++
+			agg.OpenHours += item.OpenHours
+			agg.OccupiedHours += item.OccupiedHours
+			agg.BookingCount += item.BookingCount
+			agg.NetRevenue += item.NetRevenue
+		}
+	}
+	sort.Slice(resp.Floors, func(i, j int) bool { return resp.Floors[i].Floor < resp.Floors[j].Floor })
+
+	for _, list := range [][]*inout.RoomUtilizationItem{resp.Rooms, resp.Types, resp.Floors, {&resp.Summary}} {
+		for _, item := range list {
+			finishUtilizationItem(item)
+		}
+	}
+
+	for weekday := 0; weekday < 7; weekday++ {
+		for hour := 0; hour < 24; hour++ {
+			if capacity := slotHours[weekday][hour] * float64(len(rooms)); capacity > 0 {
+				resp.Heatmap[weekday][hour] = percent(occupiedSlots[weekday][hour], capacity)
+			}
+		}
+	}
+
+	resp.NoShowRate = percent(float64(resp.NoShowBookings), float64(resp.PaidBookings))
+	if leadCount > 0 {
+		resp.AvgLeadHours = math.Round(leadHours/float64(leadCount)*100) / 100
+	}
+	resp.NetRevenue = resp.Summary.NetRevenue
+	resp.RevPerRoomHour = resp.Summary.RevPerRoomHour
+
+	return resp, nil
+}
+
+// isNoShow 开始时间已到的预订既没有前台办理入住也没有核验入场凭证时视为爽约。
+// 调度器到点自动开始的预订也会生成使用记录，但入住经办人为0，不能作为到店依据
+func isNoShow(booking analyticsBooking, usage app_model.RoomUsageLog) bool {
+	return usage.CheckInBy == 0 && booking.VerifiedAt == nil
+}
+
+// loadAnalyticsUsage 加载预订的入场记录和退款金额
+func (rs *RoomService) loadAnalyticsUsage(bookings []analyticsBooking) (map[int]app_model.RoomUsageLog, map[string]float64, error) {
+	usageLogs := make(map[int]app_model.RoomUsageLog)
+	refunds := make(map[string]float64)
+	if len(bookings) == 0 {
+		return usageLogs, refunds, nil
+	}
+
+	bookingIDs := make([]int, 0, len(bookings))
+	bookingNos := make([]string, 0, len(bookings))
+	for _, booking := range bookings {
+		bookingIDs = append(bookingIDs, booking.ID)
+		bookingNos = append(bookingNos, booking.BookingNo)
+	}
+
+	var logs []app_model.RoomUsageLog
+	if err := rs.dao().Where("booking_id IN ?", bookingIDs).Find(&logs).Error; err != nil {
+		return nil, nil, fmt.Errorf("查询使用记录失败: %v", err)
+	}
+	for _, usage := range logs {
+		usageLogs[usage.BookingID] = usage
+	}
+
+	var rows []struct {
+		OrderNo string
+		Amount  float64
+	}
+	if err := rs.dao().Model(&app_model.AppRecharge{}).
+		Select("order_no, SUM(amount) AS amount").
+		Where("transaction_type = ? AND order_no IN ?", app_model.TransactionTypeBookingRefund, bookingNos).
+		Group("order_no").
+		Scan(&rows).Error; err != nil {
+		return nil, nil, fmt.Errorf("查询预订退款失败: %v", err)
+	}
+	for _, row := range rows {
+		refunds[row.OrderNo] = row.Amount
+	}
+
+	return usageLogs, refunds, nil
+}
+
+// finishUtilizationItem 计算利用率和每房间小时收入，数值保留两位小数
+func finishUtilizationItem(item *inout.RoomUtilizationItem) {
+	item.Utilization = percent(item.OccupiedHours, item.OpenHours)
+	if item.OpenHours > 0 {
+		item.RevPerRoomHour = math.Round(item.NetRevenue/item.OpenHours*100) / 100
+	}
+	item.OpenHours = math.Round(item.OpenHours*100) / 100
+	item.OccupiedHours = math.Round(item.OccupiedHours*100) / 100
+	item.NetRevenue = math.Round(item.NetRevenue*100) / 100
+}
+
+// percent 百分比，保留两位小数
+func percent(part, total float64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return math.Round(part/total*10000) / 100
+}
+
+// ExportRoomAnalytics 导出房间利用率分析为Excel，按房间、房型、楼层、热力图分工作表
+func (rs *RoomService) ExportRoomAnalytics(req *inout.RoomAnalyticsReq) ([]byte, string, error) {
+	analytics, err := rs.GetRoomAnalytics(req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	f := excelize.NewFile()
+	defer func() {
+		if err := f.Close(); err != nil {
+			fmt.Printf("关闭Excel文件失败: %v\n", err)
+		}
+	}()
+
+	utilizationHeaders := []string{"名称", "房间数", "营业小时", "占用小时", "利用率(%)", "预订数", "净收入", "每房间小时收入"}
+	utilizationRow := func(name interface{}, item *inout.RoomUtilizationItem) []interface{} {
+		return []interface{}{name, item.RoomCount, item.OpenHours, item.OccupiedHours,
+			item.Utilization, item.BookingCount, item.NetRevenue, item.RevPerRoomHour}
+	}
+
+	summaryRows := [][]interface{}{
+		{"统计区间", fmt.Sprintf("%s ~ %s", analytics.StartDate, analytics.EndDate)},
+		{"营业时段", fmt.Sprintf("%02d:00 - %02d:00", analytics.OpenHour, analytics.CloseHour)},
+		{"房间数", analytics.Summary.RoomCount},
+		{"营业小时", analytics.Summary.OpenHours},
+		{"占用小时", analytics.Summary.OccupiedHours},
+		{"利用率(%)", analytics.Summary.Utilization},
+		{"已开始的已支付预订", analytics.PaidBookings},
+		{"爽约预订", analytics.NoShowBookings},
+		{"爽约率(%)", analytics.NoShowRate},
+		{"平均提前预订小时", analytics.AvgLeadHours},
+		{"净收入", analytics.NetRevenue},
+		{"每房间小时收入", analytics.RevPerRoomHour},
+	}
+	if err := writeAnalyticsSheet(f, "Summary", []string{"指标", "数值"}, summaryRows); err != nil {
+		return nil, "", err
+	}
+
+	roomRows := make([][]interface{}, 0, len(analytics.Rooms))
+	for _, item := range analytics.Rooms {
+		roomRows = append(roomRows, utilizationRow(item.RoomName, item))
+	}
+	if err := writeAnalyticsSheet(f, "Rooms", utilizationHeaders, roomRows); err != nil {
+		return nil, "", err
+	}
+
+	typeRows := make([][]interface{}, 0, len(analytics.Types))
+	for _, item := range analytics.Types {
+		typeRows = append(typeRows, utilizationRow(item.RoomType, item))
+	}
+	if err := writeAnalyticsSheet(f, "Types", utilizationHeaders, typeRows); err != nil {
+		return nil, "", err
+	}
+
+	floorRows := make([][]interface{}, 0, len(analytics.Floors))
+	for _, item := range analytics.Floors {
+		floorRows = append(floorRows, utilizationRow(fmt.Sprintf("%d楼", item.Floor), item))
+	}
+	if err := writeAnalyticsSheet(f, "Floors", utilizationHeaders, floorRows); err != nil {
+		return nil, "", err
+	}
+
+	heatmapHeaders := []string{"周几"}
+	for hour := 0; hour < 24; hour++ {
+		heatmapHeaders = append(heatmapHeaders, fmt.Sprintf("%02d", hour))
+	}
+	weekdays := []string{"周日", "周一", "周二", "周三", "周四", "周五", "周六"}
+	heatmapRows := make([][]interface{}, 0, 7)
+	for weekday := 0; weekday < 7; weekday++ {
+		row := []interface{}{weekdays[weekday]}
+		for hour := 0; hour < 24; hour++ {
+			row = append(row, analytics.Heatmap[weekday][hour])
+		}
+		heatmapRows = append(heatmapRows, row)
+	}
+	if err := writeAnalyticsSheet(f, "Heatmap", heatmapHeaders, heatmapRows); err != nil {
+		return nil, "", err
+	}
+
+	// 删除默认的Sheet1
+	if err := f.DeleteSheet("Sheet1"); err != nil {
+		fmt.Printf("删除默认工作表失败: %v\n", err)
+	}
+	f.SetActiveSheet(0)
+
+	buffer, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, "", err
+	}
+
+	filename := fmt.Sprintf("room_analytics_%s_%s.xlsx",
+		compactDate(analytics.StartDate), compactDate(analytics.EndDate))
+	return buffer.Bytes(), filename, nil
+}
+
+// writeAnalyticsSheet 写入一个带表头的工作表并冻结首行
+func writeAnalyticsSheet(f *excelize.File, sheetName string, headers []string, rows [][]interface{}) error {
+	if _, err := f.NewSheet(sheetName); err != nil {
+		return err
+	}
+
+	headerStyle, err := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Size: 11},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"DDDDDD"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, header := range headers {
+		cell, err := excelize.CoordinatesToCellName(i+1, 1)
+		if err != nil {
+			return err
+		}
+		f.SetCellValue(sheetName, cell, header)
+		f.SetCellStyle(sheetName, cell, cell, headerStyle)
+	}
+
+	for r, row := range rows {
+		for c, value := range row {
+			cell, err := excelize.CoordinatesToCellName(c+1, r+2)
+			if err != nil {
+				return err
+			}
+			f.SetCellValue(sheetName, cell, value)
+		}
+	}
+
+	lastCol, err := excelize.ColumnNumberToName(len(headers))
+	if err != nil {
+		return err
+	}
+	if err := f.SetColWidth(sheetName, "A", lastCol, 14); err != nil {
+		return err
+	}
+
+	if err := f.SetPanes(sheetName, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		fmt.Printf("设置冻结行失败: %v\n", err)
+	}
+	return nil
+}
+
+// compactDate 2006-01-02 转为 20060102
+func compactDate(date string) string {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return date
+	}
+	return t.Format("20060102")
+}
@@ -0,0 +1,38 @@
+package app_service
+
+import (
+	"testing"
+	"time"
+
+	"nasa-go-admin/model/app_model"
+)
+
+func TestIsNoShow(t *testing.T) {
+	start := time.Date(2025, 3, 1, 14, 0, 0, 0, time.Local)
+	verifiedAt := start.Add(5 * time.Minute)
+
+	// 调度器到点自动开始：状态为使用中，使用记录没有入住经办人
+	autoActivated := analyticsBooking{ID: 1, StartTime: start, Status: app_model.BookingStatusInUse}
+	autoUsage := app_model.RoomUsageLog{BookingID: 1, CheckInAt: start}
+
+	tests := []struct {
+		name    string
+		booking analyticsBooking
+		usage   app_model.RoomUsageLog
+		want    bool
+	}{
+		{"自动开始且无人入住算爽约", autoActivated, autoUsage, true},
+		{"自动开始后自动完成算爽约", analyticsBooking{ID: 1, StartTime: start, Status: app_model.BookingStatusCompleted}, autoUsage, true},
+		{"已支付未开始使用且没有使用记录算爽约", analyticsBooking{ID: 2, StartTime: start, Status: app_model.BookingStatusPaid}, app_model.RoomUsageLog{}, true},
+		{"前台办理入住不算爽约", autoActivated, app_model.RoomUsageLog{BookingID: 1, CheckInAt: start, CheckInBy: 7}, false},
+		{"核验入场凭证不算爽约", analyticsBooking{ID: 1, StartTime: start, Status: app_model.BookingStatusInUse, VerifiedAt: &verifiedAt}, autoUsage, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isNoShow(tt.booking, tt.usage); got != tt.want {
+				t.Errorf("isNoShow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
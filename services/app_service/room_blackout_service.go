@@ -0,0 +1,597 @@
+package app_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"nasa-go-admin/db"
+	"nasa-go-admin/inout"
+	"nasa-go-admin/model/app_model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 受影响预订的处理方式
+const (
+	BlackoutActionRebook = "rebook" // 改订同类房间
+	BlackoutActionRefund = "refund" // 取消并全额退款
+)
+
+// 受影响预订的处理结果
+const (
+	BlackoutResultRebooked = "rebooked"
+	BlackoutResultRefunded = "refunded"
+	BlackoutResultFailed   = "failed"
+)
+
+// RoomBlackoutService 房间维护/停业时段服务
+// 生效中的时段阻止新建预订、续时和候补保留；与已有预订重叠时由管理员批量改订同类房间或全额退款
+type RoomBlackoutService struct {
+	logService *BookingLogService
+	notifier   *BookingNotificationService
+	ctx        context.Context
+}
+
+// NewRoomBlackoutService 创建房间维护/停业时段服务
+func NewRoomBlackoutService() *RoomBlackoutService {
+	return &RoomBlackoutService{
+		logService: &BookingLogService{},
+		notifier:   NewBookingNotificationService(),
+	}
+}
+
+// WithContext 返回绑定请求上下文的服务，管理端传入 gin.Context 后按租户隔离数据
+func (rbs *RoomBlackoutService) WithContext(ctx context.Context) *RoomBlackoutService {
+	return &RoomBlackoutService{
+		logService: rbs.logService,
+		notifier:   rbs.notifier,
+		ctx:        ctx,
+	}
+}
+
+// dao 获取数据库连接，带上下文时由 GORM 租户插件追加 tenants_id 条件
+func (rbs *RoomBlackoutService) dao() *gorm.DB {
+	if rbs.ctx != nil {
+		return db.Dao.WithContext(rbs.ctx)
+	}
+	return db.Dao
+}
+
+// ========== 时段冲突检查 ==========
+
+// roomBlackouts 与 [startTime, endTime) 重叠、作用于房间的生效中维护/停业时段（含同商家的全场停业）
+func roomBlackouts(room *app_model.Room, startTime, endTime time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Model(&app_model.RoomBlackout{}).
+			Where("status = ? AND start_time < ? AND end_time > ?", app_model.BlackoutStatusActive, endTime, startTime).
+			Where("(room_id = ? OR (room_id = 0 AND tenants_id = ?))", room.ID, room.TenantsId)
+	}
+}
+
+// findRoomBlackout 查询与时段重叠的最早的维护/停业时段，没有时返回 nil
+func findRoomBlackout(tx *gorm.DB, room *app_model.Room, startTime, endTime time.Time) (*app_model.RoomBlackout, error) {
+	var blackout app_model.RoomBlackout
+	if err := tx.Scopes(roomBlackouts(room, startTime, endTime)).
+		Order("start_time ASC").First(&blackout).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询房间维护时段失败: %v", err)
+	}
+	return &blackout, nil
+}
+
+// roomBlackoutError 时段与维护/停业时段重叠
+type roomBlackoutError struct {
+	blackout *app_model.RoomBlackout
+}
+
+func (e *roomBlackoutError) Error() string {
+	return blackoutMessage(e.blackout)
+}
+
+// blackoutReasonText 维护/停业原因文本，如"房间维护（空调维修）"
+func blackoutReasonText(b *app_model.RoomBlackout) string {
+	scope := "房间"
+	if b.RoomID == 0 {
+		scope = "场地"
+	}
+	return fmt.Sprintf("%s%s（%s）", scope, b.GetTypeText(), b.Reason)
+}
+
+// blackoutMessage 预订时段不可用的提示
+func blackoutMessage(b *app_model.RoomBlackout) string {
+	return fmt.Sprintf("%s至%s%s，暂不可预订",
+		b.StartTime.Format("01-02 15:04"), b.EndTime.Format("01-02 15:04"), blackoutReasonText(b))
+}
+
+// slotConflictReason 时段已被预订或处于维护/停业时返回原因，其他错误返回空字符串
+func slotConflictReason(err error) string {
+	var blackoutErr *roomBlackoutError
+	switch {
+	case err == errBookingOverlap:
+		return "该时间段房间已被预订"
+	case errors.As(err, &blackoutErr):
+		return blackoutErr.Error()
+	}
+	return ""
+}
+
+// ========== 时段管理 ==========
+
+// CreateBlackout 创建维护/停业时段，返回与时段重叠的有效预订供批量处理
+func (rbs *RoomBlackoutService) CreateBlackout(req *inout.CreateRoomBlackoutReq, operatorID int) (*inout.RoomBlackoutResp, error) {
+	startTime, endTime, err := parseBlackoutWindow(req.StartTime, req.EndTime)
+	if err != nil {
+		return nil, err
+	}
+
+	blackout := &app_model.RoomBlackout{
+		TenantsId:  req.TenantsId,
+		RoomID:     req.RoomID,
+		Type:       req.Type,
+		Reason:     req.Reason,
+		StartTime:  startTime,
+		EndTime:    endTime,
+		Status:     app_model.BlackoutStatusActive,
+		OperatorID: operatorID,
+	}
+
+	if req.RoomID > 0 {
+		var room app_model.Room
+		if err := rbs.dao().Select("id, tenants_id").First(&room, req.RoomID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, fmt.Errorf("房间不存在")
+			}
+			return nil, fmt.Errorf("查询房间失败: %v", err)
+		}
+		blackout.TenantsId = room.TenantsId
+	}
+
+	// 全场停业未指定商家时由租户插件填充当前商家
+	if err := rbs.dao().Create(blackout).Error; err != nil {
+		return nil, fmt.Errorf("创建维护时段失败: %v", err)
+	}
+
+	log.Printf("维护/停业时段已创建: ID %d (房间ID: %d, %s ~ %s, 原因: %s)", blackout.ID, blackout.RoomID,
+		startTime.Format("2006-01-02 15:04"), endTime.Format("2006-01-02 15:04"), blackout.Reason)
+	return rbs.buildBlackoutResp(blackout)
+}
+
+// UpdateBlackout 更新维护/停业时段的类型、原因和时间
+func (rbs *RoomBlackoutService) UpdateBlackout(req *inout.UpdateRoomBlackoutReq) (*inout.RoomBlackoutResp, error) {
+	blackout, err := rbs.loadBlackout(req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if blackout.Status != app_model.BlackoutStatusActive {
+		return nil, fmt.Errorf("维护时段已取消，无法修改")
+	}
+
+	startTime, endTime, err := parseBlackoutWindow(req.StartTime, req.EndTime)
+	if err != nil {
+		return nil, err
+	}
+
+	blackout.Type = req.Type
+	blackout.Reason = req.Reason
+	blackout.StartTime = startTime
+	blackout.EndTime = endTime
+	if err := rbs.dao().Model(&app_model.RoomBlackout{}).Where("id = ?", blackout.ID).Updates(map[string]interface{}{
+		"type":       blackout.Type,
+		"reason":     blackout.Reason,
+		"start_time": blackout.StartTime,
+		"end_time":   blackout.EndTime,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("更新维护时段失败: %v", err)
+	}
+
+	log.Printf("维护/停业时段已更新: ID %d (%s ~ %s)", blackout.ID,
+		startTime.Format("2006-01-02 15:04"), endTime.Format("2006-01-02 15:04"))
+	return rbs.buildBlackoutResp(blackout)
+}
+
+// CancelBlackout 取消维护/停业时段，时段恢复可预订
+func (rbs *RoomBlackoutService) CancelBlackout(id int) error {
+	blackout, err := rbs.loadBlackout(id)
+	if err != nil {
+		return err
+	}
+	if blackout.Status != app_model.BlackoutStatusActive {
+		return fmt.Errorf("维护时段已取消")
+	}
+
+	if err := rbs.dao().Model(&app_model.RoomBlackout{}).Where("id = ?", blackout.ID).
+		Update("status", app_model.BlackoutStatusCancelled).Error; err != nil {
+		return fmt.Errorf("取消维护时段失败: %v", err)
+	}
+
+	log.Printf("维护/停业时段已取消: ID %d", blackout.ID)
+	return nil
+}
+
+// GetBlackoutList 获取维护/停业时段列表
+func (rbs *RoomBlackoutService) GetBlackoutList(req *inout.RoomBlackoutListReq) (*inout.RoomBlackoutListResp, error) {
+	query := rbs.dao().Model(&app_model.RoomBlackout{}).Preload("Room")
+	if req.RoomID != nil {
+		query = query.Where("room_id = ?", *req.RoomID)
+	}
+	if req.Type != "" {
+		query = query.Where("type = ?", req.Type)
+	}
+	if req.Status > 0 {
+		query = query.Where("status = ?", req.Status)
+	}
+	if req.StartDate != "" {
+		query = query.Where("end_time > ?", req.StartDate)
+	}
+	if req.EndDate != "" {
+		query = query.Where("start_time <= ?", req.EndDate+" 23:59:59")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("查询维护时段总数失败: %v", err)
+	}
+
+	var blackouts []app_model.RoomBlackout
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Offset(offset).Limit(req.PageSize).Order("start_time DESC, id DESC").Find(&blackouts).Error; err != nil {
+		return nil, fmt.Errorf("查询维护时段列表失败: %v", err)
+	}
+
+	list := make([]*inout.RoomBlackoutItem, 0, len(blackouts))
+	for i := range blackouts {
+		item := rbs.convertToItem(&blackouts[i])
+		if blackouts[i].Status == app_model.BlackoutStatusActive {
+			affected, err := rbs.affectedBookings(&blackouts[i])
+			if err != nil {
+				return nil, err
+			}
+			item.AffectedCount = len(affected)
+		}
+		list = append(list, item)
+	}
+
+	return &inout.RoomBlackoutListResp{
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		List:     list,
+	}, nil
+}
+
+// GetAffectedBookings 查询与维护/停业时段重叠的有效预订及可改订的同类房间
+func (rbs *RoomBlackoutService) GetAffectedBookings(id int) (*inout.RoomBlackoutResp, error) {
+	blackout, err := rbs.loadBlackout(id)
+	if err != nil {
+		return nil, err
+	}
+	return rbs.buildBlackoutResp(blackout)
+}
+
+// ========== 受影响预订处理 ==========
+
+// ResolveAffectedBookings 批量处理受影响的预订：改订到同类房间（时间和价格不变）或取消并全额退款，处理后通知用户
+// 单个预订处理失败不影响其余预订
+func (rbs *RoomBlackoutService) ResolveAffectedBookings(req *inout.ResolveBlackoutReq, operatorID *int) (*inout.ResolveBlackoutResp, error) {
+	blackout, err := rbs.loadBlackout(req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if blackout.Status != app_model.BlackoutStatusActive {
+		return nil, fmt.Errorf("维护时段已取消")
+	}
+
+	var targetRoom *app_model.Room
+	if req.Action == BlackoutActionRebook && req.TargetRoomID > 0 {
+		targetRoom = &app_model.Room{}
+		if err := rbs.dao().First(targetRoom, req.TargetRoomID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, fmt.Errorf("目标房间不存在")
+			}
+			return nil, fmt.Errorf("查询房间失败: %v", err)
+		}
+	}
+
+	affected, err := rbs.affectedBookings(blackout)
+	if err != nil {
+		return nil, err
+	}
+	selected := affected
+	if len(req.BookingIDs) > 0 {
+		byID := make(map[int]*app_model.RoomBooking, len(affected))
+		for i := range affected {
+			byID[affected[i].ID] = &affected[i]
+		}
+		selected = make([]app_model.RoomBooking, 0, len(req.BookingIDs))
+		for _, id := range req.BookingIDs {
+			if b, ok := byID[id]; ok {
+				selected = append(selected, *b)
+			}
+		}
+		if len(selected) == 0 {
+			return nil, fmt.Errorf("所选预订不受该维护时段影响")
+		}
+	}
+
+	resp := &inout.ResolveBlackoutResp{Results: make([]*inout.BlackoutResolveResult, 0, len(selected))}
+	for i := range selected {
+		booking := &selected[i]
+		result := &inout.BlackoutResolveResult{BookingID: booking.ID, BookingNo: booking.BookingNo}
+		resp.Results = append(resp.Results, result)
+
+		if booking.Status == app_model.BookingStatusInUse {
+			result.Result = BlackoutResultFailed
+			result.Message = "预订使用中，请到店处理"
+			resp.Failed++
+			continue
+		}
+
+		if req.Action == BlackoutActionRebook {
+			room, err := rbs.rebookBooking(blackout, booking, targetRoom, operatorID)
+			if err == nil {
+				result.Result = BlackoutResultRebooked
+				result.RoomID = room.ID
+				result.RoomName = room.RoomName
+				resp.Rebooked++
+				continue
+			}
+			if !req.FallbackRefund {
+				result.Result = BlackoutResultFailed
+				result.Message = err.Error()
+				resp.Failed++
+				continue
+			}
+			result.Message = err.Error() + "，已改为退款"
+		}
+
+		quote, err := NewBookingRefundService().WithContext(rbs.ctx).CancelBookingFullRefund(&inout.CancelBookingReq{
+			ID:     booking.ID,
+			Reason: blackoutReasonText(blackout),
+		}, operatorID)
+		if err != nil {
+			result.Result = BlackoutResultFailed
+			result.Message = err.Error()
+			resp.Failed++
+			continue
+		}
+		result.Result = BlackoutResultRefunded
+		if quote != nil {
+			result.RefundAmount = quote.RefundAmount
+			resp.RefundAmount += quote.RefundAmount
+		}
+		resp.Refunded++
+		go rbs.notifier.NotifyBookingVenueCancelled(booking.ID, blackoutReasonText(blackout))
+	}
+	resp.RefundAmount = math.Round(resp.RefundAmount*100) / 100
+
+	log.Printf("维护/停业受影响预订已处理: 时段ID %d (改订: %d, 退款: %d, 失败: %d)",
+		blackout.ID, resp.Rebooked, resp.Refunded, resp.Failed)
+	return resp, nil
+}
+
+// rebookBooking 将预订改订到同类房间，时间和价格不变；target 为空时自动选择可用的同类房间
+func (rbs *RoomBlackoutService) rebookBooking(blackout *app_model.RoomBlackout, booking *app_model.RoomBooking, target *app_model.Room, operatorID *int) (*app_model.Room, error) {
+	fromRoom := booking.Room
+	if fromRoom == nil {
+		return nil, fmt.Errorf("原房间不存在")
+	}
+
+	if target == nil {
+		var err error
+		target, err = rbs.findEquivalentRoom(booking, fromRoom)
+		if err != nil {
+			return nil, err
+		}
+		if target == nil {
+			return nil, fmt.Errorf("没有可改订的同类房间")
+		}
+	} else if target.ID == fromRoom.ID || target.TenantsId != fromRoom.TenantsId {
+		return nil, fmt.Errorf("目标房间不可用于改订")
+	}
+
+	roomLock, err := lockRoomForBooking(target.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer roomLock.Release()
+
+	var toRoom app_model.Room
+	err = rbs.dao().Transaction(func(tx *gorm.DB) error {
+		var locked app_model.RoomBooking
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, booking.ID).Error; err != nil {
+			return fmt.Errorf("查询预订失败: %v", err)
+		}
+		if locked.RoomID != fromRoom.ID ||
+			(locked.Status != app_model.BookingStatusPending && locked.Status != app_model.BookingStatusPaid) {
+			return fmt.Errorf("预订状态已变更，请刷新后重试")
+		}
+
+		if err := lockRoomAndCheckOverlap(tx, &toRoom, target.ID, locked.StartTime, locked.EndTime, locked.ID); err != nil {
+			if reason := slotConflictReason(err); reason != "" {
+				return fmt.Errorf("%s%s", toRoom.RoomName, reason)
+			}
+			return err
+		}
+		if toRoom.Status != app_model.RoomStatusAvailable {
+			return fmt.Errorf("%s当前不可用", toRoom.RoomName)
+		}
+
+		return tx.Model(&app_model.RoomBooking{}).Where("id = ?", locked.ID).Updates(map[string]interface{}{
+			"room_id": toRoom.ID,
+			"remarks": locked.Remarks + fmt.Sprintf("\n因%s由%s改订至%s", blackoutReasonText(blackout), fromRoom.RoomName, toRoom.RoomName),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	booking.RoomID = toRoom.ID
+	log.Printf("预订已改订: %s (%s -> %s)", booking.BookingNo, fromRoom.RoomName, toRoom.RoomName)
+	rbs.logService.LogBookingRebook(booking, fromRoom, &toRoom, blackout, operatorID)
+	go rbs.notifier.NotifyBookingRebooked(booking.ID, fromRoom.RoomName, blackoutReasonText(blackout))
+	return &toRoom, nil
+}
+
+// findEquivalentRoom 查找预订时段内可用的同类房间：同商家、同房型、容纳人数不低于原房间，优先小时价接近的房间
+func (rbs *RoomBlackoutService) findEquivalentRoom(booking *app_model.RoomBooking, fromRoom *app_model.Room) (*app_model.Room, error) {
+	var rooms []app_model.Room
+	if err := rbs.dao().Where("id != ? AND tenants_id = ? AND room_type = ? AND capacity >= ? AND status = ?",
+		fromRoom.ID, fromRoom.TenantsId, fromRoom.RoomType, fromRoom.Capacity, app_model.RoomStatusAvailable).
+		Order(gorm.Expr("ABS(hourly_rate - ?) ASC, id ASC", fromRoom.HourlyRate)).
+		Find(&rooms).Error; err != nil {
+		return nil, fmt.Errorf("查询同类房间失败: %v", err)
+	}
+
+	rs := &RoomService{ctx: rbs.ctx}
+	for i := range rooms {
+		available, err := rs.CheckRoomAvailability(rooms[i].ID, booking.StartTime, booking.EndTime)
+		if err != nil {
+			return nil, err
+		}
+		if available {
+			return &rooms[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// affectedBookings 查询与维护/停业时段重叠的有效预订（含使用中的预订）
+func (rbs *RoomBlackoutService) affectedBookings(blackout *app_model.RoomBlackout) ([]app_model.RoomBooking, error) {
+	query := rbs.dao().Preload("Room").Scopes(blockingBookings(time.Now())).
+		Where("start_time < ? AND end_time > ?", blackout.EndTime, blackout.StartTime)
+	if blackout.RoomID > 0 {
+		query = query.Where("room_id = ?", blackout.RoomID)
+	} else {
+		query = query.Where("tenants_id = ?", blackout.TenantsId)
+	}
+
+	var bookings []app_model.RoomBooking
+	if err := query.Order("start_time ASC, id ASC").Find(&bookings).Error; err != nil {
+		return nil, fmt.Errorf("查询受影响的预订失败: %v", err)
+	}
+	return bookings, nil
+}
+
+// buildBlackoutResp 组装维护时段及受影响预订，逐个给出可改订的同类房间
+func (rbs *RoomBlackoutService) buildBlackoutResp(blackout *app_model.RoomBlackout) (*inout.RoomBlackoutResp, error) {
+	if blackout.RoomID > 0 && blackout.Room == nil {
+		var room app_model.Room
+		if err := rbs.dao().Select("id, room_name").First(&room, blackout.RoomID).Error; err == nil {
+			blackout.Room = &room
+		}
+	}
+	resp := &inout.RoomBlackoutResp{
+		Blackout: rbs.convertToItem(blackout),
+		Affected: make([]*inout.BlackoutAffectedBooking, 0),
+	}
+	if blackout.Status != app_model.BlackoutStatusActive {
+		return resp, nil
+	}
+
+	bookings, err := rbs.affectedBookings(blackout)
+	if err != nil {
+		return nil, err
+	}
+	for i := range bookings {
+		b := &bookings[i]
+		item := &inout.BlackoutAffectedBooking{
+			BookingID:    b.ID,
+			BookingNo:    b.BookingNo,
+			UserID:       b.UserID,
+			ContactName:  b.ContactName,
+			ContactPhone: b.ContactPhone,
+			RoomID:       b.RoomID,
+			StartTime:    b.StartTime,
+			EndTime:      b.EndTime,
+			Status:       b.Status,
+			StatusText:   b.GetBookingStatusText(),
+			PaidAmount:   b.PaidAmount,
+			CanResolve:   b.Status != app_model.BookingStatusInUse,
+		}
+		if b.Room != nil {
+			item.RoomName = b.Room.RoomName
+		}
+
+		if !item.CanResolve {
+			item.Note = "预订使用中，请到店处理"
+		} else if b.Room != nil {
+			candidate, err := rbs.findEquivalentRoom(b, b.Room)
+			if err != nil {
+				return nil, err
+			}
+			if candidate != nil {
+				item.CandidateRoomID = candidate.ID
+				item.CandidateRoomName = candidate.RoomName
+			} else {
+				item.Note = "没有可改订的同类房间，可全额退款"
+			}
+		}
+		resp.Affected = append(resp.Affected, item)
+	}
+	resp.Blackout.AffectedCount = len(resp.Affected)
+	return resp, nil
+}
+
+// loadBlackout 加载维护/停业时段
+func (rbs *RoomBlackoutService) loadBlackout(id int) (*app_model.RoomBlackout, error) {
+	var blackout app_model.RoomBlackout
+	if err := rbs.dao().Preload("Room").First(&blackout, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("维护时段不存在")
+		}
+		return nil, fmt.Errorf("查询维护时段失败: %v", err)
+	}
+	return &blackout, nil
+}
+
+// convertToItem 转换为维护时段信息
+func (rbs *RoomBlackoutService) convertToItem(b *app_model.RoomBlackout) *inout.RoomBlackoutItem {
+	item := &inout.RoomBlackoutItem{
+		ID:         b.ID,
+		TenantsId:  b.TenantsId,
+		RoomID:     b.RoomID,
+		RoomName:   "全场",
+		Type:       b.Type,
+		TypeText:   b.GetTypeText(),
+		Reason:     b.Reason,
+		StartTime:  b.StartTime,
+		EndTime:    b.EndTime,
+		Status:     b.Status,
+		StatusText: b.GetStatusText(),
+		OperatorID: b.OperatorID,
+		CreateTime: b.CreateTime,
+	}
+	if b.RoomID > 0 {
+		item.RoomName = ""
+		if b.Room != nil {
+			item.RoomName = b.Room.RoomName
+		}
+	}
+	return item
+}
+
+// parseBlackoutWindow 按本地时区解析并校验维护时段，与预订时段口径一致
+func parseBlackoutWindow(start, end string) (time.Time, time.Time, error) {
+	startTime, err := parseBookingTime(start)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("开始时间格式错误: %v", err)
+	}
+	endTime, err := parseBookingTime(end)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("结束时间格式错误: %v", err)
+	}
+	if !endTime.After(startTime) {
+		return time.Time{}, time.Time{}, fmt.Errorf("结束时间必须晚于开始时间")
+	}
+	if !endTime.After(time.Now()) {
+		return time.Time{}, time.Time{}, fmt.Errorf("结束时间已过")
+	}
+	return startTime, endTime, nil
+}
@@ -0,0 +1,26 @@
+package app_service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBlackoutWindowUsesLocalTime(t *testing.T) {
+	year := time.Now().Year() + 1
+	start, end, err := parseBlackoutWindow(
+		time.Date(year, 3, 1, 14, 0, 0, 0, time.Local).Format(bookingTimeLayout),
+		time.Date(year, 3, 1, 16, 0, 0, 0, time.Local).Format(bookingTimeLayout))
+	if err != nil {
+		t.Fatalf("parseBlackoutWindow() error = %v", err)
+	}
+	if start.Location() != time.Local || start.Hour() != 14 || end.Hour() != 16 {
+		t.Errorf("parseBlackoutWindow() = (%v, %v), want 14:00-16:00 local", start, end)
+	}
+
+	if _, _, err := parseBlackoutWindow("2020-01-01 10:00:00", "2020-01-01 12:00:00"); err == nil {
+		t.Error("已过期的维护时段应返回错误")
+	}
+	if _, _, err := parseBlackoutWindow("2099-01-01 12:00:00", "2099-01-01 10:00:00"); err == nil {
+		t.Error("结束时间早于开始时间应返回错误")
+	}
+}
@@ -0,0 +1,430 @@
+package app_service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"nasa-go-admin/inout"
+	"nasa-go-admin/model/app_model"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// calendarMaxDays 日历单次最多查询天数
+	calendarMaxDays = 31
+	// calendarMaxRooms 按房型查询时最多返回的房间数
+	calendarMaxRooms = 20
+	// calendarDefaultSlotMinutes 默认可选开始时间间隔
+	calendarDefaultSlotMinutes = 60
+)
+
+// calendarBlockingStatuses 占用房间的预订状态
+var calendarBlockingStatuses = []int{app_model.BookingStatusPaid, app_model.BookingStatusInUse}
+
+// blockingBookings 占用时段的预订：已支付、使用中，以及仍在支付时限内的待支付预订
+func blockingBookings(now time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(status IN (?) OR (status = ? AND create_time > ?))",
+			calendarBlockingStatuses, app_model.BookingStatusPending, now.Add(-BookingPaymentWindow()))
+	}
+}
+
+// 日历占用原因
+const (
+	CalendarReasonBooked      = "booked"
+	CalendarReasonCleaning    = "cleaning"
+	CalendarReasonMaintenance = "maintenance"
+	CalendarReasonClosed      = "closed"
+	CalendarReasonPast        = "past"
+)
+
+// timeRange 内部使用的时间区间 [start, end)
+type timeRange struct {
+	start  time.Time
+	end    time.Time
+	reason string
+}
+
+// GetRoomCalendar 查询房间在日期范围内的占用、空闲区间和可预订时段报价
+func (rs *RoomService) GetRoomCalendar(req *inout.RoomCalendarReq) (*inout.RoomCalendarResp, error) {
+	startDate, err := parseBookingDate(req.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("开始日期格式错误: %v", err)
+	}
+	endDate, err := parseBookingDate(req.EndDate)
+	if err != nil {
+		return nil, fmt.Errorf("结束日期格式错误: %v", err)
+	}
+	if endDate.Before(startDate) {
+		return nil, fmt.Errorf("结束日期不能早于开始日期")
+	}
+	rangeEnd := endDate.AddDate(0, 0, 1)
+	if rangeEnd.Sub(startDate) > calendarMaxDays*24*time.Hour {
+		return nil, fmt.Errorf("查询范围不能超过 %d 天", calendarMaxDays)
+	}
+
+	slotMinutes := req.SlotMinutes
+	if slotMinutes <= 0 {
+		slotMinutes = calendarDefaultSlotMinutes
+	}
+	if slotMinutes < 15 || slotMinutes > 240 {
+		return nil, fmt.Errorf("时段间隔必须在 15-240 分钟之间")
+	}
+
+	rooms, err := rs.findCalendarRooms(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &inout.RoomCalendarResp{
+		StartDate:   req.StartDate,
+		EndDate:     req.EndDate,
+		Hours:       req.Hours,
+		SlotMinutes: slotMinutes,
+		Rooms:       make([]*inout.RoomCalendar, 0, len(rooms)),
+	}
+
+	dayTypes := make(map[string]string)
+	for i := range rooms {
+		calendar, err := rs.buildRoomCalendar(&rooms[i], req, startDate, rangeEnd, slotMinutes, dayTypes)
+		if err != nil {
+			return nil, err
+		}
+		resp.Rooms = append(resp.Rooms, calendar)
+	}
+
+	return resp, nil
+}
+
+// findCalendarRooms 查询需要展示日历的房间
+func (rs *RoomService) findCalendarRooms(req *inout.RoomCalendarReq) ([]app_model.Room, error) {
+	var rooms []app_model.Room
+
+	if req.RoomID > 0 {
+		var room app_model.Room
+		if err := rs.dao().First(&room, req.RoomID).Error; err != nil {
+			return nil, fmt.Errorf("房间不存在")
+		}
+		return append(rooms, room), nil
+	}
+
+	if req.RoomType == "" && req.MinCapacity <= 0 {
+		return nil, fmt.Errorf("请指定房间或房间类型/容纳人数")
+	}
+	if req.PackageID != nil {
+		return nil, fmt.Errorf("指定套餐时必须指定房间")
+	}
+
+	query := rs.dao().Model(&app_model.Room{}).Where("status != ?", app_model.RoomStatusDisabled)
+	if req.RoomType != "" {
+		query = query.Where("room_type = ?", req.RoomType)
+	}
+	if req.MinCapacity > 0 {
+		query = query.Where("capacity >= ?", req.MinCapacity)
+	}
+	if err := query.Order("hourly_rate ASC, id ASC").Limit(calendarMaxRooms).Find(&rooms).Error; err != nil {
+		return nil, fmt.Errorf("查询房间失败: %v", err)
+	}
+
+	return rooms, nil
+}
+
+// buildRoomCalendar 计算单个房间的日历
+func (rs *RoomService) buildRoomCalendar(room *app_model.Room, req *inout.RoomCalendarReq, rangeStart, rangeEnd time.Time, slotMinutes int, dayTypes map[string]string) (*inout.RoomCalendar, error) {
+	calendar := &inout.RoomCalendar{
+		RoomID:        room.ID,
+		RoomNumber:    room.RoomNumber,
+		RoomName:      room.RoomName,
+		RoomType:      room.RoomType,
+		Capacity:      room.Capacity,
+		HourlyRate:    room.HourlyRate,
+		CleaningMin:   room.CleaningMin,
+		Status:        room.Status,
+		StatusText:    room.GetStatusText(),
+		Bookable:      true,
+		BusyIntervals: make([]*inout.CalendarInterval, 0),
+		FreeIntervals: make([]*inout.CalendarInterval, 0),
+		Slots:         make([]*inout.CalendarSlot, 0),
+	}
+
+	// 维护中或停用的房间整段不可预订
+	if room.Status == app_model.RoomStatusMaintenance || room.Status == app_model.RoomStatusDisabled {
+		calendar.Bookable = false
+		calendar.UnavailableMsg = "房间" + room.GetStatusText()
+		calendar.BusyIntervals = append(calendar.BusyIntervals, toCalendarInterval(timeRange{
+			start: rangeStart, end: rangeEnd, reason: CalendarReasonMaintenance,
+		}))
+		return calendar, nil
+	}
+
+	packages, err := rs.loadCalendarPackages(room.ID, req)
+	if err != nil {
+		return nil, err
+	}
+	if req.PackageID != nil && len(packages) == 0 {
+		calendar.Bookable = false
+		calendar.UnavailableMsg = "套餐不存在或未启用"
+		return calendar, nil
+	}
+
+	busy, err := rs.loadBusyRanges(room, rangeStart, rangeEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	// 已过去的时间不可预订
+	now := time.Now()
+	if now.After(rangeStart) {
+		pastEnd := now
+		if pastEnd.After(rangeEnd) {
+			pastEnd = rangeEnd
+		}
+		busy = append(busy, timeRange{start: rangeStart, end: pastEnd, reason: CalendarReasonPast})
+	}
+
+	busy = mergeTimeRanges(busy)
+	for _, r := range busy {
+		calendar.BusyIntervals = append(calendar.BusyIntervals, toCalendarInterval(r))
+	}
+
+	free := freeTimeRanges(busy, rangeStart, rangeEnd)
+	for _, r := range free {
+		calendar.FreeIntervals = append(calendar.FreeIntervals, toCalendarInterval(r))
+	}
+
+	// 在空闲区间内按间隔生成可选开始时间，预订需完整落在空闲区间内
+	duration := time.Duration(req.Hours) * time.Hour
+	step := time.Duration(slotMinutes) * time.Minute
+	for _, r := range free {
+		slotStart := alignSlot(r.start, rangeStart, step)
+		for ; !slotStart.Add(duration).After(r.end); slotStart = slotStart.Add(step) {
+			slot := rs.quoteCalendarSlot(room, packages, req.PackageID == nil, slotStart, req.Hours, dayTypes)
+			if slot == nil {
+				continue
+			}
+			calendar.Slots = append(calendar.Slots, slot)
+			if calendar.CheapestSlot == nil || slot.FinalPrice < calendar.CheapestSlot.FinalPrice {
+				calendar.CheapestSlot = slot
+			}
+		}
+	}
+
+	if len(calendar.Slots) == 0 {
+		calendar.Bookable = false
+		calendar.UnavailableMsg = fmt.Sprintf("所选日期内没有连续 %d 小时的可预订时段", req.Hours)
+	}
+
+	return calendar, nil
+}
+
+// loadCalendarPackages 加载房间可用于报价的套餐
+func (rs *RoomService) loadCalendarPackages(roomID int, req *inout.RoomCalendarReq) ([]app_model.RoomPackage, error) {
+	query := rs.dao().Where("room_id = ? AND is_active = 1", roomID).
+		Preload("Rules", "is_active = 1").
+		Order("priority DESC, create_time DESC")
+	if req.PackageID != nil {
+		query = query.Where("id = ?", *req.PackageID)
+	}
+
+	var packages []app_model.RoomPackage
+	if err := query.Find(&packages).Error; err != nil {
+		return nil, fmt.Errorf("查询套餐失败: %v", err)
+	}
+	return packages, nil
+}
+
+// loadBusyRanges 查询房间在范围内的占用区间（含前后清洁缓冲和维护/停业时段）
+func (rs *RoomService) loadBusyRanges(room *app_model.Room, rangeStart, rangeEnd time.Time) ([]timeRange, error) {
+	buffer := time.Duration(room.CleaningMin) * time.Minute
+
+	var bookings []app_model.RoomBooking
+	if err := rs.dao().Select("id, start_time, end_time").Scopes(blockingBookings(time.Now())).
+		Where("room_id = ? AND start_time < ? AND end_time > ?",
+			room.ID, rangeEnd.Add(buffer), rangeStart.Add(-buffer)).
+		Order("start_time ASC").
+		Find(&bookings).Error; err != nil {
+		return nil, fmt.Errorf("查询房间预订失败: %v", err)
+	}
+
+	busy := make([]timeRange, 0, len(bookings)*3)
+	for _, b := range bookings {
+		if buffer > 0 {
+			busy = append(busy, timeRange{start: b.StartTime.Add(-buffer), end: b.StartTime, reason: CalendarReasonCleaning})
+		}
+		busy = append(busy, timeRange{start: b.StartTime, end: b.EndTime, reason: CalendarReasonBooked})
+		if buffer > 0 {
+			busy = append(busy, timeRange{start: b.EndTime, end: b.EndTime.Add(buffer), reason: CalendarReasonCleaning})
+		}
+	}
+
+	var blackouts []app_model.RoomBlackout
+	if err := rs.dao().Scopes(roomBlackouts(room, rangeStart, rangeEnd)).
+		Order("start_time ASC").Find(&blackouts).Error; err != nil {
+		return nil, fmt.Errorf("查询房间维护时段失败: %v", err)
+	}
+	for _, b := range blackouts {
+		reason := CalendarReasonMaintenance
+		if b.Type == app_model.BlackoutTypeClosed {
+			reason = CalendarReasonClosed
+		}
+		busy = append(busy, timeRange{start: b.StartTime, end: b.EndTime, reason: reason})
+	}
+
+	// 裁剪到查询范围
+	clipped := busy[:0]
+	for _, r := range busy {
+		if r.start.Before(rangeStart) {
+			r.start = rangeStart
+		}
+		if r.end.After(rangeEnd) {
+			r.end = rangeEnd
+		}
+		if r.end.After(r.start) {
+			clipped = append(clipped, r)
+		}
+	}
+	return clipped, nil
+}
+
+// quoteCalendarSlot 计算时段的最低报价，没有可用价格方案时返回nil
+// includeBase 为 true 时按小时计费的基础价格也参与比价
+func (rs *RoomService) quoteCalendarSlot(room *app_model.Room, packages []app_model.RoomPackage, includeBase bool, startTime time.Time, hours int, dayTypes map[string]string) *inout.CalendarSlot {
+	dateKey := startTime.Format("2006-01-02")
+	dayType, ok := dayTypes[dateKey]
+	if !ok {
+		dayType = rs.getDayType(startTime)
+		dayTypes[dateKey] = dayType
+	}
+
+	var best *inout.CalendarSlot
+
+	if includeBase {
+		quote := app_model.NewBasePriceQuote(room.HourlyRate, startTime, hours)
+		best = &inout.CalendarSlot{
+			PackageName:   "基础价格",
+			OriginalPrice: quote.OriginalPrice,
+			FinalPrice:    quote.FinalPrice,
+		}
+	}
+
+	for i := range packages {
+		pkg := &packages[i]
+		if !packageFitsHours(pkg, hours) {
+			continue
+		}
+
+		// 与下单相同的分段计价，套餐未生效或已过期时返回错误
+		quote, err := pkg.Quote(room.HourlyRate, startTime, hours)
+		if err != nil {
+			continue
+		}
+		if best != nil && quote.FinalPrice >= best.FinalPrice {
+			continue
+		}
+
+		packageID := pkg.ID
+		best = &inout.CalendarSlot{
+			PackageID:     &packageID,
+			PackageName:   pkg.PackageName,
+			OriginalPrice: quote.OriginalPrice,
+			FinalPrice:    quote.FinalPrice,
+			RuleName:      quote.RuleName,
+		}
+	}
+
+	if best == nil {
+		return nil
+	}
+
+	best.StartTime = startTime.Format("2006-01-02 15:04:05")
+	best.EndTime = startTime.Add(time.Duration(hours) * time.Hour).Format("2006-01-02 15:04:05")
+	best.DayType = dayType
+	best.DiscountAmount = math.Round((best.OriginalPrice-best.FinalPrice)*100) / 100
+	return best
+}
+
+// packageFitsHours 套餐是否适用于指定时长
+func packageFitsHours(pkg *app_model.RoomPackage, hours int) bool {
+	switch pkg.PackageType {
+	case app_model.PackageTypeFixedHours:
+		if pkg.FixedHours > 0 && pkg.FixedHours != hours {
+			return false
+		}
+	case app_model.PackageTypeDaily:
+		return hours == 24
+	case app_model.PackageTypeWeekly:
+		return hours == 168
+	}
+
+	if pkg.MinHours > 0 && hours < pkg.MinHours {
+		return false
+	}
+	if pkg.MaxHours > 0 && hours > pkg.MaxHours {
+		return false
+	}
+	return true
+}
+
+// alignSlot 返回不早于 t 且与 origin 按 step 对齐的时间
+func alignSlot(t, origin time.Time, step time.Duration) time.Time {
+	offset := t.Sub(origin)
+	if rem := offset % step; rem != 0 {
+		offset += step - rem
+	}
+	return origin.Add(offset)
+}
+
+// mergeTimeRanges 按开始时间排序并合并相同原因的重叠或相邻区间
+func mergeTimeRanges(ranges []timeRange) []timeRange {
+	if len(ranges) == 0 {
+		return ranges
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].start.Before(ranges[j].start)
+	})
+
+	merged := make([]timeRange, 0, len(ranges))
+	last := make(map[string]int) // 原因 -> merged 中最后一个区间下标
+	for _, r := range ranges {
+		if idx, ok := last[r.reason]; ok && !r.start.After(merged[idx].end) {
+			if r.end.After(merged[idx].end) {
+				merged[idx].end = r.end
+			}
+			continue
+		}
+		merged = append(merged, r)
+		last[r.reason] = len(merged) - 1
+	}
+	return merged
+}
+
+// freeTimeRanges 计算范围内不被任何占用区间覆盖的空闲区间（busy 需按开始时间排序）
+func freeTimeRanges(busy []timeRange, rangeStart, rangeEnd time.Time) []timeRange {
+	free := make([]timeRange, 0)
+	cursor := rangeStart
+	for _, r := range busy {
+		if r.start.After(cursor) {
+			free = append(free, timeRange{start: cursor, end: r.start})
+		}
+		if r.end.After(cursor) {
+			cursor = r.end
+		}
+	}
+	if rangeEnd.After(cursor) {
+		free = append(free, timeRange{start: cursor, end: rangeEnd})
+	}
+	return free
+}
+
+// toCalendarInterval 转换为响应区间
+func toCalendarInterval(r timeRange) *inout.CalendarInterval {
+	return &inout.CalendarInterval{
+		StartTime: r.start.Format("2006-01-02 15:04:05"),
+		EndTime:   r.end.Format("2006-01-02 15:04:05"),
+		Reason:    r.reason,
+	}
+}
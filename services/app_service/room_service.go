@@ -1,20 +1,40 @@
 package app_service
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"strconv"
 	"time"
 
 	"nasa-go-admin/db"
 	"nasa-go-admin/inout"
 	"nasa-go-admin/model/app_model"
+	"nasa-go-admin/redis"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-type RoomService struct{}
+type RoomService struct {
+	ctx context.Context
+}
+
+// WithContext 返回绑定请求上下文的房间服务，管理端传入 gin.Context 后按租户隔离数据
+func (rs *RoomService) WithContext(ctx context.Context) *RoomService {
+	return &RoomService{ctx: ctx}
+}
+
+// dao 获取数据库连接，带上下文时由 GORM 租户插件追加 tenants_id 条件
+func (rs *RoomService) dao() *gorm.DB {
+	if rs.ctx != nil {
+		return db.Dao.WithContext(rs.ctx)
+	}
+	return db.Dao
+}
 
 // ========== 房间管理服务 ==========
 
@@ -22,7 +42,7 @@ type RoomService struct{}
 func (rs *RoomService) CreateRoom(req *inout.CreateRoomReq, createdBy int) (*app_model.Room, error) {
 	// 检查房间号是否已存在
 	var existingRoom app_model.Room
-	if err := db.Dao.Where("room_number = ?", req.RoomNumber).First(&existingRoom).Error; err == nil {
+	if err := rs.dao().Where("room_number = ?", req.RoomNumber).First(&existingRoom).Error; err == nil {
 		return nil, fmt.Errorf("房间号 %s 已存在", req.RoomNumber)
 	} else if err != gorm.ErrRecordNotFound {
 		return nil, fmt.Errorf("检查房间号失败: %v", err)
@@ -40,10 +60,20 @@ func (rs *RoomService) CreateRoom(req *inout.CreateRoomReq, createdBy int) (*app
 		Floor:       req.Floor,
 		Area:        req.Area,
 		Description: req.Description,
+		CleaningMin: req.CleaningMin,
 		CreatedBy:   createdBy,
+
+		OvertimeUnitMin:  app_model.DefaultOvertimeUnitMin,
+		OvertimeGraceMin: app_model.DefaultOvertimeGraceMin,
+	}
+	if req.OvertimeUnitMin != nil {
+		room.OvertimeUnitMin = *req.OvertimeUnitMin
+	}
+	if req.OvertimeGraceMin != nil {
+		room.OvertimeGraceMin = *req.OvertimeGraceMin
 	}
 
-	if err := db.Dao.Create(room).Error; err != nil {
+	if err := rs.dao().Create(room).Error; err != nil {
 		return nil, fmt.Errorf("创建房间失败: %v", err)
 	}
 
@@ -54,7 +84,7 @@ func (rs *RoomService) CreateRoom(req *inout.CreateRoomReq, createdBy int) (*app
 // UpdateRoom 更新房间信息
 func (rs *RoomService) UpdateRoom(req *inout.UpdateRoomReq) (*app_model.Room, error) {
 	var room app_model.Room
-	if err := db.Dao.First(&room, req.ID).Error; err != nil {
+	if err := rs.dao().First(&room, req.ID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("房间不存在")
 		}
@@ -63,7 +93,7 @@ func (rs *RoomService) UpdateRoom(req *inout.UpdateRoomReq) (*app_model.Room, er
 
 	// 检查房间号是否被其他房间占用
 	var existingRoom app_model.Room
-	if err := db.Dao.Where("room_number = ? AND id != ?", req.RoomNumber, req.ID).First(&existingRoom).Error; err == nil {
+	if err := rs.dao().Where("room_number = ? AND id != ?", req.RoomNumber, req.ID).First(&existingRoom).Error; err == nil {
 		return nil, fmt.Errorf("房间号 %s 已被其他房间使用", req.RoomNumber)
 	} else if err != gorm.ErrRecordNotFound {
 		return nil, fmt.Errorf("检查房间号失败: %v", err)
@@ -71,20 +101,27 @@ func (rs *RoomService) UpdateRoom(req *inout.UpdateRoomReq) (*app_model.Room, er
 
 	// 更新房间信息
 	updates := map[string]interface{}{
-		"room_number": req.RoomNumber,
-		"room_name":   req.RoomName,
-		"room_type":   req.RoomType,
-		"capacity":    req.Capacity,
-		"hourly_rate": req.HourlyRate,
-		"features":    req.Features,
-		"images":      req.Images,
-		"floor":       req.Floor,
-		"area":        req.Area,
-		"description": req.Description,
-		"status":      req.Status,
-	}
-
-	if err := db.Dao.Model(&room).Updates(updates).Error; err != nil {
+		"room_number":  req.RoomNumber,
+		"room_name":    req.RoomName,
+		"room_type":    req.RoomType,
+		"capacity":     req.Capacity,
+		"hourly_rate":  req.HourlyRate,
+		"features":     req.Features,
+		"images":       req.Images,
+		"floor":        req.Floor,
+		"area":         req.Area,
+		"description":  req.Description,
+		"status":       req.Status,
+		"cleaning_min": req.CleaningMin,
+	}
+	if req.OvertimeUnitMin != nil {
+		updates["overtime_unit_min"] = *req.OvertimeUnitMin
+	}
+	if req.OvertimeGraceMin != nil {
+		updates["overtime_grace_min"] = *req.OvertimeGraceMin
+	}
+
+	if err := rs.dao().Model(&room).Updates(updates).Error; err != nil {
 		return nil, fmt.Errorf("更新房间失败: %v", err)
 	}
 
@@ -98,7 +135,7 @@ func (rs *RoomService) GetRoomList(req *inout.RoomListReq) (*inout.RoomListResp,
 	var total int64
 
 	// 构建查询条件
-	query := db.Dao.Model(&app_model.Room{})
+	query := rs.dao().Model(&app_model.Room{})
 
 	if req.RoomType != "" {
 		query = query.Where("room_type = ?", req.RoomType)
@@ -155,7 +192,7 @@ func (rs *RoomService) GetRoomList(req *inout.RoomListReq) (*inout.RoomListResp,
 // GetRoomDetail 获取房间详情
 func (rs *RoomService) GetRoomDetail(id int) (*inout.RoomDetail, error) {
 	var room app_model.Room
-	if err := db.Dao.First(&room, id).Error; err != nil {
+	if err := rs.dao().First(&room, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("房间不存在")
 		}
@@ -167,7 +204,7 @@ func (rs *RoomService) GetRoomDetail(id int) (*inout.RoomDetail, error) {
 	// 查询当前预订信息
 	var currentBooking app_model.RoomBooking
 	now := time.Now()
-	if err := db.Dao.Where("room_id = ? AND start_time <= ? AND end_time >= ? AND status IN (?)",
+	if err := rs.dao().Where("room_id = ? AND start_time <= ? AND end_time >= ? AND status IN (?)",
 		id, now, now, []int{app_model.BookingStatusPaid, app_model.BookingStatusInUse}).
 		First(&currentBooking).Error; err == nil {
 
@@ -181,14 +218,14 @@ func (rs *RoomService) GetRoomDetail(id int) (*inout.RoomDetail, error) {
 // UpdateRoomStatus 更新房间状态
 func (rs *RoomService) UpdateRoomStatus(req *inout.UpdateRoomStatusReq) error {
 	var room app_model.Room
-	if err := db.Dao.First(&room, req.ID).Error; err != nil {
+	if err := rs.dao().First(&room, req.ID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return fmt.Errorf("房间不存在")
 		}
 		return fmt.Errorf("查询房间失败: %v", err)
 	}
 
-	if err := db.Dao.Model(&room).Update("status", req.Status).Error; err != nil {
+	if err := rs.dao().Model(&room).Update("status", req.Status).Error; err != nil {
 		return fmt.Errorf("更新房间状态失败: %v", err)
 	}
 
@@ -200,7 +237,7 @@ func (rs *RoomService) UpdateRoomStatus(req *inout.UpdateRoomStatusReq) error {
 func (rs *RoomService) DeleteRoom(id int) error {
 	// 检查是否有未完成的预订
 	var count int64
-	if err := db.Dao.Model(&app_model.RoomBooking{}).
+	if err := rs.dao().Model(&app_model.RoomBooking{}).
 		Where("room_id = ? AND status IN (?)", id,
 			[]int{app_model.BookingStatusPending, app_model.BookingStatusPaid, app_model.BookingStatusInUse}).
 		Count(&count).Error; err != nil {
@@ -211,7 +248,7 @@ func (rs *RoomService) DeleteRoom(id int) error {
 		return fmt.Errorf("房间有未完成的预订，无法删除")
 	}
 
-	if err := db.Dao.Delete(&app_model.Room{}, id).Error; err != nil {
+	if err := rs.dao().Delete(&app_model.Room{}, id).Error; err != nil {
 		return fmt.Errorf("删除房间失败: %v", err)
 	}
 
@@ -221,10 +258,26 @@ func (rs *RoomService) DeleteRoom(id int) error {
 
 // ========== 预订管理服务 ==========
 
+// 预订时间格式
+const (
+	bookingTimeLayout = "2006-01-02 15:04:05"
+	bookingDateLayout = "2006-01-02"
+)
+
+// parseBookingTime 按服务器本地时区解析预订时间，与数据库连接 loc=Local 及 time.Now() 保持一致
+func parseBookingTime(value string) (time.Time, error) {
+	return time.ParseInLocation(bookingTimeLayout, value, time.Local)
+}
+
+// parseBookingDate 按服务器本地时区解析日期，返回当天零点
+func parseBookingDate(value string) (time.Time, error) {
+	return time.ParseInLocation(bookingDateLayout, value, time.Local)
+}
+
 // CreateBooking 创建预订
 func (rs *RoomService) CreateBooking(req *inout.CreateBookingReq, userID int) (*app_model.RoomBooking, error) {
 	// 解析开始时间
-	startTime, err := time.Parse("2006-01-02 15:04:05", req.StartTime)
+	startTime, err := parseBookingTime(req.StartTime)
 	if err != nil {
 		return nil, fmt.Errorf("开始时间格式错误: %v", err)
 	}
@@ -234,7 +287,7 @@ func (rs *RoomService) CreateBooking(req *inout.CreateBookingReq, userID int) (*
 
 	// 检查房间是否存在且可用
 	var room app_model.Room
-	if err := db.Dao.First(&room, req.RoomID).Error; err != nil {
+	if err := rs.dao().First(&room, req.RoomID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("房间不存在")
 		}
@@ -245,70 +298,28 @@ func (rs *RoomService) CreateBooking(req *inout.CreateBookingReq, userID int) (*
 		return nil, fmt.Errorf("房间当前不可用")
 	}
 
-	// 检查时间段是否可用
-	if available, err := rs.CheckRoomAvailability(req.RoomID, startTime, endTime); err != nil {
+	roomLock, err := lockRoomForBooking(req.RoomID)
+	if err != nil {
 		return nil, err
-	} else if !available {
-		return nil, fmt.Errorf("该时间段房间已被预订")
 	}
+	defer roomLock.Release()
 
 	// 计算价格
-	var totalAmount, originalPrice, packagePrice, discountAmount float64
-	var packageName, priceBreakdown string
-
-	originalPrice = room.HourlyRate * float64(req.Hours)
-
-	if req.PackageID != nil {
-		// 使用套餐价格
-		var pkg app_model.RoomPackage
-		if err := db.Dao.Preload("Rules").First(&pkg, *req.PackageID).Error; err != nil {
-			return nil, fmt.Errorf("套餐不存在")
-		}
-
-		// 验证套餐是否属于该房间
-		if pkg.RoomID != req.RoomID {
-			return nil, fmt.Errorf("套餐不属于该房间")
-		}
-
-		// 计算套餐价格
-		finalPrice, matchedRule, err := pkg.CalculatePrice(room.HourlyRate, startTime, req.Hours)
-		if err != nil {
-			return nil, fmt.Errorf("套餐价格计算失败: %v", err)
-		}
-
-		packagePrice = finalPrice
-		totalAmount = finalPrice
-		packageName = pkg.PackageName
-		discountAmount = originalPrice - finalPrice
-
-		// 构建价格明细
-		breakdown := map[string]interface{}{
-			"base_price":      room.HourlyRate,
-			"hours":           req.Hours,
-			"original_price":  originalPrice,
-			"package_name":    pkg.PackageName,
-			"package_type":    pkg.PackageType,
-			"final_price":     finalPrice,
-			"discount_amount": discountAmount,
-		}
-		if matchedRule != nil {
-			breakdown["rule_name"] = matchedRule.RuleName
-			breakdown["rule_type"] = matchedRule.PriceType
-			breakdown["rule_value"] = matchedRule.PriceValue
-		}
-
-		breakdownBytes, _ := json.Marshal(breakdown)
-		priceBreakdown = string(breakdownBytes)
-	} else {
-		// 使用基础价格
-		totalAmount = originalPrice
-		packagePrice = 0
+	pkg, err := rs.resolveBookingPackage(req.RoomID, req.PackageID)
+	if err != nil {
+		return nil, err
+	}
+	price, err := priceBooking(&room, pkg, startTime, req.Hours)
+	if err != nil {
+		return nil, err
 	}
+	totalAmount, discountAmount, quote := price.TotalAmount, price.DiscountAmount, price.Quote
 
 	// 生成预订号
 	bookingNo := rs.generateBookingNo()
 
 	booking := &app_model.RoomBooking{
+		TenantsId:      room.TenantsId,
 		RoomID:         req.RoomID,
 		UserID:         userID,
 		BookingNo:      bookingNo,
@@ -321,29 +332,203 @@ func (rs *RoomService) CreateBooking(req *inout.CreateBookingReq, userID int) (*
 		ContactPhone:   req.ContactPhone,
 		Remarks:        req.Remarks,
 		PackageID:      req.PackageID,
-		PackageName:    packageName,
-		OriginalPrice:  originalPrice,
-		PackagePrice:   packagePrice,
+		PackageName:    price.PackageName,
+		OriginalPrice:  price.OriginalPrice,
+		PackagePrice:   price.PackagePrice,
 		DiscountAmount: discountAmount,
-		PriceBreakdown: priceBreakdown,
 	}
 
-	if err := db.Dao.Create(booking).Error; err != nil {
-		return nil, fmt.Errorf("创建预订失败: %v", err)
+	err = rs.dao().Transaction(func(tx *gorm.DB) error {
+		// 事务内锁定房间并检查时段，Redis 锁失效时由数据库行锁兜底
+		if err := lockRoomAndCheckOverlap(tx, &room, req.RoomID, startTime, endTime, 0); err != nil {
+			if err == errBookingOverlap {
+				return fmt.Errorf("该时间段房间已被预订")
+			}
+			return err
+		}
+
+		// 核销优惠券，优惠计入 DiscountAmount
+		if req.CouponCode != "" {
+			coupon, couponDiscount, err := NewCouponService().Redeem(tx, &CouponRedemption{
+				UserID:    userID,
+				Code:      req.CouponCode,
+				OrderType: app_model.CouponOrderBooking,
+				OrderNo:   bookingNo,
+				TenantsId: room.TenantsId,
+				TargetID:  room.ID,
+				Amount:    totalAmount,
+			})
+			if err != nil {
+				return err
+			}
+
+			booking.UserCouponID = &coupon.ID
+			booking.CouponDiscount = couponDiscount
+			booking.TotalAmount = math.Round((totalAmount-couponDiscount)*100) / 100
+			booking.DiscountAmount = discountAmount + couponDiscount
+			quote.CouponCode = coupon.CouponCode
+			quote.CouponDiscount = couponDiscount
+		}
+
+		// 积分抵扣，按优惠券之后的金额计算可用积分上限
+		if req.UsePoints > 0 {
+			pointsDiscount, err := NewMembershipService().RedeemPoints(tx, userID, req.UsePoints,
+				app_model.PointsBizBooking, bookingNo, booking.TotalAmount)
+			if err != nil {
+				return err
+			}
+
+			booking.PointsUsed = req.UsePoints
+			booking.PointsDiscount = pointsDiscount
+			booking.TotalAmount = math.Round((booking.TotalAmount-pointsDiscount)*100) / 100
+			booking.DiscountAmount = math.Round((booking.DiscountAmount+pointsDiscount)*100) / 100
+			quote.PointsUsed = req.UsePoints
+			quote.PointsDiscount = pointsDiscount
+		}
+
+		// 保存分段价格明细
+		breakdownBytes, _ := json.Marshal(quote)
+		booking.PriceBreakdown = string(breakdownBytes)
+
+		if err := tx.Create(booking).Error; err != nil {
+			return fmt.Errorf("创建预订失败: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	log.Printf("成功创建预订: %s (用户ID: %d, 房间ID: %d)", bookingNo, userID, req.RoomID)
 	return booking, nil
 }
 
-// CheckRoomAvailability 检查房间可用性
+// bookingPrice 单次预订的价格计算结果
+type bookingPrice struct {
+	TotalAmount    float64
+	OriginalPrice  float64
+	PackagePrice   float64
+	DiscountAmount float64
+	PackageName    string
+	Quote          *app_model.PriceQuote
+}
+
+// resolveBookingPackage 加载预订使用的套餐并校验归属，未选套餐时返回 nil
+func (rs *RoomService) resolveBookingPackage(roomID int, packageID *int) (*app_model.RoomPackage, error) {
+	if packageID == nil {
+		return nil, nil
+	}
+
+	var pkg app_model.RoomPackage
+	if err := rs.dao().Preload("Rules").First(&pkg, *packageID).Error; err != nil {
+		return nil, fmt.Errorf("套餐不存在")
+	}
+
+	// 验证套餐是否属于该房间
+	if pkg.RoomID != roomID {
+		return nil, fmt.Errorf("套餐不属于该房间")
+	}
+	return &pkg, nil
+}
+
+// priceBooking 计算预订价格，有套餐时按规则和日期边界分段计算，否则按房间小时价
+func priceBooking(room *app_model.Room, pkg *app_model.RoomPackage, startTime time.Time, hours int) (*bookingPrice, error) {
+	price := &bookingPrice{OriginalPrice: room.HourlyRate * float64(hours)}
+
+	if pkg == nil {
+		price.Quote = app_model.NewBasePriceQuote(room.HourlyRate, startTime, hours)
+		price.TotalAmount = price.OriginalPrice
+		return price, nil
+	}
+
+	quote, err := pkg.Quote(room.HourlyRate, startTime, hours)
+	if err != nil {
+		return nil, fmt.Errorf("套餐价格计算失败: %v", err)
+	}
+
+	price.Quote = quote
+	price.PackagePrice = quote.FinalPrice
+	price.TotalAmount = quote.FinalPrice
+	price.PackageName = pkg.PackageName
+	price.DiscountAmount = price.OriginalPrice - quote.FinalPrice
+	return price, nil
+}
+
+// lockRoomForBooking 房间级别锁 - 同一房间的预订创建串行执行，锁获取失败时直接返回
+func lockRoomForBooking(roomID int) (*DistributedLock, error) {
+	roomLock := NewSecurityOrderService(redis.GetClient()).NewDistributedLock(
+		fmt.Sprintf("booking_room:%d", roomID),
+		30*time.Second,
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := roomLock.AcquireWithRenewal(ctx); err != nil {
+		return nil, fmt.Errorf("该房间预订人数较多，请稍后再试: %w", err)
+	}
+	return roomLock, nil
+}
+
+// errBookingOverlap 时段与其他有效预订（含清洁缓冲）重叠
+var errBookingOverlap = errors.New("booking overlap")
+
+// lockRoomAndCheckOverlap 在事务内锁定房间行，并锁定检查与 [startTime, endTime) 重叠的有效预订
+// 同一房间的新建、续时在房间行锁上串行，锁定后重新读取的房间信息写回 room
+// 与维护/停业时段重叠时返回 *roomBlackoutError
+func lockRoomAndCheckOverlap(tx *gorm.DB, room *app_model.Room, roomID int, startTime, endTime time.Time, excludeID int) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(room, roomID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("房间不存在")
+		}
+		return fmt.Errorf("查询房间失败: %v", err)
+	}
+
+	buffer := time.Duration(room.CleaningMin) * time.Minute
+	var ids []int
+	if err := tx.Model(&app_model.RoomBooking{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Scopes(blockingBookings(time.Now())).
+		Where("room_id = ? AND id != ? AND start_time < ? AND end_time > ?",
+			roomID, excludeID, endTime.Add(buffer), startTime.Add(-buffer)).
+		Pluck("id", &ids).Error; err != nil {
+		return fmt.Errorf("检查房间预订失败: %v", err)
+	}
+	if len(ids) > 0 {
+		return errBookingOverlap
+	}
+
+	blackout, err := findRoomBlackout(tx, room, startTime, endTime)
+	if err != nil {
+		return err
+	}
+	if blackout != nil {
+		return &roomBlackoutError{blackout: blackout}
+	}
+	return nil
+}
+
+// CheckRoomAvailability 检查房间可用性，时段与有效预订或维护/停业时段重叠时不可用
 func (rs *RoomService) CheckRoomAvailability(roomID int, startTime, endTime time.Time) (bool, error) {
+	var room app_model.Room
+	if err := rs.dao().Select("id, tenants_id, cleaning_min").First(&room, roomID).Error; err != nil {
+		return false, fmt.Errorf("查询房间失败: %v", err)
+	}
+
+	blackout, err := findRoomBlackout(rs.dao(), &room, startTime, endTime)
+	if err != nil {
+		return false, err
+	}
+	if blackout != nil {
+		return false, nil
+	}
+
+	// 预订前后需预留清洁时间
+	buffer := time.Duration(room.CleaningMin) * time.Minute
+
 	var count int64
-	if err := db.Dao.Model(&app_model.RoomBooking{}).
-		Where("room_id = ? AND status IN (?) AND ((start_time <= ? AND end_time > ?) OR (start_time < ? AND end_time >= ?))",
-			roomID,
-			[]int{app_model.BookingStatusPaid, app_model.BookingStatusInUse},
-			startTime, startTime, endTime, endTime).
+	if err := rs.dao().Model(&app_model.RoomBooking{}).Scopes(blockingBookings(time.Now())).
+		Where("room_id = ? AND start_time < ? AND end_time > ?",
+			roomID, endTime.Add(buffer), startTime.Add(-buffer)).
 		Count(&count).Error; err != nil {
 		return false, fmt.Errorf("查询房间预订失败: %v", err)
 	}
@@ -353,7 +538,7 @@ func (rs *RoomService) CheckRoomAvailability(roomID int, startTime, endTime time
 
 // CheckAvailability 检查可用性接口（增强版，支持套餐定价）
 func (rs *RoomService) CheckAvailability(req *inout.CheckAvailabilityReq) (*inout.AvailabilityResp, error) {
-	startTime, err := time.Parse("2006-01-02 15:04:05", req.StartTime)
+	startTime, err := parseBookingTime(req.StartTime)
 	if err != nil {
 		return nil, fmt.Errorf("开始时间格式错误: %v", err)
 	}
@@ -362,7 +547,7 @@ func (rs *RoomService) CheckAvailability(req *inout.CheckAvailabilityReq) (*inou
 
 	// 获取房间信息
 	var room app_model.Room
-	if err := db.Dao.First(&room, req.RoomID).Error; err != nil {
+	if err := rs.dao().First(&room, req.RoomID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return &inout.AvailabilityResp{
 				IsAvailable: false,
@@ -379,6 +564,18 @@ func (rs *RoomService) CheckAvailability(req *inout.CheckAvailabilityReq) (*inou
 		}, nil
 	}
 
+	// 维护/停业时段不可预订，也不可候补
+	blackout, err := findRoomBlackout(rs.dao(), &room, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	if blackout != nil {
+		return &inout.AvailabilityResp{
+			IsAvailable: false,
+			Message:     blackoutMessage(blackout),
+		}, nil
+	}
+
 	available, err := rs.CheckRoomAvailability(req.RoomID, startTime, endTime)
 	if err != nil {
 		return nil, err
@@ -394,7 +591,7 @@ func (rs *RoomService) CheckAvailability(req *inout.CheckAvailabilityReq) (*inou
 	}
 
 	// 获取日期类型
-	dayType := app_model.GetDayType(startTime)
+	dayType := rs.getDayType(startTime)
 	dayTypeText := rs.getDayTypeText(dayType)
 
 	// 构建价格详情
@@ -421,42 +618,54 @@ func (rs *RoomService) CheckAvailability(req *inout.CheckAvailabilityReq) (*inou
 	if available {
 		resp.Message = "房间可预订"
 	} else {
-		resp.Message = "该时间段房间已被预订"
+		resp.Message = "该时间段房间已被预订，可登记候补"
+		resp.CanWaitlist = startTime.After(time.Now())
 	}
 
 	return resp, nil
 }
 
-// GetBookingList 获取预订列表
+// GetBookingList 获取预订列表，周期预订系列合并为一项，指定 series_id 时逐条列出该系列的预订
 func (rs *RoomService) GetBookingList(req *inout.BookingListReq, userID *int) (*inout.BookingListResp, error) {
 	var bookings []app_model.RoomBooking
 	var total int64
 
-	query := db.Dao.Model(&app_model.RoomBooking{}).Preload("Room").Preload("User")
+	filter := func(query *gorm.DB) *gorm.DB {
+		// 如果指定了用户ID，只查询该用户的预订
+		if userID != nil {
+			query = query.Where("user_id = ?", *userID)
+		}
 
-	// 如果指定了用户ID，只查询该用户的预订
-	if userID != nil {
-		query = query.Where("user_id = ?", *userID)
+		if req.Status > 0 {
+			query = query.Where("status = ?", req.Status)
+		}
+		if req.RoomID > 0 {
+			query = query.Where("room_id = ?", req.RoomID)
+		}
+		if req.UserID > 0 && userID == nil { // 管理员可以查询指定用户
+			query = query.Where("user_id = ?", req.UserID)
+		}
+		if req.StartDate != "" {
+			query = query.Where("start_time >= ?", req.StartDate)
+		}
+		if req.EndDate != "" {
+			query = query.Where("start_time <= ?", req.EndDate+" 23:59:59")
+		}
+		if req.Keyword != "" {
+			query = query.Where("booking_no LIKE ? OR contact_name LIKE ? OR contact_phone LIKE ?",
+				"%"+req.Keyword+"%", "%"+req.Keyword+"%", "%"+req.Keyword+"%")
+		}
+		return query
 	}
 
-	if req.Status > 0 {
-		query = query.Where("status = ?", req.Status)
-	}
-	if req.RoomID > 0 {
-		query = query.Where("room_id = ?", req.RoomID)
-	}
-	if req.UserID > 0 && userID == nil { // 管理员可以查询指定用户
-		query = query.Where("user_id = ?", req.UserID)
-	}
-	if req.StartDate != "" {
-		query = query.Where("start_time >= ?", req.StartDate)
-	}
-	if req.EndDate != "" {
-		query = query.Where("start_time <= ?", req.EndDate+" 23:59:59")
-	}
-	if req.Keyword != "" {
-		query = query.Where("booking_no LIKE ? OR contact_name LIKE ? OR contact_phone LIKE ?",
-			"%"+req.Keyword+"%", "%"+req.Keyword+"%", "%"+req.Keyword+"%")
+	query := rs.dao().Model(&app_model.RoomBooking{}).Preload("Room").Preload("User").Scopes(filter)
+	if req.SeriesID > 0 {
+		query = query.Where("series_id = ?", req.SeriesID)
+	} else {
+		// 同一系列只保留符合条件的第一次预订作为代表
+		firstOfSeries := rs.dao().Model(&app_model.RoomBooking{}).Scopes(filter).
+			Select("MIN(id)").Where("series_id IS NOT NULL").Group("series_id")
+		query = query.Where("series_id IS NULL OR id IN (?)", firstOfSeries)
 	}
 
 	if err := query.Count(&total).Error; err != nil {
@@ -469,9 +678,26 @@ func (rs *RoomService) GetBookingList(req *inout.BookingListReq, userID *int) (*
 	}
 
 	list := make([]inout.BookingDetail, 0, len(bookings))
+	var seriesIDs []int
 	for _, booking := range bookings {
 		detail := rs.convertBookingToDetail(&booking)
 		list = append(list, *detail)
+		if booking.SeriesID != nil {
+			seriesIDs = append(seriesIDs, *booking.SeriesID)
+		}
+	}
+
+	// 合并展示时附带系列汇总
+	if req.SeriesID == 0 && len(seriesIDs) > 0 {
+		summaries, err := rs.loadSeriesSummaries(seriesIDs)
+		if err != nil {
+			return nil, err
+		}
+		for i := range list {
+			if list[i].SeriesID != nil {
+				list[i].Series = summaries[*list[i].SeriesID]
+			}
+		}
 	}
 
 	return &inout.BookingListResp{
@@ -482,40 +708,38 @@ func (rs *RoomService) GetBookingList(req *inout.BookingListReq, userID *int) (*
 	}, nil
 }
 
-// CancelBooking 取消预订
-func (rs *RoomService) CancelBooking(req *inout.CancelBookingReq, userID *int) error {
-	var booking app_model.RoomBooking
-	query := db.Dao
-
+// GetBookingDetail 获取预订详情，userID 不为空时只查询本人预订并附带入场凭证
+func (rs *RoomService) GetBookingDetail(id int, userID *int) (*inout.BookingDetail, error) {
+	query := rs.dao().Preload("Room").Preload("User")
 	if userID != nil {
 		query = query.Where("user_id = ?", *userID)
 	}
 
-	if err := query.First(&booking, req.ID).Error; err != nil {
+	var booking app_model.RoomBooking
+	if err := query.First(&booking, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return fmt.Errorf("预订不存在")
+			return nil, fmt.Errorf("预订不存在或无权限访问")
 		}
-		return fmt.Errorf("查询预订失败: %v", err)
-	}
-
-	if booking.Status != app_model.BookingStatusPending && booking.Status != app_model.BookingStatusPaid {
-		return fmt.Errorf("当前状态无法取消")
+		return nil, fmt.Errorf("查询预订失败: %v", err)
 	}
 
-	// 更新预订状态
-	updates := map[string]interface{}{
-		"status": app_model.BookingStatusCancelled,
-	}
-	if req.Reason != "" {
-		updates["remarks"] = booking.Remarks + "\n取消原因: " + req.Reason
+	detail := rs.convertBookingToDetail(&booking)
+	if userID != nil {
+		credential, err := NewBookingEntryService().WithContext(rs.ctx).GetCredential(&booking)
+		if err != nil {
+			return nil, err
+		}
+		detail.EntryCredential = credential
 	}
 
-	if err := db.Dao.Model(&booking).Updates(updates).Error; err != nil {
-		return fmt.Errorf("取消预订失败: %v", err)
-	}
+	return detail, nil
+}
 
-	log.Printf("预订已取消: %s (用户ID: %d)", booking.BookingNo, booking.UserID)
-	return nil
+// CancelBooking 取消预订
+func (rs *RoomService) CancelBooking(req *inout.CancelBookingReq, userID *int) error {
+	// 已支付的预订按取消政策退款到钱包
+	_, err := NewBookingRefundService().CancelBooking(req, userID, userID)
+	return err
 }
 
 // ========== 辅助方法 ==========
@@ -535,9 +759,13 @@ func (rs *RoomService) convertRoomToDetail(room *app_model.Room) *inout.RoomDeta
 		Floor:        room.Floor,
 		Area:         room.Area,
 		Description:  room.Description,
+		CleaningMin:  room.CleaningMin,
 		CreateTime:   room.CreateTime,
 		UpdateTime:   room.UpdateTime,
 		IsAvailable:  room.IsAvailable(),
+
+		OvertimeUnitMin:  room.OvertimeUnitMin,
+		OvertimeGraceMin: room.OvertimeGraceMin,
 	}
 
 	// 解析特色设施
@@ -576,11 +804,15 @@ func (rs *RoomService) convertBookingToDetail(booking *app_model.RoomBooking) *i
 		ContactName:    booking.ContactName,
 		ContactPhone:   booking.ContactPhone,
 		Remarks:        booking.Remarks,
+		SeriesID:       booking.SeriesID,
 		PackageID:      booking.PackageID,
 		PackageName:    booking.PackageName,
 		OriginalPrice:  booking.OriginalPrice,
 		PackagePrice:   booking.PackagePrice,
 		DiscountAmount: booking.DiscountAmount,
+		CouponDiscount: booking.CouponDiscount,
+		PointsUsed:     booking.PointsUsed,
+		PointsDiscount: booking.PointsDiscount,
 		PriceBreakdown: booking.PriceBreakdown,
 		CreateTime:     booking.CreateTime,
 		UpdateTime:     booking.UpdateTime,
@@ -643,11 +875,11 @@ func (rs *RoomService) GetRoomStatistics() (*inout.RoomStatistics, error) {
 	// 统计房间数量
 	var totalRooms, availableRooms, occupiedRooms, maintenanceRooms, disabledRooms int64
 
-	db.Dao.Model(&app_model.Room{}).Count(&totalRooms)
-	db.Dao.Model(&app_model.Room{}).Where("status = ?", app_model.RoomStatusAvailable).Count(&availableRooms)
-	db.Dao.Model(&app_model.Room{}).Where("status = ?", app_model.RoomStatusOccupied).Count(&occupiedRooms)
-	db.Dao.Model(&app_model.Room{}).Where("status = ?", app_model.RoomStatusMaintenance).Count(&maintenanceRooms)
-	db.Dao.Model(&app_model.Room{}).Where("status = ?", app_model.RoomStatusDisabled).Count(&disabledRooms)
+	rs.dao().Model(&app_model.Room{}).Count(&totalRooms)
+	rs.dao().Model(&app_model.Room{}).Where("status = ?", app_model.RoomStatusAvailable).Count(&availableRooms)
+	rs.dao().Model(&app_model.Room{}).Where("status = ?", app_model.RoomStatusOccupied).Count(&occupiedRooms)
+	rs.dao().Model(&app_model.Room{}).Where("status = ?", app_model.RoomStatusMaintenance).Count(&maintenanceRooms)
+	rs.dao().Model(&app_model.Room{}).Where("status = ?", app_model.RoomStatusDisabled).Count(&disabledRooms)
 
 	stats.TotalRooms = int(totalRooms)
 	stats.AvailableRooms = int(availableRooms)
@@ -663,14 +895,14 @@ func (rs *RoomService) GetRoomStatistics() (*inout.RoomStatistics, error) {
 	// 今日预订数
 	today := time.Now().Format("2006-01-02")
 	var todayBookings int64
-	db.Dao.Model(&app_model.RoomBooking{}).
+	rs.dao().Model(&app_model.RoomBooking{}).
 		Where("DATE(create_time) = ?", today).
 		Count(&todayBookings)
 	stats.TodayBookings = int(todayBookings)
 
 	// 今日营收
 	var todayRevenue float64
-	db.Dao.Model(&app_model.RoomBooking{}).
+	rs.dao().Model(&app_model.RoomBooking{}).
 		Where("DATE(create_time) = ? AND status IN (?)", today,
 			[]int{app_model.BookingStatusPaid, app_model.BookingStatusInUse, app_model.BookingStatusCompleted}).
 		Select("COALESCE(SUM(total_amount), 0)").Scan(&todayRevenue)
@@ -679,7 +911,7 @@ func (rs *RoomService) GetRoomStatistics() (*inout.RoomStatistics, error) {
 	// 本月营收
 	monthStart := time.Now().Format("2006-01-01")
 	var monthlyRevenue float64
-	db.Dao.Model(&app_model.RoomBooking{}).
+	rs.dao().Model(&app_model.RoomBooking{}).
 		Where("create_time >= ? AND status IN (?)", monthStart,
 			[]int{app_model.BookingStatusPaid, app_model.BookingStatusInUse, app_model.BookingStatusCompleted}).
 		Select("COALESCE(SUM(total_amount), 0)").Scan(&monthlyRevenue)
@@ -694,7 +926,7 @@ func (rs *RoomService) GetRoomStatistics() (*inout.RoomStatistics, error) {
 func (rs *RoomService) CalculatePriceWithPackage(roomID int, startTime, endTime time.Time) (float64, []string, error) {
 	// 获取房间基础价格
 	var room app_model.Room
-	if err := db.Dao.First(&room, roomID).Error; err != nil {
+	if err := rs.dao().First(&room, roomID).Error; err != nil {
 		return 0, nil, fmt.Errorf("房间不存在")
 	}
 
@@ -705,7 +937,7 @@ func (rs *RoomService) CalculatePriceWithPackage(roomID int, startTime, endTime
 	// 查询适用的套餐规则
 	now := time.Now()
 	var packages []app_model.RoomPackage
-	if err := db.Dao.Where("room_id = ? AND is_active = 1 AND start_date <= ? AND end_date >= ?",
+	if err := rs.dao().Where("room_id = ? AND is_active = 1 AND start_date <= ? AND end_date >= ?",
 		roomID, now, now).
 		Order("priority DESC").
 		Find(&packages).Error; err != nil {
@@ -724,7 +956,7 @@ func (rs *RoomService) CalculatePriceWithPackage(roomID int, startTime, endTime
 	for _, pkg := range packages {
 		// 查询套餐规则
 		var rules []app_model.RoomPackageRule
-		if err := db.Dao.Where("package_id = ? AND is_active = 1", pkg.ID).
+		if err := rs.dao().Where("package_id = ? AND is_active = 1", pkg.ID).
 			Order("priority DESC").
 			Find(&rules).Error; err != nil {
 			continue
@@ -770,23 +1002,9 @@ func (rs *RoomService) CalculatePriceWithPackage(roomID int, startTime, endTime
 	return finalPrice, appliedRules, nil
 }
 
-// getDayType 获取日期类型
+// getDayType 获取日期类型（节假日日历含调休）
 func (rs *RoomService) getDayType(date time.Time) string {
-	// 检查是否是特殊日期
-	var specialDate app_model.RoomSpecialDate
-	dateStr := date.Format("2006-01-02")
-	if err := db.Dao.Where("date = ? AND is_active = 1", dateStr).First(&specialDate).Error; err == nil {
-		return specialDate.DateType
-	}
-
-	// 检查是否是周末
-	weekday := date.Weekday()
-	if weekday == time.Saturday || weekday == time.Sunday {
-		return "weekend"
-	}
-
-	// 默认为工作日
-	return "weekday"
+	return GetHolidayCalendar().ResolveDayType(date)
 }
 
 // getDayTypeText 获取日期类型文本
@@ -811,7 +1029,7 @@ func (rs *RoomService) getDayTypeText(dayType string) string {
 func (rs *RoomService) CreatePackage(req *inout.CreatePackageReq, createdBy int) (*app_model.RoomPackage, error) {
 	// 验证房间是否存在
 	var room app_model.Room
-	if err := db.Dao.First(&room, req.RoomID).Error; err != nil {
+	if err := rs.dao().First(&room, req.RoomID).Error; err != nil {
 		return nil, fmt.Errorf("房间不存在")
 	}
 
@@ -837,8 +1055,14 @@ func (rs *RoomService) CreatePackage(req *inout.CreatePackageReq, createdBy int)
 		return nil, fmt.Errorf("结束日期必须晚于开始日期")
 	}
 
+	// 验证封顶价和最低消费
+	if req.MaxCharge > 0 && req.MinCharge > req.MaxCharge {
+		return nil, fmt.Errorf("最低消费不能高于封顶价格")
+	}
+
 	// 创建套餐
 	pkg := &app_model.RoomPackage{
+		TenantsId:   room.TenantsId,
 		RoomID:      req.RoomID,
 		PackageName: req.PackageName,
 		Description: req.Description,
@@ -847,13 +1071,15 @@ func (rs *RoomService) CreatePackage(req *inout.CreatePackageReq, createdBy int)
 		MinHours:    req.MinHours,
 		MaxHours:    req.MaxHours,
 		BasePrice:   req.BasePrice,
+		MinCharge:   req.MinCharge,
+		MaxCharge:   req.MaxCharge,
 		StartDate:   startDate,
 		EndDate:     endDate,
 		Priority:    req.Priority,
 		IsActive:    true, // 默认启用
 	}
 
-	if err := db.Dao.Create(pkg).Error; err != nil {
+	if err := rs.dao().Create(pkg).Error; err != nil {
 		return nil, fmt.Errorf("创建套餐失败: %v", err)
 	}
 
@@ -864,7 +1090,7 @@ func (rs *RoomService) CreatePackage(req *inout.CreatePackageReq, createdBy int)
 // UpdatePackage 更新套餐
 func (rs *RoomService) UpdatePackage(req *inout.UpdatePackageReq) (*app_model.RoomPackage, error) {
 	var pkg app_model.RoomPackage
-	if err := db.Dao.First(&pkg, req.ID).Error; err != nil {
+	if err := rs.dao().First(&pkg, req.ID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("套餐不存在")
 		}
@@ -874,7 +1100,7 @@ func (rs *RoomService) UpdatePackage(req *inout.UpdatePackageReq) (*app_model.Ro
 	// 验证房间是否存在
 	if req.RoomID != pkg.RoomID {
 		var room app_model.Room
-		if err := db.Dao.First(&room, req.RoomID).Error; err != nil {
+		if err := rs.dao().First(&room, req.RoomID).Error; err != nil {
 			return nil, fmt.Errorf("房间不存在")
 		}
 		pkg.RoomID = req.RoomID
@@ -888,6 +1114,8 @@ func (rs *RoomService) UpdatePackage(req *inout.UpdatePackageReq) (*app_model.Ro
 	pkg.MinHours = req.MinHours
 	pkg.MaxHours = req.MaxHours
 	pkg.BasePrice = req.BasePrice
+	pkg.MinCharge = req.MinCharge
+	pkg.MaxCharge = req.MaxCharge
 	pkg.Priority = req.Priority
 	pkg.IsActive = req.IsActive
 
@@ -912,7 +1140,12 @@ func (rs *RoomService) UpdatePackage(req *inout.UpdatePackageReq) (*app_model.Ro
 		return nil, fmt.Errorf("结束日期必须晚于开始日期")
 	}
 
-	if err := db.Dao.Save(&pkg).Error; err != nil {
+	// 验证封顶价和最低消费
+	if req.MaxCharge > 0 && req.MinCharge > req.MaxCharge {
+		return nil, fmt.Errorf("最低消费不能高于封顶价格")
+	}
+
+	if err := rs.dao().Save(&pkg).Error; err != nil {
 		return nil, fmt.Errorf("更新套餐失败: %v", err)
 	}
 
@@ -924,7 +1157,7 @@ func (rs *RoomService) UpdatePackage(req *inout.UpdatePackageReq) (*app_model.Ro
 func (rs *RoomService) CreatePackageRule(req *inout.CreatePackageRuleReq) (*app_model.RoomPackageRule, error) {
 	// 验证套餐是否存在
 	var pkg app_model.RoomPackage
-	if err := db.Dao.First(&pkg, req.PackageID).Error; err != nil {
+	if err := rs.dao().First(&pkg, req.PackageID).Error; err != nil {
 		return nil, fmt.Errorf("套餐不存在")
 	}
 
@@ -957,7 +1190,7 @@ func (rs *RoomService) CreatePackageRule(req *inout.CreatePackageRuleReq) (*app_
 		IsActive:   true, // 默认激活
 	}
 
-	if err := db.Dao.Create(rule).Error; err != nil {
+	if err := rs.dao().Create(rule).Error; err != nil {
 		return nil, fmt.Errorf("创建套餐规则失败: %v", err)
 	}
 
@@ -968,13 +1201,23 @@ func (rs *RoomService) CreatePackageRule(req *inout.CreatePackageRuleReq) (*app_
 // UpdatePackageRule 更新套餐规则
 func (rs *RoomService) UpdatePackageRule(req *inout.UpdatePackageRuleReq) (*app_model.RoomPackageRule, error) {
 	var rule app_model.RoomPackageRule
-	if err := db.Dao.First(&rule, req.ID).Error; err != nil {
+	if err := rs.dao().First(&rule, req.ID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("套餐规则不存在")
 		}
 		return nil, fmt.Errorf("查询套餐规则失败: %v", err)
 	}
 
+	// 规则和目标套餐都必须属于当前商家
+	if err := rs.checkPackageExists(rule.PackageID); err != nil {
+		return nil, err
+	}
+	if req.PackageID != rule.PackageID {
+		if err := rs.checkPackageExists(req.PackageID); err != nil {
+			return nil, err
+		}
+	}
+
 	// 更新字段
 	rule.PackageID = req.PackageID
 	rule.RuleName = req.RuleName
@@ -1002,7 +1245,7 @@ func (rs *RoomService) UpdatePackageRule(req *inout.UpdatePackageRuleReq) (*app_
 		return nil, fmt.Errorf("价格值必须大于0")
 	}
 
-	if err := db.Dao.Save(&rule).Error; err != nil {
+	if err := rs.dao().Save(&rule).Error; err != nil {
 		return nil, fmt.Errorf("更新套餐规则失败: %v", err)
 	}
 
@@ -1012,7 +1255,7 @@ func (rs *RoomService) UpdatePackageRule(req *inout.UpdatePackageRuleReq) (*app_
 
 // GetPackageList 获取套餐列表
 func (rs *RoomService) GetPackageList(req *inout.PackageListReq) (*inout.PackageListResp, error) {
-	query := db.Dao.Model(&app_model.RoomPackage{})
+	query := rs.dao().Model(&app_model.RoomPackage{})
 
 	// 添加查询条件
 	if req.RoomID != 0 {
@@ -1062,6 +1305,8 @@ func (rs *RoomService) convertPackageToDetail(pkg *app_model.RoomPackage) *inout
 		MinHours:        pkg.MinHours,
 		MaxHours:        pkg.MaxHours,
 		BasePrice:       pkg.BasePrice,
+		MinCharge:       pkg.MinCharge,
+		MaxCharge:       pkg.MaxCharge,
 		StartDate:       pkg.StartDate,
 		EndDate:         pkg.EndDate,
 		Priority:        pkg.Priority,
@@ -1115,7 +1360,7 @@ func (rs *RoomService) getAdjustmentText(adjustmentType string, adjustmentValue
 // DeletePackage 删除套餐
 func (rs *RoomService) DeletePackage(id int) error {
 	var pkg app_model.RoomPackage
-	if err := db.Dao.First(&pkg, id).Error; err != nil {
+	if err := rs.dao().First(&pkg, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return fmt.Errorf("套餐不存在")
 		}
@@ -1123,7 +1368,7 @@ func (rs *RoomService) DeletePackage(id int) error {
 	}
 
 	// 删除套餐及其关联的规则（通过外键约束自动删除）
-	if err := db.Dao.Delete(&pkg).Error; err != nil {
+	if err := rs.dao().Delete(&pkg).Error; err != nil {
 		return fmt.Errorf("删除套餐失败: %v", err)
 	}
 
@@ -1135,12 +1380,12 @@ func (rs *RoomService) DeletePackage(id int) error {
 func (rs *RoomService) GetPackageRuleList(packageID int) ([]*inout.PackageRuleDetail, error) {
 	// 验证套餐是否存在
 	var pkg app_model.RoomPackage
-	if err := db.Dao.First(&pkg, packageID).Error; err != nil {
+	if err := rs.dao().First(&pkg, packageID).Error; err != nil {
 		return nil, fmt.Errorf("套餐不存在")
 	}
 
 	var rules []app_model.RoomPackageRule
-	if err := db.Dao.Where("package_id = ?", packageID).
+	if err := rs.dao().Where("package_id = ?", packageID).
 		Order("priority DESC, created_at DESC").
 		Find(&rules).Error; err != nil {
 		return nil, fmt.Errorf("查询套餐规则列表失败: %v", err)
@@ -1174,14 +1419,18 @@ func (rs *RoomService) GetPackageRuleList(packageID int) ([]*inout.PackageRuleDe
 // DeletePackageRule 删除套餐规则
 func (rs *RoomService) DeletePackageRule(id int) error {
 	var rule app_model.RoomPackageRule
-	if err := db.Dao.First(&rule, id).Error; err != nil {
+	if err := rs.dao().First(&rule, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return fmt.Errorf("套餐规则不存在")
 		}
 		return fmt.Errorf("查询套餐规则失败: %v", err)
 	}
 
-	if err := db.Dao.Delete(&rule).Error; err != nil {
+	if err := rs.checkPackageExists(rule.PackageID); err != nil {
+		return err
+	}
+
+	if err := rs.dao().Delete(&rule).Error; err != nil {
 		return fmt.Errorf("删除套餐规则失败: %v", err)
 	}
 
@@ -1189,12 +1438,24 @@ func (rs *RoomService) DeletePackageRule(id int) error {
 	return nil
 }
 
+// checkPackageExists 检查套餐是否存在（带租户上下文时只能查到本商家的套餐）
+func (rs *RoomService) checkPackageExists(packageID int) error {
+	var pkg app_model.RoomPackage
+	if err := rs.dao().Select("id").First(&pkg, packageID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("套餐不存在")
+		}
+		return fmt.Errorf("查询套餐失败: %v", err)
+	}
+	return nil
+}
+
 // GetSpecialDateList 获取特殊日期列表
 func (rs *RoomService) GetSpecialDateList(page, pageSize int) (*inout.SpecialDateListResp, error) {
 	var dates []app_model.RoomSpecialDate
 	var total int64
 
-	query := db.Dao.Model(&app_model.RoomSpecialDate{})
+	query := rs.dao().Model(&app_model.RoomSpecialDate{})
 
 	// 获取总数
 	if err := query.Count(&total).Error; err != nil {
@@ -1237,7 +1498,7 @@ func (rs *RoomService) GetSpecialDateList(page, pageSize int) (*inout.SpecialDat
 func (rs *RoomService) CreateSpecialDate(date time.Time, dateType, name, description string) (*app_model.RoomSpecialDate, error) {
 	// 检查日期是否已存在
 	var existing app_model.RoomSpecialDate
-	if err := db.Dao.Where("date = ?", date.Format("2006-01-02")).First(&existing).Error; err == nil {
+	if err := rs.dao().Where("date = ?", date.Format("2006-01-02")).First(&existing).Error; err == nil {
 		return nil, fmt.Errorf("该日期已存在特殊日期配置")
 	}
 
@@ -1249,10 +1510,11 @@ func (rs *RoomService) CreateSpecialDate(date time.Time, dateType, name, descrip
 		IsActive:    true,
 	}
 
-	if err := db.Dao.Create(specialDate).Error; err != nil {
+	if err := rs.dao().Create(specialDate).Error; err != nil {
 		return nil, fmt.Errorf("创建特殊日期失败: %v", err)
 	}
 
+	GetHolidayCalendar().Invalidate(date.Year())
 	log.Printf("特殊日期已创建: %s (%s)", specialDate.Name, specialDate.Date.Format("2006-01-02"))
 	return specialDate, nil
 }
@@ -1260,17 +1522,18 @@ func (rs *RoomService) CreateSpecialDate(date time.Time, dateType, name, descrip
 // DeleteSpecialDate 删除特殊日期
 func (rs *RoomService) DeleteSpecialDate(id int) error {
 	var specialDate app_model.RoomSpecialDate
-	if err := db.Dao.First(&specialDate, id).Error; err != nil {
+	if err := rs.dao().First(&specialDate, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return fmt.Errorf("特殊日期不存在")
 		}
 		return fmt.Errorf("查询特殊日期失败: %v", err)
 	}
 
-	if err := db.Dao.Delete(&specialDate).Error; err != nil {
+	if err := rs.dao().Delete(&specialDate).Error; err != nil {
 		return fmt.Errorf("删除特殊日期失败: %v", err)
 	}
 
+	GetHolidayCalendar().Invalidate(specialDate.Date.Year())
 	log.Printf("特殊日期已删除: %s (%s)", specialDate.Name, specialDate.Date.Format("2006-01-02"))
 	return nil
 }
@@ -1298,6 +1561,8 @@ func (rs *RoomService) getSpecialDateTypeText(dateType string) string {
 		return "传统节日"
 	case "special":
 		return "特殊活动日"
+	case "workday":
+		return "调休上班日"
 	default:
 		return "未知类型"
 	}
@@ -1324,14 +1589,14 @@ func (rs *RoomService) getPackageTypeText(packageType string) string {
 // GetRoomPackages 获取房间可用套餐
 func (rs *RoomService) GetRoomPackages(req *inout.GetRoomPackagesReq) (*inout.GetRoomPackagesResp, error) {
 	// 解析预约时间
-	startTime, err := time.Parse("2006-01-02 15:04:05", req.StartTime)
+	startTime, err := parseBookingTime(req.StartTime)
 	if err != nil {
 		return nil, fmt.Errorf("开始时间格式错误: %v", err)
 	}
 
 	// 检查房间是否存在
 	var room app_model.Room
-	if err := db.Dao.First(&room, req.RoomID).Error; err != nil {
+	if err := rs.dao().First(&room, req.RoomID).Error; err != nil {
 		return nil, fmt.Errorf("房间不存在")
 	}
 
@@ -1341,7 +1606,7 @@ func (rs *RoomService) GetRoomPackages(req *inout.GetRoomPackagesReq) (*inout.Ge
 
 	// 查询房间的活跃套餐
 	var packages []app_model.RoomPackage
-	if err := db.Dao.Where("room_id = ? AND is_active = 1", req.RoomID).
+	if err := rs.dao().Where("room_id = ? AND is_active = 1", req.RoomID).
 		Preload("Rules", "is_active = 1").
 		Order("priority DESC, create_time DESC").
 		Find(&packages).Error; err != nil {
@@ -1457,14 +1722,14 @@ func (rs *RoomService) GetRoomPackages(req *inout.GetRoomPackagesReq) (*inout.Ge
 // BookingPricePreview 预订价格预览
 func (rs *RoomService) BookingPricePreview(req *inout.BookingPricePreviewReq) (*inout.BookingPricePreviewResp, error) {
 	// 解析预约时间
-	startTime, err := time.Parse("2006-01-02 15:04:05", req.StartTime)
+	startTime, err := parseBookingTime(req.StartTime)
 	if err != nil {
 		return nil, fmt.Errorf("开始时间格式错误: %v", err)
 	}
 
 	// 检查房间是否存在
 	var room app_model.Room
-	if err := db.Dao.First(&room, req.RoomID).Error; err != nil {
+	if err := rs.dao().First(&room, req.RoomID).Error; err != nil {
 		return nil, fmt.Errorf("房间不存在")
 	}
 
@@ -1475,15 +1740,14 @@ func (rs *RoomService) BookingPricePreview(req *inout.BookingPricePreviewReq) (*
 	// 计算基础价格
 	basePrice := room.HourlyRate
 	originalPrice := basePrice * float64(req.Hours)
-	finalPrice := originalPrice
-	discountAmount := 0.0
+	quote := app_model.NewBasePriceQuote(basePrice, startTime, req.Hours)
 	discountPercent := 0.0
-	var packageName, ruleName string
+	var packageName string
 
-	// 如果指定了套餐，计算套餐价格
+	// 如果指定了套餐，按规则和日期边界分段计算套餐价格
 	if req.PackageID != nil {
 		var pkg app_model.RoomPackage
-		if err := db.Dao.Preload("Rules").First(&pkg, *req.PackageID).Error; err != nil {
+		if err := rs.dao().Preload("Rules").First(&pkg, *req.PackageID).Error; err != nil {
 			return nil, fmt.Errorf("套餐不存在")
 		}
 
@@ -1492,33 +1756,32 @@ func (rs *RoomService) BookingPricePreview(req *inout.BookingPricePreviewReq) (*
 			return nil, fmt.Errorf("套餐不属于该房间")
 		}
 
-		// 计算套餐价格
-		calculatedPrice, matchedRule, err := pkg.CalculatePrice(basePrice, startTime, req.Hours)
+		quote, err = pkg.Quote(basePrice, startTime, req.Hours)
 		if err != nil {
 			return nil, fmt.Errorf("套餐价格计算失败: %v", err)
 		}
-
-		finalPrice = calculatedPrice
 		packageName = pkg.PackageName
-		discountAmount = originalPrice - finalPrice
-		if originalPrice > 0 {
-			discountPercent = discountAmount / originalPrice * 100
-		}
+	}
 
-		if matchedRule != nil {
-			ruleName = matchedRule.RuleName
-		}
+	finalPrice := quote.FinalPrice
+	discountAmount := originalPrice - finalPrice
+	if originalPrice > 0 {
+		discountPercent = discountAmount / originalPrice * 100
 	}
 
 	// 构建价格明细
 	priceBreakdown := &inout.PriceBreakdown{
-		BaseHourlyRate: basePrice,
-		Hours:          req.Hours,
-		SubTotal:       originalPrice,
-		RuleType:       "package",
-		RuleValue:      finalPrice - originalPrice,
-		Adjustment:     discountAmount,
-		FinalTotal:     finalPrice,
+		BaseHourlyRate:         basePrice,
+		Hours:                  req.Hours,
+		SubTotal:               originalPrice,
+		RuleType:               "package",
+		RuleValue:              finalPrice - originalPrice,
+		Adjustment:             discountAmount,
+		FinalTotal:             finalPrice,
+		LineItems:              quote.LineItems,
+		LineItemsTotal:         quote.SubTotal,
+		ChargeAdjustment:       quote.Adjustment,
+		ChargeAdjustmentReason: quote.AdjustmentReason,
 	}
 
 	return &inout.BookingPricePreviewResp{
@@ -1531,7 +1794,7 @@ func (rs *RoomService) BookingPricePreview(req *inout.BookingPricePreviewReq) (*
 		FinalPrice:      finalPrice,
 		DiscountAmount:  discountAmount,
 		DiscountPercent: discountPercent,
-		RuleName:        ruleName,
+		RuleName:        quote.RuleName,
 		DayType:         dayType,
 		DayTypeText:     dayTypeText,
 		PriceBreakdown:  priceBreakdown,
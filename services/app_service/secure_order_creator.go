@@ -4,13 +4,13 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math"
 	"math/rand"
 	"nasa-go-admin/db"
 	"nasa-go-admin/inout"
 	"nasa-go-admin/model/app_model"
-	"nasa-go-admin/services/miniapp_service"
+	"nasa-go-admin/model/miniapp_model"
 	"nasa-go-admin/services/public_service"
-	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -117,8 +117,40 @@ func (soc *SecureOrderCreator) CreateOrderSecurely(c *gin.Context, uid int, para
 		return "", fmt.Errorf("库存扣减失败: %w", err)
 	}
 
-	// 7. 查询用户钱包并处理支付
+	// 7. 核销优惠券（与扣库存同一事务，失败整体回滚）
+	orderNo := soc.generateOrderNo(uid, params.GoodsId)
 	totalPrice := goods.Price * float64(params.Num)
+	var couponDiscount float64
+	if params.CouponCode != "" {
+		_, couponDiscount, err = NewCouponService().Redeem(tx, &CouponRedemption{
+			UserID:    uid,
+			Code:      params.CouponCode,
+			OrderType: app_model.CouponOrderGoods,
+			OrderNo:   orderNo,
+			TenantsId: goods.TenantsId,
+			TargetID:  goods.Id,
+			Amount:    totalPrice,
+		})
+		if err != nil {
+			tx.Rollback()
+			return "", err
+		}
+		totalPrice = math.Round((totalPrice-couponDiscount)*100) / 100
+	}
+
+	// 8. 积分抵扣（在优惠券之后，按抵扣后的金额计算可用积分上限）
+	var pointsDiscount float64
+	if params.UsePoints > 0 {
+		pointsDiscount, err = NewMembershipService().RedeemPoints(tx, uid, params.UsePoints,
+			app_model.PointsBizGoods, orderNo, totalPrice)
+		if err != nil {
+			tx.Rollback()
+			return "", err
+		}
+		totalPrice = math.Round((totalPrice-pointsDiscount)*100) / 100
+	}
+
+	// 9. 查询用户钱包并处理支付
 	orderStatus := "pending" // 默认待支付
 
 	var walletAfterDeduct *app_model.AppWallet
@@ -139,20 +171,28 @@ func (soc *SecureOrderCreator) CreateOrderSecurely(c *gin.Context, uid int, para
 			tx.Rollback()
 			return "", fmt.Errorf("记录交易流水失败: %w", err)
 		}
+
+		// 支付成功累计消费并发放积分
+		if err := NewMembershipService().EarnPoints(tx, uid, app_model.PointsBizGoods, orderNo, totalPrice); err != nil {
+			tx.Rollback()
+			return "", err
+		}
 	}
 
-	// 8. 创建订单记录
-	orderNo := soc.generateOrderNo(uid, params.GoodsId)
+	// 10. 创建订单记录
 	order := app_model.AppOrder{
-		UserId:     uid,
-		GoodsId:    params.GoodsId,
-		Num:        params.Num,
-		Amount:     totalPrice,
-		TenantsId:  goods.TenantsId,
-		Status:     orderStatus,
-		CreateTime: time.Now(),
-		UpdateTime: time.Now(),
-		No:         orderNo,
+		UserId:         uid,
+		GoodsId:        params.GoodsId,
+		Num:            params.Num,
+		Amount:         totalPrice,
+		CouponDiscount: couponDiscount,
+		PointsUsed:     params.UsePoints,
+		PointsDiscount: pointsDiscount,
+		TenantsId:      goods.TenantsId,
+		Status:         orderStatus,
+		CreateTime:     time.Now(),
+		UpdateTime:     time.Now(),
+		No:             orderNo,
 	}
 
 	if err := tx.Create(&order).Error; err != nil {
@@ -160,18 +200,18 @@ func (soc *SecureOrderCreator) CreateOrderSecurely(c *gin.Context, uid int, para
 		return "", fmt.Errorf("创建订单失败: %w", err)
 	}
 
-	// 9. 更新商家收入统计 (简化版)
+	// 11. 更新商家收入统计 (简化版)
 	if err := soc.updateSimpleStats(tx, &goods, &order); err != nil {
 		// 统计失败不阻塞订单创建，只记录日志
 		log.Printf("更新统计失败: %v", err)
 	}
 
-	// 10. 提交事务
+	// 12. 提交事务
 	if err := tx.Commit().Error; err != nil {
 		return "", fmt.Errorf("提交事务失败: %w", err)
 	}
 
-	// 11. 订单创建成功后才设置幂等性标记
+	// 13. 订单创建成功后才设置幂等性标记
 	if setErr := soc.idempotencyChecker.SetIdempotencyMark(idempotencyKey, 2*time.Minute); setErr != nil {
 		log.Printf("设置幂等性标记失败: %v", setErr)
 		// 这个失败不影响订单创建结果
@@ -179,10 +219,10 @@ func (soc *SecureOrderCreator) CreateOrderSecurely(c *gin.Context, uid int, para
 		log.Printf("✅ 已设置幂等性标记，防止重复下单: %s", idempotencyKey)
 	}
 
-	// 12. 异步处理后续流程
+	// 14. 异步处理后续流程
 	go soc.handlePostOrderCreation(&order, &goods, orderStatus)
 
-	// 13. 如果是待支付状态，设置超时取消
+	// 15. 如果是待支付状态，设置超时取消
 	if orderStatus == "pending" {
 		if err := soc.timeoutManager.ScheduleOrderTimeout(orderNo, 15*time.Minute); err != nil {
 			log.Printf("设置订单超时失败: %v", err)
@@ -212,15 +252,14 @@ func (soc *SecureOrderCreator) handlePostOrderCreation(order *app_model.AppOrder
 		}
 	}
 
-	// 2. 发送微信小程序消息
+	// 2. 发送微信小程序订阅消息，按订单状态匹配业务事件模板
 	if order.Id != 0 {
-		// 查询用户信息获取openid
-		var user app_model.UserApp
-		if err := db.Dao.Where("id = ?", order.UserId).First(&user).Error; err == nil {
-			err := miniapp_service.SendSubscribeMsg(user.Openid, OrderPaidTemplateID, strconv.Itoa(order.Id))
-			if err != nil {
-				log.Printf("发送小程序消息失败: %v, 订单ID: %d", err, order.Id)
-			}
+		event := miniapp_model.EventOrderCreated
+		if status == "paid" {
+			event = miniapp_model.EventOrderPaid
+		}
+		if _, err := SendUserSubscribeEvent(order.UserId, event, OrderEventPayload(order, nil)); err != nil {
+			log.Printf("发送小程序消息失败: %v, 订单ID: %d", err, order.Id)
 		}
 	}
 
@@ -329,6 +368,18 @@ func (soc *SecureOrderCreator) CancelExpiredOrder(orderNo string) error {
 		return fmt.Errorf("恢复库存失败: %w", err)
 	}
 
+	// 退回下单时使用的优惠券
+	if err := NewCouponService().ReturnCoupon(tx, app_model.CouponOrderGoods, orderNo); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// 退回下单时抵扣的积分
+	if err := NewMembershipService().RollbackPoints(tx, app_model.PointsBizGoods, orderNo); err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	// 提交事务
 	if err := tx.Commit().Error; err != nil {
 		return fmt.Errorf("提交取消事务失败: %w", err)
@@ -414,6 +465,12 @@ func (soc *SecureOrderCreator) ProcessPayment(orderNo string, amount float64) er
 		return fmt.Errorf("更新订单状态失败: %w", err)
 	}
 
+	// 累计消费并发放积分
+	if err := NewMembershipService().EarnPoints(tx, order.UserId, app_model.PointsBizGoods, order.No, order.Amount); err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	// 提交事务
 	if err := tx.Commit().Error; err != nil {
 		return fmt.Errorf("提交支付事务失败: %w", err)
@@ -471,16 +528,19 @@ func (soc *SecureOrderCreator) GetOrderDetail(c *gin.Context, uid int, id int) (
 
 	// 格式化时间字段
 	response := inout.OrderItem{
-		Id:         order.Id,
-		UserId:     order.UserId,
-		GoodsId:    order.GoodsId,
-		GoodsName:  goods.GoodsName,
-		GoodsPrice: goods.Price,
-		Num:        order.Num,
-		Amount:     order.Amount,
-		Status:     order.Status,
-		CreateTime: order.CreateTime.Format("2006-01-02 15:04:05"),
-		UpdateTime: order.UpdateTime.Format("2006-01-02 15:04:05"),
+		Id:             order.Id,
+		UserId:         order.UserId,
+		GoodsId:        order.GoodsId,
+		GoodsName:      goods.GoodsName,
+		GoodsPrice:     goods.Price,
+		Num:            order.Num,
+		Amount:         order.Amount,
+		CouponDiscount: order.CouponDiscount,
+		PointsUsed:     order.PointsUsed,
+		PointsDiscount: order.PointsDiscount,
+		Status:         order.Status,
+		CreateTime:     order.CreateTime.Format("2006-01-02 15:04:05"),
+		UpdateTime:     order.UpdateTime.Format("2006-01-02 15:04:05"),
 	}
 
 	return response, nil
@@ -534,16 +594,19 @@ func (soc *SecureOrderCreator) GetMyOrderList(c *gin.Context, uid int, params in
 		goods := goodsMap[item.GoodsId]
 
 		formattedData[i] = inout.OrderItem{
-			Id:         item.Id,
-			UserId:     item.UserId,
-			GoodsId:    item.GoodsId,
-			GoodsName:  goods.GoodsName,
-			GoodsPrice: goods.Price,
-			Num:        item.Num,
-			Amount:     item.Amount,
-			Status:     item.Status,
-			CreateTime: item.CreateTime.Format("2006-01-02 15:04:05"),
-			UpdateTime: item.UpdateTime.Format("2006-01-02 15:04:05"),
+			Id:             item.Id,
+			UserId:         item.UserId,
+			GoodsId:        item.GoodsId,
+			GoodsName:      goods.GoodsName,
+			GoodsPrice:     goods.Price,
+			Num:            item.Num,
+			Amount:         item.Amount,
+			CouponDiscount: item.CouponDiscount,
+			PointsUsed:     item.PointsUsed,
+			PointsDiscount: item.PointsDiscount,
+			Status:         item.Status,
+			CreateTime:     item.CreateTime.Format("2006-01-02 15:04:05"),
+			UpdateTime:     item.UpdateTime.Format("2006-01-02 15:04:05"),
 		}
 	}
 
@@ -0,0 +1,130 @@
+package app_service
+
+import (
+	"context"
+	"fmt"
+
+	"nasa-go-admin/db"
+	"nasa-go-admin/inout"
+	"nasa-go-admin/model/app_model"
+	"nasa-go-admin/model/miniapp_model"
+	"nasa-go-admin/services/miniapp_service"
+
+	"gorm.io/gorm"
+)
+
+// SubscribeEventService 业务事件订阅消息 - 按业务单据组装事件数据，推送给单据所属用户
+type SubscribeEventService struct {
+	ctx context.Context
+}
+
+// NewSubscribeEventService 创建业务事件订阅消息服务
+func NewSubscribeEventService() *SubscribeEventService {
+	return &SubscribeEventService{}
+}
+
+// WithContext 返回绑定请求上下文的服务，管理端传入 gin.Context 后按租户隔离业务单据
+func (ses *SubscribeEventService) WithContext(ctx context.Context) *SubscribeEventService {
+	return &SubscribeEventService{ctx: ctx}
+}
+
+// dao 获取数据库连接，带上下文时由 GORM 租户插件追加 tenants_id 条件
+func (ses *SubscribeEventService) dao() *gorm.DB {
+	if ses.ctx != nil {
+		return db.Dao.WithContext(ses.ctx)
+	}
+	return db.Dao
+}
+
+// BuildEventPayload 按业务单号加载事件数据，返回单据所属用户ID
+// 商品订单事件传订单号，预订事件传预订单号
+func (ses *SubscribeEventService) BuildEventPayload(event, bizNo string) (int, map[string]interface{}, error) {
+	switch event {
+	case miniapp_model.EventOrderCreated, miniapp_model.EventOrderPaid,
+		miniapp_model.EventOrderRefundApproved, miniapp_model.EventOrderRefundRejected:
+		var order app_model.AppOrder
+		if err := ses.dao().Where("no = ?", bizNo).First(&order).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return 0, nil, fmt.Errorf("订单不存在")
+			}
+			return 0, nil, fmt.Errorf("查询订单失败: %v", err)
+		}
+
+		var refund *app_model.OrderRefund
+		if event == miniapp_model.EventOrderRefundApproved || event == miniapp_model.EventOrderRefundRejected {
+			refund = &app_model.OrderRefund{}
+			if err := ses.dao().Where("order_id = ?", order.Id).Order("id DESC").First(refund).Error; err != nil {
+				if err == gorm.ErrRecordNotFound {
+					return 0, nil, fmt.Errorf("订单没有退款申请")
+				}
+				return 0, nil, fmt.Errorf("查询退款申请失败: %v", err)
+			}
+		}
+		return order.UserId, OrderEventPayload(&order, refund), nil
+
+	case miniapp_model.EventBookingPaymentPending, miniapp_model.EventBookingStartSoon,
+		miniapp_model.EventBookingEndingSoon, miniapp_model.EventBookingCompleted,
+		miniapp_model.EventBookingWaitlistOffer, miniapp_model.EventBookingRebooked,
+		miniapp_model.EventBookingVenueCancelled:
+		var booking app_model.RoomBooking
+		if err := ses.dao().Where("booking_no = ?", bizNo).First(&booking).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return 0, nil, fmt.Errorf("预订不存在")
+			}
+			return 0, nil, fmt.Errorf("查询预订失败: %v", err)
+		}
+		msg := NewBookingNotificationService().buildMessage(&booking, bookingNoticeEvents[event], nil)
+		return booking.UserID, msg.Payload, nil
+	}
+
+	return 0, nil, fmt.Errorf("不支持的业务事件: %s", event)
+}
+
+// SendEvent 按业务单号发送订阅消息，用户需已订阅事件对应的模板
+func (ses *SubscribeEventService) SendEvent(req *inout.SendSubscribeEventReq) (*inout.SendSubscribeEventResp, error) {
+	userID, payload, err := ses.BuildEventPayload(req.Event, req.BizNo)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := SendUserSubscribeEvent(userID, req.Event, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &inout.SendSubscribeEventResp{
+		Event:      req.Event,
+		UserID:     userID,
+		TemplateID: result.TemplateID,
+		Page:       result.Page,
+		Data:       result.Data,
+	}, nil
+}
+
+// SendUserSubscribeEvent 向用户发送业务事件订阅消息
+func SendUserSubscribeEvent(userID int, event string, payload map[string]interface{}) (*inout.PreviewSubscribeTemplateResp, error) {
+	var user app_model.UserApp
+	if err := db.Dao.Select("id, openid").Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, fmt.Errorf("查询用户失败: %v", err)
+	}
+	if user.Openid == "" {
+		return nil, fmt.Errorf("用户未绑定微信")
+	}
+
+	return miniapp_service.NewSubscribeTemplateService().SendEvent(user.Openid, event, payload)
+}
+
+// OrderEventPayload 商品订单事件数据
+func OrderEventPayload(order *app_model.AppOrder, refund *app_model.OrderRefund) map[string]interface{} {
+	var goods app_model.AppGoods
+	db.Dao.Select("id, goods_name, price, cover").First(&goods, order.GoodsId)
+
+	objects := map[string]interface{}{
+		"order": order,
+		"goods": &goods,
+	}
+	if refund != nil {
+		objects["refund"] = refund
+	}
+	return miniapp_service.EventPayload(objects)
+}
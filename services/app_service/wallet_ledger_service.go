@@ -0,0 +1,258 @@
+package app_service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"time"
+
+	"nasa-go-admin/db"
+	"nasa-go-admin/model/app_model"
+
+	"gorm.io/gorm"
+)
+
+// ErrLedgerDuplicate 幂等键已记账
+var ErrLedgerDuplicate = errors.New("该业务已记账，拒绝重复记账")
+
+// ledgerDriftThreshold 对账差异阈值（元）
+const ledgerDriftThreshold = 0.01
+
+// ledgerPostingRule 交易类型对应的借贷科目
+type ledgerPostingRule struct {
+	Debit  string
+	Credit string
+}
+
+// ledgerPostingRules 钱包交易类型记账规则
+var ledgerPostingRules = map[string]ledgerPostingRule{
+	app_model.TransactionTypeRecharge:       {app_model.AccountPlatformCash, app_model.AccountUserWallet},
+	app_model.TransactionTypeRechargeBonus:  {app_model.AccountMarketing, app_model.AccountUserWallet},
+	app_model.TransactionTypeOrderPayment:   {app_model.AccountUserWallet, app_model.AccountGoodsRevenue},
+	app_model.TransactionTypeBookingPayment: {app_model.AccountUserWallet, app_model.AccountBookingRevenue},
+	app_model.TransactionTypeBookingExtend:  {app_model.AccountUserWallet, app_model.AccountBookingRevenue},
+	app_model.TransactionTypeBookingExtra:   {app_model.AccountUserWallet, app_model.AccountBookingRevenue},
+	app_model.TransactionTypeBookingAdjust:  {app_model.AccountUserWallet, app_model.AccountBookingRevenue},
+	app_model.TransactionTypeBookingOffline: {app_model.AccountPlatformCash, app_model.AccountBookingRevenue},
+	app_model.TransactionTypeOrderRefund:    {app_model.AccountGoodsRevenue, app_model.AccountUserWallet},
+	app_model.TransactionTypeBookingRefund:  {app_model.AccountBookingRevenue, app_model.AccountUserWallet},
+	app_model.TransactionTypeAdjustRefund:   {app_model.AccountBookingRevenue, app_model.AccountUserWallet},
+	app_model.TransactionTypeSystemRefund:   {app_model.AccountCompensation, app_model.AccountUserWallet},
+	app_model.TransactionTypeOpeningBalance: {app_model.AccountOpeningBalance, app_model.AccountUserWallet},
+}
+
+// WalletDrift 单个用户的对账差异
+type WalletDrift struct {
+	UserID        int     `json:"user_id"`
+	WalletBalance float64 `json:"wallet_balance"`
+	LedgerBalance float64 `json:"ledger_balance"`
+	Drift         float64 `json:"drift"`
+}
+
+// WalletReconcileReport 钱包对账报告
+type WalletReconcileReport struct {
+	BatchNo      string        `json:"batch_no"`
+	CheckedUsers int           `json:"checked_users"`
+	DriftUsers   int           `json:"drift_users"`
+	TotalDrift   float64       `json:"total_drift"`
+	TotalDebit   float64       `json:"total_debit"`
+	TotalCredit  float64       `json:"total_credit"`
+	Balanced     bool          `json:"balanced"` // 借贷是否平衡
+	Drifts       []WalletDrift `json:"drifts"`
+	StartTime    time.Time     `json:"start_time"`
+	EndTime      time.Time     `json:"end_time"`
+}
+
+// WalletLedgerService 钱包复式记账服务
+type WalletLedgerService struct{}
+
+// NewWalletLedgerService 创建钱包记账服务
+func NewWalletLedgerService() *WalletLedgerService {
+	return &WalletLedgerService{}
+}
+
+// WalletIdempotencyKey 生成钱包记账幂等键，有业务单号时按单号去重
+func WalletIdempotencyKey(transactionType, orderNo string, rechargeID int) string {
+	if orderNo != "" {
+		return fmt.Sprintf("%s:%s", transactionType, orderNo)
+	}
+	return fmt.Sprintf("%s:recharge:%d", transactionType, rechargeID)
+}
+
+// PostWalletTransaction 按钱包流水记账，必须与修改钱包余额在同一事务内调用
+// 幂等键已存在时返回已有凭证和 ErrLedgerDuplicate，调用方应回滚事务
+func (s *WalletLedgerService) PostWalletTransaction(tx *gorm.DB, record *app_model.AppRecharge, idempotencyKey string) (*app_model.WalletJournal, error) {
+	rule, ok := ledgerPostingRules[record.TransactionType]
+	if !ok {
+		return nil, fmt.Errorf("未配置记账规则的交易类型: %s", record.TransactionType)
+	}
+
+	amount := math.Round(record.Amount*100) / 100
+	if amount <= 0 {
+		return nil, fmt.Errorf("记账金额必须大于0")
+	}
+
+	// 幂等检查
+	var existing app_model.WalletJournal
+	err := tx.Where("idempotency_key = ?", idempotencyKey).First(&existing).Error
+	if err == nil {
+		return &existing, ErrLedgerDuplicate
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("查询记账凭证失败: %w", err)
+	}
+
+	journal := app_model.WalletJournal{
+		JournalNo:      s.generateJournalNo(),
+		IdempotencyKey: idempotencyKey,
+		UserID:         record.UserID,
+		BizType:        record.TransactionType,
+		BizNo:          record.OrderNo,
+		Amount:         amount,
+		Remark:         record.Remark,
+	}
+	if record.ID > 0 {
+		journal.RechargeID = &record.ID
+	}
+
+	if err := tx.Create(&journal).Error; err != nil {
+		return nil, fmt.Errorf("创建记账凭证失败: %w", err)
+	}
+
+	entries := []app_model.WalletJournalEntry{
+		{
+			JournalID:   journal.ID,
+			AccountCode: rule.Debit,
+			UserID:      s.accountUserID(rule.Debit, record.UserID),
+			Direction:   app_model.LedgerDebit,
+			Amount:      amount,
+		},
+		{
+			JournalID:   journal.ID,
+			AccountCode: rule.Credit,
+			UserID:      s.accountUserID(rule.Credit, record.UserID),
+			Direction:   app_model.LedgerCredit,
+			Amount:      amount,
+		},
+	}
+	if err := tx.Create(&entries).Error; err != nil {
+		return nil, fmt.Errorf("创建记账分录失败: %w", err)
+	}
+
+	return &journal, nil
+}
+
+// GetLedgerBalance 根据账本计算用户钱包余额
+func (s *WalletLedgerService) GetLedgerBalance(userID int) (float64, error) {
+	var balance float64
+	err := db.Dao.Model(&app_model.WalletJournalEntry{}).
+		Select("COALESCE(SUM(CASE WHEN direction = ? THEN amount ELSE -amount END), 0)", app_model.LedgerCredit).
+		Where("account_code = ? AND user_id = ?", app_model.AccountUserWallet, userID).
+		Scan(&balance).Error
+	if err != nil {
+		return 0, fmt.Errorf("计算账本余额失败: %w", err)
+	}
+	return math.Round(balance*100) / 100, nil
+}
+
+// ReconcileWallets 按用户汇总账本余额并与 app_wallet 比对，差异写入对账记录
+func (s *WalletLedgerService) ReconcileWallets() (*WalletReconcileReport, error) {
+	report := &WalletReconcileReport{
+		BatchNo:   fmt.Sprintf("RC%s", time.Now().Format("20060102150405")),
+		Drifts:    make([]WalletDrift, 0),
+		StartTime: time.Now(),
+	}
+
+	// 单条语句读取，保证钱包余额与账本处于同一快照
+	query := `
+		SELECT w.user_id, w.money AS wallet_balance, COALESCE(l.balance, 0) AS ledger_balance
+		FROM app_wallet w
+		LEFT JOIN (
+			SELECT user_id, SUM(CASE WHEN direction = 'credit' THEN amount ELSE -amount END) AS balance
+			FROM wallet_journal_entries
+			WHERE account_code = ?
+			GROUP BY user_id
+		) l ON l.user_id = w.user_id
+		UNION ALL
+		SELECT l.user_id, 0 AS wallet_balance, l.balance AS ledger_balance
+		FROM (
+			SELECT user_id, SUM(CASE WHEN direction = 'credit' THEN amount ELSE -amount END) AS balance
+			FROM wallet_journal_entries
+			WHERE account_code = ?
+			GROUP BY user_id
+		) l
+		WHERE NOT EXISTS (SELECT 1 FROM app_wallet w WHERE w.user_id = l.user_id)
+	`
+
+	var rows []WalletDrift
+	if err := db.Dao.Raw(query, app_model.AccountUserWallet, app_model.AccountUserWallet).
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("查询对账数据失败: %w", err)
+	}
+
+	report.CheckedUsers = len(rows)
+	records := make([]app_model.WalletReconcileRecord, 0)
+	for _, row := range rows {
+		drift := math.Round((row.WalletBalance-row.LedgerBalance)*100) / 100
+		if math.Abs(drift) < ledgerDriftThreshold {
+			continue
+		}
+
+		row.Drift = drift
+		report.Drifts = append(report.Drifts, row)
+		report.TotalDrift += drift
+		records = append(records, app_model.WalletReconcileRecord{
+			BatchNo:       report.BatchNo,
+			UserID:        row.UserID,
+			WalletBalance: row.WalletBalance,
+			LedgerBalance: row.LedgerBalance,
+			Drift:         drift,
+		})
+	}
+	report.DriftUsers = len(report.Drifts)
+	report.TotalDrift = math.Round(report.TotalDrift*100) / 100
+
+	// 试算平衡：全部分录借贷合计必须相等
+	var totals struct {
+		TotalDebit  float64
+		TotalCredit float64
+	}
+	if err := db.Dao.Model(&app_model.WalletJournalEntry{}).
+		Select("COALESCE(SUM(CASE WHEN direction = ? THEN amount ELSE 0 END), 0) AS total_debit, "+
+			"COALESCE(SUM(CASE WHEN direction = ? THEN amount ELSE 0 END), 0) AS total_credit",
+			app_model.LedgerDebit, app_model.LedgerCredit).
+		Scan(&totals).Error; err != nil {
+		return nil, fmt.Errorf("试算平衡失败: %w", err)
+	}
+	report.TotalDebit = math.Round(totals.TotalDebit*100) / 100
+	report.TotalCredit = math.Round(totals.TotalCredit*100) / 100
+	report.Balanced = math.Abs(report.TotalDebit-report.TotalCredit) < ledgerDriftThreshold
+
+	if len(records) > 0 {
+		if err := db.Dao.CreateInBatches(&records, 100).Error; err != nil {
+			log.Printf("保存对账差异记录失败: %v", err)
+		}
+	}
+
+	report.EndTime = time.Now()
+	log.Printf("✅ 钱包对账完成 批次:%s 用户数:%d 差异用户:%d 差异合计:%.2f 借贷平衡:%v",
+		report.BatchNo, report.CheckedUsers, report.DriftUsers, report.TotalDrift, report.Balanced)
+
+	return report, nil
+}
+
+// accountUserID 用户钱包科目记录用户ID，平台科目为0
+func (s *WalletLedgerService) accountUserID(accountCode string, userID int) int {
+	if accountCode == app_model.AccountUserWallet {
+		return userID
+	}
+	return 0
+}
+
+// generateJournalNo 生成凭证号
+func (s *WalletLedgerService) generateJournalNo() string {
+	now := time.Now()
+	return fmt.Sprintf("JN%s%09d%04d", now.Format("20060102150405"), now.Nanosecond(), rand.Intn(10000))
+}
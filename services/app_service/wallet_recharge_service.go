@@ -0,0 +1,294 @@
+package app_service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"time"
+
+	"nasa-go-admin/db"
+	"nasa-go-admin/inout"
+	"nasa-go-admin/model/app_model"
+	"nasa-go-admin/pkg/payment"
+	"nasa-go-admin/redis"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RechargeService 钱包充值服务 - 通过支付渠道充值，回调验签后入账
+type RechargeService struct{}
+
+// NewRechargeService 创建钱包充值服务
+func NewRechargeService() *RechargeService {
+	return &RechargeService{}
+}
+
+// CreateRecharge 创建待支付充值单并向支付渠道预下单
+func (s *RechargeService) CreateRecharge(uid int, amount float64) (*inout.RechargeResp, error) {
+	provider, err := payment.GetProvider()
+	if err != nil {
+		log.Printf("支付渠道不可用: %v", err)
+		return nil, fmt.Errorf("支付暂不可用，请稍后再试")
+	}
+
+	amountFen := int64(math.Round(amount * 100))
+	if amountFen <= 0 {
+		return nil, fmt.Errorf("充值金额必须大于0")
+	}
+
+	var user app_model.UserApp
+	if err := db.Dao.Select("id, openid").First(&user, uid).Error; err != nil {
+		return nil, fmt.Errorf("查询用户信息失败: %w", err)
+	}
+
+	now := time.Now()
+	recharge := app_model.AppRecharge{
+		UserID:          uid,
+		OrderNo:         s.generateRechargeNo(uid),
+		TransactionType: app_model.TransactionTypeRecharge,
+		Amount:          float64(amountFen) / 100,
+		Status:          app_model.RechargeStatusPending,
+		PaymentMethod:   provider.Name(),
+		Remark:          "用户充值",
+		CreateTime:      now,
+		UpdateTime:      now,
+	}
+	if err := db.Dao.Create(&recharge).Error; err != nil {
+		return nil, fmt.Errorf("创建充值单失败: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	prepay, err := provider.Prepay(ctx, &payment.PrepayRequest{
+		OutTradeNo:  recharge.OrderNo,
+		Description: "钱包充值",
+		AmountFen:   amountFen,
+		OpenID:      user.Openid,
+	})
+	if err != nil {
+		// 预下单失败的充值单不会再收到回调，直接置为失败
+		if updateErr := db.Dao.Model(&app_model.AppRecharge{}).
+			Where("id = ? AND status = ?", recharge.ID, app_model.RechargeStatusPending).
+			Updates(map[string]interface{}{
+				"status":      app_model.RechargeStatusFailed,
+				"update_time": time.Now(),
+			}).Error; updateErr != nil {
+			log.Printf("更新充值单状态失败 %s: %v", recharge.OrderNo, updateErr)
+		}
+		log.Printf("充值预下单失败 %s: %v", recharge.OrderNo, err)
+		return nil, fmt.Errorf("发起支付失败，请稍后再试")
+	}
+
+	log.Printf("充值单已创建: %s (用户ID: %d, 金额: %.2f, 渠道: %s)", recharge.OrderNo, uid, recharge.Amount, provider.Name())
+
+	return &inout.RechargeResp{
+		OrderNo:       recharge.OrderNo,
+		Amount:        recharge.Amount,
+		Status:        recharge.Status,
+		PaymentMethod: recharge.PaymentMethod,
+		PrepayID:      prepay.PrepayID,
+		PayParams:     prepay.PayParams,
+	}, nil
+}
+
+// GetRechargeStatus 查询充值单状态（前端支付完成后轮询）
+func (s *RechargeService) GetRechargeStatus(uid int, orderNo string) (*inout.RechargeResp, error) {
+	var recharge app_model.AppRecharge
+	if err := db.Dao.Where("order_no = ? AND user_id = ? AND transaction_type = ?",
+		orderNo, uid, app_model.TransactionTypeRecharge).First(&recharge).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("充值单不存在")
+		}
+		return nil, fmt.Errorf("查询充值单失败: %w", err)
+	}
+
+	return &inout.RechargeResp{
+		OrderNo:       recharge.OrderNo,
+		Amount:        recharge.Amount,
+		Status:        recharge.Status,
+		PaymentMethod: recharge.PaymentMethod,
+	}, nil
+}
+
+// HandlePaymentNotify 处理已验签的支付回调，重复回调幂等返回成功
+func (s *RechargeService) HandlePaymentNotify(providerName string, result *payment.NotifyResult) error {
+	securityService := NewSecurityOrderService(redis.GetClient())
+
+	tx := db.Dao.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			log.Printf("处理充值回调时发生panic: %v", r)
+			panic(r)
+		}
+	}()
+
+	// 锁定充值单
+	var recharge app_model.AppRecharge
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_no = ? AND transaction_type = ?", result.OutTradeNo, app_model.TransactionTypeRecharge).
+		First(&recharge).Error; err != nil {
+		tx.Rollback()
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("充值单不存在: %s", result.OutTradeNo)
+		}
+		return fmt.Errorf("查询充值单失败: %w", err)
+	}
+
+	action, err := decideRechargeNotify(&recharge, providerName, result)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if action == rechargeNotifyIgnore {
+		tx.Rollback()
+		return nil
+	}
+
+	now := time.Now()
+
+	// 支付未成功
+	if action == rechargeNotifyFail {
+		if err := tx.Model(&app_model.AppRecharge{}).
+			Where("id = ? AND status = ?", recharge.ID, app_model.RechargeStatusPending).
+			Updates(map[string]interface{}{
+				"status":      app_model.RechargeStatusFailed,
+				"payment_no":  result.TransactionID,
+				"update_time": now,
+			}).Error; err != nil {
+			tx.Rollback()
+			return fmt.Errorf("更新充值单状态失败: %w", err)
+		}
+		if err := tx.Commit().Error; err != nil {
+			return fmt.Errorf("提交充值回调事务失败: %w", err)
+		}
+		log.Printf("充值单 %s 支付未成功: %s", recharge.OrderNo, result.TradeState)
+		return nil
+	}
+
+	// 入账
+	walletAfterCredit, err := securityService.SafeCreditWallet(tx, recharge.UserID, recharge.Amount)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	updateResult := tx.Model(&app_model.AppRecharge{}).
+		Where("id = ? AND status = ?", recharge.ID, app_model.RechargeStatusPending).
+		Updates(map[string]interface{}{
+			"status":         app_model.RechargeStatusCompleted,
+			"payment_no":     result.TransactionID,
+			"balance_before": walletAfterCredit.Money - recharge.Amount,
+			"balance_after":  walletAfterCredit.Money,
+			"complete_time":  now,
+			"update_time":    now,
+		})
+	if updateResult.Error != nil {
+		tx.Rollback()
+		return fmt.Errorf("更新充值单状态失败: %w", updateResult.Error)
+	}
+	if updateResult.RowsAffected == 0 {
+		tx.Rollback()
+		return fmt.Errorf("充值单 %s 状态已变更", recharge.OrderNo)
+	}
+
+	recharge.Status = app_model.RechargeStatusCompleted
+	recharge.PaymentNo = result.TransactionID
+	recharge.BalanceBefore = walletAfterCredit.Money - recharge.Amount
+	recharge.BalanceAfter = walletAfterCredit.Money
+
+	// 同一事务内记账
+	if _, err := NewWalletLedgerService().PostWalletTransaction(tx, &recharge,
+		WalletIdempotencyKey(recharge.TransactionType, recharge.OrderNo, recharge.ID)); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("钱包记账失败: %w", err)
+	}
+
+	// 充值赠送（与充值入账同一事务，赠送流水使用同一充值单号）
+	bonus, err := s.grantRechargeBonus(tx, securityService, &recharge, now)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("提交充值回调事务失败: %w", err)
+	}
+
+	log.Printf("✅ 充值入账成功: %s (用户ID: %d, 金额: %.2f, 赠送: %.2f, 交易号: %s)",
+		recharge.OrderNo, recharge.UserID, recharge.Amount, bonus, result.TransactionID)
+	return nil
+}
+
+// rechargeNotifyAction 充值回调处理动作
+type rechargeNotifyAction int
+
+const (
+	rechargeNotifyIgnore rechargeNotifyAction = iota // 已处理过的回调，幂等忽略
+	rechargeNotifyFail                               // 支付未成功，充值单置为失败
+	rechargeNotifyCredit                             // 支付成功，入账
+)
+
+// decideRechargeNotify 根据锁定后的充值单决定回调处理动作，充值单已入账或已关闭时忽略重复回调
+func decideRechargeNotify(recharge *app_model.AppRecharge, providerName string, result *payment.NotifyResult) (rechargeNotifyAction, error) {
+	// 重复回调
+	if recharge.Status == app_model.RechargeStatusCompleted {
+		log.Printf("充值单 %s 已入账，忽略重复回调", recharge.OrderNo)
+		return rechargeNotifyIgnore, nil
+	}
+
+	if recharge.Status != app_model.RechargeStatusPending {
+		log.Printf("⚠️ 充值单 %s 状态为 %s，收到渠道回调 %s (交易号: %s)",
+			recharge.OrderNo, recharge.Status, result.TradeState, result.TransactionID)
+		return rechargeNotifyIgnore, nil
+	}
+
+	if recharge.PaymentMethod != providerName {
+		return rechargeNotifyIgnore, fmt.Errorf("充值单 %s 支付渠道不匹配: %s", recharge.OrderNo, providerName)
+	}
+
+	if !result.Success {
+		return rechargeNotifyFail, nil
+	}
+
+	// 校验实付金额
+	if int64(math.Round(recharge.Amount*100)) != result.AmountFen {
+		return rechargeNotifyIgnore, fmt.Errorf("充值单 %s 金额不一致: 订单 %.2f 元, 回调 %d 分",
+			recharge.OrderNo, recharge.Amount, result.AmountFen)
+	}
+
+	return rechargeNotifyCredit, nil
+}
+
+// grantRechargeBonus 按充值赠送规则向钱包入账赠送金额，返回赠送金额
+func (s *RechargeService) grantRechargeBonus(tx *gorm.DB, securityService *SecurityOrderService,
+	recharge *app_model.AppRecharge, now time.Time) (float64, error) {
+
+	rule, err := NewRechargeBonusService().MatchRule(tx, recharge.Amount, now)
+	if err != nil || rule == nil {
+		return 0, err
+	}
+
+	walletAfterCredit, err := securityService.SafeCreditWallet(tx, recharge.UserID, rule.BonusAmount)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := securityService.RecordWalletTransactionWithType(tx, recharge.UserID,
+		app_model.TransactionTypeRechargeBonus, recharge.OrderNo, rule.BonusAmount,
+		walletAfterCredit.Money-rule.BonusAmount, walletAfterCredit.Money,
+		fmt.Sprintf("充值赠送[%s]", rule.Name)); err != nil {
+		return 0, err
+	}
+
+	return rule.BonusAmount, nil
+}
+
+// generateRechargeNo 生成充值单号
+func (s *RechargeService) generateRechargeNo(uid int) string {
+	return fmt.Sprintf("RC%s%04d%04d", time.Now().Format("20060102150405"), uid%10000, rand.Intn(10000))
+}
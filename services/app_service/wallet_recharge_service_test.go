@@ -0,0 +1,66 @@
+package app_service
+
+import (
+	"testing"
+
+	"nasa-go-admin/model/app_model"
+	"nasa-go-admin/pkg/payment"
+)
+
+func TestDecideRechargeNotify(t *testing.T) {
+	pending := func() *app_model.AppRecharge {
+		return &app_model.AppRecharge{
+			OrderNo:       "RC001",
+			Amount:        100,
+			Status:        app_model.RechargeStatusPending,
+			PaymentMethod: payment.ProviderWeChat,
+		}
+	}
+	success := &payment.NotifyResult{OutTradeNo: "RC001", TransactionID: "T001", AmountFen: 10000, Success: true, TradeState: "SUCCESS"}
+
+	tests := []struct {
+		name       string
+		status     string
+		provider   string
+		result     *payment.NotifyResult
+		wantAction rechargeNotifyAction
+		wantErr    bool
+	}{
+		{"待支付且支付成功入账", app_model.RechargeStatusPending, payment.ProviderWeChat, success, rechargeNotifyCredit, false},
+		{"已入账的重复回调忽略", app_model.RechargeStatusCompleted, payment.ProviderWeChat, success, rechargeNotifyIgnore, false},
+		{"已失败的充值单忽略", app_model.RechargeStatusFailed, payment.ProviderWeChat, success, rechargeNotifyIgnore, false},
+		{"支付未成功置为失败", app_model.RechargeStatusPending, payment.ProviderWeChat,
+			&payment.NotifyResult{OutTradeNo: "RC001", AmountFen: 10000, TradeState: "PAYERROR"}, rechargeNotifyFail, false},
+		{"支付渠道不匹配", app_model.RechargeStatusPending, payment.ProviderFake, success, rechargeNotifyIgnore, true},
+		{"金额不一致", app_model.RechargeStatusPending, payment.ProviderWeChat,
+			&payment.NotifyResult{OutTradeNo: "RC001", AmountFen: 9999, Success: true}, rechargeNotifyIgnore, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recharge := pending()
+			recharge.Status = tt.status
+			action, err := decideRechargeNotify(recharge, tt.provider, tt.result)
+			if action != tt.wantAction || (err != nil) != tt.wantErr {
+				t.Errorf("decideRechargeNotify() = (%v, %v), want (%v, wantErr %v)", action, err, tt.wantAction, tt.wantErr)
+			}
+		})
+	}
+
+	// 同一回调投递两次只入账一次：首次入账后充值单为已入账，第二次忽略
+	recharge := pending()
+	credited := 0
+	for i := 0; i < 2; i++ {
+		action, err := decideRechargeNotify(recharge, payment.ProviderWeChat, success)
+		if err != nil {
+			t.Fatalf("第 %d 次回调 error = %v", i+1, err)
+		}
+		if action == rechargeNotifyCredit {
+			credited++
+			recharge.Status = app_model.RechargeStatusCompleted
+		}
+	}
+	if credited != 1 {
+		t.Errorf("重复回调入账次数 = %d, want 1", credited)
+	}
+}
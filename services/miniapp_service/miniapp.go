@@ -72,6 +72,22 @@ func SaveUserSubscription(openID, templateID string) error {
 	return db.Dao.Create(&subscription).Error
 }
 
+// IsUserSubscribed 用户是否已订阅指定模板的消息
+func IsUserSubscribed(openID, templateID string) bool {
+	if openID == "" || templateID == "" {
+		return false
+	}
+
+	var count int64
+	if err := db.Dao.Model(&miniapp_model.UserSubscription{}).
+		Where("open_id = ? AND template_id = ? AND status = ?", openID, templateID, 1).
+		Count(&count).Error; err != nil {
+		log.Printf("查询用户订阅失败: %v", err)
+		return false
+	}
+	return count > 0
+}
+
 // GetWXConfig 从数据库获取微信小程序配置
 func GetWXConfig() (*WXConfig, error) {
 	var err error
@@ -241,11 +257,26 @@ func SendSubscribeMsg(openID, templateID string, id string) error {
 	// 组装推送数据，根据模板ID区分不同类型的推送内容
 	data := generateMsgData(templateID)
 
+	return sendSubscribeData(accessToken, openID, templateID, "pages/index/index", data)
+}
+
+// SendSubscribeMsgWithData 使用业务数据发送订阅消息，page 为用户点击消息后跳转的页面
+func SendSubscribeMsgWithData(openID, templateID, page string, data map[string]map[string]string) error {
+	accessToken, err := GetAccessToken()
+	if err != nil {
+		return err
+	}
+
+	return sendSubscribeData(accessToken, openID, templateID, page, data)
+}
+
+// sendSubscribeData 调用微信订阅消息接口并记录推送历史
+func sendSubscribeData(accessToken, openID, templateID, page string, data map[string]map[string]string) error {
 	url := fmt.Sprintf("https://api.weixin.qq.com/cgi-bin/message/subscribe/send?access_token=%s", accessToken)
 	payload := map[string]interface{}{
 		"touser":      openID,
 		"template_id": templateID,
-		"page":        "pages/index/index", // 用户点击消息后跳转的页面
+		"page":        page, // 用户点击消息后跳转的页面
 		"data":        data,
 	}
 
@@ -0,0 +1,545 @@
+package miniapp_service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"nasa-go-admin/db"
+	"nasa-go-admin/inout"
+	"nasa-go-admin/model/app_model"
+	"nasa-go-admin/model/miniapp_model"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrSubscribeTemplateNotFound 业务事件未配置启用的订阅消息模板
+	ErrSubscribeTemplateNotFound = errors.New("业务事件未配置订阅消息模板")
+	// ErrUserNotSubscribed 用户未订阅模板消息
+	ErrUserNotSubscribed = errors.New("用户未订阅该模板消息")
+)
+
+// subscribeEventNames 业务事件名称
+var subscribeEventNames = map[string]string{
+	miniapp_model.EventOrderCreated:          "商品订单待支付",
+	miniapp_model.EventOrderPaid:             "商品订单支付成功",
+	miniapp_model.EventOrderRefundApproved:   "退款申请已同意",
+	miniapp_model.EventOrderRefundRejected:   "退款申请已拒绝",
+	miniapp_model.EventBookingPaymentPending: "预订待支付提醒",
+	miniapp_model.EventBookingStartSoon:      "预订即将开始",
+	miniapp_model.EventBookingEndingSoon:     "预订即将结束",
+	miniapp_model.EventBookingCompleted:      "预订完成回执",
+	miniapp_model.EventBookingWaitlistOffer:  "候补时段已保留",
+	miniapp_model.EventBookingRebooked:       "预订房间已调换",
+	miniapp_model.EventBookingVenueCancelled: "预订因商家原因取消",
+}
+
+// subscribeExprPattern 模板表达式 {{对象.字段}} 或 {{对象.字段|格式}}
+var subscribeExprPattern = regexp.MustCompile(`\{\{\s*([a-z_]+)((?:\.[a-z0-9_]+)+)\s*(?:\|\s*([a-z]+)\s*)?\}\}`)
+
+// subscribeKeywordPattern 模板关键词，如 thing1、character_string2
+var subscribeKeywordPattern = regexp.MustCompile(`^([a-z_]+?)(\d+)$`)
+
+// subscribeFormatters 表达式支持的格式
+var subscribeFormatters = map[string]func(interface{}) string{
+	"datetime": func(v interface{}) string { return formatSubscribeTime(v, "2006-01-02 15:04") },
+	"date":     func(v interface{}) string { return formatSubscribeTime(v, "2006-01-02") },
+	"time":     func(v interface{}) string { return formatSubscribeTime(v, "15:04") },
+	"amount": func(v interface{}) string {
+		if f, ok := v.(float64); ok {
+			return fmt.Sprintf("%.2f", f)
+		}
+		return formatSubscribeValue(v)
+	},
+}
+
+// subscribeKeywordLimits 微信订阅消息各类型关键词的最大长度（字符数）
+var subscribeKeywordLimits = map[string]int{
+	"thing":            20,
+	"character_string": 32,
+	"number":           32,
+	"letter":           32,
+	"symbol":           5,
+	"phrase":           5,
+	"name":             10,
+	"phone_number":     17,
+	"car_number":       8,
+}
+
+// SubscribeTemplateService 订阅消息模板配置服务
+type SubscribeTemplateService struct{}
+
+// NewSubscribeTemplateService 创建订阅消息模板配置服务
+func NewSubscribeTemplateService() *SubscribeTemplateService {
+	return &SubscribeTemplateService{}
+}
+
+// CreateTemplate 创建订阅消息模板，每个业务事件只能配置一个模板
+func (s *SubscribeTemplateService) CreateTemplate(req *inout.CreateSubscribeTemplateReq) (*inout.SubscribeTemplateItem, error) {
+	if err := validateSubscribeTemplate(req.Event, req.Page, req.Fields); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := db.Dao.Model(&miniapp_model.SubscribeTemplate{}).Where("event = ?", req.Event).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("查询模板失败: %v", err)
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("业务事件 %s 已配置模板", req.Event)
+	}
+
+	fields, _ := json.Marshal(req.Fields)
+	tpl := miniapp_model.SubscribeTemplate{
+		Event:      req.Event,
+		TemplateID: req.TemplateID,
+		Title:      req.Title,
+		Page:       req.Page,
+		Fields:     string(fields),
+		Status:     1,
+		Remark:     req.Remark,
+	}
+	if req.Status != nil {
+		tpl.Status = *req.Status
+	}
+
+	if err := db.Dao.Create(&tpl).Error; err != nil {
+		return nil, fmt.Errorf("创建模板失败: %v", err)
+	}
+	return s.convertToItem(&tpl), nil
+}
+
+// UpdateTemplate 更新订阅消息模板
+func (s *SubscribeTemplateService) UpdateTemplate(req *inout.UpdateSubscribeTemplateReq) (*inout.SubscribeTemplateItem, error) {
+	if err := validateSubscribeTemplate(req.Event, req.Page, req.Fields); err != nil {
+		return nil, err
+	}
+
+	var tpl miniapp_model.SubscribeTemplate
+	if err := db.Dao.First(&tpl, req.ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("模板不存在")
+		}
+		return nil, fmt.Errorf("查询模板失败: %v", err)
+	}
+
+	var count int64
+	if err := db.Dao.Model(&miniapp_model.SubscribeTemplate{}).
+		Where("event = ? AND id != ?", req.Event, req.ID).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("查询模板失败: %v", err)
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("业务事件 %s 已配置模板", req.Event)
+	}
+
+	fields, _ := json.Marshal(req.Fields)
+	tpl.Event = req.Event
+	tpl.TemplateID = req.TemplateID
+	tpl.Title = req.Title
+	tpl.Page = req.Page
+	tpl.Fields = string(fields)
+	tpl.Status = req.Status
+	tpl.Remark = req.Remark
+
+	if err := db.Dao.Save(&tpl).Error; err != nil {
+		return nil, fmt.Errorf("更新模板失败: %v", err)
+	}
+	return s.convertToItem(&tpl), nil
+}
+
+// DeleteTemplate 删除订阅消息模板
+func (s *SubscribeTemplateService) DeleteTemplate(id int) error {
+	result := db.Dao.Delete(&miniapp_model.SubscribeTemplate{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("删除模板失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("模板不存在")
+	}
+	return nil
+}
+
+// GetTemplateList 获取订阅消息模板列表
+func (s *SubscribeTemplateService) GetTemplateList(req *inout.SubscribeTemplateListReq) (*inout.SubscribeTemplateListResp, error) {
+	query := db.Dao.Model(&miniapp_model.SubscribeTemplate{})
+	if req.Event != "" {
+		query = query.Where("event = ?", req.Event)
+	}
+	if req.Status != nil {
+		query = query.Where("status = ?", *req.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("查询模板总数失败: %v", err)
+	}
+
+	var templates []miniapp_model.SubscribeTemplate
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Order("id ASC").Offset(offset).Limit(req.PageSize).Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("查询模板列表失败: %v", err)
+	}
+
+	list := make([]inout.SubscribeTemplateItem, 0, len(templates))
+	for i := range templates {
+		list = append(list, *s.convertToItem(&templates[i]))
+	}
+
+	return &inout.SubscribeTemplateListResp{
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		List:     list,
+	}, nil
+}
+
+// GetEventList 获取可配置的业务事件及其可引用的数据对象
+func (s *SubscribeTemplateService) GetEventList() ([]inout.SubscribeEventItem, error) {
+	var configured []string
+	if err := db.Dao.Model(&miniapp_model.SubscribeTemplate{}).
+		Where("status = ?", 1).Pluck("event", &configured).Error; err != nil {
+		return nil, fmt.Errorf("查询模板失败: %v", err)
+	}
+	configuredSet := make(map[string]bool, len(configured))
+	for _, event := range configured {
+		configuredSet[event] = true
+	}
+
+	events := make([]string, 0, len(miniapp_model.SubscribeEventScopes))
+	for event := range miniapp_model.SubscribeEventScopes {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+
+	list := make([]inout.SubscribeEventItem, 0, len(events))
+	for _, event := range events {
+		list = append(list, inout.SubscribeEventItem{
+			Event:      event,
+			Name:       subscribeEventNames[event],
+			Scopes:     miniapp_model.SubscribeEventScopes[event],
+			Configured: configuredSet[event],
+		})
+	}
+	return list, nil
+}
+
+// GetTemplate 按ID获取订阅消息模板
+func (s *SubscribeTemplateService) GetTemplate(id int) (*miniapp_model.SubscribeTemplate, error) {
+	var tpl miniapp_model.SubscribeTemplate
+	if err := db.Dao.First(&tpl, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("模板不存在")
+		}
+		return nil, fmt.Errorf("查询模板失败: %v", err)
+	}
+	return &tpl, nil
+}
+
+// GetEventTemplate 获取业务事件启用的订阅消息模板
+func (s *SubscribeTemplateService) GetEventTemplate(event string) (*miniapp_model.SubscribeTemplate, error) {
+	var tpl miniapp_model.SubscribeTemplate
+	err := db.Dao.Where("event = ? AND status = ?", event, 1).First(&tpl).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, ErrSubscribeTemplateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询模板失败: %v", err)
+	}
+	return &tpl, nil
+}
+
+// ResolvePreviewTemplate 预览时传入模板ID使用已保存的模板，否则使用请求中未保存的配置
+func (s *SubscribeTemplateService) ResolvePreviewTemplate(req *inout.PreviewSubscribeTemplateReq) (*miniapp_model.SubscribeTemplate, error) {
+	if req.ID > 0 {
+		return s.GetTemplate(req.ID)
+	}
+
+	fields, _ := json.Marshal(req.Fields)
+	return &miniapp_model.SubscribeTemplate{
+		Event:  req.Event,
+		Page:   req.Page,
+		Fields: string(fields),
+	}, nil
+}
+
+// Preview 使用事件数据渲染模板，不发送
+func (s *SubscribeTemplateService) Preview(tpl *miniapp_model.SubscribeTemplate, payload map[string]interface{}) (*inout.PreviewSubscribeTemplateResp, error) {
+	if err := validateSubscribeTemplate(tpl.Event, tpl.Page, parseSubscribeFields(tpl.Fields)); err != nil {
+		return nil, err
+	}
+	if payload == nil {
+		payload = SampleEventPayload(tpl.Event)
+	}
+
+	page, data, missing := RenderSubscribeTemplate(tpl, payload)
+	return &inout.PreviewSubscribeTemplateResp{
+		Event:      tpl.Event,
+		TemplateID: tpl.TemplateID,
+		Page:       page,
+		Data:       data,
+		Missing:    missing,
+	}, nil
+}
+
+// SendEvent 按业务事件发送订阅消息：匹配事件模板，校验用户订阅后用事件数据渲染关键词和落地页
+func (s *SubscribeTemplateService) SendEvent(openID, event string, payload map[string]interface{}) (*inout.PreviewSubscribeTemplateResp, error) {
+	tpl, err := s.GetEventTemplate(event)
+	if err != nil {
+		return nil, err
+	}
+	if !IsUserSubscribed(openID, tpl.TemplateID) {
+		return nil, ErrUserNotSubscribed
+	}
+
+	page, data, missing := RenderSubscribeTemplate(tpl, payload)
+	if len(missing) > 0 {
+		log.Printf("订阅消息存在空字段 事件:%s 字段:%v", event, missing)
+	}
+
+	if err := SendSubscribeMsgWithData(openID, tpl.TemplateID, page, data); err != nil {
+		return nil, err
+	}
+
+	return &inout.PreviewSubscribeTemplateResp{
+		Event:      event,
+		TemplateID: tpl.TemplateID,
+		Page:       page,
+		Data:       data,
+		Missing:    missing,
+	}, nil
+}
+
+// convertToItem 转换模板为响应格式
+func (s *SubscribeTemplateService) convertToItem(tpl *miniapp_model.SubscribeTemplate) *inout.SubscribeTemplateItem {
+	return &inout.SubscribeTemplateItem{
+		ID:         tpl.ID,
+		Event:      tpl.Event,
+		EventName:  subscribeEventNames[tpl.Event],
+		TemplateID: tpl.TemplateID,
+		Title:      tpl.Title,
+		Page:       tpl.Page,
+		Fields:     parseSubscribeFields(tpl.Fields),
+		Status:     tpl.Status,
+		Remark:     tpl.Remark,
+		CreateTime: tpl.CreateTime.Format("2006-01-02 15:04:05"),
+		UpdateTime: tpl.UpdateTime.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// ========== 表达式渲染 ==========
+
+// EventPayload 将业务对象按 json 字段名转换为事件数据，表达式通过 {{对象.字段}} 引用
+func EventPayload(objects map[string]interface{}) map[string]interface{} {
+	payload := make(map[string]interface{}, len(objects))
+	for name, obj := range objects {
+		if obj == nil {
+			continue
+		}
+		raw, err := json.Marshal(obj)
+		if err != nil {
+			log.Printf("转换事件数据失败 (%s): %v", name, err)
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			log.Printf("转换事件数据失败 (%s): %v", name, err)
+			continue
+		}
+		payload[name] = value
+	}
+	return payload
+}
+
+// RenderSubscribeTemplate 渲染模板关键词和落地页，返回取值为空的表达式
+func RenderSubscribeTemplate(tpl *miniapp_model.SubscribeTemplate, payload map[string]interface{}) (string, map[string]map[string]string, []string) {
+	missing := make([]string, 0)
+	page := renderSubscribeExpr(tpl.Page, payload, &missing)
+	if page == "" {
+		page = "pages/index/index"
+	}
+
+	data := make(map[string]map[string]string)
+	for keyword, expr := range parseSubscribeFields(tpl.Fields) {
+		value := renderSubscribeExpr(expr, payload, &missing)
+		data[keyword] = map[string]string{"value": truncateSubscribeValue(keyword, value)}
+	}
+	return page, data, missing
+}
+
+// renderSubscribeExpr 替换文本中的表达式
+func renderSubscribeExpr(text string, payload map[string]interface{}, missing *[]string) string {
+	return subscribeExprPattern.ReplaceAllStringFunc(text, func(expr string) string {
+		m := subscribeExprPattern.FindStringSubmatch(expr)
+		value := lookupSubscribeValue(payload, m[1], strings.Split(m[2][1:], "."))
+		if value == nil {
+			*missing = append(*missing, expr)
+			return ""
+		}
+		if format, ok := subscribeFormatters[m[3]]; ok {
+			return format(value)
+		}
+		return formatSubscribeValue(value)
+	})
+}
+
+// lookupSubscribeValue 按路径取事件数据中的值
+func lookupSubscribeValue(payload map[string]interface{}, root string, path []string) interface{} {
+	var current interface{} = payload[root]
+	for _, key := range path {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = obj[key]
+	}
+	return current
+}
+
+// formatSubscribeValue 值转换为文本，整数不带小数位
+func formatSubscribeValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case float64:
+		if val == float64(int64(val)) {
+			return strconv.FormatInt(int64(val), 10)
+		}
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		if val {
+			return "是"
+		}
+		return "否"
+	case nil:
+		return ""
+	default:
+		raw, _ := json.Marshal(val)
+		return string(raw)
+	}
+}
+
+// formatSubscribeTime 格式化时间，支持 json 序列化后的时间字符串
+func formatSubscribeTime(v interface{}, layout string) string {
+	s, ok := v.(string)
+	if !ok {
+		return formatSubscribeValue(v)
+	}
+	for _, parse := range []string{time.RFC3339Nano, "2006-01-02 15:04:05"} {
+		if t, err := time.ParseInLocation(parse, s, time.Local); err == nil {
+			return t.In(time.Local).Format(layout)
+		}
+	}
+	return s
+}
+
+// truncateSubscribeValue 按关键词类型截断超长内容，避免微信接口拒绝
+func truncateSubscribeValue(keyword, value string) string {
+	m := subscribeKeywordPattern.FindStringSubmatch(keyword)
+	if m == nil {
+		return value
+	}
+	limit, ok := subscribeKeywordLimits[m[1]]
+	if !ok {
+		return value
+	}
+	runes := []rune(value)
+	if len(runes) > limit {
+		return string(runes[:limit])
+	}
+	return value
+}
+
+// parseSubscribeFields 解析关键词映射JSON
+func parseSubscribeFields(raw string) map[string]string {
+	fields := make(map[string]string)
+	if raw == "" {
+		return fields
+	}
+	if err := json.Unmarshal([]byte(raw), &fields); err != nil {
+		log.Printf("解析模板关键词映射失败: %v", err)
+	}
+	return fields
+}
+
+// validateSubscribeTemplate 校验业务事件、关键词和表达式引用的数据对象
+func validateSubscribeTemplate(event, page string, fields map[string]string) error {
+	scopes, ok := miniapp_model.SubscribeEventScopes[event]
+	if !ok {
+		return fmt.Errorf("不支持的业务事件: %s", event)
+	}
+	if len(fields) == 0 {
+		return fmt.Errorf("关键词映射不能为空")
+	}
+
+	allowed := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		allowed[scope] = true
+	}
+
+	check := func(text string) error {
+		for _, m := range subscribeExprPattern.FindAllStringSubmatch(text, -1) {
+			if !allowed[m[1]] {
+				return fmt.Errorf("表达式 %s 引用的对象 %s 不属于事件 %s，可用对象: %s",
+					m[0], m[1], event, strings.Join(scopes, ","))
+			}
+			if m[3] != "" {
+				if _, ok := subscribeFormatters[m[3]]; !ok {
+					return fmt.Errorf("表达式 %s 使用了不支持的格式 %s", m[0], m[3])
+				}
+			}
+		}
+		// 未被识别的表达式大多是拼写错误
+		rest := subscribeExprPattern.ReplaceAllString(text, "")
+		if strings.Contains(rest, "{{") || strings.Contains(rest, "}}") {
+			return fmt.Errorf("表达式格式错误: %s", text)
+		}
+		return nil
+	}
+
+	for keyword, expr := range fields {
+		if !subscribeKeywordPattern.MatchString(keyword) {
+			return fmt.Errorf("关键词格式错误: %s", keyword)
+		}
+		if err := check(expr); err != nil {
+			return err
+		}
+	}
+	return check(page)
+}
+
+// SampleEventPayload 业务事件的示例数据，用于预览
+func SampleEventPayload(event string) map[string]interface{} {
+	now := time.Now()
+	start := now.Add(30 * time.Minute).Truncate(time.Hour).Add(time.Hour)
+	samples := map[string]interface{}{
+		"order": app_model.AppOrder{
+			Id: 1001, UserId: 1, Amount: 99, Num: 1, No: "OD" + now.Format("20060102150405") + "0001",
+			GoodsId: 1, Status: "paid", CreateTime: now, UpdateTime: now,
+		},
+		"goods": app_model.AppGoods{Id: 1, GoodsName: "示例商品", Price: 99, CreateTime: now},
+		"refund": app_model.OrderRefund{
+			Id: 1, UserId: 1, Amount: 99, No: "OD" + now.Format("20060102150405") + "0001", OrderId: 1001,
+			Reason: "不想要了", RejectReason: "商品已使用", CreateTime: now, UpdateTime: now,
+		},
+		"booking": app_model.RoomBooking{
+			ID: 2001, RoomID: 1, UserID: 1, BookingNo: "BK" + now.Format("20060102150405") + "000001",
+			StartTime: start, EndTime: start.Add(3 * time.Hour), Hours: 3,
+			TotalAmount: 264, PaidAmount: 264, Status: app_model.BookingStatusPaid, CreateTime: now,
+		},
+		"room":   app_model.Room{ID: 1, RoomNumber: "A01", RoomName: "豪华包厢A01", HourlyRate: 88},
+		"usage":  app_model.RoomUsageLog{BookingID: 2001, CheckInAt: start, ActualHours: 3, FeeStatus: app_model.UsageFeeStatusNone},
+		"notice": map[string]interface{}{"tip": "请准时到店，出示入场码核验", "pay_deadline": now.Add(time.Hour), "verify_code": "123456", "can_extend": true, "from_room_name": "豪华包厢A02", "reason": "房间维护（空调维修）", "refund_amount": 264.0},
+	}
+
+	objects := make(map[string]interface{})
+	for _, scope := range miniapp_model.SubscribeEventScopes[event] {
+		objects[scope] = samples[scope]
+	}
+	return EventPayload(objects)
+}
@@ -9,6 +9,7 @@ import (
 	"nasa-go-admin/middleware"
 	"nasa-go-admin/model/admin_model"
 	"nasa-go-admin/pkg/websocket"
+	"nasa-go-admin/redis"
 	"nasa-go-admin/services/admin_service"
 	"runtime"
 	"sync"
@@ -23,12 +24,23 @@ type NotificationType string
 
 const (
 	// 订单相关通知
-	OrderCreated   NotificationType = "order_created"
-	OrderPaid      NotificationType = "order_paid"
-	OrderCancelled NotificationType = "order_cancelled"
-	OrderRefunded  NotificationType = "order_refunded"
-	OrderShipped   NotificationType = "order_shipped"
-	OrderDelivered NotificationType = "order_delivered"
+	OrderCreated        NotificationType = "order_created"
+	OrderPaid           NotificationType = "order_paid"
+	OrderCancelled      NotificationType = "order_cancelled"
+	OrderRefunded       NotificationType = "order_refunded"
+	OrderRefundApplied  NotificationType = "order_refund_applied"
+	OrderRefundRejected NotificationType = "order_refund_rejected"
+	OrderShipped        NotificationType = "order_shipped"
+	OrderDelivered      NotificationType = "order_delivered"
+
+	// 预订相关通知
+	BookingPaymentPending NotificationType = "booking_payment_pending"
+	BookingStartSoon      NotificationType = "booking_start_soon"
+	BookingEndingSoon     NotificationType = "booking_ending_soon"
+	BookingCompleted      NotificationType = "booking_completed"
+	BookingWaitlistOffer  NotificationType = "booking_waitlist_offer"
+	BookingRebooked       NotificationType = "booking_rebooked"
+	BookingVenueCancelled NotificationType = "booking_venue_cancelled"
 
 	// 用户相关通知
 	UserRegistered NotificationType = "user_registered"
@@ -210,6 +222,12 @@ func (s *WebSocketService) InitHub() *websocket.Hub {
 			s.UnregisterUserConnection(userID, connectionID)
 		})
 
+		// 多实例部署时通过Redis在节点间分发消息和同步在线状态
+		if redisClient := redis.GetClient(); redisClient != nil {
+			s.hub.SetRemoteDeliveryHandler(s.handleRemoteDelivery)
+			s.hub.EnableBackplane(redisClient)
+		}
+
 		go s.hub.Run()
 		s.startWorkers()           // 启动工作线程池
 		go s.startStatsCollector() // 启动统计收集器
@@ -352,6 +370,16 @@ func (s *WebSocketService) sendMessageToUser(userID int, msgBytes []byte, messag
 	hub := s.GetHub()
 	clients := hub.GetUserClients(userID)
 
+	if len(clients) == 0 && hub.BackplaneEnabled() && hub.IsUserOnline(userID) {
+		// 用户连接在其他节点，由所在节点投递并更新投递状态
+		err := hub.PublishToUser(userID, message.MessageID, msgBytes)
+		if err == nil {
+			log.Printf("消息已转发至用户 %d 所在节点: MessageID=%s", userID, message.MessageID)
+			return true
+		}
+		log.Printf("消息跨节点转发失败，保存为离线消息: UserID=%d, MessageID=%s, Error=%v", userID, message.MessageID, err)
+	}
+
 	if len(clients) == 0 {
 		// 用户不在线，保存为离线消息
 		log.Printf("用户 %d 不在线，保存为离线消息: MessageID=%s", userID, message.MessageID)
@@ -393,6 +421,31 @@ func (s *WebSocketService) sendMessageToUser(userID int, msgBytes []byte, messag
 	return success
 }
 
+// handleRemoteDelivery 处理其他节点转发来的消息投递结果
+func (s *WebSocketService) handleRemoteDelivery(userID int, messageID string, payload []byte, delivered bool) {
+	if messageID == "" {
+		return
+	}
+
+	if delivered {
+		go func() {
+			time.Sleep(100 * time.Millisecond) // 等待100ms确保记录创建完成
+			s.markMessageAsDelivered(messageID, userID)
+		}()
+		return
+	}
+
+	// 用户在本节点的连接均已失效，保存为离线消息
+	var message NotificationMessage
+	if err := json.Unmarshal(payload, &message); err != nil {
+		log.Printf("解析跨节点消息失败: UserID=%d, MessageID=%s, Error=%v", userID, messageID, err)
+		return
+	}
+	if err := s.offlineService.SaveOfflineMessage(userID, &message); err != nil {
+		log.Printf("保存离线消息失败: UserID=%d, MessageID=%s, Error=%v", userID, messageID, err)
+	}
+}
+
 // startStatsCollector 启动统计数据收集器
 func (s *WebSocketService) startStatsCollector() {
 	statsTicker := time.NewTicker(100 * time.Second)
@@ -430,9 +483,9 @@ func (s *WebSocketService) startStatsCollector() {
 func (s *WebSocketService) cleanupExpiredOnlineStatus() {
 	recordService := admin_service.NewNotificationRecordService()
 
-	// 获取当前Hub中的在线用户
+	// 获取集群中的在线用户，避免误判连接在其他节点的用户
 	hub := s.GetHub()
-	hubOnlineUsers := hub.GetOnlineUserIDs()
+	hubOnlineUsers := hub.GetClusterOnlineUserIDs()
 	hubUserMap := make(map[int]bool)
 	for _, userID := range hubOnlineUsers {
 		hubUserMap[userID] = true
@@ -773,6 +826,14 @@ func (s *WebSocketService) SendOrderNotification(userID int, orderNo, status, go
 		msgType = OrderRefunded
 		content = "订单已退款"
 		adminContent = "订单退款通知"
+	case "refund_applied":
+		msgType = OrderRefundApplied
+		content = "退款申请已提交，等待商家审核"
+		adminContent = "新退款申请待审核"
+	case "refund_rejected":
+		msgType = OrderRefundRejected
+		content = "退款申请已被拒绝"
+		adminContent = "退款申请已拒绝"
 	default:
 		msgType = OrderCreated
 		content = "订单创建成功"
@@ -1063,6 +1124,7 @@ func (s *WebSocketService) GetMetrics() map[string]interface{} {
 		"last_error":         s.lastError,
 		"last_error_time":    s.lastErrorTime,
 		"hub_stats":          hubStats,
+		"cluster_stats":      hub.GetClusterStats(),
 	}
 }
 
@@ -1088,5 +1150,8 @@ func (s *WebSocketService) Close() {
 	if s.cancelWorkers != nil {
 		s.cancelWorkers()
 	}
+	if s.hub != nil {
+		s.hub.StopBackplane()
+	}
 	log.Println("WebSocket服务已关闭")
 }